@@ -2,6 +2,7 @@
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
@@ -9,16 +10,37 @@
 type (
 	// Config -.
 	Config struct {
-		App     App
-		HTTP    HTTP
-		Log     Log
-		PG      PG
-		GRPC    GRPC
-		RMQ     RMQ
-		Outbox  Outbox
-		NATS    NATS
-		Metrics Metrics
-		Swagger Swagger
+		App             App
+		HTTP            HTTP
+		Log             Log
+		PG              PG
+		GRPC            GRPC
+		RMQ             RMQ
+		Outbox          Outbox
+		NATS            NATS
+		Metrics         Metrics
+		Swagger         Swagger
+		JWT             JWT
+		Auth            Auth
+		Encryption      Encryption
+		Frontend        Frontend
+		SMTP            SMTP
+		SMS             SMS
+		Email           Email
+		Password        Password
+		MFA             MFA
+		OAuth           OAuth
+		WebAuthn        WebAuthn
+		MagicLink       MagicLink
+		AccountRecovery AccountRecovery
+		ContactChange   ContactChange
+		AccountDeletion AccountDeletion
+		DataExport      DataExport
+		Storage         Storage
+		GeoIP           GeoIP
+		LoginRisk       LoginRisk
+		Availability    Availability
+		Avatar          Avatar
 	}
 
 	// App -.
@@ -80,6 +102,244 @@
 	Swagger struct {
 		Enabled bool `env:"SWAGGER_ENABLED" envDefault:"false"`
 	}
+
+	// JWT -.
+	JWT struct {
+		Issuer              string        `env:"JWT_ISSUER" envDefault:"thiam-api"`
+		Algorithm           string        `env:"JWT_ALGORITHM" envDefault:"HS256"`
+		KeyRotationInterval time.Duration `env:"JWT_KEY_ROTATION_INTERVAL" envDefault:"720h"`
+		KeyReloadInterval   time.Duration `env:"JWT_KEY_RELOAD_INTERVAL" envDefault:"1m"`
+	}
+
+	// Auth -. Password requirements, token lifetimes, rate limits and the
+	// recovery code format. The auth usecases enforce them and serve them at
+	// /v1/auth/config, so the frontend never has to hard-code them. The
+	// variables keep the names of the sections they belong to.
+	Auth struct {
+		PasswordMinLength        int  `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+		PasswordMaxLength        int  `env:"PASSWORD_MAX_LENGTH" envDefault:"128"`
+		PasswordRequireUppercase bool `env:"PASSWORD_REQUIRE_UPPERCASE" envDefault:"true"`
+		PasswordRequireLowercase bool `env:"PASSWORD_REQUIRE_LOWERCASE" envDefault:"true"`
+		PasswordRequireNumber    bool `env:"PASSWORD_REQUIRE_NUMBER" envDefault:"true"`
+		PasswordRequireSpecial   bool `env:"PASSWORD_REQUIRE_SPECIAL" envDefault:"false"`
+
+		AccessTokenTTL       time.Duration `env:"JWT_ACCESS_TTL" envDefault:"15m"`
+		RefreshTokenTTL      time.Duration `env:"JWT_REFRESH_TTL" envDefault:"24h"`
+		RememberMeTTL        time.Duration `env:"JWT_REMEMBER_ME_TTL" envDefault:"720h"`
+		PasswordResetTTL     time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+		EmailVerificationTTL time.Duration `env:"EMAIL_VERIFICATION_TTL" envDefault:"24h"`
+		// MFACodeTTL is the lifetime of a texted one-time code.
+		MFACodeTTL         time.Duration `env:"MFA_CODE_TTL" envDefault:"5m"`
+		RecoverySessionTTL time.Duration `env:"ACCOUNT_RECOVERY_TTL" envDefault:"15m"`
+
+		// LoginMaxAttempts wrong passwords within LoginAttemptWindow lock an
+		// account for LoginLockout, doubling with each lockout in a row up to
+		// LoginMaxLockout.
+		LoginMaxAttempts        int           `env:"LOCKOUT_MAX_ATTEMPTS" envDefault:"5"`
+		LoginAttemptWindow      time.Duration `env:"LOCKOUT_WINDOW" envDefault:"15m"`
+		LoginLockout            time.Duration `env:"LOCKOUT_DURATION" envDefault:"15m"`
+		LoginMaxLockout         time.Duration `env:"LOCKOUT_MAX_DURATION" envDefault:"24h"`
+		PasswordResetMaxPerHour int           `env:"PASSWORD_RESET_MAX_PER_HOUR" envDefault:"3"`
+		MFAMaxAttempts          int           `env:"MFA_MAX_ATTEMPTS" envDefault:"5"`
+		MFAAttemptWindow        time.Duration `env:"MFA_ATTEMPT_WINDOW" envDefault:"5m"`
+
+		// RecoveryCodeCount codes are issued per set; every X in RecoveryCodeFormat
+		// becomes a random character.
+		RecoveryCodeCount  int    `env:"MFA_RECOVERY_CODE_COUNT" envDefault:"10"`
+		RecoveryCodeFormat string `env:"MFA_RECOVERY_CODE_FORMAT" envDefault:"XXXX-XXXX"`
+	}
+
+	// Encryption -.
+	Encryption struct {
+		// Key is a base64-encoded 32-byte AES key for secrets stored in the database.
+		Key string `env:"ENCRYPTION_KEY,required"`
+	}
+
+	// Frontend -.
+	Frontend struct {
+		// URL is the base that links in emails point to.
+		URL string `env:"FRONTEND_URL" envDefault:"http://localhost:3000"`
+	}
+
+	// SMTP -. Email delivery is disabled when Host is empty.
+	SMTP struct {
+		Host     string `env:"SMTP_HOST"`
+		Port     int    `env:"SMTP_PORT" envDefault:"465"`
+		Username string `env:"SMTP_USERNAME"`
+		Password string `env:"SMTP_PASSWORD"`
+		From     string `env:"SMTP_FROM" envDefault:"no-reply@localhost"`
+		UseTLS   bool   `env:"SMTP_USE_TLS" envDefault:"true"`
+	}
+
+	// SMS -. SMS delivery is disabled when Provider is empty.
+	SMS struct {
+		// Provider selects the adapter; "twilio" is the only one so far.
+		Provider string `env:"SMS_PROVIDER"`
+		// BaseURL overrides the provider API host, e.g. to point at a local stub.
+		BaseURL    string `env:"SMS_BASE_URL"`
+		AccountSID string `env:"SMS_ACCOUNT_SID"`
+		AuthToken  string `env:"SMS_AUTH_TOKEN"`
+		From       string `env:"SMS_FROM"`
+	}
+
+	// Email -.
+	Email struct {
+		VerificationResendCooldown time.Duration `env:"EMAIL_VERIFICATION_RESEND_COOLDOWN" envDefault:"1m"`
+		VerificationMaxPerHour     int           `env:"EMAIL_VERIFICATION_MAX_PER_HOUR" envDefault:"5"`
+	}
+
+	// Password -.
+	Password struct {
+		// BlocklistFile lists breached or common passwords, one per line.
+		BlocklistFile string `env:"PASSWORD_BLOCKLIST_FILE"`
+	}
+
+	// MFA -.
+	MFA struct {
+		// TOTPIssuer is the account label shown in authenticator apps.
+		TOTPIssuer string `env:"MFA_TOTP_ISSUER" envDefault:"Thiam"`
+		TOTPSkew   int    `env:"MFA_TOTP_SKEW" envDefault:"1"`
+		// ChallengeTTL bounds how long a login may wait at the MFA step.
+		ChallengeTTL       time.Duration `env:"MFA_CHALLENGE_TTL" envDefault:"5m"`
+		CodeResendCooldown time.Duration `env:"MFA_CODE_RESEND_COOLDOWN" envDefault:"1m"`
+	}
+
+	// OAuth -. A provider is enabled when its client ID is set.
+	OAuth struct {
+		StateTTL time.Duration `env:"OAUTH_STATE_TTL" envDefault:"10m"`
+		// RedirectURLs are the frontend pages providers may send users back to,
+		// comma-separated; the first is the default. {provider} is replaced with
+		// the provider name.
+		RedirectURLs []string      `env:"OAUTH_REDIRECT_URLS" envDefault:"http://localhost:3000/auth/oauth/{provider}/callback"`
+		Google       OAuthProvider `envPrefix:"OAUTH_GOOGLE_"`
+		Apple        OAuthProvider `envPrefix:"OAUTH_APPLE_"`
+		Facebook     OAuthProvider `envPrefix:"OAUTH_FACEBOOK_"`
+		GitHub       OAuthProvider `envPrefix:"OAUTH_GITHUB_"`
+	}
+
+	// OAuthProvider -. Empty URLs use the provider's public endpoints.
+	OAuthProvider struct {
+		ClientID string `env:"CLIENT_ID"`
+		// ClientSecret is, for Apple, the signed client secret JWT.
+		ClientSecret string `env:"CLIENT_SECRET"`
+		AuthURL      string `env:"AUTH_URL"`
+		TokenURL     string `env:"TOKEN_URL"`
+		UserInfoURL  string `env:"USERINFO_URL"`
+	}
+
+	// WebAuthn -. Passkeys are bound to RPID, the site's registrable domain,
+	// and only accepted from Origins, comma-separated.
+	WebAuthn struct {
+		RPID    string        `env:"WEBAUTHN_RP_ID" envDefault:"localhost"`
+		RPName  string        `env:"WEBAUTHN_RP_NAME" envDefault:"Thiam"`
+		Origins []string      `env:"WEBAUTHN_ORIGINS" envDefault:"http://localhost:3000"`
+		Timeout time.Duration `env:"WEBAUTHN_TIMEOUT" envDefault:"5m"`
+	}
+
+	// MagicLink -. SignUp lets a link sent to an unknown email create the
+	// account; BindClient only accepts it from the requesting IP and browser.
+	MagicLink struct {
+		TTL            time.Duration `env:"MAGIC_LINK_TTL" envDefault:"15m"`
+		ResendCooldown time.Duration `env:"MAGIC_LINK_RESEND_COOLDOWN" envDefault:"1m"`
+		MaxPerHour     int           `env:"MAGIC_LINK_MAX_PER_HOUR" envDefault:"5"`
+		MaxAttempts    int           `env:"MAGIC_LINK_MAX_ATTEMPTS" envDefault:"5"`
+		SignUp         bool          `env:"MAGIC_LINK_SIGN_UP" envDefault:"false"`
+		BindClient     bool          `env:"MAGIC_LINK_BIND_CLIENT" envDefault:"false"`
+	}
+
+	// GeoIP -. DatabasePath points at a MaxMind DB file such as GeoLite2-City;
+	// when empty, security events are recorded without a location.
+	GeoIP struct {
+		DatabasePath string `env:"GEOIP_DATABASE_PATH"`
+	}
+
+	// AccountRecovery -. A verified recovery can only reset MFA after
+	// CoolingOff, and its token then works for TokenTTL.
+	AccountRecovery struct {
+		ResendCooldown time.Duration `env:"ACCOUNT_RECOVERY_RESEND_COOLDOWN" envDefault:"1m"`
+		MaxPerHour     int           `env:"ACCOUNT_RECOVERY_MAX_PER_HOUR" envDefault:"3"`
+		MaxAttempts    int           `env:"ACCOUNT_RECOVERY_MAX_ATTEMPTS" envDefault:"5"`
+		CoolingOff     time.Duration `env:"ACCOUNT_RECOVERY_COOLING_OFF" envDefault:"24h"`
+		TokenTTL       time.Duration `env:"ACCOUNT_RECOVERY_TOKEN_TTL" envDefault:"48h"`
+	}
+
+	// ContactChange -. A new email is confirmed by a link valid for EmailTTL,
+	// after which the old address can change it back for RevertTTL; a new
+	// phone number is confirmed by a code valid for CodeTTL.
+	ContactChange struct {
+		EmailTTL       time.Duration `env:"CONTACT_CHANGE_EMAIL_TTL" envDefault:"24h"`
+		RevertTTL      time.Duration `env:"CONTACT_CHANGE_REVERT_TTL" envDefault:"168h"`
+		CodeTTL        time.Duration `env:"CONTACT_CHANGE_CODE_TTL" envDefault:"10m"`
+		MaxAttempts    int           `env:"CONTACT_CHANGE_MAX_ATTEMPTS" envDefault:"5"`
+		ResendCooldown time.Duration `env:"CONTACT_CHANGE_RESEND_COOLDOWN" envDefault:"1m"`
+		MaxPerHour     int           `env:"CONTACT_CHANGE_MAX_PER_HOUR" envDefault:"5"`
+	}
+
+	// AccountDeletion -. A deletion is confirmed by a link valid for TokenTTL;
+	// the account can be restored by signing in for GracePeriod, after which
+	// it is purged by a job that runs every PurgeInterval.
+	AccountDeletion struct {
+		TokenTTL       time.Duration `env:"ACCOUNT_DELETION_TOKEN_TTL" envDefault:"1h"`
+		GracePeriod    time.Duration `env:"ACCOUNT_DELETION_GRACE_PERIOD" envDefault:"720h"`
+		PurgeInterval  time.Duration `env:"ACCOUNT_DELETION_PURGE_INTERVAL" envDefault:"1h"`
+		PurgeBatchSize uint64        `env:"ACCOUNT_DELETION_PURGE_BATCH_SIZE" envDefault:"100"`
+	}
+
+	// DataExport -. A finished export can be downloaded for LinkTTL; a job
+	// that runs every Interval builds BatchSize exports at a time and starts
+	// over on any that took longer than Timeout.
+	DataExport struct {
+		LinkTTL   time.Duration `env:"DATA_EXPORT_LINK_TTL" envDefault:"72h"`
+		Interval  time.Duration `env:"DATA_EXPORT_INTERVAL" envDefault:"1m"`
+		BatchSize uint64        `env:"DATA_EXPORT_BATCH_SIZE" envDefault:"5"`
+		Timeout   time.Duration `env:"DATA_EXPORT_TIMEOUT" envDefault:"30m"`
+	}
+
+	// Storage -. Driver is "local", keeping files under LocalDir, or "s3" for
+	// any S3-compatible service.
+	Storage struct {
+		Driver   string `env:"STORAGE_DRIVER" envDefault:"local"`
+		LocalDir string `env:"STORAGE_LOCAL_DIR" envDefault:"data"`
+		// S3Endpoint is the service URL, e.g. https://s3.eu-west-1.amazonaws.com.
+		S3Endpoint        string `env:"STORAGE_S3_ENDPOINT"`
+		S3Region          string `env:"STORAGE_S3_REGION" envDefault:"us-east-1"`
+		S3Bucket          string `env:"STORAGE_S3_BUCKET"`
+		S3AccessKeyID     string `env:"STORAGE_S3_ACCESS_KEY_ID"`
+		S3SecretAccessKey string `env:"STORAGE_S3_SECRET_ACCESS_KEY"`
+		// S3PathStyle puts the bucket in the URL path, as MinIO expects.
+		S3PathStyle bool `env:"STORAGE_S3_PATH_STYLE" envDefault:"false"`
+	}
+
+	// LoginRisk -. Sign-ins are compared with HistoryWindow of past ones;
+	// Alerts tells users about new devices and countries, and StepUp emails
+	// a code to confirm high-risk logins of users without MFA.
+	LoginRisk struct {
+		HistoryWindow time.Duration `env:"LOGIN_RISK_HISTORY_WINDOW" envDefault:"2160h"`
+		Alerts        bool          `env:"LOGIN_RISK_ALERTS" envDefault:"true"`
+		StepUp        bool          `env:"LOGIN_RISK_STEP_UP" envDefault:"false"`
+	}
+
+	// Availability -. Each IP address can check MaxPerWindow emails and phone
+	// numbers within Window, and every answer takes at least MinDuration so
+	// registered and free values can't be told apart by timing.
+	Availability struct {
+		MaxPerWindow int           `env:"AVAILABILITY_MAX_PER_WINDOW" envDefault:"10"`
+		Window       time.Duration `env:"AVAILABILITY_WINDOW" envDefault:"10m"`
+		MinDuration  time.Duration `env:"AVAILABILITY_MIN_DURATION" envDefault:"300ms"`
+		// DisposableDomains can't be registered with, comma-separated;
+		// subdomains are blocked too.
+		DisposableDomains []string `env:"AVAILABILITY_DISPOSABLE_DOMAINS" envDefault:"10minutemail.com,guerrillamail.com,mailinator.com,sharklasers.com,temp-mail.org,throwawaymail.com,trashmail.com,yopmail.com"`
+	}
+
+	// Avatar -. Uploads of up to MaxBytes and MaxSide pixels a side are
+	// cropped square and stored at each of Sizes. BaseURL is the public URL of
+	// the API's /v1/avatars route.
+	Avatar struct {
+		BaseURL  string `env:"AVATAR_BASE_URL" envDefault:"http://localhost:8080/v1/avatars"`
+		MaxBytes int64  `env:"AVATAR_MAX_BYTES" envDefault:"2097152"`
+		MaxSide  int    `env:"AVATAR_MAX_SIDE" envDefault:"4096"`
+		Sizes    []int  `env:"AVATAR_SIZES" envDefault:"64,128,256"`
+	}
 )
 
 // NewConfig returns app config.
@@ -11,17 +11,28 @@
 
 	"github.com/evrone/go-clean-template/config"
 	amqprpc "github.com/evrone/go-clean-template/internal/controller/amqp_rpc"
+	"github.com/evrone/go-clean-template/internal/controller/authn"
 	"github.com/evrone/go-clean-template/internal/controller/grpc"
 	"github.com/evrone/go-clean-template/internal/controller/http"
 	natsrpc "github.com/evrone/go-clean-template/internal/controller/nats_rpc"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
 	"github.com/evrone/go-clean-template/internal/repo/persistent"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	notificationuc "github.com/evrone/go-clean-template/internal/usecase/notification"
+	"github.com/evrone/go-clean-template/pkg/encryption"
 	"github.com/evrone/go-clean-template/pkg/eventbus"
+	"github.com/evrone/go-clean-template/pkg/geoip"
 	"github.com/evrone/go-clean-template/pkg/grpcserver"
 	"github.com/evrone/go-clean-template/pkg/httpserver"
 	"github.com/evrone/go-clean-template/pkg/logger"
 	natsRPCServer "github.com/evrone/go-clean-template/pkg/nats/nats_rpc/server"
+	"github.com/evrone/go-clean-template/pkg/notify"
+	"github.com/evrone/go-clean-template/pkg/oauth"
+	"github.com/evrone/go-clean-template/pkg/password"
 	"github.com/evrone/go-clean-template/pkg/postgres"
 	rmqRPCServer "github.com/evrone/go-clean-template/pkg/rabbitmq/rmq_rpc/server"
+	"github.com/evrone/go-clean-template/pkg/storage"
+	"github.com/evrone/go-clean-template/pkg/webauthn"
 )
 
 // Run creates objects via constructors.
@@ -37,6 +48,257 @@ func Run(cfg *config.Config) { //nolint: funlen,gocritic,nolintlint,gocognit,goc
 
 	// Repositories
 	outboxRepo := persistent.NewOutboxRepo(pg)
+	userRepo := persistent.NewUserRepo(pg)
+	refreshTokenRepo := persistent.NewRefreshTokenRepo(pg)
+	signingKeyRepo := persistent.NewSigningKeyRepo(pg)
+	securityEventRepo := persistent.NewSecurityEventRepo(pg)
+	emailVerificationRepo := persistent.NewEmailVerificationRepo(pg)
+	passwordResetRepo := persistent.NewPasswordResetRepo(pg)
+	totpRepo := persistent.NewTOTPRepo(pg)
+	smsFactorRepo := persistent.NewSMSFactorRepo(pg)
+	recoveryCodeRepo := persistent.NewRecoveryCodeRepo(pg)
+	mfaChallengeRepo := persistent.NewMFAChallengeRepo(pg)
+	oauthConnectionRepo := persistent.NewOAuthConnectionRepo(pg)
+	passkeyRepo := persistent.NewPasskeyRepo(pg)
+	webauthnChallengeRepo := persistent.NewWebAuthnChallengeRepo(pg)
+	magicLinkRepo := persistent.NewMagicLinkRepo(pg)
+	accountRecoveryRepo := persistent.NewAccountRecoveryRepo(pg)
+	emailChangeRepo := persistent.NewEmailChangeRepo(pg)
+	phoneChangeRepo := persistent.NewPhoneChangeRepo(pg)
+	accountDeletionRepo := persistent.NewAccountDeletionRepo(pg)
+	dataExportRepo := persistent.NewDataExportRepo(pg)
+	availabilityCheckRepo := persistent.NewAvailabilityCheckRepo(pg)
+
+	secretCipher, err := encryption.NewAESGCMFromBase64(cfg.Encryption.Key)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - encryption.NewAESGCMFromBase64: %w", err))
+	}
+
+	var blocklist []string
+	if cfg.Password.BlocklistFile != "" {
+		blocklist, err = password.LoadBlocklist(cfg.Password.BlocklistFile)
+		if err != nil {
+			l.Fatal(fmt.Errorf("app - Run - password.LoadBlocklist: %w", err))
+		}
+	}
+
+	var fileStorage storage.Storage
+
+	switch cfg.Storage.Driver {
+	case "local":
+		fileStorage, err = storage.NewLocal(cfg.Storage.LocalDir)
+	case "s3":
+		fileStorage, err = storage.NewS3(storage.S3Config{
+			Endpoint:        cfg.Storage.S3Endpoint,
+			Region:          cfg.Storage.S3Region,
+			Bucket:          cfg.Storage.S3Bucket,
+			AccessKeyID:     cfg.Storage.S3AccessKeyID,
+			SecretAccessKey: cfg.Storage.S3SecretAccessKey,
+			PathStyle:       cfg.Storage.S3PathStyle,
+		})
+	default:
+		err = fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - storage: %w", err))
+	}
+
+	var geo authuc.GeoLocator
+	if cfg.GeoIP.DatabasePath != "" {
+		geo, err = geoip.Open(cfg.GeoIP.DatabasePath)
+		if err != nil {
+			l.Fatal(fmt.Errorf("app - Run - geoip.Open: %w", err))
+		}
+	}
+
+	passwordPolicy := password.NewPolicy(password.Requirements{
+		MinLength:        cfg.Auth.PasswordMinLength,
+		MaxLength:        cfg.Auth.PasswordMaxLength,
+		RequireUppercase: cfg.Auth.PasswordRequireUppercase,
+		RequireLowercase: cfg.Auth.PasswordRequireLowercase,
+		RequireNumber:    cfg.Auth.PasswordRequireNumber,
+		RequireSpecial:   cfg.Auth.PasswordRequireSpecial,
+	}, blocklist...)
+
+	var emailSender notify.EmailSender
+	if cfg.SMTP.Host != "" {
+		emailSender = notify.NewSMTPSender(&notify.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			UseTLS:   cfg.SMTP.UseTLS,
+		})
+	}
+
+	var smsSender notify.SMSSender
+
+	switch cfg.SMS.Provider {
+	case "":
+	case "twilio":
+		smsSender = notify.NewTwilioSender(notify.TwilioConfig{
+			BaseURL:    cfg.SMS.BaseURL,
+			AccountSID: cfg.SMS.AccountSID,
+			AuthToken:  cfg.SMS.AuthToken,
+			From:       cfg.SMS.From,
+		})
+	default:
+		l.Fatal(fmt.Errorf("app - Run - unknown SMS provider %q", cfg.SMS.Provider))
+	}
+
+	relyingParty, err := webauthn.New(webauthn.Config{
+		RPID:    cfg.WebAuthn.RPID,
+		Origins: cfg.WebAuthn.Origins,
+	})
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - webauthn.New: %w", err))
+	}
+
+	oauthClients := make(map[auth.OAuthProvider]authuc.OAuthClient)
+
+	for provider, pc := range map[auth.OAuthProvider]config.OAuthProvider{
+		auth.OAuthGoogle:   cfg.OAuth.Google,
+		auth.OAuthApple:    cfg.OAuth.Apple,
+		auth.OAuthFacebook: cfg.OAuth.Facebook,
+		auth.OAuthGitHub:   cfg.OAuth.GitHub,
+	} {
+		if pc.ClientID == "" {
+			continue
+		}
+
+		client, err := oauth.New(string(provider), oauth.Config{
+			ClientID:     pc.ClientID,
+			ClientSecret: pc.ClientSecret,
+			AuthURL:      pc.AuthURL,
+			TokenURL:     pc.TokenURL,
+			UserInfoURL:  pc.UserInfoURL,
+		})
+		if err != nil {
+			l.Fatal(fmt.Errorf("app - Run - oauth.New: %w", err))
+		}
+
+		oauthClients[provider] = client
+	}
+
+	// Use cases
+	notificationService := notificationuc.NewService(&notificationuc.ServiceDeps{
+		NotificationRepo: persistent.NewNotificationRepo(pg),
+		PrefsRepo:        persistent.NewNotificationPreferencesRepo(pg),
+		PushTokenRepo:    persistent.NewPushTokenRepo(pg),
+		DeliveryLogRepo:  persistent.NewDeliveryLogRepo(pg),
+		EmailSender:      emailSender,
+		SMSSender:        smsSender,
+	})
+
+	keyRing, err := authuc.NewKeyRing(signingKeyRepo, secretCipher, authuc.KeyRingConfig{
+		Algorithm:        cfg.JWT.Algorithm,
+		RotationInterval: cfg.JWT.KeyRotationInterval,
+		RetireAfter:      authuc.KeyRetirement(cfg.Auth.AccessTokenTTL, cfg.JWT.KeyReloadInterval),
+		ReloadInterval:   cfg.JWT.KeyReloadInterval,
+	})
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - authuc.NewKeyRing: %w", err))
+	}
+
+	tokenService := authuc.NewTokenService(keyRing, cfg.JWT.Issuer, cfg.Auth.AccessTokenTTL)
+	authUseCase := authuc.NewUseCase(&authuc.UseCaseDeps{
+		Users:              userRepo,
+		RefreshTokens:      refreshTokenRepo,
+		Verifications:      emailVerificationRepo,
+		PasswordResets:     passwordResetRepo,
+		TOTP:               totpRepo,
+		SMSFactors:         smsFactorRepo,
+		RecoveryCodes:      recoveryCodeRepo,
+		MFAChallenges:      mfaChallengeRepo,
+		OAuthConnections:   oauthConnectionRepo,
+		OAuth:              oauthClients,
+		Passkeys:           passkeyRepo,
+		WebAuthnChallenges: webauthnChallengeRepo,
+		WebAuthn:           relyingParty,
+		MagicLinks:         magicLinkRepo,
+		Recoveries:         accountRecoveryRepo,
+		EmailChanges:       emailChangeRepo,
+		PhoneChanges:       phoneChangeRepo,
+		Deletions:          accountDeletionRepo,
+		Exports:            dataExportRepo,
+		Storage:            fileStorage,
+		Availability:       availabilityCheckRepo,
+		SecurityEvents:     securityEventRepo,
+		GeoIP:              geo,
+		Hasher:             password.NewArgon2id(),
+		PasswordPolicy:     passwordPolicy,
+		Secrets:            secretCipher,
+		Tokens:             tokenService,
+		Notifier:           notificationService,
+		SMS:                notificationService,
+		Push:               notificationService,
+		Config: authuc.Config{
+			RefreshTokenTTL:            cfg.Auth.RefreshTokenTTL,
+			RememberMeTTL:              cfg.Auth.RememberMeTTL,
+			LoginMaxAttempts:           cfg.Auth.LoginMaxAttempts,
+			LoginAttemptWindow:         cfg.Auth.LoginAttemptWindow,
+			LoginLockout:               cfg.Auth.LoginLockout,
+			LoginMaxLockout:            cfg.Auth.LoginMaxLockout,
+			AppURL:                     cfg.Frontend.URL,
+			EmailVerificationTTL:       cfg.Auth.EmailVerificationTTL,
+			VerificationResendCooldown: cfg.Email.VerificationResendCooldown,
+			VerificationMaxPerHour:     cfg.Email.VerificationMaxPerHour,
+			PasswordResetTTL:           cfg.Auth.PasswordResetTTL,
+			PasswordResetMaxPerHour:    cfg.Auth.PasswordResetMaxPerHour,
+			TOTPIssuer:                 cfg.MFA.TOTPIssuer,
+			TOTPSkew:                   cfg.MFA.TOTPSkew,
+			MFAChallengeTTL:            cfg.MFA.ChallengeTTL,
+			MFAMaxAttempts:             cfg.Auth.MFAMaxAttempts,
+			MFAAttemptWindow:           cfg.Auth.MFAAttemptWindow,
+			MFACodeTTL:                 cfg.Auth.MFACodeTTL,
+			MFACodeResendCooldown:      cfg.MFA.CodeResendCooldown,
+			RecoveryCodeCount:          cfg.Auth.RecoveryCodeCount,
+			RecoveryCodeFormat:         cfg.Auth.RecoveryCodeFormat,
+			OAuthStateTTL:              cfg.OAuth.StateTTL,
+			OAuthRedirectURLs:          cfg.OAuth.RedirectURLs,
+			PasskeyRPName:              cfg.WebAuthn.RPName,
+			PasskeyTimeout:             cfg.WebAuthn.Timeout,
+			MagicLinkTTL:               cfg.MagicLink.TTL,
+			MagicLinkResendCooldown:    cfg.MagicLink.ResendCooldown,
+			MagicLinkMaxPerHour:        cfg.MagicLink.MaxPerHour,
+			MagicLinkMaxAttempts:       cfg.MagicLink.MaxAttempts,
+			MagicLinkSignUp:            cfg.MagicLink.SignUp,
+			MagicLinkBindClient:        cfg.MagicLink.BindClient,
+
+			AccountRecoveryTTL:            cfg.Auth.RecoverySessionTTL,
+			AccountRecoveryResendCooldown: cfg.AccountRecovery.ResendCooldown,
+			AccountRecoveryMaxPerHour:     cfg.AccountRecovery.MaxPerHour,
+			AccountRecoveryMaxAttempts:    cfg.AccountRecovery.MaxAttempts,
+			AccountRecoveryCoolingOff:     cfg.AccountRecovery.CoolingOff,
+			AccountRecoveryTokenTTL:       cfg.AccountRecovery.TokenTTL,
+			EmailChangeTTL:                cfg.ContactChange.EmailTTL,
+			EmailChangeRevertTTL:          cfg.ContactChange.RevertTTL,
+			PhoneChangeCodeTTL:            cfg.ContactChange.CodeTTL,
+			PhoneChangeMaxAttempts:        cfg.ContactChange.MaxAttempts,
+			ContactChangeResendCooldown:   cfg.ContactChange.ResendCooldown,
+			ContactChangeMaxPerHour:       cfg.ContactChange.MaxPerHour,
+			AccountDeletionTTL:            cfg.AccountDeletion.TokenTTL,
+			AccountDeletionGracePeriod:    cfg.AccountDeletion.GracePeriod,
+			DataExportTTL:                 cfg.DataExport.LinkTTL,
+			DataExportTimeout:             cfg.DataExport.Timeout,
+			AvailabilityMaxPerWindow:      cfg.Availability.MaxPerWindow,
+			AvailabilityWindow:            cfg.Availability.Window,
+			AvailabilityMinDuration:       cfg.Availability.MinDuration,
+			DisposableEmailDomains:        cfg.Availability.DisposableDomains,
+			AvatarBaseURL:                 cfg.Avatar.BaseURL,
+			AvatarMaxBytes:                cfg.Avatar.MaxBytes,
+			AvatarMaxSide:                 cfg.Avatar.MaxSide,
+			AvatarSizes:                   cfg.Avatar.Sizes,
+			LoginHistoryWindow:            cfg.LoginRisk.HistoryWindow,
+			LoginAlerts:                   cfg.LoginRisk.Alerts,
+			LoginStepUp:                   cfg.LoginRisk.StepUp,
+		},
+	})
+
+	purgeWorker := authuc.NewPurgeWorker(authUseCase, l, cfg.AccountDeletion.PurgeInterval, cfg.AccountDeletion.PurgeBatchSize)
+	exportWorker := authuc.NewExportWorker(authUseCase, l, cfg.DataExport.Interval, cfg.DataExport.BatchSize)
 
 	// Outbox Worker
 	var (
@@ -78,13 +340,38 @@ func Run(cfg *config.Config) { //nolint: funlen,gocritic,nolintlint,gocognit,goc
 		l.Fatal(fmt.Errorf("app - Run - natsServer - server.New: %w", err))
 	}
 
+	authenticator := authn.New(tokenService)
+
 	// gRPC Server
-	grpcServer := grpcserver.New(l, grpcserver.Port(cfg.GRPC.Port))
+	grpcServer := grpcserver.New(l,
+		grpcserver.Port(cfg.GRPC.Port),
+		grpcserver.UnaryInterceptors(grpc.AuthUnaryInterceptor(authenticator)),
+		grpcserver.StreamInterceptors(grpc.AuthStreamInterceptor(authenticator)),
+	)
 	grpc.NewRouter(grpcServer.App, l)
 
 	// HTTP Server
 	httpServer := httpserver.New(l, httpserver.Port(cfg.HTTP.Port), httpserver.Prefork(cfg.HTTP.UsePreforkMode))
-	http.NewRouter(httpServer.App, cfg, pg, l)
+	http.NewRouter(httpServer.App, cfg, pg, &http.UseCases{
+		Auth:              authUseCase,
+		Sessions:          authUseCase,
+		EmailVerification: authUseCase,
+		Password:          authUseCase,
+		MFA:               authUseCase,
+		RecoveryCodes:     authUseCase,
+		OAuth:             authUseCase,
+		Passkeys:          authUseCase,
+		MagicLink:         authUseCase,
+		AccountRecovery:   authUseCase,
+		ContactChange:     authUseCase,
+		AccountDeletion:   authUseCase,
+		DataExport:        authUseCase,
+		Profile:           authUseCase,
+		Availability:      authUseCase,
+		SecurityEvents:    authUseCase,
+		Settings:          authUseCase,
+		JWKS:              keyRing,
+	}, authenticator, l)
 
 	// Start servers
 	rmqServer.Start()
@@ -100,6 +387,9 @@ func Run(cfg *config.Config) { //nolint: funlen,gocritic,nolintlint,gocognit,goc
 		outboxWorker.Start(ctx)
 	}
 
+	purgeWorker.Start(ctx)
+	exportWorker.Start(ctx)
+
 	// Waiting signal
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
@@ -138,6 +428,9 @@ func Run(cfg *config.Config) { //nolint: funlen,gocritic,nolintlint,gocognit,goc
 		l.Error(fmt.Errorf("app - Run - natsServer.Shutdown: %w", err))
 	}
 
+	purgeWorker.Stop()
+	exportWorker.Stop()
+
 	// Stop outbox worker and close publisher
 	if outboxWorker != nil {
 		outboxWorker.Stop()
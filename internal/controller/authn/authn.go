@@ -0,0 +1,78 @@
+// Package authn authenticates bearer access tokens for every transport.
+package authn
+
+import (
+	"context"
+	"strings"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+)
+
+const (
+	bearerScheme = "bearer"
+
+	codeUnauthorized = "UNAUTHORIZED"
+)
+
+type claimsKey struct{}
+
+// Authenticator validates bearer tokens and carries the resulting identity in the context.
+type Authenticator struct {
+	verifier usecase.TokenVerifier
+}
+
+// New -.
+func New(v usecase.TokenVerifier) *Authenticator {
+	return &Authenticator{verifier: v}
+}
+
+// Authenticate validates the value of an Authorization header and returns a
+// context carrying the token claims. Failures are apperror.Unauthorized.
+func (a *Authenticator) Authenticate(ctx context.Context, authorization string) (context.Context, error) {
+	scheme, raw, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !ok || !strings.EqualFold(scheme, bearerScheme) || strings.TrimSpace(raw) == "" {
+		return ctx, apperror.Unauthorized("Missing or malformed bearer token", apperror.WithCode(codeUnauthorized))
+	}
+
+	claims, err := a.verifier.Verify(ctx, strings.TrimSpace(raw))
+	if err != nil {
+		return ctx, err //nolint:wrapcheck // the verifier already returns apperrors
+	}
+
+	return ContextWithClaims(ctx, claims), nil
+}
+
+// ContextWithClaims adds verified token claims to the context.
+func ContextWithClaims(ctx context.Context, claims *auth.Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated caller.
+func ClaimsFromContext(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*auth.Claims)
+
+	return claims, ok && claims != nil
+}
+
+// UserIDFromContext returns the authenticated user ID.
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	return claims.UserID, true
+}
+
+// SessionIDFromContext returns the session (refresh token family) of the access token.
+func SessionIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	return claims.SessionID, true
+}
@@ -0,0 +1,87 @@
+package authn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evrone/go-clean-template/internal/controller/authn"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVerifier struct {
+	claims *auth.Claims
+}
+
+func (f *fakeVerifier) Verify(_ context.Context, accessToken string) (*auth.Claims, error) {
+	if accessToken != "valid-token" {
+		return nil, apperror.Unauthorized("Invalid access token", apperror.WithCode("INVALID_TOKEN"))
+	}
+
+	return f.claims, nil
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	claims := &auth.Claims{UserID: uuid.New(), SessionID: uuid.New()}
+	a := authn.New(&fakeVerifier{claims: claims})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      string
+	}{
+		{name: "valid", authorization: "Bearer valid-token"},
+		{name: "scheme is case insensitive", authorization: "bearer valid-token"},
+		{name: "missing header", authorization: "", wantCode: "UNAUTHORIZED"},
+		{name: "wrong scheme", authorization: "Basic dXNlcjpwYXNz", wantCode: "UNAUTHORIZED"},
+		{name: "empty token", authorization: "Bearer ", wantCode: "UNAUTHORIZED"},
+		{name: "invalid token", authorization: "Bearer forged", wantCode: "INVALID_TOKEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, err := a.Authenticate(context.Background(), tt.authorization)
+
+			if tt.wantCode != "" {
+				require.Error(t, err)
+				assert.True(t, apperror.Is(err, apperror.KindUnauthorized))
+
+				appErr, ok := apperror.AsAppError(err)
+				require.True(t, ok)
+				assert.Equal(t, tt.wantCode, appErr.Code())
+
+				_, ok = authn.ClaimsFromContext(ctx)
+				assert.False(t, ok)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			userID, ok := authn.UserIDFromContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, claims.UserID, userID)
+
+			sessionID, ok := authn.SessionIDFromContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, claims.SessionID, sessionID)
+		})
+	}
+}
+
+func TestFromContext_Empty(t *testing.T) {
+	t.Parallel()
+
+	_, ok := authn.UserIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = authn.SessionIDFromContext(context.Background())
+	assert.False(t, ok)
+}
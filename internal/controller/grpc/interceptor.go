@@ -0,0 +1,137 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/evrone/go-clean-template/internal/controller/authn"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	pbgrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationMetadata = "authorization"
+	requestIDMetadata     = "x-request-id"
+)
+
+// publicServices are reachable without an access token.
+//
+//nolint:gochecknoglobals // fixed list of infrastructure services
+var publicServices = []string{
+	"/grpc.reflection.",
+	"/grpc.health.",
+}
+
+// AuthUnaryInterceptor authenticates unary calls with the bearer token from the
+// "authorization" metadata. Methods listed in public, and the reflection and
+// health services, skip authentication.
+func AuthUnaryInterceptor(a *authn.Authenticator, public ...string) pbgrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *pbgrpc.UnaryServerInfo, handler pbgrpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, a, info.FullMethod, public)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor is the streaming counterpart of AuthUnaryInterceptor.
+func AuthStreamInterceptor(a *authn.Authenticator, public ...string) pbgrpc.StreamServerInterceptor {
+	return func(srv any, ss pbgrpc.ServerStream, info *pbgrpc.StreamServerInfo, handler pbgrpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), a, info.FullMethod, public)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	pbgrpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func authenticate(ctx context.Context, a *authn.Authenticator, method string, public []string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if ids := md.Get(requestIDMetadata); len(ids) > 0 && ids[0] != "" {
+		ctx = logger.ContextWithRequestID(ctx, ids[0])
+	}
+
+	if isPublic(method, public) {
+		return ctx, nil
+	}
+
+	var authorization string
+	if values := md.Get(authorizationMetadata); len(values) > 0 {
+		authorization = values[0]
+	}
+
+	ctx, err := a.Authenticate(ctx, authorization)
+	if err != nil {
+		return ctx, toStatus(err)
+	}
+
+	return ctx, nil
+}
+
+func isPublic(method string, public []string) bool {
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+
+	for _, m := range public {
+		if method == m {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toStatus(err error) error {
+	appErr, ok := apperror.AsAppError(err)
+	if !ok {
+		return status.Error(codes.Internal, "An unexpected error occurred")
+	}
+
+	return status.Error(kindToCode(appErr.Kind()), appErr.Message())
+}
+
+func kindToCode(kind apperror.Kind) codes.Code {
+	switch kind {
+	case apperror.KindUnknown, apperror.KindInternal:
+		return codes.Internal
+	case apperror.KindValidation:
+		return codes.InvalidArgument
+	case apperror.KindNotFound:
+		return codes.NotFound
+	case apperror.KindConflict:
+		return codes.AlreadyExists
+	case apperror.KindUnauthorized:
+		return codes.Unauthenticated
+	case apperror.KindForbidden:
+		return codes.PermissionDenied
+	case apperror.KindTimeout:
+		return codes.DeadlineExceeded
+	case apperror.KindExternal:
+		return codes.Unavailable
+	case apperror.KindRateLimited:
+		return codes.ResourceExhausted
+	}
+
+	return codes.Internal
+}
@@ -0,0 +1,157 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evrone/go-clean-template/internal/controller/authn"
+	"github.com/evrone/go-clean-template/internal/controller/grpc"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pbgrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeVerifier struct {
+	claims *auth.Claims
+}
+
+func (f *fakeVerifier) Verify(_ context.Context, accessToken string) (*auth.Claims, error) {
+	if accessToken != "valid-token" {
+		return nil, apperror.Unauthorized("Invalid access token", apperror.WithCode("INVALID_TOKEN"))
+	}
+
+	return f.claims, nil
+}
+
+type fakeStream struct {
+	pbgrpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthUnaryInterceptor(t *testing.T) {
+	t.Parallel()
+
+	claims := &auth.Claims{UserID: uuid.New(), SessionID: uuid.New()}
+	interceptor := grpc.AuthUnaryInterceptor(authn.New(&fakeVerifier{claims: claims}), "/svc.Public/Ping")
+
+	tests := []struct {
+		name     string
+		method   string
+		md       metadata.MD
+		wantCode codes.Code
+		wantUser bool
+	}{
+		{
+			name:     "valid token",
+			method:   "/svc.Private/Get",
+			md:       metadata.Pairs("authorization", "Bearer valid-token", "x-request-id", "req-1"),
+			wantCode: codes.OK,
+			wantUser: true,
+		},
+		{
+			name:     "missing token",
+			method:   "/svc.Private/Get",
+			md:       metadata.MD{},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "invalid token",
+			method:   "/svc.Private/Get",
+			md:       metadata.Pairs("authorization", "Bearer forged"),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "public method",
+			method:   "/svc.Public/Ping",
+			md:       metadata.MD{},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "reflection service",
+			method:   "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+			md:       metadata.MD{},
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+
+			var handlerCtx context.Context
+
+			_, err := interceptor(ctx, nil, &pbgrpc.UnaryServerInfo{FullMethod: tt.method},
+				func(ctx context.Context, _ any) (any, error) {
+					handlerCtx = ctx
+
+					return nil, nil //nolint:nilnil // handler result is irrelevant here
+				})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+
+			if tt.wantCode != codes.OK {
+				assert.Nil(t, handlerCtx)
+
+				return
+			}
+
+			userID, ok := authn.UserIDFromContext(handlerCtx)
+			assert.Equal(t, tt.wantUser, ok)
+
+			if tt.wantUser {
+				assert.Equal(t, claims.UserID, userID)
+				assert.Equal(t, "req-1", logger.RequestIDFromContext(handlerCtx))
+			}
+		})
+	}
+}
+
+func TestAuthStreamInterceptor(t *testing.T) {
+	t.Parallel()
+
+	claims := &auth.Claims{UserID: uuid.New(), SessionID: uuid.New()}
+	interceptor := grpc.AuthStreamInterceptor(authn.New(&fakeVerifier{claims: claims}))
+	info := &pbgrpc.StreamServerInfo{FullMethod: "/svc.Private/Watch"}
+
+	t.Run("wraps stream context", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer valid-token"))
+
+		err := interceptor(nil, &fakeStream{ctx: ctx}, info, func(_ any, ss pbgrpc.ServerStream) error {
+			sessionID, ok := authn.SessionIDFromContext(ss.Context())
+			require.True(t, ok)
+			assert.Equal(t, claims.SessionID, sessionID)
+
+			return nil
+		})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects missing token", func(t *testing.T) {
+		t.Parallel()
+
+		err := interceptor(nil, &fakeStream{ctx: context.Background()}, info, func(_ any, _ pbgrpc.ServerStream) error {
+			t.Fatal("handler must not be called")
+
+			return nil
+		})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/evrone/go-clean-template/internal/controller/authn"
+	v1 "github.com/evrone/go-clean-template/internal/controller/http/v1"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Auth rejects requests without a valid bearer access token. On success the
+// token claims are available through authn.ClaimsFromContext(c.UserContext()).
+func Auth(a *authn.Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, err := a.Authenticate(c.UserContext(), c.Get(fiber.HeaderAuthorization))
+		if err != nil {
+			return v1.ErrorResponse(c, err)
+		}
+
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
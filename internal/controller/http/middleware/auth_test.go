@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evrone/go-clean-template/internal/controller/authn"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVerifier struct {
+	claims *auth.Claims
+}
+
+func (f *fakeVerifier) Verify(_ context.Context, accessToken string) (*auth.Claims, error) {
+	if accessToken != "valid-token" {
+		return nil, apperror.Unauthorized("Invalid access token", apperror.WithCode("INVALID_TOKEN"))
+	}
+
+	return f.claims, nil
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	claims := &auth.Claims{UserID: uuid.New(), SessionID: uuid.New()}
+
+	newApp := func() *fiber.App {
+		app := fiber.New()
+		app.Use(RequestID())
+		app.Use(Auth(authn.New(&fakeVerifier{claims: claims})))
+		app.Get("/", func(c *fiber.Ctx) error {
+			userID, _ := authn.UserIDFromContext(c.UserContext())
+
+			return c.SendString(userID.String())
+		})
+
+		return app
+	}
+
+	t.Run("passes claims to the handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer valid-token")
+
+		resp, err := newApp().Test(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		body, readErr := io.ReadAll(resp.Body)
+		require.NoError(t, readErr)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, claims.UserID.String(), string(body))
+	})
+
+	t.Run("rejects missing token", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+		resp, err := newApp().Test(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("rejects invalid token", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer forged")
+
+		resp, err := newApp().Test(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		body, readErr := io.ReadAll(resp.Body)
+		require.NoError(t, readErr)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, string(body), "INVALID_TOKEN")
+	})
+}
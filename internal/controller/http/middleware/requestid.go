@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"github.com/evrone/go-clean-template/pkg/logger"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -14,6 +15,7 @@
 
 // RequestID is a middleware that generates or extracts a request ID for each request.
 // If the X-Request-ID header is present, it uses that value; otherwise generates a new UUID.
+// The ID is also stored in c.UserContext() for logger.RequestIDFromContext.
 func RequestID() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		requestID := c.Get(RequestIDHeader)
@@ -22,6 +24,7 @@ func RequestID() fiber.Handler {
 		}
 
 		c.Locals(RequestIDKey, requestID)
+		c.SetUserContext(logger.ContextWithRequestID(c.UserContext(), requestID))
 		c.Set(RequestIDHeader, requestID)
 
 		return c.Next()
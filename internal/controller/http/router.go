@@ -7,14 +7,38 @@
 	"github.com/ansrivas/fiberprometheus/v2"
 	"github.com/evrone/go-clean-template/config"
 	_ "github.com/evrone/go-clean-template/docs" // Swagger docs.
+	"github.com/evrone/go-clean-template/internal/controller/authn"
 	"github.com/evrone/go-clean-template/internal/controller/http/middleware"
 	v1 "github.com/evrone/go-clean-template/internal/controller/http/v1"
+	"github.com/evrone/go-clean-template/internal/usecase"
 	"github.com/evrone/go-clean-template/pkg/logger"
 	"github.com/evrone/go-clean-template/pkg/postgres"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
 )
 
+// UseCases groups the usecases exposed over HTTP.
+type UseCases struct {
+	Auth              usecase.Auth
+	Sessions          usecase.Sessions
+	EmailVerification usecase.EmailVerification
+	Password          usecase.Password
+	MFA               usecase.MFA
+	RecoveryCodes     usecase.RecoveryCodes
+	OAuth             usecase.OAuth
+	Passkeys          usecase.Passkeys
+	MagicLink         usecase.MagicLink
+	AccountRecovery   usecase.AccountRecovery
+	ContactChange     usecase.ContactChange
+	AccountDeletion   usecase.AccountDeletion
+	DataExport        usecase.DataExport
+	Profile           usecase.Profile
+	Availability      usecase.Availability
+	SecurityEvents    usecase.SecurityEvents
+	Settings          usecase.Settings
+	JWKS              usecase.JWKS
+}
+
 // NewRouter -.
 // Swagger spec:
 // @title       Go Clean Template API
@@ -22,7 +46,7 @@
 // @version     1.0
 // @host        localhost:8080
 // @BasePath    /v1
-func NewRouter(app *fiber.App, cfg *config.Config, pg *postgres.Postgres, l logger.Interface) {
+func NewRouter(app *fiber.App, cfg *config.Config, pg *postgres.Postgres, uc *UseCases, authenticator *authn.Authenticator, l logger.Interface) {
 	app.Use(middleware.RequestID())
 	app.Use(middleware.Logger(l))
 	app.Use(middleware.Recovery(l))
@@ -42,7 +66,29 @@ func NewRouter(app *fiber.App, cfg *config.Config, pg *postgres.Postgres, l logg
 	// K8s probes
 	app.Get("/healthz", func(ctx *fiber.Ctx) error { return ctx.SendStatus(http.StatusOK) })
 	v1.NewHealthRoutes(app, pg.Pool)
+	v1.NewJWKSRoutes(app, uc.JWKS, l)
 
 	// Routers
-	_ = app.Group("/v1")
+	requireAuth := middleware.Auth(authenticator)
+
+	apiV1Group := app.Group("/v1")
+	{
+		v1.NewSettingsRoutes(apiV1Group, uc.Settings)
+		v1.NewAuthRoutes(apiV1Group, uc.Auth, requireAuth, l)
+		v1.NewSessionRoutes(apiV1Group, uc.Sessions, requireAuth, l)
+		v1.NewVerificationRoutes(apiV1Group, uc.EmailVerification, requireAuth, l)
+		v1.NewPasswordRoutes(apiV1Group, uc.Password, requireAuth, l)
+		v1.NewMFARoutes(apiV1Group, uc.MFA, requireAuth, l)
+		v1.NewRecoveryRoutes(apiV1Group, uc.RecoveryCodes, requireAuth, l)
+		v1.NewOAuthRoutes(apiV1Group, uc.OAuth, requireAuth, l)
+		v1.NewPasskeyRoutes(apiV1Group, uc.Passkeys, requireAuth, l)
+		v1.NewMagicLinkRoutes(apiV1Group, uc.MagicLink, l)
+		v1.NewAccountRecoveryRoutes(apiV1Group, uc.AccountRecovery, l)
+		v1.NewContactChangeRoutes(apiV1Group, uc.ContactChange, requireAuth, l)
+		v1.NewAccountDeletionRoutes(apiV1Group, uc.AccountDeletion, requireAuth, l)
+		v1.NewDataExportRoutes(apiV1Group, uc.DataExport, requireAuth, l)
+		v1.NewProfileRoutes(apiV1Group, uc.Profile, requireAuth, l)
+		v1.NewAvailabilityRoutes(apiV1Group, uc.Availability, l)
+		v1.NewSecurityEventRoutes(apiV1Group, uc.SecurityEvents, requireAuth, l)
+	}
 }
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type accountDeletionRoutes struct {
+	d usecase.AccountDeletion
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewAccountDeletionRoutes(apiV1Group fiber.Router, d usecase.AccountDeletion, requireAuth fiber.Handler, l logger.Interface) {
+	r := &accountDeletionRoutes{d: d, l: l, v: newValidator()}
+
+	authGroup := apiV1Group.Group("/auth")
+	{
+		authGroup.Post("/account/delete", requireAuth, r.request)
+		authGroup.Post("/account/delete/confirm", r.confirm)
+	}
+}
+
+func (r *accountDeletionRoutes) request(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.ConfirmPassword
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	err = r.d.RequestAccountDeletion(ctx.UserContext(), auth.AccountDeletionInput{
+		UserID:   claims.UserID,
+		Password: body.Password,
+		Client:   clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(response.Message{Message: "Check your email to confirm deleting your account"})
+}
+
+func (r *accountDeletionRoutes) confirm(ctx *fiber.Ctx) error {
+	var body request.ConfirmAccountDeletion
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	purgeAt, err := r.d.ConfirmAccountDeletion(ctx.UserContext(), auth.AccountDeletionConfirmInput{
+		Token:    body.Token,
+		Feedback: body.Feedback,
+		Client:   clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{
+		Message: fmt.Sprintf("Account deleted; sign in before %s to restore it", purgeAt.Format("January 2, 2006")),
+	})
+}
+
+func (r *accountDeletionRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - account-deletion - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type accountRecoveryRoutes struct {
+	ar usecase.AccountRecovery
+	l  logger.Interface
+	v  *validator.Validate
+}
+
+func NewAccountRecoveryRoutes(apiV1Group fiber.Router, ar usecase.AccountRecovery, l logger.Interface) {
+	r := &accountRecoveryRoutes{ar: ar, l: l, v: newValidator()}
+
+	recoveryGroup := apiV1Group.Group("/auth/recovery")
+	{
+		recoveryGroup.Post("/start", r.start)
+		recoveryGroup.Post("/verify", r.verify)
+		recoveryGroup.Post("/reset-mfa", r.resetMFA)
+		recoveryGroup.Post("/resend", r.resend)
+	}
+}
+
+func (r *accountRecoveryRoutes) start(ctx *fiber.Ctx) error {
+	var body request.StartAccountRecovery
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	started, err := r.ar.StartAccountRecovery(ctx.UserContext(), auth.RecoveryStartInput{
+		Identifier: body.Identifier,
+		Method:     auth.RecoveryMethod(body.Method),
+		Client:     clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(response.NewRecoveryStarted(started))
+}
+
+func (r *accountRecoveryRoutes) verify(ctx *fiber.Ctx) error {
+	var body request.VerifyAccountRecovery
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	sessionID, err := recoverySessionID(body.SessionID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	verified, err := r.ar.VerifyAccountRecovery(ctx.UserContext(), auth.RecoveryVerifyInput{
+		SessionID: sessionID,
+		Code:      body.Code,
+		Client:    clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewRecoveryVerified(verified))
+}
+
+func (r *accountRecoveryRoutes) resetMFA(ctx *fiber.Ctx) error {
+	var body request.ResetMFAWithRecovery
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	result, err := r.ar.ResetMFAWithRecovery(ctx.UserContext(), auth.RecoveryResetMFAInput{
+		Token:       body.RecoveryToken,
+		NewPassword: body.NewPassword,
+		Client:      clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewAuth(result.User, result.Tokens))
+}
+
+func (r *accountRecoveryRoutes) resend(ctx *fiber.Ctx) error {
+	var body request.ResendAccountRecovery
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	sessionID, err := recoverySessionID(body.SessionID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	err = r.ar.ResendAccountRecovery(ctx.UserContext(), auth.RecoveryResendInput{
+		SessionID: sessionID,
+		Client:    clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{Message: "If an account matches, a new recovery code has been sent"})
+}
+
+func (r *accountRecoveryRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - account-recovery - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
+
+func recoverySessionID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, apperror.Validation("Invalid recovery session ID",
+			apperror.WithField("recovery_session_id", "must be a valid UUID"))
+	}
+
+	return id, nil
+}
@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/authn"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type authRoutes struct {
+	a usecase.Auth
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewAuthRoutes(apiV1Group fiber.Router, a usecase.Auth, requireAuth fiber.Handler, l logger.Interface) {
+	r := &authRoutes{a: a, l: l, v: newValidator()}
+
+	authGroup := apiV1Group.Group("/auth")
+	{
+		authGroup.Post("/register", r.register)
+		authGroup.Post("/login", r.login)
+		authGroup.Post("/logout", requireAuth, r.logout)
+		authGroup.Post("/refresh", r.refresh)
+		authGroup.Get("/me", requireAuth, r.me)
+	}
+}
+
+func (r *authRoutes) register(ctx *fiber.Ctx) error {
+	var body request.Register
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	result, err := r.a.Register(ctx.UserContext(), auth.RegisterInput{
+		Email:    body.Email,
+		Password: body.Password,
+		Name:     body.Name,
+		Client:   clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(response.NewAuth(result.User, result.Tokens))
+}
+
+func (r *authRoutes) login(ctx *fiber.Ctx) error {
+	var body request.Login
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	result, err := r.a.Login(ctx.UserContext(), auth.LoginInput{
+		Email:      body.Email,
+		Password:   body.Password,
+		RememberMe: body.RememberMe,
+		Client:     clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	if result.Challenge != nil {
+		return ctx.Status(http.StatusForbidden).JSON(response.NewLoginChallenge(result.Challenge))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewAuth(result.User, result.Tokens))
+}
+
+func (r *authRoutes) logout(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.Logout
+	if len(ctx.Body()) > 0 {
+		if err = parseBody(ctx, r.v, &body); err != nil {
+			return r.error(ctx, err)
+		}
+	}
+
+	err = r.a.Logout(ctx.UserContext(), auth.LogoutInput{
+		UserID:       claims.UserID,
+		SessionID:    claims.SessionID,
+		RefreshToken: body.RefreshToken,
+		AllSessions:  body.AllSessions,
+		Client:       clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (r *authRoutes) refresh(ctx *fiber.Ctx) error {
+	var body request.Refresh
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	tokens, err := r.a.Refresh(ctx.UserContext(), auth.RefreshInput{
+		RefreshToken: body.RefreshToken,
+		Client:       clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewToken(tokens))
+}
+
+func (r *authRoutes) me(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	user, err := r.a.Me(ctx.UserContext(), claims.UserID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewUser(user))
+}
+
+// error logs unexpected failures before rendering them, so internals never reach the client.
+func (r *authRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - auth - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
+
+func clientInfo(ctx *fiber.Ctx) auth.ClientInfo {
+	return auth.ClientInfo{
+		IPAddress: ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
+}
+
+// callerClaims returns the claims put in the context by the auth middleware.
+func callerClaims(ctx *fiber.Ctx) (*auth.Claims, error) {
+	claims, ok := authn.ClaimsFromContext(ctx.UserContext())
+	if !ok {
+		return nil, apperror.Unauthorized("Authentication required")
+	}
+
+	return claims, nil
+}
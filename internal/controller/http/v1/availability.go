@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type availabilityRoutes struct {
+	a usecase.Availability
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewAvailabilityRoutes(apiV1Group fiber.Router, a usecase.Availability, l logger.Interface) {
+	r := &availabilityRoutes{a: a, l: l, v: newValidator()}
+
+	checkGroup := apiV1Group.Group("/auth/check")
+	{
+		checkGroup.Post("/email", r.email)
+		checkGroup.Post("/phone", r.phone)
+	}
+}
+
+func (r *availabilityRoutes) email(ctx *fiber.Ctx) error {
+	var body request.CheckEmail
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	a, err := r.a.CheckEmailAvailability(ctx.UserContext(), auth.AvailabilityInput{
+		Value:  body.Email,
+		Client: clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+
+	return ctx.Status(http.StatusOK).JSON(response.NewEmailAvailability(a))
+}
+
+func (r *availabilityRoutes) phone(ctx *fiber.Ctx) error {
+	var body request.CheckPhone
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	a, err := r.a.CheckPhoneAvailability(ctx.UserContext(), auth.AvailabilityInput{
+		Value:  body.PhoneNumber,
+		Client: clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+
+	return ctx.Status(http.StatusOK).JSON(response.NewPhoneAvailability(a))
+}
+
+func (r *availabilityRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - availability - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
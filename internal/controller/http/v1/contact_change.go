@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type contactChangeRoutes struct {
+	c usecase.ContactChange
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewContactChangeRoutes(apiV1Group fiber.Router, c usecase.ContactChange, requireAuth fiber.Handler, l logger.Interface) {
+	r := &contactChangeRoutes{c: c, l: l, v: newValidator()}
+
+	authGroup := apiV1Group.Group("/auth")
+	{
+		authGroup.Post("/email/change", requireAuth, r.changeEmail)
+		authGroup.Post("/email/change/confirm", r.confirmEmail)
+		authGroup.Post("/phone/change", requireAuth, r.changePhone)
+		authGroup.Post("/phone/change/confirm", requireAuth, r.confirmPhone)
+		authGroup.Post("/phone/remove", requireAuth, r.removePhone)
+	}
+}
+
+func (r *contactChangeRoutes) changeEmail(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.ChangeEmail
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	err = r.c.RequestEmailChange(ctx.UserContext(), auth.EmailChangeInput{
+		UserID:   claims.UserID,
+		NewEmail: body.NewEmail,
+		Password: body.Password,
+		Client:   clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(response.Message{Message: "Confirmation email sent to the new address"})
+}
+
+func (r *contactChangeRoutes) confirmEmail(ctx *fiber.Ctx) error {
+	var body request.ConfirmEmailChange
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	change, err := r.c.ConfirmEmailChange(ctx.UserContext(), body.Token, clientInfo(ctx))
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	if change.IsRevert() {
+		return ctx.Status(http.StatusOK).JSON(response.Message{
+			Message: "Email address restored and all sessions signed out; reset your password to secure your account",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{Message: "Email changed successfully"})
+}
+
+func (r *contactChangeRoutes) changePhone(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.ChangePhone
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	err = r.c.RequestPhoneChange(ctx.UserContext(), auth.PhoneChangeInput{
+		UserID:         claims.UserID,
+		NewPhoneNumber: body.NewPhoneNumber,
+		Password:       body.Password,
+		Client:         clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{Message: "Verification code sent to the new number"})
+}
+
+func (r *contactChangeRoutes) confirmPhone(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.ConfirmPhoneChange
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	user, err := r.c.ConfirmPhoneChange(ctx.UserContext(), auth.PhoneChangeConfirmInput{
+		UserID: claims.UserID,
+		Code:   body.Code,
+		Client: clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewUser(user))
+}
+
+func (r *contactChangeRoutes) removePhone(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.ConfirmPassword
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	user, err := r.c.RemovePhone(ctx.UserContext(), claims.UserID, body.Password, clientInfo(ctx))
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewUser(user))
+}
+
+func (r *contactChangeRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - contact-change - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
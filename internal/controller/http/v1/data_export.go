@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type dataExportRoutes struct {
+	d usecase.DataExport
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewDataExportRoutes(apiV1Group fiber.Router, d usecase.DataExport, requireAuth fiber.Handler, l logger.Interface) {
+	r := &dataExportRoutes{d: d, l: l, v: newValidator()}
+
+	exportGroup := apiV1Group.Group("/auth/account/export")
+	{
+		exportGroup.Post("", requireAuth, r.request)
+		exportGroup.Get("", requireAuth, r.list)
+		exportGroup.Get("/download", r.download)
+	}
+}
+
+func (r *dataExportRoutes) request(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	e, err := r.d.RequestDataExport(ctx.UserContext(), auth.DataExportInput{
+		UserID: claims.UserID,
+		Client: clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(response.NewDataExport(e))
+}
+
+func (r *dataExportRoutes) list(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	exports, err := r.d.DataExports(ctx.UserContext(), claims.UserID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewDataExportList(exports))
+}
+
+func (r *dataExportRoutes) download(ctx *fiber.Ctx) error {
+	var query request.DownloadDataExport
+	if err := parseQuery(ctx, r.v, &query); err != nil {
+		return r.error(ctx, err)
+	}
+
+	archive, err := r.d.DownloadDataExport(ctx.UserContext(), auth.DataExportDownloadInput{
+		Token:  query.Token,
+		Client: clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	ctx.Attachment(archive.FileName)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+
+	// The response closes the body once it has been sent.
+	return ctx.Status(http.StatusOK).SendStream(archive.Body, int(archive.Size))
+}
+
+func (r *dataExportRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - data-export - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
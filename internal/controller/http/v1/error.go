@@ -20,6 +20,10 @@ func ErrorResponse(ctx *fiber.Ctx, err error) error {
 
 	status := KindToHTTPStatus(appErr.Kind())
 
+	if retryAfter, ok := appErr.Fields()[apperror.RetryAfterField]; ok {
+		ctx.Set(fiber.HeaderRetryAfter, retryAfter)
+	}
+
 	return ctx.Status(status).JSON(response.Error{
 		Code:    appErr.Code(),
 		Message: appErr.Message(),
@@ -46,6 +50,8 @@ func KindToHTTPStatus(kind apperror.Kind) int {
 		return http.StatusGatewayTimeout
 	case apperror.KindExternal:
 		return http.StatusBadGateway
+	case apperror.KindRateLimited:
+		return http.StatusTooManyRequests
 	}
 
 	return http.StatusInternalServerError
@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+const jwksCacheControl = "public, max-age=300"
+
+type jwksRoutes struct {
+	j usecase.JWKS
+	l logger.Interface
+}
+
+type JWKSResponse struct {
+	Keys []auth.JWK `json:"keys"`
+}
+
+func NewJWKSRoutes(app *fiber.App, j usecase.JWKS, l logger.Interface) {
+	r := &jwksRoutes{j: j, l: l}
+
+	app.Get("/.well-known/jwks.json", r.jwks)
+}
+
+func (r *jwksRoutes) jwks(ctx *fiber.Ctx) error {
+	keys, err := r.j.JWKS(ctx.UserContext())
+	if err != nil {
+		r.l.Error(fmt.Errorf("http - v1 - jwks: %w", err))
+
+		return ErrorResponse(ctx, err)
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, jwksCacheControl)
+
+	return ctx.Status(http.StatusOK).JSON(JWKSResponse{Keys: keys})
+}
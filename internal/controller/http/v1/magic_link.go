@@ -0,0 +1,120 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type magicLinkRoutes struct {
+	m usecase.MagicLink
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewMagicLinkRoutes(apiV1Group fiber.Router, m usecase.MagicLink, l logger.Interface) {
+	r := &magicLinkRoutes{m: m, l: l, v: newValidator()}
+
+	magicLinkGroup := apiV1Group.Group("/auth/magic-link")
+	{
+		magicLinkGroup.Post("/send", r.send)
+		magicLinkGroup.Post("/verify", r.verify)
+		magicLinkGroup.Post("/resend", r.resend)
+	}
+}
+
+func (r *magicLinkRoutes) send(ctx *fiber.Ctx) error {
+	var body request.SendMagicLink
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	sent, err := r.m.SendMagicLink(ctx.UserContext(), auth.MagicLinkSendInput{
+		Identifier: body.Identifier,
+		Method:     auth.MagicLinkMethod(body.Method),
+		Client:     clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(response.NewMagicLinkSent(sent))
+}
+
+func (r *magicLinkRoutes) verify(ctx *fiber.Ctx) error {
+	var body request.VerifyMagicLink
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	sessionID, err := magicLinkSessionID(body.SessionID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	result, err := r.m.VerifyMagicLink(ctx.UserContext(), auth.MagicLinkVerifyInput{
+		SessionID:  sessionID,
+		Token:      body.Token,
+		Code:       body.Code,
+		RememberMe: body.RememberMe,
+		Client:     clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	if result.Challenge != nil {
+		return ctx.Status(http.StatusForbidden).JSON(response.NewLoginChallenge(result.Challenge))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewAuth(result.User, result.Tokens))
+}
+
+func (r *magicLinkRoutes) resend(ctx *fiber.Ctx) error {
+	var body request.ResendMagicLink
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	sessionID, err := magicLinkSessionID(body.SessionID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	sent, err := r.m.ResendMagicLink(ctx.UserContext(), auth.MagicLinkResendInput{
+		SessionID: sessionID,
+		Method:    auth.MagicLinkMethod(body.Method),
+		Client:    clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewMagicLinkSent(sent))
+}
+
+func (r *magicLinkRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - magic-link - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
+
+func magicLinkSessionID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, apperror.Validation("Invalid session ID", apperror.WithField("session_id", "must be a valid UUID"))
+	}
+
+	return id, nil
+}
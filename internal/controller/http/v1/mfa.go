@@ -0,0 +1,204 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type mfaRoutes struct {
+	m usecase.MFA
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewMFARoutes(apiV1Group fiber.Router, m usecase.MFA, requireAuth fiber.Handler, l logger.Interface) {
+	r := &mfaRoutes{m: m, l: l, v: newValidator()}
+
+	mfaGroup := apiV1Group.Group("/auth/mfa")
+	{
+		mfaGroup.Get("/status", requireAuth, r.status)
+		mfaGroup.Post("/totp/setup", requireAuth, r.setupTOTP)
+		mfaGroup.Post("/totp/verify", requireAuth, r.verifyTOTP)
+		mfaGroup.Delete("/totp", requireAuth, r.disableTOTP)
+		mfaGroup.Post("/sms/setup", requireAuth, r.setupSMS)
+		mfaGroup.Post("/sms/verify", requireAuth, r.verifySMS)
+		mfaGroup.Delete("/sms", requireAuth, r.disableSMS)
+		mfaGroup.Post("/challenge", r.challenge)
+		mfaGroup.Post("/send-code", r.sendCode)
+	}
+}
+
+func (r *mfaRoutes) status(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	status, err := r.m.MFAStatus(ctx.UserContext(), claims.UserID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewMFAStatus(status))
+}
+
+func (r *mfaRoutes) setupTOTP(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	setup, err := r.m.SetupTOTP(ctx.UserContext(), claims.UserID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewTOTPSetup(setup))
+}
+
+func (r *mfaRoutes) verifyTOTP(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.VerifyTOTP
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	codes, err := r.m.VerifyTOTPSetup(ctx.UserContext(), claims.UserID, body.Code, clientInfo(ctx))
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.MFAEnabled{
+		Message:       "MFA enabled successfully",
+		RecoveryCodes: codes,
+	})
+}
+
+func (r *mfaRoutes) disableTOTP(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.DisableMFA
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	if err = r.m.DisableTOTP(ctx.UserContext(), claims.UserID, body.Password, clientInfo(ctx)); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (r *mfaRoutes) setupSMS(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.SetupSMS
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	if err = r.m.SetupSMS(ctx.UserContext(), claims.UserID, body.PhoneNumber); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{Message: "Verification code sent"})
+}
+
+func (r *mfaRoutes) verifySMS(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.VerifySMS
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	codes, err := r.m.VerifySMSSetup(ctx.UserContext(), claims.UserID, body.Code, clientInfo(ctx))
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.MFAEnabled{
+		Message:       "MFA enabled successfully",
+		RecoveryCodes: codes,
+	})
+}
+
+func (r *mfaRoutes) disableSMS(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.DisableMFA
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	if err = r.m.DisableSMS(ctx.UserContext(), claims.UserID, body.Password, clientInfo(ctx)); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (r *mfaRoutes) challenge(ctx *fiber.Ctx) error {
+	var body request.MFAChallenge
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	result, err := r.m.CompleteMFAChallenge(ctx.UserContext(), auth.MFAChallengeInput{
+		ChallengeToken: body.ChallengeToken,
+		Code:           body.Code,
+		Method:         auth.MFAMethod(body.Method),
+		Client:         clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewAuth(result.User, result.Tokens))
+}
+
+func (r *mfaRoutes) sendCode(ctx *fiber.Ctx) error {
+	var body request.SendMFACode
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	if err := r.m.SendMFACode(ctx.UserContext(), body.ChallengeToken, auth.MFAMethod(body.Method)); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{Message: "Verification code sent"})
+}
+
+func (r *mfaRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - mfa - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
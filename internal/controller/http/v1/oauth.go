@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type oauthRoutes struct {
+	o usecase.OAuth
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewOAuthRoutes(apiV1Group fiber.Router, o usecase.OAuth, requireAuth fiber.Handler, l logger.Interface) {
+	r := &oauthRoutes{o: o, l: l, v: newValidator()}
+
+	oauthGroup := apiV1Group.Group("/auth/oauth")
+	{
+		oauthGroup.Get("/connections", requireAuth, r.connections)
+		oauthGroup.Delete("/connections/:provider", requireAuth, r.unlink)
+		oauthGroup.Get("/:provider/authorize", r.authorize)
+		oauthGroup.Post("/:provider/callback", r.callback)
+	}
+}
+
+func (r *oauthRoutes) authorize(ctx *fiber.Ctx) error {
+	var query request.OAuthAuthorize
+	if err := parseQuery(ctx, r.v, &query); err != nil {
+		return r.error(ctx, err)
+	}
+
+	location, err := r.o.AuthorizeOAuth(ctx.UserContext(), auth.OAuthAuthorizeInput{
+		Provider:    auth.OAuthProvider(ctx.Params("provider")),
+		RedirectURI: query.RedirectURI,
+		State:       query.State,
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Redirect(location, http.StatusFound)
+}
+
+func (r *oauthRoutes) callback(ctx *fiber.Ctx) error {
+	var body request.OAuthCallback
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	result, err := r.o.OAuthCallback(ctx.UserContext(), auth.OAuthCallbackInput{
+		Provider:    auth.OAuthProvider(ctx.Params("provider")),
+		Code:        body.Code,
+		State:       body.State,
+		RedirectURI: body.RedirectURI,
+		Client:      clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	if result.Challenge != nil {
+		return ctx.Status(http.StatusForbidden).JSON(response.NewLoginChallenge(result.Challenge))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewAuth(result.User, result.Tokens))
+}
+
+func (r *oauthRoutes) connections(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	connections, err := r.o.OAuthConnections(ctx.UserContext(), claims.UserID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewOAuthConnectionList(connections))
+}
+
+func (r *oauthRoutes) unlink(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	provider := auth.OAuthProvider(ctx.Params("provider"))
+
+	if err = r.o.UnlinkOAuth(ctx.UserContext(), claims.UserID, provider, clientInfo(ctx)); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (r *oauthRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - oauth - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
@@ -0,0 +1,239 @@
+package v1
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type passkeyRoutes struct {
+	p usecase.Passkeys
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewPasskeyRoutes(apiV1Group fiber.Router, p usecase.Passkeys, requireAuth fiber.Handler, l logger.Interface) {
+	r := &passkeyRoutes{p: p, l: l, v: newValidator()}
+
+	passkeyGroup := apiV1Group.Group("/auth/passkeys")
+	{
+		passkeyGroup.Get("", requireAuth, r.list)
+		passkeyGroup.Post("/register/start", requireAuth, r.registerStart)
+		passkeyGroup.Post("/register/finish", requireAuth, r.registerFinish)
+		passkeyGroup.Post("/authenticate/start", r.authenticateStart)
+		passkeyGroup.Post("/authenticate/finish", r.authenticateFinish)
+		passkeyGroup.Patch("/:passkey_id", requireAuth, r.rename)
+		passkeyGroup.Delete("/:passkey_id", requireAuth, r.remove)
+	}
+}
+
+func (r *passkeyRoutes) list(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	passkeys, err := r.p.Passkeys(ctx.UserContext(), claims.UserID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewPasskeyList(passkeys))
+}
+
+func (r *passkeyRoutes) registerStart(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	opts, err := r.p.StartPasskeyRegistration(ctx.UserContext(), claims.UserID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewPasskeyRegistrationOptions(opts))
+}
+
+func (r *passkeyRoutes) registerFinish(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.PasskeyRegistration
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	in := auth.PasskeyRegistrationInput{
+		UserID:     claims.UserID,
+		Transports: body.Response.Transports,
+		Name:       body.Name,
+		Client:     clientInfo(ctx),
+	}
+
+	if err = decodeBase64URL(
+		base64Field{"raw_id", body.RawID, &in.CredentialID},
+		base64Field{"response.client_data_json", body.Response.ClientDataJSON, &in.ClientDataJSON},
+		base64Field{"response.attestation_object", body.Response.AttestationObject, &in.AttestationObject},
+	); err != nil {
+		return r.error(ctx, err)
+	}
+
+	p, err := r.p.FinishPasskeyRegistration(ctx.UserContext(), in)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(response.NewPasskey(p))
+}
+
+func (r *passkeyRoutes) authenticateStart(ctx *fiber.Ctx) error {
+	var body request.PasskeyAuthStart
+
+	// The body is optional: without one any discoverable passkey may be used.
+	if len(ctx.Body()) > 0 {
+		if err := parseBody(ctx, r.v, &body); err != nil {
+			return r.error(ctx, err)
+		}
+	}
+
+	opts, err := r.p.StartPasskeyLogin(ctx.UserContext(), auth.PasskeyLoginStartInput{
+		ChallengeToken: body.ChallengeToken,
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewPasskeyAuthenticationOptions(opts))
+}
+
+func (r *passkeyRoutes) authenticateFinish(ctx *fiber.Ctx) error {
+	var body request.PasskeyAuthentication
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	in := auth.PasskeyLoginInput{
+		ChallengeToken: body.ChallengeToken,
+		RememberMe:     body.RememberMe,
+		Client:         clientInfo(ctx),
+	}
+
+	if err := decodeBase64URL(
+		base64Field{"raw_id", body.RawID, &in.CredentialID},
+		base64Field{"response.client_data_json", body.Response.ClientDataJSON, &in.ClientDataJSON},
+		base64Field{"response.authenticator_data", body.Response.AuthenticatorData, &in.AuthenticatorData},
+		base64Field{"response.signature", body.Response.Signature, &in.Signature},
+		base64Field{"response.user_handle", body.Response.UserHandle, &in.UserHandle},
+	); err != nil {
+		return r.error(ctx, err)
+	}
+
+	result, err := r.p.FinishPasskeyLogin(ctx.UserContext(), in)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	if result.Challenge != nil {
+		return ctx.Status(http.StatusForbidden).JSON(response.NewLoginChallenge(result.Challenge))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewAuth(result.User, result.Tokens))
+}
+
+func (r *passkeyRoutes) rename(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	id, err := passkeyID(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.UpdatePasskey
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	p, err := r.p.RenamePasskey(ctx.UserContext(), claims.UserID, id, body.Name)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewPasskey(p))
+}
+
+func (r *passkeyRoutes) remove(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	id, err := passkeyID(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	if err = r.p.DeletePasskey(ctx.UserContext(), claims.UserID, id, clientInfo(ctx)); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (r *passkeyRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - passkey - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
+
+func passkeyID(ctx *fiber.Ctx) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Params("passkey_id"))
+	if err != nil {
+		return uuid.Nil, apperror.Validation("Invalid passkey ID", apperror.WithField("passkey_id", "must be a valid UUID"))
+	}
+
+	return id, nil
+}
+
+// base64Field is a request field holding binary WebAuthn data.
+type base64Field struct {
+	name  string
+	value string
+	dst   *[]byte
+}
+
+// decodeBase64URL decodes each field into its destination. Browsers differ
+// on padding, so it is accepted but not required.
+func decodeBase64URL(fields ...base64Field) error {
+	for _, f := range fields {
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(f.value, "="))
+		if err != nil {
+			return apperror.Validation("Invalid request body",
+				apperror.WithCode("INVALID_REQUEST"),
+				apperror.WithField(f.name, "must be base64url encoded"),
+			)
+		}
+
+		*f.dst = decoded
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type passwordRoutes struct {
+	p usecase.Password
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewPasswordRoutes(apiV1Group fiber.Router, p usecase.Password, requireAuth fiber.Handler, l logger.Interface) {
+	r := &passwordRoutes{p: p, l: l, v: newValidator()}
+
+	passwordGroup := apiV1Group.Group("/auth/password")
+	{
+		passwordGroup.Post("/forgot", r.forgot)
+		passwordGroup.Post("/reset", r.reset)
+		passwordGroup.Post("/change", requireAuth, r.change)
+		passwordGroup.Post("/validate", r.validate)
+	}
+}
+
+func (r *passwordRoutes) forgot(ctx *fiber.Ctx) error {
+	var body request.ForgotPassword
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	if err := r.p.ForgotPassword(ctx.UserContext(), body.Email, clientInfo(ctx)); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(response.Message{
+		Message: "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+func (r *passwordRoutes) reset(ctx *fiber.Ctx) error {
+	var body request.ResetPassword
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	err := r.p.ResetPassword(ctx.UserContext(), auth.ResetPasswordInput{
+		Token:    body.Token,
+		Password: body.Password,
+		Client:   clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{Message: "Password has been reset"})
+}
+
+func (r *passwordRoutes) change(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.ChangePassword
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	err = r.p.ChangePassword(ctx.UserContext(), auth.ChangePasswordInput{
+		UserID:          claims.UserID,
+		SessionID:       claims.SessionID,
+		CurrentPassword: body.CurrentPassword,
+		NewPassword:     body.NewPassword,
+		Client:          clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{Message: "Password changed"})
+}
+
+func (r *passwordRoutes) validate(ctx *fiber.Ctx) error {
+	var body request.ValidatePassword
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	result := r.p.ValidatePassword(ctx.UserContext(), body.Password)
+
+	return ctx.Status(http.StatusOK).JSON(response.NewPasswordValidation(result))
+}
+
+func (r *passwordRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - password - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
@@ -0,0 +1,173 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// avatarFormField is the multipart field an avatar is uploaded in.
+const avatarFormField = "avatar"
+
+type profileRoutes struct {
+	p usecase.Profile
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewProfileRoutes(apiV1Group fiber.Router, p usecase.Profile, requireAuth fiber.Handler, l logger.Interface) {
+	r := &profileRoutes{p: p, l: l, v: newValidator()}
+
+	profileGroup := apiV1Group.Group("/auth/profile")
+	{
+		profileGroup.Patch("", requireAuth, r.update)
+		profileGroup.Put("/avatar", requireAuth, r.uploadAvatar)
+		profileGroup.Delete("/avatar", requireAuth, r.removeAvatar)
+	}
+
+	// Avatar URLs are unguessable and meant to be embedded, so they are
+	// served without authentication.
+	apiV1Group.Get("/avatars/:user_id/:file", r.avatar)
+}
+
+func (r *profileRoutes) update(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.UpdateProfile
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	user, err := r.p.UpdateProfile(ctx.UserContext(), auth.ProfileUpdateInput{
+		UserID:    claims.UserID,
+		Name:      body.Name,
+		AvatarURL: body.AvatarURL,
+		Client:    clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewUser(user))
+}
+
+func (r *profileRoutes) uploadAvatar(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	data, err := formFile(ctx, avatarFormField)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	user, err := r.p.UploadAvatar(ctx.UserContext(), auth.AvatarUploadInput{
+		UserID: claims.UserID,
+		Data:   data,
+		Client: clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewUser(user))
+}
+
+func (r *profileRoutes) removeAvatar(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	user, err := r.p.RemoveAvatar(ctx.UserContext(), claims.UserID, clientInfo(ctx))
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewUser(user))
+}
+
+func (r *profileRoutes) avatar(ctx *fiber.Ctx) error {
+	var query request.AvatarQuery
+	if err := parseQuery(ctx, r.v, &query); err != nil {
+		return r.error(ctx, err)
+	}
+
+	userID, err := uuid.Parse(ctx.Params("user_id"))
+	if err != nil {
+		return r.error(ctx, apperror.NotFound("Avatar not found"))
+	}
+
+	name, ext, _ := strings.Cut(ctx.Params("file"), ".")
+
+	uploadID, err := uuid.Parse(name)
+	if err != nil {
+		return r.error(ctx, apperror.NotFound("Avatar not found"))
+	}
+
+	img, err := r.p.Avatar(ctx.UserContext(), auth.AvatarInput{
+		UserID:    userID,
+		UploadID:  uploadID,
+		Extension: ext,
+		Size:      query.Size,
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	// Each upload gets a new URL, so a stored avatar never changes.
+	ctx.Set(fiber.HeaderContentType, img.ContentType)
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	ctx.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	// The response closes the body once it has been sent.
+	return ctx.Status(http.StatusOK).SendStream(img.Body)
+}
+
+func (r *profileRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - profile - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
+
+// formFile reads an uploaded file. Fiber's body limit bounds its size.
+func formFile(ctx *fiber.Ctx, field string) ([]byte, error) {
+	fh, err := ctx.FormFile(field)
+	if err != nil {
+		return nil, apperror.Validation("Invalid upload",
+			apperror.WithCode("INVALID_REQUEST"),
+			apperror.WithField(field, "must be a file sent as multipart/form-data"),
+			apperror.WithCause(err),
+		)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, fmt.Errorf("fh.Open: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type recoveryRoutes struct {
+	rc usecase.RecoveryCodes
+	l  logger.Interface
+	v  *validator.Validate
+}
+
+func NewRecoveryRoutes(apiV1Group fiber.Router, rc usecase.RecoveryCodes, requireAuth fiber.Handler, l logger.Interface) {
+	r := &recoveryRoutes{rc: rc, l: l, v: newValidator()}
+
+	recoveryGroup := apiV1Group.Group("/auth/recovery")
+	{
+		recoveryGroup.Get("/codes", requireAuth, r.codeStatus)
+		recoveryGroup.Post("/codes", requireAuth, r.generateCodes)
+		recoveryGroup.Post("/use-code", r.useCode)
+	}
+}
+
+func (r *recoveryRoutes) codeStatus(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	status, err := r.rc.RecoveryCodeStatus(ctx.UserContext(), claims.UserID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewRecoveryCodeStatus(status))
+}
+
+func (r *recoveryRoutes) generateCodes(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var body request.ConfirmPassword
+	if err = parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	codes, err := r.rc.GenerateRecoveryCodes(ctx.UserContext(), claims.UserID, body.Password, clientInfo(ctx))
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.RecoveryCodes{Codes: codes})
+}
+
+func (r *recoveryRoutes) useCode(ctx *fiber.Ctx) error {
+	var body request.UseRecoveryCode
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	result, err := r.rc.UseRecoveryCode(ctx.UserContext(), auth.RecoveryCodeLoginInput{
+		Email:  body.Email,
+		Code:   body.Code,
+		Client: clientInfo(ctx),
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	if result.Challenge != nil {
+		return ctx.Status(http.StatusForbidden).JSON(response.NewLoginChallenge(result.Challenge))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewAuth(result.User, result.Tokens))
+}
+
+func (r *recoveryRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - recovery - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
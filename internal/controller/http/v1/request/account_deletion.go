@@ -0,0 +1,6 @@
+package request
+
+type ConfirmAccountDeletion struct {
+	Token    string `json:"token" validate:"required,max=128" example:"dGhpcy1pcy1hLWRlbGV0aW9uLXRva2Vu..."`
+	Feedback string `json:"feedback" validate:"max=1000" example:"I no longer need the account"`
+}
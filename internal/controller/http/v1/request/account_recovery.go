@@ -0,0 +1,21 @@
+package request
+
+type StartAccountRecovery struct {
+	// Identifier is an email address or an E.164 phone number.
+	Identifier string `json:"identifier" validate:"required,max=255" example:"user@example.com"`
+	Method     string `json:"method" validate:"required,oneof=email sms" example:"email"`
+}
+
+type VerifyAccountRecovery struct {
+	SessionID string `json:"recovery_session_id" validate:"required,uuid"`
+	Code      string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+type ResetMFAWithRecovery struct {
+	RecoveryToken string `json:"recovery_token" validate:"required,max=128"`
+	NewPassword   string `json:"new_password" validate:"omitempty,max=1024" example:"NewSecureP@ss456"`
+}
+
+type ResendAccountRecovery struct {
+	SessionID string `json:"recovery_session_id" validate:"required,uuid"`
+}
@@ -0,0 +1,26 @@
+package request
+
+type Register struct {
+	Email    string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+	Password string `json:"password" validate:"required,max=1024" example:"SecureP@ss123"`
+	Name     string `json:"name" validate:"omitempty,max=255" example:"John Doe"`
+}
+
+type Login struct {
+	Email      string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+	Password   string `json:"password" validate:"required,max=128" example:"SecureP@ss123"`
+	RememberMe bool   `json:"remember_me" example:"false"`
+}
+
+type Logout struct {
+	RefreshToken string `json:"refresh_token" example:"dGhpcy1pcy1hLXJlZnJlc2g..."`
+	AllSessions  bool   `json:"all_sessions" example:"false"`
+}
+
+type Refresh struct {
+	RefreshToken string `json:"refresh_token" validate:"required" example:"dGhpcy1pcy1hLXJlZnJlc2g..."`
+}
+
+type VerifyEmail struct {
+	Token string `json:"token" validate:"required,max=128" example:"dGhpcy1pcy1hLXZlcmlmaWNhdGlvbg..."`
+}
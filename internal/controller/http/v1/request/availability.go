@@ -0,0 +1,10 @@
+package request
+
+type CheckEmail struct {
+	Email string `json:"email" validate:"required,max=255" example:"user@example.com"`
+}
+
+// CheckPhone takes the number as typed; it is normalized to E.164.
+type CheckPhone struct {
+	PhoneNumber string `json:"phone_number" validate:"required,max=32" example:"+14155551234"`
+}
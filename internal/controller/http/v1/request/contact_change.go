@@ -0,0 +1,19 @@
+package request
+
+type ChangeEmail struct {
+	NewEmail string `json:"new_email" validate:"required,email,max=255" example:"newemail@example.com"`
+	Password string `json:"password" validate:"required,max=1024" example:"SecureP@ss123"`
+}
+
+type ConfirmEmailChange struct {
+	Token string `json:"token" validate:"required,max=128" example:"dGhpcy1pcy1hbi1lbWFpbC1jaGFuZ2U..."`
+}
+
+type ChangePhone struct {
+	NewPhoneNumber string `json:"new_phone_number" validate:"required,e164" example:"+14155551234"`
+	Password       string `json:"password" validate:"required,max=1024" example:"SecureP@ss123"`
+}
+
+type ConfirmPhoneChange struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
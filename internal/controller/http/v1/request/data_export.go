@@ -0,0 +1,5 @@
+package request
+
+type DownloadDataExport struct {
+	Token string `query:"token" validate:"required,max=128" example:"dGhpcy1pcy1hbi1leHBvcnQtdG9rZW4..."`
+}
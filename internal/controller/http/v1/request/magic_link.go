@@ -0,0 +1,20 @@
+package request
+
+type SendMagicLink struct {
+	// Identifier is an email address or an E.164 phone number.
+	Identifier string `json:"identifier" validate:"required,max=255" example:"user@example.com"`
+	Method     string `json:"method" validate:"required,oneof=email sms" example:"email"`
+}
+
+// VerifyMagicLink takes either the token from the link or the one-time code.
+type VerifyMagicLink struct {
+	SessionID  string `json:"session_id" validate:"required,uuid"`
+	Token      string `json:"token" validate:"required_without=Code,max=128"`
+	Code       string `json:"code" validate:"omitempty,len=6,numeric" example:"123456"`
+	RememberMe bool   `json:"remember_me" example:"false"`
+}
+
+type ResendMagicLink struct {
+	SessionID string `json:"session_id" validate:"required,uuid"`
+	Method    string `json:"method" validate:"omitempty,oneof=email sms" example:"sms"`
+}
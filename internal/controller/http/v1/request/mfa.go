@@ -0,0 +1,28 @@
+package request
+
+type VerifyTOTP struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+type SetupSMS struct {
+	PhoneNumber string `json:"phone_number" validate:"required,e164" example:"+14155551234"`
+}
+
+type VerifySMS struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+type MFAChallenge struct {
+	ChallengeToken string `json:"challenge_token" validate:"required,max=128"`
+	Code           string `json:"code" validate:"required,max=32" example:"123456"`
+	Method         string `json:"method" validate:"omitempty,oneof=totp sms recovery_code email" example:"totp"`
+}
+
+type DisableMFA struct {
+	Password string `json:"password" validate:"required,max=1024" example:"SecureP@ss123"`
+}
+
+type SendMFACode struct {
+	ChallengeToken string `json:"challenge_token" validate:"required,max=128"`
+	Method         string `json:"method" validate:"omitempty,oneof=sms email" example:"sms"`
+}
@@ -0,0 +1,12 @@
+package request
+
+type OAuthAuthorize struct {
+	RedirectURI string `query:"redirect_uri" validate:"omitempty,url,max=2048"`
+	State       string `query:"state" validate:"omitempty,max=256"`
+}
+
+type OAuthCallback struct {
+	Code        string `json:"code" validate:"required,max=2048" example:"4/0AX4XfWg"`
+	State       string `json:"state" validate:"required,max=4096"`
+	RedirectURI string `json:"redirect_uri" validate:"omitempty,url,max=2048" example:"https://app.example.com/auth/oauth/google/callback"`
+}
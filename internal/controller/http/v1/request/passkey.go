@@ -0,0 +1,42 @@
+package request
+
+// Binary WebAuthn fields are base64url encoded, with or without padding.
+
+type PasskeyRegistration struct {
+	ID       string             `json:"id" validate:"required,max=1400"`
+	RawID    string             `json:"raw_id" validate:"required,max=1400"`
+	Type     string             `json:"type" validate:"required,eq=public-key" example:"public-key"`
+	Name     string             `json:"name" validate:"omitempty,max=100" example:"MacBook Pro Touch ID"`
+	Response PasskeyAttestation `json:"response"`
+}
+
+type PasskeyAttestation struct {
+	AttestationObject string   `json:"attestation_object" validate:"required,max=65536"`
+	ClientDataJSON    string   `json:"client_data_json" validate:"required,max=8192"`
+	Transports        []string `json:"transports" validate:"max=8,dive,oneof=usb nfc ble internal hybrid smart-card"`
+}
+
+type PasskeyAuthStart struct {
+	// ChallengeToken makes the passkey complete a pending MFA challenge.
+	ChallengeToken string `json:"challenge_token" validate:"omitempty,max=128"`
+}
+
+type PasskeyAuthentication struct {
+	ID             string           `json:"id" validate:"required,max=1400"`
+	RawID          string           `json:"raw_id" validate:"required,max=1400"`
+	Type           string           `json:"type" validate:"required,eq=public-key" example:"public-key"`
+	Response       PasskeyAssertion `json:"response"`
+	ChallengeToken string           `json:"challenge_token" validate:"omitempty,max=128"`
+	RememberMe     bool             `json:"remember_me" example:"false"`
+}
+
+type PasskeyAssertion struct {
+	AuthenticatorData string `json:"authenticator_data" validate:"required,max=8192"`
+	ClientDataJSON    string `json:"client_data_json" validate:"required,max=8192"`
+	Signature         string `json:"signature" validate:"required,max=2048"`
+	UserHandle        string `json:"user_handle" validate:"omitempty,max=128"`
+}
+
+type UpdatePasskey struct {
+	Name string `json:"name" validate:"required,max=100" example:"Work Laptop"`
+}
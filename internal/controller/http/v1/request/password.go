@@ -0,0 +1,19 @@
+package request
+
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" validate:"required,max=128" example:"dGhpcy1pcy1hLXJlc2V0LXRva2Vu..."`
+	Password string `json:"password" validate:"required,max=1024" example:"NewSecureP@ss123"`
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required,max=1024" example:"SecureP@ss123"`
+	NewPassword     string `json:"new_password" validate:"required,max=1024" example:"NewSecureP@ss123"`
+}
+
+type ValidatePassword struct {
+	Password string `json:"password" validate:"required,max=1024" example:"SecureP@ss123"`
+}
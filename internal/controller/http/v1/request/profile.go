@@ -0,0 +1,13 @@
+package request
+
+// UpdateProfile changes the fields that are present; an empty avatar_url
+// removes the avatar.
+type UpdateProfile struct {
+	Name      *string `json:"name" validate:"omitempty,max=100" example:"John Doe"`
+	AvatarURL *string `json:"avatar_url" validate:"omitempty,max=2048" example:"https://example.com/avatar.jpg"`
+}
+
+// AvatarQuery asks for an avatar about size pixels a side.
+type AvatarQuery struct {
+	Size int `query:"size" validate:"omitempty,min=1,max=4096"`
+}
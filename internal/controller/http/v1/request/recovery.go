@@ -0,0 +1,10 @@
+package request
+
+type ConfirmPassword struct {
+	Password string `json:"password" validate:"required,max=1024" example:"SecureP@ss123"`
+}
+
+type UseRecoveryCode struct {
+	Email string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+	Code  string `json:"code" validate:"required,max=32" example:"ABCD-1234"`
+}
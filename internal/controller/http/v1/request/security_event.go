@@ -0,0 +1,10 @@
+package request
+
+// SecurityEventQuery filters the audit log; from and to are RFC 3339 times.
+type SecurityEventQuery struct {
+	Page      int    `query:"page" validate:"omitempty,min=1"`
+	Limit     int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	EventType string `query:"event_type" validate:"omitempty,max=50"`
+	From      string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To        string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
@@ -0,0 +1,77 @@
+package response
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+)
+
+// RecoveryStarted reads the same whether or not an account was found.
+type RecoveryStarted struct {
+	Message           string    `json:"message"`
+	SessionID         string    `json:"recovery_session_id"`
+	MaskedDestination string    `json:"masked_destination,omitempty"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+func NewRecoveryStarted(s *auth.RecoveryStarted) RecoveryStarted {
+	res := RecoveryStarted{
+		Message:   "If an account matches, a recovery code has been sent by email",
+		SessionID: s.SessionID.String(),
+		ExpiresAt: s.ExpiresAt,
+	}
+
+	if s.Method == auth.RecoverySMS {
+		res.Message = "If an account matches, a recovery code has been sent by SMS"
+	}
+
+	switch {
+	case s.Destination == "":
+	case s.Method == auth.RecoverySMS:
+		res.MaskedDestination = maskPhone(s.Destination)
+	default:
+		res.MaskedDestination = maskEmail(s.Destination)
+	}
+
+	return res
+}
+
+// RecoveryVerified carries the recovery token, which only works from
+// AvailableAt until ExpiresAt.
+type RecoveryVerified struct {
+	RecoveryToken    string           `json:"recovery_token"`
+	AvailableActions []string         `json:"available_actions"`
+	AvailableAt      time.Time        `json:"available_at"`
+	ExpiresAt        time.Time        `json:"expires_at"`
+	UserInfo         RecoveryUserInfo `json:"user_info"`
+}
+
+type RecoveryUserInfo struct {
+	EmailMasked string   `json:"email_masked"`
+	MFAEnabled  bool     `json:"mfa_enabled"`
+	MFAMethods  []string `json:"mfa_methods"`
+}
+
+func NewRecoveryVerified(v *auth.RecoveryVerified) RecoveryVerified {
+	methods := []string{}
+
+	if v.MFA.TOTPEnabled {
+		methods = append(methods, string(auth.MFAMethodTOTP))
+	}
+
+	if v.MFA.SMSEnabled {
+		methods = append(methods, string(auth.MFAMethodSMS))
+	}
+
+	return RecoveryVerified{
+		RecoveryToken:    v.Token,
+		AvailableActions: v.Actions,
+		AvailableAt:      v.AvailableAt,
+		ExpiresAt:        v.ExpiresAt,
+		UserInfo: RecoveryUserInfo{
+			EmailMasked: maskEmail(v.Email),
+			MFAEnabled:  v.MFA.Enabled(),
+			MFAMethods:  methods,
+		},
+	}
+}
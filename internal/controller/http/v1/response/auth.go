@@ -0,0 +1,86 @@
+package response
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	Name          *string   `json:"name,omitempty"`
+	AvatarURL     *string   `json:"avatar_url,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
+	PhoneNumber   *string   `json:"phone_number,omitempty"`
+	PhoneVerified bool      `json:"phone_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type Auth struct {
+	User         User   `json:"user"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+type LoginChallenge struct {
+	ChallengeType    string     `json:"challenge_type"`
+	ChallengeToken   string     `json:"challenge_token"`
+	AvailableMethods []string   `json:"available_methods,omitempty"`
+	LockedUntil      *time.Time `json:"locked_until,omitempty"`
+	Message          string     `json:"message,omitempty"`
+}
+
+func NewUser(u *auth.User) User {
+	return User{
+		ID:            u.ID,
+		Email:         u.Email,
+		Name:          u.Name,
+		AvatarURL:     u.AvatarURL,
+		EmailVerified: u.EmailVerified,
+		PhoneNumber:   u.PhoneNumber,
+		PhoneVerified: u.PhoneVerified,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+	}
+}
+
+func NewAuth(u *auth.User, t *auth.TokenPair) Auth {
+	return Auth{
+		User:         NewUser(u),
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		ExpiresIn:    t.ExpiresIn,
+		TokenType:    t.TokenType,
+	}
+}
+
+func NewToken(t *auth.TokenPair) Token {
+	return Token{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		ExpiresIn:    t.ExpiresIn,
+		TokenType:    t.TokenType,
+	}
+}
+
+func NewLoginChallenge(c *auth.LoginChallenge) LoginChallenge {
+	return LoginChallenge{
+		ChallengeType:    string(c.Type),
+		ChallengeToken:   c.Token,
+		AvailableMethods: c.AvailableMethods,
+		LockedUntil:      c.LockedUntil,
+		Message:          c.Message,
+	}
+}
@@ -0,0 +1,27 @@
+package response
+
+import "github.com/evrone/go-clean-template/internal/entity/auth"
+
+type Availability struct {
+	Available bool   `json:"available"`
+	Message   string `json:"message"`
+}
+
+func NewEmailAvailability(a *auth.Availability) Availability {
+	switch a.Reason {
+	case auth.UnavailableDisposable:
+		return Availability{Message: "Disposable email addresses are not allowed"}
+	case auth.UnavailableTaken:
+		return Availability{Message: "Email is already registered"}
+	default:
+		return Availability{Available: true, Message: "Email is available"}
+	}
+}
+
+func NewPhoneAvailability(a *auth.Availability) Availability {
+	if a.Reason == auth.UnavailableTaken {
+		return Availability{Message: "Phone number is already registered"}
+	}
+
+	return Availability{Available: true, Message: "Phone number is available"}
+}
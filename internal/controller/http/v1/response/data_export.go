@@ -0,0 +1,42 @@
+package response
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/google/uuid"
+)
+
+type DataExport struct {
+	ID          uuid.UUID             `json:"id"`
+	Status      auth.DataExportStatus `json:"status"`
+	Size        int64                 `json:"size,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time            `json:"expires_at,omitempty"`
+}
+
+type DataExportList struct {
+	Exports []DataExport `json:"exports"`
+}
+
+func NewDataExport(e *auth.DataExport) DataExport {
+	return DataExport{
+		ID:          e.ID,
+		Status:      e.Status,
+		Size:        e.Size,
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+}
+
+func NewDataExportList(exports []auth.DataExport) DataExportList {
+	list := DataExportList{Exports: make([]DataExport, 0, len(exports))}
+
+	for i := range exports {
+		list.Exports = append(list.Exports, NewDataExport(&exports[i]))
+	}
+
+	return list
+}
@@ -0,0 +1,56 @@
+package response
+
+import (
+	"strings"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+)
+
+// MagicLinkSent reads the same whether or not an account was found.
+type MagicLinkSent struct {
+	Message           string    `json:"message"`
+	SessionID         string    `json:"session_id"`
+	MaskedDestination string    `json:"masked_destination,omitempty"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	Method            string    `json:"method"`
+}
+
+func NewMagicLinkSent(s *auth.MagicLinkSent) MagicLinkSent {
+	res := MagicLinkSent{
+		Message:   "If an account matches, a sign-in link and code have been sent by email",
+		SessionID: s.SessionID.String(),
+		ExpiresAt: s.ExpiresAt,
+		Method:    string(s.Method),
+	}
+
+	if s.Method == auth.MagicLinkSMS {
+		res.Message = "If an account matches, a sign-in link and code have been sent by SMS"
+	}
+
+	switch {
+	case s.Destination == "":
+	case s.Method == auth.MagicLinkSMS:
+		res.MaskedDestination = maskPhone(s.Destination)
+	default:
+		res.MaskedDestination = maskEmail(s.Destination)
+	}
+
+	return res
+}
+
+// maskEmail keeps the first and last character of the local part, e.g.
+// j***n@example.com.
+func maskEmail(e string) string {
+	at := strings.LastIndexByte(e, '@')
+	if at < 0 {
+		return e
+	}
+
+	local := e[:at]
+	if len(local) <= 2 {
+		return local[:1] + "***" + e[at:]
+	}
+
+	return local[:1] + "***" + local[len(local)-1:] + e[at:]
+}
@@ -0,0 +1,5 @@
+package response
+
+type Message struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,58 @@
+package response
+
+import "github.com/evrone/go-clean-template/internal/entity/auth"
+
+type MFAStatus struct {
+	MFAEnabled             bool    `json:"mfa_enabled"`
+	TOTPEnabled            bool    `json:"totp_enabled"`
+	SMSEnabled             bool    `json:"sms_enabled"`
+	RecoveryCodesRemaining int     `json:"recovery_codes_remaining"`
+	PhoneNumberMasked      *string `json:"phone_number_masked,omitempty"`
+}
+
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	// QRCodeURI is the payload clients render as a QR code for the app to scan.
+	QRCodeURI  string `json:"qr_code_uri"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFAEnabled struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func NewMFAStatus(s *auth.MFAStatus) MFAStatus {
+	res := MFAStatus{
+		MFAEnabled:             s.Enabled(),
+		TOTPEnabled:            s.TOTPEnabled,
+		SMSEnabled:             s.SMSEnabled,
+		RecoveryCodesRemaining: s.RecoveryCodesRemaining,
+	}
+
+	if s.PhoneNumber != "" {
+		masked := maskPhone(s.PhoneNumber)
+		res.PhoneNumberMasked = &masked
+	}
+
+	return res
+}
+
+func NewTOTPSetup(s *auth.TOTPSetup) TOTPSetup {
+	return TOTPSetup{
+		Secret:     s.Secret,
+		QRCodeURI:  s.URI,
+		OTPAuthURI: s.URI,
+	}
+}
+
+// maskPhone keeps the country prefix and last three digits, e.g. +1***234.
+func maskPhone(p string) string {
+	const keepHead, keepTail = 2, 3
+
+	if len(p) <= keepHead+keepTail {
+		return p
+	}
+
+	return p[:keepHead] + "***" + p[len(p)-keepTail:]
+}
@@ -0,0 +1,39 @@
+package response
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+)
+
+type OAuthConnection struct {
+	Provider       string    `json:"provider"`
+	ProviderUserID string    `json:"provider_user_id"`
+	Email          *string   `json:"email,omitempty"`
+	Name           *string   `json:"name,omitempty"`
+	AvatarURL      *string   `json:"avatar_url,omitempty"`
+	ConnectedAt    time.Time `json:"connected_at"`
+}
+
+type OAuthConnectionList struct {
+	Connections []OAuthConnection `json:"connections"`
+}
+
+func NewOAuthConnectionList(connections []auth.OAuthConnection) OAuthConnectionList {
+	list := OAuthConnectionList{Connections: make([]OAuthConnection, 0, len(connections))}
+
+	for i := range connections {
+		c := &connections[i]
+
+		list.Connections = append(list.Connections, OAuthConnection{
+			Provider:       string(c.Provider),
+			ProviderUserID: c.ProviderUserID,
+			Email:          c.ProviderEmail,
+			Name:           c.ProviderName,
+			AvatarURL:      c.ProviderAvatarURL,
+			ConnectedAt:    c.ConnectedAt,
+		})
+	}
+
+	return list
+}
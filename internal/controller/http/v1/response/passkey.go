@@ -0,0 +1,154 @@
+package response
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/google/uuid"
+)
+
+type Passkey struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	CredentialID string     `json:"credential_id"`
+	AAGUID       *string    `json:"aaguid,omitempty"`
+	DeviceType   *string    `json:"device_type,omitempty"`
+	BackedUp     bool       `json:"backed_up"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+}
+
+type PasskeyList struct {
+	Passkeys []Passkey `json:"passkeys"`
+}
+
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type PasskeyUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type PasskeyCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey      string `json:"resident_key"`
+	UserVerification string `json:"user_verification"`
+}
+
+type PasskeyDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type PasskeyRegistrationOptions struct {
+	Challenge              string                        `json:"challenge"`
+	RP                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUser                   `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParam      `json:"pub_key_cred_params"`
+	Timeout                int64                         `json:"timeout"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticator_selection"`
+	Attestation            string                        `json:"attestation"`
+	ExcludeCredentials     []PasskeyDescriptor           `json:"exclude_credentials"`
+}
+
+type PasskeyAuthenticationOptions struct {
+	Challenge        string              `json:"challenge"`
+	Timeout          int64               `json:"timeout"`
+	RPID             string              `json:"rp_id"`
+	AllowCredentials []PasskeyDescriptor `json:"allow_credentials"`
+	UserVerification string              `json:"user_verification"`
+}
+
+func NewPasskey(p *auth.Passkey) Passkey {
+	res := Passkey{
+		ID:           p.ID,
+		Name:         p.Name,
+		CredentialID: base64.RawURLEncoding.EncodeToString(p.CredentialID),
+		BackedUp:     p.BackedUp,
+		CreatedAt:    p.CreatedAt,
+		LastUsedAt:   p.LastUsedAt,
+	}
+
+	// Authenticators that do not disclose their model report an all-zero AAGUID.
+	if id, err := uuid.FromBytes(p.AAGUID); err == nil && id != uuid.Nil {
+		aaguid := id.String()
+		res.AAGUID = &aaguid
+	}
+
+	if p.DeviceType != nil {
+		deviceType := string(*p.DeviceType)
+		res.DeviceType = &deviceType
+	}
+
+	return res
+}
+
+func NewPasskeyList(passkeys []auth.Passkey) PasskeyList {
+	list := PasskeyList{Passkeys: make([]Passkey, 0, len(passkeys))}
+
+	for i := range passkeys {
+		list.Passkeys = append(list.Passkeys, NewPasskey(&passkeys[i]))
+	}
+
+	return list
+}
+
+func NewPasskeyRegistrationOptions(o *auth.PasskeyCreationOptions) PasskeyRegistrationOptions {
+	params := make([]PasskeyCredentialParam, len(o.Algorithms))
+	for i, alg := range o.Algorithms {
+		params[i] = PasskeyCredentialParam{Type: publicKeyCredentialType, Alg: alg}
+	}
+
+	return PasskeyRegistrationOptions{
+		Challenge: base64.RawURLEncoding.EncodeToString(o.Challenge),
+		RP:        PasskeyRelyingParty{ID: o.RPID, Name: o.RPName},
+		User: PasskeyUser{
+			ID:          base64.RawURLEncoding.EncodeToString(o.UserHandle),
+			Name:        o.UserName,
+			DisplayName: o.UserDisplayName,
+		},
+		PubKeyCredParams: params,
+		Timeout:          o.Timeout.Milliseconds(),
+		AuthenticatorSelection: PasskeyAuthenticatorSelection{
+			ResidentKey:      o.ResidentKey,
+			UserVerification: o.UserVerification,
+		},
+		Attestation:        o.Attestation,
+		ExcludeCredentials: newPasskeyDescriptors(o.Exclude),
+	}
+}
+
+func NewPasskeyAuthenticationOptions(o *auth.PasskeyRequestOptions) PasskeyAuthenticationOptions {
+	return PasskeyAuthenticationOptions{
+		Challenge:        base64.RawURLEncoding.EncodeToString(o.Challenge),
+		Timeout:          o.Timeout.Milliseconds(),
+		RPID:             o.RPID,
+		AllowCredentials: newPasskeyDescriptors(o.Allow),
+		UserVerification: o.UserVerification,
+	}
+}
+
+const publicKeyCredentialType = "public-key"
+
+func newPasskeyDescriptors(descriptors []auth.PasskeyDescriptor) []PasskeyDescriptor {
+	res := make([]PasskeyDescriptor, len(descriptors))
+	for i, d := range descriptors {
+		res[i] = PasskeyDescriptor{
+			Type:       publicKeyCredentialType,
+			ID:         base64.RawURLEncoding.EncodeToString(d.ID),
+			Transports: d.Transports,
+		}
+	}
+
+	return res
+}
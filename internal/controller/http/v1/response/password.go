@@ -0,0 +1,17 @@
+package response
+
+import "github.com/evrone/go-clean-template/pkg/password"
+
+type PasswordValidation struct {
+	Valid    bool     `json:"valid"`
+	Score    int      `json:"score"`
+	Feedback []string `json:"feedback,omitempty"`
+}
+
+func NewPasswordValidation(r password.Result) PasswordValidation {
+	return PasswordValidation{
+		Valid:    r.Valid,
+		Score:    r.Score,
+		Feedback: r.Feedback,
+	}
+}
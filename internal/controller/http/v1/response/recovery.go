@@ -0,0 +1,25 @@
+package response
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+)
+
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
+
+type RecoveryCodeStatus struct {
+	Total       int        `json:"total"`
+	Remaining   int        `json:"remaining"`
+	GeneratedAt *time.Time `json:"generated_at,omitempty"`
+}
+
+func NewRecoveryCodeStatus(s *auth.RecoveryCodeStatus) RecoveryCodeStatus {
+	return RecoveryCodeStatus{
+		Total:       s.Total,
+		Remaining:   s.Remaining,
+		GeneratedAt: s.GeneratedAt,
+	}
+}
@@ -0,0 +1,92 @@
+package response
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/google/uuid"
+)
+
+type SecurityEvent struct {
+	ID        uuid.UUID              `json:"id"`
+	EventType auth.SecurityEventType `json:"event_type"`
+	Timestamp time.Time              `json:"timestamp"`
+	IPAddress *string                `json:"ip_address,omitempty"`
+	UserAgent *string                `json:"user_agent,omitempty"`
+	Location  *SecurityEventLocation `json:"location,omitempty"`
+	Device    *SecurityEventDevice   `json:"device,omitempty"`
+	Details   map[string]any         `json:"details,omitempty"`
+	Success   bool                   `json:"success"`
+	RiskLevel auth.RiskLevel         `json:"risk_level"`
+}
+
+type SecurityEventLocation struct {
+	City        *string `json:"city,omitempty"`
+	Region      *string `json:"region,omitempty"`
+	Country     *string `json:"country,omitempty"`
+	CountryCode *string `json:"country_code,omitempty"`
+}
+
+type SecurityEventDevice struct {
+	Type    *string `json:"type,omitempty"`
+	OS      *string `json:"os,omitempty"`
+	Browser *string `json:"browser,omitempty"`
+}
+
+type SecurityEventList struct {
+	Events     []SecurityEvent `json:"events"`
+	Pagination Pagination      `json:"pagination"`
+}
+
+type Pagination struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+func NewSecurityEvent(e *auth.SecurityEvent) SecurityEvent {
+	res := SecurityEvent{
+		ID:        e.ID,
+		EventType: e.Type,
+		Timestamp: e.CreatedAt,
+		IPAddress: e.IPAddress,
+		UserAgent: e.UserAgent,
+		Details:   e.Details,
+		Success:   e.Success,
+		RiskLevel: e.RiskLevel,
+	}
+
+	if e.LocationCity != nil || e.LocationRegion != nil || e.LocationCountry != nil || e.LocationCountryCode != nil {
+		res.Location = &SecurityEventLocation{
+			City:        e.LocationCity,
+			Region:      e.LocationRegion,
+			Country:     e.LocationCountry,
+			CountryCode: e.LocationCountryCode,
+		}
+	}
+
+	if e.DeviceType != nil || e.DeviceOS != nil || e.DeviceBrowser != nil {
+		res.Device = &SecurityEventDevice{Type: e.DeviceType, OS: e.DeviceOS, Browser: e.DeviceBrowser}
+	}
+
+	return res
+}
+
+func NewSecurityEventList(l *auth.SecurityEventList) SecurityEventList {
+	res := SecurityEventList{
+		Events: make([]SecurityEvent, 0, len(l.Events)),
+		Pagination: Pagination{
+			Page:       l.Page,
+			Limit:      l.Limit,
+			Total:      l.Total,
+			TotalPages: (l.Total + l.Limit - 1) / l.Limit,
+		},
+	}
+
+	for i := range l.Events {
+		res.Events = append(res.Events, NewSecurityEvent(&l.Events[i]))
+	}
+
+	return res
+}
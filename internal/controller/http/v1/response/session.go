@@ -0,0 +1,42 @@
+package response
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/google/uuid"
+)
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	UserAgent    *string   `json:"user_agent,omitempty"`
+	IPAddress    *string   `json:"ip_address,omitempty"`
+	DeviceType   string    `json:"device_type"`
+	IsCurrent    bool      `json:"is_current"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+type SessionList struct {
+	Sessions []Session `json:"sessions"`
+}
+
+func NewSessionList(sessions []auth.Session) SessionList {
+	list := SessionList{Sessions: make([]Session, 0, len(sessions))}
+
+	for i := range sessions {
+		s := &sessions[i]
+
+		list.Sessions = append(list.Sessions, Session{
+			ID:           s.ID,
+			UserAgent:    s.UserAgent,
+			IPAddress:    s.IPAddress,
+			DeviceType:   string(s.DeviceType()),
+			IsCurrent:    s.IsCurrent,
+			CreatedAt:    s.CreatedAt,
+			LastActiveAt: s.LastActiveAt,
+		})
+	}
+
+	return list
+}
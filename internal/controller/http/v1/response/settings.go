@@ -0,0 +1,108 @@
+package response
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+)
+
+// AuthConfig mirrors auth.Settings with lifetimes and windows in seconds.
+type AuthConfig struct {
+	PasswordRequirements PasswordRequirements `json:"password_requirements"`
+	TokenExpiration      TokenExpiration      `json:"token_expiration"`
+	RateLimits           RateLimits           `json:"rate_limits"`
+	RecoveryCodes        RecoveryCodeFormat   `json:"recovery_codes"`
+}
+
+type PasswordRequirements struct {
+	MinLength        int  `json:"min_length"`
+	MaxLength        int  `json:"max_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireNumber    bool `json:"require_number"`
+	RequireSpecial   bool `json:"require_special"`
+}
+
+type TokenExpiration struct {
+	AccessTokenSeconds          int64 `json:"access_token_seconds"`
+	RefreshTokenSeconds         int64 `json:"refresh_token_seconds"`
+	RefreshTokenRememberSeconds int64 `json:"refresh_token_remember_seconds"`
+	PasswordResetSeconds        int64 `json:"password_reset_seconds"`
+	EmailVerificationSeconds    int64 `json:"email_verification_seconds"`
+	MFACodeSeconds              int64 `json:"mfa_code_seconds"`
+	RecoverySessionSeconds      int64 `json:"recovery_session_seconds"`
+}
+
+type RateLimits struct {
+	LoginAttempts         LoginAttemptLimit `json:"login_attempts"`
+	PasswordResetRequests RequestLimit      `json:"password_reset_requests"`
+	MFAAttempts           AttemptLimit      `json:"mfa_attempts"`
+}
+
+type LoginAttemptLimit struct {
+	MaxAttempts       int   `json:"max_attempts"`
+	WindowSeconds     int64 `json:"window_seconds"`
+	LockoutSeconds    int64 `json:"lockout_seconds"`
+	MaxLockoutSeconds int64 `json:"max_lockout_seconds"`
+}
+
+type RequestLimit struct {
+	MaxRequests   int   `json:"max_requests"`
+	WindowSeconds int64 `json:"window_seconds"`
+}
+
+type AttemptLimit struct {
+	MaxAttempts   int   `json:"max_attempts"`
+	WindowSeconds int64 `json:"window_seconds"`
+}
+
+type RecoveryCodeFormat struct {
+	Count  int    `json:"count"`
+	Format string `json:"format"`
+}
+
+func NewAuthConfig(s *auth.Settings) AuthConfig {
+	return AuthConfig{
+		PasswordRequirements: PasswordRequirements{
+			MinLength:        s.PasswordMinLength,
+			MaxLength:        s.PasswordMaxLength,
+			RequireUppercase: s.PasswordRequireUppercase,
+			RequireLowercase: s.PasswordRequireLowercase,
+			RequireNumber:    s.PasswordRequireNumber,
+			RequireSpecial:   s.PasswordRequireSpecial,
+		},
+		TokenExpiration: TokenExpiration{
+			AccessTokenSeconds:          seconds(s.AccessTokenTTL),
+			RefreshTokenSeconds:         seconds(s.RefreshTokenTTL),
+			RefreshTokenRememberSeconds: seconds(s.RememberMeTTL),
+			PasswordResetSeconds:        seconds(s.PasswordResetTTL),
+			EmailVerificationSeconds:    seconds(s.EmailVerificationTTL),
+			MFACodeSeconds:              seconds(s.MFACodeTTL),
+			RecoverySessionSeconds:      seconds(s.RecoverySessionTTL),
+		},
+		RateLimits: RateLimits{
+			LoginAttempts: LoginAttemptLimit{
+				MaxAttempts:       s.LoginMaxAttempts,
+				WindowSeconds:     seconds(s.LoginAttemptWindow),
+				LockoutSeconds:    seconds(s.LoginLockout),
+				MaxLockoutSeconds: seconds(s.LoginMaxLockout),
+			},
+			PasswordResetRequests: RequestLimit{
+				MaxRequests:   s.PasswordResetMaxRequests,
+				WindowSeconds: seconds(s.PasswordResetWindow),
+			},
+			MFAAttempts: AttemptLimit{
+				MaxAttempts:   s.MFAMaxAttempts,
+				WindowSeconds: seconds(s.MFAAttemptWindow),
+			},
+		},
+		RecoveryCodes: RecoveryCodeFormat{
+			Count:  s.RecoveryCodeCount,
+			Format: s.RecoveryCodeFormat,
+		},
+	}
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
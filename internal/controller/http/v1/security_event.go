@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type securityEventRoutes struct {
+	s usecase.SecurityEvents
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewSecurityEventRoutes(apiV1Group fiber.Router, s usecase.SecurityEvents, requireAuth fiber.Handler, l logger.Interface) {
+	r := &securityEventRoutes{s: s, l: l, v: newValidator()}
+
+	eventGroup := apiV1Group.Group("/auth/security/events")
+	{
+		eventGroup.Get("", requireAuth, r.list)
+		eventGroup.Get("/:event_id", requireAuth, r.get)
+	}
+}
+
+func (r *securityEventRoutes) list(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	var query request.SecurityEventQuery
+	if err = parseQuery(ctx, r.v, &query); err != nil {
+		return r.error(ctx, err)
+	}
+
+	events, err := r.s.SecurityEvents(ctx.UserContext(), auth.SecurityEventListInput{
+		UserID: claims.UserID,
+		Filter: auth.SecurityEventFilter{
+			Type: auth.SecurityEventType(query.EventType),
+			From: parseTime(query.From),
+			To:   parseTime(query.To),
+		},
+		Page:  query.Page,
+		Limit: query.Limit,
+	})
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewSecurityEventList(events))
+}
+
+func (r *securityEventRoutes) get(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	id, err := uuid.Parse(ctx.Params("event_id"))
+	if err != nil {
+		return r.error(ctx, apperror.Validation("Invalid event ID", apperror.WithField("event_id", "must be a valid UUID")))
+	}
+
+	e, err := r.s.SecurityEvent(ctx.UserContext(), claims.UserID, id)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewSecurityEvent(e))
+}
+
+func (r *securityEventRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - security - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
+
+// parseTime reads an RFC 3339 time the validator has already accepted; an
+// empty value means no bound.
+func parseTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type sessionRoutes struct {
+	s usecase.Sessions
+	l logger.Interface
+}
+
+func NewSessionRoutes(apiV1Group fiber.Router, s usecase.Sessions, requireAuth fiber.Handler, l logger.Interface) {
+	r := &sessionRoutes{s: s, l: l}
+
+	sessionGroup := apiV1Group.Group("/auth/sessions", requireAuth)
+	{
+		sessionGroup.Get("", r.list)
+		sessionGroup.Post("/revoke-all", r.revokeAll)
+		sessionGroup.Delete("/:session_id", r.revoke)
+	}
+}
+
+func (r *sessionRoutes) list(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	sessions, err := r.s.ListSessions(ctx.UserContext(), claims.UserID, claims.SessionID)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.NewSessionList(sessions))
+}
+
+func (r *sessionRoutes) revoke(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	sessionID, err := uuid.Parse(ctx.Params("session_id"))
+	if err != nil {
+		return r.error(ctx, apperror.Validation("Invalid session ID", apperror.WithField("session_id", "must be a valid UUID")))
+	}
+
+	if err = r.s.RevokeSession(ctx.UserContext(), claims.UserID, sessionID, clientInfo(ctx)); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (r *sessionRoutes) revokeAll(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	revoked, err := r.s.RevokeOtherSessions(ctx.UserContext(), claims.UserID, claims.SessionID, clientInfo(ctx))
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{
+		Message: fmt.Sprintf("%d other session(s) revoked", revoked),
+	})
+}
+
+func (r *sessionRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - session - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
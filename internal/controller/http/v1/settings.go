@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+// settingsCacheControl lets clients reuse the settings for an hour; they
+// only change on a redeploy.
+const settingsCacheControl = "public, max-age=3600"
+
+type settingsRoutes struct {
+	s usecase.Settings
+}
+
+func NewSettingsRoutes(apiV1Group fiber.Router, s usecase.Settings) {
+	r := &settingsRoutes{s: s}
+
+	apiV1Group.Get("/auth/config", r.config)
+}
+
+func (r *settingsRoutes) config(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, settingsCacheControl)
+
+	return ctx.Status(http.StatusOK).JSON(response.NewAuthConfig(r.s.Settings(ctx.UserContext())))
+}
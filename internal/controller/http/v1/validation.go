@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	return v
+}
+
+// parseBody decodes the request body into req and validates it.
+func parseBody(ctx *fiber.Ctx, v *validator.Validate, req any) error {
+	if err := ctx.BodyParser(req); err != nil {
+		return apperror.Validation("Invalid request body", apperror.WithCode("INVALID_REQUEST"), apperror.WithCause(err))
+	}
+
+	if err := v.Struct(req); err != nil {
+		return validationError(err)
+	}
+
+	return nil
+}
+
+func parseQuery(ctx *fiber.Ctx, v *validator.Validate, req any) error {
+	if err := ctx.QueryParser(req); err != nil {
+		return apperror.Validation("Invalid query parameters", apperror.WithCode("INVALID_REQUEST"), apperror.WithCause(err))
+	}
+
+	if err := v.Struct(req); err != nil {
+		return validationError(err)
+	}
+
+	return nil
+}
+
+func validationError(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return apperror.Validation("Invalid request parameters", apperror.WithCause(err))
+	}
+
+	fields := make(map[string]string, len(verrs))
+
+	for _, fe := range verrs {
+		fields[fe.Field()] = fieldMessage(fe)
+	}
+
+	return apperror.Validation("Invalid request parameters", apperror.WithFields(fields), apperror.WithCause(err))
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param() + " characters"
+	case "max":
+		return "must be at most " + fe.Param() + " characters"
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	}
+
+	return "is invalid"
+}
@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/request"
+	"github.com/evrone/go-clean-template/internal/controller/http/v1/response"
+	"github.com/evrone/go-clean-template/internal/usecase"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type verificationRoutes struct {
+	e usecase.EmailVerification
+	l logger.Interface
+	v *validator.Validate
+}
+
+func NewVerificationRoutes(apiV1Group fiber.Router, e usecase.EmailVerification, requireAuth fiber.Handler, l logger.Interface) {
+	r := &verificationRoutes{e: e, l: l, v: newValidator()}
+
+	emailGroup := apiV1Group.Group("/auth/email")
+	{
+		emailGroup.Post("/verify", r.verify)
+		emailGroup.Post("/resend-verification", requireAuth, r.resend)
+	}
+}
+
+func (r *verificationRoutes) verify(ctx *fiber.Ctx) error {
+	var body request.VerifyEmail
+	if err := parseBody(ctx, r.v, &body); err != nil {
+		return r.error(ctx, err)
+	}
+
+	if err := r.e.VerifyEmail(ctx.UserContext(), body.Token); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.Message{Message: "Email verified"})
+}
+
+func (r *verificationRoutes) resend(ctx *fiber.Ctx) error {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return r.error(ctx, err)
+	}
+
+	if err = r.e.ResendVerification(ctx.UserContext(), claims.UserID); err != nil {
+		return r.error(ctx, err)
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(response.Message{Message: "Verification email sent"})
+}
+
+func (r *verificationRoutes) error(ctx *fiber.Ctx, err error) error {
+	if kind := apperror.GetKind(err); kind == apperror.KindUnknown || kind == apperror.KindInternal {
+		r.l.Error(fmt.Errorf("http - v1 - verification - %s: %w", ctx.Path(), err))
+	}
+
+	return ErrorResponse(ctx, err)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/event"
+	"github.com/google/uuid"
+)
+
+// OutboxAccountDeleted is published once a deleted account has been purged.
+const OutboxAccountDeleted = "account.deleted"
+
+// AccountDeletion is a request to delete the user's account, confirmed with a
+// single-use token mailed to them. Confirming it only marks the account
+// deleted; it is purged once the grace period is over.
+type AccountDeletion struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	TokenHash   string
+	Feedback    *string
+	ExpiresAt   time.Time
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// IsExpired reports whether the token can no longer be used at the given time.
+func (d *AccountDeletion) IsExpired(now time.Time) bool {
+	return !d.ExpiresAt.After(now)
+}
+
+// AccountDeleted tells downstream services to forget the user. It carries no
+// personal data beyond the ID, which no longer resolves to an account.
+type AccountDeleted struct {
+	event.Base
+	UserID    uuid.UUID `json:"user_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgedAt  time.Time `json:"purged_at"`
+}
+
+func NewAccountDeleted(userID uuid.UUID, deletedAt, purgedAt time.Time) *AccountDeleted {
+	return &AccountDeleted{
+		Base:      event.NewBase(OutboxAccountDeleted, outboxAggregateUser, userID.String()),
+		UserID:    userID,
+		DeletedAt: deletedAt,
+		PurgedAt:  purgedAt,
+	}
+}
+
+func (e *AccountDeleted) Payload() any { return e }
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryMethod is the channel an account recovery code is delivered over.
+type RecoveryMethod string
+
+const (
+	RecoveryEmail RecoveryMethod = "email"
+	RecoverySMS   RecoveryMethod = "sms"
+)
+
+// RecoveryActionResetMFA removes every second factor from the account.
+const RecoveryActionResetMFA = "reset_mfa"
+
+// RecoverySession is a self-service recovery for a user who lost their second
+// factor. Entering the code sent to their email or phone swaps the OTP for a
+// recovery token, which can reset MFA between AvailableAt and ExpiresAt.
+// UserID is empty when no account matched the identifier.
+type RecoverySession struct {
+	ID                uuid.UUID
+	UserID            *uuid.UUID
+	Identifier        string
+	IdentifierType    IdentifierType
+	Method            RecoveryMethod
+	OTPHash           *string
+	RecoveryTokenHash *string
+	Attempts          int
+	SentAt            time.Time
+	OTPVerifiedAt     *time.Time
+	AvailableAt       *time.Time
+	ExpiresAt         time.Time
+	CompletedAt       *time.Time
+	CanceledAt        *time.Time
+	IPAddress         *string
+	UserAgent         *string
+	CreatedAt         time.Time
+}
+
+// IsExpired reports whether the session can no longer be used at the given time.
+func (s *RecoverySession) IsExpired(now time.Time) bool {
+	return !s.ExpiresAt.After(now)
+}
+
+// IsOpen reports whether the session has been neither completed nor canceled.
+func (s *RecoverySession) IsOpen() bool {
+	return s.CompletedAt == nil && s.CanceledAt == nil
+}
+
+// IsVerified reports whether the code was entered and a recovery token issued.
+func (s *RecoverySession) IsVerified() bool {
+	return s.OTPVerifiedAt != nil
+}
+
+// RecoveryStarted describes a recovery that was requested. Destination is
+// only set when it is the identifier itself, so the response never reveals
+// an account's other contact details or whether it exists.
+type RecoveryStarted struct {
+	SessionID   uuid.UUID
+	Method      RecoveryMethod
+	Destination string
+	ExpiresAt   time.Time
+}
+
+// RecoveryVerified carries the recovery token issued for a verified session.
+// The token only works from AvailableAt, after the cooling-off period.
+type RecoveryVerified struct {
+	Token       string
+	Actions     []string
+	AvailableAt time.Time
+	ExpiresAt   time.Time
+	Email       string
+	MFA         *MFAStatus
+}
@@ -0,0 +1,18 @@
+package auth
+
+// UnavailableReason says why an email address or phone number can't be used
+// to register.
+type UnavailableReason string
+
+const (
+	UnavailableTaken      UnavailableReason = "taken"
+	UnavailableDisposable UnavailableReason = "disposable"
+)
+
+// Availability answers whether Value, the normalized email address or phone
+// number, can be used to register; Reason is set when it can't.
+type Availability struct {
+	Value     string
+	Available bool
+	Reason    UnavailableReason
+}
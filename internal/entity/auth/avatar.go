@@ -0,0 +1,9 @@
+package auth
+
+import "io"
+
+// AvatarImage is a stored avatar being served. The caller must close Body.
+type AvatarImage struct {
+	ContentType string
+	Body        io.ReadCloser
+}
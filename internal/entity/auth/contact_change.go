@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/event"
+	"github.com/google/uuid"
+)
+
+// Outbox event types published when a user's contact details change.
+const (
+	OutboxEmailChanged = "user.email_changed"
+	OutboxPhoneChanged = "user.phone_changed"
+
+	outboxAggregateUser = "user"
+)
+
+// EmailChange is a pending switch of the user's email to NewEmail, confirmed
+// with a single-use token mailed to that address. A change that undoes an
+// earlier one has RevertsID set; its token goes to the address being
+// restored, as the "wasn't me" link.
+type EmailChange struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	NewEmail    string
+	TokenHash   string
+	RevertsID   *uuid.UUID
+	ExpiresAt   time.Time
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// IsExpired reports whether the token can no longer be used at the given time.
+func (c *EmailChange) IsExpired(now time.Time) bool {
+	return !c.ExpiresAt.After(now)
+}
+
+// IsRevert reports whether confirming the change restores a previous address.
+func (c *EmailChange) IsRevert() bool {
+	return c.RevertsID != nil
+}
+
+// PhoneChange is a pending switch of the user's phone number to
+// NewPhoneNumber, confirmed with a code texted to that number.
+type PhoneChange struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	NewPhoneNumber string
+	OTPHash        string
+	Attempts       int
+	ExpiresAt      time.Time
+	ConfirmedAt    *time.Time
+	CreatedAt      time.Time
+}
+
+// IsExpired reports whether the code can no longer be used at the given time.
+func (c *PhoneChange) IsExpired(now time.Time) bool {
+	return !c.ExpiresAt.After(now)
+}
+
+// EmailChanged is published once a new email address takes effect.
+type EmailChanged struct {
+	event.Base
+	UserID   uuid.UUID `json:"user_id"`
+	OldEmail string    `json:"old_email"`
+	NewEmail string    `json:"new_email"`
+	Reverted bool      `json:"reverted"`
+}
+
+func NewEmailChanged(userID uuid.UUID, oldEmail, newEmail string, reverted bool) *EmailChanged {
+	return &EmailChanged{
+		Base:     event.NewBase(OutboxEmailChanged, outboxAggregateUser, userID.String()),
+		UserID:   userID,
+		OldEmail: oldEmail,
+		NewEmail: newEmail,
+		Reverted: reverted,
+	}
+}
+
+func (e *EmailChanged) Payload() any { return e }
+
+// PhoneChanged is published when a phone number is changed or removed; a
+// removal has an empty NewPhoneNumber.
+type PhoneChanged struct {
+	event.Base
+	UserID         uuid.UUID `json:"user_id"`
+	OldPhoneNumber string    `json:"old_phone_number,omitempty"`
+	NewPhoneNumber string    `json:"new_phone_number,omitempty"`
+}
+
+func NewPhoneChanged(userID uuid.UUID, oldPhone, newPhone string) *PhoneChanged {
+	return &PhoneChanged{
+		Base:           event.NewBase(OutboxPhoneChanged, outboxAggregateUser, userID.String()),
+		UserID:         userID,
+		OldPhoneNumber: oldPhone,
+		NewPhoneNumber: newPhone,
+	}
+}
+
+func (e *PhoneChanged) Payload() any { return e }
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready"
+	DataExportFailed     DataExportStatus = "failed"
+	DataExportExpired    DataExportStatus = "expired"
+)
+
+// DataExport is a user's request for a copy of their personal data. A
+// background job builds the archive, stores it under ObjectKey and mails a
+// download link whose token is hashed into TokenHash; the archive is deleted
+// once ExpiresAt passes.
+type DataExport struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      DataExportStatus
+	ObjectKey   *string
+	Size        int64
+	TokenHash   *string
+	Error       *string
+	ExpiresAt   *time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// IsExpired reports whether the archive can no longer be downloaded at the
+// given time.
+func (e *DataExport) IsExpired(now time.Time) bool {
+	return e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+}
+
+// DataExportTable holds one table's rows for a user as a JSON array.
+type DataExportTable struct {
+	Name string
+	Rows json.RawMessage
+}
+
+// DataExportArchive is a finished export being downloaded. The caller must
+// close Body.
+type DataExportArchive struct {
+	FileName string
+	Size     int64
+	Body     io.ReadCloser
+}
@@ -0,0 +1,56 @@
+package auth
+
+import "errors"
+
+var (
+	ErrUserNotFound         = errors.New("user not found")
+	ErrEmailAlreadyExists   = errors.New("email already exists")
+	ErrPhoneAlreadyExists   = errors.New("phone number already exists")
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenReused   = errors.New("refresh token already used")
+
+	ErrVerificationNotFound = errors.New("email verification not found")
+	ErrVerificationUsed     = errors.New("email verification already used")
+
+	ErrPasswordResetNotFound = errors.New("password reset not found")
+	ErrPasswordResetUsed     = errors.New("password reset already used")
+
+	ErrTOTPNotFound       = errors.New("totp enrollment not found")
+	ErrTOTPAlreadyEnabled = errors.New("totp already enabled")
+	ErrTOTPCodeReused     = errors.New("totp code already used")
+
+	ErrSMSFactorNotFound       = errors.New("sms factor not found")
+	ErrSMSFactorAlreadyEnabled = errors.New("sms factor already enabled")
+	ErrSMSCodeInvalid          = errors.New("sms code invalid")
+
+	ErrRecoveryCodeInvalid = errors.New("recovery code invalid")
+
+	ErrOAuthConnectionNotFound = errors.New("oauth connection not found")
+	ErrOAuthConnectionExists   = errors.New("oauth connection already exists")
+
+	ErrPasskeyNotFound           = errors.New("passkey not found")
+	ErrPasskeyExists             = errors.New("passkey already registered")
+	ErrWebAuthnChallengeNotFound = errors.New("webauthn challenge not found")
+
+	ErrMagicLinkNotFound = errors.New("magic link session not found")
+	ErrMagicLinkUsed     = errors.New("magic link session already used")
+
+	ErrRecoverySessionNotFound = errors.New("recovery session not found")
+	ErrRecoverySessionUsed     = errors.New("recovery session already used")
+
+	ErrEmailChangeNotFound = errors.New("email change not found")
+	ErrEmailChangeUsed     = errors.New("email change already confirmed")
+	ErrPhoneChangeNotFound = errors.New("phone change not found")
+	ErrPhoneChangeUsed     = errors.New("phone change already confirmed")
+
+	ErrAccountDeletionNotFound = errors.New("account deletion not found")
+	ErrAccountDeletionUsed     = errors.New("account deletion already confirmed")
+
+	ErrDataExportNotFound   = errors.New("data export not found")
+	ErrDataExportInProgress = errors.New("data export already in progress")
+
+	ErrSecurityEventNotFound = errors.New("security event not found")
+
+	ErrMFAChallengeNotFound  = errors.New("mfa challenge not found")
+	ErrMFAChallengeCompleted = errors.New("mfa challenge already completed")
+)
@@ -0,0 +1,240 @@
+package auth
+
+import "github.com/google/uuid"
+
+type RegisterInput struct {
+	Email    string
+	Password string
+	Name     string
+	Client   ClientInfo
+}
+
+type LoginInput struct {
+	Email      string
+	Password   string
+	RememberMe bool
+	Client     ClientInfo
+}
+
+type LogoutInput struct {
+	UserID       uuid.UUID
+	SessionID    uuid.UUID
+	RefreshToken string
+	AllSessions  bool
+	Client       ClientInfo
+}
+
+type RefreshInput struct {
+	RefreshToken string
+	Client       ClientInfo
+}
+
+type ResetPasswordInput struct {
+	Token    string
+	Password string
+	Client   ClientInfo
+}
+
+type ChangePasswordInput struct {
+	UserID          uuid.UUID
+	SessionID       uuid.UUID
+	CurrentPassword string
+	NewPassword     string
+	Client          ClientInfo
+}
+
+type MFAChallengeInput struct {
+	ChallengeToken string
+	Code           string
+	Method         MFAMethod
+	Client         ClientInfo
+}
+
+type RecoveryCodeLoginInput struct {
+	Email  string
+	Code   string
+	Client ClientInfo
+}
+
+type OAuthAuthorizeInput struct {
+	Provider    OAuthProvider
+	RedirectURI string
+	State       string
+}
+
+type OAuthCallbackInput struct {
+	Provider    OAuthProvider
+	Code        string
+	State       string
+	RedirectURI string
+	Client      ClientInfo
+}
+
+type PasskeyRegistrationInput struct {
+	UserID            uuid.UUID
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AttestationObject []byte
+	Transports        []string
+	Name              string
+	Client            ClientInfo
+}
+
+// PasskeyLoginStartInput starts a passkey sign-in. With ChallengeToken the
+// passkey completes that pending MFA challenge; otherwise it is the first
+// factor.
+type PasskeyLoginStartInput struct {
+	ChallengeToken string
+}
+
+type PasskeyLoginInput struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+	ChallengeToken    string
+	RememberMe        bool
+	Client            ClientInfo
+}
+
+type MagicLinkSendInput struct {
+	Identifier string
+	Method     MagicLinkMethod
+	Client     ClientInfo
+}
+
+// MagicLinkVerifyInput completes a passwordless sign-in with either the
+// emailed link's token or the one-time code.
+type MagicLinkVerifyInput struct {
+	SessionID  uuid.UUID
+	Token      string
+	Code       string
+	RememberMe bool
+	Client     ClientInfo
+}
+
+type MagicLinkResendInput struct {
+	SessionID uuid.UUID
+	Method    MagicLinkMethod
+	Client    ClientInfo
+}
+
+type RecoveryStartInput struct {
+	Identifier string
+	Method     RecoveryMethod
+	Client     ClientInfo
+}
+
+// RecoveryVerifyInput proves control of the email or phone a recovery code
+// was sent to.
+type RecoveryVerifyInput struct {
+	SessionID uuid.UUID
+	Code      string
+	Client    ClientInfo
+}
+
+// RecoveryResetMFAInput spends a recovery token to remove the account's
+// second factors, optionally setting a new password at the same time.
+type RecoveryResetMFAInput struct {
+	Token       string
+	NewPassword string
+	Client      ClientInfo
+}
+
+type RecoveryResendInput struct {
+	SessionID uuid.UUID
+	Client    ClientInfo
+}
+
+// EmailChangeInput starts a switch to NewEmail; Password re-authenticates
+// the caller.
+type EmailChangeInput struct {
+	UserID   uuid.UUID
+	NewEmail string
+	Password string
+	Client   ClientInfo
+}
+
+// PhoneChangeInput starts a switch to NewPhoneNumber; Password
+// re-authenticates the caller.
+type PhoneChangeInput struct {
+	UserID         uuid.UUID
+	NewPhoneNumber string
+	Password       string
+	Client         ClientInfo
+}
+
+type PhoneChangeConfirmInput struct {
+	UserID uuid.UUID
+	Code   string
+	Client ClientInfo
+}
+
+// AccountDeletionInput asks to delete the account; Password re-authenticates
+// the caller.
+type AccountDeletionInput struct {
+	UserID   uuid.UUID
+	Password string
+	Client   ClientInfo
+}
+
+// AccountDeletionConfirmInput confirms a deletion with the emailed Token;
+// Feedback optionally says why the user is leaving.
+type AccountDeletionConfirmInput struct {
+	Token    string
+	Feedback string
+	Client   ClientInfo
+}
+
+// DataExportInput asks for a copy of the user's personal data.
+type DataExportInput struct {
+	UserID uuid.UUID
+	Client ClientInfo
+}
+
+// DataExportDownloadInput downloads an export with the emailed Token.
+type DataExportDownloadInput struct {
+	Token  string
+	Client ClientInfo
+}
+
+// AvailabilityInput checks whether Value, an email address or a phone
+// number, is free to register with.
+type AvailabilityInput struct {
+	Value  string
+	Client ClientInfo
+}
+
+// ProfileUpdateInput changes the fields that are set. An empty AvatarURL
+// removes the avatar.
+type ProfileUpdateInput struct {
+	UserID    uuid.UUID
+	Name      *string
+	AvatarURL *string
+	Client    ClientInfo
+}
+
+// AvatarUploadInput replaces the user's avatar with the image in Data.
+type AvatarUploadInput struct {
+	UserID uuid.UUID
+	Data   []byte
+	Client ClientInfo
+}
+
+// AvatarInput asks for an uploaded avatar at about Size pixels a side; 0
+// asks for the largest stored size.
+type AvatarInput struct {
+	UserID    uuid.UUID
+	UploadID  uuid.UUID
+	Extension string
+	Size      int
+}
+
+// SecurityEventListInput pages through a user's audit log. Page counts from 1.
+type SecurityEventListInput struct {
+	UserID uuid.UUID
+	Filter SecurityEventFilter
+	Page   int
+	Limit  int
+}
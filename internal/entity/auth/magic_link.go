@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentifierType says whether a passwordless sign-in names an email address
+// or a phone number.
+type IdentifierType string
+
+const (
+	IdentifierEmail IdentifierType = "email"
+	IdentifierPhone IdentifierType = "phone"
+)
+
+// MagicLinkMethod is the channel a sign-in link and code are delivered over.
+type MagicLinkMethod string
+
+const (
+	MagicLinkEmail MagicLinkMethod = "email"
+	MagicLinkSMS   MagicLinkMethod = "sms"
+)
+
+// MagicLinkSession is a pending passwordless sign-in. The link token and the
+// one-time code are stored hashed and either one completes it. UserID is
+// empty when no account matched the identifier at the time it was requested.
+type MagicLinkSession struct {
+	ID             uuid.UUID
+	Identifier     string
+	IdentifierType IdentifierType
+	Method         MagicLinkMethod
+	TokenHash      string
+	OTPHash        *string
+	UserID         *uuid.UUID
+	Attempts       int
+	SentAt         time.Time
+	ExpiresAt      time.Time
+	VerifiedAt     *time.Time
+	IPAddress      *string
+	UserAgent      *string
+	CreatedAt      time.Time
+}
+
+// IsExpired reports whether the session can no longer be used at the given time.
+func (s *MagicLinkSession) IsExpired(now time.Time) bool {
+	return !s.ExpiresAt.After(now)
+}
+
+// SameClient reports whether c is the client that requested the session.
+func (s *MagicLinkSession) SameClient(c ClientInfo) bool {
+	return equalOptional(s.IPAddress, c.IPAddress) && equalOptional(s.UserAgent, c.UserAgent)
+}
+
+func equalOptional(stored *string, s string) bool {
+	if stored == nil {
+		return s == ""
+	}
+
+	return *stored == s
+}
+
+// MagicLinkSent describes a sign-in link that was requested. Destination is
+// only set when it is the identifier itself, so the response never reveals
+// an account's other contact details or whether it exists.
+type MagicLinkSent struct {
+	SessionID   uuid.UUID
+	Method      MagicLinkMethod
+	Destination string
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFAMethod is a second factor a user can complete a login challenge with.
+type MFAMethod string
+
+const (
+	MFAMethodTOTP         MFAMethod = "totp"
+	MFAMethodSMS          MFAMethod = "sms"
+	MFAMethodRecoveryCode MFAMethod = "recovery_code"
+	// MFAMethodPasskey is completed with a passkey assertion instead of a code.
+	MFAMethodPasskey MFAMethod = "passkey"
+	// MFAMethodEmail is a one-time code emailed for a single risky login. It
+	// cannot be enrolled.
+	MFAMethodEmail MFAMethod = "email"
+)
+
+// TOTP is a user's authenticator app enrollment. It only counts as a second
+// factor once VerifiedAt is set.
+type TOTP struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	SecretEncrypted string     `json:"-"`
+	LastUsedStep    *int64     `json:"-"`
+	VerifiedAt      *time.Time `json:"verified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// IsVerified reports whether the enrollment has been confirmed with a code.
+func (t *TOTP) IsVerified() bool {
+	return t.VerifiedAt != nil
+}
+
+// SMSFactor is a phone number enrolled for SMS codes. The latest code sent to
+// it is stored hashed alongside, with a count of failed attempts against it.
+type SMSFactor struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	PhoneNumber   string     `json:"phone_number"`
+	CodeHash      *string    `json:"-"`
+	CodeExpiresAt *time.Time `json:"-"`
+	CodeAttempts  int        `json:"-"`
+	CodeSentAt    *time.Time `json:"-"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// IsVerified reports whether the phone number has been confirmed with a code.
+func (f *SMSFactor) IsVerified() bool {
+	return f.VerifiedAt != nil
+}
+
+// CodeExpired reports whether there is no code that can still be used at the given time.
+func (f *SMSFactor) CodeExpired(now time.Time) bool {
+	return f.CodeHash == nil || f.CodeExpiresAt == nil || !f.CodeExpiresAt.After(now)
+}
+
+// RecoveryCodeStatus summarizes a user's current set of recovery codes.
+// GeneratedAt is nil when the user has never generated a set.
+type RecoveryCodeStatus struct {
+	Total       int
+	Remaining   int
+	GeneratedAt *time.Time
+}
+
+// TOTPSetup is returned once when enrollment starts. Secret is shown to the
+// user for manual entry; URI is the otpauth:// payload to render as a QR code.
+type TOTPSetup struct {
+	Secret string
+	URI    string
+}
+
+// MFAChallenge is the pending second step of a login. The raw token is handed
+// to the client, which completes the login by presenting it with a code from
+// one of AvailableMethods. A code emailed for the challenge is stored hashed
+// on it.
+type MFAChallenge struct {
+	ID               uuid.UUID   `json:"id"`
+	UserID           uuid.UUID   `json:"user_id"`
+	TokenHash        string      `json:"-"`
+	AvailableMethods []MFAMethod `json:"available_methods"`
+	RememberMe       bool        `json:"remember_me"`
+	Attempts         int         `json:"attempts"`
+	CodeHash         *string     `json:"-"`
+	CodeExpiresAt    *time.Time  `json:"-"`
+	CodeSentAt       *time.Time  `json:"-"`
+	IPAddress        *string     `json:"ip_address,omitempty"`
+	UserAgent        *string     `json:"user_agent,omitempty"`
+	ExpiresAt        time.Time   `json:"expires_at"`
+	CompletedAt      *time.Time  `json:"completed_at,omitempty"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+// IsExpired reports whether the challenge can no longer be completed at the given time.
+func (c *MFAChallenge) IsExpired(now time.Time) bool {
+	return !c.ExpiresAt.After(now)
+}
+
+// CodeExpired reports whether there is no emailed code that can still be used at the given time.
+func (c *MFAChallenge) CodeExpired(now time.Time) bool {
+	return c.CodeHash == nil || c.CodeExpiresAt == nil || !c.CodeExpiresAt.After(now)
+}
+
+// Allows reports whether the challenge can be completed with method.
+func (c *MFAChallenge) Allows(method MFAMethod) bool {
+	return slices.Contains(c.AvailableMethods, method)
+}
+
+// MFAStatus summarizes which second factors a user has enabled.
+type MFAStatus struct {
+	TOTPEnabled            bool
+	SMSEnabled             bool
+	PhoneNumber            string
+	Passkeys               int
+	RecoveryCodesRemaining int
+}
+
+// Enabled reports whether any second factor is active. Recovery codes are a
+// fallback and do not count on their own, and neither do passkeys, which
+// sign users in by themselves.
+func (s *MFAStatus) Enabled() bool {
+	return s.TOTPEnabled || s.SMSEnabled
+}
+
+// Methods lists the second factors a login challenge can be completed with.
+func (s *MFAStatus) Methods() []MFAMethod {
+	var methods []MFAMethod
+
+	if s.TOTPEnabled {
+		methods = append(methods, MFAMethodTOTP)
+	}
+
+	if s.SMSEnabled {
+		methods = append(methods, MFAMethodSMS)
+	}
+
+	if len(methods) > 0 && s.Passkeys > 0 {
+		methods = append(methods, MFAMethodPasskey)
+	}
+
+	if len(methods) > 0 && s.RecoveryCodesRemaining > 0 {
+		methods = append(methods, MFAMethodRecoveryCode)
+	}
+
+	return methods
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthProvider is a social login provider.
+type OAuthProvider string
+
+const (
+	OAuthGoogle   OAuthProvider = "google"
+	OAuthApple    OAuthProvider = "apple"
+	OAuthFacebook OAuthProvider = "facebook"
+	OAuthGitHub   OAuthProvider = "github"
+)
+
+// OAuthConnection links a user to an account at a social login provider. The
+// provider's tokens are stored encrypted.
+type OAuthConnection struct {
+	ID                    uuid.UUID     `json:"id"`
+	UserID                uuid.UUID     `json:"user_id"`
+	Provider              OAuthProvider `json:"provider"`
+	ProviderUserID        string        `json:"provider_user_id"`
+	ProviderEmail         *string       `json:"provider_email,omitempty"`
+	ProviderName          *string       `json:"provider_name,omitempty"`
+	ProviderAvatarURL     *string       `json:"provider_avatar_url,omitempty"`
+	AccessTokenEncrypted  *string       `json:"-"`
+	RefreshTokenEncrypted *string       `json:"-"`
+	TokenExpiresAt        *time.Time    `json:"-"`
+	ConnectedAt           time.Time     `json:"connected_at"`
+}
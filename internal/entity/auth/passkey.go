@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasskeyDeviceType says whether a passkey lives on the device it was
+// created on or on a roaming authenticator such as a security key or phone.
+type PasskeyDeviceType string
+
+const (
+	PasskeyPlatform      PasskeyDeviceType = "platform"
+	PasskeyCrossPlatform PasskeyDeviceType = "cross_platform"
+)
+
+// Passkey is a WebAuthn credential registered to a user. PublicKey is the
+// COSE-encoded key assertions are checked against.
+type Passkey struct {
+	ID           uuid.UUID          `json:"id"`
+	UserID       uuid.UUID          `json:"user_id"`
+	CredentialID []byte             `json:"credential_id"`
+	PublicKey    []byte             `json:"-"`
+	Name         string             `json:"name"`
+	AAGUID       []byte             `json:"aaguid,omitempty"`
+	SignCount    int64              `json:"-"`
+	Transports   []string           `json:"transports,omitempty"`
+	DeviceType   *PasskeyDeviceType `json:"device_type,omitempty"`
+	BackedUp     bool               `json:"backed_up"`
+	LastUsedAt   *time.Time         `json:"last_used_at,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// WebAuthnCeremony is the kind of WebAuthn request a challenge was issued for.
+type WebAuthnCeremony string
+
+const (
+	CeremonyRegistration   WebAuthnCeremony = "registration"
+	CeremonyAuthentication WebAuthnCeremony = "authentication"
+)
+
+// WebAuthnChallenge is a pending registration or authentication ceremony.
+// The random challenge is stored hashed and can be used once. UserID is set
+// when the ceremony is bound to a known user; MFAChallengeID when the
+// assertion completes a login's MFA step.
+type WebAuthnChallenge struct {
+	ID             uuid.UUID
+	UserID         *uuid.UUID
+	ChallengeHash  string
+	Ceremony       WebAuthnCeremony
+	MFAChallengeID *uuid.UUID
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+// IsExpired reports whether the ceremony can no longer be finished at the given time.
+func (c *WebAuthnChallenge) IsExpired(now time.Time) bool {
+	return !c.ExpiresAt.After(now)
+}
+
+// PasskeyDescriptor identifies a credential the browser may use or must
+// not register again.
+type PasskeyDescriptor struct {
+	ID         []byte
+	Transports []string
+}
+
+// PasskeyCreationOptions are the parameters for navigator.credentials.create().
+type PasskeyCreationOptions struct {
+	Challenge        []byte
+	RPID             string
+	RPName           string
+	UserHandle       []byte
+	UserName         string
+	UserDisplayName  string
+	Algorithms       []int
+	Timeout          time.Duration
+	ResidentKey      string
+	UserVerification string
+	Attestation      string
+	Exclude          []PasskeyDescriptor
+}
+
+// PasskeyRequestOptions are the parameters for navigator.credentials.get().
+// An empty Allow list lets the user pick any passkey they hold for the site.
+type PasskeyRequestOptions struct {
+	Challenge        []byte
+	RPID             string
+	Timeout          time.Duration
+	Allow            []PasskeyDescriptor
+	UserVerification string
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SecurityEventType string
+
+const (
+	EventLoginSuccess             SecurityEventType = "login_success"
+	EventLoginFailed              SecurityEventType = "login_failed"
+	EventLogout                   SecurityEventType = "logout"
+	EventMagicLinkSent            SecurityEventType = "magic_link_sent"
+	EventMagicLinkUsed            SecurityEventType = "magic_link_used"
+	EventPasswordChanged          SecurityEventType = "password_changed"
+	EventPasswordResetRequested   SecurityEventType = "password_reset_requested"
+	EventPasswordResetCompleted   SecurityEventType = "password_reset_completed"
+	EventMFAEnabled               SecurityEventType = "mfa_enabled"
+	EventMFADisabled              SecurityEventType = "mfa_disabled"
+	EventMFAChallengeSuccess      SecurityEventType = "mfa_challenge_success"
+	EventMFAChallengeFailed       SecurityEventType = "mfa_challenge_failed"
+	EventRecoveryCodeUsed         SecurityEventType = "recovery_code_used"
+	EventRecoveryCodesRegenerated SecurityEventType = "recovery_codes_regenerated"
+	EventPasskeyRegistered        SecurityEventType = "passkey_registered"
+	EventPasskeyRemoved           SecurityEventType = "passkey_removed"
+	EventPasskeyUsed              SecurityEventType = "passkey_used"
+	EventOAuthLinked              SecurityEventType = "oauth_linked"
+	EventOAuthUnlinked            SecurityEventType = "oauth_unlinked"
+	EventOAuthLogin               SecurityEventType = "oauth_login"
+	EventEmailChanged             SecurityEventType = "email_changed"
+	EventPhoneChanged             SecurityEventType = "phone_changed"
+	EventProfileUpdated           SecurityEventType = "profile_updated"
+	EventAccountLocked            SecurityEventType = "account_locked"
+	EventAccountUnlocked          SecurityEventType = "account_unlocked"
+	EventAccountRecoveryStarted   SecurityEventType = "account_recovery_started"
+	EventAccountRecoveryVerified  SecurityEventType = "account_recovery_verified"
+	EventAccountRecoveryCompleted SecurityEventType = "account_recovery_completed"
+	EventAccountRecoveryCanceled  SecurityEventType = "account_recovery_canceled"
+	EventAccountDeletionRequested SecurityEventType = "account_deletion_requested"
+	EventAccountDeleted           SecurityEventType = "account_deleted"
+	EventAccountRestored          SecurityEventType = "account_restored"
+	EventDataExportRequested      SecurityEventType = "data_export_requested"
+	EventDataExportDownloaded     SecurityEventType = "data_export_downloaded"
+	EventSessionRevoked           SecurityEventType = "session_revoked"
+	EventAllSessionsRevoked       SecurityEventType = "all_sessions_revoked"
+	EventSuspiciousActivity       SecurityEventType = "suspicious_activity"
+	EventNewDeviceLogin           SecurityEventType = "new_device_login"
+)
+
+type RiskLevel string
+
+const (
+	RiskLow    RiskLevel = "low"
+	RiskMedium RiskLevel = "medium"
+	RiskHigh   RiskLevel = "high"
+)
+
+type SecurityEvent struct {
+	ID                  uuid.UUID         `json:"id"`
+	UserID              *uuid.UUID        `json:"user_id,omitempty"`
+	Type                SecurityEventType `json:"event_type"`
+	Success             bool              `json:"success"`
+	RiskLevel           RiskLevel         `json:"risk_level"`
+	IPAddress           *string           `json:"ip_address,omitempty"`
+	UserAgent           *string           `json:"user_agent,omitempty"`
+	LocationCity        *string           `json:"location_city,omitempty"`
+	LocationRegion      *string           `json:"location_region,omitempty"`
+	LocationCountry     *string           `json:"location_country,omitempty"`
+	LocationCountryCode *string           `json:"location_country_code,omitempty"`
+	DeviceType          *string           `json:"device_type,omitempty"`
+	DeviceOS            *string           `json:"device_os,omitempty"`
+	DeviceBrowser       *string           `json:"device_browser,omitempty"`
+	Details             map[string]any    `json:"details,omitempty"`
+	CreatedAt           time.Time         `json:"created_at"`
+}
+
+// SecurityEventFilter narrows a user's audit log. Zero fields match everything.
+type SecurityEventFilter struct {
+	Type SecurityEventType
+	From *time.Time
+	To   *time.Time
+}
+
+// SecurityEventList is one page of a user's audit log, newest first.
+type SecurityEventList struct {
+	Events []SecurityEvent
+	Page   int
+	Limit  int
+	Total  int
+}
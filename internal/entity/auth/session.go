@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo describes the client a request originated from.
+type ClientInfo struct {
+	IPAddress  string
+	UserAgent  string
+	DeviceInfo string
+}
+
+// RefreshToken is a stored refresh token. All generations of a token share a
+// FamilyID, which identifies the session across rotations.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	TokenHash  string     `json:"-"`
+	FamilyID   uuid.UUID  `json:"family_id"`
+	Generation int        `json:"generation"`
+	DeviceInfo *string    `json:"device_info,omitempty"`
+	IPAddress  *string    `json:"ip_address,omitempty"`
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the token is neither revoked nor expired at the given time.
+func (t *RefreshToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && t.ExpiresAt.After(now)
+}
+
+// DeviceType is a coarse classification of the client behind a session.
+type DeviceType string
+
+const (
+	DeviceDesktop DeviceType = "desktop"
+	DeviceMobile  DeviceType = "mobile"
+	DeviceTablet  DeviceType = "tablet"
+	DeviceUnknown DeviceType = "unknown"
+)
+
+// Session is an active refresh token family. Its ID is the family ID, which is
+// also the sid claim of the access tokens issued for it.
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"user_id"`
+	DeviceInfo   *string   `json:"device_info,omitempty"`
+	IPAddress    *string   `json:"ip_address,omitempty"`
+	UserAgent    *string   `json:"user_agent,omitempty"`
+	IsCurrent    bool      `json:"is_current"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// DeviceType guesses the device type from the session's user agent.
+func (s *Session) DeviceType() DeviceType {
+	if s.UserAgent == nil || *s.UserAgent == "" {
+		return DeviceUnknown
+	}
+
+	ua := strings.ToLower(*s.UserAgent)
+
+	switch {
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"),
+		strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi"), strings.Contains(ua, "iphone"), strings.Contains(ua, "android"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
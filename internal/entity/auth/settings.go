@@ -0,0 +1,35 @@
+package auth
+
+import "time"
+
+// Settings are the rules the auth usecases enforce that clients should know
+// up front: password requirements, token lifetimes, rate limits and the
+// recovery code format.
+type Settings struct {
+	PasswordMinLength        int
+	PasswordMaxLength        int
+	PasswordRequireUppercase bool
+	PasswordRequireLowercase bool
+	PasswordRequireNumber    bool
+	PasswordRequireSpecial   bool
+
+	AccessTokenTTL       time.Duration
+	RefreshTokenTTL      time.Duration
+	RememberMeTTL        time.Duration
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+	MFACodeTTL           time.Duration
+	RecoverySessionTTL   time.Duration
+
+	LoginMaxAttempts         int
+	LoginAttemptWindow       time.Duration
+	LoginLockout             time.Duration
+	LoginMaxLockout          time.Duration
+	PasswordResetMaxRequests int
+	PasswordResetWindow      time.Duration
+	MFAMaxAttempts           int
+	MFAAttemptWindow         time.Duration
+
+	RecoveryCodeCount  int
+	RecoveryCodeFormat string
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SigningKey is a JWT signing key. SecretEncrypted holds the HMAC secret or the
+// PEM-encoded private key, encrypted at rest.
+type SigningKey struct {
+	ID              uuid.UUID
+	KID             string
+	Algorithm       string
+	SecretEncrypted string
+	IsActive        bool
+	ExpiresAt       *time.Time
+	RevokedAt       *time.Time
+	CreatedAt       time.Time
+}
+
+// IsUsable reports whether tokens signed with the key may still be accepted.
+func (k *SigningKey) IsUsable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
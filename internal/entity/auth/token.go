@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const TokenTypeBearer = "Bearer"
+
+// Claims are the verified contents of an access token.
+type Claims struct {
+	TokenID   string
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// TokenPair is the set of tokens handed to a client after authentication.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+	TokenType    string
+}
+
+type ChallengeType string
+
+const (
+	ChallengeMFARequired               ChallengeType = "mfa_required"
+	ChallengeAccountLocked             ChallengeType = "account_locked"
+	ChallengeEmailVerificationRequired ChallengeType = "email_verification_required"
+)
+
+// LoginChallenge is returned instead of tokens when login cannot complete yet.
+type LoginChallenge struct {
+	Type             ChallengeType
+	Token            string
+	AvailableMethods []string
+	LockedUntil      *time.Time
+	Message          string
+}
+
+// AuthResult is the outcome of an authentication attempt. Exactly one of
+// Tokens or Challenge is set.
+type AuthResult struct {
+	User      *User
+	Tokens    *TokenPair
+	Challenge *LoginChallenge
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusActive              Status = "active"
+	StatusPendingVerification Status = "pending_verification"
+	StatusDisabled            Status = "disabled"
+	StatusDeleted             Status = "deleted"
+)
+
+type User struct {
+	ID                  uuid.UUID  `json:"id"`
+	Email               string     `json:"email"`
+	PasswordHash        *string    `json:"-"`
+	Name                *string    `json:"name,omitempty"`
+	AvatarURL           *string    `json:"avatar_url,omitempty"`
+	AvatarKeys          []string   `json:"-"`
+	EmailVerified       bool       `json:"email_verified"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at,omitempty"`
+	PhoneNumber         *string    `json:"phone_number,omitempty"`
+	PhoneVerified       bool       `json:"phone_verified"`
+	PhoneVerifiedAt     *time.Time `json:"phone_verified_at,omitempty"`
+	Status              Status     `json:"status"`
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"-"`
+	LastLoginAt         *time.Time `json:"last_login_at,omitempty"`
+	LastLoginIP         *string    `json:"-"`
+	DeletedAt           *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// HasPassword reports whether the user can sign in with a password.
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != nil && *u.PasswordHash != ""
+}
+
+// IsLocked reports whether the account is temporarily locked at the given time.
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(now)
+}
+
+// LockoutPolicy locks an account after MaxAttempts failed sign-ins within
+// Window. Each lockout lasts twice as long as the one before it, starting at
+// Lockout and capped at MaxLockout; the doubling starts over once an account
+// has gone MaxLockout without a failure.
+type LockoutPolicy struct {
+	MaxAttempts int
+	Window      time.Duration
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+}
+
+// LoginFailures is an account's record of failed sign-ins. Attempts counts
+// failures since the last lockout; Lockouts counts lockouts in a row.
+type LoginFailures struct {
+	Attempts    int
+	Lockouts    int
+	LastFailure *time.Time
+	LockedUntil *time.Time
+}
+
+// Fail returns f after a failed sign-in at now, and whether that failure
+// locked the account. Failures while the account is locked are not counted.
+func (p LockoutPolicy) Fail(f LoginFailures, now time.Time) (LoginFailures, bool) {
+	if f.LockedUntil != nil && f.LockedUntil.After(now) {
+		return f, false
+	}
+
+	if f.LastFailure == nil || !f.LastFailure.After(now.Add(-p.MaxLockout)) {
+		f.Lockouts = 0
+	}
+
+	if f.LastFailure == nil || !f.LastFailure.After(now.Add(-p.Window)) {
+		f.Attempts = 0
+	}
+
+	f.Attempts++
+	f.LastFailure = &now
+
+	if f.Attempts < p.MaxAttempts {
+		return f, false
+	}
+
+	until := now.Add(p.lockout(f.Lockouts))
+	f.Attempts = 0
+	f.Lockouts++
+	f.LockedUntil = &until
+
+	return f, true
+}
+
+// lockout is how long the account is locked for after n earlier lockouts in a row.
+func (p LockoutPolicy) lockout(n int) time.Duration {
+	d := p.Lockout
+	for range n {
+		if d >= p.MaxLockout/2 {
+			return p.MaxLockout
+		}
+
+		d *= 2
+	}
+
+	return min(d, p.MaxLockout)
+}
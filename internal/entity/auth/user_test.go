@@ -0,0 +1,79 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutPolicy_Fail(t *testing.T) {
+	t.Parallel()
+
+	policy := auth.LockoutPolicy{MaxAttempts: 3, Window: 15 * time.Minute, Lockout: 15 * time.Minute, MaxLockout: time.Hour}
+	now := time.Date(2025, 12, 22, 9, 0, 0, 0, time.UTC)
+
+	var (
+		f      auth.LoginFailures
+		locked bool
+	)
+
+	fail := func(at time.Time) {
+		f, locked = policy.Fail(f, at)
+	}
+
+	fail(now)
+	fail(now.Add(time.Minute))
+	assert.False(t, locked)
+	assert.Equal(t, 2, f.Attempts)
+
+	fail(now.Add(2 * time.Minute))
+	require.True(t, locked)
+	assert.Equal(t, now.Add(17*time.Minute), *f.LockedUntil)
+	assert.Equal(t, 0, f.Attempts)
+	assert.Equal(t, 1, f.Lockouts)
+
+	// Failures while locked are ignored.
+	fail(now.Add(5 * time.Minute))
+	assert.False(t, locked)
+	assert.Equal(t, 0, f.Attempts)
+
+	// The next lockout in a row lasts twice as long, and the one after that
+	// is capped.
+	for i := range 3 {
+		fail(now.Add(20*time.Minute + time.Duration(i)*time.Second))
+	}
+
+	require.True(t, locked)
+	assert.Equal(t, 30*time.Minute, f.LockedUntil.Sub(*f.LastFailure))
+
+	for i := range 3 {
+		fail(now.Add(time.Hour + time.Duration(i)*time.Second))
+	}
+
+	require.True(t, locked)
+	assert.Equal(t, time.Hour, f.LockedUntil.Sub(*f.LastFailure))
+	assert.Equal(t, 3, f.Lockouts)
+}
+
+func TestLockoutPolicy_Fail_Window(t *testing.T) {
+	t.Parallel()
+
+	policy := auth.LockoutPolicy{MaxAttempts: 3, Window: 15 * time.Minute, Lockout: 15 * time.Minute, MaxLockout: time.Hour}
+	now := time.Date(2025, 12, 22, 9, 0, 0, 0, time.UTC)
+	last := now.Add(-16 * time.Minute)
+
+	// Old failures fall out of the window, and a quiet spell as long as the
+	// longest lockout forgets earlier lockouts.
+	f, locked := policy.Fail(auth.LoginFailures{Attempts: 2, Lockouts: 1, LastFailure: &last}, now)
+	assert.False(t, locked)
+	assert.Equal(t, 1, f.Attempts)
+	assert.Equal(t, 1, f.Lockouts)
+
+	last = now.Add(-2 * time.Hour)
+	f, _ = policy.Fail(auth.LoginFailures{Attempts: 2, Lockouts: 2, LastFailure: &last}, now)
+	assert.Equal(t, 1, f.Attempts)
+	assert.Equal(t, 0, f.Lockouts)
+}
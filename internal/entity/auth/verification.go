@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailVerification is a single-use token proving that the user controls Email.
+type EmailVerification struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Email      string     `json:"email"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsExpired reports whether the token can no longer be used at the given time.
+func (v *EmailVerification) IsExpired(now time.Time) bool {
+	return !v.ExpiresAt.After(now)
+}
+
+// PasswordReset is a single-use token that lets the user set a new password.
+type PasswordReset struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsExpired reports whether the token can no longer be used at the given time.
+func (r *PasswordReset) IsExpired(now time.Time) bool {
+	return !r.ExpiresAt.After(now)
+}
@@ -11,6 +11,9 @@ type EmailMessage struct {
 	Body        string
 	HTMLBody    string
 	Attachments []Attachment
+	// Transactional mail, such as account verification, is sent regardless of
+	// the user's email preference.
+	Transactional bool
 }
 
 type Attachment struct {
@@ -20,8 +23,13 @@ type Attachment struct {
 }
 
 type SMSMessage struct {
+	UserID uuid.UUID
+	// To is the recipient in E.164 format, e.g. +14155551234.
 	To   string
 	Body string
+	// Transactional messages, such as one-time codes, are sent regardless of
+	// the user's SMS preference.
+	Transactional bool
 }
 
 type PushMessage struct {
@@ -3,7 +3,9 @@
 
 import (
 	"context"
+	"time"
 
+	"github.com/evrone/go-clean-template/internal/entity/auth"
 	"github.com/evrone/go-clean-template/internal/entity/event"
 	"github.com/evrone/go-clean-template/internal/entity/notification"
 	"github.com/google/uuid"
@@ -49,4 +51,194 @@
 		Store(ctx context.Context, log *notification.DeliveryLog) error
 		GetByNotificationID(ctx context.Context, notificationID uuid.UUID) ([]notification.DeliveryLog, error)
 	}
+
+	// UserRepo handles user account persistence.
+	UserRepo interface {
+		Create(ctx context.Context, u *auth.User) error
+		GetByID(ctx context.Context, id uuid.UUID) (*auth.User, error)
+		GetByEmail(ctx context.Context, email string) (*auth.User, error)
+		GetByPhone(ctx context.Context, phone string) (*auth.User, error)
+		UpdateLastLogin(ctx context.Context, id uuid.UUID, at time.Time, ip string) error
+		RecordLoginFailure(ctx context.Context, id uuid.UUID, at time.Time, policy auth.LockoutPolicy) (*auth.LoginFailures, bool, error)
+		UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+		UpdateProfile(ctx context.Context, u *auth.User) error
+	}
+
+	// RefreshTokenRepo handles refresh token persistence.
+	RefreshTokenRepo interface {
+		Store(ctx context.Context, t *auth.RefreshToken) error
+		GetByHash(ctx context.Context, hash string) (*auth.RefreshToken, error)
+		Rotate(ctx context.Context, currentID uuid.UUID, next *auth.RefreshToken) error
+		FamilyActive(ctx context.Context, userID, familyID uuid.UUID) (bool, error)
+		RevokeFamily(ctx context.Context, userID, familyID uuid.UUID) error
+		RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+		ListSessions(ctx context.Context, userID uuid.UUID) ([]auth.Session, error)
+		RevokeOtherFamilies(ctx context.Context, userID, keepFamilyID uuid.UUID) (int64, error)
+	}
+
+	// EmailVerificationRepo handles email verification token persistence.
+	EmailVerificationRepo interface {
+		Store(ctx context.Context, v *auth.EmailVerification) error
+		GetByHash(ctx context.Context, hash string) (*auth.EmailVerification, error)
+		CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+		Confirm(ctx context.Context, v *auth.EmailVerification, at time.Time) error
+	}
+
+	// PasswordResetRepo handles password reset token persistence.
+	PasswordResetRepo interface {
+		Store(ctx context.Context, r *auth.PasswordReset) error
+		GetByHash(ctx context.Context, hash string) (*auth.PasswordReset, error)
+		CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+		Consume(ctx context.Context, r *auth.PasswordReset, passwordHash string, at time.Time) error
+	}
+
+	// TOTPRepo handles authenticator app enrollments.
+	TOTPRepo interface {
+		GetByUserID(ctx context.Context, userID uuid.UUID) (*auth.TOTP, error)
+		Upsert(ctx context.Context, t *auth.TOTP) error
+		UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+		MarkVerified(ctx context.Context, userID uuid.UUID, at time.Time) error
+		Delete(ctx context.Context, userID uuid.UUID) error
+	}
+
+	// SMSFactorRepo handles phone numbers enrolled for SMS codes.
+	SMSFactorRepo interface {
+		GetByUserID(ctx context.Context, userID uuid.UUID) (*auth.SMSFactor, error)
+		Upsert(ctx context.Context, f *auth.SMSFactor) error
+		SetCode(ctx context.Context, userID uuid.UUID, hash string, expiresAt, sentAt time.Time) error
+		RecordCodeFailure(ctx context.Context, userID uuid.UUID) (int, error)
+		ConsumeCode(ctx context.Context, userID uuid.UUID, hash string, at time.Time) error
+		Delete(ctx context.Context, userID uuid.UUID) error
+	}
+
+	// RecoveryCodeRepo handles hashed MFA recovery codes.
+	RecoveryCodeRepo interface {
+		Replace(ctx context.Context, userID uuid.UUID, hashes []string) error
+		Status(ctx context.Context, userID uuid.UUID) (*auth.RecoveryCodeStatus, error)
+		Consume(ctx context.Context, userID uuid.UUID, hash string, at time.Time) error
+		DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	}
+
+	// OAuthConnectionRepo handles links to social login provider accounts.
+	OAuthConnectionRepo interface {
+		GetByProvider(ctx context.Context, provider auth.OAuthProvider, providerUserID string) (*auth.OAuthConnection, error)
+		ListByUserID(ctx context.Context, userID uuid.UUID) ([]auth.OAuthConnection, error)
+		Create(ctx context.Context, c *auth.OAuthConnection) error
+		CreateWithUser(ctx context.Context, u *auth.User, c *auth.OAuthConnection) error
+		UpdateTokens(ctx context.Context, c *auth.OAuthConnection) error
+		Delete(ctx context.Context, userID uuid.UUID, provider auth.OAuthProvider) error
+	}
+
+	// PasskeyRepo handles registered WebAuthn credentials.
+	PasskeyRepo interface {
+		ListByUserID(ctx context.Context, userID uuid.UUID) ([]auth.Passkey, error)
+		GetByCredentialID(ctx context.Context, credentialID []byte) (*auth.Passkey, error)
+		Create(ctx context.Context, p *auth.Passkey) error
+		Rename(ctx context.Context, userID, id uuid.UUID, name string) (*auth.Passkey, error)
+		RecordUse(ctx context.Context, id uuid.UUID, signCount int64, backedUp bool, at time.Time) error
+		Delete(ctx context.Context, userID, id uuid.UUID) error
+	}
+
+	// WebAuthnChallengeRepo handles pending passkey ceremonies.
+	WebAuthnChallengeRepo interface {
+		Store(ctx context.Context, c *auth.WebAuthnChallenge) error
+		Consume(ctx context.Context, hash string, ceremony auth.WebAuthnCeremony) (*auth.WebAuthnChallenge, error)
+	}
+
+	// MagicLinkRepo handles pending passwordless sign-ins.
+	MagicLinkRepo interface {
+		Store(ctx context.Context, s *auth.MagicLinkSession) error
+		GetByID(ctx context.Context, id uuid.UUID) (*auth.MagicLinkSession, error)
+		CountSince(ctx context.Context, identifier string, identifierType auth.IdentifierType, since time.Time) (int, error)
+		Reissue(ctx context.Context, s *auth.MagicLinkSession) error
+		RecordFailure(ctx context.Context, id uuid.UUID) (int, error)
+		Consume(ctx context.Context, s *auth.MagicLinkSession, at time.Time) error
+	}
+
+	// AccountRecoveryRepo handles self-service account recovery sessions.
+	AccountRecoveryRepo interface {
+		Store(ctx context.Context, s *auth.RecoverySession) error
+		GetByID(ctx context.Context, id uuid.UUID) (*auth.RecoverySession, error)
+		GetByTokenHash(ctx context.Context, hash string) (*auth.RecoverySession, error)
+		CountSince(ctx context.Context, identifier string, since time.Time) (int, error)
+		Reissue(ctx context.Context, s *auth.RecoverySession) error
+		RecordFailure(ctx context.Context, id uuid.UUID) (int, error)
+		Verify(ctx context.Context, s *auth.RecoverySession) error
+		ResetMFA(ctx context.Context, s *auth.RecoverySession, passwordHash *string, at time.Time) error
+		CancelForUser(ctx context.Context, userID uuid.UUID, at time.Time) (int64, error)
+	}
+
+	// EmailChangeRepo handles pending email address changes and their reverts.
+	EmailChangeRepo interface {
+		Store(ctx context.Context, c *auth.EmailChange) error
+		GetByTokenHash(ctx context.Context, hash string) (*auth.EmailChange, error)
+		CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+		Confirm(ctx context.Context, c, revert *auth.EmailChange, at time.Time, events []event.OutboxEvent) error
+	}
+
+	// PhoneChangeRepo handles pending phone number changes and removals.
+	PhoneChangeRepo interface {
+		Store(ctx context.Context, c *auth.PhoneChange) error
+		GetPending(ctx context.Context, userID uuid.UUID) (*auth.PhoneChange, error)
+		CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+		RecordFailure(ctx context.Context, id uuid.UUID) (int, error)
+		Confirm(ctx context.Context, c *auth.PhoneChange, at time.Time, events []event.OutboxEvent) error
+		RemovePhone(ctx context.Context, userID uuid.UUID, phone string, at time.Time, events []event.OutboxEvent) error
+	}
+
+	// AccountDeletionRepo handles account deletion requests and the purge of
+	// deleted accounts.
+	AccountDeletionRepo interface {
+		Store(ctx context.Context, d *auth.AccountDeletion) error
+		GetByTokenHash(ctx context.Context, hash string) (*auth.AccountDeletion, error)
+		Confirm(ctx context.Context, d *auth.AccountDeletion, at time.Time) error
+		Restore(ctx context.Context, userID uuid.UUID, status auth.Status) error
+		ListPurgeable(ctx context.Context, deletedBefore time.Time, limit uint64) ([]auth.User, error)
+		Purge(ctx context.Context, userID uuid.UUID, deletedBefore time.Time, events []event.OutboxEvent) error
+	}
+
+	// DataExportRepo handles personal data exports and reads the data they
+	// contain.
+	DataExportRepo interface {
+		Create(ctx context.Context, e *auth.DataExport) error
+		ListByUser(ctx context.Context, userID uuid.UUID) ([]auth.DataExport, error)
+		GetByTokenHash(ctx context.Context, hash string) (*auth.DataExport, error)
+		Claim(ctx context.Context, staleBefore time.Time, limit uint64) ([]auth.DataExport, error)
+		Complete(ctx context.Context, e *auth.DataExport) error
+		Fail(ctx context.Context, id uuid.UUID, reason string) error
+		ListExpired(ctx context.Context, now time.Time, limit uint64) ([]auth.DataExport, error)
+		Expire(ctx context.Context, id uuid.UUID) error
+		Collect(ctx context.Context, userID uuid.UUID) ([]auth.DataExportTable, error)
+	}
+
+	// AvailabilityCheckRepo rate limits email and phone availability checks
+	// per IP address.
+	AvailabilityCheckRepo interface {
+		Record(ctx context.Context, ip string, since time.Time) (int, error)
+	}
+
+	// MFAChallengeRepo handles pending login MFA challenges.
+	MFAChallengeRepo interface {
+		Store(ctx context.Context, c *auth.MFAChallenge) error
+		GetByHash(ctx context.Context, hash string) (*auth.MFAChallenge, error)
+		SetCode(ctx context.Context, id uuid.UUID, hash string, expiresAt, sentAt time.Time) error
+		RecordFailure(ctx context.Context, id uuid.UUID) (int, error)
+		FailuresSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+		Complete(ctx context.Context, id uuid.UUID, at time.Time) error
+	}
+
+	// SigningKeyRepo handles JWT signing key persistence.
+	SigningKeyRepo interface {
+		ListUsable(ctx context.Context, now time.Time) ([]auth.SigningKey, error)
+		Rotate(ctx context.Context, key *auth.SigningKey, retireAt, staleBefore time.Time) (bool, error)
+		Revoke(ctx context.Context, kid string) error
+	}
+
+	// SecurityEventRepo handles the security audit log.
+	SecurityEventRepo interface {
+		Store(ctx context.Context, e *auth.SecurityEvent) error
+		GetByID(ctx context.Context, userID, id uuid.UUID) (*auth.SecurityEvent, error)
+		ListByUserID(ctx context.Context, userID uuid.UUID, f auth.SecurityEventFilter, limit, offset uint64) ([]auth.SecurityEvent, error)
+		CountByUserID(ctx context.Context, userID uuid.UUID, f auth.SecurityEventFilter) (int, error)
+	}
 )
@@ -0,0 +1,323 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/event"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+//nolint:gochecknoglobals // column list shared by all account deletion queries
+var accountDeletionColumns = []string{
+	"id", "user_id", "token_hash", "feedback", "expires_at", "confirmed_at", "created_at",
+}
+
+// userDataTables hold rows keyed by user ID without a foreign key to users,
+// so purging an account deletes them explicitly.
+//
+//nolint:gochecknoglobals // fixed list of notification tables
+var userDataTables = []string{
+	"notifications", "notification_preferences", "push_tokens", "notification_delivery_logs",
+}
+
+type AccountDeletionRepo struct {
+	*postgres.Postgres
+}
+
+func NewAccountDeletionRepo(pg *postgres.Postgres) *AccountDeletionRepo {
+	return &AccountDeletionRepo{pg}
+}
+
+// Store saves a deletion request in place of the user's pending one, so only
+// the latest link works.
+func (r *AccountDeletionRepo) Store(ctx context.Context, d *auth.AccountDeletion) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+
+	d.CreatedAt = time.Now().UTC()
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Store - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Delete("account_deletion_requests").
+		Where("user_id = ? AND confirmed_at IS NULL", d.UserID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Store - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Store - tx.Exec: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Insert("account_deletion_requests").
+		Columns(accountDeletionColumns...).
+		Values(d.ID, d.UserID, d.TokenHash, d.Feedback, d.ExpiresAt, d.ConfirmedAt, d.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Store - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Store - tx.Exec: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Store - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AccountDeletionRepo) GetByTokenHash(ctx context.Context, hash string) (*auth.AccountDeletion, error) {
+	sql, args, err := r.Builder.
+		Select(accountDeletionColumns...).
+		From("account_deletion_requests").
+		Where("token_hash = ?", hash).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("AccountDeletionRepo - GetByTokenHash - r.Builder: %w", err)
+	}
+
+	d, err := scanAccountDeletion(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrAccountDeletionNotFound
+		}
+
+		return nil, fmt.Errorf("AccountDeletionRepo - GetByTokenHash - r.Pool.QueryRow: %w", err)
+	}
+
+	return d, nil
+}
+
+// Confirm consumes the request, keeping its feedback, and marks the user
+// deleted as of at in one transaction. It returns auth.ErrAccountDeletionUsed
+// when the request was confirmed concurrently and auth.ErrUserNotFound when
+// the account is already deleted or disabled.
+func (r *AccountDeletionRepo) Confirm(ctx context.Context, d *auth.AccountDeletion, at time.Time) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Confirm - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("account_deletion_requests").
+		Set("confirmed_at", at).
+		Set("feedback", d.Feedback).
+		Where("id = ? AND confirmed_at IS NULL", d.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Confirm - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Confirm - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrAccountDeletionUsed
+	}
+
+	sql, args, err = r.Builder.
+		Update("users").
+		Set("status", auth.StatusDeleted).
+		Set("deleted_at", at).
+		Set("updated_at", at).
+		Where("id = ? AND status NOT IN (?, ?)", d.UserID, auth.StatusDeleted, auth.StatusDisabled).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Confirm - r.Builder: %w", err)
+	}
+
+	tag, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Confirm - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Confirm - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// Restore takes a deleted account back to status. It returns
+// auth.ErrUserNotFound when the account is not deleted, or already purged.
+func (r *AccountDeletionRepo) Restore(ctx context.Context, userID uuid.UUID, status auth.Status) error {
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("status", status).
+		Set("deleted_at", nil).
+		Set("updated_at", time.Now().UTC()).
+		Where("id = ? AND status = ?", userID, auth.StatusDeleted).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Restore - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Restore - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// ListPurgeable returns up to limit accounts deleted before deletedBefore,
+// oldest first.
+func (r *AccountDeletionRepo) ListPurgeable(ctx context.Context, deletedBefore time.Time, limit uint64) ([]auth.User, error) {
+	sql, args, err := r.Builder.
+		Select(userColumns...).
+		From("users").
+		Where("status = ? AND deleted_at <= ?", auth.StatusDeleted, deletedBefore).
+		OrderBy("deleted_at").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("AccountDeletionRepo - ListPurgeable - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("AccountDeletionRepo - ListPurgeable - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var users []auth.User
+
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("AccountDeletionRepo - ListPurgeable - scanUser: %w", err)
+		}
+
+		users = append(users, *u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("AccountDeletionRepo - ListPurgeable - rows.Err: %w", err)
+	}
+
+	return users, nil
+}
+
+// Purge erases a deleted account in one transaction: the user row goes, and
+// with it every auth table through ON DELETE CASCADE, along with the user's
+// notification data. Security events are kept for auditing but stripped of
+// the client, location and details that could identify the user. events are
+// written to the outbox alongside. It returns auth.ErrUserNotFound when the
+// account was restored, or deleted again, after deletedBefore.
+func (r *AccountDeletionRepo) Purge(ctx context.Context, userID uuid.UUID, deletedBefore time.Time, events []event.OutboxEvent) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Purge - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Select("id").
+		From("users").
+		Where("id = ? AND status = ? AND deleted_at <= ?", userID, auth.StatusDeleted, deletedBefore).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Purge - r.Builder: %w", err)
+	}
+
+	var id uuid.UUID
+
+	if err = tx.QueryRow(ctx, sql, args...).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.ErrUserNotFound
+		}
+
+		return fmt.Errorf("AccountDeletionRepo - Purge - tx.QueryRow: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Update("security_events").
+		Set("ip_address", nil).
+		Set("user_agent", nil).
+		Set("location_city", nil).
+		Set("location_region", nil).
+		Set("details", nil).
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Purge - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Purge - tx.Exec: %w", err)
+	}
+
+	for _, table := range userDataTables {
+		sql, args, err = r.Builder.
+			Delete(table).
+			Where("user_id = ?", userID).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("AccountDeletionRepo - Purge - r.Builder: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("AccountDeletionRepo - Purge - tx.Exec %s: %w", table, err)
+		}
+	}
+
+	sql, args, err = r.Builder.
+		Delete("users").
+		Where("id = ?", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Purge - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Purge - tx.Exec: %w", err)
+	}
+
+	if err = storeOutboxEvents(ctx, tx, r.Builder, events); err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Purge - storeOutboxEvents: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("AccountDeletionRepo - Purge - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func scanAccountDeletion(row pgx.Row) (*auth.AccountDeletion, error) {
+	var d auth.AccountDeletion
+
+	err := row.Scan(&d.ID, &d.UserID, &d.TokenHash, &d.Feedback, &d.ExpiresAt, &d.ConfirmedAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
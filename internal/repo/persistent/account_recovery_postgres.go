@@ -0,0 +1,313 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+//nolint:gochecknoglobals // column list shared by all recovery session queries
+var recoverySessionColumns = []string{
+	"id", "user_id", "identifier", "identifier_type", "method", "otp_hash", "recovery_token_hash", "attempts",
+	"sent_at", "otp_verified_at", "available_at", "expires_at", "completed_at", "canceled_at",
+	"ip_address", "user_agent", "created_at",
+}
+
+// recoverySessionOpen matches sessions that were neither completed nor canceled.
+const recoverySessionOpen = "completed_at IS NULL AND canceled_at IS NULL"
+
+type AccountRecoveryRepo struct {
+	*postgres.Postgres
+}
+
+func NewAccountRecoveryRepo(pg *postgres.Postgres) *AccountRecoveryRepo {
+	return &AccountRecoveryRepo{pg}
+}
+
+func (r *AccountRecoveryRepo) Store(ctx context.Context, s *auth.RecoverySession) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	s.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Insert("account_recovery_sessions").
+		Columns(recoverySessionColumns...).
+		Values(
+			s.ID, s.UserID, s.Identifier, s.IdentifierType, s.Method, s.OTPHash, s.RecoveryTokenHash, s.Attempts,
+			s.SentAt, s.OTPVerifiedAt, s.AvailableAt, s.ExpiresAt, s.CompletedAt, s.CanceledAt,
+			s.IPAddress, s.UserAgent, s.CreatedAt,
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - Store - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AccountRecoveryRepo) GetByID(ctx context.Context, id uuid.UUID) (*auth.RecoverySession, error) {
+	sql, args, err := r.Builder.
+		Select(recoverySessionColumns...).
+		From("account_recovery_sessions").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("AccountRecoveryRepo - GetByID - r.Builder: %w", err)
+	}
+
+	s, err := scanRecoverySession(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrRecoverySessionNotFound
+		}
+
+		return nil, fmt.Errorf("AccountRecoveryRepo - GetByID - r.Pool.QueryRow: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *AccountRecoveryRepo) GetByTokenHash(ctx context.Context, hash string) (*auth.RecoverySession, error) {
+	sql, args, err := r.Builder.
+		Select(recoverySessionColumns...).
+		From("account_recovery_sessions").
+		Where("recovery_token_hash = ?", hash).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("AccountRecoveryRepo - GetByTokenHash - r.Builder: %w", err)
+	}
+
+	s, err := scanRecoverySession(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrRecoverySessionNotFound
+		}
+
+		return nil, fmt.Errorf("AccountRecoveryRepo - GetByTokenHash - r.Pool.QueryRow: %w", err)
+	}
+
+	return s, nil
+}
+
+// CountSince counts the recoveries started for an identifier after since.
+func (r *AccountRecoveryRepo) CountSince(ctx context.Context, identifier string, since time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)").
+		From("account_recovery_sessions").
+		Where("identifier = ? AND created_at > ?", identifier, since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("AccountRecoveryRepo - CountSince - r.Builder: %w", err)
+	}
+
+	var count int
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("AccountRecoveryRepo - CountSince - r.Pool.QueryRow: %w", err)
+	}
+
+	return count, nil
+}
+
+// Reissue replaces the code of a session that has not been verified yet after
+// it has been sent again. Failed attempts carry over.
+func (r *AccountRecoveryRepo) Reissue(ctx context.Context, s *auth.RecoverySession) error {
+	sql, args, err := r.Builder.
+		Update("account_recovery_sessions").
+		Set("otp_hash", s.OTPHash).
+		Set("sent_at", s.SentAt).
+		Where("id = ? AND otp_verified_at IS NULL AND "+recoverySessionOpen, s.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - Reissue - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - Reissue - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrRecoverySessionUsed
+	}
+
+	return nil
+}
+
+func (r *AccountRecoveryRepo) RecordFailure(ctx context.Context, id uuid.UUID) (int, error) {
+	sql, args, err := r.Builder.
+		Update("account_recovery_sessions").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where("id = ?", id).
+		Suffix("RETURNING attempts").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("AccountRecoveryRepo - RecordFailure - r.Builder: %w", err)
+	}
+
+	var attempts int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrRecoverySessionNotFound
+		}
+
+		return 0, fmt.Errorf("AccountRecoveryRepo - RecordFailure - r.Pool.QueryRow: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// Verify swaps the session's code for its recovery token, saving when the
+// token becomes usable and when it expires. It returns
+// auth.ErrRecoverySessionUsed when the session was verified concurrently.
+func (r *AccountRecoveryRepo) Verify(ctx context.Context, s *auth.RecoverySession) error {
+	sql, args, err := r.Builder.
+		Update("account_recovery_sessions").
+		Set("otp_hash", nil).
+		Set("recovery_token_hash", s.RecoveryTokenHash).
+		Set("otp_verified_at", s.OTPVerifiedAt).
+		Set("available_at", s.AvailableAt).
+		Set("expires_at", s.ExpiresAt).
+		Where("id = ? AND otp_verified_at IS NULL AND "+recoverySessionOpen, s.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - Verify - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - Verify - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrRecoverySessionUsed
+	}
+
+	return nil
+}
+
+// ResetMFA completes a verified session and, in the same transaction, removes
+// the user's authenticator app, SMS factor and recovery codes. A non-nil
+// passwordHash also replaces the password and clears any lockout. It returns
+// auth.ErrRecoverySessionUsed when the session was completed or canceled
+// concurrently.
+func (r *AccountRecoveryRepo) ResetMFA(ctx context.Context, s *auth.RecoverySession, passwordHash *string, at time.Time) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - ResetMFA - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("account_recovery_sessions").
+		Set("completed_at", at).
+		Where("id = ? AND otp_verified_at IS NOT NULL AND "+recoverySessionOpen, s.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - ResetMFA - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - ResetMFA - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrRecoverySessionUsed
+	}
+
+	for _, table := range []string{"mfa_totp", "mfa_sms", "recovery_codes"} {
+		sql, args, err = r.Builder.
+			Delete(table).
+			Where("user_id = ?", *s.UserID).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("AccountRecoveryRepo - ResetMFA - r.Builder: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("AccountRecoveryRepo - ResetMFA - tx.Exec: %w", err)
+		}
+	}
+
+	if passwordHash != nil {
+		sql, args, err = r.Builder.
+			Update("users").
+			Set("password_hash", *passwordHash).
+			Set("failed_login_attempts", 0).
+			Set("last_failed_login_at", nil).
+			Set("lockout_count", 0).
+			Set("locked_until", nil).
+			Set("updated_at", at).
+			Where("id = ?", *s.UserID).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("AccountRecoveryRepo - ResetMFA - r.Builder: %w", err)
+		}
+
+		tag, err = tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("AccountRecoveryRepo - ResetMFA - tx.Exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return auth.ErrUserNotFound
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("AccountRecoveryRepo - ResetMFA - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// CancelForUser cancels the user's recoveries that are still open and
+// unexpired, returning how many there were.
+func (r *AccountRecoveryRepo) CancelForUser(ctx context.Context, userID uuid.UUID, at time.Time) (int64, error) {
+	sql, args, err := r.Builder.
+		Update("account_recovery_sessions").
+		Set("canceled_at", at).
+		Where("user_id = ? AND expires_at > ? AND "+recoverySessionOpen, userID, at).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("AccountRecoveryRepo - CancelForUser - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("AccountRecoveryRepo - CancelForUser - r.Pool.Exec: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func scanRecoverySession(row pgx.Row) (*auth.RecoverySession, error) {
+	var s auth.RecoverySession
+
+	err := row.Scan(
+		&s.ID, &s.UserID, &s.Identifier, &s.IdentifierType, &s.Method, &s.OTPHash, &s.RecoveryTokenHash, &s.Attempts,
+		&s.SentAt, &s.OTPVerifiedAt, &s.AvailableAt, &s.ExpiresAt, &s.CompletedAt, &s.CanceledAt,
+		&s.IPAddress, &s.UserAgent, &s.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
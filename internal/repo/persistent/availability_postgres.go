@@ -0,0 +1,64 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/pkg/postgres"
+)
+
+type AvailabilityCheckRepo struct {
+	*postgres.Postgres
+}
+
+func NewAvailabilityCheckRepo(pg *postgres.Postgres) *AvailabilityCheckRepo {
+	return &AvailabilityCheckRepo{pg}
+}
+
+// Record logs a check from ip and counts the checks it made after since,
+// this one included. Checks from any address made before since are no longer
+// needed and are deleted.
+func (r *AvailabilityCheckRepo) Record(ctx context.Context, ip string, since time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Insert("availability_checks").
+		Columns("ip_address", "created_at").
+		Values(ip, time.Now().UTC()).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("AvailabilityCheckRepo - Record - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return 0, fmt.Errorf("AvailabilityCheckRepo - Record - r.Pool.Exec: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Select("COUNT(*)").
+		From("availability_checks").
+		Where("ip_address = ? AND created_at > ?", ip, since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("AvailabilityCheckRepo - Record - r.Builder: %w", err)
+	}
+
+	var count int
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("AvailabilityCheckRepo - Record - r.Pool.QueryRow: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Delete("availability_checks").
+		Where("created_at <= ?", since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("AvailabilityCheckRepo - Record - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return 0, fmt.Errorf("AvailabilityCheckRepo - Record - r.Pool.Exec: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,370 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const dataExportsInProgressUniqueConstraint = "data_exports_in_progress_unique"
+
+//nolint:gochecknoglobals // column list shared by all data export queries
+var dataExportColumns = []string{
+	"id", "user_id", "status", "object_key", "size", "token_hash", "error",
+	"expires_at", "started_at", "completed_at", "created_at",
+}
+
+// exportTable is a table copied into a user's data export. Only the listed
+// columns are exported, which leaves out password hashes, token hashes and
+// encrypted secrets.
+type exportTable struct {
+	name       string
+	userColumn string
+	orderBy    string
+	columns    []string
+}
+
+//nolint:gochecknoglobals // fixed list of tables holding personal data
+var exportTables = []exportTable{
+	{
+		name: "users", userColumn: "id", orderBy: "created_at",
+		columns: []string{
+			"id", "email", "name", "avatar_url", "email_verified", "email_verified_at",
+			"phone_number", "phone_verified", "phone_verified_at", "status",
+			"last_login_at", "last_login_ip", "deleted_at", "created_at", "updated_at",
+		},
+	},
+	{
+		name: "security_events", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{
+			"id", "event_type", "success", "risk_level", "ip_address", "user_agent",
+			"location_city", "location_region", "location_country", "location_country_code",
+			"device_type", "device_os", "device_browser", "details", "created_at",
+		},
+	},
+	{
+		name: "refresh_tokens", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{
+			"id", "device_info", "ip_address", "user_agent", "expires_at", "revoked_at", "last_used_at", "created_at",
+		},
+	},
+	{
+		name: "oauth_connections", userColumn: "user_id", orderBy: "connected_at",
+		columns: []string{
+			"id", "provider", "provider_user_id", "provider_email", "provider_name", "provider_avatar_url", "connected_at",
+		},
+	},
+	{
+		name: "passkeys", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{"id", "name", "device_type", "backed_up", "transports", "last_used_at", "created_at"},
+	},
+	{
+		name: "mfa_totp", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{"id", "verified_at", "created_at"},
+	},
+	{
+		name: "mfa_sms", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{"id", "phone_number", "verified_at", "created_at"},
+	},
+	{
+		name: "notifications", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{"id", "type", "title", "body", "data", "action_url", "image_url", "read", "read_at", "created_at"},
+	},
+	{
+		name: "notification_delivery_logs", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{
+			"id", "notification_id", "channel", "status", "provider", "provider_message_id",
+			"error_message", "attempts", "created_at", "delivered_at",
+		},
+	},
+	{
+		name: "push_tokens", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{"id", "platform", "device_id", "active", "created_at", "updated_at"},
+	},
+	{
+		name: "notification_preferences", userColumn: "user_id", orderBy: "created_at",
+		columns: []string{
+			"email_enabled", "sms_enabled", "push_enabled", "in_app_enabled",
+			"quiet_start", "quiet_end", "created_at", "updated_at",
+		},
+	},
+}
+
+type DataExportRepo struct {
+	*postgres.Postgres
+}
+
+func NewDataExportRepo(pg *postgres.Postgres) *DataExportRepo {
+	return &DataExportRepo{pg}
+}
+
+// Create queues an export. It returns auth.ErrDataExportInProgress when the
+// user already has one pending or processing.
+func (r *DataExportRepo) Create(ctx context.Context, e *auth.DataExport) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	e.Status = auth.DataExportPending
+	e.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Insert("data_exports").
+		Columns("id", "user_id", "status", "created_at").
+		Values(e.ID, e.UserID, e.Status, e.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("DataExportRepo - Create - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		if isUniqueViolation(err, dataExportsInProgressUniqueConstraint) {
+			return auth.ErrDataExportInProgress
+		}
+
+		return fmt.Errorf("DataExportRepo - Create - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUser returns the user's exports, newest first.
+func (r *DataExportRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]auth.DataExport, error) {
+	sql, args, err := r.Builder.
+		Select(dataExportColumns...).
+		From("data_exports").
+		Where("user_id = ?", userID).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("DataExportRepo - ListByUser - r.Builder: %w", err)
+	}
+
+	exports, err := r.query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("DataExportRepo - ListByUser - %w", err)
+	}
+
+	return exports, nil
+}
+
+func (r *DataExportRepo) GetByTokenHash(ctx context.Context, hash string) (*auth.DataExport, error) {
+	sql, args, err := r.Builder.
+		Select(dataExportColumns...).
+		From("data_exports").
+		Where("token_hash = ?", hash).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("DataExportRepo - GetByTokenHash - r.Builder: %w", err)
+	}
+
+	e, err := scanDataExport(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrDataExportNotFound
+		}
+
+		return nil, fmt.Errorf("DataExportRepo - GetByTokenHash - r.Pool.QueryRow: %w", err)
+	}
+
+	return e, nil
+}
+
+// Claim marks up to limit pending exports as processing and returns them,
+// oldest first. Exports left processing since before staleBefore, by a job
+// that died, are claimed again. Concurrent jobs never claim the same export.
+func (r *DataExportRepo) Claim(ctx context.Context, staleBefore time.Time, limit uint64) ([]auth.DataExport, error) {
+	sql := `
+		UPDATE data_exports SET status = $1, started_at = $2
+		WHERE id IN (
+			SELECT id FROM data_exports
+			WHERE status = $3 OR (status = $1 AND started_at < $4)
+			ORDER BY created_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, status, object_key, size, token_hash, error,
+			expires_at, started_at, completed_at, created_at
+	`
+
+	exports, err := r.query(ctx, sql,
+		auth.DataExportProcessing, time.Now().UTC(), auth.DataExportPending, staleBefore, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("DataExportRepo - Claim - %w", err)
+	}
+
+	return exports, nil
+}
+
+// Complete records the stored archive of a processing export and makes it
+// downloadable until e.ExpiresAt.
+func (r *DataExportRepo) Complete(ctx context.Context, e *auth.DataExport) error {
+	sql, args, err := r.Builder.
+		Update("data_exports").
+		Set("status", auth.DataExportReady).
+		Set("object_key", e.ObjectKey).
+		Set("size", e.Size).
+		Set("token_hash", e.TokenHash).
+		Set("expires_at", e.ExpiresAt).
+		Set("completed_at", e.CompletedAt).
+		Where("id = ? AND status = ?", e.ID, auth.DataExportProcessing).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("DataExportRepo - Complete - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("DataExportRepo - Complete - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrDataExportNotFound
+	}
+
+	e.Status = auth.DataExportReady
+
+	return nil
+}
+
+func (r *DataExportRepo) Fail(ctx context.Context, id uuid.UUID, reason string) error {
+	sql, args, err := r.Builder.
+		Update("data_exports").
+		Set("status", auth.DataExportFailed).
+		Set("error", reason).
+		Set("completed_at", time.Now().UTC()).
+		Where("id = ? AND status = ?", id, auth.DataExportProcessing).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("DataExportRepo - Fail - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("DataExportRepo - Fail - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// ListExpired returns up to limit ready exports whose link expired before
+// now, oldest first.
+func (r *DataExportRepo) ListExpired(ctx context.Context, now time.Time, limit uint64) ([]auth.DataExport, error) {
+	sql, args, err := r.Builder.
+		Select(dataExportColumns...).
+		From("data_exports").
+		Where("status = ? AND expires_at <= ?", auth.DataExportReady, now).
+		OrderBy("expires_at").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("DataExportRepo - ListExpired - r.Builder: %w", err)
+	}
+
+	exports, err := r.query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("DataExportRepo - ListExpired - %w", err)
+	}
+
+	return exports, nil
+}
+
+// Expire forgets the archive of an export whose link has expired.
+func (r *DataExportRepo) Expire(ctx context.Context, id uuid.UUID) error {
+	sql, args, err := r.Builder.
+		Update("data_exports").
+		Set("status", auth.DataExportExpired).
+		Set("object_key", nil).
+		Set("token_hash", nil).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("DataExportRepo - Expire - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("DataExportRepo - Expire - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// Collect reads everything stored about the user, one JSON array per table,
+// from a single snapshot of the database.
+func (r *DataExportRepo) Collect(ctx context.Context, userID uuid.UUID) ([]auth.DataExportTable, error) {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("DataExportRepo - Collect - r.Pool.BeginTx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // read-only
+
+	tables := make([]auth.DataExportTable, 0, len(exportTables))
+
+	for _, t := range exportTables {
+		rows := r.Builder.
+			Select(t.columns...).
+			From(t.name).
+			Where(t.userColumn+" = ?", userID)
+
+		sql, args, err := r.Builder.
+			Select(fmt.Sprintf("COALESCE(json_agg(t ORDER BY t.%s), '[]')", t.orderBy)).
+			FromSelect(rows, "t").
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("DataExportRepo - Collect - r.Builder: %w", err)
+		}
+
+		var data []byte
+
+		if err = tx.QueryRow(ctx, sql, args...).Scan(&data); err != nil {
+			return nil, fmt.Errorf("DataExportRepo - Collect - tx.QueryRow %s: %w", t.name, err)
+		}
+
+		tables = append(tables, auth.DataExportTable{Name: t.name, Rows: data})
+	}
+
+	return tables, nil
+}
+
+func (r *DataExportRepo) query(ctx context.Context, sql string, args ...any) ([]auth.DataExport, error) {
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []auth.DataExport
+
+	for rows.Next() {
+		e, err := scanDataExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanDataExport: %w", err)
+		}
+
+		exports = append(exports, *e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return exports, nil
+}
+
+func scanDataExport(row pgx.Row) (*auth.DataExport, error) {
+	var e auth.DataExport
+
+	err := row.Scan(&e.ID, &e.UserID, &e.Status, &e.ObjectKey, &e.Size, &e.TokenHash, &e.Error,
+		&e.ExpiresAt, &e.StartedAt, &e.CompletedAt, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
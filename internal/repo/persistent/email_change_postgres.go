@@ -0,0 +1,237 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/event"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+//nolint:gochecknoglobals // column list shared by all email change queries
+var emailChangeColumns = []string{
+	"id", "user_id", "new_email", "token_hash", "reverts_id", "expires_at", "confirmed_at", "created_at",
+}
+
+// emailChangePending matches requested changes that are still waiting for
+// confirmation; reverts are left out so a new request cannot void them.
+const emailChangePending = "confirmed_at IS NULL AND reverts_id IS NULL"
+
+type EmailChangeRepo struct {
+	*postgres.Postgres
+}
+
+func NewEmailChangeRepo(pg *postgres.Postgres) *EmailChangeRepo {
+	return &EmailChangeRepo{pg}
+}
+
+// Store saves a change request. A requested change replaces the user's
+// pending one, so only the latest link works; a revert never replaces anything.
+func (r *EmailChangeRepo) Store(ctx context.Context, c *auth.EmailChange) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	c.CreatedAt = time.Now().UTC()
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("EmailChangeRepo - Store - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	if !c.IsRevert() {
+		sql, args, err := r.Builder.
+			Delete("email_change_requests").
+			Where("user_id = ? AND "+emailChangePending, c.UserID).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("EmailChangeRepo - Store - r.Builder: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("EmailChangeRepo - Store - tx.Exec: %w", err)
+		}
+	}
+
+	sql, args, err := r.Builder.
+		Insert("email_change_requests").
+		Columns(emailChangeColumns...).
+		Values(c.ID, c.UserID, c.NewEmail, c.TokenHash, c.RevertsID, c.ExpiresAt, c.ConfirmedAt, c.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("EmailChangeRepo - Store - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("EmailChangeRepo - Store - tx.Exec: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("EmailChangeRepo - Store - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EmailChangeRepo) GetByTokenHash(ctx context.Context, hash string) (*auth.EmailChange, error) {
+	sql, args, err := r.Builder.
+		Select(emailChangeColumns...).
+		From("email_change_requests").
+		Where("token_hash = ?", hash).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("EmailChangeRepo - GetByTokenHash - r.Builder: %w", err)
+	}
+
+	c, err := scanEmailChange(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrEmailChangeNotFound
+		}
+
+		return nil, fmt.Errorf("EmailChangeRepo - GetByTokenHash - r.Pool.QueryRow: %w", err)
+	}
+
+	return c, nil
+}
+
+// CountSince counts the changes the user requested after since. Reverts are
+// not counted.
+func (r *EmailChangeRepo) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)").
+		From("email_change_requests").
+		Where("user_id = ? AND reverts_id IS NULL AND created_at > ?", userID, since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("EmailChangeRepo - CountSince - r.Builder: %w", err)
+	}
+
+	var count int
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("EmailChangeRepo - CountSince - r.Pool.QueryRow: %w", err)
+	}
+
+	return count, nil
+}
+
+// Confirm consumes the request and moves the user to its address in one
+// transaction, storing revert, the request that undoes it, and writing events
+// to the outbox alongside. Revert links of earlier changes are kept, so each
+// can undo its change until it expires. Confirming a revert also voids the
+// user's pending changes. It returns auth.ErrEmailChangeUsed when the request
+// was confirmed concurrently and auth.ErrEmailAlreadyExists when another
+// account took the address in the meantime.
+func (r *EmailChangeRepo) Confirm(ctx context.Context, c, revert *auth.EmailChange, at time.Time, events []event.OutboxEvent) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("EmailChangeRepo - Confirm - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("email_change_requests").
+		Set("confirmed_at", at).
+		Where("id = ? AND confirmed_at IS NULL", c.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("EmailChangeRepo - Confirm - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("EmailChangeRepo - Confirm - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrEmailChangeUsed
+	}
+
+	sql, args, err = r.Builder.
+		Update("users").
+		Set("email", c.NewEmail).
+		Set("email_verified", true).
+		Set("email_verified_at", at).
+		Set("updated_at", at).
+		Where("id = ?", c.UserID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("EmailChangeRepo - Confirm - r.Builder: %w", err)
+	}
+
+	tag, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		if isUniqueViolation(err, usersEmailUniqueConstraint) {
+			return auth.ErrEmailAlreadyExists
+		}
+
+		return fmt.Errorf("EmailChangeRepo - Confirm - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	if c.IsRevert() {
+		sql, args, err = r.Builder.
+			Delete("email_change_requests").
+			Where("user_id = ? AND "+emailChangePending, c.UserID).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("EmailChangeRepo - Confirm - r.Builder: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("EmailChangeRepo - Confirm - tx.Exec: %w", err)
+		}
+	}
+
+	if revert != nil {
+		if revert.ID == uuid.Nil {
+			revert.ID = uuid.New()
+		}
+
+		revert.CreatedAt = at
+
+		sql, args, err = r.Builder.
+			Insert("email_change_requests").
+			Columns(emailChangeColumns...).
+			Values(revert.ID, revert.UserID, revert.NewEmail, revert.TokenHash, revert.RevertsID, revert.ExpiresAt, nil, revert.CreatedAt).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("EmailChangeRepo - Confirm - r.Builder: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("EmailChangeRepo - Confirm - tx.Exec: %w", err)
+		}
+	}
+
+	if err = storeOutboxEvents(ctx, tx, r.Builder, events); err != nil {
+		return fmt.Errorf("EmailChangeRepo - Confirm - storeOutboxEvents: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("EmailChangeRepo - Confirm - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func scanEmailChange(row pgx.Row) (*auth.EmailChange, error) {
+	var c auth.EmailChange
+
+	err := row.Scan(&c.ID, &c.UserID, &c.NewEmail, &c.TokenHash, &c.RevertsID, &c.ExpiresAt, &c.ConfirmedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
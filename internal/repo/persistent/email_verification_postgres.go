@@ -0,0 +1,150 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type EmailVerificationRepo struct {
+	*postgres.Postgres
+}
+
+func NewEmailVerificationRepo(pg *postgres.Postgres) *EmailVerificationRepo {
+	return &EmailVerificationRepo{pg}
+}
+
+func (r *EmailVerificationRepo) Store(ctx context.Context, v *auth.EmailVerification) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+
+	v.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Insert("email_verifications").
+		Columns("id", "user_id", "email", "token_hash", "expires_at", "created_at").
+		Values(v.ID, v.UserID, v.Email, v.TokenHash, v.ExpiresAt, v.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("EmailVerificationRepo - Store - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("EmailVerificationRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EmailVerificationRepo) GetByHash(ctx context.Context, hash string) (*auth.EmailVerification, error) {
+	sql, args, err := r.Builder.
+		Select("id", "user_id", "email", "token_hash", "expires_at", "verified_at", "created_at").
+		From("email_verifications").
+		Where("token_hash = ?", hash).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("EmailVerificationRepo - GetByHash - r.Builder: %w", err)
+	}
+
+	var v auth.EmailVerification
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&v.ID, &v.UserID, &v.Email, &v.TokenHash, &v.ExpiresAt, &v.VerifiedAt, &v.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrVerificationNotFound
+		}
+
+		return nil, fmt.Errorf("EmailVerificationRepo - GetByHash - r.Pool.QueryRow: %w", err)
+	}
+
+	return &v, nil
+}
+
+func (r *EmailVerificationRepo) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)").
+		From("email_verifications").
+		Where("user_id = ? AND created_at > ?", userID, since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("EmailVerificationRepo - CountSince - r.Builder: %w", err)
+	}
+
+	var count int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("EmailVerificationRepo - CountSince - r.Pool.QueryRow: %w", err)
+	}
+
+	return count, nil
+}
+
+// Confirm consumes the token and marks the user's email as verified in one
+// transaction, activating accounts that were pending verification. It returns
+// auth.ErrVerificationUsed when the token was consumed concurrently and
+// auth.ErrVerificationNotFound when the account no longer has that address.
+func (r *EmailVerificationRepo) Confirm(ctx context.Context, v *auth.EmailVerification, at time.Time) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("EmailVerificationRepo - Confirm - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("email_verifications").
+		Set("verified_at", at).
+		Where("id = ? AND verified_at IS NULL", v.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("EmailVerificationRepo - Confirm - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("EmailVerificationRepo - Confirm - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrVerificationUsed
+	}
+
+	sql, args, err = r.Builder.
+		Update("users").
+		Set("email_verified", true).
+		Set("email_verified_at", at).
+		Set("status", sq.Expr("CASE WHEN status = ? THEN ? ELSE status END",
+			auth.StatusPendingVerification, auth.StatusActive)).
+		Set("updated_at", at).
+		Where("id = ? AND email = ?", v.UserID, v.Email).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("EmailVerificationRepo - Confirm - r.Builder: %w", err)
+	}
+
+	tag, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("EmailVerificationRepo - Confirm - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrVerificationNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("EmailVerificationRepo - Confirm - tx.Commit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+//nolint:gochecknoglobals // column list shared by all magic link queries
+var magicLinkColumns = []string{
+	"id", "identifier", "identifier_type", "delivery_method", "token_hash", "otp_hash", "user_id",
+	"attempts", "sent_at", "expires_at", "verified_at", "ip_address", "user_agent", "created_at",
+}
+
+type MagicLinkRepo struct {
+	*postgres.Postgres
+}
+
+func NewMagicLinkRepo(pg *postgres.Postgres) *MagicLinkRepo {
+	return &MagicLinkRepo{pg}
+}
+
+func (r *MagicLinkRepo) Store(ctx context.Context, s *auth.MagicLinkSession) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	s.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Insert("magic_link_sessions").
+		Columns(magicLinkColumns...).
+		Values(
+			s.ID, s.Identifier, s.IdentifierType, s.Method, s.TokenHash, s.OTPHash, s.UserID,
+			s.Attempts, s.SentAt, s.ExpiresAt, s.VerifiedAt, s.IPAddress, s.UserAgent, s.CreatedAt,
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("MagicLinkRepo - Store - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("MagicLinkRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MagicLinkRepo) GetByID(ctx context.Context, id uuid.UUID) (*auth.MagicLinkSession, error) {
+	sql, args, err := r.Builder.
+		Select(magicLinkColumns...).
+		From("magic_link_sessions").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("MagicLinkRepo - GetByID - r.Builder: %w", err)
+	}
+
+	var s auth.MagicLinkSession
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&s.ID, &s.Identifier, &s.IdentifierType, &s.Method, &s.TokenHash, &s.OTPHash, &s.UserID,
+		&s.Attempts, &s.SentAt, &s.ExpiresAt, &s.VerifiedAt, &s.IPAddress, &s.UserAgent, &s.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrMagicLinkNotFound
+		}
+
+		return nil, fmt.Errorf("MagicLinkRepo - GetByID - r.Pool.QueryRow: %w", err)
+	}
+
+	return &s, nil
+}
+
+// CountSince counts the sessions requested for an identifier after since.
+func (r *MagicLinkRepo) CountSince(ctx context.Context, identifier string, identifierType auth.IdentifierType, since time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)").
+		From("magic_link_sessions").
+		Where("identifier = ? AND identifier_type = ? AND created_at > ?", identifier, identifierType, since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("MagicLinkRepo - CountSince - r.Builder: %w", err)
+	}
+
+	var count int
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("MagicLinkRepo - CountSince - r.Pool.QueryRow: %w", err)
+	}
+
+	return count, nil
+}
+
+// Reissue replaces the token and code of a pending session after it has been
+// sent again. Failed attempts carry over.
+func (r *MagicLinkRepo) Reissue(ctx context.Context, s *auth.MagicLinkSession) error {
+	sql, args, err := r.Builder.
+		Update("magic_link_sessions").
+		Set("token_hash", s.TokenHash).
+		Set("otp_hash", s.OTPHash).
+		Set("delivery_method", s.Method).
+		Set("sent_at", s.SentAt).
+		Where("id = ? AND verified_at IS NULL", s.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("MagicLinkRepo - Reissue - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("MagicLinkRepo - Reissue - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrMagicLinkUsed
+	}
+
+	return nil
+}
+
+func (r *MagicLinkRepo) RecordFailure(ctx context.Context, id uuid.UUID) (int, error) {
+	sql, args, err := r.Builder.
+		Update("magic_link_sessions").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where("id = ?", id).
+		Suffix("RETURNING attempts").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("MagicLinkRepo - RecordFailure - r.Builder: %w", err)
+	}
+
+	var attempts int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrMagicLinkNotFound
+		}
+
+		return 0, fmt.Errorf("MagicLinkRepo - RecordFailure - r.Pool.QueryRow: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// Consume marks the session as used. When a link for an email identifier
+// went to that address, the user's email is marked as verified in the same
+// transaction, activating an account that was pending verification. It
+// returns auth.ErrMagicLinkUsed when the session was consumed concurrently.
+func (r *MagicLinkRepo) Consume(ctx context.Context, s *auth.MagicLinkSession, at time.Time) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("MagicLinkRepo - Consume - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("magic_link_sessions").
+		Set("verified_at", at).
+		Where("id = ? AND verified_at IS NULL", s.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("MagicLinkRepo - Consume - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("MagicLinkRepo - Consume - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrMagicLinkUsed
+	}
+
+	if s.UserID != nil && s.IdentifierType == auth.IdentifierEmail && s.Method == auth.MagicLinkEmail {
+		sql, args, err = r.Builder.
+			Update("users").
+			Set("email_verified", true).
+			Set("email_verified_at", sq.Expr("COALESCE(email_verified_at, ?)", at)).
+			Set("status", sq.Expr("CASE WHEN status = ? THEN ? ELSE status END",
+				auth.StatusPendingVerification, auth.StatusActive)).
+			Set("updated_at", at).
+			Where("id = ? AND email = ?", *s.UserID, s.Identifier).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("MagicLinkRepo - Consume - r.Builder: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("MagicLinkRepo - Consume - tx.Exec: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("MagicLinkRepo - Consume - tx.Commit: %w", err)
+	}
+
+	return nil
+}
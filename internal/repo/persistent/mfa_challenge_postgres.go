@@ -0,0 +1,187 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type MFAChallengeRepo struct {
+	*postgres.Postgres
+}
+
+func NewMFAChallengeRepo(pg *postgres.Postgres) *MFAChallengeRepo {
+	return &MFAChallengeRepo{pg}
+}
+
+func (r *MFAChallengeRepo) Store(ctx context.Context, c *auth.MFAChallenge) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	c.CreatedAt = time.Now().UTC()
+
+	methods := make([]string, len(c.AvailableMethods))
+	for i, m := range c.AvailableMethods {
+		methods[i] = string(m)
+	}
+
+	sql, args, err := r.Builder.
+		Insert("mfa_challenges").
+		Columns("id", "user_id", "challenge_token_hash", "available_methods", "remember_me",
+			"code_hash", "code_expires_at", "code_sent_at",
+			"ip_address", "user_agent", "expires_at", "created_at").
+		Values(c.ID, c.UserID, c.TokenHash, methods, c.RememberMe,
+			c.CodeHash, c.CodeExpiresAt, c.CodeSentAt,
+			c.IPAddress, c.UserAgent, c.ExpiresAt, c.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("MFAChallengeRepo - Store - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("MFAChallengeRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MFAChallengeRepo) GetByHash(ctx context.Context, hash string) (*auth.MFAChallenge, error) {
+	sql, args, err := r.Builder.
+		Select("id", "user_id", "challenge_token_hash", "available_methods", "remember_me", "attempts",
+			"code_hash", "code_expires_at", "code_sent_at", "ip_address", "user_agent", "expires_at", "completed_at", "created_at").
+		From("mfa_challenges").
+		Where("challenge_token_hash = ?", hash).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("MFAChallengeRepo - GetByHash - r.Builder: %w", err)
+	}
+
+	var (
+		c       auth.MFAChallenge
+		methods []string
+	)
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&c.ID, &c.UserID, &c.TokenHash, &methods, &c.RememberMe, &c.Attempts,
+		&c.CodeHash, &c.CodeExpiresAt, &c.CodeSentAt, &c.IPAddress, &c.UserAgent, &c.ExpiresAt, &c.CompletedAt, &c.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrMFAChallengeNotFound
+		}
+
+		return nil, fmt.Errorf("MFAChallengeRepo - GetByHash - r.Pool.QueryRow: %w", err)
+	}
+
+	c.AvailableMethods = make([]auth.MFAMethod, len(methods))
+	for i, m := range methods {
+		c.AvailableMethods[i] = auth.MFAMethod(m)
+	}
+
+	return &c, nil
+}
+
+// SetCode replaces the code emailed for a pending challenge. It returns
+// ErrMFAChallengeCompleted once the challenge has been used.
+func (r *MFAChallengeRepo) SetCode(ctx context.Context, id uuid.UUID, hash string, expiresAt, sentAt time.Time) error {
+	sql, args, err := r.Builder.
+		Update("mfa_challenges").
+		Set("code_hash", hash).
+		Set("code_expires_at", expiresAt).
+		Set("code_sent_at", sentAt).
+		Where("id = ? AND completed_at IS NULL", id).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("MFAChallengeRepo - SetCode - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("MFAChallengeRepo - SetCode - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrMFAChallengeCompleted
+	}
+
+	return nil
+}
+
+// RecordFailure increments the challenge's failed attempt counter and returns the new value.
+func (r *MFAChallengeRepo) RecordFailure(ctx context.Context, id uuid.UUID) (int, error) {
+	sql, args, err := r.Builder.
+		Update("mfa_challenges").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where("id = ?", id).
+		Suffix("RETURNING attempts").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("MFAChallengeRepo - RecordFailure - r.Builder: %w", err)
+	}
+
+	var attempts int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrMFAChallengeNotFound
+		}
+
+		return 0, fmt.Errorf("MFAChallengeRepo - RecordFailure - r.Pool.QueryRow: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// FailuresSince sums the failed attempts across the user's challenges created after since.
+func (r *MFAChallengeRepo) FailuresSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Select("COALESCE(SUM(attempts), 0)").
+		From("mfa_challenges").
+		Where("user_id = ? AND created_at > ?", userID, since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("MFAChallengeRepo - FailuresSince - r.Builder: %w", err)
+	}
+
+	var failures int
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("MFAChallengeRepo - FailuresSince - r.Pool.QueryRow: %w", err)
+	}
+
+	return failures, nil
+}
+
+// Complete marks the challenge as used. It returns ErrMFAChallengeCompleted if
+// another request completed it first.
+func (r *MFAChallengeRepo) Complete(ctx context.Context, id uuid.UUID, at time.Time) error {
+	sql, args, err := r.Builder.
+		Update("mfa_challenges").
+		Set("completed_at", at).
+		Where("id = ? AND completed_at IS NULL", id).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("MFAChallengeRepo - Complete - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("MFAChallengeRepo - Complete - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrMFAChallengeCompleted
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	oauthProviderUserConstraint = "oauth_connections_provider_user"
+	oauthUserProviderConstraint = "oauth_connections_user_provider"
+)
+
+//nolint:gochecknoglobals // column list shared by all oauth connection queries
+var oauthConnectionColumns = []string{
+	"id", "user_id", "provider", "provider_user_id", "provider_email", "provider_name", "provider_avatar_url",
+	"access_token_encrypted", "refresh_token_encrypted", "token_expires_at", "connected_at",
+}
+
+type OAuthConnectionRepo struct {
+	*postgres.Postgres
+}
+
+func NewOAuthConnectionRepo(pg *postgres.Postgres) *OAuthConnectionRepo {
+	return &OAuthConnectionRepo{pg}
+}
+
+func (r *OAuthConnectionRepo) GetByProvider(ctx context.Context, provider auth.OAuthProvider, providerUserID string) (*auth.OAuthConnection, error) {
+	sql, args, err := r.Builder.
+		Select(oauthConnectionColumns...).
+		From("oauth_connections").
+		Where("provider = ? AND provider_user_id = ?", provider, providerUserID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("OAuthConnectionRepo - GetByProvider - r.Builder: %w", err)
+	}
+
+	c, err := scanOAuthConnection(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrOAuthConnectionNotFound
+		}
+
+		return nil, fmt.Errorf("OAuthConnectionRepo - GetByProvider - r.Pool.QueryRow: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *OAuthConnectionRepo) ListByUserID(ctx context.Context, userID uuid.UUID) ([]auth.OAuthConnection, error) {
+	sql, args, err := r.Builder.
+		Select(oauthConnectionColumns...).
+		From("oauth_connections").
+		Where("user_id = ?", userID).
+		OrderBy("connected_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("OAuthConnectionRepo - ListByUserID - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("OAuthConnectionRepo - ListByUserID - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var connections []auth.OAuthConnection
+
+	for rows.Next() {
+		c, err := scanOAuthConnection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("OAuthConnectionRepo - ListByUserID - rows.Scan: %w", err)
+		}
+
+		connections = append(connections, *c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("OAuthConnectionRepo - ListByUserID - rows.Err: %w", err)
+	}
+
+	return connections, nil
+}
+
+// Create links an existing user. It returns auth.ErrOAuthConnectionExists
+// when the provider account or the user's slot for that provider is taken.
+func (r *OAuthConnectionRepo) Create(ctx context.Context, c *auth.OAuthConnection) error {
+	sql, args, err := r.insert(c).ToSql()
+	if err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - Create - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		if isOAuthConnectionConflict(err) {
+			return auth.ErrOAuthConnectionExists
+		}
+
+		return fmt.Errorf("OAuthConnectionRepo - Create - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// CreateWithUser signs up a new user and links the provider account in one
+// transaction.
+func (r *OAuthConnectionRepo) CreateWithUser(ctx context.Context, u *auth.User, c *auth.OAuthConnection) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - CreateWithUser - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := insertUser(r.Builder, u).ToSql()
+	if err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - CreateWithUser - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		if isUniqueViolation(err, usersEmailUniqueConstraint) {
+			return auth.ErrEmailAlreadyExists
+		}
+
+		return fmt.Errorf("OAuthConnectionRepo - CreateWithUser - tx.Exec: %w", err)
+	}
+
+	c.UserID = u.ID
+
+	sql, args, err = r.insert(c).ToSql()
+	if err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - CreateWithUser - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		if isOAuthConnectionConflict(err) {
+			return auth.ErrOAuthConnectionExists
+		}
+
+		return fmt.Errorf("OAuthConnectionRepo - CreateWithUser - tx.Exec: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - CreateWithUser - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTokens refreshes the stored tokens and profile after a sign-in.
+func (r *OAuthConnectionRepo) UpdateTokens(ctx context.Context, c *auth.OAuthConnection) error {
+	sql, args, err := r.Builder.
+		Update("oauth_connections").
+		Set("provider_email", c.ProviderEmail).
+		Set("provider_name", c.ProviderName).
+		Set("provider_avatar_url", c.ProviderAvatarURL).
+		Set("access_token_encrypted", c.AccessTokenEncrypted).
+		Set("refresh_token_encrypted", sq.Expr("COALESCE(?, refresh_token_encrypted)", c.RefreshTokenEncrypted)).
+		Set("token_expires_at", c.TokenExpiresAt).
+		Where("id = ?", c.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - UpdateTokens - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - UpdateTokens - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrOAuthConnectionNotFound
+	}
+
+	return nil
+}
+
+func (r *OAuthConnectionRepo) Delete(ctx context.Context, userID uuid.UUID, provider auth.OAuthProvider) error {
+	sql, args, err := r.Builder.
+		Delete("oauth_connections").
+		Where("user_id = ? AND provider = ?", userID, provider).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - Delete - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("OAuthConnectionRepo - Delete - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrOAuthConnectionNotFound
+	}
+
+	return nil
+}
+
+func (r *OAuthConnectionRepo) insert(c *auth.OAuthConnection) sq.InsertBuilder {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	c.ConnectedAt = time.Now().UTC()
+
+	return r.Builder.
+		Insert("oauth_connections").
+		Columns(oauthConnectionColumns...).
+		Values(
+			c.ID, c.UserID, c.Provider, c.ProviderUserID, c.ProviderEmail, c.ProviderName, c.ProviderAvatarURL,
+			c.AccessTokenEncrypted, c.RefreshTokenEncrypted, c.TokenExpiresAt, c.ConnectedAt,
+		)
+}
+
+func scanOAuthConnection(row pgx.Row) (*auth.OAuthConnection, error) {
+	var c auth.OAuthConnection
+
+	err := row.Scan(
+		&c.ID, &c.UserID, &c.Provider, &c.ProviderUserID, &c.ProviderEmail, &c.ProviderName, &c.ProviderAvatarURL,
+		&c.AccessTokenEncrypted, &c.RefreshTokenEncrypted, &c.TokenExpiresAt, &c.ConnectedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func isOAuthConnectionConflict(err error) bool {
+	return isUniqueViolation(err, oauthProviderUserConstraint) || isUniqueViolation(err, oauthUserProviderConstraint)
+}
@@ -9,6 +9,7 @@
 	"github.com/evrone/go-clean-template/internal/entity/event"
 	"github.com/evrone/go-clean-template/pkg/postgres"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type OutboxRepo struct {
@@ -24,15 +25,7 @@ func (r *OutboxRepo) Store(ctx context.Context, events []event.OutboxEvent) erro
 		return nil
 	}
 
-	query := r.Builder.
-		Insert("outbox_events").
-		Columns("id", "aggregate_type", "aggregate_id", "event_type", "payload", "created_at")
-
-	for i := range events {
-		query = query.Values(events[i].ID, events[i].AggregateType, events[i].AggregateID, events[i].EventType, events[i].Payload, events[i].CreatedAt)
-	}
-
-	sql, args, err := query.ToSql()
+	sql, args, err := insertOutboxEvents(r.Builder, events).ToSql()
 	if err != nil {
 		return fmt.Errorf("OutboxRepo.Store - build query: %w", err)
 	}
@@ -128,3 +121,34 @@ func (r *OutboxRepo) MarkFailed(ctx context.Context, id uuid.UUID, publishErr er
 
 	return nil
 }
+
+func insertOutboxEvents(b sq.StatementBuilderType, events []event.OutboxEvent) sq.InsertBuilder {
+	query := b.
+		Insert("outbox_events").
+		Columns("id", "aggregate_type", "aggregate_id", "event_type", "payload", "created_at")
+
+	for i := range events {
+		query = query.Values(events[i].ID, events[i].AggregateType, events[i].AggregateID, events[i].EventType, events[i].Payload, events[i].CreatedAt)
+	}
+
+	return query
+}
+
+// storeOutboxEvents writes events within tx, so repos that change state can
+// publish them atomically with the change.
+func storeOutboxEvents(ctx context.Context, tx pgx.Tx, b sq.StatementBuilderType, events []event.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	sql, args, err := insertOutboxEvents(b, events).ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const passkeysCredentialUniqueConstraint = "passkeys_credential_unique"
+
+//nolint:gochecknoglobals // column list shared by all passkey queries
+var passkeyColumns = []string{
+	"id", "user_id", "credential_id", "public_key", "name", "aaguid", "sign_count",
+	"transports", "device_type", "backed_up", "last_used_at", "created_at",
+}
+
+type PasskeyRepo struct {
+	*postgres.Postgres
+}
+
+func NewPasskeyRepo(pg *postgres.Postgres) *PasskeyRepo {
+	return &PasskeyRepo{pg}
+}
+
+func (r *PasskeyRepo) ListByUserID(ctx context.Context, userID uuid.UUID) ([]auth.Passkey, error) {
+	sql, args, err := r.Builder.
+		Select(passkeyColumns...).
+		From("passkeys").
+		Where("user_id = ?", userID).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("PasskeyRepo - ListByUserID - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("PasskeyRepo - ListByUserID - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var passkeys []auth.Passkey
+
+	for rows.Next() {
+		p, err := scanPasskey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("PasskeyRepo - ListByUserID - rows.Scan: %w", err)
+		}
+
+		passkeys = append(passkeys, *p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("PasskeyRepo - ListByUserID - rows.Err: %w", err)
+	}
+
+	return passkeys, nil
+}
+
+func (r *PasskeyRepo) GetByCredentialID(ctx context.Context, credentialID []byte) (*auth.Passkey, error) {
+	sql, args, err := r.Builder.
+		Select(passkeyColumns...).
+		From("passkeys").
+		Where("credential_id = ?", credentialID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("PasskeyRepo - GetByCredentialID - r.Builder: %w", err)
+	}
+
+	p, err := scanPasskey(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrPasskeyNotFound
+		}
+
+		return nil, fmt.Errorf("PasskeyRepo - GetByCredentialID - r.Pool.QueryRow: %w", err)
+	}
+
+	return p, nil
+}
+
+// Create stores a new passkey. It returns auth.ErrPasskeyExists when the
+// credential is already registered.
+func (r *PasskeyRepo) Create(ctx context.Context, p *auth.Passkey) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+
+	p.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Insert("passkeys").
+		Columns(passkeyColumns...).
+		Values(
+			p.ID, p.UserID, p.CredentialID, p.PublicKey, p.Name, p.AAGUID, p.SignCount,
+			p.Transports, p.DeviceType, p.BackedUp, p.LastUsedAt, p.CreatedAt,
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PasskeyRepo - Create - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		if isUniqueViolation(err, passkeysCredentialUniqueConstraint) {
+			return auth.ErrPasskeyExists
+		}
+
+		return fmt.Errorf("PasskeyRepo - Create - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// Rename changes the name of one of the user's passkeys and returns it.
+func (r *PasskeyRepo) Rename(ctx context.Context, userID, id uuid.UUID, name string) (*auth.Passkey, error) {
+	sql, args, err := r.Builder.
+		Update("passkeys").
+		Set("name", name).
+		Where("id = ? AND user_id = ?", id, userID).
+		Suffix("RETURNING " + strings.Join(passkeyColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("PasskeyRepo - Rename - r.Builder: %w", err)
+	}
+
+	p, err := scanPasskey(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrPasskeyNotFound
+		}
+
+		return nil, fmt.Errorf("PasskeyRepo - Rename - r.Pool.QueryRow: %w", err)
+	}
+
+	return p, nil
+}
+
+// RecordUse stores the signature counter and backup state reported by a
+// successful assertion.
+func (r *PasskeyRepo) RecordUse(ctx context.Context, id uuid.UUID, signCount int64, backedUp bool, at time.Time) error {
+	sql, args, err := r.Builder.
+		Update("passkeys").
+		Set("sign_count", signCount).
+		Set("backed_up", backedUp).
+		Set("last_used_at", at).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PasskeyRepo - RecordUse - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PasskeyRepo - RecordUse - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+func (r *PasskeyRepo) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	sql, args, err := r.Builder.
+		Delete("passkeys").
+		Where("id = ? AND user_id = ?", id, userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PasskeyRepo - Delete - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PasskeyRepo - Delete - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+func scanPasskey(row pgx.Row) (*auth.Passkey, error) {
+	var p auth.Passkey
+
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.CredentialID, &p.PublicKey, &p.Name, &p.AAGUID, &p.SignCount,
+		&p.Transports, &p.DeviceType, &p.BackedUp, &p.LastUsedAt, &p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,162 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type PasswordResetRepo struct {
+	*postgres.Postgres
+}
+
+func NewPasswordResetRepo(pg *postgres.Postgres) *PasswordResetRepo {
+	return &PasswordResetRepo{pg}
+}
+
+func (r *PasswordResetRepo) Store(ctx context.Context, pr *auth.PasswordReset) error {
+	if pr.ID == uuid.Nil {
+		pr.ID = uuid.New()
+	}
+
+	pr.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Insert("password_resets").
+		Columns("id", "user_id", "token_hash", "expires_at", "created_at").
+		Values(pr.ID, pr.UserID, pr.TokenHash, pr.ExpiresAt, pr.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo - Store - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PasswordResetRepo) GetByHash(ctx context.Context, hash string) (*auth.PasswordReset, error) {
+	sql, args, err := r.Builder.
+		Select("id", "user_id", "token_hash", "expires_at", "used_at", "created_at").
+		From("password_resets").
+		Where("token_hash = ?", hash).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("PasswordResetRepo - GetByHash - r.Builder: %w", err)
+	}
+
+	var pr auth.PasswordReset
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&pr.ID, &pr.UserID, &pr.TokenHash, &pr.ExpiresAt, &pr.UsedAt, &pr.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrPasswordResetNotFound
+		}
+
+		return nil, fmt.Errorf("PasswordResetRepo - GetByHash - r.Pool.QueryRow: %w", err)
+	}
+
+	return &pr, nil
+}
+
+func (r *PasswordResetRepo) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)").
+		From("password_resets").
+		Where("user_id = ? AND created_at > ?", userID, since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("PasswordResetRepo - CountSince - r.Builder: %w", err)
+	}
+
+	var count int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("PasswordResetRepo - CountSince - r.Pool.QueryRow: %w", err)
+	}
+
+	return count, nil
+}
+
+// Consume marks the token used, invalidates the user's other outstanding reset
+// tokens and stores the new password hash in one transaction. It returns
+// auth.ErrPasswordResetUsed when the token was consumed concurrently.
+func (r *PasswordResetRepo) Consume(ctx context.Context, pr *auth.PasswordReset, passwordHash string, at time.Time) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo - Consume - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("password_resets").
+		Set("used_at", at).
+		Where("id = ? AND used_at IS NULL", pr.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo - Consume - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo - Consume - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrPasswordResetUsed
+	}
+
+	sql, args, err = r.Builder.
+		Update("password_resets").
+		Set("used_at", at).
+		Where("user_id = ? AND used_at IS NULL", pr.UserID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo - Consume - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("PasswordResetRepo - Consume - tx.Exec: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Update("users").
+		Set("password_hash", passwordHash).
+		Set("failed_login_attempts", 0).
+		Set("last_failed_login_at", nil).
+		Set("lockout_count", 0).
+		Set("locked_until", nil).
+		Set("updated_at", at).
+		Where("id = ?", pr.UserID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo - Consume - r.Builder: %w", err)
+	}
+
+	tag, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo - Consume - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("PasswordResetRepo - Consume - tx.Commit: %w", err)
+	}
+
+	return nil
+}
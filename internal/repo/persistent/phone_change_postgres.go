@@ -0,0 +1,277 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/event"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const usersPhoneUniqueConstraint = "users_phone_unique"
+
+//nolint:gochecknoglobals // column list shared by all phone change queries
+var phoneChangeColumns = []string{
+	"id", "user_id", "new_phone_number", "otp_hash", "attempts", "expires_at", "confirmed_at", "created_at",
+}
+
+type PhoneChangeRepo struct {
+	*postgres.Postgres
+}
+
+func NewPhoneChangeRepo(pg *postgres.Postgres) *PhoneChangeRepo {
+	return &PhoneChangeRepo{pg}
+}
+
+// Store saves a change request in place of the user's pending one, so only
+// the latest code works.
+func (r *PhoneChangeRepo) Store(ctx context.Context, c *auth.PhoneChange) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	c.CreatedAt = time.Now().UTC()
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Store - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	if err = r.deletePending(ctx, tx, c.UserID); err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Store - r.deletePending: %w", err)
+	}
+
+	sql, args, err := r.Builder.
+		Insert("phone_change_requests").
+		Columns(phoneChangeColumns...).
+		Values(c.ID, c.UserID, c.NewPhoneNumber, c.OTPHash, c.Attempts, c.ExpiresAt, c.ConfirmedAt, c.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Store - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Store - tx.Exec: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Store - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// GetPending returns the user's change that is waiting for its code.
+func (r *PhoneChangeRepo) GetPending(ctx context.Context, userID uuid.UUID) (*auth.PhoneChange, error) {
+	sql, args, err := r.Builder.
+		Select(phoneChangeColumns...).
+		From("phone_change_requests").
+		Where("user_id = ? AND confirmed_at IS NULL", userID).
+		OrderBy("created_at DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("PhoneChangeRepo - GetPending - r.Builder: %w", err)
+	}
+
+	c, err := scanPhoneChange(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrPhoneChangeNotFound
+		}
+
+		return nil, fmt.Errorf("PhoneChangeRepo - GetPending - r.Pool.QueryRow: %w", err)
+	}
+
+	return c, nil
+}
+
+// CountSince counts the changes the user requested after since.
+func (r *PhoneChangeRepo) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)").
+		From("phone_change_requests").
+		Where("user_id = ? AND created_at > ?", userID, since).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("PhoneChangeRepo - CountSince - r.Builder: %w", err)
+	}
+
+	var count int
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("PhoneChangeRepo - CountSince - r.Pool.QueryRow: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *PhoneChangeRepo) RecordFailure(ctx context.Context, id uuid.UUID) (int, error) {
+	sql, args, err := r.Builder.
+		Update("phone_change_requests").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where("id = ?", id).
+		Suffix("RETURNING attempts").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("PhoneChangeRepo - RecordFailure - r.Builder: %w", err)
+	}
+
+	var attempts int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrPhoneChangeNotFound
+		}
+
+		return 0, fmt.Errorf("PhoneChangeRepo - RecordFailure - r.Pool.QueryRow: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// Confirm consumes the request and sets the user's verified phone number in
+// one transaction, writing events to the outbox alongside. It returns
+// auth.ErrPhoneChangeUsed when the request was confirmed concurrently and
+// auth.ErrPhoneAlreadyExists when another account took the number in the
+// meantime.
+func (r *PhoneChangeRepo) Confirm(ctx context.Context, c *auth.PhoneChange, at time.Time, events []event.OutboxEvent) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Confirm - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("phone_change_requests").
+		Set("confirmed_at", at).
+		Where("id = ? AND confirmed_at IS NULL", c.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Confirm - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Confirm - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrPhoneChangeUsed
+	}
+
+	sql, args, err = r.Builder.
+		Update("users").
+		Set("phone_number", c.NewPhoneNumber).
+		Set("phone_verified", true).
+		Set("phone_verified_at", at).
+		Set("updated_at", at).
+		Where("id = ?", c.UserID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Confirm - r.Builder: %w", err)
+	}
+
+	tag, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		if isUniqueViolation(err, usersPhoneUniqueConstraint) {
+			return auth.ErrPhoneAlreadyExists
+		}
+
+		return fmt.Errorf("PhoneChangeRepo - Confirm - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	if err = storeOutboxEvents(ctx, tx, r.Builder, events); err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Confirm - storeOutboxEvents: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("PhoneChangeRepo - Confirm - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// RemovePhone clears the user's phone number, provided it is still phone, and
+// voids any pending change in one transaction, writing events to the outbox
+// alongside. It returns auth.ErrUserNotFound when the number changed
+// concurrently.
+func (r *PhoneChangeRepo) RemovePhone(ctx context.Context, userID uuid.UUID, phone string, at time.Time, events []event.OutboxEvent) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - RemovePhone - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("phone_number", nil).
+		Set("phone_verified", false).
+		Set("phone_verified_at", nil).
+		Set("updated_at", at).
+		Where("id = ? AND phone_number = ?", userID, phone).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - RemovePhone - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PhoneChangeRepo - RemovePhone - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	if err = r.deletePending(ctx, tx, userID); err != nil {
+		return fmt.Errorf("PhoneChangeRepo - RemovePhone - r.deletePending: %w", err)
+	}
+
+	if err = storeOutboxEvents(ctx, tx, r.Builder, events); err != nil {
+		return fmt.Errorf("PhoneChangeRepo - RemovePhone - storeOutboxEvents: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("PhoneChangeRepo - RemovePhone - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PhoneChangeRepo) deletePending(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	sql, args, err := r.Builder.
+		Delete("phone_change_requests").
+		Where("user_id = ? AND confirmed_at IS NULL", userID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func scanPhoneChange(row pgx.Row) (*auth.PhoneChange, error) {
+	var c auth.PhoneChange
+
+	err := row.Scan(&c.ID, &c.UserID, &c.NewPhoneNumber, &c.OTPHash, &c.Attempts, &c.ExpiresAt, &c.ConfirmedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
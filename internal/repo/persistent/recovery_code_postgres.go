@@ -0,0 +1,127 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+)
+
+type RecoveryCodeRepo struct {
+	*postgres.Postgres
+}
+
+func NewRecoveryCodeRepo(pg *postgres.Postgres) *RecoveryCodeRepo {
+	return &RecoveryCodeRepo{pg}
+}
+
+// Replace deletes the user's existing codes, used or not, and stores the new
+// set in one transaction.
+func (r *RecoveryCodeRepo) Replace(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RecoveryCodeRepo - Replace - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Delete("recovery_codes").
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("RecoveryCodeRepo - Replace - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("RecoveryCodeRepo - Replace - tx.Exec: %w", err)
+	}
+
+	if len(hashes) > 0 {
+		now := time.Now().UTC()
+
+		query := r.Builder.
+			Insert("recovery_codes").
+			Columns("id", "user_id", "code_hash", "created_at")
+
+		for _, h := range hashes {
+			query = query.Values(uuid.New(), userID, h, now)
+		}
+
+		sql, args, err = query.ToSql()
+		if err != nil {
+			return fmt.Errorf("RecoveryCodeRepo - Replace - r.Builder: %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("RecoveryCodeRepo - Replace - tx.Exec: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("RecoveryCodeRepo - Replace - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodeRepo) Status(ctx context.Context, userID uuid.UUID) (*auth.RecoveryCodeStatus, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)", "COUNT(*) FILTER (WHERE used_at IS NULL)", "MAX(created_at)").
+		From("recovery_codes").
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("RecoveryCodeRepo - Status - r.Builder: %w", err)
+	}
+
+	var s auth.RecoveryCodeStatus
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&s.Total, &s.Remaining, &s.GeneratedAt); err != nil {
+		return nil, fmt.Errorf("RecoveryCodeRepo - Status - r.Pool.QueryRow: %w", err)
+	}
+
+	return &s, nil
+}
+
+// Consume marks an unused code as used. It returns ErrRecoveryCodeInvalid when
+// no unused code with that hash belongs to the user.
+func (r *RecoveryCodeRepo) Consume(ctx context.Context, userID uuid.UUID, hash string, at time.Time) error {
+	sql, args, err := r.Builder.
+		Update("recovery_codes").
+		Set("used_at", at).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("RecoveryCodeRepo - Consume - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RecoveryCodeRepo - Consume - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrRecoveryCodeInvalid
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodeRepo) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	sql, args, err := r.Builder.
+		Delete("recovery_codes").
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("RecoveryCodeRepo - DeleteByUserID - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("RecoveryCodeRepo - DeleteByUserID - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type RefreshTokenRepo struct {
+	*postgres.Postgres
+}
+
+func NewRefreshTokenRepo(pg *postgres.Postgres) *RefreshTokenRepo {
+	return &RefreshTokenRepo{pg}
+}
+
+func (r *RefreshTokenRepo) Store(ctx context.Context, t *auth.RefreshToken) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+
+	if t.FamilyID == uuid.Nil {
+		t.FamilyID = uuid.New()
+	}
+
+	t.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Insert("refresh_tokens").
+		Columns("id", "user_id", "token_hash", "family_id", "generation", "device_info", "ip_address", "user_agent", "expires_at", "created_at").
+		Values(t.ID, t.UserID, t.TokenHash, t.FamilyID, t.Generation, t.DeviceInfo, t.IPAddress, t.UserAgent, t.ExpiresAt, t.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - Store - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepo) GetByHash(ctx context.Context, hash string) (*auth.RefreshToken, error) {
+	sql, args, err := r.Builder.
+		Select("id", "user_id", "token_hash", "family_id", "generation", "device_info", "ip_address", "user_agent",
+			"expires_at", "revoked_at", "last_used_at", "created_at").
+		From("refresh_tokens").
+		Where("token_hash = ?", hash).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("RefreshTokenRepo - GetByHash - r.Builder: %w", err)
+	}
+
+	var t auth.RefreshToken
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&t.ID, &t.UserID, &t.TokenHash, &t.FamilyID, &t.Generation, &t.DeviceInfo, &t.IPAddress, &t.UserAgent,
+		&t.ExpiresAt, &t.RevokedAt, &t.LastUsedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrRefreshTokenNotFound
+		}
+
+		return nil, fmt.Errorf("RefreshTokenRepo - GetByHash - r.Pool.QueryRow: %w", err)
+	}
+
+	return &t, nil
+}
+
+// Rotate marks the current token as used and stores its successor in one
+// transaction. It returns auth.ErrRefreshTokenReused when the current token was
+// already revoked, including by a concurrent rotation.
+func (r *RefreshTokenRepo) Rotate(ctx context.Context, currentID uuid.UUID, next *auth.RefreshToken) error {
+	now := time.Now().UTC()
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - Rotate - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Update("refresh_tokens").
+		Set("revoked_at", now).
+		Set("last_used_at", now).
+		Where("id = ? AND revoked_at IS NULL", currentID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - Rotate - r.Builder: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - Rotate - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrRefreshTokenReused
+	}
+
+	if next.ID == uuid.Nil {
+		next.ID = uuid.New()
+	}
+
+	next.CreatedAt = now
+
+	sql, args, err = r.Builder.
+		Insert("refresh_tokens").
+		Columns("id", "user_id", "token_hash", "family_id", "generation", "device_info", "ip_address", "user_agent", "expires_at", "created_at").
+		Values(next.ID, next.UserID, next.TokenHash, next.FamilyID, next.Generation, next.DeviceInfo, next.IPAddress, next.UserAgent, next.ExpiresAt, next.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - Rotate - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("RefreshTokenRepo - Rotate - tx.Exec: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("RefreshTokenRepo - Rotate - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// FamilyActive reports whether the family still has a token that isn't
+// revoked, that is whether the session it belongs to is still signed in.
+func (r *RefreshTokenRepo) FamilyActive(ctx context.Context, userID, familyID uuid.UUID) (bool, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)").
+		From("refresh_tokens").
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("RefreshTokenRepo - FamilyActive - r.Builder: %w", err)
+	}
+
+	var count int
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("RefreshTokenRepo - FamilyActive - r.Pool.QueryRow: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, userID, familyID uuid.UUID) error {
+	sql, args, err := r.Builder.
+		Update("refresh_tokens").
+		Set("revoked_at", time.Now().UTC()).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - RevokeFamily - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - RevokeFamily - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	sql, args, err := r.Builder.
+		Update("refresh_tokens").
+		Set("revoked_at", time.Now().UTC()).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - RevokeAllForUser - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo - RevokeAllForUser - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// ListSessions returns the user's active refresh token families, most recently
+// used first. A family has at most one unrevoked token, so each row is a session.
+func (r *RefreshTokenRepo) ListSessions(ctx context.Context, userID uuid.UUID) ([]auth.Session, error) {
+	sql, args, err := r.Builder.
+		Select("rt.family_id", "rt.user_id", "rt.device_info", "rt.ip_address", "rt.user_agent",
+			"(SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = rt.family_id)",
+			"rt.created_at", "rt.expires_at").
+		From("refresh_tokens rt").
+		Where("rt.user_id = ? AND rt.revoked_at IS NULL AND rt.expires_at > ?", userID, time.Now().UTC()).
+		OrderBy("rt.created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("RefreshTokenRepo - ListSessions - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("RefreshTokenRepo - ListSessions - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]auth.Session, 0)
+
+	for rows.Next() {
+		var s auth.Session
+
+		err = rows.Scan(&s.ID, &s.UserID, &s.DeviceInfo, &s.IPAddress, &s.UserAgent, &s.CreatedAt, &s.LastActiveAt, &s.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("RefreshTokenRepo - ListSessions - rows.Scan: %w", err)
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("RefreshTokenRepo - ListSessions - rows.Err: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeOtherFamilies revokes every active token of the user outside the given
+// family and returns how many sessions were ended.
+func (r *RefreshTokenRepo) RevokeOtherFamilies(ctx context.Context, userID, keepFamilyID uuid.UUID) (int64, error) {
+	sql, args, err := r.Builder.
+		Update("refresh_tokens").
+		Set("revoked_at", time.Now().UTC()).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("RefreshTokenRepo - RevokeOtherFamilies - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("RefreshTokenRepo - RevokeOtherFamilies - r.Pool.Exec: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -40,3 +40,143 @@ func TestNewOutboxRepo(t *testing.T) {
 	repo := NewOutboxRepo(nil)
 	assert.NotNil(t, repo)
 }
+
+func TestNewUserRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewUserRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewRefreshTokenRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewRefreshTokenRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewSigningKeyRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewSigningKeyRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewSecurityEventRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewSecurityEventRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewEmailVerificationRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewEmailVerificationRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewPasswordResetRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewPasswordResetRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewTOTPRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewTOTPRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewMFAChallengeRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMFAChallengeRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewSMSFactorRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewSMSFactorRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewRecoveryCodeRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewRecoveryCodeRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewOAuthConnectionRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewOAuthConnectionRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewPasskeyRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewPasskeyRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewWebAuthnChallengeRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewWebAuthnChallengeRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewMagicLinkRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMagicLinkRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewAccountRecoveryRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewAccountRecoveryRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewEmailChangeRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewEmailChangeRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewPhoneChangeRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewPhoneChangeRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewAccountDeletionRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewAccountDeletionRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewDataExportRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewDataExportRepo(nil)
+	assert.NotNil(t, repo)
+}
+
+func TestNewAvailabilityCheckRepo(t *testing.T) {
+	t.Parallel()
+
+	repo := NewAvailabilityCheckRepo(nil)
+	assert.NotNil(t, repo)
+}
@@ -0,0 +1,170 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+//nolint:gochecknoglobals // column list shared by all security event queries
+var securityEventColumns = []string{
+	"id", "user_id", "event_type", "success", "risk_level", "ip_address", "user_agent",
+	"location_city", "location_region", "location_country", "location_country_code",
+	"device_type", "device_os", "device_browser", "details", "created_at",
+}
+
+type SecurityEventRepo struct {
+	*postgres.Postgres
+}
+
+func NewSecurityEventRepo(pg *postgres.Postgres) *SecurityEventRepo {
+	return &SecurityEventRepo{pg}
+}
+
+func (r *SecurityEventRepo) Store(ctx context.Context, e *auth.SecurityEvent) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	if e.RiskLevel == "" {
+		e.RiskLevel = auth.RiskLow
+	}
+
+	e.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Insert("security_events").
+		Columns(securityEventColumns...).
+		Values(e.ID, e.UserID, e.Type, e.Success, e.RiskLevel, e.IPAddress, e.UserAgent,
+			e.LocationCity, e.LocationRegion, e.LocationCountry, e.LocationCountryCode,
+			e.DeviceType, e.DeviceOS, e.DeviceBrowser, e.Details, e.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("SecurityEventRepo - Store - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("SecurityEventRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns one of the user's events; another user's event is reported
+// as not found.
+func (r *SecurityEventRepo) GetByID(ctx context.Context, userID, id uuid.UUID) (*auth.SecurityEvent, error) {
+	sql, args, err := r.Builder.
+		Select(securityEventColumns...).
+		From("security_events").
+		Where("id = ? AND user_id = ?", id, userID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SecurityEventRepo - GetByID - r.Builder: %w", err)
+	}
+
+	e, err := scanSecurityEvent(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrSecurityEventNotFound
+		}
+
+		return nil, fmt.Errorf("SecurityEventRepo - GetByID - r.Pool.QueryRow: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *SecurityEventRepo) ListByUserID(ctx context.Context, userID uuid.UUID, f auth.SecurityEventFilter, limit, offset uint64) ([]auth.SecurityEvent, error) {
+	sql, args, err := r.Builder.
+		Select(securityEventColumns...).
+		From("security_events").
+		Where(securityEventFilter(userID, f)).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SecurityEventRepo - ListByUserID - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SecurityEventRepo - ListByUserID - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]auth.SecurityEvent, 0)
+
+	for rows.Next() {
+		e, err := scanSecurityEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("SecurityEventRepo - ListByUserID - rows.Scan: %w", err)
+		}
+
+		events = append(events, *e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SecurityEventRepo - ListByUserID - rows.Err: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *SecurityEventRepo) CountByUserID(ctx context.Context, userID uuid.UUID, f auth.SecurityEventFilter) (int, error) {
+	sql, args, err := r.Builder.
+		Select("COUNT(*)").
+		From("security_events").
+		Where(securityEventFilter(userID, f)).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("SecurityEventRepo - CountByUserID - r.Builder: %w", err)
+	}
+
+	var count int
+
+	if err = r.Pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("SecurityEventRepo - CountByUserID - r.Pool.QueryRow: %w", err)
+	}
+
+	return count, nil
+}
+
+func securityEventFilter(userID uuid.UUID, f auth.SecurityEventFilter) sq.And {
+	where := sq.And{sq.Eq{"user_id": userID}}
+
+	if f.Type != "" {
+		where = append(where, sq.Eq{"event_type": f.Type})
+	}
+
+	if f.From != nil {
+		where = append(where, sq.GtOrEq{"created_at": *f.From})
+	}
+
+	if f.To != nil {
+		where = append(where, sq.LtOrEq{"created_at": *f.To})
+	}
+
+	return where
+}
+
+func scanSecurityEvent(row pgx.Row) (*auth.SecurityEvent, error) {
+	var e auth.SecurityEvent
+
+	err := row.Scan(&e.ID, &e.UserID, &e.Type, &e.Success, &e.RiskLevel, &e.IPAddress, &e.UserAgent,
+		&e.LocationCity, &e.LocationRegion, &e.LocationCountry, &e.LocationCountryCode,
+		&e.DeviceType, &e.DeviceOS, &e.DeviceBrowser, &e.Details, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
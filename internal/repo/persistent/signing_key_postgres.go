@@ -0,0 +1,159 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// signingKeyRotationLock serializes key rotation across application instances.
+const signingKeyRotationLock = 7_216_001
+
+type SigningKeyRepo struct {
+	*postgres.Postgres
+}
+
+func NewSigningKeyRepo(pg *postgres.Postgres) *SigningKeyRepo {
+	return &SigningKeyRepo{pg}
+}
+
+func (r *SigningKeyRepo) ListUsable(ctx context.Context, now time.Time) ([]auth.SigningKey, error) {
+	sql, args, err := r.Builder.
+		Select("id", "kid", "algorithm", "secret_encrypted", "is_active", "expires_at", "revoked_at", "created_at").
+		From("jwt_signing_keys").
+		Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SigningKeyRepo - ListUsable - r.Builder: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SigningKeyRepo - ListUsable - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]auth.SigningKey, 0)
+
+	for rows.Next() {
+		var k auth.SigningKey
+
+		err = rows.Scan(&k.ID, &k.KID, &k.Algorithm, &k.SecretEncrypted, &k.IsActive, &k.ExpiresAt, &k.RevokedAt, &k.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("SigningKeyRepo - ListUsable - rows.Scan: %w", err)
+		}
+
+		keys = append(keys, k)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SigningKeyRepo - ListUsable - rows.Err: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Rotate stores key as the only active key and retires the previous active keys
+// so they stop verifying at retireAt. It does nothing and returns false when an
+// active key created at or after staleBefore already exists, which happens when
+// another instance rotated first.
+func (r *SigningKeyRepo) Rotate(ctx context.Context, key *auth.SigningKey, retireAt, staleBefore time.Time) (bool, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", signingKeyRotationLock); err != nil {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - pg_advisory_xact_lock: %w", err)
+	}
+
+	sql, args, err := r.Builder.
+		Select("created_at").
+		From("jwt_signing_keys").
+		Where("is_active = TRUE AND revoked_at IS NULL").
+		OrderBy("created_at DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - r.Builder: %w", err)
+	}
+
+	var latest time.Time
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&latest)
+	if err == nil && !latest.Before(staleBefore) {
+		return false, nil
+	}
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - tx.QueryRow: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Update("jwt_signing_keys").
+		Set("is_active", false).
+		Set("expires_at", sq.Expr("LEAST(COALESCE(expires_at, ?), ?)", retireAt, retireAt)).
+		Where("is_active = TRUE").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - tx.Exec: %w", err)
+	}
+
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+
+	key.IsActive = true
+	key.CreatedAt = time.Now().UTC()
+
+	sql, args, err = r.Builder.
+		Insert("jwt_signing_keys").
+		Columns("id", "kid", "algorithm", "secret_encrypted", "is_active", "expires_at", "created_at").
+		Values(key.ID, key.KID, key.Algorithm, key.SecretEncrypted, key.IsActive, key.ExpiresAt, key.CreatedAt).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - tx.Exec: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("SigningKeyRepo - Rotate - tx.Commit: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *SigningKeyRepo) Revoke(ctx context.Context, kid string) error {
+	sql, args, err := r.Builder.
+		Update("jwt_signing_keys").
+		Set("is_active", false).
+		Set("revoked_at", time.Now().UTC()).
+		Where("kid = ? AND revoked_at IS NULL", kid).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("SigningKeyRepo - Revoke - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("SigningKeyRepo - Revoke - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type SMSFactorRepo struct {
+	*postgres.Postgres
+}
+
+func NewSMSFactorRepo(pg *postgres.Postgres) *SMSFactorRepo {
+	return &SMSFactorRepo{pg}
+}
+
+func (r *SMSFactorRepo) GetByUserID(ctx context.Context, userID uuid.UUID) (*auth.SMSFactor, error) {
+	sql, args, err := r.Builder.
+		Select("id", "user_id", "phone_number", "code_hash", "code_expires_at", "code_attempts",
+			"code_sent_at", "verified_at", "created_at").
+		From("mfa_sms").
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SMSFactorRepo - GetByUserID - r.Builder: %w", err)
+	}
+
+	var f auth.SMSFactor
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&f.ID, &f.UserID, &f.PhoneNumber, &f.CodeHash, &f.CodeExpiresAt, &f.CodeAttempts,
+		&f.CodeSentAt, &f.VerifiedAt, &f.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrSMSFactorNotFound
+		}
+
+		return nil, fmt.Errorf("SMSFactorRepo - GetByUserID - r.Pool.QueryRow: %w", err)
+	}
+
+	return &f, nil
+}
+
+// Upsert stores a pending enrollment together with its first code, replacing
+// an earlier unverified one. It returns ErrSMSFactorAlreadyEnabled when the
+// user has a verified phone.
+func (r *SMSFactorRepo) Upsert(ctx context.Context, f *auth.SMSFactor) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+
+	f.CreatedAt = time.Now().UTC()
+
+	sql := `
+		INSERT INTO mfa_sms (id, user_id, phone_number, code_hash, code_expires_at, code_sent_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET
+			phone_number = EXCLUDED.phone_number,
+			code_hash = EXCLUDED.code_hash,
+			code_expires_at = EXCLUDED.code_expires_at,
+			code_attempts = 0,
+			code_sent_at = EXCLUDED.code_sent_at,
+			created_at = EXCLUDED.created_at
+		WHERE mfa_sms.verified_at IS NULL
+		RETURNING id
+	`
+
+	err := r.Pool.QueryRow(ctx, sql,
+		f.ID, f.UserID, f.PhoneNumber, f.CodeHash, f.CodeExpiresAt, f.CodeSentAt, f.CreatedAt,
+	).Scan(&f.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.ErrSMSFactorAlreadyEnabled
+		}
+
+		return fmt.Errorf("SMSFactorRepo - Upsert - r.Pool.QueryRow: %w", err)
+	}
+
+	return nil
+}
+
+// SetCode replaces the outstanding code and resets its attempt counter.
+func (r *SMSFactorRepo) SetCode(ctx context.Context, userID uuid.UUID, hash string, expiresAt, sentAt time.Time) error {
+	sql, args, err := r.Builder.
+		Update("mfa_sms").
+		Set("code_hash", hash).
+		Set("code_expires_at", expiresAt).
+		Set("code_attempts", 0).
+		Set("code_sent_at", sentAt).
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("SMSFactorRepo - SetCode - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("SMSFactorRepo - SetCode - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrSMSFactorNotFound
+	}
+
+	return nil
+}
+
+// RecordCodeFailure increments the failed attempts against the outstanding code
+// and returns the new value.
+func (r *SMSFactorRepo) RecordCodeFailure(ctx context.Context, userID uuid.UUID) (int, error) {
+	sql, args, err := r.Builder.
+		Update("mfa_sms").
+		Set("code_attempts", sq.Expr("code_attempts + 1")).
+		Where("user_id = ?", userID).
+		Suffix("RETURNING code_attempts").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("SMSFactorRepo - RecordCodeFailure - r.Builder: %w", err)
+	}
+
+	var attempts int
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, auth.ErrSMSFactorNotFound
+		}
+
+		return 0, fmt.Errorf("SMSFactorRepo - RecordCodeFailure - r.Pool.QueryRow: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// ConsumeCode clears the outstanding code if it matches hash and is still live
+// at the given time, and marks the phone verified if it was not already. It
+// returns ErrSMSCodeInvalid otherwise, including when another request used the
+// code first.
+func (r *SMSFactorRepo) ConsumeCode(ctx context.Context, userID uuid.UUID, hash string, at time.Time) error {
+	sql, args, err := r.Builder.
+		Update("mfa_sms").
+		Set("code_hash", nil).
+		Set("code_expires_at", nil).
+		Set("code_attempts", 0).
+		Set("verified_at", sq.Expr("COALESCE(verified_at, ?)", at)).
+		Where("user_id = ? AND code_hash = ? AND code_expires_at > ?", userID, hash, at).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("SMSFactorRepo - ConsumeCode - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("SMSFactorRepo - ConsumeCode - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrSMSCodeInvalid
+	}
+
+	return nil
+}
+
+func (r *SMSFactorRepo) Delete(ctx context.Context, userID uuid.UUID) error {
+	sql, args, err := r.Builder.
+		Delete("mfa_sms").
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("SMSFactorRepo - Delete - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("SMSFactorRepo - Delete - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrSMSFactorNotFound
+	}
+
+	return nil
+}
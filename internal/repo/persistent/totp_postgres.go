@@ -0,0 +1,147 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type TOTPRepo struct {
+	*postgres.Postgres
+}
+
+func NewTOTPRepo(pg *postgres.Postgres) *TOTPRepo {
+	return &TOTPRepo{pg}
+}
+
+func (r *TOTPRepo) GetByUserID(ctx context.Context, userID uuid.UUID) (*auth.TOTP, error) {
+	sql, args, err := r.Builder.
+		Select("id", "user_id", "secret_encrypted", "last_used_step", "verified_at", "created_at").
+		From("mfa_totp").
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("TOTPRepo - GetByUserID - r.Builder: %w", err)
+	}
+
+	var t auth.TOTP
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&t.ID, &t.UserID, &t.SecretEncrypted, &t.LastUsedStep, &t.VerifiedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrTOTPNotFound
+		}
+
+		return nil, fmt.Errorf("TOTPRepo - GetByUserID - r.Pool.QueryRow: %w", err)
+	}
+
+	return &t, nil
+}
+
+// Upsert stores a pending enrollment, replacing an earlier unverified one.
+// It returns ErrTOTPAlreadyEnabled when the user has a verified enrollment.
+func (r *TOTPRepo) Upsert(ctx context.Context, t *auth.TOTP) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+
+	t.CreatedAt = time.Now().UTC()
+
+	sql := `
+		INSERT INTO mfa_totp (id, user_id, secret_encrypted, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret_encrypted = EXCLUDED.secret_encrypted,
+			last_used_step = NULL,
+			created_at = EXCLUDED.created_at
+		WHERE mfa_totp.verified_at IS NULL
+		RETURNING id
+	`
+
+	err := r.Pool.QueryRow(ctx, sql, t.ID, t.UserID, t.SecretEncrypted, t.CreatedAt).Scan(&t.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.ErrTOTPAlreadyEnabled
+		}
+
+		return fmt.Errorf("TOTPRepo - Upsert - r.Pool.QueryRow: %w", err)
+	}
+
+	return nil
+}
+
+// UseStep records step as the last accepted code. It returns
+// ErrTOTPCodeReused when step is not newer than the one already recorded, so
+// concurrent requests cannot both accept the same code.
+func (r *TOTPRepo) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	sql, args, err := r.Builder.
+		Update("mfa_totp").
+		Set("last_used_step", step).
+		Where("user_id = ? AND (last_used_step IS NULL OR last_used_step < ?)", userID, step).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("TOTPRepo - UseStep - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TOTPRepo - UseStep - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrTOTPCodeReused
+	}
+
+	return nil
+}
+
+func (r *TOTPRepo) MarkVerified(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	sql, args, err := r.Builder.
+		Update("mfa_totp").
+		Set("verified_at", at).
+		Where("user_id = ? AND verified_at IS NULL", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("TOTPRepo - MarkVerified - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TOTPRepo - MarkVerified - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrTOTPAlreadyEnabled
+	}
+
+	return nil
+}
+
+func (r *TOTPRepo) Delete(ctx context.Context, userID uuid.UUID) error {
+	sql, args, err := r.Builder.
+		Delete("mfa_totp").
+		Where("user_id = ?", userID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("TOTPRepo - Delete - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("TOTPRepo - Delete - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrTOTPNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	pgUniqueViolation = "23505"
+
+	usersEmailUniqueConstraint = "users_email_unique"
+)
+
+//nolint:gochecknoglobals // column list shared by all user queries
+var userColumns = []string{
+	"id", "email", "password_hash", "name", "avatar_url", "avatar_keys",
+	"email_verified", "email_verified_at", "phone_number", "phone_verified", "phone_verified_at",
+	"status", "failed_login_attempts", "locked_until", "last_login_at", "last_login_ip",
+	"deleted_at", "created_at", "updated_at",
+}
+
+type UserRepo struct {
+	*postgres.Postgres
+}
+
+func NewUserRepo(pg *postgres.Postgres) *UserRepo {
+	return &UserRepo{pg}
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *auth.User) error {
+	sql, args, err := insertUser(r.Builder, u).ToSql()
+	if err != nil {
+		return fmt.Errorf("UserRepo - Create - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		if isUniqueViolation(err, usersEmailUniqueConstraint) {
+			return auth.ErrEmailAlreadyExists
+		}
+
+		return fmt.Errorf("UserRepo - Create - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) GetByID(ctx context.Context, id uuid.UUID) (*auth.User, error) {
+	sql, args, err := r.Builder.
+		Select(userColumns...).
+		From("users").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo - GetByID - r.Builder: %w", err)
+	}
+
+	u, err := scanUser(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("UserRepo - GetByID - r.Pool.QueryRow: %w", err)
+	}
+
+	return u, nil
+}
+
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*auth.User, error) {
+	sql, args, err := r.Builder.
+		Select(userColumns...).
+		From("users").
+		Where("email = ?", email).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo - GetByEmail - r.Builder: %w", err)
+	}
+
+	u, err := scanUser(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("UserRepo - GetByEmail - r.Pool.QueryRow: %w", err)
+	}
+
+	return u, nil
+}
+
+func (r *UserRepo) GetByPhone(ctx context.Context, phone string) (*auth.User, error) {
+	sql, args, err := r.Builder.
+		Select(userColumns...).
+		From("users").
+		Where("phone_number = ?", phone).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo - GetByPhone - r.Builder: %w", err)
+	}
+
+	u, err := scanUser(r.Pool.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("UserRepo - GetByPhone - r.Pool.QueryRow: %w", err)
+	}
+
+	return u, nil
+}
+
+// UpdateLastLogin records a successful sign-in, which also clears the
+// account's failed attempts and any lockout that has run out.
+func (r *UserRepo) UpdateLastLogin(ctx context.Context, id uuid.UUID, at time.Time, ip string) error {
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("last_login_at", at).
+		Set("last_login_ip", nullableString(ip)).
+		Set("failed_login_attempts", 0).
+		Set("last_failed_login_at", nil).
+		Set("lockout_count", 0).
+		Set("locked_until", nil).
+		Set("updated_at", time.Now().UTC()).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdateLastLogin - r.Builder: %w", err)
+	}
+
+	_, err = r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdateLastLogin - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// RecordLoginFailure counts a failed sign-in against the user under policy
+// and reports whether it locked the account. The user's row stays locked
+// while the new state is worked out, so concurrent failures from any number
+// of app instances are all counted.
+func (r *UserRepo) RecordLoginFailure(ctx context.Context, id uuid.UUID, at time.Time, policy auth.LockoutPolicy) (*auth.LoginFailures, bool, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("UserRepo - RecordLoginFailure - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	sql, args, err := r.Builder.
+		Select("failed_login_attempts", "lockout_count", "last_failed_login_at", "locked_until").
+		From("users").
+		Where("id = ?", id).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("UserRepo - RecordLoginFailure - r.Builder: %w", err)
+	}
+
+	var f auth.LoginFailures
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&f.Attempts, &f.Lockouts, &f.LastFailure, &f.LockedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, auth.ErrUserNotFound
+		}
+
+		return nil, false, fmt.Errorf("UserRepo - RecordLoginFailure - tx.QueryRow: %w", err)
+	}
+
+	f, locked := policy.Fail(f, at)
+
+	sql, args, err = r.Builder.
+		Update("users").
+		Set("failed_login_attempts", f.Attempts).
+		Set("lockout_count", f.Lockouts).
+		Set("last_failed_login_at", f.LastFailure).
+		Set("locked_until", f.LockedUntil).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("UserRepo - RecordLoginFailure - r.Builder: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return nil, false, fmt.Errorf("UserRepo - RecordLoginFailure - tx.Exec: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("UserRepo - RecordLoginFailure - tx.Commit: %w", err)
+	}
+
+	return &f, locked, nil
+}
+
+func (r *UserRepo) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("password_hash", passwordHash).
+		Set("updated_at", time.Now().UTC()).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdatePassword - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdatePassword - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateProfile saves the user's name and avatar.
+func (r *UserRepo) UpdateProfile(ctx context.Context, u *auth.User) error {
+	u.UpdatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Update("users").
+		Set("name", u.Name).
+		Set("avatar_url", u.AvatarURL).
+		Set("avatar_keys", u.AvatarKeys).
+		Set("updated_at", u.UpdatedAt).
+		Where("id = ?", u.ID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdateProfile - r.Builder: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo - UpdateProfile - r.Pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// insertUser fills in the ID and timestamps of u and builds its insert.
+func insertUser(b sq.StatementBuilderType, u *auth.User) sq.InsertBuilder {
+	now := time.Now().UTC()
+
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+
+	u.CreatedAt = now
+	u.UpdatedAt = now
+
+	return b.
+		Insert("users").
+		Columns(userColumns...).
+		Values(
+			u.ID, u.Email, u.PasswordHash, u.Name, u.AvatarURL, u.AvatarKeys,
+			u.EmailVerified, u.EmailVerifiedAt, u.PhoneNumber, u.PhoneVerified, u.PhoneVerifiedAt,
+			u.Status, u.FailedLoginAttempts, u.LockedUntil, u.LastLoginAt, u.LastLoginIP,
+			u.DeletedAt, u.CreatedAt, u.UpdatedAt,
+		)
+}
+
+func scanUser(row pgx.Row) (*auth.User, error) {
+	var u auth.User
+
+	err := row.Scan(
+		&u.ID, &u.Email, &u.PasswordHash, &u.Name, &u.AvatarURL, &u.AvatarKeys,
+		&u.EmailVerified, &u.EmailVerifiedAt, &u.PhoneNumber, &u.PhoneVerified, &u.PhoneVerifiedAt,
+		&u.Status, &u.FailedLoginAttempts, &u.LockedUntil, &u.LastLoginAt, &u.LastLoginIP,
+		&u.DeletedAt, &u.CreatedAt, &u.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == constraint
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
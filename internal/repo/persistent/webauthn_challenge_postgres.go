@@ -0,0 +1,85 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type WebAuthnChallengeRepo struct {
+	*postgres.Postgres
+}
+
+func NewWebAuthnChallengeRepo(pg *postgres.Postgres) *WebAuthnChallengeRepo {
+	return &WebAuthnChallengeRepo{pg}
+}
+
+// Store saves a pending ceremony and clears out ones that expired unanswered.
+func (r *WebAuthnChallengeRepo) Store(ctx context.Context, c *auth.WebAuthnChallenge) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	c.CreatedAt = time.Now().UTC()
+
+	sql, args, err := r.Builder.
+		Delete("webauthn_challenges").
+		Where("expires_at < ?", c.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("WebAuthnChallengeRepo - Store - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("WebAuthnChallengeRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	sql, args, err = r.Builder.
+		Insert("webauthn_challenges").
+		Columns("id", "user_id", "challenge_hash", "ceremony", "mfa_challenge_id", "expires_at", "created_at").
+		Values(c.ID, c.UserID, c.ChallengeHash, c.Ceremony, c.MFAChallengeID, c.ExpiresAt, c.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("WebAuthnChallengeRepo - Store - r.Builder: %w", err)
+	}
+
+	if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("WebAuthnChallengeRepo - Store - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// Consume deletes the ceremony with the given challenge hash and returns it,
+// so that a challenge can be answered only once. Expiry is left to the caller.
+func (r *WebAuthnChallengeRepo) Consume(ctx context.Context, hash string, ceremony auth.WebAuthnCeremony) (*auth.WebAuthnChallenge, error) {
+	sql, args, err := r.Builder.
+		Delete("webauthn_challenges").
+		Where("challenge_hash = ? AND ceremony = ?", hash, ceremony).
+		Suffix("RETURNING id, user_id, challenge_hash, ceremony, mfa_challenge_id, expires_at, created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("WebAuthnChallengeRepo - Consume - r.Builder: %w", err)
+	}
+
+	var c auth.WebAuthnChallenge
+
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&c.ID, &c.UserID, &c.ChallengeHash, &c.Ceremony, &c.MFAChallengeID, &c.ExpiresAt, &c.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrWebAuthnChallengeNotFound
+		}
+
+		return nil, fmt.Errorf("WebAuthnChallengeRepo - Consume - r.Pool.QueryRow: %w", err)
+	}
+
+	return &c, nil
+}
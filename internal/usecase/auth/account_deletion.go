@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/event"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+)
+
+const confirmAccountDeletionPath = "/confirm-account-deletion"
+
+// RequestAccountDeletion re-checks the password and mails the user a link
+// that confirms deleting their account. A new request voids the previous link.
+func (uc *UseCase) RequestAccountDeletion(ctx context.Context, in auth.AccountDeletionInput) error {
+	user, err := uc.reauthenticate(ctx, in.UserID, in.Password, in.Client)
+	if err != nil {
+		return err
+	}
+
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestAccountDeletion - token.Generate: %w", err)
+	}
+
+	err = uc.deletions.Store(ctx, &auth.AccountDeletion{
+		UserID:    user.ID,
+		TokenHash: token.Hash(raw),
+		ExpiresAt: uc.now().UTC().Add(uc.cfg.AccountDeletionTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestAccountDeletion - uc.deletions.Store: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventAccountDeletionRequested,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+	})
+
+	return uc.sendEmail(ctx, user, user.Email, accountDeletionTemplate, emailData{
+		Link:      uc.link(confirmAccountDeletionPath, raw),
+		ExpiresIn: humanDuration(uc.cfg.AccountDeletionTTL),
+	})
+}
+
+// ConfirmAccountDeletion follows an emailed deletion link. The account is
+// deleted and signed out everywhere straight away, but its data is only
+// erased once AccountDeletionGracePeriod has passed; signing in before then
+// restores it. It returns when the account will be purged.
+func (uc *UseCase) ConfirmAccountDeletion(ctx context.Context, in auth.AccountDeletionConfirmInput) (time.Time, error) {
+	d, err := uc.deletions.GetByTokenHash(ctx, token.Hash(in.Token))
+	if err != nil {
+		if errors.Is(err, auth.ErrAccountDeletionNotFound) {
+			return time.Time{}, errAccountDeletionInvalid()
+		}
+
+		return time.Time{}, fmt.Errorf("UseCase - ConfirmAccountDeletion - uc.deletions.GetByTokenHash: %w", err)
+	}
+
+	if d.ConfirmedAt != nil {
+		return time.Time{}, errAccountDeletionInvalid()
+	}
+
+	now := uc.now().UTC()
+
+	if d.IsExpired(now) {
+		return time.Time{}, apperror.Unauthorized("Account deletion link has expired", apperror.WithCode(codeTokenExpired))
+	}
+
+	user, err := uc.users.GetByID(ctx, d.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return time.Time{}, errAccountDeletionInvalid()
+		}
+
+		return time.Time{}, fmt.Errorf("UseCase - ConfirmAccountDeletion - uc.users.GetByID: %w", err)
+	}
+
+	if feedback := strings.TrimSpace(in.Feedback); feedback != "" {
+		d.Feedback = &feedback
+	}
+
+	if err = uc.deletions.Confirm(ctx, d, now); err != nil {
+		if errors.Is(err, auth.ErrAccountDeletionUsed) || errors.Is(err, auth.ErrUserNotFound) {
+			return time.Time{}, errAccountDeletionInvalid()
+		}
+
+		return time.Time{}, fmt.Errorf("UseCase - ConfirmAccountDeletion - uc.deletions.Confirm: %w", err)
+	}
+
+	if err = uc.refreshTokens.RevokeAllForUser(ctx, user.ID); err != nil {
+		return time.Time{}, fmt.Errorf("UseCase - ConfirmAccountDeletion - uc.refreshTokens.RevokeAllForUser: %w", err)
+	}
+
+	purgeAt := now.Add(uc.cfg.AccountDeletionGracePeriod)
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventAccountDeleted,
+		Success:   true,
+		RiskLevel: auth.RiskHigh,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+		Details:   map[string]any{"purge_at": purgeAt},
+	})
+
+	// The account is already deleted; delivery errors are recorded in the
+	// delivery log.
+	_ = uc.sendEmail(ctx, user, user.Email, accountDeletedTemplate, emailData{
+		ExpiresIn: humanDuration(uc.cfg.AccountDeletionGracePeriod),
+	})
+
+	return purgeAt, nil
+}
+
+// PurgeDeletedAccounts erases up to limit accounts whose grace period is
+// over, along with their data export archives, publishing an account.deleted
+// event for each, and reports how many it erased. Accounts restored in the
+// meantime are skipped.
+func (uc *UseCase) PurgeDeletedAccounts(ctx context.Context, limit uint64) (int, error) {
+	now := uc.now().UTC()
+	deletedBefore := now.Add(-uc.cfg.AccountDeletionGracePeriod)
+
+	users, err := uc.deletions.ListPurgeable(ctx, deletedBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("UseCase - PurgeDeletedAccounts - uc.deletions.ListPurgeable: %w", err)
+	}
+
+	purged := 0
+
+	for i := range users {
+		u := &users[i]
+
+		deletedAt := deletedBefore
+		if u.DeletedAt != nil {
+			deletedAt = *u.DeletedAt
+		}
+
+		deleted, err := outboxEvent(auth.NewAccountDeleted(u.ID, deletedAt, now))
+		if err != nil {
+			return purged, fmt.Errorf("UseCase - PurgeDeletedAccounts - outboxEvent: %w", err)
+		}
+
+		// Export archives and avatars live outside the database, so they go
+		// first; a failure leaves the account to be purged on the next run.
+		if err = uc.removeDataExports(ctx, u.ID); err != nil {
+			return purged, fmt.Errorf("UseCase - PurgeDeletedAccounts - uc.removeDataExports: %w", err)
+		}
+
+		if err = uc.removeAvatar(ctx, u.AvatarKeys); err != nil {
+			return purged, fmt.Errorf("UseCase - PurgeDeletedAccounts - uc.removeAvatar: %w", err)
+		}
+
+		if err = uc.deletions.Purge(ctx, u.ID, deletedBefore, []event.OutboxEvent{deleted}); err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) {
+				continue
+			}
+
+			return purged, fmt.Errorf("UseCase - PurgeDeletedAccounts - uc.deletions.Purge: %w", err)
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
+
+// restorable reports whether a deleted account is still in its grace period,
+// so signing in restores it.
+func (uc *UseCase) restorable(user *auth.User) bool {
+	return user.Status == auth.StatusDeleted && user.DeletedAt != nil &&
+		user.DeletedAt.Add(uc.cfg.AccountDeletionGracePeriod).After(uc.now())
+}
+
+// signInBlocked reports whether the account may not sign in: it is disabled,
+// or deleted and past its grace period.
+func (uc *UseCase) signInBlocked(user *auth.User) bool {
+	return user.Status == auth.StatusDisabled || (user.Status == auth.StatusDeleted && !uc.restorable(user))
+}
+
+// restoreAccount cancels the deletion of an account that is signing in
+// during its grace period.
+func (uc *UseCase) restoreAccount(ctx context.Context, user *auth.User, client auth.ClientInfo) error {
+	if !uc.restorable(user) {
+		return errInvalidCredentials()
+	}
+
+	status := auth.StatusPendingVerification
+	if user.EmailVerified {
+		status = auth.StatusActive
+	}
+
+	if err := uc.deletions.Restore(ctx, user.ID, status); err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return errInvalidCredentials()
+		}
+
+		return fmt.Errorf("UseCase - restoreAccount - uc.deletions.Restore: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventAccountRestored,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"deleted_at": user.DeletedAt},
+	})
+
+	user.Status = status
+	user.DeletedAt = nil
+
+	return nil
+}
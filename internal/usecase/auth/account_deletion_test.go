@@ -0,0 +1,166 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type accountDeletionFixture struct {
+	uc        *authuc.UseCase
+	user      *auth.User
+	users     *memoryUserRepo
+	deletions *memoryAccountDeletionRepo
+	revoked   []uuid.UUID
+	mail      *mockEmailNotifier
+	events    *mockSecurityEventRepo
+}
+
+func newAccountDeletionFixture(t *testing.T, users ...*auth.User) *accountDeletionFixture {
+	t.Helper()
+
+	f := &accountDeletionFixture{
+		user:   existingUser(auth.StatusActive),
+		mail:   &mockEmailNotifier{},
+		events: &mockSecurityEventRepo{},
+	}
+	f.user.EmailVerified = true
+
+	f.users = newMemoryUserRepo(append(users, f.user)...)
+	f.deletions = newMemoryAccountDeletionRepo(f.users)
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: f.users,
+		RefreshTokens: &mockRefreshTokenRepo{
+			revokeAllForUserFunc: func(_ context.Context, userID uuid.UUID) error {
+				f.revoked = append(f.revoked, userID)
+
+				return nil
+			},
+		},
+		Deletions:      f.deletions,
+		Notifier:       f.mail,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+func TestUseCase_AccountDeletion(t *testing.T) {
+	t.Parallel()
+
+	f := newAccountDeletionFixture(t)
+	ctx := context.Background()
+
+	err := f.uc.RequestAccountDeletion(ctx, auth.AccountDeletionInput{UserID: f.user.ID, Password: "wrong"})
+	requireAppError(t, err, apperror.KindValidation, "PASSWORD_INCORRECT")
+
+	err = f.uc.RequestAccountDeletion(ctx, auth.AccountDeletionInput{UserID: f.user.ID, Password: "SecureP@ss123"})
+	require.NoError(t, err)
+	assert.Equal(t, auth.StatusActive, f.user.Status, "nothing changes before the link is followed")
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, "Confirm your account deletion", mail[0].Subject)
+	assert.Len(t, eventsOfType(f.events, auth.EventAccountDeletionRequested), 1)
+
+	raw := linkToken(t, mail[0].Body)
+
+	purgeAt, err := f.uc.ConfirmAccountDeletion(ctx, auth.AccountDeletionConfirmInput{Token: raw, Feedback: " Too many emails "})
+	require.NoError(t, err)
+	assert.Equal(t, auth.StatusDeleted, f.user.Status)
+	require.NotNil(t, f.user.DeletedAt)
+	assert.Equal(t, f.user.DeletedAt.Add(30*24*time.Hour), purgeAt)
+	assert.Equal(t, []uuid.UUID{f.user.ID}, f.revoked, "deleting the account signs the user out everywhere")
+	assert.Len(t, eventsOfType(f.events, auth.EventAccountDeleted), 1)
+
+	for _, d := range f.deletions.deletions {
+		assert.Equal(t, "Too many emails", *d.Feedback)
+	}
+
+	mail = f.mail.sent()
+	require.Len(t, mail, 2)
+	assert.Equal(t, "Your account has been deleted", mail[1].Subject)
+	assert.Contains(t, mail[1].Body, "30 days")
+
+	_, err = f.uc.ConfirmAccountDeletion(ctx, auth.AccountDeletionConfirmInput{Token: raw})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	// Signing in during the grace period restores the account.
+	result, err := f.uc.Login(ctx, auth.LoginInput{Email: f.user.Email, Password: "SecureP@ss123"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	assert.Equal(t, auth.StatusActive, f.user.Status)
+	assert.Nil(t, f.user.DeletedAt)
+	assert.Len(t, eventsOfType(f.events, auth.EventAccountRestored), 1)
+}
+
+func TestUseCase_AccountDeletion_ExpiredLink(t *testing.T) {
+	t.Parallel()
+
+	f := newAccountDeletionFixture(t)
+	ctx := context.Background()
+
+	require.NoError(t, f.deletions.Store(ctx, &auth.AccountDeletion{
+		UserID:    f.user.ID,
+		TokenHash: token.Hash("expired"),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+
+	_, err := f.uc.ConfirmAccountDeletion(ctx, auth.AccountDeletionConfirmInput{Token: "expired"})
+	requireAppError(t, err, apperror.KindUnauthorized, "TOKEN_EXPIRED")
+	assert.Equal(t, auth.StatusActive, f.user.Status)
+}
+
+func TestUseCase_PurgeDeletedAccounts(t *testing.T) {
+	t.Parallel()
+
+	longAgo := time.Now().Add(-31 * 24 * time.Hour)
+	recently := time.Now().Add(-24 * time.Hour)
+
+	expired := existingUser(auth.StatusDeleted)
+	expired.Email = "expired@example.com"
+	expired.DeletedAt = &longAgo
+
+	grace := existingUser(auth.StatusDeleted)
+	grace.Email = "grace@example.com"
+	grace.DeletedAt = &recently
+
+	f := newAccountDeletionFixture(t, expired, grace)
+	ctx := context.Background()
+
+	// Past the grace period, signing in no longer restores the account.
+	_, err := f.uc.Login(ctx, auth.LoginInput{Email: expired.Email, Password: "SecureP@ss123"})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_CREDENTIALS")
+
+	purged, err := f.uc.PurgeDeletedAccounts(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = f.users.GetByID(ctx, expired.ID)
+	require.ErrorIs(t, err, auth.ErrUserNotFound)
+
+	_, err = f.users.GetByID(ctx, grace.ID)
+	require.NoError(t, err, "accounts in their grace period are kept")
+
+	require.Len(t, f.deletions.outbox, 1)
+	assert.Equal(t, auth.OutboxAccountDeleted, f.deletions.outbox[0].EventType)
+
+	var payload auth.AccountDeleted
+	require.NoError(t, json.Unmarshal(f.deletions.outbox[0].Payload, &payload))
+	assert.Equal(t, expired.ID, payload.UserID)
+	assert.WithinDuration(t, longAgo, payload.DeletedAt, time.Second)
+
+	purged, err = f.uc.PurgeDeletedAccounts(ctx, 10)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+}
@@ -0,0 +1,512 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/evrone/go-clean-template/pkg/useragent"
+	"github.com/google/uuid"
+)
+
+const accountRecoveryWindow = time.Hour
+
+// StartAccountRecovery begins recovery for a user who lost their second
+// factor by sending a one-time code to their email address or verified phone
+// number. A session is issued whether or not an account matches, and nothing
+// is sent when none does, so the response never reveals which identifiers
+// are registered.
+func (uc *UseCase) StartAccountRecovery(ctx context.Context, in auth.RecoveryStartInput) (*auth.RecoveryStarted, error) {
+	identifier, identifierType, err := normalizeIdentifier(in.Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	method := in.Method
+	if method == "" {
+		method = auth.RecoveryEmail
+		if identifierType == auth.IdentifierPhone {
+			method = auth.RecoverySMS
+		}
+	}
+
+	now := uc.now().UTC()
+
+	recent, err := uc.recoveries.CountSince(ctx, identifier, now.Add(-accountRecoveryWindow))
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - StartAccountRecovery - uc.recoveries.CountSince: %w", err)
+	}
+
+	if recent >= uc.cfg.AccountRecoveryMaxPerHour {
+		return nil, apperror.RateLimited("Too many recovery requests", apperror.WithRetryAfter(accountRecoveryWindow))
+	}
+
+	user, err := uc.magicLinkAccount(ctx, identifier, identifierType)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &auth.RecoverySession{
+		Identifier:     identifier,
+		IdentifierType: identifierType,
+		Method:         method,
+		SentAt:         now,
+		ExpiresAt:      now.Add(uc.cfg.AccountRecoveryTTL),
+		IPAddress:      optional(in.Client.IPAddress),
+		UserAgent:      optional(in.Client.UserAgent),
+	}
+
+	if user != nil {
+		s.UserID = &user.ID
+	}
+
+	if err = uc.issueRecoveryCode(ctx, s, user, in.Client, false); err != nil {
+		return nil, err
+	}
+
+	return recoveryStarted(s), nil
+}
+
+// ResendAccountRecovery sends a fresh code for a recovery that has not been
+// verified yet. The earlier code stops working and the session keeps its
+// original expiry.
+func (uc *UseCase) ResendAccountRecovery(ctx context.Context, in auth.RecoveryResendInput) error {
+	s, err := uc.pendingRecovery(ctx, in.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if wait := s.SentAt.Add(uc.cfg.AccountRecoveryResendCooldown).Sub(uc.now()); wait > 0 {
+		return apperror.RateLimited("Please wait before requesting another code", apperror.WithRetryAfter(wait))
+	}
+
+	var user *auth.User
+
+	if s.UserID != nil {
+		user, err = uc.users.GetByID(ctx, *s.UserID)
+		if err != nil && !errors.Is(err, auth.ErrUserNotFound) {
+			return fmt.Errorf("UseCase - ResendAccountRecovery - uc.users.GetByID: %w", err)
+		}
+	}
+
+	s.SentAt = uc.now().UTC()
+
+	return uc.issueRecoveryCode(ctx, s, user, in.Client, true)
+}
+
+// VerifyAccountRecovery checks the code sent for a recovery and issues a
+// recovery token. The token only resets MFA once AccountRecoveryCoolingOff
+// has passed, and the account's other channels are told recovery is under
+// way so the owner can stop it by signing in. The code stops working after
+// too many wrong guesses.
+func (uc *UseCase) VerifyAccountRecovery(ctx context.Context, in auth.RecoveryVerifyInput) (*auth.RecoveryVerified, error) {
+	s, err := uc.pendingRecovery(ctx, in.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Attempts >= uc.cfg.AccountRecoveryMaxAttempts {
+		return nil, errAccountRecoveryInvalid()
+	}
+
+	// Sessions for unknown identifiers never match, as no code was sent.
+	if s.UserID == nil || s.OTPHash == nil ||
+		subtle.ConstantTimeCompare([]byte(accountRecoveryCodeHash(s.ID, in.Code)), []byte(*s.OTPHash)) != 1 {
+		return nil, uc.accountRecoveryRejected(ctx, s, in.Client)
+	}
+
+	user, err := uc.recoveringUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - VerifyAccountRecovery - token.Generate: %w", err)
+	}
+
+	now := uc.now().UTC()
+	tokenHash := token.Hash(raw)
+	availableAt := now.Add(uc.cfg.AccountRecoveryCoolingOff)
+
+	s.OTPHash = nil
+	s.RecoveryTokenHash = &tokenHash
+	s.OTPVerifiedAt = &now
+	s.AvailableAt = &availableAt
+	s.ExpiresAt = availableAt.Add(uc.cfg.AccountRecoveryTokenTTL)
+
+	if err = uc.recoveries.Verify(ctx, s); err != nil {
+		if errors.Is(err, auth.ErrRecoverySessionUsed) {
+			return nil, errAccountRecoveryInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - VerifyAccountRecovery - uc.recoveries.Verify: %w", err)
+	}
+
+	mfa, err := uc.MFAStatus(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventAccountRecoveryVerified,
+		Success:   true,
+		RiskLevel: auth.RiskHigh,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+		Details:   map[string]any{"method": string(s.Method), "available_at": availableAt},
+	})
+
+	uc.notifyAccountRecovery(ctx, user, s, in.Client)
+
+	return &auth.RecoveryVerified{
+		Token:       raw,
+		Actions:     []string{auth.RecoveryActionResetMFA},
+		AvailableAt: availableAt,
+		ExpiresAt:   s.ExpiresAt,
+		Email:       user.Email,
+		MFA:         mfa,
+	}, nil
+}
+
+// ResetMFAWithRecovery spends a recovery token to remove the account's
+// authenticator app, SMS factor and recovery codes, optionally setting a new
+// password, and signs the user in. All other sessions are revoked.
+func (uc *UseCase) ResetMFAWithRecovery(ctx context.Context, in auth.RecoveryResetMFAInput) (*auth.AuthResult, error) {
+	s, err := uc.recoveries.GetByTokenHash(ctx, token.Hash(in.Token))
+	if err != nil {
+		if errors.Is(err, auth.ErrRecoverySessionNotFound) {
+			return nil, errAccountRecoveryInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - ResetMFAWithRecovery - uc.recoveries.GetByTokenHash: %w", err)
+	}
+
+	if !s.IsOpen() || !s.IsVerified() || s.UserID == nil || s.AvailableAt == nil {
+		return nil, errAccountRecoveryInvalid()
+	}
+
+	now := uc.now().UTC()
+
+	if s.IsExpired(now) {
+		return nil, apperror.Unauthorized("Recovery session has expired; start again", apperror.WithCode(codeRecoveryExpired))
+	}
+
+	if wait := s.AvailableAt.Sub(now); wait > 0 {
+		return nil, apperror.Forbidden(
+			fmt.Sprintf("Two-factor authentication can be reset after %s", s.AvailableAt.Format(emailTimeLayout)),
+			apperror.WithCode(codeRecoveryCoolingOff),
+			apperror.WithRetryAfter(wait),
+		)
+	}
+
+	user, err := uc.recoveringUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var passwordHash *string
+
+	if in.NewPassword != "" {
+		if res := uc.policy.Check(in.NewPassword, user.Email, deref(user.Name)); !res.Valid {
+			return nil, errPasswordTooWeak("new_password", res)
+		}
+
+		hash, err := uc.hasher.Hash(in.NewPassword)
+		if err != nil {
+			return nil, fmt.Errorf("UseCase - ResetMFAWithRecovery - uc.hasher.Hash: %w", err)
+		}
+
+		passwordHash = &hash
+	}
+
+	mfa, err := uc.MFAStatus(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = uc.recoveries.ResetMFA(ctx, s, passwordHash, now); err != nil {
+		if errors.Is(err, auth.ErrRecoverySessionUsed) || errors.Is(err, auth.ErrUserNotFound) {
+			return nil, errAccountRecoveryInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - ResetMFAWithRecovery - uc.recoveries.ResetMFA: %w", err)
+	}
+
+	if err = uc.refreshTokens.RevokeAllForUser(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("UseCase - ResetMFAWithRecovery - uc.refreshTokens.RevokeAllForUser: %w", err)
+	}
+
+	uc.recordAccountRecovered(ctx, user, s, mfa, passwordHash != nil, in.Client)
+
+	return uc.finishLogin(ctx, user, false, in.Client)
+}
+
+// cancelAccountRecovery calls off the user's open recoveries. Signing in
+// shows the owner still has the account, so a recovery someone else started
+// must not go on to remove their second factor.
+func (uc *UseCase) cancelAccountRecovery(ctx context.Context, userID uuid.UUID, client auth.ClientInfo) error {
+	canceled, err := uc.recoveries.CancelForUser(ctx, userID, uc.now().UTC())
+	if err != nil {
+		return fmt.Errorf("UseCase - cancelAccountRecovery - uc.recoveries.CancelForUser: %w", err)
+	}
+
+	if canceled > 0 {
+		uc.recordEvent(ctx, &auth.SecurityEvent{
+			UserID:    &userID,
+			Type:      auth.EventAccountRecoveryCanceled,
+			Success:   true,
+			IPAddress: optional(client.IPAddress),
+			UserAgent: optional(client.UserAgent),
+			Details:   map[string]any{"reason": "signed_in", "sessions": canceled},
+		})
+	}
+
+	return nil
+}
+
+// pendingRecovery looks up a session whose code can still be entered.
+func (uc *UseCase) pendingRecovery(ctx context.Context, id uuid.UUID) (*auth.RecoverySession, error) {
+	s, err := uc.recoveries.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, auth.ErrRecoverySessionNotFound) {
+			return nil, errAccountRecoveryInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - pendingRecovery - uc.recoveries.GetByID: %w", err)
+	}
+
+	if !s.IsOpen() || s.IsVerified() {
+		return nil, errAccountRecoveryInvalid()
+	}
+
+	if s.IsExpired(uc.now()) {
+		return nil, apperror.Unauthorized("Recovery code has expired; start again", apperror.WithCode(codeRecoveryExpired))
+	}
+
+	return s, nil
+}
+
+// recoveringUser returns the account a session recovers, which must still be
+// open.
+func (uc *UseCase) recoveringUser(ctx context.Context, s *auth.RecoverySession) (*auth.User, error) {
+	user, err := uc.users.GetByID(ctx, *s.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, errAccountRecoveryInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - recoveringUser - uc.users.GetByID: %w", err)
+	}
+
+	if user.Status == auth.StatusDisabled || user.Status == auth.StatusDeleted {
+		return nil, errAccountRecoveryInvalid()
+	}
+
+	return user, nil
+}
+
+// issueRecoveryCode gives the session a new code, saves it and sends it to
+// wherever the method reaches the user.
+func (uc *UseCase) issueRecoveryCode(ctx context.Context, s *auth.RecoverySession, user *auth.User, client auth.ClientInfo, resend bool) error {
+	code, err := token.NumericCode(smsCodeDigits)
+	if err != nil {
+		return fmt.Errorf("UseCase - issueRecoveryCode - token.NumericCode: %w", err)
+	}
+
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	otpHash := accountRecoveryCodeHash(s.ID, code)
+	s.OTPHash = &otpHash
+
+	if resend {
+		if err = uc.recoveries.Reissue(ctx, s); err != nil {
+			if errors.Is(err, auth.ErrRecoverySessionUsed) {
+				return errAccountRecoveryInvalid()
+			}
+
+			return fmt.Errorf("UseCase - issueRecoveryCode - uc.recoveries.Reissue: %w", err)
+		}
+	} else if err = uc.recoveries.Store(ctx, s); err != nil {
+		return fmt.Errorf("UseCase - issueRecoveryCode - uc.recoveries.Store: %w", err)
+	}
+
+	to := recoveryDestination(s, user)
+	if to == "" {
+		return nil
+	}
+
+	expiresIn := humanDuration(s.ExpiresAt.Sub(s.SentAt))
+
+	// As with password resets, a failed delivery must not tell the caller
+	// the account exists; it is recorded in the delivery log.
+	if s.Method == auth.RecoverySMS {
+		_ = uc.sms.SendSMS(ctx, &notification.SMSMessage{
+			UserID: user.ID,
+			To:     to,
+			Body: fmt.Sprintf("Your account recovery code is %s. It expires in %s. If you did not ask to recover your account, ignore this message.",
+				code, expiresIn),
+			Transactional: true,
+		})
+	} else {
+		_ = uc.sendEmail(ctx, user, to, accountRecoveryCodeTemplate, emailData{Code: code, ExpiresIn: expiresIn})
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    s.UserID,
+		Type:      auth.EventAccountRecoveryStarted,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(s.Method), "resend": resend},
+	})
+
+	return nil
+}
+
+// accountRecoveryRejected counts a wrong code against the session and records it.
+func (uc *UseCase) accountRecoveryRejected(ctx context.Context, s *auth.RecoverySession, client auth.ClientInfo) error {
+	if _, err := uc.recoveries.RecordFailure(ctx, s.ID); err != nil && !errors.Is(err, auth.ErrRecoverySessionNotFound) {
+		return fmt.Errorf("UseCase - accountRecoveryRejected - uc.recoveries.RecordFailure: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    s.UserID,
+		Type:      auth.EventAccountRecoveryVerified,
+		Success:   false,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"reason": "invalid_code"},
+	})
+
+	return errAccountRecoveryCodeInvalid()
+}
+
+// notifyAccountRecovery warns the user over every channel other than the one
+// the recovery code went to that their second factor is about to be reset.
+func (uc *UseCase) notifyAccountRecovery(ctx context.Context, user *auth.User, s *auth.RecoverySession, client auth.ClientInfo) {
+	data := uc.signInDetails(useragent.Parse(client.UserAgent), uc.events.locate(client.IPAddress), client.IPAddress)
+	data.Time = s.AvailableAt.Format(emailTimeLayout)
+	data.Link = uc.cfg.AppURL + securitySettingsPath
+
+	// The code was already verified; delivery errors are recorded in the
+	// delivery log.
+	if s.Method != auth.RecoveryEmail {
+		_ = uc.sendEmail(ctx, user, user.Email, accountRecoveryStartedTemplate, data)
+	}
+
+	if s.Method != auth.RecoverySMS && user.PhoneVerified && user.PhoneNumber != nil {
+		_ = uc.sms.SendSMS(ctx, &notification.SMSMessage{
+			UserID: user.ID,
+			To:     *user.PhoneNumber,
+			Body: fmt.Sprintf("Someone is recovering your account and can remove two-factor authentication after %s. Not you? Sign in to cancel it.",
+				data.Time),
+			Transactional: true,
+		})
+	}
+
+	_ = uc.push.SendPush(ctx, &notification.PushMessage{
+		UserID: user.ID,
+		Title:  "Account recovery started",
+		Body:   fmt.Sprintf("From %s, %s. Not you? Sign in to cancel it.", data.Device, data.Location),
+		Data:   map[string]string{"type": string(auth.EventAccountRecoveryVerified), "available_at": data.Time},
+	})
+}
+
+// recordAccountRecovered audits a completed recovery and tells the user
+// their second factors were removed.
+func (uc *UseCase) recordAccountRecovered(ctx context.Context, user *auth.User, s *auth.RecoverySession, mfa *auth.MFAStatus, passwordReset bool, client auth.ClientInfo) {
+	var methods []string
+
+	if mfa.TOTPEnabled {
+		methods = append(methods, string(auth.MFAMethodTOTP))
+	}
+
+	if mfa.SMSEnabled {
+		methods = append(methods, string(auth.MFAMethodSMS))
+	}
+
+	if len(methods) > 0 {
+		uc.recordEvent(ctx, &auth.SecurityEvent{
+			UserID:    &user.ID,
+			Type:      auth.EventMFADisabled,
+			Success:   true,
+			RiskLevel: auth.RiskHigh,
+			IPAddress: optional(client.IPAddress),
+			UserAgent: optional(client.UserAgent),
+			Details:   map[string]any{"methods": methods, "reason": "account_recovery"},
+		})
+	}
+
+	if passwordReset {
+		uc.recordEvent(ctx, &auth.SecurityEvent{
+			UserID:    &user.ID,
+			Type:      auth.EventPasswordResetCompleted,
+			Success:   true,
+			IPAddress: optional(client.IPAddress),
+			UserAgent: optional(client.UserAgent),
+			Details:   map[string]any{"reason": "account_recovery"},
+		})
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventAccountRecoveryCompleted,
+		Success:   true,
+		RiskLevel: auth.RiskHigh,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(s.Method), "password_reset": passwordReset},
+	})
+
+	data := uc.signInDetails(useragent.Parse(client.UserAgent), uc.events.locate(client.IPAddress), client.IPAddress)
+	data.Link = uc.cfg.AppURL + securitySettingsPath
+
+	// MFA is already gone; delivery errors are recorded in the delivery log.
+	_ = uc.sendEmail(ctx, user, user.Email, accountRecoveredTemplate, data)
+}
+
+// recoveryDestination is the address or number a recovery code is sent to,
+// or "" when there is none: the account does not exist, is closed, or has
+// no verified phone for SMS.
+func recoveryDestination(s *auth.RecoverySession, user *auth.User) string {
+	if user == nil || user.Status == auth.StatusDisabled || user.Status == auth.StatusDeleted {
+		return ""
+	}
+
+	if s.Method == auth.RecoveryEmail {
+		return user.Email
+	}
+
+	if user.PhoneVerified && user.PhoneNumber != nil {
+		return *user.PhoneNumber
+	}
+
+	return ""
+}
+
+func recoveryStarted(s *auth.RecoverySession) *auth.RecoveryStarted {
+	started := &auth.RecoveryStarted{SessionID: s.ID, Method: s.Method, ExpiresAt: s.ExpiresAt}
+
+	if (s.IdentifierType == auth.IdentifierEmail) == (s.Method == auth.RecoveryEmail) {
+		started.Destination = s.Identifier
+	}
+
+	return started
+}
+
+// accountRecoveryCodeHash binds the hash to the session like magicLinkCodeHash.
+func accountRecoveryCodeHash(sessionID uuid.UUID, code string) string {
+	return token.Hash(sessionID.String() + ":" + code)
+}
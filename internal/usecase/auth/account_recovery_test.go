@@ -0,0 +1,212 @@
+package auth_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type accountRecoveryFixture struct {
+	uc       *authuc.UseCase
+	user     *auth.User
+	sessions *memoryAccountRecoveryRepo
+	mail     *mockEmailNotifier
+	sms      *mockSMSNotifier
+	push     *mockPushNotifier
+	events   *mockSecurityEventRepo
+}
+
+var recoveryClient = auth.ClientInfo{IPAddress: "203.0.113.7", UserAgent: safariOnIPhone}
+
+// newAccountRecoveryFixture returns a user with a verified phone number and,
+// when withMFA is set, an authenticator app and recovery codes.
+func newAccountRecoveryFixture(t *testing.T, withMFA bool) *accountRecoveryFixture {
+	t.Helper()
+
+	phone := testPhone
+
+	f := &accountRecoveryFixture{
+		user:   existingUser(auth.StatusActive),
+		mail:   &mockEmailNotifier{},
+		sms:    &mockSMSNotifier{},
+		push:   &mockPushNotifier{},
+		events: &mockSecurityEventRepo{},
+	}
+	f.user.PhoneNumber = &phone
+	f.user.PhoneVerified = true
+
+	users := newMemoryUserRepo(f.user)
+	totps := newMemoryTOTPRepo()
+	codes := newMemoryRecoveryCodeRepo()
+
+	if withMFA {
+		entry, _ := pendingTOTP(t, f.user.ID)
+		verifiedAt := time.Now()
+		entry.VerifiedAt = &verifiedAt
+		totps = newMemoryTOTPRepo(entry)
+
+		require.NoError(t, codes.Replace(context.Background(), f.user.ID, []string{"a", "b"}))
+	}
+
+	f.sessions = newMemoryAccountRecoveryRepo(users, totps, newMemorySMSFactorRepo(), codes)
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:          users,
+		TOTP:           totps,
+		RecoveryCodes:  codes,
+		Recoveries:     f.sessions,
+		Notifier:       f.mail,
+		SMS:            f.sms,
+		Push:           f.push,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+// verify starts a recovery by email and enters the code that was sent.
+func (f *accountRecoveryFixture) verify(t *testing.T) *auth.RecoveryVerified {
+	t.Helper()
+
+	ctx := context.Background()
+
+	started, err := f.uc.StartAccountRecovery(ctx, auth.RecoveryStartInput{
+		Identifier: f.user.Email, Method: auth.RecoveryEmail, Client: recoveryClient,
+	})
+	require.NoError(t, err)
+
+	mail := f.mail.sent()
+	require.NotEmpty(t, mail)
+
+	code := regexp.MustCompile(`\d{6}`).FindString(mail[len(mail)-1].Body)
+	require.NotEmpty(t, code)
+
+	verified, err := f.uc.VerifyAccountRecovery(ctx, auth.RecoveryVerifyInput{
+		SessionID: started.SessionID, Code: code, Client: recoveryClient,
+	})
+	require.NoError(t, err)
+
+	return verified
+}
+
+func TestUseCase_AccountRecovery(t *testing.T) {
+	t.Parallel()
+
+	f := newAccountRecoveryFixture(t, true)
+	ctx := context.Background()
+
+	started, err := f.uc.StartAccountRecovery(ctx, auth.RecoveryStartInput{
+		Identifier: "User@Example.com", Method: auth.RecoveryEmail, Client: recoveryClient,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, f.user.Email, started.Destination)
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, "Your account recovery code", mail[0].Subject)
+
+	err = f.uc.ResendAccountRecovery(ctx, auth.RecoveryResendInput{SessionID: started.SessionID})
+	requireAppError(t, err, apperror.KindRateLimited, "RATE_LIMITED")
+
+	_, err = f.uc.VerifyAccountRecovery(ctx, auth.RecoveryVerifyInput{SessionID: started.SessionID, Code: "000000"})
+	requireAppError(t, err, apperror.KindUnauthorized, "RECOVERY_CODE_INVALID")
+
+	verified, err := f.uc.VerifyAccountRecovery(ctx, auth.RecoveryVerifyInput{
+		SessionID: started.SessionID,
+		Code:      regexp.MustCompile(`\d{6}`).FindString(mail[0].Body),
+		Client:    recoveryClient,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{auth.RecoveryActionResetMFA}, verified.Actions)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), verified.AvailableAt, time.Minute)
+	assert.True(t, verified.MFA.TOTPEnabled)
+
+	// The other channels hear about it; the email that got the code does not.
+	texts := f.sms.sent()
+	require.Len(t, texts, 1)
+	assert.Equal(t, testPhone, texts[0].To)
+	assert.Contains(t, texts[0].Body, "Sign in to cancel")
+	assert.Len(t, f.push.sent(), 1)
+	assert.Len(t, f.mail.sent(), 1)
+
+	// The code only works once.
+	_, err = f.uc.VerifyAccountRecovery(ctx, auth.RecoveryVerifyInput{SessionID: started.SessionID, Code: "000000"})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	_, err = f.uc.ResetMFAWithRecovery(ctx, auth.RecoveryResetMFAInput{Token: verified.Token})
+	requireAppError(t, err, apperror.KindForbidden, "RECOVERY_COOLING_OFF")
+
+	f.sessions.finishCoolingOff()
+
+	_, err = f.uc.ResetMFAWithRecovery(ctx, auth.RecoveryResetMFAInput{Token: verified.Token, NewPassword: "weak"})
+	requireAppError(t, err, apperror.KindValidation, "PASSWORD_TOO_WEAK")
+
+	result, err := f.uc.ResetMFAWithRecovery(ctx, auth.RecoveryResetMFAInput{
+		Token: verified.Token, NewPassword: "NewSecureP@ss456", Client: recoveryClient,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	assert.Equal(t, "hashed:NewSecureP@ss456", *f.user.PasswordHash)
+
+	mfa, err := f.uc.MFAStatus(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.False(t, mfa.Enabled())
+
+	disabled := eventsOfType(f.events, auth.EventMFADisabled)
+	require.Len(t, disabled, 1)
+	assert.Equal(t, []string{"totp"}, disabled[0].Details["methods"])
+	assert.Len(t, eventsOfType(f.events, auth.EventAccountRecoveryCompleted), 1)
+
+	mail = f.mail.sent()
+	require.Len(t, mail, 2)
+	assert.Equal(t, "Two-factor authentication was removed", mail[1].Subject)
+
+	_, err = f.uc.ResetMFAWithRecovery(ctx, auth.RecoveryResetMFAInput{Token: verified.Token})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+}
+
+func TestUseCase_AccountRecovery_UnknownIdentifier(t *testing.T) {
+	t.Parallel()
+
+	f := newAccountRecoveryFixture(t, false)
+	ctx := context.Background()
+
+	started, err := f.uc.StartAccountRecovery(ctx, auth.RecoveryStartInput{
+		Identifier: "nobody@example.com", Method: auth.RecoveryEmail,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, started.SessionID)
+	assert.Empty(t, f.mail.sent())
+
+	_, err = f.uc.VerifyAccountRecovery(ctx, auth.RecoveryVerifyInput{SessionID: started.SessionID, Code: "123456"})
+	requireAppError(t, err, apperror.KindUnauthorized, "RECOVERY_CODE_INVALID")
+}
+
+func TestUseCase_AccountRecovery_SignInCancels(t *testing.T) {
+	t.Parallel()
+
+	f := newAccountRecoveryFixture(t, false)
+	ctx := context.Background()
+
+	verified := f.verify(t)
+
+	_, err := f.uc.Login(ctx, auth.LoginInput{Email: f.user.Email, Password: "SecureP@ss123"})
+	require.NoError(t, err)
+
+	canceled := eventsOfType(f.events, auth.EventAccountRecoveryCanceled)
+	require.Len(t, canceled, 1)
+	assert.Equal(t, "signed_in", canceled[0].Details["reason"])
+
+	f.sessions.finishCoolingOff()
+
+	_, err = f.uc.ResetMFAWithRecovery(ctx, auth.RecoveryResetMFAInput{Token: verified.Token})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+}
@@ -0,0 +1,598 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/repo"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/encryption"
+	"github.com/evrone/go-clean-template/pkg/password"
+	"github.com/evrone/go-clean-template/pkg/storage"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+)
+
+// dummyPassword is hashed once and verified against when the account does
+// not exist, so unknown emails take as long as wrong passwords.
+const dummyPassword = "dummy-password-for-timing"
+
+type Config struct {
+	RefreshTokenTTL time.Duration
+	RememberMeTTL   time.Duration
+
+	// LoginMaxAttempts wrong passwords within LoginAttemptWindow lock the
+	// account for LoginLockout, doubling with each lockout in a row up to
+	// LoginMaxLockout.
+	LoginMaxAttempts   int
+	LoginAttemptWindow time.Duration
+	LoginLockout       time.Duration
+	LoginMaxLockout    time.Duration
+
+	// AppURL is the frontend base URL that emailed links point to.
+	AppURL                     string
+	EmailVerificationTTL       time.Duration
+	VerificationResendCooldown time.Duration
+	VerificationMaxPerHour     int
+	PasswordResetTTL           time.Duration
+	PasswordResetMaxPerHour    int
+
+	// TOTPIssuer labels the account in authenticator apps.
+	TOTPIssuer string
+	// TOTPSkew is how many 30-second steps either side of now a code may be from.
+	TOTPSkew int
+	// MFAChallengeTTL bounds how long a login may wait at the MFA step.
+	MFAChallengeTTL time.Duration
+	// MFAMaxAttempts failed codes within MFAAttemptWindow block further attempts.
+	MFAMaxAttempts   int
+	MFAAttemptWindow time.Duration
+	// MFACodeTTL is the lifetime of a texted code.
+	MFACodeTTL            time.Duration
+	MFACodeResendCooldown time.Duration
+	// RecoveryCodeCount codes are issued per set, each shaped like
+	// RecoveryCodeFormat with X standing for a random character.
+	RecoveryCodeCount  int
+	RecoveryCodeFormat string
+
+	// OAuthStateTTL bounds how long a social sign-in may take at the provider.
+	OAuthStateTTL time.Duration
+	// OAuthRedirectURLs are where providers may send users back to; the first
+	// is the default. "{provider}" stands for the provider name.
+	OAuthRedirectURLs []string
+
+	// MagicLinkTTL bounds how long a passwordless sign-in link and code work.
+	MagicLinkTTL            time.Duration
+	MagicLinkResendCooldown time.Duration
+	MagicLinkMaxPerHour     int
+	// MagicLinkMaxAttempts wrong codes void a sign-in.
+	MagicLinkMaxAttempts int
+	// MagicLinkSignUp lets a link sent to an unknown email create the account.
+	MagicLinkSignUp bool
+	// MagicLinkBindClient only accepts a sign-in from the IP address and user
+	// agent that requested it.
+	MagicLinkBindClient bool
+
+	// AccountRecoveryTTL bounds how long an account recovery code works.
+	AccountRecoveryTTL            time.Duration
+	AccountRecoveryResendCooldown time.Duration
+	AccountRecoveryMaxPerHour     int
+	// AccountRecoveryMaxAttempts wrong codes void a recovery.
+	AccountRecoveryMaxAttempts int
+	// AccountRecoveryCoolingOff is how long a verified recovery waits before
+	// it can reset MFA, giving the owner time to cancel it by signing in.
+	AccountRecoveryCoolingOff time.Duration
+	// AccountRecoveryTokenTTL is how long a recovery token works once the
+	// cooling-off period is over.
+	AccountRecoveryTokenTTL time.Duration
+
+	// EmailChangeTTL bounds how long the link confirming a new email works;
+	// EmailChangeRevertTTL is how long the old address can undo the change.
+	EmailChangeTTL       time.Duration
+	EmailChangeRevertTTL time.Duration
+	// PhoneChangeCodeTTL is the lifetime of the code texted to a new number.
+	// PhoneChangeMaxAttempts wrong codes void it.
+	PhoneChangeCodeTTL     time.Duration
+	PhoneChangeMaxAttempts int
+	// ContactChangeResendCooldown and ContactChangeMaxPerHour limit how often
+	// each of the email and phone number can be changed.
+	ContactChangeResendCooldown time.Duration
+	ContactChangeMaxPerHour     int
+
+	// AccountDeletionTTL bounds how long the link confirming a deletion works.
+	AccountDeletionTTL time.Duration
+	// AccountDeletionGracePeriod is how long a deleted account can still be
+	// restored by signing in before it is purged.
+	AccountDeletionGracePeriod time.Duration
+
+	// DataExportTTL is how long a finished data export can be downloaded
+	// before its archive is deleted. DataExportTimeout is how long building
+	// one may take before another job starts over.
+	DataExportTTL     time.Duration
+	DataExportTimeout time.Duration
+
+	// AvailabilityMaxPerWindow email and phone availability checks can be made
+	// from an IP address within AvailabilityWindow. Every answer takes at
+	// least AvailabilityMinDuration, which should exceed a user lookup.
+	AvailabilityMaxPerWindow int
+	AvailabilityWindow       time.Duration
+	AvailabilityMinDuration  time.Duration
+	// DisposableEmailDomains can't be registered with, subdomains included.
+	DisposableEmailDomains []string
+
+	// AvatarBaseURL is where uploaded avatars are served from. Uploads of up
+	// to AvatarMaxBytes and AvatarMaxSide pixels a side are cropped square
+	// and stored at each of AvatarSizes.
+	AvatarBaseURL  string
+	AvatarMaxBytes int64
+	AvatarMaxSide  int
+	AvatarSizes    []int
+
+	// PasskeyRPName is the site name authenticators show when saving a passkey.
+	PasskeyRPName string
+	// PasskeyTimeout bounds how long a passkey ceremony may take.
+	PasskeyTimeout time.Duration
+
+	// LoginHistoryWindow is how far back sign-ins count when deciding whether
+	// a device or country is new to the user.
+	LoginHistoryWindow time.Duration
+	// LoginAlerts emails and pushes the user after a sign-in from a new
+	// device or country.
+	LoginAlerts bool
+	// LoginStepUp holds back high-risk logins of users without MFA until they
+	// enter a code sent to their email.
+	LoginStepUp bool
+}
+
+type UseCase struct {
+	users         repo.UserRepo
+	refreshTokens repo.RefreshTokenRepo
+	verifications repo.EmailVerificationRepo
+	resets        repo.PasswordResetRepo
+	totp          repo.TOTPRepo
+	smsFactors    repo.SMSFactorRepo
+	recoveryCodes repo.RecoveryCodeRepo
+	challenges    repo.MFAChallengeRepo
+	magicLinks    repo.MagicLinkRepo
+	recoveries    repo.AccountRecoveryRepo
+	emailChanges  repo.EmailChangeRepo
+	phoneChanges  repo.PhoneChangeRepo
+	deletions     repo.AccountDeletionRepo
+	exports       repo.DataExportRepo
+	storage       storage.Storage
+	availability  repo.AvailabilityCheckRepo
+	disposable    map[string]struct{}
+	avatarSizes   []int
+	events        *SecurityEventRecorder
+	eventLog      repo.SecurityEventRepo
+	hasher        password.Hasher
+	policy        *password.Policy
+	cipher        encryption.Cipher
+	tokens        *TokenService
+	notifier      EmailNotifier
+	sms           SMSNotifier
+	push          PushNotifier
+	cfg           Config
+	now           func() time.Time
+
+	oauth            map[auth.OAuthProvider]OAuthClient
+	oauthConnections repo.OAuthConnectionRepo
+
+	passkeys           repo.PasskeyRepo
+	webauthnChallenges repo.WebAuthnChallengeRepo
+	webauthn           PasskeyVerifier
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+type UseCaseDeps struct {
+	Users          repo.UserRepo
+	RefreshTokens  repo.RefreshTokenRepo
+	Verifications  repo.EmailVerificationRepo
+	PasswordResets repo.PasswordResetRepo
+	TOTP           repo.TOTPRepo
+	SMSFactors     repo.SMSFactorRepo
+	RecoveryCodes  repo.RecoveryCodeRepo
+	MFAChallenges  repo.MFAChallengeRepo
+	MagicLinks     repo.MagicLinkRepo
+	Recoveries     repo.AccountRecoveryRepo
+	EmailChanges   repo.EmailChangeRepo
+	PhoneChanges   repo.PhoneChangeRepo
+	Deletions      repo.AccountDeletionRepo
+	// Exports and Storage back personal data exports; Storage keeps the
+	// archives.
+	Exports repo.DataExportRepo
+	Storage storage.Storage
+	// Availability rate limits email and phone availability checks.
+	Availability repo.AvailabilityCheckRepo
+	// OAuthConnections and OAuth back social sign-in; providers missing from
+	// OAuth are unavailable.
+	OAuthConnections repo.OAuthConnectionRepo
+	OAuth            map[auth.OAuthProvider]OAuthClient
+	// Passkeys, WebAuthnChallenges and WebAuthn back passkey sign-in.
+	Passkeys           repo.PasskeyRepo
+	WebAuthnChallenges repo.WebAuthnChallengeRepo
+	WebAuthn           PasskeyVerifier
+	SecurityEvents     repo.SecurityEventRepo
+	// GeoIP adds locations to security events when set.
+	GeoIP          GeoLocator
+	Hasher         password.Hasher
+	PasswordPolicy *password.Policy
+	Secrets        encryption.Cipher
+	Tokens         *TokenService
+	Notifier       EmailNotifier
+	SMS            SMSNotifier
+	Push           PushNotifier
+	Config         Config
+}
+
+func NewUseCase(deps *UseCaseDeps) *UseCase {
+	return &UseCase{
+		users:         deps.Users,
+		refreshTokens: deps.RefreshTokens,
+		verifications: deps.Verifications,
+		resets:        deps.PasswordResets,
+		totp:          deps.TOTP,
+		smsFactors:    deps.SMSFactors,
+		recoveryCodes: deps.RecoveryCodes,
+		challenges:    deps.MFAChallenges,
+		magicLinks:    deps.MagicLinks,
+		recoveries:    deps.Recoveries,
+		emailChanges:  deps.EmailChanges,
+		phoneChanges:  deps.PhoneChanges,
+		deletions:     deps.Deletions,
+		exports:       deps.Exports,
+		storage:       deps.Storage,
+		availability:  deps.Availability,
+		disposable:    disposableDomains(deps.Config.DisposableEmailDomains),
+		avatarSizes:   avatarSizes(deps.Config.AvatarSizes),
+		events:        NewSecurityEventRecorder(deps.SecurityEvents, deps.GeoIP),
+		eventLog:      deps.SecurityEvents,
+		hasher:        deps.Hasher,
+		policy:        deps.PasswordPolicy,
+		cipher:        deps.Secrets,
+		tokens:        deps.Tokens,
+		notifier:      deps.Notifier,
+		sms:           deps.SMS,
+		push:          deps.Push,
+		cfg:           deps.Config,
+		now:           time.Now,
+
+		oauth:            deps.OAuth,
+		oauthConnections: deps.OAuthConnections,
+
+		passkeys:           deps.Passkeys,
+		webauthnChallenges: deps.WebAuthnChallenges,
+		webauthn:           deps.WebAuthn,
+	}
+}
+
+func (uc *UseCase) Register(ctx context.Context, in auth.RegisterInput) (*auth.AuthResult, error) {
+	email, err := normalizeEmail(in.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.isDisposable(email) {
+		return nil, errDisposableEmail("email")
+	}
+
+	if res := uc.policy.Check(in.Password, email, in.Name); !res.Valid {
+		return nil, errPasswordTooWeak("password", res)
+	}
+
+	hash, err := uc.hasher.Hash(in.Password)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - Register - uc.hasher.Hash: %w", err)
+	}
+
+	user := &auth.User{
+		Email:        email,
+		PasswordHash: &hash,
+		Status:       auth.StatusPendingVerification,
+	}
+
+	if name := strings.TrimSpace(in.Name); name != "" {
+		user.Name = &name
+	}
+
+	if err = uc.users.Create(ctx, user); err != nil {
+		if errors.Is(err, auth.ErrEmailAlreadyExists) {
+			return nil, apperror.Conflict("An account with this email already exists",
+				apperror.WithCode(codeEmailAlreadyExists),
+				apperror.WithCause(err),
+			)
+		}
+
+		return nil, fmt.Errorf("UseCase - Register - uc.users.Create: %w", err)
+	}
+
+	// A failed delivery is recorded in the delivery log and the user can ask
+	// for another email, so it must not undo the registration.
+	_ = uc.sendVerification(ctx, user)
+
+	tokens, err := uc.startSession(ctx, user.ID, false, in.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.AuthResult{User: user, Tokens: tokens}, nil
+}
+
+func (uc *UseCase) Login(ctx context.Context, in auth.LoginInput) (*auth.AuthResult, error) {
+	email, err := normalizeEmail(in.Email)
+	if err != nil {
+		return nil, errInvalidCredentials()
+	}
+
+	user, err := uc.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			uc.verifyDummy(in.Password)
+			uc.recordLoginFailure(ctx, nil, "unknown_email", in.Client)
+
+			return nil, errInvalidCredentials()
+		}
+
+		return nil, fmt.Errorf("UseCase - Login - uc.users.GetByEmail: %w", err)
+	}
+
+	if locked := uc.lockedOut(ctx, user, in.Client); locked != nil {
+		return locked, nil
+	}
+
+	if (user.Status == auth.StatusDeleted && !uc.restorable(user)) || !user.HasPassword() {
+		uc.verifyDummy(in.Password)
+		uc.recordLoginFailure(ctx, &user.ID, "no_password", in.Client)
+
+		return nil, errInvalidCredentials()
+	}
+
+	ok, err := uc.hasher.Verify(in.Password, *user.PasswordHash)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - Login - uc.hasher.Verify: %w", err)
+	}
+
+	if !ok {
+		return uc.failPassword(ctx, user, in.Client)
+	}
+
+	if user.Status == auth.StatusDisabled {
+		uc.recordLoginFailure(ctx, &user.ID, "account_disabled", in.Client)
+
+		return nil, apperror.Forbidden("Account has been disabled", apperror.WithCode(codeAccountDisabled))
+	}
+
+	mfa, err := uc.MFAStatus(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfa.Enabled() {
+		return uc.startMFAChallenge(ctx, user, mfa.Methods(), in)
+	}
+
+	if uc.cfg.LoginStepUp {
+		risk, err := uc.assessLogin(ctx, user.ID, in.Client)
+		if err != nil {
+			return nil, err
+		}
+
+		if risk.level == auth.RiskHigh {
+			return uc.startStepUpChallenge(ctx, user, risk, in)
+		}
+	}
+
+	return uc.finishLogin(ctx, user, in.RememberMe, in.Client)
+}
+
+func (uc *UseCase) Logout(ctx context.Context, in auth.LogoutInput) error {
+	if in.AllSessions {
+		if err := uc.refreshTokens.RevokeAllForUser(ctx, in.UserID); err != nil {
+			return fmt.Errorf("UseCase - Logout - uc.refreshTokens.RevokeAllForUser: %w", err)
+		}
+
+		uc.recordLogout(ctx, in)
+
+		return nil
+	}
+
+	if err := uc.refreshTokens.RevokeFamily(ctx, in.UserID, in.SessionID); err != nil {
+		return fmt.Errorf("UseCase - Logout - uc.refreshTokens.RevokeFamily: %w", err)
+	}
+
+	uc.recordLogout(ctx, in)
+
+	if in.RefreshToken == "" {
+		return nil
+	}
+
+	rt, err := uc.refreshTokens.GetByHash(ctx, token.Hash(in.RefreshToken))
+	if err != nil {
+		if errors.Is(err, auth.ErrRefreshTokenNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("UseCase - Logout - uc.refreshTokens.GetByHash: %w", err)
+	}
+
+	if rt.UserID != in.UserID || rt.FamilyID == in.SessionID {
+		return nil
+	}
+
+	if err = uc.refreshTokens.RevokeFamily(ctx, in.UserID, rt.FamilyID); err != nil {
+		return fmt.Errorf("UseCase - Logout - uc.refreshTokens.RevokeFamily: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *UseCase) recordLogout(ctx context.Context, in auth.LogoutInput) {
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &in.UserID,
+		Type:      auth.EventLogout,
+		Success:   true,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+		Details:   map[string]any{"all_sessions": in.AllSessions},
+	})
+}
+
+func (uc *UseCase) Me(ctx context.Context, userID uuid.UUID) (*auth.User, error) {
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, apperror.NotFound("User not found", apperror.WithCause(err))
+		}
+
+		return nil, fmt.Errorf("UseCase - Me - uc.users.GetByID: %w", err)
+	}
+
+	return user, nil
+}
+
+// finishLogin records the sign-in and opens a session for a fully
+// authenticated user, who is alerted when it came from somewhere new. An
+// account deleted within the grace period is restored.
+func (uc *UseCase) finishLogin(ctx context.Context, user *auth.User, rememberMe bool, client auth.ClientInfo) (*auth.AuthResult, error) {
+	now := uc.now().UTC()
+
+	if user.Status == auth.StatusDeleted {
+		if err := uc.restoreAccount(ctx, user, client); err != nil {
+			return nil, err
+		}
+	}
+
+	risk, err := uc.assessLogin(ctx, user.ID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	lockedUntil := user.LockedUntil
+
+	if err = uc.users.UpdateLastLogin(ctx, user.ID, now, client.IPAddress); err != nil {
+		return nil, fmt.Errorf("UseCase - finishLogin - uc.users.UpdateLastLogin: %w", err)
+	}
+
+	user.LastLoginAt = &now
+	user.FailedLoginAttempts = 0
+
+	if err = uc.cancelAccountRecovery(ctx, user.ID, client); err != nil {
+		return nil, err
+	}
+
+	// A lockout that has run out is only cleared now; every sign-in path
+	// refuses one that is still running before getting here.
+	if lockedUntil != nil {
+		uc.unlocked(ctx, user, "lockout_expired", client)
+		user.LockedUntil = nil
+	}
+
+	tokens, err := uc.startSession(ctx, user.ID, rememberMe, client)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventLoginSuccess,
+		Success:   true,
+		RiskLevel: risk.level,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+	})
+
+	uc.reviewLogin(ctx, user, risk, client)
+
+	return &auth.AuthResult{User: user, Tokens: tokens}, nil
+}
+
+func (uc *UseCase) recordLoginFailure(ctx context.Context, userID *uuid.UUID, reason string, client auth.ClientInfo) {
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    userID,
+		Type:      auth.EventLoginFailed,
+		Success:   false,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"reason": reason},
+	})
+}
+
+// startSession creates a new refresh token family and the access token bound to it.
+func (uc *UseCase) startSession(ctx context.Context, userID uuid.UUID, rememberMe bool, client auth.ClientInfo) (*auth.TokenPair, error) {
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - startSession - token.Generate: %w", err)
+	}
+
+	ttl := uc.cfg.RefreshTokenTTL
+	if rememberMe {
+		ttl = uc.cfg.RememberMeTTL
+	}
+
+	rt := &auth.RefreshToken{
+		UserID:     userID,
+		TokenHash:  token.Hash(raw),
+		FamilyID:   uuid.New(),
+		Generation: 1,
+		DeviceInfo: optional(client.DeviceInfo),
+		IPAddress:  optional(client.IPAddress),
+		UserAgent:  optional(client.UserAgent),
+		ExpiresAt:  uc.now().UTC().Add(ttl),
+	}
+
+	if err = uc.refreshTokens.Store(ctx, rt); err != nil {
+		return nil, fmt.Errorf("UseCase - startSession - uc.refreshTokens.Store: %w", err)
+	}
+
+	return uc.tokenPair(ctx, userID, rt.FamilyID, raw)
+}
+
+func (uc *UseCase) tokenPair(ctx context.Context, userID, sessionID uuid.UUID, refreshToken string) (*auth.TokenPair, error) {
+	access, err := uc.tokens.Issue(ctx, userID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - tokenPair - uc.tokens.Issue: %w", err)
+	}
+
+	return &auth.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(uc.tokens.AccessTTL().Seconds()),
+		TokenType:    auth.TokenTypeBearer,
+	}, nil
+}
+
+func (uc *UseCase) verifyDummy(pw string) {
+	uc.dummyOnce.Do(func() {
+		uc.dummyHash, _ = uc.hasher.Hash(dummyPassword) //nolint:errcheck // best-effort timing equalization
+	})
+
+	if uc.dummyHash != "" {
+		_, _ = uc.hasher.Verify(pw, uc.dummyHash) //nolint:errcheck // result is irrelevant
+	}
+}
+
+func normalizeEmail(raw string) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", apperror.Validation("Invalid email address", apperror.WithField("email", "must be a valid email address"))
+	}
+
+	return email, nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
@@ -0,0 +1,623 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/password"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthUseCase(t *testing.T, users *mockUserRepo, refreshTokens *mockRefreshTokenRepo) *authuc.UseCase {
+	t.Helper()
+
+	return newAuthUseCaseWithEvents(t, users, refreshTokens, &mockSecurityEventRepo{})
+}
+
+func newAuthUseCaseWithEvents(t *testing.T, users *mockUserRepo, refreshTokens *mockRefreshTokenRepo, events *mockSecurityEventRepo) *authuc.UseCase {
+	t.Helper()
+
+	return newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:          users,
+		RefreshTokens:  refreshTokens,
+		SecurityEvents: events,
+	})
+}
+
+// newTestUseCase fills every dependency left nil in deps with a no-op mock.
+func newTestUseCase(t *testing.T, deps *authuc.UseCaseDeps) *authuc.UseCase {
+	t.Helper()
+
+	if deps.Users == nil {
+		deps.Users = &mockUserRepo{}
+	}
+
+	if deps.RefreshTokens == nil {
+		deps.RefreshTokens = &mockRefreshTokenRepo{}
+	}
+
+	if deps.Verifications == nil {
+		deps.Verifications = &mockEmailVerificationRepo{}
+	}
+
+	if deps.PasswordResets == nil {
+		deps.PasswordResets = &mockPasswordResetRepo{}
+	}
+
+	if deps.TOTP == nil {
+		deps.TOTP = newMemoryTOTPRepo()
+	}
+
+	if deps.SMSFactors == nil {
+		deps.SMSFactors = newMemorySMSFactorRepo()
+	}
+
+	if deps.RecoveryCodes == nil {
+		deps.RecoveryCodes = newMemoryRecoveryCodeRepo()
+	}
+
+	if deps.MFAChallenges == nil {
+		deps.MFAChallenges = newMemoryMFAChallengeRepo()
+	}
+
+	if deps.OAuthConnections == nil {
+		deps.OAuthConnections = newMemoryOAuthConnectionRepo(newMemoryUserRepo())
+	}
+
+	if deps.Passkeys == nil {
+		deps.Passkeys = newMemoryPasskeyRepo()
+	}
+
+	if deps.WebAuthnChallenges == nil {
+		deps.WebAuthnChallenges = newMemoryWebAuthnChallengeRepo()
+	}
+
+	if deps.WebAuthn == nil {
+		deps.WebAuthn = &fakePasskeyVerifier{}
+	}
+
+	if deps.MagicLinks == nil {
+		deps.MagicLinks = newMemoryMagicLinkRepo(newMemoryUserRepo())
+	}
+
+	if deps.Recoveries == nil {
+		deps.Recoveries = newMemoryAccountRecoveryRepo(newMemoryUserRepo(), newMemoryTOTPRepo(), newMemorySMSFactorRepo(), newMemoryRecoveryCodeRepo())
+	}
+
+	if deps.EmailChanges == nil {
+		deps.EmailChanges = newMemoryEmailChangeRepo(newMemoryUserRepo())
+	}
+
+	if deps.PhoneChanges == nil {
+		deps.PhoneChanges = newMemoryPhoneChangeRepo(newMemoryUserRepo())
+	}
+
+	if deps.Deletions == nil {
+		deps.Deletions = newMemoryAccountDeletionRepo(newMemoryUserRepo())
+	}
+
+	if deps.Exports == nil {
+		deps.Exports = newMemoryDataExportRepo()
+	}
+
+	if deps.Storage == nil {
+		deps.Storage = newMemoryStorage()
+	}
+
+	if deps.Availability == nil {
+		deps.Availability = newMemoryAvailabilityCheckRepo()
+	}
+
+	if deps.SecurityEvents == nil {
+		deps.SecurityEvents = &mockSecurityEventRepo{}
+	}
+
+	if deps.Hasher == nil {
+		deps.Hasher = plainHasher{}
+	}
+
+	if deps.PasswordPolicy == nil {
+		deps.PasswordPolicy = password.NewPolicy(password.Requirements{
+			MinLength:        8,
+			MaxLength:        128,
+			RequireUppercase: true,
+			RequireLowercase: true,
+			RequireNumber:    true,
+		}, "password123")
+	}
+
+	if deps.Secrets == nil {
+		deps.Secrets = newTestCipher(t)
+	}
+
+	if deps.Tokens == nil {
+		deps.Tokens = newTestTokenService(t)
+	}
+
+	if deps.Notifier == nil {
+		deps.Notifier = &mockEmailNotifier{}
+	}
+
+	if deps.SMS == nil {
+		deps.SMS = &mockSMSNotifier{}
+	}
+
+	if deps.Push == nil {
+		deps.Push = &mockPushNotifier{}
+	}
+
+	deps.Config = authuc.Config{
+		RefreshTokenTTL:            24 * time.Hour,
+		RememberMeTTL:              30 * 24 * time.Hour,
+		LoginMaxAttempts:           3,
+		LoginAttemptWindow:         15 * time.Minute,
+		LoginLockout:               15 * time.Minute,
+		LoginMaxLockout:            time.Hour,
+		AppURL:                     "https://app.example.com",
+		EmailVerificationTTL:       24 * time.Hour,
+		VerificationResendCooldown: time.Minute,
+		VerificationMaxPerHour:     5,
+		PasswordResetTTL:           time.Hour,
+		PasswordResetMaxPerHour:    3,
+		TOTPIssuer:                 "Thiam",
+		TOTPSkew:                   1,
+		MFAChallengeTTL:            5 * time.Minute,
+		MFAMaxAttempts:             3,
+		MFAAttemptWindow:           5 * time.Minute,
+		MFACodeTTL:                 5 * time.Minute,
+		MFACodeResendCooldown:      time.Minute,
+		RecoveryCodeCount:          4,
+		RecoveryCodeFormat:         "XXXX-XXXX",
+		OAuthStateTTL:              10 * time.Minute,
+		OAuthRedirectURLs:          []string{"https://app.example.com/oauth/{provider}", "https://m.example.com/oauth"},
+		PasskeyRPName:              "Thiam",
+		PasskeyTimeout:             5 * time.Minute,
+		MagicLinkTTL:               15 * time.Minute,
+		MagicLinkResendCooldown:    time.Minute,
+		MagicLinkMaxPerHour:        3,
+		MagicLinkMaxAttempts:       3,
+		MagicLinkSignUp:            true,
+		MagicLinkBindClient:        true,
+		LoginHistoryWindow:         90 * 24 * time.Hour,
+		LoginAlerts:                true,
+		LoginStepUp:                true,
+
+		AccountRecoveryTTL:            15 * time.Minute,
+		AccountRecoveryResendCooldown: time.Minute,
+		AccountRecoveryMaxPerHour:     3,
+		AccountRecoveryMaxAttempts:    3,
+		AccountRecoveryCoolingOff:     24 * time.Hour,
+		AccountRecoveryTokenTTL:       48 * time.Hour,
+		EmailChangeTTL:                24 * time.Hour,
+		EmailChangeRevertTTL:          7 * 24 * time.Hour,
+		PhoneChangeCodeTTL:            10 * time.Minute,
+		PhoneChangeMaxAttempts:        3,
+		ContactChangeResendCooldown:   time.Minute,
+		ContactChangeMaxPerHour:       3,
+		AccountDeletionTTL:            time.Hour,
+		AccountDeletionGracePeriod:    30 * 24 * time.Hour,
+		DataExportTTL:                 72 * time.Hour,
+		DataExportTimeout:             30 * time.Minute,
+		AvailabilityMaxPerWindow:      5,
+		AvailabilityWindow:            10 * time.Minute,
+		AvailabilityMinDuration:       20 * time.Millisecond,
+		DisposableEmailDomains:        []string{"mailinator.com", " Trashmail.COM "},
+		AvatarBaseURL:                 "https://api.example.com/v1/avatars/",
+		AvatarMaxBytes:                64 << 10,
+		AvatarMaxSide:                 512,
+		AvatarSizes:                   []int{32, 16, 0, 32},
+	}
+
+	return authuc.NewUseCase(deps)
+}
+
+func existingUser(status auth.Status) *auth.User {
+	hash := "hashed:SecureP@ss123"
+
+	return &auth.User{
+		ID:           uuid.New(),
+		Email:        "user@example.com",
+		PasswordHash: &hash,
+		Status:       status,
+	}
+}
+
+func requireAppError(t *testing.T, err error, kind apperror.Kind, code string) {
+	t.Helper()
+
+	appErr, ok := apperror.AsAppError(err)
+	require.True(t, ok, "expected apperror, got %v", err)
+	assert.Equal(t, kind, appErr.Kind())
+	assert.Equal(t, code, appErr.Code())
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_Register(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		users    *mockUserRepo
+		input    auth.RegisterInput
+		wantKind apperror.Kind
+		wantCode string
+		wantErr  bool
+	}{
+		{
+			name:  "success",
+			users: &mockUserRepo{},
+			input: auth.RegisterInput{Email: "  User@Example.com ", Password: "SecureP@ss123", Name: "John Doe"},
+		},
+		{
+			name:     "invalid email",
+			users:    &mockUserRepo{},
+			input:    auth.RegisterInput{Email: "not-an-email", Password: "SecureP@ss123"},
+			wantErr:  true,
+			wantKind: apperror.KindValidation,
+			wantCode: "VALIDATION_ERROR",
+		},
+		{
+			name:     "disposable email",
+			users:    &mockUserRepo{},
+			input:    auth.RegisterInput{Email: "user@eu.mailinator.com", Password: "SecureP@ss123"},
+			wantErr:  true,
+			wantKind: apperror.KindValidation,
+			wantCode: "VALIDATION_ERROR",
+		},
+		{
+			name:     "short password",
+			users:    &mockUserRepo{},
+			input:    auth.RegisterInput{Email: "user@example.com", Password: "short"},
+			wantErr:  true,
+			wantKind: apperror.KindValidation,
+			wantCode: "PASSWORD_TOO_WEAK",
+		},
+		{
+			name: "email taken",
+			users: &mockUserRepo{
+				createFunc: func(_ context.Context, _ *auth.User) error {
+					return auth.ErrEmailAlreadyExists
+				},
+			},
+			input:    auth.RegisterInput{Email: "user@example.com", Password: "SecureP@ss123"},
+			wantErr:  true,
+			wantKind: apperror.KindConflict,
+			wantCode: "EMAIL_ALREADY_EXISTS",
+		},
+		{
+			name: "repo error",
+			users: &mockUserRepo{
+				createFunc: func(_ context.Context, _ *auth.User) error {
+					return errRepo
+				},
+			},
+			input:    auth.RegisterInput{Email: "user@example.com", Password: "SecureP@ss123"},
+			wantErr:  true,
+			wantKind: apperror.KindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stored *auth.RefreshToken
+
+			refreshTokens := &mockRefreshTokenRepo{
+				storeFunc: func(_ context.Context, rt *auth.RefreshToken) error {
+					stored = rt
+
+					return nil
+				},
+			}
+
+			result, err := newAuthUseCase(t, tt.users, refreshTokens).Register(context.Background(), tt.input)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantKind, apperror.GetKind(err))
+
+				if tt.wantCode != "" {
+					requireAppError(t, err, tt.wantKind, tt.wantCode)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "user@example.com", result.User.Email)
+			assert.Equal(t, auth.StatusPendingVerification, result.User.Status)
+			require.NotNil(t, result.User.Name)
+			assert.Equal(t, "John Doe", *result.User.Name)
+			assert.Equal(t, "hashed:SecureP@ss123", *result.User.PasswordHash)
+			require.NotNil(t, result.Tokens)
+			assert.Equal(t, auth.TokenTypeBearer, result.Tokens.TokenType)
+			assert.Equal(t, 900, result.Tokens.ExpiresIn)
+			require.NotNil(t, stored)
+			assert.Equal(t, token.Hash(result.Tokens.RefreshToken), stored.TokenHash)
+			assert.Equal(t, 1, stored.Generation)
+		})
+	}
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_Login(t *testing.T) {
+	t.Parallel()
+
+	lockedUntil := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		user          *auth.User
+		getErr        error
+		password      string
+		wantChallenge auth.ChallengeType
+		wantKind      apperror.Kind
+		wantCode      string
+		wantErr       bool
+	}{
+		{
+			name:     "success",
+			user:     existingUser(auth.StatusActive),
+			password: "SecureP@ss123",
+		},
+		{
+			name:     "pending verification can sign in",
+			user:     existingUser(auth.StatusPendingVerification),
+			password: "SecureP@ss123",
+		},
+		{
+			name:     "unknown email",
+			getErr:   auth.ErrUserNotFound,
+			password: "SecureP@ss123",
+			wantErr:  true,
+			wantKind: apperror.KindUnauthorized,
+			wantCode: "INVALID_CREDENTIALS",
+		},
+		{
+			name:     "wrong password",
+			user:     existingUser(auth.StatusActive),
+			password: "wrong",
+			wantErr:  true,
+			wantKind: apperror.KindUnauthorized,
+			wantCode: "INVALID_CREDENTIALS",
+		},
+		{
+			name:     "deleted account",
+			user:     existingUser(auth.StatusDeleted),
+			password: "SecureP@ss123",
+			wantErr:  true,
+			wantKind: apperror.KindUnauthorized,
+			wantCode: "INVALID_CREDENTIALS",
+		},
+		{
+			name:     "disabled account",
+			user:     existingUser(auth.StatusDisabled),
+			password: "SecureP@ss123",
+			wantErr:  true,
+			wantKind: apperror.KindForbidden,
+			wantCode: "ACCOUNT_DISABLED",
+		},
+		{
+			name: "locked account",
+			user: func() *auth.User {
+				u := existingUser(auth.StatusActive)
+				u.LockedUntil = &lockedUntil
+
+				return u
+			}(),
+			password:      "SecureP@ss123",
+			wantChallenge: auth.ChallengeAccountLocked,
+		},
+		{
+			name:     "repo error",
+			getErr:   errRepo,
+			password: "SecureP@ss123",
+			wantErr:  true,
+			wantKind: apperror.KindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			users := &mockUserRepo{
+				getByEmailFunc: func(_ context.Context, email string) (*auth.User, error) {
+					assert.Equal(t, "user@example.com", email)
+
+					return tt.user, tt.getErr
+				},
+			}
+
+			result, err := newAuthUseCase(t, users, &mockRefreshTokenRepo{}).Login(context.Background(), auth.LoginInput{
+				Email:    "User@example.com",
+				Password: tt.password,
+				Client:   auth.ClientInfo{IPAddress: "203.0.113.7"},
+			})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantKind, apperror.GetKind(err))
+
+				if tt.wantCode != "" {
+					requireAppError(t, err, tt.wantKind, tt.wantCode)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tt.wantChallenge != "" {
+				require.NotNil(t, result.Challenge)
+				assert.Nil(t, result.Tokens)
+				assert.Equal(t, tt.wantChallenge, result.Challenge.Type)
+				assert.Equal(t, &lockedUntil, result.Challenge.LockedUntil)
+
+				return
+			}
+
+			require.NotNil(t, result.Tokens)
+			assert.NotEmpty(t, result.Tokens.AccessToken)
+			assert.NotEmpty(t, result.Tokens.RefreshToken)
+			assert.NotNil(t, result.User.LastLoginAt)
+		})
+	}
+}
+
+func TestUseCase_Login_RememberMeExtendsRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	var stored *auth.RefreshToken
+
+	users := &mockUserRepo{
+		getByEmailFunc: func(_ context.Context, _ string) (*auth.User, error) {
+			return existingUser(auth.StatusActive), nil
+		},
+	}
+	refreshTokens := &mockRefreshTokenRepo{
+		storeFunc: func(_ context.Context, rt *auth.RefreshToken) error {
+			stored = rt
+
+			return nil
+		},
+	}
+
+	_, err := newAuthUseCase(t, users, refreshTokens).Login(context.Background(), auth.LoginInput{
+		Email:      "user@example.com",
+		Password:   "SecureP@ss123",
+		RememberMe: true,
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), stored.ExpiresAt, time.Minute)
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_Logout(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	otherFamily := uuid.New()
+
+	tests := []struct {
+		name         string
+		input        auth.LogoutInput
+		stored       *auth.RefreshToken
+		wantRevoked  []uuid.UUID
+		wantAll      bool
+		revokeAllErr error
+		wantErr      bool
+	}{
+		{
+			name:        "current session",
+			input:       auth.LogoutInput{UserID: userID, SessionID: sessionID},
+			wantRevoked: []uuid.UUID{sessionID},
+		},
+		{
+			name:        "current session and supplied refresh token",
+			input:       auth.LogoutInput{UserID: userID, SessionID: sessionID, RefreshToken: "other"},
+			stored:      &auth.RefreshToken{UserID: userID, FamilyID: otherFamily},
+			wantRevoked: []uuid.UUID{sessionID, otherFamily},
+		},
+		{
+			name:        "refresh token of another user is ignored",
+			input:       auth.LogoutInput{UserID: userID, SessionID: sessionID, RefreshToken: "foreign"},
+			stored:      &auth.RefreshToken{UserID: uuid.New(), FamilyID: otherFamily},
+			wantRevoked: []uuid.UUID{sessionID},
+		},
+		{
+			name:    "all sessions",
+			input:   auth.LogoutInput{UserID: userID, SessionID: sessionID, AllSessions: true},
+			wantAll: true,
+		},
+		{
+			name:         "all sessions repo error",
+			input:        auth.LogoutInput{UserID: userID, SessionID: sessionID, AllSessions: true},
+			revokeAllErr: errRepo,
+			wantAll:      true,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				revoked   []uuid.UUID
+				revokeAll bool
+			)
+
+			refreshTokens := &mockRefreshTokenRepo{
+				getByHashFunc: func(_ context.Context, hash string) (*auth.RefreshToken, error) {
+					if tt.stored == nil {
+						return nil, auth.ErrRefreshTokenNotFound
+					}
+
+					assert.Equal(t, token.Hash(tt.input.RefreshToken), hash)
+
+					return tt.stored, nil
+				},
+				revokeFamilyFunc: func(_ context.Context, uid, familyID uuid.UUID) error {
+					assert.Equal(t, userID, uid)
+
+					revoked = append(revoked, familyID)
+
+					return nil
+				},
+				revokeAllForUserFunc: func(_ context.Context, _ uuid.UUID) error {
+					revokeAll = true
+
+					return tt.revokeAllErr
+				},
+			}
+
+			err := newAuthUseCase(t, &mockUserRepo{}, refreshTokens).Logout(context.Background(), tt.input)
+
+			if tt.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAll, revokeAll)
+			assert.Equal(t, tt.wantRevoked, revoked)
+		})
+	}
+}
+
+func TestUseCase_Me(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+
+	uc := newAuthUseCase(t, &mockUserRepo{
+		getByIDFunc: func(_ context.Context, id uuid.UUID) (*auth.User, error) {
+			if id == user.ID {
+				return user, nil
+			}
+
+			return nil, auth.ErrUserNotFound
+		},
+	}, &mockRefreshTokenRepo{})
+
+	got, err := uc.Me(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	_, err = uc.Me(context.Background(), uuid.New())
+	require.Error(t, err)
+	assert.True(t, apperror.IsNotFound(err))
+}
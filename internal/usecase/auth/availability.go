@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/notify"
+)
+
+// CheckEmailAvailability tells whether an email address is free to register
+// with. Addresses are compared case-insensitively and disposable domains are
+// refused. Each IP address gets a limited number of checks and every answer
+// takes at least AvailabilityMinDuration, so the check is of little use for
+// finding out who has an account.
+func (uc *UseCase) CheckEmailAvailability(ctx context.Context, in auth.AvailabilityInput) (*auth.Availability, error) {
+	defer uc.padAvailability(ctx, time.Now())
+
+	if err := uc.limitAvailability(ctx, in.Client); err != nil {
+		return nil, err
+	}
+
+	email, err := normalizeEmail(in.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.isDisposable(email) {
+		return &auth.Availability{Value: email, Reason: auth.UnavailableDisposable}, nil
+	}
+
+	switch _, err = uc.users.GetByEmail(ctx, email); {
+	case err == nil:
+		return &auth.Availability{Value: email, Reason: auth.UnavailableTaken}, nil
+	case errors.Is(err, auth.ErrUserNotFound):
+		return &auth.Availability{Value: email, Available: true}, nil
+	default:
+		return nil, fmt.Errorf("UseCase - CheckEmailAvailability - uc.users.GetByEmail: %w", err)
+	}
+}
+
+// CheckPhoneAvailability tells whether a phone number is free to register
+// with. Spaces, dashes, dots and brackets are ignored and a leading 00 is
+// read as +, so the number compares in E.164 form. It is rate limited and
+// padded like CheckEmailAvailability.
+func (uc *UseCase) CheckPhoneAvailability(ctx context.Context, in auth.AvailabilityInput) (*auth.Availability, error) {
+	defer uc.padAvailability(ctx, time.Now())
+
+	if err := uc.limitAvailability(ctx, in.Client); err != nil {
+		return nil, err
+	}
+
+	phone, err := normalizePhone(in.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch _, err = uc.users.GetByPhone(ctx, phone); {
+	case err == nil:
+		return &auth.Availability{Value: phone, Reason: auth.UnavailableTaken}, nil
+	case errors.Is(err, auth.ErrUserNotFound):
+		return &auth.Availability{Value: phone, Available: true}, nil
+	default:
+		return nil, fmt.Errorf("UseCase - CheckPhoneAvailability - uc.users.GetByPhone: %w", err)
+	}
+}
+
+// limitAvailability counts the check against the client's IP address. Every
+// check counts, including refused ones, so a client over the limit stays
+// there until it slows down.
+func (uc *UseCase) limitAvailability(ctx context.Context, client auth.ClientInfo) error {
+	recent, err := uc.availability.Record(ctx, client.IPAddress, uc.now().UTC().Add(-uc.cfg.AvailabilityWindow))
+	if err != nil {
+		return fmt.Errorf("UseCase - limitAvailability - uc.availability.Record: %w", err)
+	}
+
+	if recent > uc.cfg.AvailabilityMaxPerWindow {
+		return apperror.RateLimited("Too many availability checks", apperror.WithRetryAfter(uc.cfg.AvailabilityWindow))
+	}
+
+	return nil
+}
+
+// padAvailability holds an answer until AvailabilityMinDuration has passed
+// since start, so taken, free and refused values take equally long.
+func (uc *UseCase) padAvailability(ctx context.Context, start time.Time) {
+	wait := uc.cfg.AvailabilityMinDuration - time.Since(start)
+	if wait <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// isDisposable reports whether a normalized email address is at one of the
+// DisposableEmailDomains or a subdomain of one.
+func (uc *UseCase) isDisposable(email string) bool {
+	domain := email[strings.LastIndexByte(email, '@')+1:]
+
+	for domain != "" {
+		if _, ok := uc.disposable[domain]; ok {
+			return true
+		}
+
+		_, domain, _ = strings.Cut(domain, ".")
+	}
+
+	return false
+}
+
+// normalizePhone turns a phone number as people write it into E.164.
+func normalizePhone(raw string) (string, error) {
+	phone := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		default:
+			return r
+		}
+	}, strings.TrimSpace(raw))
+
+	if rest, ok := strings.CutPrefix(phone, "00"); ok {
+		phone = "+" + rest
+	}
+
+	if err := notify.ValidateE164(phone); err != nil {
+		return "", apperror.Validation("Invalid phone number",
+			apperror.WithField("phone_number", "must be an international number, e.g. +14155551234"))
+	}
+
+	return phone, nil
+}
+
+// disposableDomains turns the configured domains into a set.
+func disposableDomains(domains []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(domains))
+
+	for _, d := range domains {
+		if d = strings.ToLower(strings.Trim(strings.TrimSpace(d), ".")); d != "" {
+			set[d] = struct{}{}
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,182 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAvailabilityUseCase(t *testing.T) *authuc.UseCase {
+	t.Helper()
+
+	phone := "+14155551234"
+
+	user := existingUser(auth.StatusActive)
+	user.PhoneNumber = &phone
+
+	return newTestUseCase(t, &authuc.UseCaseDeps{Users: newMemoryUserRepo(user)})
+}
+
+func TestUseCase_CheckEmailAvailability(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		email  string
+		want   auth.Availability
+		reject bool
+	}{
+		{
+			name:  "free",
+			email: "new@example.com",
+			want:  auth.Availability{Value: "new@example.com", Available: true},
+		},
+		{
+			name:  "taken in another case",
+			email: "  USER@Example.com ",
+			want:  auth.Availability{Value: "user@example.com", Reason: auth.UnavailableTaken},
+		},
+		{
+			name:  "disposable",
+			email: "someone@mailinator.com",
+			want:  auth.Availability{Value: "someone@mailinator.com", Reason: auth.UnavailableDisposable},
+		},
+		{
+			name:  "disposable subdomain",
+			email: "someone@mx.trashmail.com",
+			want:  auth.Availability{Value: "someone@mx.trashmail.com", Reason: auth.UnavailableDisposable},
+		},
+		{
+			name:  "lookalike domain",
+			email: "someone@notmailinator.com",
+			want:  auth.Availability{Value: "someone@notmailinator.com", Available: true},
+		},
+		{
+			name:   "invalid",
+			email:  "not-an-email",
+			reject: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			uc := newAvailabilityUseCase(t)
+
+			got, err := uc.CheckEmailAvailability(context.Background(), auth.AvailabilityInput{Value: tt.email})
+			if tt.reject {
+				requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func TestUseCase_CheckPhoneAvailability(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		phone  string
+		want   auth.Availability
+		reject bool
+	}{
+		{
+			name:  "free",
+			phone: "+442071838750",
+			want:  auth.Availability{Value: "+442071838750", Available: true},
+		},
+		{
+			name:  "taken as typed",
+			phone: " +1 (415) 555-1234 ",
+			want:  auth.Availability{Value: "+14155551234", Reason: auth.UnavailableTaken},
+		},
+		{
+			name:  "international prefix",
+			phone: "0014155551234",
+			want:  auth.Availability{Value: "+14155551234", Reason: auth.UnavailableTaken},
+		},
+		{
+			name:   "no country code",
+			phone:  "415.555.1234",
+			reject: true,
+		},
+		{
+			name:   "letters",
+			phone:  "+1415CALLNOW",
+			reject: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			uc := newAvailabilityUseCase(t)
+
+			got, err := uc.CheckPhoneAvailability(context.Background(), auth.AvailabilityInput{Value: tt.phone})
+			if tt.reject {
+				requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func TestUseCase_Availability_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	uc := newAvailabilityUseCase(t)
+	ctx := context.Background()
+	client := auth.ClientInfo{IPAddress: "203.0.113.7"}
+
+	// Email and phone checks share the limit, and refused checks count too.
+	for range 2 {
+		_, err := uc.CheckEmailAvailability(ctx, auth.AvailabilityInput{Value: "new@example.com", Client: client})
+		require.NoError(t, err)
+
+		_, err = uc.CheckPhoneAvailability(ctx, auth.AvailabilityInput{Value: "12345", Client: client})
+		requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+	}
+
+	_, err := uc.CheckEmailAvailability(ctx, auth.AvailabilityInput{Value: "new@example.com", Client: client})
+	require.NoError(t, err)
+
+	_, err = uc.CheckPhoneAvailability(ctx, auth.AvailabilityInput{Value: "+442071838750", Client: client})
+	requireAppError(t, err, apperror.KindRateLimited, "RATE_LIMITED")
+
+	_, err = uc.CheckEmailAvailability(ctx, auth.AvailabilityInput{
+		Value:  "new@example.com",
+		Client: auth.ClientInfo{IPAddress: "198.51.100.1"},
+	})
+	require.NoError(t, err, "other addresses have their own limit")
+}
+
+func TestUseCase_Availability_UniformTiming(t *testing.T) {
+	t.Parallel()
+
+	uc := newAvailabilityUseCase(t)
+	ctx := context.Background()
+
+	for _, email := range []string{"user@example.com", "new@example.com", "a@mailinator.com", "invalid"} {
+		start := time.Now()
+		_, _ = uc.CheckEmailAvailability(ctx, auth.AvailabilityInput{Value: email}) //nolint:errcheck // only timing matters
+
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond, email)
+	}
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+)
+
+// CompleteMFAChallenge finishes a login that stopped at an MFA challenge. Failed
+// codes count against a per-user limit over a sliding window.
+func (uc *UseCase) CompleteMFAChallenge(ctx context.Context, in auth.MFAChallengeInput) (*auth.AuthResult, error) {
+	c, err := uc.pendingChallenge(ctx, in.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	method := in.Method
+	if method == "" {
+		method = auth.MFAMethodTOTP
+		if c.Allows(auth.MFAMethodEmail) {
+			method = auth.MFAMethodEmail
+		}
+	}
+
+	return uc.completeChallenge(ctx, c, method, in.Code, in.Client)
+}
+
+// completeChallenge checks code against a pending challenge and, when it
+// matches, signs the user in.
+func (uc *UseCase) completeChallenge(ctx context.Context, c *auth.MFAChallenge, method auth.MFAMethod, code string, client auth.ClientInfo) (*auth.AuthResult, error) {
+	if err := uc.checkChallenge(ctx, c, method); err != nil {
+		return nil, err
+	}
+
+	ok, err := uc.verifyMFACode(ctx, c, method, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		if err = uc.failChallenge(ctx, c, method, client); err != nil {
+			return nil, err
+		}
+
+		if method == auth.MFAMethodRecoveryCode {
+			return nil, errRecoveryCodeInvalid()
+		}
+
+		return nil, errMFAInvalidCode()
+	}
+
+	result, err := uc.passChallenge(ctx, c, method, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if method == auth.MFAMethodRecoveryCode {
+		uc.recoveryCodeUsed(ctx, result.User, client)
+	}
+
+	return result, nil
+}
+
+// checkChallenge rejects a method the challenge does not offer, and any
+// attempt once the user has failed too many over the attempt window.
+func (uc *UseCase) checkChallenge(ctx context.Context, c *auth.MFAChallenge, method auth.MFAMethod) error {
+	if !c.Allows(method) {
+		return apperror.Validation("This verification method is not enabled",
+			apperror.WithCode(codeMFANotEnabled),
+			apperror.WithField("method", "is not available for this account"),
+		)
+	}
+
+	failures, err := uc.challenges.FailuresSince(ctx, c.UserID, uc.now().UTC().Add(-uc.cfg.MFAAttemptWindow))
+	if err != nil {
+		return fmt.Errorf("UseCase - checkChallenge - uc.challenges.FailuresSince: %w", err)
+	}
+
+	if failures >= uc.cfg.MFAMaxAttempts {
+		return apperror.RateLimited("Too many failed verification attempts",
+			apperror.WithRetryAfter(uc.cfg.MFAAttemptWindow))
+	}
+
+	return nil
+}
+
+// failChallenge counts a failed attempt against the challenge.
+func (uc *UseCase) failChallenge(ctx context.Context, c *auth.MFAChallenge, method auth.MFAMethod, client auth.ClientInfo) error {
+	if _, err := uc.challenges.RecordFailure(ctx, c.ID); err != nil {
+		return fmt.Errorf("UseCase - failChallenge - uc.challenges.RecordFailure: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &c.UserID,
+		Type:      auth.EventMFAChallengeFailed,
+		Success:   false,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(method)},
+	})
+
+	return nil
+}
+
+// passChallenge marks the challenge used and signs the user in.
+func (uc *UseCase) passChallenge(ctx context.Context, c *auth.MFAChallenge, method auth.MFAMethod, client auth.ClientInfo) (*auth.AuthResult, error) {
+	if err := uc.challenges.Complete(ctx, c.ID, uc.now().UTC()); err != nil {
+		if errors.Is(err, auth.ErrMFAChallengeCompleted) {
+			return nil, errChallengeInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - passChallenge - uc.challenges.Complete: %w", err)
+	}
+
+	user, err := uc.users.GetByID(ctx, c.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, errChallengeInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - passChallenge - uc.users.GetByID: %w", err)
+	}
+
+	result, err := uc.finishLogin(ctx, user, c.RememberMe, client)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventMFAChallengeSuccess,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(method)},
+	})
+
+	return result, nil
+}
+
+// pendingChallenge looks up a challenge that can still be completed.
+func (uc *UseCase) pendingChallenge(ctx context.Context, raw string) (*auth.MFAChallenge, error) {
+	c, err := uc.challenges.GetByHash(ctx, token.Hash(raw))
+	if err != nil {
+		if errors.Is(err, auth.ErrMFAChallengeNotFound) {
+			return nil, errChallengeInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - pendingChallenge - uc.challenges.GetByHash: %w", err)
+	}
+
+	if c.CompletedAt != nil {
+		return nil, errChallengeInvalid()
+	}
+
+	if c.IsExpired(uc.now()) {
+		return nil, apperror.Unauthorized("MFA challenge has expired; sign in again", apperror.WithCode(codeTokenExpired))
+	}
+
+	return c, nil
+}
+
+// startMFAChallenge stores a challenge for a user whose password checked out
+// and returns it in place of tokens.
+func (uc *UseCase) startMFAChallenge(ctx context.Context, user *auth.User, methods []auth.MFAMethod, in auth.LoginInput) (*auth.AuthResult, error) {
+	_, raw, err := uc.newChallenge(ctx, user.ID, methods, in.RememberMe, in.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]string, len(methods))
+	for i, m := range methods {
+		available[i] = string(m)
+	}
+
+	return &auth.AuthResult{
+		User: user,
+		Challenge: &auth.LoginChallenge{
+			Type:             auth.ChallengeMFARequired,
+			Token:            raw,
+			AvailableMethods: available,
+			Message:          "Multi-factor authentication required",
+		},
+	}, nil
+}
+
+// newChallenge stores a pending challenge and returns it with its raw token.
+func (uc *UseCase) newChallenge(ctx context.Context, userID uuid.UUID, methods []auth.MFAMethod, rememberMe bool, client auth.ClientInfo) (*auth.MFAChallenge, string, error) {
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return nil, "", fmt.Errorf("UseCase - newChallenge - token.Generate: %w", err)
+	}
+
+	c := &auth.MFAChallenge{
+		UserID:           userID,
+		TokenHash:        token.Hash(raw),
+		AvailableMethods: methods,
+		RememberMe:       rememberMe,
+		IPAddress:        optional(client.IPAddress),
+		UserAgent:        optional(client.UserAgent),
+		ExpiresAt:        uc.now().UTC().Add(uc.cfg.MFAChallengeTTL),
+	}
+
+	if err = uc.challenges.Store(ctx, c); err != nil {
+		return nil, "", fmt.Errorf("UseCase - newChallenge - uc.challenges.Store: %w", err)
+	}
+
+	return c, raw, nil
+}
+
+// verifyMFACode checks a second-factor code for the given method.
+func (uc *UseCase) verifyMFACode(ctx context.Context, c *auth.MFAChallenge, method auth.MFAMethod, code string) (bool, error) {
+	switch method {
+	case auth.MFAMethodTOTP:
+		t, err := uc.totp.GetByUserID(ctx, c.UserID)
+		if err != nil {
+			if errors.Is(err, auth.ErrTOTPNotFound) {
+				return false, nil
+			}
+
+			return false, fmt.Errorf("UseCase - verifyMFACode - uc.totp.GetByUserID: %w", err)
+		}
+
+		if !t.IsVerified() {
+			return false, nil
+		}
+
+		return uc.checkTOTP(ctx, t, code)
+	case auth.MFAMethodSMS:
+		f, err := uc.smsFactors.GetByUserID(ctx, c.UserID)
+		if err != nil {
+			if errors.Is(err, auth.ErrSMSFactorNotFound) {
+				return false, nil
+			}
+
+			return false, fmt.Errorf("UseCase - verifyMFACode - uc.smsFactors.GetByUserID: %w", err)
+		}
+
+		if !f.IsVerified() {
+			return false, nil
+		}
+
+		return uc.checkSMSCode(ctx, f, code)
+	case auth.MFAMethodRecoveryCode:
+		return uc.checkRecoveryCode(ctx, c.UserID, code)
+	case auth.MFAMethodEmail:
+		return uc.checkChallengeCode(c, code)
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,237 @@
+package auth_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mfaFixture struct {
+	uc         *authuc.UseCase
+	user       *auth.User
+	secret     string
+	challenges *memoryMFAChallengeRepo
+	events     *mockSecurityEventRepo
+	lastLogins *atomic.Int32
+}
+
+// newMFAFixture returns a use case whose only user has TOTP enabled.
+func newMFAFixture(t *testing.T) *mfaFixture {
+	t.Helper()
+
+	user := existingUser(auth.StatusActive)
+
+	entry, secret := pendingTOTP(t, user.ID)
+	verifiedAt := time.Now()
+	entry.VerifiedAt = &verifiedAt
+
+	f := &mfaFixture{
+		user:       user,
+		secret:     secret,
+		challenges: newMemoryMFAChallengeRepo(),
+		events:     &mockSecurityEventRepo{},
+		lastLogins: &atomic.Int32{},
+	}
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: &mockUserRepo{
+			getByEmailFunc: func(_ context.Context, _ string) (*auth.User, error) {
+				return user, nil
+			},
+			getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+				return user, nil
+			},
+			updateLastLoginFunc: func(_ context.Context, _ uuid.UUID, _ time.Time, _ string) error {
+				f.lastLogins.Add(1)
+
+				return nil
+			},
+		},
+		TOTP:           newMemoryTOTPRepo(entry),
+		MFAChallenges:  f.challenges,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+func (f *mfaFixture) login(t *testing.T) string {
+	t.Helper()
+
+	result, err := f.uc.Login(context.Background(), auth.LoginInput{
+		Email:    f.user.Email,
+		Password: "SecureP@ss123",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+
+	return result.Challenge.Token
+}
+
+func (f *mfaFixture) eventTypes() []auth.SecurityEventType {
+	var types []auth.SecurityEventType
+	for _, e := range f.events.stored() {
+		types = append(types, e.Type)
+	}
+
+	return types
+}
+
+func TestUseCase_Login_MFARequired(t *testing.T) {
+	t.Parallel()
+
+	f := newMFAFixture(t)
+
+	result, err := f.uc.Login(context.Background(), auth.LoginInput{
+		Email:    f.user.Email,
+		Password: "SecureP@ss123",
+	})
+	require.NoError(t, err)
+
+	assert.Nil(t, result.Tokens)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeMFARequired, result.Challenge.Type)
+	assert.NotEmpty(t, result.Challenge.Token)
+	assert.Equal(t, []string{"totp"}, result.Challenge.AvailableMethods)
+	assert.Zero(t, f.lastLogins.Load(), "last login is recorded only once MFA completes")
+}
+
+func TestUseCase_CompleteMFAChallenge_Success(t *testing.T) {
+	t.Parallel()
+
+	f := newMFAFixture(t)
+	challengeToken := f.login(t)
+
+	code, err := totp.Code(f.secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	result, err := f.uc.CompleteMFAChallenge(context.Background(), auth.MFAChallengeInput{
+		ChallengeToken: challengeToken,
+		Code:           code,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	assert.NotEmpty(t, result.Tokens.AccessToken)
+	assert.Equal(t, int32(1), f.lastLogins.Load())
+	assert.Equal(t, []auth.SecurityEventType{auth.EventLoginSuccess, auth.EventMFAChallengeSuccess}, f.eventTypes())
+
+	// The challenge is single use.
+	_, err = f.uc.CompleteMFAChallenge(context.Background(), auth.MFAChallengeInput{
+		ChallengeToken: challengeToken,
+		Code:           code,
+	})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+}
+
+func TestUseCase_CompleteMFAChallenge_ReplayedCode(t *testing.T) {
+	t.Parallel()
+
+	f := newMFAFixture(t)
+
+	code, err := totp.Code(f.secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	_, err = f.uc.CompleteMFAChallenge(context.Background(), auth.MFAChallengeInput{
+		ChallengeToken: f.login(t),
+		Code:           code,
+	})
+	require.NoError(t, err)
+
+	_, err = f.uc.CompleteMFAChallenge(context.Background(), auth.MFAChallengeInput{
+		ChallengeToken: f.login(t),
+		Code:           code,
+	})
+	requireAppError(t, err, apperror.KindValidation, "MFA_INVALID_CODE")
+}
+
+func TestUseCase_CompleteMFAChallenge_AttemptLimit(t *testing.T) {
+	t.Parallel()
+
+	f := newMFAFixture(t)
+	challengeToken := f.login(t)
+
+	for range 3 {
+		_, err := f.uc.CompleteMFAChallenge(context.Background(), auth.MFAChallengeInput{
+			ChallengeToken: challengeToken,
+			Code:           "000000",
+		})
+		requireAppError(t, err, apperror.KindValidation, "MFA_INVALID_CODE")
+	}
+
+	code, err := totp.Code(f.secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	// The limit is per user, so a fresh login does not reset it.
+	_, err = f.uc.CompleteMFAChallenge(context.Background(), auth.MFAChallengeInput{
+		ChallengeToken: f.login(t),
+		Code:           code,
+	})
+	require.Error(t, err)
+	assert.True(t, apperror.IsRateLimited(err))
+
+	assert.Equal(t, []auth.SecurityEventType{
+		auth.EventMFAChallengeFailed,
+		auth.EventMFAChallengeFailed,
+		auth.EventMFAChallengeFailed,
+	}, f.eventTypes())
+}
+
+func TestUseCase_CompleteMFAChallenge_Rejects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		in       func(t *testing.T, f *mfaFixture) auth.MFAChallengeInput
+		wantKind apperror.Kind
+		wantCode string
+	}{
+		{
+			name: "unknown challenge",
+			in: func(_ *testing.T, _ *mfaFixture) auth.MFAChallengeInput {
+				return auth.MFAChallengeInput{ChallengeToken: "nope", Code: "123456"}
+			},
+			wantKind: apperror.KindUnauthorized,
+			wantCode: "INVALID_TOKEN",
+		},
+		{
+			name: "expired challenge",
+			in: func(t *testing.T, f *mfaFixture) auth.MFAChallengeInput {
+				challengeToken := f.login(t)
+				f.challenges.expire()
+
+				return auth.MFAChallengeInput{ChallengeToken: challengeToken, Code: "123456"}
+			},
+			wantKind: apperror.KindUnauthorized,
+			wantCode: "TOKEN_EXPIRED",
+		},
+		{
+			name: "method not enabled",
+			in: func(t *testing.T, f *mfaFixture) auth.MFAChallengeInput {
+				return auth.MFAChallengeInput{ChallengeToken: f.login(t), Code: "123456", Method: "sms"}
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "MFA_NOT_ENABLED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newMFAFixture(t)
+
+			_, err := f.uc.CompleteMFAChallenge(context.Background(), tt.in(t, f))
+			requireAppError(t, err, tt.wantKind, tt.wantCode)
+			assert.Zero(t, f.lastLogins.Load())
+		})
+	}
+}
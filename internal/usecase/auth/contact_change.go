@@ -0,0 +1,439 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/event"
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/notify"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/evrone/go-clean-template/pkg/useragent"
+	"github.com/google/uuid"
+)
+
+const (
+	confirmEmailChangePath = "/confirm-email-change"
+
+	contactChangeWindow = time.Hour
+)
+
+// RequestEmailChange re-checks the password and mails a confirmation link to
+// the new address. The email only changes once the link is followed, and a
+// new request voids the previous link.
+func (uc *UseCase) RequestEmailChange(ctx context.Context, in auth.EmailChangeInput) error {
+	email, err := normalizeEmail(in.NewEmail)
+	if err != nil {
+		return apperror.Validation("Invalid email address", apperror.WithField("new_email", "must be a valid email address"))
+	}
+
+	if uc.isDisposable(email) {
+		return errDisposableEmail("new_email")
+	}
+
+	user, err := uc.reauthenticate(ctx, in.UserID, in.Password, in.Client)
+	if err != nil {
+		return err
+	}
+
+	if email == user.Email {
+		return apperror.Validation("New email is the same as the current one",
+			apperror.WithField("new_email", "must differ from the current email"))
+	}
+
+	switch _, err = uc.users.GetByEmail(ctx, email); {
+	case err == nil:
+		return errEmailAlreadyExists()
+	case !errors.Is(err, auth.ErrUserNotFound):
+		return fmt.Errorf("UseCase - RequestEmailChange - uc.users.GetByEmail: %w", err)
+	}
+
+	if err = uc.checkContactChangeRate(ctx, uc.emailChanges.CountSince, user.ID); err != nil {
+		return err
+	}
+
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestEmailChange - token.Generate: %w", err)
+	}
+
+	err = uc.emailChanges.Store(ctx, &auth.EmailChange{
+		UserID:    user.ID,
+		NewEmail:  email,
+		TokenHash: token.Hash(raw),
+		ExpiresAt: uc.now().UTC().Add(uc.cfg.EmailChangeTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestEmailChange - uc.emailChanges.Store: %w", err)
+	}
+
+	return uc.sendEmail(ctx, user, email, emailChangeTemplate, emailData{
+		Link:      uc.link(confirmEmailChangePath, raw),
+		ExpiresIn: humanDuration(uc.cfg.EmailChangeTTL),
+	})
+}
+
+// ConfirmEmailChange follows an emailed change link and moves the account to
+// its address. The previous address is then mailed a link that changes it
+// back; following that one also signs the user out everywhere, as someone
+// else may have made the change.
+func (uc *UseCase) ConfirmEmailChange(ctx context.Context, rawToken string, client auth.ClientInfo) (*auth.EmailChange, error) {
+	c, err := uc.emailChanges.GetByTokenHash(ctx, token.Hash(rawToken))
+	if err != nil {
+		if errors.Is(err, auth.ErrEmailChangeNotFound) {
+			return nil, errEmailChangeInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - ConfirmEmailChange - uc.emailChanges.GetByTokenHash: %w", err)
+	}
+
+	if c.ConfirmedAt != nil {
+		return nil, errEmailChangeInvalid()
+	}
+
+	now := uc.now().UTC()
+
+	if c.IsExpired(now) {
+		return nil, apperror.Unauthorized("Email change link has expired", apperror.WithCode(codeTokenExpired))
+	}
+
+	user, err := uc.users.GetByID(ctx, c.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, errEmailChangeInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - ConfirmEmailChange - uc.users.GetByID: %w", err)
+	}
+
+	if user.Status == auth.StatusDisabled || user.Status == auth.StatusDeleted {
+		return nil, errEmailChangeInvalid()
+	}
+
+	previous := user.Email
+
+	changed, err := outboxEvent(auth.NewEmailChanged(user.ID, previous, c.NewEmail, c.IsRevert()))
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - ConfirmEmailChange - outboxEvent: %w", err)
+	}
+
+	var (
+		revert    *auth.EmailChange
+		revertRaw string
+	)
+
+	if !c.IsRevert() {
+		revertRaw, err = token.Generate(token.DefaultLength)
+		if err != nil {
+			return nil, fmt.Errorf("UseCase - ConfirmEmailChange - token.Generate: %w", err)
+		}
+
+		revert = &auth.EmailChange{
+			UserID:    user.ID,
+			NewEmail:  previous,
+			TokenHash: token.Hash(revertRaw),
+			RevertsID: &c.ID,
+			ExpiresAt: now.Add(uc.cfg.EmailChangeRevertTTL),
+		}
+	}
+
+	if err = uc.emailChanges.Confirm(ctx, c, revert, now, []event.OutboxEvent{changed}); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrEmailAlreadyExists):
+			return nil, errEmailAlreadyExists()
+		case errors.Is(err, auth.ErrEmailChangeUsed), errors.Is(err, auth.ErrUserNotFound):
+			return nil, errEmailChangeInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - ConfirmEmailChange - uc.emailChanges.Confirm: %w", err)
+	}
+
+	risk := auth.RiskMedium
+	if c.IsRevert() {
+		risk = auth.RiskHigh
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventEmailChanged,
+		Success:   true,
+		RiskLevel: risk,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"previous_email": previous, "reverted": c.IsRevert()},
+	})
+
+	if c.IsRevert() {
+		if err = uc.refreshTokens.RevokeAllForUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("UseCase - ConfirmEmailChange - uc.refreshTokens.RevokeAllForUser: %w", err)
+		}
+
+		return c, nil
+	}
+
+	data := uc.signInDetails(useragent.Parse(client.UserAgent), uc.events.locate(client.IPAddress), client.IPAddress)
+	data.Email = c.NewEmail
+	data.Link = uc.link(confirmEmailChangePath, revertRaw)
+	data.ExpiresIn = humanDuration(uc.cfg.EmailChangeRevertTTL)
+
+	// The address has already changed; delivery errors are recorded in the
+	// delivery log.
+	_ = uc.sendEmail(ctx, user, previous, emailChangedTemplate, data)
+
+	return c, nil
+}
+
+// RequestPhoneChange re-checks the password and texts a code to the new
+// number. The number only changes once the code is entered, and a new
+// request voids the previous code.
+func (uc *UseCase) RequestPhoneChange(ctx context.Context, in auth.PhoneChangeInput) error {
+	if err := notify.ValidateE164(in.NewPhoneNumber); err != nil {
+		return apperror.Validation("Invalid phone number",
+			apperror.WithField("new_phone_number", "must be in E.164 format, e.g. +14155551234"))
+	}
+
+	user, err := uc.reauthenticate(ctx, in.UserID, in.Password, in.Client)
+	if err != nil {
+		return err
+	}
+
+	if user.PhoneNumber != nil && *user.PhoneNumber == in.NewPhoneNumber {
+		return apperror.Validation("New phone number is the same as the current one",
+			apperror.WithField("new_phone_number", "must differ from the current phone number"))
+	}
+
+	switch _, err = uc.users.GetByPhone(ctx, in.NewPhoneNumber); {
+	case err == nil:
+		return errPhoneAlreadyExists()
+	case !errors.Is(err, auth.ErrUserNotFound):
+		return fmt.Errorf("UseCase - RequestPhoneChange - uc.users.GetByPhone: %w", err)
+	}
+
+	if err = uc.checkContactChangeRate(ctx, uc.phoneChanges.CountSince, user.ID); err != nil {
+		return err
+	}
+
+	code, err := token.NumericCode(smsCodeDigits)
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestPhoneChange - token.NumericCode: %w", err)
+	}
+
+	c := &auth.PhoneChange{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		NewPhoneNumber: in.NewPhoneNumber,
+		ExpiresAt:      uc.now().UTC().Add(uc.cfg.PhoneChangeCodeTTL),
+	}
+	c.OTPHash = phoneChangeCodeHash(c.ID, code)
+
+	if err = uc.phoneChanges.Store(ctx, c); err != nil {
+		return fmt.Errorf("UseCase - RequestPhoneChange - uc.phoneChanges.Store: %w", err)
+	}
+
+	err = uc.sms.SendSMS(ctx, &notification.SMSMessage{
+		UserID:        user.ID,
+		To:            c.NewPhoneNumber,
+		Body:          fmt.Sprintf("Your verification code is %s. It expires in %s.", code, humanDuration(uc.cfg.PhoneChangeCodeTTL)),
+		Transactional: true,
+	})
+	if err != nil {
+		return fmt.Errorf("UseCase - RequestPhoneChange - uc.sms.SendSMS: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmPhoneChange checks the code texted to the new number and makes it
+// the account's verified phone number. The code stops working after too many
+// wrong guesses. The old number and the account email are told about the change.
+func (uc *UseCase) ConfirmPhoneChange(ctx context.Context, in auth.PhoneChangeConfirmInput) (*auth.User, error) {
+	c, err := uc.phoneChanges.GetPending(ctx, in.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrPhoneChangeNotFound) {
+			return nil, apperror.Validation("Request a phone number change first", apperror.WithCode(codeMFACodeExpired))
+		}
+
+		return nil, fmt.Errorf("UseCase - ConfirmPhoneChange - uc.phoneChanges.GetPending: %w", err)
+	}
+
+	now := uc.now().UTC()
+
+	if c.IsExpired(now) || c.Attempts >= uc.cfg.PhoneChangeMaxAttempts {
+		return nil, apperror.Validation("Verification code has expired; request a new one",
+			apperror.WithCode(codeMFACodeExpired))
+	}
+
+	if subtle.ConstantTimeCompare([]byte(phoneChangeCodeHash(c.ID, in.Code)), []byte(c.OTPHash)) != 1 {
+		if _, err = uc.phoneChanges.RecordFailure(ctx, c.ID); err != nil && !errors.Is(err, auth.ErrPhoneChangeNotFound) {
+			return nil, fmt.Errorf("UseCase - ConfirmPhoneChange - uc.phoneChanges.RecordFailure: %w", err)
+		}
+
+		return nil, errMFAInvalidCode()
+	}
+
+	user, err := uc.users.GetByID(ctx, in.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, apperror.NotFound("User not found", apperror.WithCause(err))
+		}
+
+		return nil, fmt.Errorf("UseCase - ConfirmPhoneChange - uc.users.GetByID: %w", err)
+	}
+
+	previous := deref(user.PhoneNumber)
+
+	changed, err := outboxEvent(auth.NewPhoneChanged(user.ID, previous, c.NewPhoneNumber))
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - ConfirmPhoneChange - outboxEvent: %w", err)
+	}
+
+	if err = uc.phoneChanges.Confirm(ctx, c, now, []event.OutboxEvent{changed}); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrPhoneAlreadyExists):
+			return nil, errPhoneAlreadyExists()
+		case errors.Is(err, auth.ErrPhoneChangeUsed):
+			return nil, errMFAInvalidCode()
+		}
+
+		return nil, fmt.Errorf("UseCase - ConfirmPhoneChange - uc.phoneChanges.Confirm: %w", err)
+	}
+
+	user.PhoneNumber = &c.NewPhoneNumber
+	user.PhoneVerified = true
+	user.PhoneVerifiedAt = &now
+
+	uc.phoneChanged(ctx, user, previous, in.Client)
+
+	return user, nil
+}
+
+// RemovePhone re-checks the password and removes the phone number from the
+// account. A number that SMS two-factor authentication sends codes to has to
+// be disabled there first.
+func (uc *UseCase) RemovePhone(ctx context.Context, userID uuid.UUID, pw string, client auth.ClientInfo) (*auth.User, error) {
+	user, err := uc.reauthenticate(ctx, userID, pw, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PhoneNumber == nil {
+		return nil, apperror.Validation("Account has no phone number")
+	}
+
+	previous := *user.PhoneNumber
+
+	f, err := uc.smsFactors.GetByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, auth.ErrSMSFactorNotFound) {
+		return nil, fmt.Errorf("UseCase - RemovePhone - uc.smsFactors.GetByUserID: %w", err)
+	}
+
+	if f != nil && f.IsVerified() && f.PhoneNumber == previous {
+		return nil, apperror.Validation("Disable SMS two-factor authentication before removing this phone number",
+			apperror.WithCode(codePhoneRequiredByMFA))
+	}
+
+	changed, err := outboxEvent(auth.NewPhoneChanged(user.ID, previous, ""))
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - RemovePhone - outboxEvent: %w", err)
+	}
+
+	now := uc.now().UTC()
+
+	if err = uc.phoneChanges.RemovePhone(ctx, user.ID, previous, now, []event.OutboxEvent{changed}); err != nil {
+		return nil, fmt.Errorf("UseCase - RemovePhone - uc.phoneChanges.RemovePhone: %w", err)
+	}
+
+	user.PhoneNumber = nil
+	user.PhoneVerified = false
+	user.PhoneVerifiedAt = nil
+
+	uc.phoneChanged(ctx, user, previous, client)
+
+	return user, nil
+}
+
+// phoneChanged audits a changed or removed phone number and tells the
+// previous number and the account email about it.
+func (uc *UseCase) phoneChanged(ctx context.Context, user *auth.User, previous string, client auth.ClientInfo) {
+	action := "removed"
+	if user.PhoneNumber != nil {
+		action = "changed"
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventPhoneChanged,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"action": action, "had_phone": previous != ""},
+	})
+
+	// The number has already changed; delivery errors are recorded in the
+	// delivery log.
+	if previous != "" {
+		_ = uc.sms.SendSMS(ctx, &notification.SMSMessage{
+			UserID:        user.ID,
+			To:            previous,
+			Body:          "This number was " + action + " on your account. If this was not you, reset your password right away.",
+			Transactional: true,
+		})
+	}
+
+	data := uc.signInDetails(useragent.Parse(client.UserAgent), uc.events.locate(client.IPAddress), client.IPAddress)
+	data.Phone = deref(user.PhoneNumber)
+	data.Link = uc.cfg.AppURL + securitySettingsPath
+
+	_ = uc.sendEmail(ctx, user, user.Email, phoneChangedTemplate, data)
+}
+
+// checkContactChangeRate allows one change request per cooldown and a fixed
+// number per hour, counted by count.
+func (uc *UseCase) checkContactChangeRate(
+	ctx context.Context, count func(context.Context, uuid.UUID, time.Time) (int, error), userID uuid.UUID,
+) error {
+	now := uc.now().UTC()
+
+	recent, err := count(ctx, userID, now.Add(-uc.cfg.ContactChangeResendCooldown))
+	if err != nil {
+		return fmt.Errorf("UseCase - checkContactChangeRate - count: %w", err)
+	}
+
+	if recent > 0 {
+		return apperror.RateLimited("Please wait before requesting another change",
+			apperror.WithRetryAfter(uc.cfg.ContactChangeResendCooldown))
+	}
+
+	hourly, err := count(ctx, userID, now.Add(-contactChangeWindow))
+	if err != nil {
+		return fmt.Errorf("UseCase - checkContactChangeRate - count: %w", err)
+	}
+
+	if hourly >= uc.cfg.ContactChangeMaxPerHour {
+		return apperror.RateLimited("Too many change requests", apperror.WithRetryAfter(contactChangeWindow))
+	}
+
+	return nil
+}
+
+// outboxEvent serializes e for the transactional outbox.
+func outboxEvent(e event.Event) (event.OutboxEvent, error) {
+	payload, err := json.Marshal(e.Payload())
+	if err != nil {
+		return event.OutboxEvent{}, fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return event.NewOutboxEvent(e, payload), nil
+}
+
+// phoneChangeCodeHash binds the hash to the request like magicLinkCodeHash.
+func phoneChangeCodeHash(id uuid.UUID, code string) string {
+	return token.Hash(id.String() + ":" + code)
+}
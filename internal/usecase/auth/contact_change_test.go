@@ -0,0 +1,231 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type contactChangeFixture struct {
+	uc      *authuc.UseCase
+	user    *auth.User
+	users   *memoryUserRepo
+	emails  *memoryEmailChangeRepo
+	phones  *memoryPhoneChangeRepo
+	factors *memorySMSFactorRepo
+	revoked []uuid.UUID
+	mail    *mockEmailNotifier
+	sms     *mockSMSNotifier
+	events  *mockSecurityEventRepo
+}
+
+// newContactChangeFixture returns a user with a verified phone number next
+// to another account that owns taken@example.com and +14155550000.
+func newContactChangeFixture(t *testing.T) *contactChangeFixture {
+	t.Helper()
+
+	phone := testPhone
+	otherPhone := "+14155550000"
+
+	f := &contactChangeFixture{
+		user:    existingUser(auth.StatusActive),
+		factors: newMemorySMSFactorRepo(),
+		mail:    &mockEmailNotifier{},
+		sms:     &mockSMSNotifier{},
+		events:  &mockSecurityEventRepo{},
+	}
+	f.user.PhoneNumber = &phone
+	f.user.PhoneVerified = true
+
+	other := existingUser(auth.StatusActive)
+	other.Email = "taken@example.com"
+	other.PhoneNumber = &otherPhone
+
+	f.users = newMemoryUserRepo(f.user, other)
+	f.emails = newMemoryEmailChangeRepo(f.users)
+	f.phones = newMemoryPhoneChangeRepo(f.users)
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: f.users,
+		RefreshTokens: &mockRefreshTokenRepo{
+			revokeAllForUserFunc: func(_ context.Context, userID uuid.UUID) error {
+				f.revoked = append(f.revoked, userID)
+
+				return nil
+			},
+		},
+		SMSFactors:     f.factors,
+		EmailChanges:   f.emails,
+		PhoneChanges:   f.phones,
+		Notifier:       f.mail,
+		SMS:            f.sms,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+func TestUseCase_EmailChange(t *testing.T) {
+	t.Parallel()
+
+	f := newContactChangeFixture(t)
+	ctx := context.Background()
+
+	err := f.uc.RequestEmailChange(ctx, auth.EmailChangeInput{UserID: f.user.ID, NewEmail: "new@example.com", Password: "wrong"})
+	requireAppError(t, err, apperror.KindValidation, "PASSWORD_INCORRECT")
+
+	err = f.uc.RequestEmailChange(ctx, auth.EmailChangeInput{UserID: f.user.ID, NewEmail: "Taken@example.com", Password: "SecureP@ss123"})
+	requireAppError(t, err, apperror.KindConflict, "EMAIL_ALREADY_EXISTS")
+
+	err = f.uc.RequestEmailChange(ctx, auth.EmailChangeInput{UserID: f.user.ID, NewEmail: "new@mailinator.com", Password: "SecureP@ss123"})
+	requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+
+	err = f.uc.RequestEmailChange(ctx, auth.EmailChangeInput{UserID: f.user.ID, NewEmail: "New@example.com", Password: "SecureP@ss123"})
+	require.NoError(t, err)
+
+	err = f.uc.RequestEmailChange(ctx, auth.EmailChangeInput{UserID: f.user.ID, NewEmail: "other@example.com", Password: "SecureP@ss123"})
+	requireAppError(t, err, apperror.KindRateLimited, "RATE_LIMITED")
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, []string{"new@example.com"}, mail[0].To)
+	assert.Equal(t, "Confirm your new email address", mail[0].Subject)
+	assert.Equal(t, "user@example.com", f.user.Email, "nothing changes before the link is followed")
+
+	change, err := f.uc.ConfirmEmailChange(ctx, linkToken(t, mail[0].Body), recoveryClient)
+	require.NoError(t, err)
+	assert.False(t, change.IsRevert())
+	assert.Equal(t, "new@example.com", f.user.Email)
+
+	changed := eventsOfType(f.events, auth.EventEmailChanged)
+	require.Len(t, changed, 1)
+	assert.Equal(t, "user@example.com", changed[0].Details["previous_email"])
+
+	require.Len(t, f.emails.outbox, 1)
+	assert.Equal(t, auth.OutboxEmailChanged, f.emails.outbox[0].EventType)
+	assert.Equal(t, f.user.ID.String(), f.emails.outbox[0].AggregateID)
+
+	var payload auth.EmailChanged
+	require.NoError(t, json.Unmarshal(f.emails.outbox[0].Payload, &payload))
+	assert.Equal(t, "user@example.com", payload.OldEmail)
+	assert.Equal(t, "new@example.com", payload.NewEmail)
+
+	// The old address hears about it and gets a link to undo it.
+	mail = f.mail.sent()
+	require.Len(t, mail, 2)
+	assert.Equal(t, []string{"user@example.com"}, mail[1].To)
+	assert.Equal(t, "Your email address was changed", mail[1].Subject)
+	assert.Contains(t, mail[1].Body, "new@example.com")
+	assert.Empty(t, f.revoked)
+
+	revert, err := f.uc.ConfirmEmailChange(ctx, linkToken(t, mail[1].Body), recoveryClient)
+	require.NoError(t, err)
+	assert.True(t, revert.IsRevert())
+	assert.Equal(t, "user@example.com", f.user.Email)
+	assert.Equal(t, []uuid.UUID{f.user.ID}, f.revoked, "undoing a change signs the user out everywhere")
+	require.Len(t, f.emails.outbox, 2)
+	assert.Len(t, f.mail.sent(), 2)
+
+	_, err = f.uc.ConfirmEmailChange(ctx, linkToken(t, mail[1].Body), recoveryClient)
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+}
+
+func TestUseCase_EmailChange_AddressTakenBeforeConfirm(t *testing.T) {
+	t.Parallel()
+
+	f := newContactChangeFixture(t)
+	ctx := context.Background()
+
+	err := f.uc.RequestEmailChange(ctx, auth.EmailChangeInput{UserID: f.user.ID, NewEmail: "new@example.com", Password: "SecureP@ss123"})
+	require.NoError(t, err)
+
+	require.NoError(t, f.users.Create(ctx, &auth.User{Email: "new@example.com", Status: auth.StatusActive}))
+
+	_, err = f.uc.ConfirmEmailChange(ctx, linkToken(t, f.mail.sent()[0].Body), recoveryClient)
+	requireAppError(t, err, apperror.KindConflict, "EMAIL_ALREADY_EXISTS")
+	assert.Equal(t, "user@example.com", f.user.Email)
+	assert.Empty(t, f.emails.outbox)
+}
+
+func TestUseCase_PhoneChange(t *testing.T) {
+	t.Parallel()
+
+	f := newContactChangeFixture(t)
+	ctx := context.Background()
+
+	err := f.uc.RequestPhoneChange(ctx, auth.PhoneChangeInput{UserID: f.user.ID, NewPhoneNumber: "+14155550000", Password: "SecureP@ss123"})
+	requireAppError(t, err, apperror.KindConflict, "PHONE_ALREADY_EXISTS")
+
+	err = f.uc.RequestPhoneChange(ctx, auth.PhoneChangeInput{UserID: f.user.ID, NewPhoneNumber: "+14155559999", Password: "SecureP@ss123"})
+	require.NoError(t, err)
+
+	texts := f.sms.sent()
+	require.Len(t, texts, 1)
+	assert.Equal(t, "+14155559999", texts[0].To)
+
+	_, err = f.uc.ConfirmPhoneChange(ctx, auth.PhoneChangeConfirmInput{UserID: f.user.ID, Code: "000000"})
+	requireAppError(t, err, apperror.KindValidation, "MFA_INVALID_CODE")
+
+	code := f.sms.lastCode(t)
+
+	user, err := f.uc.ConfirmPhoneChange(ctx, auth.PhoneChangeConfirmInput{UserID: f.user.ID, Code: code})
+	require.NoError(t, err)
+	assert.Equal(t, "+14155559999", *user.PhoneNumber)
+	assert.True(t, user.PhoneVerified)
+
+	require.Len(t, f.phones.outbox, 1)
+	assert.Equal(t, auth.OutboxPhoneChanged, f.phones.outbox[0].EventType)
+	assert.Len(t, eventsOfType(f.events, auth.EventPhoneChanged), 1)
+
+	// The old number and the account email are both told.
+	texts = f.sms.sent()
+	require.Len(t, texts, 2)
+	assert.Equal(t, testPhone, texts[1].To)
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, "Your phone number was changed", mail[0].Subject)
+
+	_, err = f.uc.ConfirmPhoneChange(ctx, auth.PhoneChangeConfirmInput{UserID: f.user.ID, Code: code})
+	requireAppError(t, err, apperror.KindValidation, "MFA_CODE_EXPIRED")
+}
+
+func TestUseCase_RemovePhone(t *testing.T) {
+	t.Parallel()
+
+	f := newContactChangeFixture(t)
+	ctx := context.Background()
+
+	verifiedAt := time.Now()
+	require.NoError(t, f.factors.Upsert(ctx, &auth.SMSFactor{UserID: f.user.ID, PhoneNumber: testPhone}))
+	f.factors.entries[f.user.ID].VerifiedAt = &verifiedAt
+
+	_, err := f.uc.RemovePhone(ctx, f.user.ID, "SecureP@ss123", recoveryClient)
+	requireAppError(t, err, apperror.KindValidation, "PHONE_REQUIRED_FOR_MFA")
+
+	require.NoError(t, f.factors.Delete(ctx, f.user.ID))
+
+	user, err := f.uc.RemovePhone(ctx, f.user.ID, "SecureP@ss123", recoveryClient)
+	require.NoError(t, err)
+	assert.Nil(t, user.PhoneNumber)
+	assert.False(t, user.PhoneVerified)
+
+	require.Len(t, f.phones.outbox, 1)
+
+	var payload auth.PhoneChanged
+	require.NoError(t, json.Unmarshal(f.phones.outbox[0].Payload, &payload))
+	assert.Equal(t, testPhone, payload.OldPhoneNumber)
+	assert.Empty(t, payload.NewPhoneNumber)
+
+	removed := eventsOfType(f.events, auth.EventPhoneChanged)
+	require.Len(t, removed, 1)
+	assert.Equal(t, "removed", removed[0].Details["action"])
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/storage"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+)
+
+const (
+	downloadDataExportPath = "/download-data-export"
+	dataExportContentType  = "application/zip"
+)
+
+// RequestDataExport queues a copy of the user's personal data. A background
+// job builds it and emails the user a link to download it; only one export
+// can be in progress at a time.
+func (uc *UseCase) RequestDataExport(ctx context.Context, in auth.DataExportInput) (*auth.DataExport, error) {
+	e := &auth.DataExport{UserID: in.UserID}
+
+	if err := uc.exports.Create(ctx, e); err != nil {
+		if errors.Is(err, auth.ErrDataExportInProgress) {
+			return nil, apperror.Conflict("A data export is already in progress", apperror.WithCode(codeExportInProgress))
+		}
+
+		return nil, fmt.Errorf("UseCase - RequestDataExport - uc.exports.Create: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &in.UserID,
+		Type:      auth.EventDataExportRequested,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+		Details:   map[string]any{"export_id": e.ID},
+	})
+
+	return e, nil
+}
+
+// DataExports lists the user's data exports, newest first.
+func (uc *UseCase) DataExports(ctx context.Context, userID uuid.UUID) ([]auth.DataExport, error) {
+	exports, err := uc.exports.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - DataExports - uc.exports.ListByUser: %w", err)
+	}
+
+	return exports, nil
+}
+
+// DownloadDataExport opens the archive of a finished export from its emailed
+// link. The caller must close the archive's Body.
+func (uc *UseCase) DownloadDataExport(ctx context.Context, in auth.DataExportDownloadInput) (*auth.DataExportArchive, error) {
+	e, err := uc.exports.GetByTokenHash(ctx, token.Hash(in.Token))
+	if err != nil {
+		if errors.Is(err, auth.ErrDataExportNotFound) {
+			return nil, errDataExportInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - DownloadDataExport - uc.exports.GetByTokenHash: %w", err)
+	}
+
+	if e.Status != auth.DataExportReady || e.ObjectKey == nil || e.IsExpired(uc.now()) {
+		return nil, errDataExportExpired()
+	}
+
+	body, err := uc.storage.Get(ctx, *e.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, errDataExportExpired()
+		}
+
+		return nil, fmt.Errorf("UseCase - DownloadDataExport - uc.storage.Get: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &e.UserID,
+		Type:      auth.EventDataExportDownloaded,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+		Details:   map[string]any{"export_id": e.ID},
+	})
+
+	return &auth.DataExportArchive{
+		FileName: "data-export-" + e.CreatedAt.UTC().Format(time.DateOnly) + ".zip",
+		Size:     e.Size,
+		Body:     body,
+	}, nil
+}
+
+// ProcessDataExports builds up to limit queued exports and reports how many
+// it finished. An export that cannot be built is marked failed, so the user
+// can ask again.
+func (uc *UseCase) ProcessDataExports(ctx context.Context, limit uint64) (int, error) {
+	exports, err := uc.exports.Claim(ctx, uc.now().UTC().Add(-uc.cfg.DataExportTimeout), limit)
+	if err != nil {
+		return 0, fmt.Errorf("UseCase - ProcessDataExports - uc.exports.Claim: %w", err)
+	}
+
+	var (
+		finished int
+		errs     []error
+	)
+
+	for i := range exports {
+		e := &exports[i]
+
+		if buildErr := uc.buildDataExport(ctx, e); buildErr != nil {
+			errs = append(errs, buildErr)
+
+			if err = uc.exports.Fail(ctx, e.ID, buildErr.Error()); err != nil {
+				errs = append(errs, fmt.Errorf("UseCase - ProcessDataExports - uc.exports.Fail: %w", err))
+			}
+
+			continue
+		}
+
+		finished++
+	}
+
+	return finished, errors.Join(errs...)
+}
+
+// ExpireDataExports deletes the archives of up to limit exports whose link
+// has expired and reports how many it deleted.
+func (uc *UseCase) ExpireDataExports(ctx context.Context, limit uint64) (int, error) {
+	exports, err := uc.exports.ListExpired(ctx, uc.now().UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("UseCase - ExpireDataExports - uc.exports.ListExpired: %w", err)
+	}
+
+	for i := range exports {
+		e := &exports[i]
+
+		if e.ObjectKey != nil {
+			if err = uc.storage.Delete(ctx, *e.ObjectKey); err != nil {
+				return i, fmt.Errorf("UseCase - ExpireDataExports - uc.storage.Delete: %w", err)
+			}
+		}
+
+		if err = uc.exports.Expire(ctx, e.ID); err != nil {
+			return i, fmt.Errorf("UseCase - ExpireDataExports - uc.exports.Expire: %w", err)
+		}
+	}
+
+	return len(exports), nil
+}
+
+// buildDataExport stores the archive of a claimed export and mails the user
+// a link to download it.
+func (uc *UseCase) buildDataExport(ctx context.Context, e *auth.DataExport) error {
+	user, err := uc.users.GetByID(ctx, e.UserID)
+	if err != nil {
+		return fmt.Errorf("UseCase - buildDataExport - uc.users.GetByID: %w", err)
+	}
+
+	tables, err := uc.exports.Collect(ctx, e.UserID)
+	if err != nil {
+		return fmt.Errorf("UseCase - buildDataExport - uc.exports.Collect: %w", err)
+	}
+
+	now := uc.now().UTC()
+
+	archive, err := dataExportArchive(tables, now)
+	if err != nil {
+		return fmt.Errorf("UseCase - buildDataExport - dataExportArchive: %w", err)
+	}
+
+	// Every attempt gets its own key, so a job that lost the export to
+	// another one never deletes the other's archive.
+	key := fmt.Sprintf("data-exports/%s/%s.zip", e.UserID, uuid.New())
+
+	if err = uc.storage.Put(ctx, key, archive, dataExportContentType); err != nil {
+		return fmt.Errorf("UseCase - buildDataExport - uc.storage.Put: %w", err)
+	}
+
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return fmt.Errorf("UseCase - buildDataExport - token.Generate: %w", err)
+	}
+
+	hash := token.Hash(raw)
+	expiresAt := now.Add(uc.cfg.DataExportTTL)
+
+	e.ObjectKey = &key
+	e.Size = int64(len(archive))
+	e.TokenHash = &hash
+	e.ExpiresAt = &expiresAt
+	e.CompletedAt = &now
+
+	if err = uc.exports.Complete(ctx, e); err != nil {
+		// Nothing points at the archive now, so nothing would delete it later.
+		_ = uc.storage.Delete(ctx, key)
+
+		return fmt.Errorf("UseCase - buildDataExport - uc.exports.Complete: %w", err)
+	}
+
+	// The export is ready; delivery errors are recorded in the delivery log
+	// and the user can ask for another one.
+	_ = uc.sendEmail(ctx, user, user.Email, dataExportReadyTemplate, emailData{
+		Link:      uc.link(downloadDataExportPath, raw),
+		ExpiresIn: humanDuration(uc.cfg.DataExportTTL),
+	})
+
+	return nil
+}
+
+// removeDataExports deletes the archives of all the user's exports.
+func (uc *UseCase) removeDataExports(ctx context.Context, userID uuid.UUID) error {
+	exports, err := uc.exports.ListByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - removeDataExports - uc.exports.ListByUser: %w", err)
+	}
+
+	for i := range exports {
+		if key := exports[i].ObjectKey; key != nil {
+			if err = uc.storage.Delete(ctx, *key); err != nil {
+				return fmt.Errorf("UseCase - removeDataExports - uc.storage.Delete: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// dataExportArchive zips the tables, one indented JSON file each.
+func dataExportArchive(tables []auth.DataExportTable, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, t := range tables {
+		var rows bytes.Buffer
+
+		if err := json.Indent(&rows, t.Rows, "", "  "); err != nil {
+			return nil, fmt.Errorf("json.Indent %s: %w", t.Name, err)
+		}
+
+		rows.WriteByte('\n')
+
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: t.Name + ".json", Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, fmt.Errorf("zw.CreateHeader %s: %w", t.Name, err)
+		}
+
+		if _, err = w.Write(rows.Bytes()); err != nil {
+			return nil, fmt.Errorf("w.Write %s: %w", t.Name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("zw.Close: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,203 @@
+package auth_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dataExportFixture struct {
+	uc      *authuc.UseCase
+	user    *auth.User
+	exports *memoryDataExportRepo
+	storage *memoryStorage
+	mail    *mockEmailNotifier
+	events  *mockSecurityEventRepo
+}
+
+func newDataExportFixture(t *testing.T, users ...*auth.User) *dataExportFixture {
+	t.Helper()
+
+	f := &dataExportFixture{
+		user: existingUser(auth.StatusActive),
+		exports: newMemoryDataExportRepo(
+			auth.DataExportTable{Name: "users", Rows: json.RawMessage(`[{"email":"user@example.com"}]`)},
+			auth.DataExportTable{Name: "push_tokens", Rows: json.RawMessage(`[]`)},
+		),
+		storage: newMemoryStorage(),
+		mail:    &mockEmailNotifier{},
+		events:  &mockSecurityEventRepo{},
+	}
+
+	userRepo := newMemoryUserRepo(append(users, f.user)...)
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:          userRepo,
+		Deletions:      newMemoryAccountDeletionRepo(userRepo),
+		Exports:        f.exports,
+		Storage:        f.storage,
+		Notifier:       f.mail,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+func TestUseCase_DataExport(t *testing.T) {
+	t.Parallel()
+
+	f := newDataExportFixture(t)
+	ctx := context.Background()
+
+	e, err := f.uc.RequestDataExport(ctx, auth.DataExportInput{UserID: f.user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, auth.DataExportPending, e.Status)
+	assert.Len(t, eventsOfType(f.events, auth.EventDataExportRequested), 1)
+
+	_, err = f.uc.RequestDataExport(ctx, auth.DataExportInput{UserID: f.user.ID})
+	requireAppError(t, err, apperror.KindConflict, "DATA_EXPORT_IN_PROGRESS")
+
+	finished, err := f.uc.ProcessDataExports(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, finished)
+	assert.Len(t, f.storage.objects, 1)
+
+	exports, err := f.uc.DataExports(ctx, f.user.ID)
+	require.NoError(t, err)
+	require.Len(t, exports, 1)
+	assert.Equal(t, auth.DataExportReady, exports[0].Status)
+	require.NotNil(t, exports[0].ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(72*time.Hour), *exports[0].ExpiresAt, time.Minute)
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, "Your data export is ready", mail[0].Subject)
+	assert.Contains(t, mail[0].Body, "3 days")
+
+	archive, err := f.uc.DownloadDataExport(ctx, auth.DataExportDownloadInput{Token: linkToken(t, mail[0].Body)})
+	require.NoError(t, err)
+	assert.Equal(t, "data-export-"+time.Now().UTC().Format(time.DateOnly)+".zip", archive.FileName)
+	assert.Len(t, eventsOfType(f.events, auth.EventDataExportDownloaded), 1)
+
+	data, err := io.ReadAll(archive.Body)
+	require.NoError(t, err)
+	require.NoError(t, archive.Body.Close())
+	assert.Equal(t, archive.Size, int64(len(data)))
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 2)
+	assert.Equal(t, "users.json", zr.File[0].Name)
+	assert.Equal(t, "push_tokens.json", zr.File[1].Name)
+
+	rc, err := zr.File[0].Open()
+	require.NoError(t, err)
+
+	users, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.JSONEq(t, `[{"email":"user@example.com"}]`, string(users))
+
+	_, err = f.uc.RequestDataExport(ctx, auth.DataExportInput{UserID: f.user.ID})
+	require.NoError(t, err, "a finished export does not block the next one")
+
+	_, err = f.uc.DownloadDataExport(ctx, auth.DataExportDownloadInput{Token: "unknown"})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+}
+
+func TestUseCase_DataExport_Expiry(t *testing.T) {
+	t.Parallel()
+
+	f := newDataExportFixture(t)
+	ctx := context.Background()
+
+	_, err := f.uc.RequestDataExport(ctx, auth.DataExportInput{UserID: f.user.ID})
+	require.NoError(t, err)
+
+	_, err = f.uc.ProcessDataExports(ctx, 10)
+	require.NoError(t, err)
+
+	raw := linkToken(t, f.mail.sent()[0].Body)
+
+	for _, e := range f.exports.exports {
+		past := time.Now().Add(-time.Minute)
+		e.ExpiresAt = &past
+	}
+
+	_, err = f.uc.DownloadDataExport(ctx, auth.DataExportDownloadInput{Token: raw})
+	requireAppError(t, err, apperror.KindUnauthorized, "TOKEN_EXPIRED")
+
+	expired, err := f.uc.ExpireDataExports(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Empty(t, f.storage.objects, "the archive is deleted")
+
+	_, err = f.uc.DownloadDataExport(ctx, auth.DataExportDownloadInput{Token: raw})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	expired, err = f.uc.ExpireDataExports(ctx, 10)
+	require.NoError(t, err)
+	assert.Zero(t, expired)
+}
+
+func TestUseCase_DataExport_Failure(t *testing.T) {
+	t.Parallel()
+
+	f := newDataExportFixture(t)
+	f.exports.collectErr = errRepo
+	ctx := context.Background()
+
+	_, err := f.uc.RequestDataExport(ctx, auth.DataExportInput{UserID: f.user.ID})
+	require.NoError(t, err)
+
+	finished, err := f.uc.ProcessDataExports(ctx, 10)
+	require.ErrorIs(t, err, errRepo)
+	assert.Zero(t, finished)
+	assert.Empty(t, f.storage.objects)
+	assert.Empty(t, f.mail.sent())
+
+	exports, err := f.uc.DataExports(ctx, f.user.ID)
+	require.NoError(t, err)
+	require.Len(t, exports, 1)
+	assert.Equal(t, auth.DataExportFailed, exports[0].Status)
+
+	_, err = f.uc.RequestDataExport(ctx, auth.DataExportInput{UserID: f.user.ID})
+	require.NoError(t, err, "a failed export can be requested again")
+}
+
+func TestUseCase_PurgeDeletedAccounts_RemovesDataExports(t *testing.T) {
+	t.Parallel()
+
+	longAgo := time.Now().Add(-31 * 24 * time.Hour)
+
+	deleted := existingUser(auth.StatusActive)
+	deleted.Email = "deleted@example.com"
+
+	f := newDataExportFixture(t, deleted)
+	ctx := context.Background()
+
+	_, err := f.uc.RequestDataExport(ctx, auth.DataExportInput{UserID: deleted.ID})
+	require.NoError(t, err)
+
+	_, err = f.uc.ProcessDataExports(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, f.storage.objects, 1)
+
+	deleted.Status = auth.StatusDeleted
+	deleted.DeletedAt = &longAgo
+
+	purged, err := f.uc.PurgeDeletedAccounts(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Empty(t, f.storage.objects)
+}
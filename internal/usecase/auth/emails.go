@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+//nolint:gochecknoglobals // templates are parsed once at startup
+var (
+	verifyEmailTemplate   = mustEmailTemplate("verify_email", "Verify your email address")
+	resetPasswordTemplate = mustEmailTemplate("reset_password", "Reset your password")
+	magicLinkTemplate     = mustEmailTemplate("magic_link", "Your sign-in link")
+	accountLockedTemplate = mustEmailTemplate("account_locked", "Your account has been temporarily locked")
+
+	recoveryCodeUsedTemplate = mustEmailTemplate("recovery_code_used", "A recovery code was used to sign in")
+	newSignInTemplate        = mustEmailTemplate("new_sign_in", "New sign-in to your account")
+	loginCodeTemplate        = mustEmailTemplate("login_code", "Confirm your sign-in")
+
+	accountRecoveryCodeTemplate    = mustEmailTemplate("account_recovery_code", "Your account recovery code")
+	accountRecoveryStartedTemplate = mustEmailTemplate("account_recovery_started", "Account recovery started")
+	accountRecoveredTemplate       = mustEmailTemplate("account_recovered", "Two-factor authentication was removed")
+
+	emailChangeTemplate  = mustEmailTemplate("email_change", "Confirm your new email address")
+	emailChangedTemplate = mustEmailTemplate("email_changed", "Your email address was changed")
+	phoneChangedTemplate = mustEmailTemplate("phone_changed", "Your phone number was changed")
+
+	accountDeletionTemplate = mustEmailTemplate("account_deletion", "Confirm your account deletion")
+	accountDeletedTemplate  = mustEmailTemplate("account_deleted", "Your account has been deleted")
+
+	dataExportReadyTemplate = mustEmailTemplate("data_export_ready", "Your data export is ready")
+)
+
+// EmailNotifier delivers account email. notification.Service implements it.
+type EmailNotifier interface {
+	SendEmail(ctx context.Context, msg *notification.EmailMessage) error
+}
+
+type emailTemplate struct {
+	subject string
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+type emailData struct {
+	Name      string
+	Link      string
+	Code      string
+	ExpiresIn string
+	Remaining int
+
+	// Device, Location, IPAddress and Time describe a sign-in.
+	Device    string
+	Location  string
+	IPAddress string
+	Time      string
+
+	// Email and Phone are the new contact details in a change notice.
+	Email string
+	Phone string
+}
+
+func mustEmailTemplate(name, subject string) *emailTemplate {
+	return &emailTemplate{
+		subject: subject,
+		text:    texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/"+name+".txt.tmpl")),
+		html:    htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/"+name+".html.tmpl")),
+	}
+}
+
+// sendEmail renders tmpl for the user and sends it to the given address as
+// transactional mail.
+func (uc *UseCase) sendEmail(ctx context.Context, user *auth.User, to string, tmpl *emailTemplate, data emailData) error {
+	if data.Name == "" {
+		data.Name = "there"
+		if user.Name != nil && *user.Name != "" {
+			data.Name = *user.Name
+		}
+	}
+
+	var text, html bytes.Buffer
+
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return fmt.Errorf("UseCase - sendEmail - tmpl.text.Execute: %w", err)
+	}
+
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return fmt.Errorf("UseCase - sendEmail - tmpl.html.Execute: %w", err)
+	}
+
+	err := uc.notifier.SendEmail(ctx, &notification.EmailMessage{
+		UserID:        user.ID,
+		To:            []string{to},
+		Subject:       tmpl.subject,
+		Body:          text.String(),
+		HTMLBody:      html.String(),
+		Transactional: true,
+	})
+	if err != nil {
+		return fmt.Errorf("UseCase - sendEmail - uc.notifier.SendEmail: %w", err)
+	}
+
+	return nil
+}
+
+// link builds a frontend URL carrying a one-time token.
+func (uc *UseCase) link(path, rawToken string) string {
+	return uc.cfg.AppURL + path + "?token=" + url.QueryEscape(rawToken)
+}
+
+// emailTimeLayout formats times shown in email, which are always UTC.
+const emailTimeLayout = "January 2, 2006 at 15:04 MST"
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+
+	return s
+}
+
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d.Round(time.Minute)/time.Minute), "minute")
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+
+	return fmt.Sprintf("%d %ss", n, unit)
+}
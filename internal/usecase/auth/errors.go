@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/password"
+)
+
+const (
+	codeInvalidCredentials = "INVALID_CREDENTIALS"
+	codeInvalidToken       = "INVALID_TOKEN"
+	codeTokenExpired       = "TOKEN_EXPIRED"
+	codeAccountDisabled    = "ACCOUNT_DISABLED"
+	codeAccountLocked      = "ACCOUNT_LOCKED"
+	codeEmailAlreadyExists = "EMAIL_ALREADY_EXISTS"
+	codePhoneAlreadyExists = "PHONE_ALREADY_EXISTS"
+	codePhoneRequiredByMFA = "PHONE_REQUIRED_FOR_MFA"
+	codePasswordTooWeak    = "PASSWORD_TOO_WEAK"
+	codeRefreshInvalid     = "REFRESH_TOKEN_INVALID"
+	codeRefreshExpired     = "REFRESH_TOKEN_EXPIRED"
+	codePasswordIncorrect  = "PASSWORD_INCORRECT"
+	codePasswordSameAsOld  = "PASSWORD_SAME_AS_OLD"
+	codeResetExpired       = "PASSWORD_RESET_EXPIRED"
+	codeResetInvalid       = "PASSWORD_RESET_INVALID"
+	codeMFAInvalidCode     = "MFA_INVALID_CODE"
+	codeMFANotEnabled      = "MFA_NOT_ENABLED"
+	codeMFAAlreadyEnabled  = "MFA_ALREADY_ENABLED"
+	codeTOTPSetupRequired  = "TOTP_SETUP_REQUIRED"
+	codeMFACodeExpired     = "MFA_CODE_EXPIRED"
+	codeRecoveryInvalid    = "RECOVERY_CODE_INVALID"
+	codeRecoveryExpired    = "RECOVERY_SESSION_EXPIRED"
+	codeRecoveryCoolingOff = "RECOVERY_COOLING_OFF"
+	codeOAuthError         = "OAUTH_ERROR"
+	codeOAuthStateMismatch = "OAUTH_STATE_MISMATCH"
+	codeOAuthCodeInvalid   = "OAUTH_CODE_INVALID"
+	codeOAuthAccountExists = "OAUTH_ACCOUNT_EXISTS"
+	codeOAuthNotLinked     = "OAUTH_NOT_LINKED"
+	codeOAuthLastMethod    = "OAUTH_LAST_METHOD"
+	codePasskeyRegFailed   = "PASSKEY_REGISTRATION_FAILED"
+	codePasskeyAuthFailed  = "PASSKEY_AUTH_FAILED"
+	codePasskeyNotFound    = "PASSKEY_NOT_FOUND"
+	codePasskeyLastMethod  = "PASSKEY_LAST_METHOD"
+	codeExportInProgress   = "DATA_EXPORT_IN_PROGRESS"
+	codeAvatarTooLarge     = "AVATAR_TOO_LARGE"
+	codeAvatarUnsupported  = "AVATAR_UNSUPPORTED"
+)
+
+func errInvalidCredentials() error {
+	return apperror.Unauthorized("Invalid email or password", apperror.WithCode(codeInvalidCredentials))
+}
+
+func errAccountLocked(retryAfter time.Duration) error {
+	return apperror.RateLimited("Account is temporarily locked due to too many failed attempts",
+		apperror.WithCode(codeAccountLocked),
+		apperror.WithRetryAfter(retryAfter),
+	)
+}
+
+func errMagicLinkInvalid() error {
+	return apperror.Unauthorized("Invalid magic link or code", apperror.WithCode(codeInvalidToken))
+}
+
+func errRefreshTokenInvalid() error {
+	return apperror.Unauthorized("Refresh token is invalid or has been revoked", apperror.WithCode(codeRefreshInvalid))
+}
+
+func errVerificationInvalid() error {
+	return apperror.Unauthorized("Verification link is invalid or has already been used", apperror.WithCode(codeInvalidToken))
+}
+
+func errEmailAlreadyExists() error {
+	return apperror.Conflict("An account with this email already exists", apperror.WithCode(codeEmailAlreadyExists))
+}
+
+func errPhoneAlreadyExists() error {
+	return apperror.Conflict("An account with this phone number already exists", apperror.WithCode(codePhoneAlreadyExists))
+}
+
+func errDisposableEmail(field string) error {
+	return apperror.Validation("Disposable email addresses are not allowed",
+		apperror.WithField(field, "must not be a disposable email address"))
+}
+
+func errEmailChangeInvalid() error {
+	return apperror.Unauthorized("Email change link is invalid or has already been used", apperror.WithCode(codeInvalidToken))
+}
+
+func errAccountDeletionInvalid() error {
+	return apperror.Unauthorized("Account deletion link is invalid or has already been used", apperror.WithCode(codeInvalidToken))
+}
+
+func errDataExportInvalid() error {
+	return apperror.Unauthorized("Data export link is invalid", apperror.WithCode(codeInvalidToken))
+}
+
+func errDataExportExpired() error {
+	return apperror.Unauthorized("Data export link has expired", apperror.WithCode(codeTokenExpired))
+}
+
+func errPasswordTooWeak(field string, res password.Result) error {
+	return apperror.Validation("Password does not meet the requirements",
+		apperror.WithCode(codePasswordTooWeak),
+		apperror.WithField(field, strings.Join(res.Feedback, "; ")),
+	)
+}
+
+func errPasswordResetInvalid() error {
+	return apperror.Unauthorized("Password reset link is invalid or has already been used", apperror.WithCode(codeResetInvalid))
+}
+
+func errMFAInvalidCode() error {
+	return apperror.Validation("Verification code is invalid",
+		apperror.WithCode(codeMFAInvalidCode),
+		apperror.WithField("code", "is invalid or has already been used"),
+	)
+}
+
+func errRecoveryCodeInvalid() error {
+	return apperror.Validation("Recovery code is invalid",
+		apperror.WithCode(codeRecoveryInvalid),
+		apperror.WithField("code", "is invalid or has already been used"),
+	)
+}
+
+func errAccountRecoveryInvalid() error {
+	return apperror.Unauthorized("Recovery session is invalid or has already been used", apperror.WithCode(codeInvalidToken))
+}
+
+func errAccountRecoveryCodeInvalid() error {
+	return apperror.Unauthorized("Recovery code is invalid", apperror.WithCode(codeRecoveryInvalid))
+}
+
+func errMFAAlreadyEnabled() error {
+	return apperror.Conflict("Authenticator app is already enabled", apperror.WithCode(codeMFAAlreadyEnabled))
+}
+
+func errChallengeInvalid() error {
+	return apperror.Unauthorized("MFA challenge is invalid or has already been used", apperror.WithCode(codeInvalidToken))
+}
+
+func errOAuthStateMismatch() error {
+	return apperror.Validation("Sign-in request is invalid or has expired; start again",
+		apperror.WithCode(codeOAuthStateMismatch),
+		apperror.WithField("state", "does not match a pending sign-in"),
+	)
+}
+
+func errOAuthAccountExists() error {
+	return apperror.Conflict("An account with this email already exists; sign in with your existing method instead",
+		apperror.WithCode(codeOAuthAccountExists))
+}
+
+func errOAuthProvider(err error) error {
+	return apperror.External("Could not complete sign-in with the provider",
+		apperror.WithCode(codeOAuthError),
+		apperror.WithCause(err),
+	)
+}
+
+func errOAuthNotLinked() error {
+	return apperror.NotFound("This provider is not linked to your account", apperror.WithCode(codeOAuthNotLinked))
+}
+
+func errPasskeyRegistrationFailed(err error) error {
+	return apperror.Validation("Passkey could not be registered; try again",
+		apperror.WithCode(codePasskeyRegFailed),
+		apperror.WithCause(err),
+	)
+}
+
+func errPasskeyAuthFailed(err error) error {
+	return apperror.Unauthorized("Passkey sign-in failed",
+		apperror.WithCode(codePasskeyAuthFailed),
+		apperror.WithCause(err),
+	)
+}
+
+func errPasskeyNotFound() error {
+	return apperror.NotFound("Passkey not found", apperror.WithCode(codePasskeyNotFound))
+}
+
+func errAvatarTooLarge(message string) error {
+	return apperror.Validation(message, apperror.WithCode(codeAvatarTooLarge))
+}
+
+func errAvatarUnsupported(err error) error {
+	return apperror.Validation("Avatar must be a JPEG, PNG or GIF image",
+		apperror.WithCode(codeAvatarUnsupported),
+		apperror.WithCause(err),
+	)
+}
+
+func errAvatarNotFound() error {
+	return apperror.NotFound("Avatar not found")
+}
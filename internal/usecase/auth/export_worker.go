@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/evrone/go-clean-template/pkg/logger"
+)
+
+// ExportWorker periodically builds queued data exports and deletes the
+// archives of expired ones, in batches of batchSize until none are left.
+type ExportWorker struct {
+	uc        *UseCase
+	logger    logger.Interface
+	interval  time.Duration
+	batchSize uint64
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewExportWorker(uc *UseCase, l logger.Interface, interval time.Duration, batchSize uint64) *ExportWorker {
+	return &ExportWorker{
+		uc:        uc,
+		logger:    l,
+		interval:  interval,
+		batchSize: batchSize,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (w *ExportWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+
+	w.logger.Info("export worker - started")
+}
+
+func (w *ExportWorker) Stop() {
+	close(w.stop)
+	<-w.done
+	w.logger.Info("export worker - stopped")
+}
+
+func (w *ExportWorker) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.drain(ctx, "built", w.uc.ProcessDataExports)
+			w.drain(ctx, "expired", w.uc.ExpireDataExports)
+		}
+	}
+}
+
+// drain runs job until a batch comes up short.
+func (w *ExportWorker) drain(ctx context.Context, verb string, job func(context.Context, uint64) (int, error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		default:
+		}
+
+		n, err := job(ctx, w.batchSize)
+		if n > 0 {
+			w.logger.Info("export worker - %s %d exports", verb, n)
+		}
+
+		if err != nil {
+			w.logger.Error(err, "export worker - "+verb)
+
+			return
+		}
+
+		if uint64(n) < w.batchSize { //nolint:gosec // n is never negative
+			return
+		}
+	}
+}
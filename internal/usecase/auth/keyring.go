@@ -0,0 +1,422 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/repo"
+	"github.com/evrone/go-clean-template/pkg/encryption"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	_defaultReloadInterval = time.Minute
+	_minMissReload         = 5 * time.Second
+	_keyClockSkew          = 30 * time.Second
+
+	hmacSecretLength = 64
+	rsaKeyBits       = 2048
+	kidLength        = 12
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	errNoActiveKey          = errors.New("no active signing key")
+	errUnknownKey           = errors.New("unknown signing key")
+	errInvalidKeyMaterial   = errors.New("invalid signing key material")
+)
+
+// KeyRingConfig -.
+type KeyRingConfig struct {
+	// Algorithm is used for newly generated keys.
+	Algorithm string
+	// RotationInterval is how long a key stays the active signing key.
+	RotationInterval time.Duration
+	// RetireAfter is how long a rotated-out key keeps verifying tokens. It must
+	// be at least KeyRetirement of the access token TTL so rotation never
+	// invalidates live tokens.
+	RetireAfter time.Duration
+	// ReloadInterval is how often keys are re-read from the database, which is
+	// how rotations and revocations made by other instances are picked up.
+	ReloadInterval time.Duration
+}
+
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	createdAt time.Time
+	expiresAt *time.Time
+	active    bool
+}
+
+// KeyRing keeps the usable JWT signing keys from jwt_signing_keys in memory
+// and rotates the active key when it gets older than the rotation interval.
+type KeyRing struct {
+	repo   repo.SigningKeyRepo
+	cipher encryption.Cipher
+	cfg    KeyRingConfig
+	now    func() time.Time
+
+	reloadMu sync.Mutex
+
+	mu       sync.RWMutex
+	active   *signingKey
+	keys     map[string]*signingKey
+	loadedAt time.Time
+}
+
+func NewKeyRing(r repo.SigningKeyRepo, c encryption.Cipher, cfg KeyRingConfig) (*KeyRing, error) {
+	if _, err := signingMethod(cfg.Algorithm); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = _defaultReloadInterval
+	}
+
+	return &KeyRing{
+		repo:   r,
+		cipher: c,
+		cfg:    cfg,
+		now:    time.Now,
+		keys:   make(map[string]*signingKey),
+	}, nil
+}
+
+// KeyRetirement returns how long a rotated-out key has to keep verifying.
+// Other instances go on signing with it until their next reload, so the
+// tokens they issue live up to reloadInterval longer than accessTokenTTL;
+// a little more is allowed for their clocks disagreeing.
+func KeyRetirement(accessTokenTTL, reloadInterval time.Duration) time.Duration {
+	if reloadInterval <= 0 {
+		reloadInterval = _defaultReloadInterval
+	}
+
+	return accessTokenTTL + reloadInterval + _keyClockSkew
+}
+
+// signer returns the active key, rotating first when it is due.
+func (k *KeyRing) signer(ctx context.Context) (*signingKey, error) {
+	now := k.now()
+
+	if k.stale(now, k.cfg.ReloadInterval) {
+		if err := k.reload(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	k.mu.RLock()
+	active := k.active
+	k.mu.RUnlock()
+
+	if active == nil || !active.createdAt.Add(k.cfg.RotationInterval).After(now) {
+		if err := k.rotate(ctx, now.Add(-k.cfg.RotationInterval)); err != nil {
+			return nil, err
+		}
+
+		k.mu.RLock()
+		active = k.active
+		k.mu.RUnlock()
+	}
+
+	if active == nil {
+		return nil, errNoActiveKey
+	}
+
+	return active, nil
+}
+
+// verifier returns the usable key with the given kid.
+func (k *KeyRing) verifier(ctx context.Context, kid string) (*signingKey, error) {
+	now := k.now()
+
+	if k.stale(now, k.cfg.ReloadInterval) {
+		if err := k.reload(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	key := k.lookup(kid)
+	if key == nil && k.stale(now, _minMissReload) {
+		if err := k.reload(ctx); err != nil {
+			return nil, err
+		}
+
+		key = k.lookup(kid)
+	}
+
+	if key == nil || (key.expiresAt != nil && !key.expiresAt.After(now)) {
+		return nil, errUnknownKey
+	}
+
+	return key, nil
+}
+
+// Rotate generates a new active key immediately.
+func (k *KeyRing) Rotate(ctx context.Context) error {
+	return k.rotate(ctx, k.now().Add(time.Nanosecond))
+}
+
+// Revoke stops a key from verifying. Other instances drop it on their next reload.
+func (k *KeyRing) Revoke(ctx context.Context, kid string) error {
+	if err := k.repo.Revoke(ctx, kid); err != nil {
+		return fmt.Errorf("KeyRing - Revoke - k.repo.Revoke: %w", err)
+	}
+
+	return k.reload(ctx)
+}
+
+// JWKS returns the public halves of the usable asymmetric keys.
+func (k *KeyRing) JWKS(ctx context.Context) ([]auth.JWK, error) {
+	if k.stale(k.now(), k.cfg.ReloadInterval) {
+		if err := k.reload(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := make([]auth.JWK, 0, len(k.keys))
+
+	for _, key := range k.keys {
+		if jwk, ok := publicJWK(key); ok {
+			jwks = append(jwks, jwk)
+		}
+	}
+
+	return jwks, nil
+}
+
+func (k *KeyRing) lookup(kid string) *signingKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.keys[kid]
+}
+
+func (k *KeyRing) stale(now time.Time, maxAge time.Duration) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return now.Sub(k.loadedAt) >= maxAge
+}
+
+func (k *KeyRing) rotate(ctx context.Context, staleBefore time.Time) error {
+	key, err := k.generate()
+	if err != nil {
+		return err
+	}
+
+	if _, err = k.repo.Rotate(ctx, key, k.now().UTC().Add(k.cfg.RetireAfter), staleBefore); err != nil {
+		return fmt.Errorf("KeyRing - rotate - k.repo.Rotate: %w", err)
+	}
+
+	return k.reload(ctx)
+}
+
+func (k *KeyRing) reload(ctx context.Context) error {
+	k.reloadMu.Lock()
+	defer k.reloadMu.Unlock()
+
+	now := k.now()
+
+	stored, err := k.repo.ListUsable(ctx, now.UTC())
+	if err != nil {
+		return fmt.Errorf("KeyRing - reload - k.repo.ListUsable: %w", err)
+	}
+
+	keys := make(map[string]*signingKey, len(stored))
+
+	var active *signingKey
+
+	for i := range stored {
+		key, err := k.decode(&stored[i])
+		if err != nil {
+			return err
+		}
+
+		keys[key.kid] = key
+
+		// Keys are listed newest first; the newest active one signs.
+		if key.active && active == nil {
+			active = key
+		}
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.active = active
+	k.loadedAt = now
+	k.mu.Unlock()
+
+	return nil
+}
+
+func (k *KeyRing) generate() (*auth.SigningKey, error) {
+	material, err := generateKeyMaterial(k.cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := k.cipher.Encrypt(material)
+	if err != nil {
+		return nil, fmt.Errorf("KeyRing - generate - k.cipher.Encrypt: %w", err)
+	}
+
+	kid, err := token.Generate(kidLength)
+	if err != nil {
+		return nil, fmt.Errorf("KeyRing - generate - token.Generate: %w", err)
+	}
+
+	return &auth.SigningKey{
+		KID:             kid,
+		Algorithm:       k.cfg.Algorithm,
+		SecretEncrypted: encrypted,
+	}, nil
+}
+
+func (k *KeyRing) decode(stored *auth.SigningKey) (*signingKey, error) {
+	method, err := signingMethod(stored.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("KeyRing - decode - kid %s: %w", stored.KID, err)
+	}
+
+	material, err := k.cipher.Decrypt(stored.SecretEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("KeyRing - decode - k.cipher.Decrypt - kid %s: %w", stored.KID, err)
+	}
+
+	key := &signingKey{
+		kid:       stored.KID,
+		method:    method,
+		createdAt: stored.CreatedAt,
+		expiresAt: stored.ExpiresAt,
+		active:    stored.IsActive,
+	}
+
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		key.signKey, key.verifyKey = material, material
+	default:
+		signer, err := parsePrivateKey(material)
+		if err != nil {
+			return nil, fmt.Errorf("KeyRing - decode - kid %s: %w", stored.KID, err)
+		}
+
+		key.signKey, key.verifyKey = signer, signer.Public()
+	}
+
+	return key, nil
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	if !slices.Contains(supportedAlgorithms, alg) {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	return jwt.GetSigningMethod(alg), nil
+}
+
+func generateKeyMaterial(alg string) ([]byte, error) {
+	var (
+		private any
+		err     error
+	)
+
+	switch alg {
+	case "HS256", "HS384", "HS512":
+		secret := make([]byte, hmacSecretLength)
+		if _, err = rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generateKeyMaterial - rand.Read: %w", err)
+		}
+
+		return secret, nil
+	case "RS256", "RS384", "RS512":
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		private, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		private, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("generateKeyMaterial - GenerateKey: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("generateKeyMaterial - x509.MarshalPKCS8PrivateKey: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func parsePrivateKey(material []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(material)
+	if block == nil {
+		return nil, errInvalidKeyMaterial
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParsePKCS8PrivateKey: %w", err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errInvalidKeyMaterial
+	}
+
+	return signer, nil
+}
+
+func publicJWK(key *signingKey) (auth.JWK, bool) {
+	jwk := auth.JWK{KeyID: key.kid, Use: "sig", Algorithm: key.method.Alg()}
+
+	switch pub := key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdh, err := pub.ECDH()
+		if err != nil {
+			return auth.JWK{}, false
+		}
+
+		// Uncompressed point: 0x04 || X || Y, each coordinate padded to the curve size.
+		point := ecdh.Bytes()
+		size := (len(point) - 1) / 2 //nolint:mnd // two coordinates
+
+		jwk.KeyType = "EC"
+		jwk.Curve = pub.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+	default:
+		return auth.JWK{}, false
+	}
+
+	return jwk, true
+}
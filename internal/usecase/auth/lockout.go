@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+)
+
+func (uc *UseCase) lockoutPolicy() auth.LockoutPolicy {
+	return auth.LockoutPolicy{
+		MaxAttempts: uc.cfg.LoginMaxAttempts,
+		Window:      uc.cfg.LoginAttemptWindow,
+		Lockout:     uc.cfg.LoginLockout,
+		MaxLockout:  uc.cfg.LoginMaxLockout,
+	}
+}
+
+// failPassword counts a wrong password against the account. The attempt that
+// locks the account gets the lockout in place of the usual error.
+func (uc *UseCase) failPassword(ctx context.Context, user *auth.User, client auth.ClientInfo) (*auth.AuthResult, error) {
+	locked, err := uc.countPasswordFailure(ctx, user, "invalid_password", client)
+	if err != nil {
+		return nil, err
+	}
+
+	if !locked {
+		return nil, errInvalidCredentials()
+	}
+
+	return accountLocked(user), nil
+}
+
+// countPasswordFailure records a wrong password, whether given to sign in or
+// to confirm a sensitive change, and reports whether it locked the account.
+// A new lockout is audited and the user is told about it.
+func (uc *UseCase) countPasswordFailure(ctx context.Context, user *auth.User, reason string, client auth.ClientInfo) (bool, error) {
+	uc.recordLoginFailure(ctx, &user.ID, reason, client)
+
+	f, locked, err := uc.users.RecordLoginFailure(ctx, user.ID, uc.now().UTC(), uc.lockoutPolicy())
+	if err != nil {
+		return false, fmt.Errorf("UseCase - countPasswordFailure - uc.users.RecordLoginFailure: %w", err)
+	}
+
+	if !locked {
+		return false, nil
+	}
+
+	user.LockedUntil = f.LockedUntil
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventAccountLocked,
+		Success:   true,
+		RiskLevel: auth.RiskHigh,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details: map[string]any{
+			"locked_until":    f.LockedUntil.Format(time.RFC3339),
+			"lockout_seconds": int(f.LockedUntil.Sub(*f.LastFailure).Seconds()),
+			"lockouts":        f.Lockouts,
+		},
+	})
+
+	// The lockout stands either way; delivery errors are recorded in the
+	// delivery log.
+	_ = uc.sendEmail(ctx, user, user.Email, accountLockedTemplate, emailData{
+		Link:      uc.cfg.AppURL + forgotPasswordPath,
+		ExpiresIn: humanDuration(f.LockedUntil.Sub(*f.LastFailure)),
+		IPAddress: orUnknown(client.IPAddress),
+		Time:      f.LockedUntil.Format(emailTimeLayout),
+	})
+
+	return true, nil
+}
+
+// lockedOut refuses a sign-in while the account is locked, whichever first
+// factor was used, so that a lockout can't be sidestepped by signing in some
+// other way. It returns nil when the user may go on.
+func (uc *UseCase) lockedOut(ctx context.Context, user *auth.User, client auth.ClientInfo) *auth.AuthResult {
+	if !user.IsLocked(uc.now().UTC()) {
+		return nil
+	}
+
+	uc.recordLoginFailure(ctx, &user.ID, "account_locked", client)
+
+	return accountLocked(user)
+}
+
+// unlocked audits the first sign-in after a lockout ran out, or a password
+// reset that lifted one.
+func (uc *UseCase) unlocked(ctx context.Context, user *auth.User, reason string, client auth.ClientInfo) {
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventAccountUnlocked,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"reason": reason},
+	})
+}
+
+// accountLocked is returned in place of tokens while the user is locked out.
+func accountLocked(user *auth.User) *auth.AuthResult {
+	return &auth.AuthResult{
+		User: user,
+		Challenge: &auth.LoginChallenge{
+			Type:        auth.ChallengeAccountLocked,
+			LockedUntil: user.LockedUntil,
+			Message:     "Account is temporarily locked due to too many failed login attempts",
+		},
+	}
+}
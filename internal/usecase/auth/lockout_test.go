@@ -0,0 +1,158 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseCase_Login_Lockout(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	events := &mockSecurityEventRepo{}
+	mail := &mockEmailNotifier{}
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{Users: newMemoryUserRepo(user), SecurityEvents: events, Notifier: mail})
+	ctx := context.Background()
+	client := auth.ClientInfo{IPAddress: "203.0.113.7", UserAgent: chromeOnMac}
+
+	login := func(pw string) (*auth.AuthResult, error) {
+		return uc.Login(ctx, auth.LoginInput{Email: user.Email, Password: pw, Client: client})
+	}
+
+	for range 2 {
+		_, err := login("wrong")
+		requireAppError(t, err, apperror.KindUnauthorized, "INVALID_CREDENTIALS")
+	}
+
+	result, err := login("wrong")
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeAccountLocked, result.Challenge.Type)
+	require.NotNil(t, result.Challenge.LockedUntil)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), *result.Challenge.LockedUntil, time.Minute)
+
+	locked := eventsOfType(events, auth.EventAccountLocked)
+	require.Len(t, locked, 1)
+	assert.Equal(t, auth.RiskHigh, locked[0].RiskLevel)
+	assert.Equal(t, 900, locked[0].Details["lockout_seconds"])
+
+	sent := mail.sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "Your account has been temporarily locked", sent[0].Subject)
+	assert.Contains(t, sent[0].Body, "15 minutes")
+	assert.Contains(t, sent[0].Body, "https://app.example.com/forgot-password")
+
+	// The right password does not help while the account is locked.
+	result, err = login("SecureP@ss123")
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Nil(t, result.Tokens)
+
+	// Once the lockout runs out, a sign-in clears it.
+	past := time.Now().Add(-time.Second)
+	user.LockedUntil = &past
+
+	result, err = login("SecureP@ss123")
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+
+	unlocked := eventsOfType(events, auth.EventAccountUnlocked)
+	require.Len(t, unlocked, 1)
+	assert.Equal(t, "lockout_expired", unlocked[0].Details["reason"])
+	assert.Nil(t, user.LockedUntil)
+	assert.Zero(t, user.FailedLoginAttempts)
+}
+
+func TestUseCase_Login_LockoutEscalates(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{Users: newMemoryUserRepo(user)})
+	ctx := context.Background()
+
+	lockOut := func() time.Duration {
+		t.Helper()
+
+		var result *auth.AuthResult
+
+		for range 3 {
+			var err error
+
+			result, err = uc.Login(ctx, auth.LoginInput{Email: user.Email, Password: "wrong"})
+			if err != nil {
+				requireAppError(t, err, apperror.KindUnauthorized, "INVALID_CREDENTIALS")
+			}
+		}
+
+		require.NotNil(t, result)
+		require.NotNil(t, result.Challenge)
+
+		return time.Until(*result.Challenge.LockedUntil)
+	}
+
+	assert.InDelta(t, 15*time.Minute, lockOut(), float64(time.Minute))
+
+	past := time.Now().Add(-time.Second)
+	user.LockedUntil = &past
+
+	assert.InDelta(t, 30*time.Minute, lockOut(), float64(time.Minute))
+}
+
+func TestUseCase_Reauthenticate_Lockout(t *testing.T) {
+	t.Parallel()
+
+	f := newContactChangeFixture(t)
+	ctx := context.Background()
+
+	change := func(pw string) error {
+		return f.uc.RequestEmailChange(ctx, auth.EmailChangeInput{UserID: f.user.ID, NewEmail: "new@example.com", Password: pw})
+	}
+
+	for range 2 {
+		requireAppError(t, change("wrong"), apperror.KindValidation, "PASSWORD_INCORRECT")
+	}
+
+	// Guessing through a sensitive change counts like guessing at sign-in.
+	requireAppError(t, change("wrong"), apperror.KindRateLimited, "ACCOUNT_LOCKED")
+	require.NotNil(t, f.user.LockedUntil)
+	assert.Len(t, eventsOfType(f.events, auth.EventAccountLocked), 1)
+	assert.Len(t, eventsOfType(f.events, auth.EventLoginFailed), 3)
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, "Your account has been temporarily locked", mail[0].Subject)
+
+	requireAppError(t, change("SecureP@ss123"), apperror.KindRateLimited, "ACCOUNT_LOCKED")
+
+	err := f.uc.DisableSMS(ctx, f.user.ID, "SecureP@ss123", auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindRateLimited, "ACCOUNT_LOCKED")
+
+	result, err := f.uc.Login(ctx, auth.LoginInput{Email: f.user.Email, Password: "SecureP@ss123"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeAccountLocked, result.Challenge.Type)
+
+	past := time.Now().Add(-time.Second)
+	f.user.LockedUntil = &past
+
+	require.NoError(t, change("SecureP@ss123"))
+}
+
+func eventsOfType(events *mockSecurityEventRepo, typ auth.SecurityEventType) []auth.SecurityEvent {
+	var matched []auth.SecurityEvent
+
+	for _, e := range events.stored() {
+		if e.Type == typ {
+			matched = append(matched, e)
+		}
+	}
+
+	return matched
+}
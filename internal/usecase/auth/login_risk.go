@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/geoip"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/evrone/go-clean-template/pkg/useragent"
+	"github.com/google/uuid"
+)
+
+// loginHistoryLimit caps how many recent sign-ins a login is compared with.
+const loginHistoryLimit = 100
+
+// PushNotifier delivers push notifications. notification.Service implements it.
+type PushNotifier interface {
+	SendPush(ctx context.Context, msg *notification.PushMessage) error
+}
+
+// loginRisk is how a sign-in compares with the user's recent history.
+type loginRisk struct {
+	level      auth.RiskLevel
+	newDevice  bool
+	newIP      bool
+	newCountry bool
+	device     useragent.Device
+	location   *geoip.Location
+}
+
+// reasons lists what made the sign-in stand out, for the audit log.
+func (r *loginRisk) reasons() []string {
+	var reasons []string
+
+	if r.newDevice {
+		reasons = append(reasons, "new_device")
+	}
+
+	if r.newIP {
+		reasons = append(reasons, "new_ip")
+	}
+
+	if r.newCountry {
+		reasons = append(reasons, "new_country")
+	}
+
+	return reasons
+}
+
+// knownClients is where a user has signed in from before.
+type knownClients struct {
+	devices   map[string]bool
+	ips       map[string]bool
+	countries map[string]bool
+}
+
+func (k *knownClients) add(userAgent, ip, countryCode *string) {
+	ua := ""
+	if userAgent != nil {
+		ua = *userAgent
+	}
+
+	k.devices[useragent.Parse(ua).Fingerprint()] = true
+
+	if ip != nil && *ip != "" {
+		k.ips[*ip] = true
+	}
+
+	if countryCode != nil && *countryCode != "" {
+		k.countries[*countryCode] = true
+	}
+}
+
+// assessLogin compares a sign-in with the user's successful sign-ins over
+// LoginHistoryWindow and with the devices of their active sessions. A device
+// or country never seen before is medium risk, and both together are high.
+// A user with no history has nothing to compare with and is low risk.
+func (uc *UseCase) assessLogin(ctx context.Context, userID uuid.UUID, client auth.ClientInfo) (*loginRisk, error) {
+	risk := &loginRisk{
+		level:    auth.RiskLow,
+		device:   useragent.Parse(client.UserAgent),
+		location: uc.events.locate(client.IPAddress),
+	}
+
+	since := uc.now().UTC().Add(-uc.cfg.LoginHistoryWindow)
+
+	history, err := uc.eventLog.ListByUserID(ctx, userID,
+		auth.SecurityEventFilter{Type: auth.EventLoginSuccess, From: &since}, loginHistoryLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - assessLogin - uc.eventLog.ListByUserID: %w", err)
+	}
+
+	sessions, err := uc.refreshTokens.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - assessLogin - uc.refreshTokens.ListSessions: %w", err)
+	}
+
+	if len(history) == 0 && len(sessions) == 0 {
+		return risk, nil
+	}
+
+	known := knownClients{devices: map[string]bool{}, ips: map[string]bool{}, countries: map[string]bool{}}
+
+	for i := range history {
+		known.add(history[i].UserAgent, history[i].IPAddress, history[i].LocationCountryCode)
+	}
+
+	for i := range sessions {
+		var country *string
+
+		if sessions[i].IPAddress != nil {
+			if loc := uc.events.locate(*sessions[i].IPAddress); loc != nil {
+				country = &loc.CountryCode
+			}
+		}
+
+		known.add(sessions[i].UserAgent, sessions[i].IPAddress, country)
+	}
+
+	risk.newDevice = !known.devices[risk.device.Fingerprint()]
+	risk.newIP = client.IPAddress != "" && !known.ips[client.IPAddress]
+	risk.newCountry = risk.location != nil && risk.location.CountryCode != "" &&
+		len(known.countries) > 0 && !known.countries[risk.location.CountryCode]
+
+	switch {
+	case risk.newDevice && risk.newCountry:
+		risk.level = auth.RiskHigh
+	case risk.newDevice || risk.newCountry:
+		risk.level = auth.RiskMedium
+	}
+
+	return risk, nil
+}
+
+// reviewLogin audits a completed sign-in that did not match the user's
+// history and, when LoginAlerts is on, tells the user about it.
+func (uc *UseCase) reviewLogin(ctx context.Context, user *auth.User, risk *loginRisk, client auth.ClientInfo) {
+	if risk.newDevice {
+		uc.recordEvent(ctx, &auth.SecurityEvent{
+			UserID:    &user.ID,
+			Type:      auth.EventNewDeviceLogin,
+			Success:   true,
+			RiskLevel: risk.level,
+			IPAddress: optional(client.IPAddress),
+			UserAgent: optional(client.UserAgent),
+			Details:   map[string]any{"reasons": risk.reasons()},
+		})
+	}
+
+	if risk.level == auth.RiskHigh {
+		uc.recordSuspiciousLogin(ctx, user.ID, risk, "signed_in", client)
+	}
+
+	if risk.level == auth.RiskLow || !uc.cfg.LoginAlerts {
+		return
+	}
+
+	data := uc.signInDetails(risk.device, risk.location, client.IPAddress)
+	data.Link = uc.cfg.AppURL + securitySettingsPath
+
+	// The sign-in already succeeded; delivery errors are recorded in the
+	// delivery log.
+	_ = uc.sendEmail(ctx, user, user.Email, newSignInTemplate, data)
+	_ = uc.push.SendPush(ctx, &notification.PushMessage{
+		UserID: user.ID,
+		Title:  "New sign-in to your account",
+		Body:   fmt.Sprintf("%s, %s. Not you? Secure your account now.", data.Device, data.Location),
+		Data:   map[string]string{"type": string(auth.EventNewDeviceLogin), "risk_level": string(risk.level)},
+	})
+}
+
+func (uc *UseCase) recordSuspiciousLogin(ctx context.Context, userID uuid.UUID, risk *loginRisk, action string, client auth.ClientInfo) {
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &userID,
+		Type:      auth.EventSuspiciousActivity,
+		Success:   false,
+		RiskLevel: risk.level,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"reasons": risk.reasons(), "action": action},
+	})
+}
+
+// startStepUpChallenge holds back a high-risk login from a user without MFA
+// until they enter a code sent to their email.
+func (uc *UseCase) startStepUpChallenge(ctx context.Context, user *auth.User, risk *loginRisk, in auth.LoginInput) (*auth.AuthResult, error) {
+	c, raw, err := uc.newChallenge(ctx, user.ID, []auth.MFAMethod{auth.MFAMethodEmail}, in.RememberMe, in.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = uc.sendChallengeCode(ctx, user, c); err != nil {
+		return nil, err
+	}
+
+	uc.recordSuspiciousLogin(ctx, user.ID, risk, "email_code_required", in.Client)
+
+	return &auth.AuthResult{
+		User: user,
+		Challenge: &auth.LoginChallenge{
+			Type:             auth.ChallengeMFARequired,
+			Token:            raw,
+			AvailableMethods: []string{string(auth.MFAMethodEmail)},
+			Message:          "Confirm this sign-in with the code sent to your email",
+		},
+	}, nil
+}
+
+// resendChallengeCode emails a fresh code for a pending challenge.
+func (uc *UseCase) resendChallengeCode(ctx context.Context, c *auth.MFAChallenge) error {
+	if c.CodeSentAt != nil {
+		if wait := c.CodeSentAt.Add(uc.cfg.MFACodeResendCooldown).Sub(uc.now()); wait > 0 {
+			return apperror.RateLimited("Please wait before requesting another code", apperror.WithRetryAfter(wait))
+		}
+	}
+
+	user, err := uc.users.GetByID(ctx, c.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return errChallengeInvalid()
+		}
+
+		return fmt.Errorf("UseCase - resendChallengeCode - uc.users.GetByID: %w", err)
+	}
+
+	return uc.sendChallengeCode(ctx, user, c)
+}
+
+// sendChallengeCode replaces the challenge's code and emails the new one,
+// describing the sign-in it confirms.
+func (uc *UseCase) sendChallengeCode(ctx context.Context, user *auth.User, c *auth.MFAChallenge) error {
+	code, err := token.NumericCode(smsCodeDigits)
+	if err != nil {
+		return fmt.Errorf("UseCase - sendChallengeCode - token.NumericCode: %w", err)
+	}
+
+	now := uc.now().UTC()
+
+	if err = uc.challenges.SetCode(ctx, c.ID, challengeCodeHash(c.ID, code), now.Add(uc.cfg.MFACodeTTL), now); err != nil {
+		if errors.Is(err, auth.ErrMFAChallengeCompleted) {
+			return errChallengeInvalid()
+		}
+
+		return fmt.Errorf("UseCase - sendChallengeCode - uc.challenges.SetCode: %w", err)
+	}
+
+	var ua, ip string
+	if c.UserAgent != nil {
+		ua = *c.UserAgent
+	}
+
+	if c.IPAddress != nil {
+		ip = *c.IPAddress
+	}
+
+	data := uc.signInDetails(useragent.Parse(ua), uc.events.locate(ip), ip)
+	data.Code = code
+	data.ExpiresIn = humanDuration(uc.cfg.MFACodeTTL)
+	data.Link = uc.cfg.AppURL + securitySettingsPath
+
+	return uc.sendEmail(ctx, user, user.Email, loginCodeTemplate, data)
+}
+
+// checkChallengeCode matches code against the one emailed for the challenge.
+func (uc *UseCase) checkChallengeCode(c *auth.MFAChallenge, code string) (bool, error) {
+	if c.CodeExpired(uc.now()) {
+		return false, apperror.Validation("Verification code has expired; request a new one",
+			apperror.WithCode(codeMFACodeExpired))
+	}
+
+	return subtle.ConstantTimeCompare([]byte(challengeCodeHash(c.ID, code)), []byte(*c.CodeHash)) == 1, nil
+}
+
+// signInDetails describes a sign-in for the emails about it.
+func (uc *UseCase) signInDetails(d useragent.Device, loc *geoip.Location, ip string) emailData {
+	data := emailData{
+		Device:   "Unknown device",
+		Location: "Unknown location",
+		Time:     uc.now().UTC().Format(emailTimeLayout),
+	}
+
+	switch {
+	case d.Browser != "" && d.OS != "":
+		data.Device = d.Browser + " on " + d.OS
+	case d.Browser != "" || d.OS != "":
+		data.Device = d.Browser + d.OS
+	}
+
+	if loc != nil {
+		var parts []string
+
+		for _, p := range []string{loc.City, loc.Country} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+
+		if len(parts) > 0 {
+			data.Location = strings.Join(parts, ", ")
+		}
+	}
+
+	data.IPAddress = orUnknown(ip)
+
+	return data
+}
+
+// challengeCodeHash binds the hash to the challenge like smsCodeHash does to the user.
+func challengeCodeHash(challengeID uuid.UUID, code string) string {
+	return token.Hash(challengeID.String() + ":" + code)
+}
@@ -0,0 +1,215 @@
+package auth_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const safariOnIPhone = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1"
+
+type loginRiskFixture struct {
+	uc     *authuc.UseCase
+	user   *auth.User
+	events *mockSecurityEventRepo
+	mail   *mockEmailNotifier
+	push   *mockPushNotifier
+}
+
+// newLoginRiskFixture returns a user who last signed in a day ago with
+// Chrome on a Mac from Lisbon, with TOTP enabled when withMFA is set.
+func newLoginRiskFixture(t *testing.T, withMFA bool) *loginRiskFixture {
+	t.Helper()
+
+	f := &loginRiskFixture{
+		user:   existingUser(auth.StatusActive),
+		events: &mockSecurityEventRepo{},
+		mail:   &mockEmailNotifier{},
+		push:   &mockPushNotifier{},
+	}
+
+	totps := newMemoryTOTPRepo()
+
+	if withMFA {
+		entry, _ := pendingTOTP(t, f.user.ID)
+		verifiedAt := time.Now()
+		entry.VerifiedAt = &verifiedAt
+		totps = newMemoryTOTPRepo(entry)
+	}
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:          newMemoryUserRepo(f.user),
+		TOTP:           totps,
+		SecurityEvents: f.events,
+		Notifier:       f.mail,
+		Push:           f.push,
+		GeoIP: fakeGeoLocator{known: map[string]geoip.Location{
+			"198.51.100.1": {City: "Lisbon", Country: "Portugal", CountryCode: "PT"},
+			"198.51.100.2": {City: "Porto", Country: "Portugal", CountryCode: "PT"},
+			"203.0.113.9":  {City: "Lagos", Country: "Nigeria", CountryCode: "NG"},
+		}},
+	})
+
+	ip, ua, country := "198.51.100.1", chromeOnMac, "PT"
+	require.NoError(t, f.events.Store(context.Background(), &auth.SecurityEvent{
+		UserID:              &f.user.ID,
+		Type:                auth.EventLoginSuccess,
+		Success:             true,
+		IPAddress:           &ip,
+		UserAgent:           &ua,
+		LocationCountryCode: &country,
+		CreatedAt:           time.Now().Add(-24 * time.Hour),
+	}))
+
+	return f
+}
+
+func (f *loginRiskFixture) login(t *testing.T, client auth.ClientInfo) *auth.AuthResult {
+	t.Helper()
+
+	result, err := f.uc.Login(context.Background(), auth.LoginInput{Email: f.user.Email, Password: "SecureP@ss123", Client: client})
+	require.NoError(t, err)
+
+	return result
+}
+
+func (f *loginRiskFixture) eventsOfType(typ auth.SecurityEventType) []auth.SecurityEvent {
+	return eventsOfType(f.events, typ)
+}
+
+func TestUseCase_Login_KnownDevice(t *testing.T) {
+	t.Parallel()
+
+	f := newLoginRiskFixture(t, false)
+
+	// A browser update and a new address in the same country are not news.
+	result := f.login(t, auth.ClientInfo{
+		IPAddress: "198.51.100.2",
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36",
+	})
+	require.NotNil(t, result.Tokens)
+
+	success := f.eventsOfType(auth.EventLoginSuccess)
+	require.Len(t, success, 2)
+	assert.Equal(t, auth.RiskLow, success[1].RiskLevel)
+	assert.Empty(t, f.eventsOfType(auth.EventNewDeviceLogin))
+	assert.Empty(t, f.mail.sent())
+	assert.Empty(t, f.push.sent())
+}
+
+func TestUseCase_Login_NewDevice(t *testing.T) {
+	t.Parallel()
+
+	f := newLoginRiskFixture(t, false)
+
+	result := f.login(t, auth.ClientInfo{IPAddress: "198.51.100.2", UserAgent: safariOnIPhone})
+	require.NotNil(t, result.Tokens)
+
+	newDevice := f.eventsOfType(auth.EventNewDeviceLogin)
+	require.Len(t, newDevice, 1)
+	assert.Equal(t, auth.RiskMedium, newDevice[0].RiskLevel)
+	assert.Equal(t, []string{"new_device", "new_ip"}, newDevice[0].Details["reasons"])
+	assert.Empty(t, f.eventsOfType(auth.EventSuspiciousActivity))
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, "New sign-in to your account", mail[0].Subject)
+	assert.Contains(t, mail[0].Body, "Safari 17 on iOS 17.2")
+	assert.Contains(t, mail[0].Body, "Porto, Portugal")
+
+	push := f.push.sent()
+	require.Len(t, push, 1)
+	assert.Equal(t, f.user.ID, push[0].UserID)
+	assert.Equal(t, "medium", push[0].Data["risk_level"])
+
+	// Once seen, the device is known.
+	f.login(t, auth.ClientInfo{IPAddress: "198.51.100.2", UserAgent: safariOnIPhone})
+	assert.Len(t, f.eventsOfType(auth.EventNewDeviceLogin), 1)
+	assert.Len(t, f.mail.sent(), 1)
+}
+
+func TestUseCase_Login_FirstSignInIsNotNew(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	mail := &mockEmailNotifier{}
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{Users: newMemoryUserRepo(user), Notifier: mail})
+
+	result, err := uc.Login(context.Background(), auth.LoginInput{
+		Email:    user.Email,
+		Password: "SecureP@ss123",
+		Client:   auth.ClientInfo{IPAddress: "203.0.113.9", UserAgent: safariOnIPhone},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	assert.Empty(t, mail.sent())
+}
+
+func TestUseCase_Login_HighRiskStepUp(t *testing.T) {
+	t.Parallel()
+
+	f := newLoginRiskFixture(t, false)
+	ctx := context.Background()
+	client := auth.ClientInfo{IPAddress: "203.0.113.9", UserAgent: safariOnIPhone}
+
+	result := f.login(t, client)
+	require.Nil(t, result.Tokens)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeMFARequired, result.Challenge.Type)
+	assert.Equal(t, []string{"email"}, result.Challenge.AvailableMethods)
+
+	suspicious := f.eventsOfType(auth.EventSuspiciousActivity)
+	require.Len(t, suspicious, 1)
+	assert.Equal(t, auth.RiskHigh, suspicious[0].RiskLevel)
+	assert.Equal(t, "email_code_required", suspicious[0].Details["action"])
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, "Confirm your sign-in", mail[0].Subject)
+	assert.Contains(t, mail[0].Body, "Lagos, Nigeria")
+
+	code := regexp.MustCompile(`\d{6}`).FindString(mail[0].Body)
+	require.NotEmpty(t, code)
+
+	err := f.uc.SendMFACode(ctx, result.Challenge.Token, "")
+	requireAppError(t, err, apperror.KindRateLimited, "RATE_LIMITED")
+
+	_, err = f.uc.CompleteMFAChallenge(ctx, auth.MFAChallengeInput{
+		ChallengeToken: result.Challenge.Token, Code: "000000", Client: client,
+	})
+	requireAppError(t, err, apperror.KindValidation, "MFA_INVALID_CODE")
+
+	signedIn, err := f.uc.CompleteMFAChallenge(ctx, auth.MFAChallengeInput{
+		ChallengeToken: result.Challenge.Token, Code: code, Client: client,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, signedIn.Tokens)
+
+	newDevice := f.eventsOfType(auth.EventNewDeviceLogin)
+	require.Len(t, newDevice, 1)
+	assert.Equal(t, auth.RiskHigh, newDevice[0].RiskLevel)
+	assert.Equal(t, []string{"new_device", "new_ip", "new_country"}, newDevice[0].Details["reasons"])
+	assert.Len(t, f.eventsOfType(auth.EventSuspiciousActivity), 2)
+	assert.Len(t, f.mail.sent(), 2)
+	assert.Len(t, f.push.sent(), 1)
+}
+
+func TestUseCase_Login_HighRiskWithMFA(t *testing.T) {
+	t.Parallel()
+
+	f := newLoginRiskFixture(t, true)
+
+	// Users with MFA get their usual challenge, not an emailed code.
+	result := f.login(t, auth.ClientInfo{IPAddress: "203.0.113.9", UserAgent: safariOnIPhone})
+	require.NotNil(t, result.Challenge)
+	assert.NotContains(t, result.Challenge.AvailableMethods, "email")
+	assert.Empty(t, f.mail.sent())
+}
@@ -0,0 +1,457 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/notify"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+)
+
+const (
+	magicLinkPath = "/magic-link"
+
+	magicLinkWindow = time.Hour
+)
+
+// SendMagicLink starts a passwordless sign-in for an email address or phone
+// number, delivering a link and a one-time code over the chosen method. A
+// session is issued whether or not an account matches, and nothing is sent
+// when none does, so the response never reveals which identifiers are
+// registered. With sign-up enabled, an unknown email gets a link that
+// creates the account.
+func (uc *UseCase) SendMagicLink(ctx context.Context, in auth.MagicLinkSendInput) (*auth.MagicLinkSent, error) {
+	identifier, identifierType, err := normalizeIdentifier(in.Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	method := in.Method
+	if method == "" {
+		method = auth.MagicLinkEmail
+		if identifierType == auth.IdentifierPhone {
+			method = auth.MagicLinkSMS
+		}
+	}
+
+	now := uc.now().UTC()
+
+	recent, err := uc.magicLinks.CountSince(ctx, identifier, identifierType, now.Add(-magicLinkWindow))
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - SendMagicLink - uc.magicLinks.CountSince: %w", err)
+	}
+
+	if recent >= uc.cfg.MagicLinkMaxPerHour {
+		return nil, apperror.RateLimited("Too many sign-in links requested", apperror.WithRetryAfter(magicLinkWindow))
+	}
+
+	user, err := uc.magicLinkAccount(ctx, identifier, identifierType)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &auth.MagicLinkSession{
+		Identifier:     identifier,
+		IdentifierType: identifierType,
+		Method:         method,
+		SentAt:         now,
+		ExpiresAt:      now.Add(uc.cfg.MagicLinkTTL),
+		IPAddress:      optional(in.Client.IPAddress),
+		UserAgent:      optional(in.Client.UserAgent),
+	}
+
+	if user != nil {
+		s.UserID = &user.ID
+	}
+
+	if err = uc.issueMagicLink(ctx, s, user, in.Client, false); err != nil {
+		return nil, err
+	}
+
+	return magicLinkSent(s), nil
+}
+
+// ResendMagicLink sends a pending sign-in again with a fresh link and code,
+// optionally over another method. The earlier ones stop working and the
+// session keeps its original expiry.
+func (uc *UseCase) ResendMagicLink(ctx context.Context, in auth.MagicLinkResendInput) (*auth.MagicLinkSent, error) {
+	s, err := uc.pendingMagicLink(ctx, in.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wait := s.SentAt.Add(uc.cfg.MagicLinkResendCooldown).Sub(uc.now()); wait > 0 {
+		return nil, apperror.RateLimited("Please wait before requesting another sign-in link", apperror.WithRetryAfter(wait))
+	}
+
+	if in.Method != "" {
+		s.Method = in.Method
+	}
+
+	var user *auth.User
+
+	if s.UserID != nil {
+		user, err = uc.users.GetByID(ctx, *s.UserID)
+		if err != nil && !errors.Is(err, auth.ErrUserNotFound) {
+			return nil, fmt.Errorf("UseCase - ResendMagicLink - uc.users.GetByID: %w", err)
+		}
+	}
+
+	s.SentAt = uc.now().UTC()
+
+	if err = uc.issueMagicLink(ctx, s, user, in.Client, true); err != nil {
+		return nil, err
+	}
+
+	return magicLinkSent(s), nil
+}
+
+// VerifyMagicLink completes a passwordless sign-in with the link's token or
+// the one-time code. The code stops working after too many wrong guesses.
+// Where configured, only the browser that asked for the link may use it.
+// Users with MFA get a challenge, as with a password login.
+func (uc *UseCase) VerifyMagicLink(ctx context.Context, in auth.MagicLinkVerifyInput) (*auth.AuthResult, error) {
+	s, err := uc.pendingMagicLink(ctx, in.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Attempts >= uc.cfg.MagicLinkMaxAttempts {
+		return nil, errMagicLinkInvalid()
+	}
+
+	if uc.cfg.MagicLinkBindClient && !s.SameClient(in.Client) {
+		return nil, uc.magicLinkRejected(ctx, s, "client_mismatch", in.Client)
+	}
+
+	if !magicLinkMatches(s, in.Token, in.Code) {
+		return nil, uc.magicLinkRejected(ctx, s, "invalid_code", in.Client)
+	}
+
+	now := uc.now().UTC()
+
+	if err = uc.magicLinks.Consume(ctx, s, now); err != nil {
+		if errors.Is(err, auth.ErrMagicLinkUsed) {
+			return nil, errMagicLinkInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - VerifyMagicLink - uc.magicLinks.Consume: %w", err)
+	}
+
+	user, err := uc.magicLinkUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.signInBlocked(user) {
+		return nil, apperror.Forbidden("Account has been disabled", apperror.WithCode(codeAccountDisabled))
+	}
+
+	if locked := uc.lockedOut(ctx, user, in.Client); locked != nil {
+		return locked, nil
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventMagicLinkUsed,
+		Success:   true,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+		Details:   map[string]any{"method": string(s.Method)},
+	})
+
+	mfa, err := uc.MFAStatus(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfa.Enabled() {
+		return uc.startMFAChallenge(ctx, user, mfa.Methods(), auth.LoginInput{RememberMe: in.RememberMe, Client: in.Client})
+	}
+
+	return uc.finishLogin(ctx, user, in.RememberMe, in.Client)
+}
+
+// pendingMagicLink looks up a session that can still be used.
+func (uc *UseCase) pendingMagicLink(ctx context.Context, id uuid.UUID) (*auth.MagicLinkSession, error) {
+	s, err := uc.magicLinks.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, auth.ErrMagicLinkNotFound) {
+			return nil, errMagicLinkInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - pendingMagicLink - uc.magicLinks.GetByID: %w", err)
+	}
+
+	if s.VerifiedAt != nil {
+		return nil, errMagicLinkInvalid()
+	}
+
+	if s.IsExpired(uc.now()) {
+		return nil, apperror.Unauthorized("Magic link has expired", apperror.WithCode(codeTokenExpired))
+	}
+
+	return s, nil
+}
+
+// magicLinkAccount finds the account an identifier signs in to. Phone
+// numbers only count once verified; nil means there is none.
+func (uc *UseCase) magicLinkAccount(ctx context.Context, identifier string, identifierType auth.IdentifierType) (*auth.User, error) {
+	if identifierType == auth.IdentifierEmail {
+		user, err := uc.users.GetByEmail(ctx, identifier)
+		if err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) {
+				return nil, nil //nolint:nilnil // no account is a valid outcome here
+			}
+
+			return nil, fmt.Errorf("UseCase - magicLinkAccount - uc.users.GetByEmail: %w", err)
+		}
+
+		return user, nil
+	}
+
+	user, err := uc.users.GetByPhone(ctx, identifier)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, nil //nolint:nilnil // no account is a valid outcome here
+		}
+
+		return nil, fmt.Errorf("UseCase - magicLinkAccount - uc.users.GetByPhone: %w", err)
+	}
+
+	if !user.PhoneVerified {
+		return nil, nil //nolint:nilnil // an unverified number does not identify the account
+	}
+
+	return user, nil
+}
+
+// issueMagicLink gives the session a new token and code, saves it and sends
+// both to wherever the method reaches the user.
+func (uc *UseCase) issueMagicLink(ctx context.Context, s *auth.MagicLinkSession, user *auth.User, client auth.ClientInfo, resend bool) error {
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return fmt.Errorf("UseCase - issueMagicLink - token.Generate: %w", err)
+	}
+
+	code, err := token.NumericCode(smsCodeDigits)
+	if err != nil {
+		return fmt.Errorf("UseCase - issueMagicLink - token.NumericCode: %w", err)
+	}
+
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	otpHash := magicLinkCodeHash(s.ID, code)
+	s.TokenHash = token.Hash(raw)
+	s.OTPHash = &otpHash
+
+	if resend {
+		if err = uc.magicLinks.Reissue(ctx, s); err != nil {
+			if errors.Is(err, auth.ErrMagicLinkUsed) {
+				return errMagicLinkInvalid()
+			}
+
+			return fmt.Errorf("UseCase - issueMagicLink - uc.magicLinks.Reissue: %w", err)
+		}
+	} else if err = uc.magicLinks.Store(ctx, s); err != nil {
+		return fmt.Errorf("UseCase - issueMagicLink - uc.magicLinks.Store: %w", err)
+	}
+
+	to := uc.magicLinkDestination(s, user)
+	if to == "" {
+		return nil
+	}
+
+	if user == nil {
+		user = &auth.User{Email: to}
+	}
+
+	link := uc.link(magicLinkPath, raw) + "&session_id=" + s.ID.String()
+
+	// As with password resets, a failed delivery must not tell the caller
+	// the account exists; it is recorded in the delivery log.
+	if s.Method == auth.MagicLinkSMS {
+		_ = uc.sms.SendSMS(ctx, &notification.SMSMessage{
+			UserID: user.ID,
+			To:     to,
+			Body: fmt.Sprintf("Your sign-in code is %s, or open %s. It expires in %s.",
+				code, link, humanDuration(s.ExpiresAt.Sub(s.SentAt))),
+			Transactional: true,
+		})
+	} else {
+		_ = uc.sendEmail(ctx, user, to, magicLinkTemplate, emailData{
+			Link:      link,
+			Code:      code,
+			ExpiresIn: humanDuration(s.ExpiresAt.Sub(s.SentAt)),
+		})
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    s.UserID,
+		Type:      auth.EventMagicLinkSent,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(s.Method), "resend": resend},
+	})
+
+	return nil
+}
+
+// magicLinkDestination is the address or number a session is delivered to,
+// or "" when it cannot be: the account is closed, has no verified phone for
+// SMS, or does not exist and sign-up by email is off.
+func (uc *UseCase) magicLinkDestination(s *auth.MagicLinkSession, user *auth.User) string {
+	if user == nil {
+		if uc.cfg.MagicLinkSignUp && s.IdentifierType == auth.IdentifierEmail && s.Method == auth.MagicLinkEmail {
+			return s.Identifier
+		}
+
+		return ""
+	}
+
+	if uc.signInBlocked(user) {
+		return ""
+	}
+
+	if s.Method == auth.MagicLinkEmail {
+		return user.Email
+	}
+
+	if user.PhoneVerified && user.PhoneNumber != nil {
+		return *user.PhoneNumber
+	}
+
+	return ""
+}
+
+// magicLinkUser returns the account a verified session signs in to, creating
+// it when the session was a sign-up by email.
+func (uc *UseCase) magicLinkUser(ctx context.Context, s *auth.MagicLinkSession) (*auth.User, error) {
+	if s.UserID != nil {
+		user, err := uc.users.GetByID(ctx, *s.UserID)
+		if err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) {
+				return nil, errMagicLinkInvalid()
+			}
+
+			return nil, fmt.Errorf("UseCase - magicLinkUser - uc.users.GetByID: %w", err)
+		}
+
+		return user, nil
+	}
+
+	if uc.magicLinkDestination(s, nil) == "" {
+		return nil, errMagicLinkInvalid()
+	}
+
+	// Sending is refused for nobody, so as not to reveal which addresses are
+	// registered; a disposable address is turned away when it would sign up.
+	if uc.isDisposable(s.Identifier) {
+		return nil, errDisposableEmail("identifier")
+	}
+
+	now := uc.now().UTC()
+	user := &auth.User{
+		Email:           s.Identifier,
+		Status:          auth.StatusActive,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+
+	err := uc.users.Create(ctx, user)
+	if err == nil {
+		return user, nil
+	}
+
+	if !errors.Is(err, auth.ErrEmailAlreadyExists) {
+		return nil, fmt.Errorf("UseCase - magicLinkUser - uc.users.Create: %w", err)
+	}
+
+	// The account was registered after the link was sent; the link still
+	// proves the address belongs to whoever opened it.
+	user, err = uc.users.GetByEmail(ctx, s.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - magicLinkUser - uc.users.GetByEmail: %w", err)
+	}
+
+	return user, nil
+}
+
+// magicLinkRejected counts a failed attempt against the session and records it.
+func (uc *UseCase) magicLinkRejected(ctx context.Context, s *auth.MagicLinkSession, reason string, client auth.ClientInfo) error {
+	if _, err := uc.magicLinks.RecordFailure(ctx, s.ID); err != nil && !errors.Is(err, auth.ErrMagicLinkNotFound) {
+		return fmt.Errorf("UseCase - magicLinkRejected - uc.magicLinks.RecordFailure: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    s.UserID,
+		Type:      auth.EventMagicLinkUsed,
+		Success:   false,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"reason": reason},
+	})
+
+	return errMagicLinkInvalid()
+}
+
+func magicLinkMatches(s *auth.MagicLinkSession, rawToken, code string) bool {
+	switch {
+	case rawToken != "":
+		return subtle.ConstantTimeCompare([]byte(token.Hash(rawToken)), []byte(s.TokenHash)) == 1
+	case code != "" && s.OTPHash != nil:
+		return subtle.ConstantTimeCompare([]byte(magicLinkCodeHash(s.ID, code)), []byte(*s.OTPHash)) == 1
+	default:
+		return false
+	}
+}
+
+func magicLinkSent(s *auth.MagicLinkSession) *auth.MagicLinkSent {
+	sent := &auth.MagicLinkSent{SessionID: s.ID, Method: s.Method, ExpiresAt: s.ExpiresAt}
+
+	if (s.IdentifierType == auth.IdentifierEmail) == (s.Method == auth.MagicLinkEmail) {
+		sent.Destination = s.Identifier
+	}
+
+	return sent
+}
+
+// magicLinkCodeHash binds the hash to the session so equal codes never share
+// a stored value.
+func magicLinkCodeHash(sessionID uuid.UUID, code string) string {
+	return token.Hash(sessionID.String() + ":" + code)
+}
+
+// normalizeIdentifier tells an email address from an E.164 phone number.
+func normalizeIdentifier(raw string) (string, auth.IdentifierType, error) {
+	raw = strings.TrimSpace(raw)
+
+	if strings.Contains(raw, "@") {
+		email, err := normalizeEmail(raw)
+		if err != nil {
+			return "", "", apperror.Validation("Invalid email address",
+				apperror.WithField("identifier", "must be a valid email address"))
+		}
+
+		return email, auth.IdentifierEmail, nil
+	}
+
+	if err := notify.ValidateE164(raw); err != nil {
+		return "", "", apperror.Validation("Invalid identifier",
+			apperror.WithField("identifier", "must be an email address or a phone number in E.164 format"))
+	}
+
+	return raw, auth.IdentifierPhone, nil
+}
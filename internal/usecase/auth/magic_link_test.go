@@ -0,0 +1,376 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type magicLinkFixture struct {
+	uc       *authuc.UseCase
+	users    *memoryUserRepo
+	sessions *memoryMagicLinkRepo
+	mail     *mockEmailNotifier
+	sms      *mockSMSNotifier
+	events   *mockSecurityEventRepo
+}
+
+var magicLinkClient = auth.ClientInfo{IPAddress: "203.0.113.7", UserAgent: "Mozilla/5.0"}
+
+func newMagicLinkFixture(t *testing.T, users ...*auth.User) *magicLinkFixture {
+	t.Helper()
+
+	f := &magicLinkFixture{
+		users:  newMemoryUserRepo(users...),
+		mail:   &mockEmailNotifier{},
+		sms:    &mockSMSNotifier{},
+		events: &mockSecurityEventRepo{},
+	}
+	f.sessions = newMemoryMagicLinkRepo(f.users)
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:          f.users,
+		MagicLinks:     f.sessions,
+		Notifier:       f.mail,
+		SMS:            f.sms,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+func (f *magicLinkFixture) eventTypes() []auth.SecurityEventType {
+	var types []auth.SecurityEventType
+	for _, e := range f.events.stored() {
+		types = append(types, e.Type)
+	}
+
+	return types
+}
+
+func TestUseCase_MagicLink_Email(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusPendingVerification)
+	f := newMagicLinkFixture(t, user)
+	ctx := context.Background()
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: " User@Example.com ", Client: magicLinkClient})
+	require.NoError(t, err)
+	assert.Equal(t, auth.MagicLinkEmail, sent.Method)
+	assert.Equal(t, user.Email, sent.Destination)
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, []string{user.Email}, mail[0].To)
+	assert.Contains(t, mail[0].Body, "session_id="+sent.SessionID.String())
+
+	result, err := f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     linkToken(t, mail[0].Body),
+		Client:    magicLinkClient,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	assert.Equal(t, user.ID, result.User.ID)
+	assert.True(t, result.User.EmailVerified, "an email link proves the address")
+	assert.Equal(t, auth.StatusActive, result.User.Status)
+	assert.Equal(t, []auth.SecurityEventType{auth.EventMagicLinkSent, auth.EventMagicLinkUsed, auth.EventLoginSuccess}, f.eventTypes())
+
+	_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     linkToken(t, mail[0].Body),
+		Client:    magicLinkClient,
+	})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+}
+
+func TestUseCase_MagicLink_SMSCode(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	phone := testPhone
+	user.PhoneNumber = &phone
+	user.PhoneVerified = true
+
+	f := newMagicLinkFixture(t, user)
+	ctx := context.Background()
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: testPhone, Client: magicLinkClient})
+	require.NoError(t, err)
+	assert.Equal(t, auth.MagicLinkSMS, sent.Method)
+	assert.Equal(t, testPhone, sent.Destination)
+	assert.Empty(t, f.mail.sent())
+
+	result, err := f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Code:      f.sms.lastCode(t),
+		Client:    magicLinkClient,
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, result.Tokens)
+}
+
+func TestUseCase_MagicLink_AttemptLimit(t *testing.T) {
+	t.Parallel()
+
+	f := newMagicLinkFixture(t, existingUser(auth.StatusActive))
+	ctx := context.Background()
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "user@example.com", Client: magicLinkClient})
+	require.NoError(t, err)
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+
+	for range 3 {
+		_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{SessionID: sent.SessionID, Code: "000000", Client: magicLinkClient})
+		requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+	}
+
+	// Once the attempts are spent, even the right token is refused.
+	_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     linkToken(t, mail[0].Body),
+		Client:    magicLinkClient,
+	})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	failed := 0
+
+	for _, e := range f.events.stored() {
+		if e.Type == auth.EventMagicLinkUsed && !e.Success {
+			failed++
+		}
+	}
+
+	assert.Equal(t, 3, failed)
+}
+
+func TestUseCase_MagicLink_BoundToClient(t *testing.T) {
+	t.Parallel()
+
+	f := newMagicLinkFixture(t, existingUser(auth.StatusActive))
+	ctx := context.Background()
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "user@example.com", Client: magicLinkClient})
+	require.NoError(t, err)
+
+	raw := linkToken(t, f.mail.sent()[0].Body)
+
+	_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     raw,
+		Client:    auth.ClientInfo{IPAddress: "198.51.100.1", UserAgent: magicLinkClient.UserAgent},
+	})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{SessionID: sent.SessionID, Token: raw, Client: magicLinkClient})
+	require.NoError(t, err)
+}
+
+func TestUseCase_MagicLink_SignUp(t *testing.T) {
+	t.Parallel()
+
+	f := newMagicLinkFixture(t)
+	ctx := context.Background()
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "new@example.com", Client: magicLinkClient})
+	require.NoError(t, err)
+
+	mail := f.mail.sent()
+	require.Len(t, mail, 1)
+	assert.Equal(t, []string{"new@example.com"}, mail[0].To)
+
+	result, err := f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     linkToken(t, mail[0].Body),
+		Client:    magicLinkClient,
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, result.Tokens)
+	assert.Equal(t, "new@example.com", result.User.Email)
+	assert.True(t, result.User.EmailVerified)
+	assert.Nil(t, result.User.PasswordHash)
+
+	stored, err := f.users.GetByEmail(ctx, "new@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, result.User.ID, stored.ID)
+}
+
+func TestUseCase_MagicLink_Locked(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	user.LockedUntil = &lockedUntil
+
+	f := newMagicLinkFixture(t, user)
+	ctx := context.Background()
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: user.Email, Client: magicLinkClient})
+	require.NoError(t, err)
+
+	result, err := f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     linkToken(t, f.mail.sent()[0].Body),
+		Client:    magicLinkClient,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeAccountLocked, result.Challenge.Type)
+	assert.Nil(t, result.Tokens)
+	assert.Equal(t, &lockedUntil, user.LockedUntil, "the lockout stands")
+}
+
+func TestUseCase_MagicLink_SignUpDisposable(t *testing.T) {
+	t.Parallel()
+
+	existing := existingUser(auth.StatusActive)
+	existing.Email = "old@mailinator.com"
+
+	f := newMagicLinkFixture(t, existing)
+	ctx := context.Background()
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "new@mx.trashmail.com", Client: magicLinkClient})
+	require.NoError(t, err, "sending looks the same as for a registered address")
+	require.Len(t, f.mail.sent(), 1)
+
+	_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     linkToken(t, f.mail.sent()[0].Body),
+		Client:    magicLinkClient,
+	})
+	requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+
+	_, err = f.users.GetByEmail(ctx, "new@mx.trashmail.com")
+	require.ErrorIs(t, err, auth.ErrUserNotFound, "no account is created")
+
+	sent, err = f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "old@mailinator.com", Client: magicLinkClient})
+	require.NoError(t, err)
+	require.Len(t, f.mail.sent(), 2)
+
+	result, err := f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     linkToken(t, f.mail.sent()[1].Body),
+		Client:    magicLinkClient,
+	})
+	require.NoError(t, err, "existing accounts can still sign in")
+	assert.Equal(t, existing.ID, result.User.ID)
+}
+
+func TestUseCase_MagicLink_UnknownPhone(t *testing.T) {
+	t.Parallel()
+
+	f := newMagicLinkFixture(t)
+	ctx := context.Background()
+
+	// Sign-up only covers email, so nothing is sent, but the response looks
+	// the same as for a registered number.
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: testPhone, Client: magicLinkClient})
+	require.NoError(t, err)
+	assert.Equal(t, testPhone, sent.Destination)
+	assert.Empty(t, f.sms.sent())
+
+	_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{SessionID: sent.SessionID, Code: "123456", Client: magicLinkClient})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	_, err = f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "not-an-identifier"})
+	requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+}
+
+func TestUseCase_MagicLink_RateLimited(t *testing.T) {
+	t.Parallel()
+
+	f := newMagicLinkFixture(t, existingUser(auth.StatusActive))
+	ctx := context.Background()
+
+	for range 3 {
+		_, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "user@example.com", Client: magicLinkClient})
+		require.NoError(t, err)
+	}
+
+	_, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "user@example.com", Client: magicLinkClient})
+	require.Error(t, err)
+	assert.True(t, apperror.IsRateLimited(err))
+	assert.Len(t, f.mail.sent(), 3)
+}
+
+func TestUseCase_ResendMagicLink(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	phone := testPhone
+	user.PhoneNumber = &phone
+	user.PhoneVerified = true
+
+	f := newMagicLinkFixture(t, user)
+	ctx := context.Background()
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "user@example.com", Client: magicLinkClient})
+	require.NoError(t, err)
+
+	first := linkToken(t, f.mail.sent()[0].Body)
+
+	_, err = f.uc.ResendMagicLink(ctx, auth.MagicLinkResendInput{SessionID: sent.SessionID, Method: auth.MagicLinkSMS})
+	require.Error(t, err)
+	assert.True(t, apperror.IsRateLimited(err))
+
+	f.sessions.backdate(2 * time.Minute)
+
+	resent, err := f.uc.ResendMagicLink(ctx, auth.MagicLinkResendInput{SessionID: sent.SessionID, Method: auth.MagicLinkSMS})
+	require.NoError(t, err)
+	assert.Equal(t, sent.SessionID, resent.SessionID)
+	assert.Equal(t, auth.MagicLinkSMS, resent.Method)
+	assert.Empty(t, resent.Destination, "the number is not shown to someone who only knows the email")
+
+	// The first link was replaced by the new code.
+	_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{SessionID: sent.SessionID, Token: first, Client: magicLinkClient})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	_, err = f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Code:      f.sms.lastCode(t),
+		Client:    magicLinkClient,
+	})
+	require.NoError(t, err)
+}
+
+func TestUseCase_MagicLink_MFA(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	f := newMagicLinkFixture(t, user)
+	ctx := context.Background()
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:      f.users,
+		MagicLinks: f.sessions,
+		Notifier:   f.mail,
+		SMSFactors: newMemorySMSFactorRepo(func() *auth.SMSFactor {
+			factor := verifiedSMSFactor()
+			factor.UserID = user.ID
+
+			return factor
+		}()),
+	})
+
+	sent, err := f.uc.SendMagicLink(ctx, auth.MagicLinkSendInput{Identifier: "user@example.com", Client: magicLinkClient})
+	require.NoError(t, err)
+
+	result, err := f.uc.VerifyMagicLink(ctx, auth.MagicLinkVerifyInput{
+		SessionID: sent.SessionID,
+		Token:     linkToken(t, f.mail.sent()[0].Body),
+		Client:    magicLinkClient,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, result.Tokens)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeMFARequired, result.Challenge.Type)
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+)
+
+// MFAStatus reports which second factors the user has enabled.
+func (uc *UseCase) MFAStatus(ctx context.Context, userID uuid.UUID) (*auth.MFAStatus, error) {
+	status := &auth.MFAStatus{}
+
+	t, err := uc.totp.GetByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, auth.ErrTOTPNotFound) {
+		return nil, fmt.Errorf("UseCase - MFAStatus - uc.totp.GetByUserID: %w", err)
+	}
+
+	status.TOTPEnabled = t != nil && t.IsVerified()
+
+	f, err := uc.smsFactors.GetByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, auth.ErrSMSFactorNotFound) {
+		return nil, fmt.Errorf("UseCase - MFAStatus - uc.smsFactors.GetByUserID: %w", err)
+	}
+
+	if f != nil && f.IsVerified() {
+		status.SMSEnabled = true
+		status.PhoneNumber = f.PhoneNumber
+	}
+
+	passkeys, err := uc.passkeys.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - MFAStatus - uc.passkeys.ListByUserID: %w", err)
+	}
+
+	status.Passkeys = len(passkeys)
+
+	if status.Enabled() {
+		codes, err := uc.recoveryCodes.Status(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("UseCase - MFAStatus - uc.recoveryCodes.Status: %w", err)
+		}
+
+		status.RecoveryCodesRemaining = codes.Remaining
+	}
+
+	return status, nil
+}
+
+// reauthenticate loads the user and checks their password before a sensitive
+// change such as removing a second factor. Wrong passwords count towards the
+// same lockout as failed sign-ins, and a locked account is refused outright.
+func (uc *UseCase) reauthenticate(ctx context.Context, userID uuid.UUID, pw string, client auth.ClientInfo) (*auth.User, error) {
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, apperror.NotFound("User not found", apperror.WithCause(err))
+		}
+
+		return nil, fmt.Errorf("UseCase - reauthenticate - uc.users.GetByID: %w", err)
+	}
+
+	if !user.HasPassword() {
+		return nil, apperror.Validation("Account has no password; use password reset to set one",
+			apperror.WithCode(codePasswordIncorrect))
+	}
+
+	now := uc.now().UTC()
+
+	if user.IsLocked(now) {
+		return nil, errAccountLocked(user.LockedUntil.Sub(now))
+	}
+
+	ok, err := uc.hasher.Verify(pw, *user.PasswordHash)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - reauthenticate - uc.hasher.Verify: %w", err)
+	}
+
+	if !ok {
+		locked, err := uc.countPasswordFailure(ctx, user, "reauthentication", client)
+		if err != nil {
+			return nil, err
+		}
+
+		if locked {
+			return nil, errAccountLocked(user.LockedUntil.Sub(now))
+		}
+
+		return nil, apperror.Validation("Password is incorrect",
+			apperror.WithCode(codePasswordIncorrect),
+			apperror.WithField("password", "is incorrect"),
+		)
+	}
+
+	return user, nil
+}
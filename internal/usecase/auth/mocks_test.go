@@ -0,0 +1,2308 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/event"
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/encryption"
+	"github.com/evrone/go-clean-template/pkg/geoip"
+	"github.com/evrone/go-clean-template/pkg/oauth"
+	"github.com/evrone/go-clean-template/pkg/storage"
+	"github.com/evrone/go-clean-template/pkg/webauthn"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var errRepo = errors.New("repository error")
+
+type mockUserRepo struct {
+	createFunc          func(ctx context.Context, u *auth.User) error
+	getByIDFunc         func(ctx context.Context, id uuid.UUID) (*auth.User, error)
+	getByEmailFunc      func(ctx context.Context, email string) (*auth.User, error)
+	getByPhoneFunc      func(ctx context.Context, phone string) (*auth.User, error)
+	updateLastLoginFunc func(ctx context.Context, id uuid.UUID, at time.Time, ip string) error
+	updatePasswordFunc  func(ctx context.Context, id uuid.UUID, passwordHash string) error
+	loginFailureFunc    func(ctx context.Context, id uuid.UUID, at time.Time, policy auth.LockoutPolicy) (*auth.LoginFailures, bool, error)
+}
+
+func (m *mockUserRepo) Create(ctx context.Context, u *auth.User) error {
+	if m.createFunc != nil {
+		return m.createFunc(ctx, u)
+	}
+
+	u.ID = uuid.New()
+
+	return nil
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*auth.User, error) {
+	if m.getByIDFunc != nil {
+		return m.getByIDFunc(ctx, id)
+	}
+
+	return nil, auth.ErrUserNotFound
+}
+
+func (m *mockUserRepo) GetByEmail(ctx context.Context, email string) (*auth.User, error) {
+	if m.getByEmailFunc != nil {
+		return m.getByEmailFunc(ctx, email)
+	}
+
+	return nil, auth.ErrUserNotFound
+}
+
+func (m *mockUserRepo) GetByPhone(ctx context.Context, phone string) (*auth.User, error) {
+	if m.getByPhoneFunc != nil {
+		return m.getByPhoneFunc(ctx, phone)
+	}
+
+	return nil, auth.ErrUserNotFound
+}
+
+func (m *mockUserRepo) UpdateLastLogin(ctx context.Context, id uuid.UUID, at time.Time, ip string) error {
+	if m.updateLastLoginFunc != nil {
+		return m.updateLastLoginFunc(ctx, id, at, ip)
+	}
+
+	return nil
+}
+
+func (m *mockUserRepo) RecordLoginFailure(ctx context.Context, id uuid.UUID, at time.Time, policy auth.LockoutPolicy) (*auth.LoginFailures, bool, error) {
+	if m.loginFailureFunc != nil {
+		return m.loginFailureFunc(ctx, id, at, policy)
+	}
+
+	return &auth.LoginFailures{Attempts: 1, LastFailure: &at}, false, nil
+}
+
+func (m *mockUserRepo) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	if m.updatePasswordFunc != nil {
+		return m.updatePasswordFunc(ctx, id, passwordHash)
+	}
+
+	return nil
+}
+
+func (m *mockUserRepo) UpdateProfile(context.Context, *auth.User) error {
+	return nil
+}
+
+type mockRefreshTokenRepo struct {
+	storeFunc            func(ctx context.Context, t *auth.RefreshToken) error
+	getByHashFunc        func(ctx context.Context, hash string) (*auth.RefreshToken, error)
+	rotateFunc           func(ctx context.Context, currentID uuid.UUID, next *auth.RefreshToken) error
+	familyActiveFunc     func(ctx context.Context, userID, familyID uuid.UUID) (bool, error)
+	revokeFamilyFunc     func(ctx context.Context, userID, familyID uuid.UUID) error
+	revokeAllForUserFunc func(ctx context.Context, userID uuid.UUID) error
+	listSessionsFunc     func(ctx context.Context, userID uuid.UUID) ([]auth.Session, error)
+	revokeOtherFunc      func(ctx context.Context, userID, keepFamilyID uuid.UUID) (int64, error)
+}
+
+func (m *mockRefreshTokenRepo) Store(ctx context.Context, t *auth.RefreshToken) error {
+	if m.storeFunc != nil {
+		return m.storeFunc(ctx, t)
+	}
+
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) GetByHash(ctx context.Context, hash string) (*auth.RefreshToken, error) {
+	if m.getByHashFunc != nil {
+		return m.getByHashFunc(ctx, hash)
+	}
+
+	return nil, auth.ErrRefreshTokenNotFound
+}
+
+func (m *mockRefreshTokenRepo) Rotate(ctx context.Context, currentID uuid.UUID, next *auth.RefreshToken) error {
+	if m.rotateFunc != nil {
+		return m.rotateFunc(ctx, currentID, next)
+	}
+
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) FamilyActive(ctx context.Context, userID, familyID uuid.UUID) (bool, error) {
+	if m.familyActiveFunc != nil {
+		return m.familyActiveFunc(ctx, userID, familyID)
+	}
+
+	return true, nil
+}
+
+func (m *mockRefreshTokenRepo) RevokeFamily(ctx context.Context, userID, familyID uuid.UUID) error {
+	if m.revokeFamilyFunc != nil {
+		return m.revokeFamilyFunc(ctx, userID, familyID)
+	}
+
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	if m.revokeAllForUserFunc != nil {
+		return m.revokeAllForUserFunc(ctx, userID)
+	}
+
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) ListSessions(ctx context.Context, userID uuid.UUID) ([]auth.Session, error) {
+	if m.listSessionsFunc != nil {
+		return m.listSessionsFunc(ctx, userID)
+	}
+
+	return nil, nil
+}
+
+func (m *mockRefreshTokenRepo) RevokeOtherFamilies(ctx context.Context, userID, keepFamilyID uuid.UUID) (int64, error) {
+	if m.revokeOtherFunc != nil {
+		return m.revokeOtherFunc(ctx, userID, keepFamilyID)
+	}
+
+	return 0, nil
+}
+
+type mockEmailVerificationRepo struct {
+	storeFunc      func(ctx context.Context, v *auth.EmailVerification) error
+	getByHashFunc  func(ctx context.Context, hash string) (*auth.EmailVerification, error)
+	countSinceFunc func(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	confirmFunc    func(ctx context.Context, v *auth.EmailVerification, at time.Time) error
+}
+
+func (m *mockEmailVerificationRepo) Store(ctx context.Context, v *auth.EmailVerification) error {
+	if m.storeFunc != nil {
+		return m.storeFunc(ctx, v)
+	}
+
+	return nil
+}
+
+func (m *mockEmailVerificationRepo) GetByHash(ctx context.Context, hash string) (*auth.EmailVerification, error) {
+	if m.getByHashFunc != nil {
+		return m.getByHashFunc(ctx, hash)
+	}
+
+	return nil, auth.ErrVerificationNotFound
+}
+
+func (m *mockEmailVerificationRepo) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	if m.countSinceFunc != nil {
+		return m.countSinceFunc(ctx, userID, since)
+	}
+
+	return 0, nil
+}
+
+func (m *mockEmailVerificationRepo) Confirm(ctx context.Context, v *auth.EmailVerification, at time.Time) error {
+	if m.confirmFunc != nil {
+		return m.confirmFunc(ctx, v, at)
+	}
+
+	return nil
+}
+
+type mockPasswordResetRepo struct {
+	storeFunc      func(ctx context.Context, r *auth.PasswordReset) error
+	getByHashFunc  func(ctx context.Context, hash string) (*auth.PasswordReset, error)
+	countSinceFunc func(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	consumeFunc    func(ctx context.Context, r *auth.PasswordReset, passwordHash string, at time.Time) error
+}
+
+func (m *mockPasswordResetRepo) Store(ctx context.Context, r *auth.PasswordReset) error {
+	if m.storeFunc != nil {
+		return m.storeFunc(ctx, r)
+	}
+
+	return nil
+}
+
+func (m *mockPasswordResetRepo) GetByHash(ctx context.Context, hash string) (*auth.PasswordReset, error) {
+	if m.getByHashFunc != nil {
+		return m.getByHashFunc(ctx, hash)
+	}
+
+	return nil, auth.ErrPasswordResetNotFound
+}
+
+func (m *mockPasswordResetRepo) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	if m.countSinceFunc != nil {
+		return m.countSinceFunc(ctx, userID, since)
+	}
+
+	return 0, nil
+}
+
+func (m *mockPasswordResetRepo) Consume(ctx context.Context, r *auth.PasswordReset, passwordHash string, at time.Time) error {
+	if m.consumeFunc != nil {
+		return m.consumeFunc(ctx, r, passwordHash, at)
+	}
+
+	return nil
+}
+
+// memoryTOTPRepo keeps enrollments in memory and enforces the same step
+// ordering as the Postgres implementation.
+type memoryTOTPRepo struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]*auth.TOTP
+}
+
+func newMemoryTOTPRepo(entries ...*auth.TOTP) *memoryTOTPRepo {
+	m := &memoryTOTPRepo{entries: make(map[uuid.UUID]*auth.TOTP)}
+
+	for _, e := range entries {
+		m.entries[e.UserID] = e
+	}
+
+	return m
+}
+
+func (m *memoryTOTPRepo) GetByUserID(_ context.Context, userID uuid.UUID) (*auth.TOTP, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[userID]
+	if !ok {
+		return nil, auth.ErrTOTPNotFound
+	}
+
+	cp := *e
+
+	return &cp, nil
+}
+
+func (m *memoryTOTPRepo) Upsert(_ context.Context, t *auth.TOTP) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[t.UserID]; ok && e.IsVerified() {
+		return auth.ErrTOTPAlreadyEnabled
+	}
+
+	cp := *t
+	m.entries[t.UserID] = &cp
+
+	return nil
+}
+
+func (m *memoryTOTPRepo) UseStep(_ context.Context, userID uuid.UUID, step int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[userID]
+	if !ok || (e.LastUsedStep != nil && *e.LastUsedStep >= step) {
+		return auth.ErrTOTPCodeReused
+	}
+
+	e.LastUsedStep = &step
+
+	return nil
+}
+
+func (m *memoryTOTPRepo) MarkVerified(_ context.Context, userID uuid.UUID, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[userID]
+	if !ok || e.IsVerified() {
+		return auth.ErrTOTPAlreadyEnabled
+	}
+
+	e.VerifiedAt = &at
+
+	return nil
+}
+
+func (m *memoryTOTPRepo) Delete(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[userID]; !ok {
+		return auth.ErrTOTPNotFound
+	}
+
+	delete(m.entries, userID)
+
+	return nil
+}
+
+// memorySMSFactorRepo mirrors the Postgres SMS factor semantics: pending
+// numbers can be replaced and a consumed code cannot be reused.
+type memorySMSFactorRepo struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]*auth.SMSFactor
+}
+
+func newMemorySMSFactorRepo(entries ...*auth.SMSFactor) *memorySMSFactorRepo {
+	m := &memorySMSFactorRepo{entries: make(map[uuid.UUID]*auth.SMSFactor)}
+
+	for _, e := range entries {
+		m.entries[e.UserID] = e
+	}
+
+	return m
+}
+
+func (m *memorySMSFactorRepo) GetByUserID(_ context.Context, userID uuid.UUID) (*auth.SMSFactor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[userID]
+	if !ok {
+		return nil, auth.ErrSMSFactorNotFound
+	}
+
+	cp := *e
+
+	return &cp, nil
+}
+
+func (m *memorySMSFactorRepo) Upsert(_ context.Context, f *auth.SMSFactor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[f.UserID]; ok && e.IsVerified() {
+		return auth.ErrSMSFactorAlreadyEnabled
+	}
+
+	cp := *f
+	cp.ID = uuid.New()
+	cp.CodeAttempts = 0
+	m.entries[f.UserID] = &cp
+
+	return nil
+}
+
+func (m *memorySMSFactorRepo) SetCode(_ context.Context, userID uuid.UUID, hash string, expiresAt, sentAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[userID]
+	if !ok {
+		return auth.ErrSMSFactorNotFound
+	}
+
+	e.CodeHash = &hash
+	e.CodeExpiresAt = &expiresAt
+	e.CodeSentAt = &sentAt
+	e.CodeAttempts = 0
+
+	return nil
+}
+
+func (m *memorySMSFactorRepo) RecordCodeFailure(_ context.Context, userID uuid.UUID) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[userID]
+	if !ok {
+		return 0, auth.ErrSMSFactorNotFound
+	}
+
+	e.CodeAttempts++
+
+	return e.CodeAttempts, nil
+}
+
+func (m *memorySMSFactorRepo) ConsumeCode(_ context.Context, userID uuid.UUID, hash string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[userID]
+	if !ok || e.CodeHash == nil || *e.CodeHash != hash {
+		return auth.ErrSMSCodeInvalid
+	}
+
+	e.CodeHash = nil
+	e.CodeExpiresAt = nil
+
+	if e.VerifiedAt == nil {
+		e.VerifiedAt = &at
+	}
+
+	return nil
+}
+
+func (m *memorySMSFactorRepo) Delete(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[userID]; !ok {
+		return auth.ErrSMSFactorNotFound
+	}
+
+	delete(m.entries, userID)
+
+	return nil
+}
+
+// expireCode pushes the outstanding code for userID into the past.
+func (m *memorySMSFactorRepo) expireCode(userID uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	past := time.Now().Add(-time.Hour)
+	m.entries[userID].CodeExpiresAt = &past
+	m.entries[userID].CodeSentAt = &past
+}
+
+type memoryRecoveryCodeRepo struct {
+	mu    sync.Mutex
+	codes map[uuid.UUID]map[string]*time.Time
+}
+
+func newMemoryRecoveryCodeRepo() *memoryRecoveryCodeRepo {
+	return &memoryRecoveryCodeRepo{codes: make(map[uuid.UUID]map[string]*time.Time)}
+}
+
+func (m *memoryRecoveryCodeRepo) Replace(_ context.Context, userID uuid.UUID, hashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	set := make(map[string]*time.Time, len(hashes))
+	for _, h := range hashes {
+		set[h] = nil
+	}
+
+	m.codes[userID] = set
+
+	return nil
+}
+
+func (m *memoryRecoveryCodeRepo) Status(_ context.Context, userID uuid.UUID) (*auth.RecoveryCodeStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := &auth.RecoveryCodeStatus{Total: len(m.codes[userID])}
+
+	for _, usedAt := range m.codes[userID] {
+		if usedAt == nil {
+			s.Remaining++
+		}
+	}
+
+	return s, nil
+}
+
+func (m *memoryRecoveryCodeRepo) Consume(_ context.Context, userID uuid.UUID, hash string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usedAt, ok := m.codes[userID][hash]
+	if !ok || usedAt != nil {
+		return auth.ErrRecoveryCodeInvalid
+	}
+
+	m.codes[userID][hash] = &at
+
+	return nil
+}
+
+func (m *memoryRecoveryCodeRepo) DeleteByUserID(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.codes, userID)
+
+	return nil
+}
+
+// memoryUserRepo keeps users in memory, for flows that create a user and
+// read it back.
+type memoryUserRepo struct {
+	mu       sync.Mutex
+	users    map[uuid.UUID]*auth.User
+	failures map[uuid.UUID]auth.LoginFailures
+}
+
+func newMemoryUserRepo(users ...*auth.User) *memoryUserRepo {
+	m := &memoryUserRepo{users: make(map[uuid.UUID]*auth.User), failures: make(map[uuid.UUID]auth.LoginFailures)}
+	for _, u := range users {
+		m.users[u.ID] = u
+	}
+
+	return m
+}
+
+func (m *memoryUserRepo) Create(_ context.Context, u *auth.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if existing.Email == u.Email {
+			return auth.ErrEmailAlreadyExists
+		}
+	}
+
+	u.ID = uuid.New()
+	m.users[u.ID] = u
+
+	return nil
+}
+
+func (m *memoryUserRepo) GetByID(_ context.Context, id uuid.UUID) (*auth.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return nil, auth.ErrUserNotFound
+	}
+
+	return u, nil
+}
+
+func (m *memoryUserRepo) GetByEmail(_ context.Context, email string) (*auth.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+
+	return nil, auth.ErrUserNotFound
+}
+
+func (m *memoryUserRepo) GetByPhone(_ context.Context, phone string) (*auth.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.users {
+		if u.PhoneNumber != nil && *u.PhoneNumber == phone {
+			return u, nil
+		}
+	}
+
+	return nil, auth.ErrUserNotFound
+}
+
+func (m *memoryUserRepo) UpdateLastLogin(_ context.Context, id uuid.UUID, _ time.Time, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.failures, id)
+
+	if u, ok := m.users[id]; ok {
+		u.FailedLoginAttempts = 0
+		u.LockedUntil = nil
+	}
+
+	return nil
+}
+
+func (m *memoryUserRepo) RecordLoginFailure(_ context.Context, id uuid.UUID, at time.Time, policy auth.LockoutPolicy) (*auth.LoginFailures, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return nil, false, auth.ErrUserNotFound
+	}
+
+	f := m.failures[id]
+	f.LockedUntil = u.LockedUntil
+
+	f, locked := policy.Fail(f, at)
+	m.failures[id] = f
+	u.FailedLoginAttempts = f.Attempts
+	u.LockedUntil = f.LockedUntil
+
+	return &f, locked, nil
+}
+
+func (m *memoryUserRepo) UpdatePassword(_ context.Context, id uuid.UUID, passwordHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return auth.ErrUserNotFound
+	}
+
+	u.PasswordHash = &passwordHash
+
+	return nil
+}
+
+func (m *memoryUserRepo) UpdateProfile(_ context.Context, u *auth.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.users[u.ID]
+	if !ok {
+		return auth.ErrUserNotFound
+	}
+
+	stored.Name = u.Name
+	stored.AvatarURL = u.AvatarURL
+	stored.AvatarKeys = u.AvatarKeys
+
+	return nil
+}
+
+type memoryOAuthConnectionRepo struct {
+	mu          sync.Mutex
+	users       *memoryUserRepo
+	connections []*auth.OAuthConnection
+}
+
+func newMemoryOAuthConnectionRepo(users *memoryUserRepo, connections ...*auth.OAuthConnection) *memoryOAuthConnectionRepo {
+	return &memoryOAuthConnectionRepo{users: users, connections: connections}
+}
+
+func (m *memoryOAuthConnectionRepo) GetByProvider(_ context.Context, provider auth.OAuthProvider, providerUserID string) (*auth.OAuthConnection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.connections {
+		if c.Provider == provider && c.ProviderUserID == providerUserID {
+			cp := *c
+
+			return &cp, nil
+		}
+	}
+
+	return nil, auth.ErrOAuthConnectionNotFound
+}
+
+func (m *memoryOAuthConnectionRepo) ListByUserID(_ context.Context, userID uuid.UUID) ([]auth.OAuthConnection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []auth.OAuthConnection
+
+	for _, c := range m.connections {
+		if c.UserID == userID {
+			out = append(out, *c)
+		}
+	}
+
+	return out, nil
+}
+
+func (m *memoryOAuthConnectionRepo) Create(_ context.Context, c *auth.OAuthConnection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insert(c)
+}
+
+func (m *memoryOAuthConnectionRepo) CreateWithUser(ctx context.Context, u *auth.User, c *auth.OAuthConnection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.users.Create(ctx, u); err != nil {
+		return err
+	}
+
+	c.UserID = u.ID
+
+	return m.insert(c)
+}
+
+func (m *memoryOAuthConnectionRepo) UpdateTokens(_ context.Context, c *auth.OAuthConnection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.connections {
+		if existing.ID == c.ID {
+			cp := *c
+			m.connections[i] = &cp
+
+			return nil
+		}
+	}
+
+	return auth.ErrOAuthConnectionNotFound
+}
+
+func (m *memoryOAuthConnectionRepo) Delete(_ context.Context, userID uuid.UUID, provider auth.OAuthProvider) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, c := range m.connections {
+		if c.UserID == userID && c.Provider == provider {
+			m.connections = append(m.connections[:i], m.connections[i+1:]...)
+
+			return nil
+		}
+	}
+
+	return auth.ErrOAuthConnectionNotFound
+}
+
+func (m *memoryOAuthConnectionRepo) insert(c *auth.OAuthConnection) error {
+	for _, existing := range m.connections {
+		if existing.Provider == c.Provider && (existing.ProviderUserID == c.ProviderUserID || existing.UserID == c.UserID) {
+			return auth.ErrOAuthConnectionExists
+		}
+	}
+
+	c.ID = uuid.New()
+	c.ConnectedAt = time.Now()
+
+	cp := *c
+	m.connections = append(m.connections, &cp)
+
+	return nil
+}
+
+// fakeOAuthClient plays a provider that accepts goodOAuthCode, once PKCE
+// checks out, for a single identity.
+type fakeOAuthClient struct {
+	mu        sync.Mutex
+	identity  oauth.Identity
+	challenge string
+}
+
+const goodOAuthCode = "good-code"
+
+func (f *fakeOAuthClient) AuthCodeURL(state, codeChallenge, redirectURI string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.challenge = codeChallenge
+
+	return "https://idp.example.com/authorize?" + url.Values{"state": {state}, "redirect_uri": {redirectURI}}.Encode()
+}
+
+func (f *fakeOAuthClient) Exchange(_ context.Context, code, codeVerifier, _ string) (*oauth.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if code != goodOAuthCode || oauth.S256Challenge(codeVerifier) != f.challenge {
+		return nil, oauth.ErrInvalidGrant
+	}
+
+	return &oauth.Token{AccessToken: "provider-access", RefreshToken: "provider-refresh"}, nil
+}
+
+func (f *fakeOAuthClient) Identity(_ context.Context, _ *oauth.Token) (*oauth.Identity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.identity
+
+	return &id, nil
+}
+
+// memoryPasskeyRepo mirrors the Postgres passkey semantics: a credential
+// can be registered once and passkeys are only reachable by their owner.
+type memoryPasskeyRepo struct {
+	mu       sync.Mutex
+	passkeys []*auth.Passkey
+}
+
+func newMemoryPasskeyRepo(passkeys ...*auth.Passkey) *memoryPasskeyRepo {
+	return &memoryPasskeyRepo{passkeys: passkeys}
+}
+
+func (m *memoryPasskeyRepo) ListByUserID(_ context.Context, userID uuid.UUID) ([]auth.Passkey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []auth.Passkey
+
+	for _, p := range m.passkeys {
+		if p.UserID == userID {
+			out = append(out, *p)
+		}
+	}
+
+	return out, nil
+}
+
+func (m *memoryPasskeyRepo) GetByCredentialID(_ context.Context, credentialID []byte) (*auth.Passkey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.passkeys {
+		if bytes.Equal(p.CredentialID, credentialID) {
+			cp := *p
+
+			return &cp, nil
+		}
+	}
+
+	return nil, auth.ErrPasskeyNotFound
+}
+
+func (m *memoryPasskeyRepo) Create(_ context.Context, p *auth.Passkey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.passkeys {
+		if bytes.Equal(existing.CredentialID, p.CredentialID) {
+			return auth.ErrPasskeyExists
+		}
+	}
+
+	p.ID = uuid.New()
+	p.CreatedAt = time.Now()
+
+	cp := *p
+	m.passkeys = append(m.passkeys, &cp)
+
+	return nil
+}
+
+func (m *memoryPasskeyRepo) Rename(_ context.Context, userID, id uuid.UUID, name string) (*auth.Passkey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.passkeys {
+		if p.ID == id && p.UserID == userID {
+			p.Name = name
+			cp := *p
+
+			return &cp, nil
+		}
+	}
+
+	return nil, auth.ErrPasskeyNotFound
+}
+
+func (m *memoryPasskeyRepo) RecordUse(_ context.Context, id uuid.UUID, signCount int64, backedUp bool, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.passkeys {
+		if p.ID == id {
+			p.SignCount = signCount
+			p.BackedUp = backedUp
+			p.LastUsedAt = &at
+
+			return nil
+		}
+	}
+
+	return auth.ErrPasskeyNotFound
+}
+
+func (m *memoryPasskeyRepo) Delete(_ context.Context, userID, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, p := range m.passkeys {
+		if p.ID == id && p.UserID == userID {
+			m.passkeys = append(m.passkeys[:i], m.passkeys[i+1:]...)
+
+			return nil
+		}
+	}
+
+	return auth.ErrPasskeyNotFound
+}
+
+type memoryWebAuthnChallengeRepo struct {
+	mu         sync.Mutex
+	challenges map[string]*auth.WebAuthnChallenge
+}
+
+func newMemoryWebAuthnChallengeRepo() *memoryWebAuthnChallengeRepo {
+	return &memoryWebAuthnChallengeRepo{challenges: make(map[string]*auth.WebAuthnChallenge)}
+}
+
+func (m *memoryWebAuthnChallengeRepo) Store(_ context.Context, c *auth.WebAuthnChallenge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now().UTC()
+
+	cp := *c
+	m.challenges[c.ChallengeHash] = &cp
+
+	return nil
+}
+
+func (m *memoryWebAuthnChallengeRepo) Consume(_ context.Context, hash string, ceremony auth.WebAuthnCeremony) (*auth.WebAuthnChallenge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.challenges[hash]
+	if !ok || c.Ceremony != ceremony {
+		return nil, auth.ErrWebAuthnChallengeNotFound
+	}
+
+	delete(m.challenges, hash)
+
+	return c, nil
+}
+
+// memoryMagicLinkRepo mirrors the Postgres magic link semantics: a session
+// is consumed once, and consuming an email link verifies the user's email.
+type memoryMagicLinkRepo struct {
+	mu       sync.Mutex
+	users    *memoryUserRepo
+	sessions map[uuid.UUID]*auth.MagicLinkSession
+}
+
+func newMemoryMagicLinkRepo(users *memoryUserRepo) *memoryMagicLinkRepo {
+	return &memoryMagicLinkRepo{users: users, sessions: make(map[uuid.UUID]*auth.MagicLinkSession)}
+}
+
+func (m *memoryMagicLinkRepo) Store(_ context.Context, s *auth.MagicLinkSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	s.CreatedAt = s.SentAt
+
+	cp := *s
+	m.sessions[s.ID] = &cp
+
+	return nil
+}
+
+func (m *memoryMagicLinkRepo) GetByID(_ context.Context, id uuid.UUID) (*auth.MagicLinkSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, auth.ErrMagicLinkNotFound
+	}
+
+	cp := *s
+
+	return &cp, nil
+}
+
+func (m *memoryMagicLinkRepo) CountSince(_ context.Context, identifier string, identifierType auth.IdentifierType, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+
+	for _, s := range m.sessions {
+		if s.Identifier == identifier && s.IdentifierType == identifierType && s.CreatedAt.After(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *memoryMagicLinkRepo) Reissue(_ context.Context, s *auth.MagicLinkSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.sessions[s.ID]
+	if !ok || stored.VerifiedAt != nil {
+		return auth.ErrMagicLinkUsed
+	}
+
+	stored.TokenHash = s.TokenHash
+	stored.OTPHash = s.OTPHash
+	stored.Method = s.Method
+	stored.SentAt = s.SentAt
+
+	return nil
+}
+
+func (m *memoryMagicLinkRepo) RecordFailure(_ context.Context, id uuid.UUID) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return 0, auth.ErrMagicLinkNotFound
+	}
+
+	s.Attempts++
+
+	return s.Attempts, nil
+}
+
+func (m *memoryMagicLinkRepo) Consume(ctx context.Context, s *auth.MagicLinkSession, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.sessions[s.ID]
+	if !ok || stored.VerifiedAt != nil {
+		return auth.ErrMagicLinkUsed
+	}
+
+	stored.VerifiedAt = &at
+
+	if s.UserID != nil && s.IdentifierType == auth.IdentifierEmail && s.Method == auth.MagicLinkEmail {
+		if u, err := m.users.GetByID(ctx, *s.UserID); err == nil && u.Email == s.Identifier {
+			u.EmailVerified = true
+			if u.Status == auth.StatusPendingVerification {
+				u.Status = auth.StatusActive
+			}
+		}
+	}
+
+	return nil
+}
+
+// backdate moves every session back in time, as if it had been sent d ago.
+func (m *memoryMagicLinkRepo) backdate(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.sessions {
+		s.SentAt = s.SentAt.Add(-d)
+		s.CreatedAt = s.CreatedAt.Add(-d)
+		s.ExpiresAt = s.ExpiresAt.Add(-d)
+	}
+}
+
+// memoryAccountRecoveryRepo mirrors the Postgres recovery semantics: a code
+// is swapped for a token once, and resetting MFA removes the user's second
+// factors and optionally sets their password.
+type memoryAccountRecoveryRepo struct {
+	mu       sync.Mutex
+	users    *memoryUserRepo
+	totp     *memoryTOTPRepo
+	sms      *memorySMSFactorRepo
+	codes    *memoryRecoveryCodeRepo
+	sessions map[uuid.UUID]*auth.RecoverySession
+}
+
+func newMemoryAccountRecoveryRepo(users *memoryUserRepo, totp *memoryTOTPRepo, sms *memorySMSFactorRepo, codes *memoryRecoveryCodeRepo) *memoryAccountRecoveryRepo {
+	return &memoryAccountRecoveryRepo{
+		users:    users,
+		totp:     totp,
+		sms:      sms,
+		codes:    codes,
+		sessions: make(map[uuid.UUID]*auth.RecoverySession),
+	}
+}
+
+func (m *memoryAccountRecoveryRepo) Store(_ context.Context, s *auth.RecoverySession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	s.CreatedAt = s.SentAt
+
+	cp := *s
+	m.sessions[s.ID] = &cp
+
+	return nil
+}
+
+func (m *memoryAccountRecoveryRepo) GetByID(_ context.Context, id uuid.UUID) (*auth.RecoverySession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, auth.ErrRecoverySessionNotFound
+	}
+
+	cp := *s
+
+	return &cp, nil
+}
+
+func (m *memoryAccountRecoveryRepo) GetByTokenHash(_ context.Context, hash string) (*auth.RecoverySession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.sessions {
+		if s.RecoveryTokenHash != nil && *s.RecoveryTokenHash == hash {
+			cp := *s
+
+			return &cp, nil
+		}
+	}
+
+	return nil, auth.ErrRecoverySessionNotFound
+}
+
+func (m *memoryAccountRecoveryRepo) CountSince(_ context.Context, identifier string, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+
+	for _, s := range m.sessions {
+		if s.Identifier == identifier && s.CreatedAt.After(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *memoryAccountRecoveryRepo) Reissue(_ context.Context, s *auth.RecoverySession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.sessions[s.ID]
+	if !ok || stored.IsVerified() || !stored.IsOpen() {
+		return auth.ErrRecoverySessionUsed
+	}
+
+	stored.OTPHash = s.OTPHash
+	stored.SentAt = s.SentAt
+
+	return nil
+}
+
+func (m *memoryAccountRecoveryRepo) RecordFailure(_ context.Context, id uuid.UUID) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return 0, auth.ErrRecoverySessionNotFound
+	}
+
+	s.Attempts++
+
+	return s.Attempts, nil
+}
+
+func (m *memoryAccountRecoveryRepo) Verify(_ context.Context, s *auth.RecoverySession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.sessions[s.ID]
+	if !ok || stored.IsVerified() || !stored.IsOpen() {
+		return auth.ErrRecoverySessionUsed
+	}
+
+	stored.OTPHash = nil
+	stored.RecoveryTokenHash = s.RecoveryTokenHash
+	stored.OTPVerifiedAt = s.OTPVerifiedAt
+	stored.AvailableAt = s.AvailableAt
+	stored.ExpiresAt = s.ExpiresAt
+
+	return nil
+}
+
+func (m *memoryAccountRecoveryRepo) ResetMFA(ctx context.Context, s *auth.RecoverySession, passwordHash *string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.sessions[s.ID]
+	if !ok || !stored.IsVerified() || !stored.IsOpen() {
+		return auth.ErrRecoverySessionUsed
+	}
+
+	stored.CompletedAt = &at
+
+	_ = m.totp.Delete(ctx, *s.UserID)
+	_ = m.sms.Delete(ctx, *s.UserID)
+	_ = m.codes.DeleteByUserID(ctx, *s.UserID)
+
+	if passwordHash != nil {
+		return m.users.UpdatePassword(ctx, *s.UserID, *passwordHash)
+	}
+
+	return nil
+}
+
+func (m *memoryAccountRecoveryRepo) CancelForUser(_ context.Context, userID uuid.UUID, at time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var canceled int64
+
+	for _, s := range m.sessions {
+		if s.UserID != nil && *s.UserID == userID && s.IsOpen() && !s.IsExpired(at) {
+			s.CanceledAt = &at
+			canceled++
+		}
+	}
+
+	return canceled, nil
+}
+
+// finishCoolingOff makes every verified session's token usable now.
+func (m *memoryAccountRecoveryRepo) finishCoolingOff() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	past := time.Now().Add(-time.Second)
+
+	for _, s := range m.sessions {
+		if s.AvailableAt != nil {
+			s.AvailableAt = &past
+		}
+	}
+}
+
+// memoryEmailChangeRepo mirrors the Postgres email change semantics: a new
+// request voids the pending one but not reverts, and confirming moves the
+// user to the new address unless another account has it.
+type memoryEmailChangeRepo struct {
+	mu      sync.Mutex
+	users   *memoryUserRepo
+	changes map[uuid.UUID]*auth.EmailChange
+	outbox  []event.OutboxEvent
+}
+
+func newMemoryEmailChangeRepo(users *memoryUserRepo) *memoryEmailChangeRepo {
+	return &memoryEmailChangeRepo{users: users, changes: make(map[uuid.UUID]*auth.EmailChange)}
+}
+
+func (m *memoryEmailChangeRepo) Store(_ context.Context, c *auth.EmailChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store(c, time.Now())
+
+	return nil
+}
+
+func (m *memoryEmailChangeRepo) store(c *auth.EmailChange, at time.Time) {
+	if !c.IsRevert() {
+		m.dropPending(c.UserID)
+	}
+
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	c.CreatedAt = at
+
+	cp := *c
+	m.changes[c.ID] = &cp
+}
+
+func (m *memoryEmailChangeRepo) dropPending(userID uuid.UUID) {
+	for id, c := range m.changes {
+		if c.UserID == userID && c.ConfirmedAt == nil && !c.IsRevert() {
+			delete(m.changes, id)
+		}
+	}
+}
+
+func (m *memoryEmailChangeRepo) GetByTokenHash(_ context.Context, hash string) (*auth.EmailChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.changes {
+		if c.TokenHash == hash {
+			cp := *c
+
+			return &cp, nil
+		}
+	}
+
+	return nil, auth.ErrEmailChangeNotFound
+}
+
+func (m *memoryEmailChangeRepo) CountSince(_ context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+
+	for _, c := range m.changes {
+		if c.UserID == userID && !c.IsRevert() && c.CreatedAt.After(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *memoryEmailChangeRepo) Confirm(ctx context.Context, c, revert *auth.EmailChange, at time.Time, events []event.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.changes[c.ID]
+	if !ok || stored.ConfirmedAt != nil {
+		return auth.ErrEmailChangeUsed
+	}
+
+	if other, err := m.users.GetByEmail(ctx, c.NewEmail); err == nil && other.ID != c.UserID {
+		return auth.ErrEmailAlreadyExists
+	}
+
+	user, err := m.users.GetByID(ctx, c.UserID)
+	if err != nil {
+		return err
+	}
+
+	stored.ConfirmedAt = &at
+	user.Email = c.NewEmail
+	user.EmailVerified = true
+
+	if c.IsRevert() {
+		m.dropPending(c.UserID)
+	}
+
+	if revert != nil {
+		m.store(revert, at)
+	}
+
+	m.outbox = append(m.outbox, events...)
+
+	return nil
+}
+
+// memoryPhoneChangeRepo mirrors the Postgres phone change semantics: only
+// the latest request is pending, and confirming sets the user's verified
+// number unless another account has it.
+type memoryPhoneChangeRepo struct {
+	mu      sync.Mutex
+	users   *memoryUserRepo
+	changes map[uuid.UUID]*auth.PhoneChange
+	outbox  []event.OutboxEvent
+}
+
+func newMemoryPhoneChangeRepo(users *memoryUserRepo) *memoryPhoneChangeRepo {
+	return &memoryPhoneChangeRepo{users: users, changes: make(map[uuid.UUID]*auth.PhoneChange)}
+}
+
+func (m *memoryPhoneChangeRepo) Store(_ context.Context, c *auth.PhoneChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dropPending(c.UserID)
+
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	c.CreatedAt = time.Now()
+
+	cp := *c
+	m.changes[c.ID] = &cp
+
+	return nil
+}
+
+func (m *memoryPhoneChangeRepo) dropPending(userID uuid.UUID) {
+	for id, c := range m.changes {
+		if c.UserID == userID && c.ConfirmedAt == nil {
+			delete(m.changes, id)
+		}
+	}
+}
+
+func (m *memoryPhoneChangeRepo) GetPending(_ context.Context, userID uuid.UUID) (*auth.PhoneChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.changes {
+		if c.UserID == userID && c.ConfirmedAt == nil {
+			cp := *c
+
+			return &cp, nil
+		}
+	}
+
+	return nil, auth.ErrPhoneChangeNotFound
+}
+
+func (m *memoryPhoneChangeRepo) CountSince(_ context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+
+	for _, c := range m.changes {
+		if c.UserID == userID && c.CreatedAt.After(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *memoryPhoneChangeRepo) RecordFailure(_ context.Context, id uuid.UUID) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.changes[id]
+	if !ok {
+		return 0, auth.ErrPhoneChangeNotFound
+	}
+
+	c.Attempts++
+
+	return c.Attempts, nil
+}
+
+func (m *memoryPhoneChangeRepo) Confirm(ctx context.Context, c *auth.PhoneChange, at time.Time, events []event.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.changes[c.ID]
+	if !ok || stored.ConfirmedAt != nil {
+		return auth.ErrPhoneChangeUsed
+	}
+
+	if other, err := m.users.GetByPhone(ctx, c.NewPhoneNumber); err == nil && other.ID != c.UserID {
+		return auth.ErrPhoneAlreadyExists
+	}
+
+	user, err := m.users.GetByID(ctx, c.UserID)
+	if err != nil {
+		return err
+	}
+
+	phone := c.NewPhoneNumber
+	stored.ConfirmedAt = &at
+	user.PhoneNumber = &phone
+	user.PhoneVerified = true
+	m.outbox = append(m.outbox, events...)
+
+	return nil
+}
+
+func (m *memoryPhoneChangeRepo) RemovePhone(ctx context.Context, userID uuid.UUID, phone string, _ time.Time, events []event.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, err := m.users.GetByID(ctx, userID)
+	if err != nil || user.PhoneNumber == nil || *user.PhoneNumber != phone {
+		return auth.ErrUserNotFound
+	}
+
+	user.PhoneNumber = nil
+	user.PhoneVerified = false
+	m.dropPending(userID)
+	m.outbox = append(m.outbox, events...)
+
+	return nil
+}
+
+// memoryAccountDeletionRepo mirrors the Postgres account deletion semantics:
+// only the latest request is pending, and purging removes the user from
+// users.
+type memoryAccountDeletionRepo struct {
+	mu        sync.Mutex
+	users     *memoryUserRepo
+	deletions map[uuid.UUID]*auth.AccountDeletion
+	outbox    []event.OutboxEvent
+}
+
+func newMemoryAccountDeletionRepo(users *memoryUserRepo) *memoryAccountDeletionRepo {
+	return &memoryAccountDeletionRepo{users: users, deletions: make(map[uuid.UUID]*auth.AccountDeletion)}
+}
+
+func (m *memoryAccountDeletionRepo) Store(_ context.Context, d *auth.AccountDeletion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, existing := range m.deletions {
+		if existing.UserID == d.UserID && existing.ConfirmedAt == nil {
+			delete(m.deletions, id)
+		}
+	}
+
+	d.ID = uuid.New()
+	d.CreatedAt = time.Now()
+
+	cp := *d
+	m.deletions[d.ID] = &cp
+
+	return nil
+}
+
+func (m *memoryAccountDeletionRepo) GetByTokenHash(_ context.Context, hash string) (*auth.AccountDeletion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range m.deletions {
+		if d.TokenHash == hash {
+			cp := *d
+
+			return &cp, nil
+		}
+	}
+
+	return nil, auth.ErrAccountDeletionNotFound
+}
+
+func (m *memoryAccountDeletionRepo) Confirm(ctx context.Context, d *auth.AccountDeletion, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.deletions[d.ID]
+	if !ok || stored.ConfirmedAt != nil {
+		return auth.ErrAccountDeletionUsed
+	}
+
+	user, err := m.users.GetByID(ctx, d.UserID)
+	if err != nil || user.Status == auth.StatusDeleted || user.Status == auth.StatusDisabled {
+		return auth.ErrUserNotFound
+	}
+
+	stored.ConfirmedAt = &at
+	stored.Feedback = d.Feedback
+	user.Status = auth.StatusDeleted
+	user.DeletedAt = &at
+
+	return nil
+}
+
+func (m *memoryAccountDeletionRepo) Restore(ctx context.Context, userID uuid.UUID, status auth.Status) error {
+	user, err := m.users.GetByID(ctx, userID)
+	if err != nil || user.Status != auth.StatusDeleted {
+		return auth.ErrUserNotFound
+	}
+
+	user.Status = status
+	user.DeletedAt = nil
+
+	return nil
+}
+
+func (m *memoryAccountDeletionRepo) ListPurgeable(_ context.Context, deletedBefore time.Time, limit uint64) ([]auth.User, error) {
+	m.users.mu.Lock()
+	defer m.users.mu.Unlock()
+
+	var users []auth.User
+
+	for _, u := range m.users.users {
+		if uint64(len(users)) == limit {
+			break
+		}
+
+		if u.Status == auth.StatusDeleted && u.DeletedAt != nil && !u.DeletedAt.After(deletedBefore) {
+			users = append(users, *u)
+		}
+	}
+
+	return users, nil
+}
+
+func (m *memoryAccountDeletionRepo) Purge(_ context.Context, userID uuid.UUID, deletedBefore time.Time, events []event.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users.mu.Lock()
+	defer m.users.mu.Unlock()
+
+	u, ok := m.users.users[userID]
+	if !ok || u.Status != auth.StatusDeleted || u.DeletedAt == nil || u.DeletedAt.After(deletedBefore) {
+		return auth.ErrUserNotFound
+	}
+
+	delete(m.users.users, userID)
+
+	for id, d := range m.deletions {
+		if d.UserID == userID {
+			delete(m.deletions, id)
+		}
+	}
+
+	m.outbox = append(m.outbox, events...)
+
+	return nil
+}
+
+// memoryDataExportRepo mirrors the Postgres data export semantics: one
+// export in progress per user, and Collect returns tables or fails with
+// collectErr.
+type memoryDataExportRepo struct {
+	mu         sync.Mutex
+	exports    map[uuid.UUID]*auth.DataExport
+	tables     []auth.DataExportTable
+	collectErr error
+}
+
+func newMemoryDataExportRepo(tables ...auth.DataExportTable) *memoryDataExportRepo {
+	return &memoryDataExportRepo{exports: make(map[uuid.UUID]*auth.DataExport), tables: tables}
+}
+
+func (m *memoryDataExportRepo) Create(_ context.Context, e *auth.DataExport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.exports {
+		if existing.UserID == e.UserID &&
+			(existing.Status == auth.DataExportPending || existing.Status == auth.DataExportProcessing) {
+			return auth.ErrDataExportInProgress
+		}
+	}
+
+	e.ID = uuid.New()
+	e.Status = auth.DataExportPending
+	e.CreatedAt = time.Now()
+
+	cp := *e
+	m.exports[e.ID] = &cp
+
+	return nil
+}
+
+func (m *memoryDataExportRepo) ListByUser(_ context.Context, userID uuid.UUID) ([]auth.DataExport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var exports []auth.DataExport
+
+	for _, e := range m.exports {
+		if e.UserID == userID {
+			exports = append(exports, *e)
+		}
+	}
+
+	slices.SortFunc(exports, func(a, b auth.DataExport) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	return exports, nil
+}
+
+func (m *memoryDataExportRepo) GetByTokenHash(_ context.Context, hash string) (*auth.DataExport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.exports {
+		if e.TokenHash != nil && *e.TokenHash == hash {
+			cp := *e
+
+			return &cp, nil
+		}
+	}
+
+	return nil, auth.ErrDataExportNotFound
+}
+
+func (m *memoryDataExportRepo) Claim(_ context.Context, staleBefore time.Time, limit uint64) ([]auth.DataExport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var claimed []auth.DataExport
+
+	for _, e := range m.exports {
+		if uint64(len(claimed)) == limit {
+			break
+		}
+
+		stale := e.Status == auth.DataExportProcessing && e.StartedAt != nil && e.StartedAt.Before(staleBefore)
+		if e.Status != auth.DataExportPending && !stale {
+			continue
+		}
+
+		now := time.Now()
+		e.Status = auth.DataExportProcessing
+		e.StartedAt = &now
+		claimed = append(claimed, *e)
+	}
+
+	return claimed, nil
+}
+
+func (m *memoryDataExportRepo) Complete(_ context.Context, e *auth.DataExport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.exports[e.ID]
+	if !ok || stored.Status != auth.DataExportProcessing {
+		return auth.ErrDataExportNotFound
+	}
+
+	e.Status = auth.DataExportReady
+	*stored = *e
+
+	return nil
+}
+
+func (m *memoryDataExportRepo) Fail(_ context.Context, id uuid.UUID, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.exports[id]; ok && e.Status == auth.DataExportProcessing {
+		e.Status = auth.DataExportFailed
+		e.Error = &reason
+	}
+
+	return nil
+}
+
+func (m *memoryDataExportRepo) ListExpired(_ context.Context, now time.Time, limit uint64) ([]auth.DataExport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var exports []auth.DataExport
+
+	for _, e := range m.exports {
+		if uint64(len(exports)) == limit {
+			break
+		}
+
+		if e.Status == auth.DataExportReady && e.IsExpired(now) {
+			exports = append(exports, *e)
+		}
+	}
+
+	return exports, nil
+}
+
+func (m *memoryDataExportRepo) Expire(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.exports[id]; ok {
+		e.Status = auth.DataExportExpired
+		e.ObjectKey = nil
+		e.TokenHash = nil
+	}
+
+	return nil
+}
+
+func (m *memoryDataExportRepo) Collect(_ context.Context, _ uuid.UUID) ([]auth.DataExportTable, error) {
+	return m.tables, m.collectErr
+}
+
+// memoryStorage keeps objects in a map.
+type memoryStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: make(map[string][]byte)}
+}
+
+func (m *memoryStorage) Put(_ context.Context, key string, data []byte, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.objects[key] = data
+
+	return nil
+}
+
+func (m *memoryStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryStorage) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.objects, key)
+
+	return nil
+}
+
+// memoryAvailabilityCheckRepo keeps check times per IP address.
+type memoryAvailabilityCheckRepo struct {
+	mu     sync.Mutex
+	checks map[string][]time.Time
+}
+
+func newMemoryAvailabilityCheckRepo() *memoryAvailabilityCheckRepo {
+	return &memoryAvailabilityCheckRepo{checks: make(map[string][]time.Time)}
+}
+
+func (m *memoryAvailabilityCheckRepo) Record(_ context.Context, ip string, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checks[ip] = append(m.checks[ip], time.Now().UTC())
+
+	count := 0
+
+	for _, at := range m.checks[ip] {
+		if at.After(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// fakePasskeyVerifier plays the relying party for a single authenticator:
+// registrations yield credential and assertions report signCount, provided
+// the client data answers the challenge issued for the ceremony.
+type fakePasskeyVerifier struct {
+	mu         sync.Mutex
+	credential webauthn.Credential
+	signCount  uint32
+	reject     bool
+	requireUV  bool
+}
+
+func (f *fakePasskeyVerifier) ID() string {
+	return "example.com"
+}
+
+func (f *fakePasskeyVerifier) VerifyRegistration(resp webauthn.RegistrationResponse, challenge []byte, requireUV bool) (*webauthn.Credential, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requireUV = requireUV
+
+	if err := f.check(resp.ClientDataJSON, challenge); err != nil {
+		return nil, err
+	}
+
+	cred := f.credential
+
+	return &cred, nil
+}
+
+func (f *fakePasskeyVerifier) VerifyAssertion(resp webauthn.AssertionResponse, challenge, publicKey []byte, requireUV bool) (*webauthn.Assertion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requireUV = requireUV
+
+	if err := f.check(resp.ClientDataJSON, challenge); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(publicKey, f.credential.PublicKey) {
+		return nil, webauthn.ErrVerification
+	}
+
+	return &webauthn.Assertion{SignCount: f.signCount, UserVerified: true}, nil
+}
+
+func (f *fakePasskeyVerifier) check(clientDataJSON, challenge []byte) error {
+	got, err := webauthn.ClientChallenge(clientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	if f.reject || !bytes.Equal(got, challenge) {
+		return webauthn.ErrVerification
+	}
+
+	return nil
+}
+
+// lastRequireUV reports whether the latest verification demanded user verification.
+func (f *fakePasskeyVerifier) lastRequireUV() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.requireUV
+}
+
+type memoryMFAChallengeRepo struct {
+	mu         sync.Mutex
+	challenges map[string]*auth.MFAChallenge
+}
+
+func newMemoryMFAChallengeRepo() *memoryMFAChallengeRepo {
+	return &memoryMFAChallengeRepo{challenges: make(map[string]*auth.MFAChallenge)}
+}
+
+func (m *memoryMFAChallengeRepo) Store(_ context.Context, c *auth.MFAChallenge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now().UTC()
+
+	cp := *c
+	m.challenges[c.TokenHash] = &cp
+
+	return nil
+}
+
+func (m *memoryMFAChallengeRepo) GetByHash(_ context.Context, hash string) (*auth.MFAChallenge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.challenges[hash]
+	if !ok {
+		return nil, auth.ErrMFAChallengeNotFound
+	}
+
+	cp := *c
+
+	return &cp, nil
+}
+
+func (m *memoryMFAChallengeRepo) SetCode(_ context.Context, id uuid.UUID, hash string, expiresAt, sentAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.challenges {
+		if c.ID == id {
+			if c.CompletedAt != nil {
+				return auth.ErrMFAChallengeCompleted
+			}
+
+			c.CodeHash, c.CodeExpiresAt, c.CodeSentAt = &hash, &expiresAt, &sentAt
+
+			return nil
+		}
+	}
+
+	return auth.ErrMFAChallengeCompleted
+}
+
+func (m *memoryMFAChallengeRepo) RecordFailure(_ context.Context, id uuid.UUID) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.challenges {
+		if c.ID == id {
+			c.Attempts++
+
+			return c.Attempts, nil
+		}
+	}
+
+	return 0, auth.ErrMFAChallengeNotFound
+}
+
+func (m *memoryMFAChallengeRepo) FailuresSince(_ context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var failures int
+
+	for _, c := range m.challenges {
+		if c.UserID == userID && c.CreatedAt.After(since) {
+			failures += c.Attempts
+		}
+	}
+
+	return failures, nil
+}
+
+func (m *memoryMFAChallengeRepo) Complete(_ context.Context, id uuid.UUID, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.challenges {
+		if c.ID == id {
+			if c.CompletedAt != nil {
+				return auth.ErrMFAChallengeCompleted
+			}
+
+			c.CompletedAt = &at
+
+			return nil
+		}
+	}
+
+	return auth.ErrMFAChallengeNotFound
+}
+
+// expire moves every stored challenge's expiry into the past.
+func (m *memoryMFAChallengeRepo) expire() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.challenges {
+		c.ExpiresAt = time.Now().Add(-time.Second)
+	}
+}
+
+type mockEmailNotifier struct {
+	mu       sync.Mutex
+	messages []notification.EmailMessage
+	err      error
+}
+
+func (m *mockEmailNotifier) SendEmail(_ context.Context, msg *notification.EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, *msg)
+
+	return m.err
+}
+
+func (m *mockEmailNotifier) sent() []notification.EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]notification.EmailMessage(nil), m.messages...)
+}
+
+type mockSMSNotifier struct {
+	mu       sync.Mutex
+	messages []notification.SMSMessage
+}
+
+func (m *mockSMSNotifier) SendSMS(_ context.Context, msg *notification.SMSMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, *msg)
+
+	return nil
+}
+
+func (m *mockSMSNotifier) sent() []notification.SMSMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]notification.SMSMessage(nil), m.messages...)
+}
+
+// lastCode returns the one-time code from the most recent message.
+func (m *mockSMSNotifier) lastCode(t *testing.T) string {
+	t.Helper()
+
+	sent := m.sent()
+	require.NotEmpty(t, sent)
+
+	code := regexp.MustCompile(`\d{6}`).FindString(sent[len(sent)-1].Body)
+	require.NotEmpty(t, code)
+
+	return code
+}
+
+type mockPushNotifier struct {
+	mu       sync.Mutex
+	messages []notification.PushMessage
+}
+
+func (m *mockPushNotifier) SendPush(_ context.Context, msg *notification.PushMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, *msg)
+
+	return nil
+}
+
+func (m *mockPushNotifier) sent() []notification.PushMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]notification.PushMessage(nil), m.messages...)
+}
+
+type mockSecurityEventRepo struct {
+	mu     sync.Mutex
+	events []auth.SecurityEvent
+}
+
+func (m *mockSecurityEventRepo) Store(_ context.Context, e *auth.SecurityEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+
+	m.events = append(m.events, *e)
+
+	return nil
+}
+
+func (m *mockSecurityEventRepo) GetByID(_ context.Context, userID, id uuid.UUID) (*auth.SecurityEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.events {
+		if e.ID == id && e.UserID != nil && *e.UserID == userID {
+			return &e, nil
+		}
+	}
+
+	return nil, auth.ErrSecurityEventNotFound
+}
+
+func (m *mockSecurityEventRepo) ListByUserID(_ context.Context, userID uuid.UUID, f auth.SecurityEventFilter, limit, offset uint64) ([]auth.SecurityEvent, error) {
+	matched := m.matching(userID, f)
+
+	// Newest first, as stored order is oldest first.
+	slices.Reverse(matched)
+
+	start := min(int(offset), len(matched))    //nolint:gosec // small test values
+	end := min(start+int(limit), len(matched)) //nolint:gosec // small test values
+
+	return matched[start:end], nil
+}
+
+func (m *mockSecurityEventRepo) CountByUserID(_ context.Context, userID uuid.UUID, f auth.SecurityEventFilter) (int, error) {
+	return len(m.matching(userID, f)), nil
+}
+
+func (m *mockSecurityEventRepo) matching(userID uuid.UUID, f auth.SecurityEventFilter) []auth.SecurityEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []auth.SecurityEvent
+
+	for _, e := range m.events {
+		switch {
+		case e.UserID == nil || *e.UserID != userID:
+		case f.Type != "" && e.Type != f.Type:
+		case f.From != nil && e.CreatedAt.Before(*f.From):
+		case f.To != nil && e.CreatedAt.After(*f.To):
+		default:
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
+
+func (m *mockSecurityEventRepo) stored() []auth.SecurityEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]auth.SecurityEvent(nil), m.events...)
+}
+
+// fakeGeoLocator places every address it knows in one city.
+type fakeGeoLocator struct {
+	known map[string]geoip.Location
+}
+
+func (f fakeGeoLocator) Lookup(ip string) (*geoip.Location, error) {
+	loc, ok := f.known[ip]
+	if !ok {
+		return nil, geoip.ErrNotFound
+	}
+
+	return &loc, nil
+}
+
+// plainHasher keeps tests fast; it is obviously not meant for real passwords.
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+func (plainHasher) Verify(password, encodedHash string) (bool, error) {
+	return strings.TrimPrefix(encodedHash, "hashed:") == password, nil
+}
+
+// memorySigningKeyRepo mimics the rotation semantics of the postgres repo.
+type memorySigningKeyRepo struct {
+	mu      sync.Mutex
+	keys    []auth.SigningKey
+	listErr error
+}
+
+func (m *memorySigningKeyRepo) ListUsable(_ context.Context, now time.Time) ([]auth.SigningKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+
+	usable := make([]auth.SigningKey, 0, len(m.keys))
+
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		if m.keys[i].IsUsable(now) {
+			usable = append(usable, m.keys[i])
+		}
+	}
+
+	return usable, nil
+}
+
+func (m *memorySigningKeyRepo) Rotate(_ context.Context, key *auth.SigningKey, retireAt, staleBefore time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.keys {
+		if m.keys[i].IsActive && m.keys[i].RevokedAt == nil && !m.keys[i].CreatedAt.Before(staleBefore) {
+			return false, nil
+		}
+	}
+
+	for i := range m.keys {
+		if m.keys[i].IsActive {
+			m.keys[i].IsActive = false
+			m.keys[i].ExpiresAt = &retireAt
+		}
+	}
+
+	key.ID = uuid.New()
+	key.IsActive = true
+	key.CreatedAt = time.Now()
+	m.keys = append(m.keys, *key)
+
+	return true, nil
+}
+
+func (m *memorySigningKeyRepo) Revoke(_ context.Context, kid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	for i := range m.keys {
+		if m.keys[i].KID == kid {
+			m.keys[i].IsActive = false
+			m.keys[i].RevokedAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (m *memorySigningKeyRepo) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.keys)
+}
+
+func newTestCipher(t *testing.T) *encryption.AESGCM {
+	t.Helper()
+
+	c, err := encryption.NewAESGCM(bytes.Repeat([]byte{0x42}, 32))
+	require.NoError(t, err)
+
+	return c
+}
+
+func newTestKeyRing(t *testing.T, alg string) (*authuc.KeyRing, *memorySigningKeyRepo) {
+	t.Helper()
+
+	c := newTestCipher(t)
+
+	keyRepo := &memorySigningKeyRepo{}
+
+	ring, err := authuc.NewKeyRing(keyRepo, c, authuc.KeyRingConfig{
+		Algorithm:        alg,
+		RotationInterval: 24 * time.Hour,
+		RetireAfter:      15 * time.Minute,
+		ReloadInterval:   time.Nanosecond,
+	})
+	require.NoError(t, err)
+
+	return ring, keyRepo
+}
+
+func newTestTokenService(t *testing.T) *authuc.TokenService {
+	t.Helper()
+
+	ring, _ := newTestKeyRing(t, "HS256")
+
+	return authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+}
@@ -0,0 +1,436 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/oauth"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+)
+
+// redirectProviderPlaceholder in a configured redirect URL is replaced with
+// the provider name.
+const redirectProviderPlaceholder = "{provider}"
+
+// OAuthClient runs the authorization code flow against one provider.
+// *oauth.Client implements it.
+type OAuthClient interface {
+	AuthCodeURL(state, codeChallenge, redirectURI string) string
+	Exchange(ctx context.Context, code, codeVerifier, redirectURI string) (*oauth.Token, error)
+	Identity(ctx context.Context, t *oauth.Token) (*oauth.Identity, error)
+}
+
+// oauthState is what a sign-in carries through the provider. It is sealed
+// with the secrets cipher, so it cannot be read or altered on the way, and
+// holds the PKCE verifier the code has to be redeemed with.
+type oauthState struct {
+	Provider    auth.OAuthProvider `json:"p"`
+	Verifier    string             `json:"v"`
+	RedirectURI string             `json:"r"`
+	ClientState string             `json:"s,omitempty"`
+	ExpiresAt   int64              `json:"e"`
+}
+
+// AuthorizeOAuth starts a social sign-in and returns the provider URL to send
+// the user to. A state from the caller is kept in front of ours, separated by
+// a dot, so the frontend can check it when the provider redirects back.
+func (uc *UseCase) AuthorizeOAuth(_ context.Context, in auth.OAuthAuthorizeInput) (string, error) {
+	client, err := uc.oauthClient(in.Provider)
+	if err != nil {
+		return "", err
+	}
+
+	redirectURI, err := uc.oauthRedirectURI(in.Provider, in.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.Contains(in.State, ".") {
+		return "", apperror.Validation("Invalid state", apperror.WithField("state", "must not contain a dot"))
+	}
+
+	verifier, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return "", fmt.Errorf("UseCase - AuthorizeOAuth - token.Generate: %w", err)
+	}
+
+	payload, err := json.Marshal(oauthState{
+		Provider:    in.Provider,
+		Verifier:    verifier,
+		RedirectURI: redirectURI,
+		ClientState: in.State,
+		ExpiresAt:   uc.now().Add(uc.cfg.OAuthStateTTL).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("UseCase - AuthorizeOAuth - json.Marshal: %w", err)
+	}
+
+	state, err := uc.cipher.Encrypt(payload)
+	if err != nil {
+		return "", fmt.Errorf("UseCase - AuthorizeOAuth - uc.cipher.Encrypt: %w", err)
+	}
+
+	if in.State != "" {
+		state = in.State + "." + state
+	}
+
+	return client.AuthCodeURL(state, oauth.S256Challenge(verifier), redirectURI), nil
+}
+
+// OAuthCallback redeems the code the provider sent back and signs the user
+// in. A provider account seen before signs in its linked user. Otherwise a
+// verified provider email links to a local account whose email is verified
+// too, and a new email signs up a passwordless account. Users with MFA get a
+// challenge, as with a password login.
+func (uc *UseCase) OAuthCallback(ctx context.Context, in auth.OAuthCallbackInput) (*auth.AuthResult, error) {
+	client, err := uc.oauthClient(in.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := uc.openOAuthState(in.State, in.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.RedirectURI != "" && in.RedirectURI != st.RedirectURI {
+		return nil, errOAuthStateMismatch()
+	}
+
+	tok, err := client.Exchange(ctx, in.Code, st.Verifier, st.RedirectURI)
+	if err != nil {
+		if errors.Is(err, oauth.ErrInvalidGrant) {
+			return nil, apperror.Validation("Authorization code is invalid or has expired",
+				apperror.WithCode(codeOAuthCodeInvalid),
+				apperror.WithField("code", "was rejected by the provider"),
+				apperror.WithCause(err),
+			)
+		}
+
+		return nil, errOAuthProvider(err)
+	}
+
+	id, err := client.Identity(ctx, tok)
+	if err != nil {
+		return nil, errOAuthProvider(err)
+	}
+
+	conn, err := uc.newOAuthConnection(in.Provider, id, tok)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.oauthUser(ctx, conn, id, in.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.signInBlocked(user) {
+		return nil, apperror.Forbidden("Account has been disabled", apperror.WithCode(codeAccountDisabled))
+	}
+
+	if locked := uc.lockedOut(ctx, user, in.Client); locked != nil {
+		return locked, nil
+	}
+
+	mfa, err := uc.MFAStatus(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfa.Enabled() {
+		return uc.startMFAChallenge(ctx, user, mfa.Methods(), auth.LoginInput{Client: in.Client})
+	}
+
+	return uc.finishLogin(ctx, user, false, in.Client)
+}
+
+func (uc *UseCase) OAuthConnections(ctx context.Context, userID uuid.UUID) ([]auth.OAuthConnection, error) {
+	connections, err := uc.oauthConnections.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - OAuthConnections - uc.oauthConnections.ListByUserID: %w", err)
+	}
+
+	return connections, nil
+}
+
+// UnlinkOAuth removes a provider link, unless it is the last way the user
+// has to sign in.
+func (uc *UseCase) UnlinkOAuth(ctx context.Context, userID uuid.UUID, provider auth.OAuthProvider, client auth.ClientInfo) error {
+	connections, err := uc.oauthConnections.ListByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - UnlinkOAuth - uc.oauthConnections.ListByUserID: %w", err)
+	}
+
+	if !slices.ContainsFunc(connections, func(c auth.OAuthConnection) bool { return c.Provider == provider }) {
+		return errOAuthNotLinked()
+	}
+
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - UnlinkOAuth - uc.users.GetByID: %w", err)
+	}
+
+	passkeys, err := uc.passkeys.ListByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - UnlinkOAuth - uc.passkeys.ListByUserID: %w", err)
+	}
+
+	if loginMethodCount(user, connections, passkeys) <= 1 {
+		return apperror.Validation("This is your only way to sign in; set a password or link another provider first",
+			apperror.WithCode(codeOAuthLastMethod))
+	}
+
+	if err = uc.oauthConnections.Delete(ctx, userID, provider); err != nil {
+		if errors.Is(err, auth.ErrOAuthConnectionNotFound) {
+			return errOAuthNotLinked()
+		}
+
+		return fmt.Errorf("UseCase - UnlinkOAuth - uc.oauthConnections.Delete: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &userID,
+		Type:      auth.EventOAuthUnlinked,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"provider": string(provider)},
+	})
+
+	return nil
+}
+
+// oauthUser finds or creates the user a provider identity signs in as.
+func (uc *UseCase) oauthUser(ctx context.Context, conn *auth.OAuthConnection, id *oauth.Identity, client auth.ClientInfo) (*auth.User, error) {
+	existing, err := uc.oauthConnections.GetByProvider(ctx, conn.Provider, conn.ProviderUserID)
+	if err == nil {
+		return uc.oauthLogin(ctx, existing, conn, client)
+	}
+
+	if !errors.Is(err, auth.ErrOAuthConnectionNotFound) {
+		return nil, fmt.Errorf("UseCase - oauthUser - uc.oauthConnections.GetByProvider: %w", err)
+	}
+
+	email, err := normalizeEmail(id.Email)
+	if err != nil {
+		return nil, apperror.Validation("The provider did not share a usable email address",
+			apperror.WithCode(codeOAuthError),
+			apperror.WithField("email", "is missing from the provider account"),
+		)
+	}
+
+	user, err := uc.users.GetByEmail(ctx, email)
+
+	switch {
+	case err == nil:
+		// Linking on email alone would let whoever controls either side take
+		// over the other, so both addresses have to be verified.
+		if !id.EmailVerified || !user.EmailVerified {
+			return nil, errOAuthAccountExists()
+		}
+
+		conn.UserID = user.ID
+
+		if err = uc.oauthConnections.Create(ctx, conn); err != nil {
+			if errors.Is(err, auth.ErrOAuthConnectionExists) {
+				return nil, errOAuthAccountExists()
+			}
+
+			return nil, fmt.Errorf("UseCase - oauthUser - uc.oauthConnections.Create: %w", err)
+		}
+	case errors.Is(err, auth.ErrUserNotFound):
+		user, err = uc.oauthSignUp(ctx, email, conn, id)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("UseCase - oauthUser - uc.users.GetByEmail: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventOAuthLinked,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"provider": string(conn.Provider)},
+	})
+
+	return user, nil
+}
+
+// oauthLogin refreshes a known connection with the latest tokens and profile
+// and returns its user.
+func (uc *UseCase) oauthLogin(ctx context.Context, existing, conn *auth.OAuthConnection, client auth.ClientInfo) (*auth.User, error) {
+	conn.ID = existing.ID
+	conn.UserID = existing.UserID
+
+	if err := uc.oauthConnections.UpdateTokens(ctx, conn); err != nil {
+		return nil, fmt.Errorf("UseCase - oauthLogin - uc.oauthConnections.UpdateTokens: %w", err)
+	}
+
+	user, err := uc.users.GetByID(ctx, existing.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - oauthLogin - uc.users.GetByID: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventOAuthLogin,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"provider": string(conn.Provider)},
+	})
+
+	return user, nil
+}
+
+// oauthSignUp creates a passwordless account for a new email. It starts out
+// verified only when the provider vouches for the address. Disposable
+// addresses are refused, as in Register.
+func (uc *UseCase) oauthSignUp(ctx context.Context, email string, conn *auth.OAuthConnection, id *oauth.Identity) (*auth.User, error) {
+	if uc.isDisposable(email) {
+		return nil, errDisposableEmail("email")
+	}
+
+	user := &auth.User{
+		Email:     email,
+		Name:      optional(strings.TrimSpace(id.Name)),
+		AvatarURL: optional(id.AvatarURL),
+		Status:    auth.StatusPendingVerification,
+	}
+
+	if id.EmailVerified {
+		now := uc.now().UTC()
+
+		user.Status = auth.StatusActive
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := uc.oauthConnections.CreateWithUser(ctx, user, conn); err != nil {
+		if errors.Is(err, auth.ErrEmailAlreadyExists) || errors.Is(err, auth.ErrOAuthConnectionExists) {
+			return nil, errOAuthAccountExists()
+		}
+
+		return nil, fmt.Errorf("UseCase - oauthSignUp - uc.oauthConnections.CreateWithUser: %w", err)
+	}
+
+	if !user.EmailVerified {
+		// As with Register, a failed delivery can be retried by the user.
+		_ = uc.sendVerification(ctx, user)
+	}
+
+	return user, nil
+}
+
+// newOAuthConnection builds the connection for an identity, encrypting the
+// provider's tokens.
+func (uc *UseCase) newOAuthConnection(provider auth.OAuthProvider, id *oauth.Identity, tok *oauth.Token) (*auth.OAuthConnection, error) {
+	access, err := uc.encryptOptional(tok.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - newOAuthConnection - uc.encryptOptional: %w", err)
+	}
+
+	refresh, err := uc.encryptOptional(tok.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - newOAuthConnection - uc.encryptOptional: %w", err)
+	}
+
+	return &auth.OAuthConnection{
+		Provider:              provider,
+		ProviderUserID:        id.Subject,
+		ProviderEmail:         optional(id.Email),
+		ProviderName:          optional(id.Name),
+		ProviderAvatarURL:     optional(id.AvatarURL),
+		AccessTokenEncrypted:  access,
+		RefreshTokenEncrypted: refresh,
+		TokenExpiresAt:        tok.ExpiresAt,
+	}, nil
+}
+
+func (uc *UseCase) oauthClient(provider auth.OAuthProvider) (OAuthClient, error) {
+	client, ok := uc.oauth[provider]
+	if !ok {
+		return nil, apperror.Validation("This sign-in provider is not available",
+			apperror.WithCode(codeOAuthError),
+			apperror.WithField("provider", "is not supported or not configured"),
+		)
+	}
+
+	return client, nil
+}
+
+// oauthRedirectURI returns requested if it is one of the allowed redirect
+// URLs, or the first allowed one when nothing was requested.
+func (uc *UseCase) oauthRedirectURI(provider auth.OAuthProvider, requested string) (string, error) {
+	for _, allowed := range uc.cfg.OAuthRedirectURLs {
+		allowed = strings.ReplaceAll(allowed, redirectProviderPlaceholder, string(provider))
+
+		if requested == "" || requested == allowed {
+			return allowed, nil
+		}
+	}
+
+	return "", apperror.Validation("Redirect URI is not allowed",
+		apperror.WithCode(codeOAuthError),
+		apperror.WithField("redirect_uri", "is not an allowed redirect URI"),
+	)
+}
+
+// openOAuthState checks a state returned by the frontend against the
+// provider it claims to be for.
+func (uc *UseCase) openOAuthState(raw string, provider auth.OAuthProvider) (*oauthState, error) {
+	clientState, sealed := "", raw
+	if i := strings.LastIndexByte(raw, '.'); i >= 0 {
+		clientState, sealed = raw[:i], raw[i+1:]
+	}
+
+	payload, err := uc.cipher.Decrypt(sealed)
+	if err != nil {
+		return nil, errOAuthStateMismatch()
+	}
+
+	var st oauthState
+	if err = json.Unmarshal(payload, &st); err != nil {
+		return nil, errOAuthStateMismatch()
+	}
+
+	if st.Provider != provider || st.ClientState != clientState || uc.now().Unix() > st.ExpiresAt {
+		return nil, errOAuthStateMismatch()
+	}
+
+	return &st, nil
+}
+
+func (uc *UseCase) encryptOptional(s string) (*string, error) {
+	if s == "" {
+		return nil, nil //nolint:nilnil // nothing to store
+	}
+
+	encrypted, err := uc.cipher.Encrypt([]byte(s))
+	if err != nil {
+		return nil, err
+	}
+
+	return &encrypted, nil
+}
+
+// loginMethodCount is how many independent ways the user has to sign in.
+func loginMethodCount(user *auth.User, connections []auth.OAuthConnection, passkeys []auth.Passkey) int {
+	n := len(connections) + len(passkeys)
+	if user.HasPassword() {
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,372 @@
+package auth_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type oauthFixture struct {
+	uc          *authuc.UseCase
+	users       *memoryUserRepo
+	connections *memoryOAuthConnectionRepo
+	google      *fakeOAuthClient
+	events      *mockSecurityEventRepo
+	notifier    *mockEmailNotifier
+}
+
+func newOAuthFixture(t *testing.T, users ...*auth.User) *oauthFixture {
+	t.Helper()
+
+	f := &oauthFixture{
+		users: newMemoryUserRepo(users...),
+		google: &fakeOAuthClient{identity: oauth.Identity{
+			Subject:       "google-123",
+			Email:         "User@Example.com",
+			EmailVerified: true,
+			Name:          "Ada Lovelace",
+		}},
+		events:   &mockSecurityEventRepo{},
+		notifier: &mockEmailNotifier{},
+	}
+	f.connections = newMemoryOAuthConnectionRepo(f.users)
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:            f.users,
+		OAuthConnections: f.connections,
+		OAuth: map[auth.OAuthProvider]authuc.OAuthClient{
+			auth.OAuthGoogle: f.google,
+			auth.OAuthGitHub: &fakeOAuthClient{},
+		},
+		SecurityEvents: f.events,
+		Notifier:       f.notifier,
+	})
+
+	return f
+}
+
+// authorize starts a sign-in and returns the state the provider echoes back.
+func (f *oauthFixture) authorize(t *testing.T, provider auth.OAuthProvider, clientState string) string {
+	t.Helper()
+
+	location, err := f.uc.AuthorizeOAuth(context.Background(), auth.OAuthAuthorizeInput{Provider: provider, State: clientState})
+	require.NoError(t, err)
+
+	u, err := url.Parse(location)
+	require.NoError(t, err)
+
+	return u.Query().Get("state")
+}
+
+func (f *oauthFixture) signIn(t *testing.T) (*auth.AuthResult, error) {
+	t.Helper()
+
+	return f.uc.OAuthCallback(context.Background(), auth.OAuthCallbackInput{
+		Provider: auth.OAuthGoogle,
+		Code:     goodOAuthCode,
+		State:    f.authorize(t, auth.OAuthGoogle, ""),
+	})
+}
+
+func TestUseCase_AuthorizeOAuth(t *testing.T) {
+	t.Parallel()
+
+	f := newOAuthFixture(t)
+	ctx := context.Background()
+
+	location, err := f.uc.AuthorizeOAuth(ctx, auth.OAuthAuthorizeInput{Provider: auth.OAuthGoogle, State: "csrf-123"})
+	require.NoError(t, err)
+
+	u, err := url.Parse(location)
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.example.com/oauth/google", u.Query().Get("redirect_uri"))
+	assert.True(t, strings.HasPrefix(u.Query().Get("state"), "csrf-123."), "the caller's state leads ours")
+
+	location, err = f.uc.AuthorizeOAuth(ctx, auth.OAuthAuthorizeInput{Provider: auth.OAuthGoogle, RedirectURI: "https://m.example.com/oauth"})
+	require.NoError(t, err)
+	assert.Contains(t, location, url.QueryEscape("https://m.example.com/oauth"))
+
+	_, err = f.uc.AuthorizeOAuth(ctx, auth.OAuthAuthorizeInput{Provider: auth.OAuthGoogle, RedirectURI: "https://evil.example.com/oauth"})
+	requireAppError(t, err, apperror.KindValidation, "OAUTH_ERROR")
+
+	_, err = f.uc.AuthorizeOAuth(ctx, auth.OAuthAuthorizeInput{Provider: auth.OAuthFacebook})
+	requireAppError(t, err, apperror.KindValidation, "OAUTH_ERROR")
+}
+
+func TestUseCase_OAuthCallback_SignUp(t *testing.T) {
+	t.Parallel()
+
+	f := newOAuthFixture(t)
+	ctx := context.Background()
+
+	result, err := f.signIn(t)
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+
+	user := result.User
+	assert.Equal(t, "user@example.com", user.Email)
+	assert.Equal(t, auth.StatusActive, user.Status)
+	assert.True(t, user.EmailVerified)
+	assert.False(t, user.HasPassword())
+	assert.Empty(t, f.notifier.sent(), "a verified email needs no confirmation")
+
+	connections, err := f.uc.OAuthConnections(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, connections, 1)
+	assert.Equal(t, "google-123", connections[0].ProviderUserID)
+	require.NotNil(t, connections[0].AccessTokenEncrypted)
+	assert.NotContains(t, *connections[0].AccessTokenEncrypted, "provider-access")
+
+	// The same provider account signs straight back in.
+	again, err := f.signIn(t)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, again.User.ID)
+
+	stored := f.events.stored()
+	require.Len(t, stored, 4)
+	assert.Equal(t, auth.EventOAuthLinked, stored[0].Type)
+	assert.Equal(t, auth.EventLoginSuccess, stored[1].Type)
+	assert.Equal(t, auth.EventOAuthLogin, stored[2].Type)
+	assert.Equal(t, "google", stored[2].Details["provider"])
+	assert.Equal(t, auth.EventLoginSuccess, stored[3].Type)
+}
+
+func TestUseCase_OAuthCallback_UnverifiedEmail(t *testing.T) {
+	t.Parallel()
+
+	f := newOAuthFixture(t)
+	f.google.identity.EmailVerified = false
+
+	result, err := f.signIn(t)
+	require.NoError(t, err)
+	assert.Equal(t, auth.StatusPendingVerification, result.User.Status)
+	assert.False(t, result.User.EmailVerified)
+	assert.Len(t, f.notifier.sent(), 1, "the address still has to be confirmed")
+}
+
+func TestUseCase_OAuthCallback_DisposableEmail(t *testing.T) {
+	t.Parallel()
+
+	f := newOAuthFixture(t)
+	f.google.identity.Email = "ada@mx.trashmail.com"
+
+	_, err := f.signIn(t)
+	requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+
+	_, err = f.users.GetByEmail(context.Background(), "ada@mx.trashmail.com")
+	require.ErrorIs(t, err, auth.ErrUserNotFound, "no account is created")
+}
+
+func TestUseCase_OAuthCallback_LinksVerifiedAccount(t *testing.T) {
+	t.Parallel()
+
+	existing := existingUser(auth.StatusActive)
+	existing.EmailVerified = true
+
+	f := newOAuthFixture(t, existing)
+
+	result, err := f.signIn(t)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, result.User.ID)
+
+	connections, err := f.uc.OAuthConnections(context.Background(), existing.ID)
+	require.NoError(t, err)
+	assert.Len(t, connections, 1)
+}
+
+func TestUseCase_OAuthCallback_Locked(t *testing.T) {
+	t.Parallel()
+
+	existing := existingUser(auth.StatusActive)
+	existing.EmailVerified = true
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	existing.LockedUntil = &lockedUntil
+
+	f := newOAuthFixture(t, existing)
+
+	result, err := f.signIn(t)
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeAccountLocked, result.Challenge.Type)
+	assert.Nil(t, result.Tokens)
+	assert.Equal(t, &lockedUntil, existing.LockedUntil, "signing in with a provider doesn't lift the lockout")
+	assert.Empty(t, eventsOfType(f.events, auth.EventAccountUnlocked))
+}
+
+func TestUseCase_OAuthCallback_RequiresMFA(t *testing.T) {
+	t.Parallel()
+
+	existing := existingUser(auth.StatusActive)
+	existing.EmailVerified = true
+
+	entry, _ := pendingTOTP(t, existing.ID)
+	verifiedAt := time.Now()
+	entry.VerifiedAt = &verifiedAt
+
+	users := newMemoryUserRepo(existing)
+	google := &fakeOAuthClient{identity: oauth.Identity{Subject: "google-123", Email: existing.Email, EmailVerified: true}}
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:            users,
+		TOTP:             newMemoryTOTPRepo(entry),
+		OAuthConnections: newMemoryOAuthConnectionRepo(users),
+		OAuth:            map[auth.OAuthProvider]authuc.OAuthClient{auth.OAuthGoogle: google},
+	})
+
+	location, err := uc.AuthorizeOAuth(context.Background(), auth.OAuthAuthorizeInput{Provider: auth.OAuthGoogle})
+	require.NoError(t, err)
+
+	u, err := url.Parse(location)
+	require.NoError(t, err)
+
+	result, err := uc.OAuthCallback(context.Background(), auth.OAuthCallbackInput{
+		Provider: auth.OAuthGoogle,
+		Code:     goodOAuthCode,
+		State:    u.Query().Get("state"),
+	})
+	require.NoError(t, err)
+	assert.Nil(t, result.Tokens)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeMFARequired, result.Challenge.Type)
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_OAuthCallback_Rejects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		prepare  func(t *testing.T, f *oauthFixture) auth.OAuthCallbackInput
+		wantKind apperror.Kind
+		wantCode string
+	}{
+		{
+			name: "forged state",
+			prepare: func(_ *testing.T, _ *oauthFixture) auth.OAuthCallbackInput {
+				return auth.OAuthCallbackInput{State: "not-ours"}
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "OAUTH_STATE_MISMATCH",
+		},
+		{
+			name: "state from another provider",
+			prepare: func(t *testing.T, f *oauthFixture) auth.OAuthCallbackInput {
+				t.Helper()
+
+				return auth.OAuthCallbackInput{State: f.authorize(t, auth.OAuthGitHub, "")}
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "OAUTH_STATE_MISMATCH",
+		},
+		{
+			name: "caller state swapped",
+			prepare: func(t *testing.T, f *oauthFixture) auth.OAuthCallbackInput {
+				t.Helper()
+
+				state := f.authorize(t, auth.OAuthGoogle, "mine")
+
+				return auth.OAuthCallbackInput{State: "theirs" + strings.TrimPrefix(state, "mine")}
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "OAUTH_STATE_MISMATCH",
+		},
+		{
+			name: "different redirect uri",
+			prepare: func(t *testing.T, f *oauthFixture) auth.OAuthCallbackInput {
+				t.Helper()
+
+				return auth.OAuthCallbackInput{State: f.authorize(t, auth.OAuthGoogle, ""), RedirectURI: "https://m.example.com/oauth"}
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "OAUTH_STATE_MISMATCH",
+		},
+		{
+			name: "code rejected by provider",
+			prepare: func(t *testing.T, f *oauthFixture) auth.OAuthCallbackInput {
+				t.Helper()
+
+				return auth.OAuthCallbackInput{State: f.authorize(t, auth.OAuthGoogle, ""), Code: "stale-code"}
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "OAUTH_CODE_INVALID",
+		},
+		{
+			name: "no email from provider",
+			prepare: func(t *testing.T, f *oauthFixture) auth.OAuthCallbackInput {
+				t.Helper()
+
+				f.google.identity.Email = ""
+
+				return auth.OAuthCallbackInput{State: f.authorize(t, auth.OAuthGoogle, "")}
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "OAUTH_ERROR",
+		},
+		{
+			name: "local account not verified",
+			prepare: func(t *testing.T, f *oauthFixture) auth.OAuthCallbackInput {
+				t.Helper()
+
+				require.NoError(t, f.users.Create(context.Background(), existingUser(auth.StatusPendingVerification)))
+
+				return auth.OAuthCallbackInput{State: f.authorize(t, auth.OAuthGoogle, "")}
+			},
+			wantKind: apperror.KindConflict,
+			wantCode: "OAUTH_ACCOUNT_EXISTS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newOAuthFixture(t)
+
+			in := tt.prepare(t, f)
+			in.Provider = auth.OAuthGoogle
+
+			if in.Code == "" {
+				in.Code = goodOAuthCode
+			}
+
+			_, err := f.uc.OAuthCallback(context.Background(), in)
+			requireAppError(t, err, tt.wantKind, tt.wantCode)
+		})
+	}
+}
+
+func TestUseCase_UnlinkOAuth(t *testing.T) {
+	t.Parallel()
+
+	f := newOAuthFixture(t)
+	ctx := context.Background()
+
+	result, err := f.signIn(t)
+	require.NoError(t, err)
+
+	userID := result.User.ID
+
+	err = f.uc.UnlinkOAuth(ctx, userID, auth.OAuthGitHub, auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindNotFound, "OAUTH_NOT_LINKED")
+
+	err = f.uc.UnlinkOAuth(ctx, userID, auth.OAuthGoogle, auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "OAUTH_LAST_METHOD")
+
+	require.NoError(t, f.users.UpdatePassword(ctx, userID, "hashed:SecureP@ss123"))
+	require.NoError(t, f.uc.UnlinkOAuth(ctx, userID, auth.OAuthGoogle, auth.ClientInfo{}))
+
+	connections, err := f.uc.OAuthConnections(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, connections)
+
+	stored := f.events.stored()
+	assert.Equal(t, auth.EventOAuthUnlinked, stored[len(stored)-1].Type)
+}
@@ -0,0 +1,470 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/evrone/go-clean-template/pkg/webauthn"
+	"github.com/google/uuid"
+)
+
+const defaultPasskeyName = "Passkey"
+
+// PasskeyVerifier checks WebAuthn responses for the relying party.
+// *webauthn.RelyingParty implements it.
+type PasskeyVerifier interface {
+	ID() string
+	VerifyRegistration(resp webauthn.RegistrationResponse, challenge []byte, requireUV bool) (*webauthn.Credential, error)
+	VerifyAssertion(resp webauthn.AssertionResponse, challenge, publicKey []byte, requireUV bool) (*webauthn.Assertion, error)
+}
+
+func (uc *UseCase) Passkeys(ctx context.Context, userID uuid.UUID) ([]auth.Passkey, error) {
+	passkeys, err := uc.passkeys.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - Passkeys - uc.passkeys.ListByUserID: %w", err)
+	}
+
+	return passkeys, nil
+}
+
+// StartPasskeyRegistration issues the options for creating a passkey. The
+// user's existing passkeys are excluded so an authenticator is not
+// registered twice.
+func (uc *UseCase) StartPasskeyRegistration(ctx context.Context, userID uuid.UUID) (*auth.PasskeyCreationOptions, error) {
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, apperror.NotFound("User not found", apperror.WithCause(err))
+		}
+
+		return nil, fmt.Errorf("UseCase - StartPasskeyRegistration - uc.users.GetByID: %w", err)
+	}
+
+	passkeys, err := uc.passkeys.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - StartPasskeyRegistration - uc.passkeys.ListByUserID: %w", err)
+	}
+
+	challenge, err := uc.newWebAuthnChallenge(ctx, &auth.WebAuthnChallenge{
+		UserID:   &user.ID,
+		Ceremony: auth.CeremonyRegistration,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := user.Email
+	if user.Name != nil && *user.Name != "" {
+		displayName = *user.Name
+	}
+
+	return &auth.PasskeyCreationOptions{
+		Challenge:        challenge,
+		RPID:             uc.webauthn.ID(),
+		RPName:           uc.cfg.PasskeyRPName,
+		UserHandle:       user.ID[:],
+		UserName:         user.Email,
+		UserDisplayName:  displayName,
+		Algorithms:       webauthn.Algorithms,
+		Timeout:          uc.cfg.PasskeyTimeout,
+		ResidentKey:      "required",
+		UserVerification: "preferred",
+		Attestation:      "none",
+		Exclude:          passkeyDescriptors(passkeys),
+	}, nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator's attestation and
+// stores the new passkey.
+func (uc *UseCase) FinishPasskeyRegistration(ctx context.Context, in auth.PasskeyRegistrationInput) (*auth.Passkey, error) {
+	c, challenge, err := uc.consumeWebAuthnChallenge(ctx, in.ClientDataJSON, auth.CeremonyRegistration)
+	if err != nil {
+		if errors.Is(err, auth.ErrWebAuthnChallengeNotFound) {
+			return nil, errPasskeyRegistrationFailed(err)
+		}
+
+		return nil, err
+	}
+
+	if c.UserID == nil || *c.UserID != in.UserID {
+		return nil, errPasskeyRegistrationFailed(nil)
+	}
+
+	cred, err := uc.webauthn.VerifyRegistration(webauthn.RegistrationResponse{
+		ClientDataJSON:    in.ClientDataJSON,
+		AttestationObject: in.AttestationObject,
+	}, challenge, false)
+	if err != nil {
+		return nil, errPasskeyRegistrationFailed(err)
+	}
+
+	if len(in.CredentialID) > 0 && !bytes.Equal(in.CredentialID, cred.ID) {
+		return nil, errPasskeyRegistrationFailed(nil)
+	}
+
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+
+	p := &auth.Passkey{
+		UserID:       in.UserID,
+		CredentialID: cred.ID,
+		PublicKey:    cred.PublicKey,
+		Name:         name,
+		AAGUID:       cred.AAGUID,
+		SignCount:    int64(cred.SignCount),
+		Transports:   in.Transports,
+		DeviceType:   passkeyDeviceType(in.Transports),
+		BackedUp:     cred.BackedUp,
+	}
+
+	if err = uc.passkeys.Create(ctx, p); err != nil {
+		if errors.Is(err, auth.ErrPasskeyExists) {
+			return nil, apperror.Conflict("This passkey is already registered",
+				apperror.WithCode(codePasskeyRegFailed),
+				apperror.WithCause(err),
+			)
+		}
+
+		return nil, fmt.Errorf("UseCase - FinishPasskeyRegistration - uc.passkeys.Create: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &p.UserID,
+		Type:      auth.EventPasskeyRegistered,
+		Success:   true,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+		Details:   map[string]any{"passkey_id": p.ID.String()},
+	})
+
+	return p, nil
+}
+
+// StartPasskeyLogin issues the options for signing in with a passkey. With a
+// challenge token the passkey completes that login's MFA step and only the
+// user's own passkeys are offered. Otherwise it is a sign-in of its own,
+// which requires user verification and lets the authenticator pick any
+// discoverable passkey; the account is found from the credential at finish,
+// so the response reveals nothing about which emails are registered.
+func (uc *UseCase) StartPasskeyLogin(ctx context.Context, in auth.PasskeyLoginStartInput) (*auth.PasskeyRequestOptions, error) {
+	c := &auth.WebAuthnChallenge{Ceremony: auth.CeremonyAuthentication}
+	opts := &auth.PasskeyRequestOptions{
+		RPID:             uc.webauthn.ID(),
+		Timeout:          uc.cfg.PasskeyTimeout,
+		UserVerification: "required",
+	}
+
+	if in.ChallengeToken != "" {
+		mfa, err := uc.pendingChallenge(ctx, in.ChallengeToken)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = uc.checkChallenge(ctx, mfa, auth.MFAMethodPasskey); err != nil {
+			return nil, err
+		}
+
+		passkeys, err := uc.passkeys.ListByUserID(ctx, mfa.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("UseCase - StartPasskeyLogin - uc.passkeys.ListByUserID: %w", err)
+		}
+
+		c.UserID = &mfa.UserID
+		c.MFAChallengeID = &mfa.ID
+		opts.Allow = passkeyDescriptors(passkeys)
+		opts.UserVerification = "preferred"
+	}
+
+	challenge, err := uc.newWebAuthnChallenge(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Challenge = challenge
+
+	return opts, nil
+}
+
+// FinishPasskeyLogin verifies a passkey assertion and signs the user in, or
+// completes the MFA challenge the ceremony was started for. A passkey used as
+// the first factor has verified the user itself, so no further MFA step
+// follows. A signature counter that fails to advance suggests a cloned
+// authenticator and is refused.
+func (uc *UseCase) FinishPasskeyLogin(ctx context.Context, in auth.PasskeyLoginInput) (*auth.AuthResult, error) {
+	c, challenge, err := uc.consumeWebAuthnChallenge(ctx, in.ClientDataJSON, auth.CeremonyAuthentication)
+	if err != nil {
+		if errors.Is(err, auth.ErrWebAuthnChallengeNotFound) {
+			return nil, errPasskeyAuthFailed(err)
+		}
+
+		return nil, err
+	}
+
+	var mfa *auth.MFAChallenge
+
+	switch {
+	case c.MFAChallengeID != nil:
+		if mfa, err = uc.pendingChallenge(ctx, in.ChallengeToken); err != nil {
+			return nil, err
+		}
+
+		if mfa.ID != *c.MFAChallengeID {
+			return nil, errPasskeyAuthFailed(nil)
+		}
+	case in.ChallengeToken != "":
+		return nil, errPasskeyAuthFailed(nil)
+	}
+
+	p, err := uc.passkeys.GetByCredentialID(ctx, in.CredentialID)
+	if err != nil {
+		if errors.Is(err, auth.ErrPasskeyNotFound) {
+			return nil, errPasskeyAuthFailed(err)
+		}
+
+		return nil, fmt.Errorf("UseCase - FinishPasskeyLogin - uc.passkeys.GetByCredentialID: %w", err)
+	}
+
+	// A discoverable sign-in names the account only through the user handle,
+	// which has to match the credential's owner.
+	if c.UserID == nil && len(in.UserHandle) == 0 {
+		return nil, errPasskeyAuthFailed(nil)
+	}
+
+	if (c.UserID != nil && *c.UserID != p.UserID) || (len(in.UserHandle) > 0 && !bytes.Equal(in.UserHandle, p.UserID[:])) {
+		return nil, errPasskeyAuthFailed(nil)
+	}
+
+	assertion, err := uc.webauthn.VerifyAssertion(webauthn.AssertionResponse{
+		ClientDataJSON:    in.ClientDataJSON,
+		AuthenticatorData: in.AuthenticatorData,
+		Signature:         in.Signature,
+		UserHandle:        in.UserHandle,
+	}, challenge, p.PublicKey, mfa == nil)
+	if err != nil {
+		return nil, uc.passkeyRejected(ctx, p, mfa, err, in.Client)
+	}
+
+	if signCount := int64(assertion.SignCount); (signCount != 0 || p.SignCount != 0) && signCount <= p.SignCount {
+		uc.recordEvent(ctx, &auth.SecurityEvent{
+			UserID:    &p.UserID,
+			Type:      auth.EventSuspiciousActivity,
+			Success:   false,
+			RiskLevel: auth.RiskHigh,
+			IPAddress: optional(in.Client.IPAddress),
+			UserAgent: optional(in.Client.UserAgent),
+			Details: map[string]any{
+				"reason":              "passkey_sign_count_regression",
+				"passkey_id":          p.ID.String(),
+				"stored_sign_count":   p.SignCount,
+				"received_sign_count": signCount,
+			},
+		})
+
+		return nil, errPasskeyAuthFailed(nil)
+	}
+
+	if err = uc.passkeys.RecordUse(ctx, p.ID, int64(assertion.SignCount), assertion.BackedUp, uc.now().UTC()); err != nil {
+		return nil, fmt.Errorf("UseCase - FinishPasskeyLogin - uc.passkeys.RecordUse: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &p.UserID,
+		Type:      auth.EventPasskeyUsed,
+		Success:   true,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+		Details:   map[string]any{"passkey_id": p.ID.String()},
+	})
+
+	if mfa != nil {
+		return uc.passChallenge(ctx, mfa, auth.MFAMethodPasskey, in.Client)
+	}
+
+	user, err := uc.users.GetByID(ctx, p.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, errPasskeyAuthFailed(err)
+		}
+
+		return nil, fmt.Errorf("UseCase - FinishPasskeyLogin - uc.users.GetByID: %w", err)
+	}
+
+	if uc.signInBlocked(user) {
+		return nil, apperror.Forbidden("Account has been disabled", apperror.WithCode(codeAccountDisabled))
+	}
+
+	if locked := uc.lockedOut(ctx, user, in.Client); locked != nil {
+		return locked, nil
+	}
+
+	return uc.finishLogin(ctx, user, in.RememberMe, in.Client)
+}
+
+func (uc *UseCase) RenamePasskey(ctx context.Context, userID, id uuid.UUID, name string) (*auth.Passkey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, apperror.Validation("Passkey name is required", apperror.WithField("name", "must not be blank"))
+	}
+
+	p, err := uc.passkeys.Rename(ctx, userID, id, name)
+	if err != nil {
+		if errors.Is(err, auth.ErrPasskeyNotFound) {
+			return nil, errPasskeyNotFound()
+		}
+
+		return nil, fmt.Errorf("UseCase - RenamePasskey - uc.passkeys.Rename: %w", err)
+	}
+
+	return p, nil
+}
+
+// DeletePasskey removes a passkey, unless it is the last way the user has to
+// sign in.
+func (uc *UseCase) DeletePasskey(ctx context.Context, userID, id uuid.UUID, client auth.ClientInfo) error {
+	passkeys, err := uc.passkeys.ListByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - DeletePasskey - uc.passkeys.ListByUserID: %w", err)
+	}
+
+	if !slices.ContainsFunc(passkeys, func(p auth.Passkey) bool { return p.ID == id }) {
+		return errPasskeyNotFound()
+	}
+
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - DeletePasskey - uc.users.GetByID: %w", err)
+	}
+
+	connections, err := uc.oauthConnections.ListByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - DeletePasskey - uc.oauthConnections.ListByUserID: %w", err)
+	}
+
+	if loginMethodCount(user, connections, passkeys) <= 1 {
+		return apperror.Validation("This is your only way to sign in; set a password or add another passkey first",
+			apperror.WithCode(codePasskeyLastMethod))
+	}
+
+	if err = uc.passkeys.Delete(ctx, userID, id); err != nil {
+		if errors.Is(err, auth.ErrPasskeyNotFound) {
+			return errPasskeyNotFound()
+		}
+
+		return fmt.Errorf("UseCase - DeletePasskey - uc.passkeys.Delete: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &userID,
+		Type:      auth.EventPasskeyRemoved,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"passkey_id": id.String()},
+	})
+
+	return nil
+}
+
+// passkeyRejected records an assertion that failed verification, counting it
+// against the MFA challenge when there is one.
+func (uc *UseCase) passkeyRejected(ctx context.Context, p *auth.Passkey, mfa *auth.MFAChallenge, cause error, client auth.ClientInfo) error {
+	if mfa != nil {
+		if err := uc.failChallenge(ctx, mfa, auth.MFAMethodPasskey, client); err != nil {
+			return err
+		}
+
+		return errPasskeyAuthFailed(cause)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &p.UserID,
+		Type:      auth.EventPasskeyUsed,
+		Success:   false,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"passkey_id": p.ID.String()},
+	})
+
+	return errPasskeyAuthFailed(cause)
+}
+
+// newWebAuthnChallenge stores a pending ceremony and returns its raw challenge.
+func (uc *UseCase) newWebAuthnChallenge(ctx context.Context, c *auth.WebAuthnChallenge) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - newWebAuthnChallenge - webauthn.NewChallenge: %w", err)
+	}
+
+	c.ChallengeHash = webAuthnChallengeHash(challenge)
+	c.ExpiresAt = uc.now().UTC().Add(uc.cfg.PasskeyTimeout)
+
+	if err = uc.webauthnChallenges.Store(ctx, c); err != nil {
+		return nil, fmt.Errorf("UseCase - newWebAuthnChallenge - uc.webauthnChallenges.Store: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// consumeWebAuthnChallenge finds the pending ceremony a response's client
+// data answers and uses it up. It returns auth.ErrWebAuthnChallengeNotFound
+// when there is none or it has expired.
+func (uc *UseCase) consumeWebAuthnChallenge(ctx context.Context, clientDataJSON []byte, ceremony auth.WebAuthnCeremony) (*auth.WebAuthnChallenge, []byte, error) {
+	challenge, err := webauthn.ClientChallenge(clientDataJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", auth.ErrWebAuthnChallengeNotFound, err)
+	}
+
+	c, err := uc.webauthnChallenges.Consume(ctx, webAuthnChallengeHash(challenge), ceremony)
+	if err != nil {
+		if errors.Is(err, auth.ErrWebAuthnChallengeNotFound) {
+			return nil, nil, err
+		}
+
+		return nil, nil, fmt.Errorf("UseCase - consumeWebAuthnChallenge - uc.webauthnChallenges.Consume: %w", err)
+	}
+
+	if c.IsExpired(uc.now()) {
+		return nil, nil, auth.ErrWebAuthnChallengeNotFound
+	}
+
+	return c, challenge, nil
+}
+
+func webAuthnChallengeHash(challenge []byte) string {
+	return token.Hash(base64.RawURLEncoding.EncodeToString(challenge))
+}
+
+func passkeyDescriptors(passkeys []auth.Passkey) []auth.PasskeyDescriptor {
+	descriptors := make([]auth.PasskeyDescriptor, len(passkeys))
+	for i, p := range passkeys {
+		descriptors[i] = auth.PasskeyDescriptor{ID: p.CredentialID, Transports: p.Transports}
+	}
+
+	return descriptors
+}
+
+// passkeyDeviceType infers where a passkey lives from the transports the
+// browser reported for it.
+func passkeyDeviceType(transports []string) *auth.PasskeyDeviceType {
+	if len(transports) == 0 {
+		return nil
+	}
+
+	t := auth.PasskeyCrossPlatform
+	if slices.Contains(transports, "internal") {
+		t = auth.PasskeyPlatform
+	}
+
+	return &t
+}
@@ -0,0 +1,355 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/webauthn"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type passkeyFixture struct {
+	uc       *authuc.UseCase
+	user     *auth.User
+	passkeys *memoryPasskeyRepo
+	verifier *fakePasskeyVerifier
+	events   *mockSecurityEventRepo
+}
+
+func newPasskeyFixture(t *testing.T, user *auth.User, smsFactors ...*auth.SMSFactor) *passkeyFixture {
+	t.Helper()
+
+	for _, f := range smsFactors {
+		f.UserID = user.ID
+	}
+
+	f := &passkeyFixture{
+		user:     user,
+		passkeys: newMemoryPasskeyRepo(),
+		verifier: &fakePasskeyVerifier{credential: webauthn.Credential{
+			ID:        []byte("credential-1"),
+			PublicKey: []byte("cose-key-1"),
+			AAGUID:    make([]byte, 16),
+			SignCount: 1,
+		}},
+		events: &mockSecurityEventRepo{},
+	}
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: &mockUserRepo{
+			getByEmailFunc: func(_ context.Context, _ string) (*auth.User, error) {
+				return user, nil
+			},
+			getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+				return user, nil
+			},
+		},
+		SMSFactors:     newMemorySMSFactorRepo(smsFactors...),
+		Passkeys:       f.passkeys,
+		WebAuthn:       f.verifier,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+// register runs a registration ceremony for the verifier's credential.
+func (f *passkeyFixture) register(t *testing.T) *auth.Passkey {
+	t.Helper()
+
+	ctx := context.Background()
+
+	opts, err := f.uc.StartPasskeyRegistration(ctx, f.user.ID)
+	require.NoError(t, err)
+
+	p, err := f.uc.FinishPasskeyRegistration(ctx, auth.PasskeyRegistrationInput{
+		UserID:            f.user.ID,
+		CredentialID:      f.verifier.credential.ID,
+		ClientDataJSON:    clientDataFor(opts.Challenge),
+		AttestationObject: []byte("attestation"),
+		Transports:        []string{"internal", "hybrid"},
+	})
+	require.NoError(t, err)
+
+	return p
+}
+
+func (f *passkeyFixture) eventTypes() []auth.SecurityEventType {
+	var types []auth.SecurityEventType
+	for _, e := range f.events.stored() {
+		types = append(types, e.Type)
+	}
+
+	return types
+}
+
+func clientDataFor(challenge []byte) []byte {
+	return []byte(`{"type":"webauthn","challenge":"` + base64.RawURLEncoding.EncodeToString(challenge) + `"}`)
+}
+
+func TestUseCase_PasskeyRegistration(t *testing.T) {
+	t.Parallel()
+
+	f := newPasskeyFixture(t, existingUser(auth.StatusActive))
+	ctx := context.Background()
+
+	p := f.register(t)
+	assert.Equal(t, "Passkey", p.Name)
+	assert.Equal(t, int64(1), p.SignCount)
+	require.NotNil(t, p.DeviceType)
+	assert.Equal(t, auth.PasskeyPlatform, *p.DeviceType)
+
+	opts, err := f.uc.StartPasskeyRegistration(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", opts.RPID)
+	assert.Equal(t, f.user.ID[:], opts.UserHandle)
+	assert.Len(t, opts.Challenge, webauthn.ChallengeLength)
+	require.Len(t, opts.Exclude, 1, "registered passkeys are excluded")
+	assert.Equal(t, p.CredentialID, opts.Exclude[0].ID)
+
+	in := auth.PasskeyRegistrationInput{
+		UserID:         f.user.ID,
+		ClientDataJSON: clientDataFor(opts.Challenge),
+	}
+
+	_, err = f.uc.FinishPasskeyRegistration(ctx, in)
+	requireAppError(t, err, apperror.KindConflict, "PASSKEY_REGISTRATION_FAILED")
+
+	// The challenge was used up by the attempt above.
+	_, err = f.uc.FinishPasskeyRegistration(ctx, in)
+	requireAppError(t, err, apperror.KindValidation, "PASSKEY_REGISTRATION_FAILED")
+
+	assert.Equal(t, []auth.SecurityEventType{auth.EventPasskeyRegistered}, f.eventTypes())
+}
+
+func TestUseCase_PasskeyRegistration_Rejected(t *testing.T) {
+	t.Parallel()
+
+	f := newPasskeyFixture(t, existingUser(auth.StatusActive))
+	ctx := context.Background()
+
+	opts, err := f.uc.StartPasskeyRegistration(ctx, f.user.ID)
+	require.NoError(t, err)
+
+	// A ceremony started by one user cannot be finished by another.
+	_, err = f.uc.FinishPasskeyRegistration(ctx, auth.PasskeyRegistrationInput{
+		UserID:         uuid.New(),
+		ClientDataJSON: clientDataFor(opts.Challenge),
+	})
+	requireAppError(t, err, apperror.KindValidation, "PASSKEY_REGISTRATION_FAILED")
+
+	f.verifier.reject = true
+
+	opts, err = f.uc.StartPasskeyRegistration(ctx, f.user.ID)
+	require.NoError(t, err)
+
+	_, err = f.uc.FinishPasskeyRegistration(ctx, auth.PasskeyRegistrationInput{
+		UserID:         f.user.ID,
+		ClientDataJSON: clientDataFor(opts.Challenge),
+	})
+	requireAppError(t, err, apperror.KindValidation, "PASSKEY_REGISTRATION_FAILED")
+
+	passkeys, err := f.uc.Passkeys(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, passkeys)
+}
+
+func TestUseCase_PasskeyLogin(t *testing.T) {
+	t.Parallel()
+
+	f := newPasskeyFixture(t, existingUser(auth.StatusActive), verifiedSMSFactor())
+	ctx := context.Background()
+
+	p := f.register(t)
+	f.verifier.signCount = 5
+
+	opts, err := f.uc.StartPasskeyLogin(ctx, auth.PasskeyLoginStartInput{})
+	require.NoError(t, err)
+	assert.Empty(t, opts.Allow, "discoverable sign-in offers any passkey")
+	assert.Equal(t, "required", opts.UserVerification)
+
+	in := auth.PasskeyLoginInput{
+		CredentialID:   p.CredentialID,
+		ClientDataJSON: clientDataFor(opts.Challenge),
+		UserHandle:     f.user.ID[:],
+	}
+
+	// A passkey is a sign-in of its own: no MFA step follows, even with SMS enabled.
+	result, err := f.uc.FinishPasskeyLogin(ctx, in)
+	require.NoError(t, err)
+	assert.Nil(t, result.Challenge)
+	require.NotNil(t, result.Tokens)
+	assert.True(t, f.verifier.lastRequireUV())
+
+	_, err = f.uc.FinishPasskeyLogin(ctx, in)
+	requireAppError(t, err, apperror.KindUnauthorized, "PASSKEY_AUTH_FAILED")
+
+	passkeys, err := f.uc.Passkeys(ctx, f.user.ID)
+	require.NoError(t, err)
+	require.Len(t, passkeys, 1)
+	assert.Equal(t, int64(5), passkeys[0].SignCount)
+	assert.NotNil(t, passkeys[0].LastUsedAt)
+
+	// Without the user handle the passkey can't be tied to an account.
+	opts, err = f.uc.StartPasskeyLogin(ctx, auth.PasskeyLoginStartInput{})
+	require.NoError(t, err)
+
+	_, err = f.uc.FinishPasskeyLogin(ctx, auth.PasskeyLoginInput{
+		CredentialID:   p.CredentialID,
+		ClientDataJSON: clientDataFor(opts.Challenge),
+	})
+	requireAppError(t, err, apperror.KindUnauthorized, "PASSKEY_AUTH_FAILED")
+}
+
+func TestUseCase_PasskeyLogin_Locked(t *testing.T) {
+	t.Parallel()
+
+	f := newPasskeyFixture(t, existingUser(auth.StatusActive))
+	ctx := context.Background()
+
+	p := f.register(t)
+	f.verifier.signCount = 5
+
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	f.user.LockedUntil = &lockedUntil
+
+	opts, err := f.uc.StartPasskeyLogin(ctx, auth.PasskeyLoginStartInput{})
+	require.NoError(t, err)
+
+	result, err := f.uc.FinishPasskeyLogin(ctx, auth.PasskeyLoginInput{
+		CredentialID:   p.CredentialID,
+		ClientDataJSON: clientDataFor(opts.Challenge),
+		UserHandle:     f.user.ID[:],
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeAccountLocked, result.Challenge.Type)
+	assert.Nil(t, result.Tokens)
+	assert.Equal(t, &lockedUntil, f.user.LockedUntil, "a passkey doesn't lift the lockout")
+	assert.NotContains(t, f.eventTypes(), auth.EventAccountUnlocked)
+}
+
+func TestUseCase_PasskeyLogin_SignCountRegression(t *testing.T) {
+	t.Parallel()
+
+	f := newPasskeyFixture(t, existingUser(auth.StatusActive))
+	ctx := context.Background()
+
+	p := f.register(t)
+
+	opts, err := f.uc.StartPasskeyLogin(ctx, auth.PasskeyLoginStartInput{})
+	require.NoError(t, err)
+
+	_, err = f.uc.FinishPasskeyLogin(ctx, auth.PasskeyLoginInput{
+		CredentialID:   p.CredentialID,
+		ClientDataJSON: clientDataFor(opts.Challenge),
+		UserHandle:     f.user.ID[:],
+	})
+	requireAppError(t, err, apperror.KindUnauthorized, "PASSKEY_AUTH_FAILED")
+
+	events := f.events.stored()
+	require.Len(t, events, 2)
+	assert.Equal(t, auth.EventSuspiciousActivity, events[1].Type)
+	assert.Equal(t, auth.RiskHigh, events[1].RiskLevel)
+	assert.Equal(t, "passkey_sign_count_regression", events[1].Details["reason"])
+}
+
+func TestUseCase_PasskeyLogin_MFA(t *testing.T) {
+	t.Parallel()
+
+	f := newPasskeyFixture(t, existingUser(auth.StatusActive), verifiedSMSFactor())
+	ctx := context.Background()
+
+	p := f.register(t)
+	f.verifier.signCount = 2
+
+	result, err := f.uc.Login(ctx, auth.LoginInput{Email: f.user.Email, Password: "SecureP@ss123"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, []string{"sms", "passkey"}, result.Challenge.AvailableMethods)
+
+	challengeToken := result.Challenge.Token
+
+	opts, err := f.uc.StartPasskeyLogin(ctx, auth.PasskeyLoginStartInput{ChallengeToken: challengeToken})
+	require.NoError(t, err)
+	require.Len(t, opts.Allow, 1)
+	assert.Equal(t, "preferred", opts.UserVerification)
+
+	in := auth.PasskeyLoginInput{
+		CredentialID:   p.CredentialID,
+		ClientDataJSON: clientDataFor(opts.Challenge),
+	}
+
+	// The assertion only counts towards the login it was started for.
+	_, err = f.uc.FinishPasskeyLogin(ctx, in)
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	opts, err = f.uc.StartPasskeyLogin(ctx, auth.PasskeyLoginStartInput{ChallengeToken: challengeToken})
+	require.NoError(t, err)
+
+	in.ClientDataJSON = clientDataFor(opts.Challenge)
+	in.ChallengeToken = challengeToken
+
+	authResult, err := f.uc.FinishPasskeyLogin(ctx, in)
+	require.NoError(t, err)
+	require.NotNil(t, authResult.Tokens)
+	assert.False(t, f.verifier.lastRequireUV(), "the password already verified the user")
+
+	_, err = f.uc.StartPasskeyLogin(ctx, auth.PasskeyLoginStartInput{ChallengeToken: challengeToken})
+	require.Error(t, err, "a completed challenge cannot be reused")
+}
+
+func TestUseCase_RenamePasskey(t *testing.T) {
+	t.Parallel()
+
+	f := newPasskeyFixture(t, existingUser(auth.StatusActive))
+	ctx := context.Background()
+
+	p := f.register(t)
+
+	_, err := f.uc.RenamePasskey(ctx, f.user.ID, p.ID, "  ")
+	requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+
+	_, err = f.uc.RenamePasskey(ctx, uuid.New(), p.ID, "Laptop")
+	requireAppError(t, err, apperror.KindNotFound, "PASSKEY_NOT_FOUND")
+
+	renamed, err := f.uc.RenamePasskey(ctx, f.user.ID, p.ID, " Laptop ")
+	require.NoError(t, err)
+	assert.Equal(t, "Laptop", renamed.Name)
+}
+
+func TestUseCase_DeletePasskey(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	user.PasswordHash = nil
+
+	f := newPasskeyFixture(t, user)
+	ctx := context.Background()
+
+	p := f.register(t)
+
+	err := f.uc.DeletePasskey(ctx, f.user.ID, p.ID, auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "PASSKEY_LAST_METHOD")
+
+	err = f.uc.DeletePasskey(ctx, f.user.ID, uuid.New(), auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindNotFound, "PASSKEY_NOT_FOUND")
+
+	hash := "hashed:SecureP@ss123"
+	user.PasswordHash = &hash
+
+	require.NoError(t, f.uc.DeletePasskey(ctx, f.user.ID, p.ID, auth.ClientInfo{}))
+
+	passkeys, err := f.uc.Passkeys(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, passkeys)
+
+	assert.Equal(t, []auth.SecurityEventType{auth.EventPasskeyRegistered, auth.EventPasskeyRemoved}, f.eventTypes())
+}
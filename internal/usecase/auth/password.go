@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/password"
+	"github.com/evrone/go-clean-template/pkg/token"
+)
+
+const (
+	resetPasswordPath  = "/reset-password"
+	forgotPasswordPath = "/forgot-password"
+
+	passwordResetWindow = time.Hour
+)
+
+// ForgotPassword emails a password reset link. It reports success whether or
+// not the account exists, and silently drops requests over the hourly limit,
+// so the response never reveals which emails are registered.
+func (uc *UseCase) ForgotPassword(ctx context.Context, email string, client auth.ClientInfo) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	user, err := uc.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("UseCase - ForgotPassword - uc.users.GetByEmail: %w", err)
+	}
+
+	if user.Status == auth.StatusDisabled || user.Status == auth.StatusDeleted {
+		return nil
+	}
+
+	now := uc.now().UTC()
+
+	recent, err := uc.resets.CountSince(ctx, user.ID, now.Add(-passwordResetWindow))
+	if err != nil {
+		return fmt.Errorf("UseCase - ForgotPassword - uc.resets.CountSince: %w", err)
+	}
+
+	if recent >= uc.cfg.PasswordResetMaxPerHour {
+		return nil
+	}
+
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return fmt.Errorf("UseCase - ForgotPassword - token.Generate: %w", err)
+	}
+
+	reset := &auth.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: token.Hash(raw),
+		ExpiresAt: now.Add(uc.cfg.PasswordResetTTL),
+	}
+
+	if err = uc.resets.Store(ctx, reset); err != nil {
+		return fmt.Errorf("UseCase - ForgotPassword - uc.resets.Store: %w", err)
+	}
+
+	// Failing here would tell the caller the account exists; delivery errors
+	// are recorded in the delivery log instead.
+	_ = uc.sendEmail(ctx, user, user.Email, resetPasswordTemplate, emailData{
+		Link:      uc.link(resetPasswordPath, raw),
+		ExpiresIn: humanDuration(uc.cfg.PasswordResetTTL),
+	})
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventPasswordResetRequested,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+	})
+
+	return nil
+}
+
+// ResetPassword sets a new password using an emailed reset token and signs the
+// user out everywhere.
+func (uc *UseCase) ResetPassword(ctx context.Context, in auth.ResetPasswordInput) error {
+	reset, err := uc.resets.GetByHash(ctx, token.Hash(in.Token))
+	if err != nil {
+		if errors.Is(err, auth.ErrPasswordResetNotFound) {
+			return errPasswordResetInvalid()
+		}
+
+		return fmt.Errorf("UseCase - ResetPassword - uc.resets.GetByHash: %w", err)
+	}
+
+	if reset.UsedAt != nil {
+		return errPasswordResetInvalid()
+	}
+
+	now := uc.now().UTC()
+
+	if reset.IsExpired(now) {
+		return apperror.Unauthorized("Password reset link has expired", apperror.WithCode(codeResetExpired))
+	}
+
+	user, err := uc.users.GetByID(ctx, reset.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return errPasswordResetInvalid()
+		}
+
+		return fmt.Errorf("UseCase - ResetPassword - uc.users.GetByID: %w", err)
+	}
+
+	if user.Status == auth.StatusDisabled || user.Status == auth.StatusDeleted {
+		return errPasswordResetInvalid()
+	}
+
+	if res := uc.policy.Check(in.Password, user.Email, deref(user.Name)); !res.Valid {
+		return errPasswordTooWeak("password", res)
+	}
+
+	hash, err := uc.hasher.Hash(in.Password)
+	if err != nil {
+		return fmt.Errorf("UseCase - ResetPassword - uc.hasher.Hash: %w", err)
+	}
+
+	if err = uc.resets.Consume(ctx, reset, hash, now); err != nil {
+		if errors.Is(err, auth.ErrPasswordResetUsed) || errors.Is(err, auth.ErrUserNotFound) {
+			return errPasswordResetInvalid()
+		}
+
+		return fmt.Errorf("UseCase - ResetPassword - uc.resets.Consume: %w", err)
+	}
+
+	if err = uc.refreshTokens.RevokeAllForUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("UseCase - ResetPassword - uc.refreshTokens.RevokeAllForUser: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventPasswordResetCompleted,
+		Success:   true,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+	})
+
+	if user.IsLocked(now) {
+		uc.unlocked(ctx, user, "password_reset", in.Client)
+	}
+
+	return nil
+}
+
+// ChangePassword replaces the password of a signed-in user after checking the
+// current one. Every other session is signed out.
+func (uc *UseCase) ChangePassword(ctx context.Context, in auth.ChangePasswordInput) error {
+	user, err := uc.users.GetByID(ctx, in.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return apperror.NotFound("User not found", apperror.WithCause(err))
+		}
+
+		return fmt.Errorf("UseCase - ChangePassword - uc.users.GetByID: %w", err)
+	}
+
+	if !user.HasPassword() {
+		return apperror.Validation("Account has no password; use password reset to set one",
+			apperror.WithCode(codePasswordIncorrect))
+	}
+
+	ok, err := uc.hasher.Verify(in.CurrentPassword, *user.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("UseCase - ChangePassword - uc.hasher.Verify: %w", err)
+	}
+
+	if !ok {
+		uc.recordEvent(ctx, &auth.SecurityEvent{
+			UserID:    &user.ID,
+			Type:      auth.EventPasswordChanged,
+			Success:   false,
+			RiskLevel: auth.RiskMedium,
+			IPAddress: optional(in.Client.IPAddress),
+			UserAgent: optional(in.Client.UserAgent),
+			Details:   map[string]any{"reason": "incorrect_current_password"},
+		})
+
+		return apperror.Validation("Current password is incorrect",
+			apperror.WithCode(codePasswordIncorrect),
+			apperror.WithField("current_password", "is incorrect"),
+		)
+	}
+
+	if in.NewPassword == in.CurrentPassword {
+		return apperror.Validation("New password must differ from the current one",
+			apperror.WithCode(codePasswordSameAsOld),
+			apperror.WithField("new_password", "must differ from the current password"),
+		)
+	}
+
+	if res := uc.policy.Check(in.NewPassword, user.Email, deref(user.Name)); !res.Valid {
+		return errPasswordTooWeak("new_password", res)
+	}
+
+	hash, err := uc.hasher.Hash(in.NewPassword)
+	if err != nil {
+		return fmt.Errorf("UseCase - ChangePassword - uc.hasher.Hash: %w", err)
+	}
+
+	if err = uc.users.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return fmt.Errorf("UseCase - ChangePassword - uc.users.UpdatePassword: %w", err)
+	}
+
+	if _, err = uc.refreshTokens.RevokeOtherFamilies(ctx, user.ID, in.SessionID); err != nil {
+		return fmt.Errorf("UseCase - ChangePassword - uc.refreshTokens.RevokeOtherFamilies: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventPasswordChanged,
+		Success:   true,
+		IPAddress: optional(in.Client.IPAddress),
+		UserAgent: optional(in.Client.UserAgent),
+	})
+
+	return nil
+}
+
+// ValidatePassword scores a candidate password against the policy.
+func (uc *UseCase) ValidatePassword(_ context.Context, pw string) password.Result {
+	return uc.policy.Check(pw)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,339 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_ForgotPassword(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		user     *auth.User
+		recent   int
+		wantSent bool
+	}{
+		{
+			name:     "sends reset link",
+			user:     existingUser(auth.StatusActive),
+			wantSent: true,
+		},
+		{
+			name: "unknown email",
+		},
+		{
+			name: "disabled account",
+			user: existingUser(auth.StatusDisabled),
+		},
+		{
+			name:   "hourly limit reached",
+			user:   existingUser(auth.StatusActive),
+			recent: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stored *auth.PasswordReset
+
+			notifier := &mockEmailNotifier{}
+			events := &mockSecurityEventRepo{}
+
+			uc := newTestUseCase(t, &authuc.UseCaseDeps{
+				Users: &mockUserRepo{
+					getByEmailFunc: func(_ context.Context, _ string) (*auth.User, error) {
+						if tt.user == nil {
+							return nil, auth.ErrUserNotFound
+						}
+
+						return tt.user, nil
+					},
+				},
+				PasswordResets: &mockPasswordResetRepo{
+					countSinceFunc: func(_ context.Context, _ uuid.UUID, _ time.Time) (int, error) {
+						return tt.recent, nil
+					},
+					storeFunc: func(_ context.Context, r *auth.PasswordReset) error {
+						stored = r
+
+						return nil
+					},
+				},
+				SecurityEvents: events,
+				Notifier:       notifier,
+			})
+
+			err := uc.ForgotPassword(context.Background(), "User@Example.com", auth.ClientInfo{})
+			require.NoError(t, err)
+
+			sent := notifier.sent()
+
+			if !tt.wantSent {
+				assert.Empty(t, sent)
+				assert.Nil(t, stored)
+
+				return
+			}
+
+			require.Len(t, sent, 1)
+			require.NotNil(t, stored)
+			assert.Equal(t, tt.user.ID, stored.UserID)
+			assert.Equal(t, stored.TokenHash, token.Hash(linkToken(t, sent[0].Body)))
+			assert.Contains(t, sent[0].Body, "https://app.example.com/reset-password?token=")
+
+			recorded := events.stored()
+			require.Len(t, recorded, 1)
+			assert.Equal(t, auth.EventPasswordResetRequested, recorded[0].Type)
+		})
+	}
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_ResetPassword(t *testing.T) {
+	t.Parallel()
+
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		reset       func(userID uuid.UUID) *auth.PasswordReset
+		password    string
+		consumeErr  error
+		wantKind    apperror.Kind
+		wantCode    string
+		wantConsume bool
+	}{
+		{
+			name:        "success",
+			password:    "NewSecure123",
+			wantConsume: true,
+		},
+		{
+			name: "unknown token",
+			reset: func(_ uuid.UUID) *auth.PasswordReset {
+				return nil
+			},
+			password: "NewSecure123",
+			wantKind: apperror.KindUnauthorized,
+			wantCode: "PASSWORD_RESET_INVALID",
+		},
+		{
+			name: "already used",
+			reset: func(userID uuid.UUID) *auth.PasswordReset {
+				return &auth.PasswordReset{ID: uuid.New(), UserID: userID, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+			},
+			password: "NewSecure123",
+			wantKind: apperror.KindUnauthorized,
+			wantCode: "PASSWORD_RESET_INVALID",
+		},
+		{
+			name: "expired",
+			reset: func(userID uuid.UUID) *auth.PasswordReset {
+				return &auth.PasswordReset{ID: uuid.New(), UserID: userID, ExpiresAt: time.Now().Add(-time.Second)}
+			},
+			password: "NewSecure123",
+			wantKind: apperror.KindUnauthorized,
+			wantCode: "PASSWORD_RESET_EXPIRED",
+		},
+		{
+			name:     "weak password",
+			password: "password123",
+			wantKind: apperror.KindValidation,
+			wantCode: "PASSWORD_TOO_WEAK",
+		},
+		{
+			name:        "consumed concurrently",
+			password:    "NewSecure123",
+			consumeErr:  auth.ErrPasswordResetUsed,
+			wantKind:    apperror.KindUnauthorized,
+			wantCode:    "PASSWORD_RESET_INVALID",
+			wantConsume: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			user := existingUser(auth.StatusActive)
+
+			reset := &auth.PasswordReset{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}
+			if tt.reset != nil {
+				reset = tt.reset(user.ID)
+			}
+
+			var (
+				consumedHash string
+				revokedAll   bool
+			)
+
+			uc := newTestUseCase(t, &authuc.UseCaseDeps{
+				Users: &mockUserRepo{
+					getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+						return user, nil
+					},
+				},
+				RefreshTokens: &mockRefreshTokenRepo{
+					revokeAllForUserFunc: func(_ context.Context, uid uuid.UUID) error {
+						assert.Equal(t, user.ID, uid)
+
+						revokedAll = true
+
+						return nil
+					},
+				},
+				PasswordResets: &mockPasswordResetRepo{
+					getByHashFunc: func(_ context.Context, hash string) (*auth.PasswordReset, error) {
+						if reset == nil || hash != token.Hash("reset-token") {
+							return nil, auth.ErrPasswordResetNotFound
+						}
+
+						return reset, nil
+					},
+					consumeFunc: func(_ context.Context, _ *auth.PasswordReset, passwordHash string, _ time.Time) error {
+						consumedHash = passwordHash
+
+						return tt.consumeErr
+					},
+				},
+			})
+
+			err := uc.ResetPassword(context.Background(), auth.ResetPasswordInput{
+				Token:    "reset-token",
+				Password: tt.password,
+			})
+
+			assert.Equal(t, tt.wantConsume, consumedHash != "")
+
+			if tt.wantCode != "" {
+				requireAppError(t, err, tt.wantKind, tt.wantCode)
+				assert.False(t, revokedAll)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "hashed:"+tt.password, consumedHash)
+			assert.True(t, revokedAll)
+		})
+	}
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_ChangePassword(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		current     string
+		next        string
+		wantKind    apperror.Kind
+		wantCode    string
+		wantUpdated bool
+	}{
+		{
+			name:        "success",
+			current:     "SecureP@ss123",
+			next:        "EvenMoreSecure456",
+			wantUpdated: true,
+		},
+		{
+			name:     "wrong current password",
+			current:  "WrongP@ss123",
+			next:     "EvenMoreSecure456",
+			wantKind: apperror.KindValidation,
+			wantCode: "PASSWORD_INCORRECT",
+		},
+		{
+			name:     "same as old",
+			current:  "SecureP@ss123",
+			next:     "SecureP@ss123",
+			wantKind: apperror.KindValidation,
+			wantCode: "PASSWORD_SAME_AS_OLD",
+		},
+		{
+			name:     "weak new password",
+			current:  "SecureP@ss123",
+			next:     "weak",
+			wantKind: apperror.KindValidation,
+			wantCode: "PASSWORD_TOO_WEAK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			user := existingUser(auth.StatusActive)
+			sessionID := uuid.New()
+
+			var (
+				updatedHash string
+				keptSession uuid.UUID
+			)
+
+			uc := newTestUseCase(t, &authuc.UseCaseDeps{
+				Users: &mockUserRepo{
+					getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+						return user, nil
+					},
+					updatePasswordFunc: func(_ context.Context, _ uuid.UUID, passwordHash string) error {
+						updatedHash = passwordHash
+
+						return nil
+					},
+				},
+				RefreshTokens: &mockRefreshTokenRepo{
+					revokeOtherFunc: func(_ context.Context, _, keep uuid.UUID) (int64, error) {
+						keptSession = keep
+
+						return 1, nil
+					},
+				},
+			})
+
+			err := uc.ChangePassword(context.Background(), auth.ChangePasswordInput{
+				UserID:          user.ID,
+				SessionID:       sessionID,
+				CurrentPassword: tt.current,
+				NewPassword:     tt.next,
+			})
+
+			if tt.wantCode != "" {
+				requireAppError(t, err, tt.wantKind, tt.wantCode)
+				assert.Empty(t, updatedHash)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "hashed:"+tt.next, updatedHash)
+			assert.Equal(t, sessionID, keptSession)
+		})
+	}
+}
+
+func TestUseCase_ValidatePassword(t *testing.T) {
+	t.Parallel()
+
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{})
+
+	assert.True(t, uc.ValidatePassword(context.Background(), "Secure123").Valid)
+
+	weak := uc.ValidatePassword(context.Background(), "secure")
+	assert.False(t, weak.Valid)
+	assert.NotEmpty(t, weak.Feedback)
+}
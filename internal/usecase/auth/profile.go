@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/imaging"
+	"github.com/evrone/go-clean-template/pkg/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	maxNameLength      = 100
+	maxAvatarURLLength = 2048
+)
+
+// avatarExtensions names the files of the formats avatars are stored in.
+var avatarExtensions = map[string]string{
+	imaging.JPEG: "jpg",
+	imaging.PNG:  "png",
+}
+
+// UpdateProfile changes the fields of the input that are set. An empty
+// AvatarURL removes the avatar; an uploaded avatar that is replaced or
+// removed is deleted from storage.
+func (uc *UseCase) UpdateProfile(ctx context.Context, in auth.ProfileUpdateInput) (*auth.User, error) {
+	user, err := uc.Me(ctx, in.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			return nil, apperror.Validation("Invalid name",
+				apperror.WithField("name", fmt.Sprintf("must be 1 to %d characters", maxNameLength)))
+		}
+
+		if deref(user.Name) != name {
+			user.Name = &name
+			changed = append(changed, "name")
+		}
+	}
+
+	var stale []string
+
+	if in.AvatarURL != nil && strings.TrimSpace(*in.AvatarURL) != deref(user.AvatarURL) {
+		avatar := strings.TrimSpace(*in.AvatarURL)
+		if avatar != "" {
+			if err = uc.validateAvatarURL(avatar); err != nil {
+				return nil, err
+			}
+		}
+
+		stale = user.AvatarKeys
+		user.AvatarURL = optional(avatar)
+		user.AvatarKeys = nil
+		changed = append(changed, "avatar_url")
+	}
+
+	if len(changed) == 0 {
+		return user, nil
+	}
+
+	if err = uc.saveProfile(ctx, user, changed, stale, in.Client); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// UploadAvatar makes the uploaded image the user's avatar. Its format is
+// judged from its content, and it is cropped square and stored at each of
+// AvatarSizes, re-encoded so that no metadata from the upload survives.
+func (uc *UseCase) UploadAvatar(ctx context.Context, in auth.AvatarUploadInput) (*auth.User, error) {
+	if int64(len(in.Data)) > uc.cfg.AvatarMaxBytes {
+		return nil, errAvatarTooLarge(fmt.Sprintf("Avatar must be at most %d KB", uc.cfg.AvatarMaxBytes/1024))
+	}
+
+	img, err := imaging.Decode(in.Data, uc.cfg.AvatarMaxSide)
+	if err != nil {
+		if errors.Is(err, imaging.ErrTooLarge) {
+			return nil, errAvatarTooLarge(fmt.Sprintf("Avatar must be at most %d pixels wide and high", uc.cfg.AvatarMaxSide))
+		}
+
+		return nil, errAvatarUnsupported(err)
+	}
+
+	user, err := uc.Me(ctx, in.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := imaging.Format(img)
+	ext := avatarExtensions[contentType]
+	upload := uuid.New()
+	keys := make([]string, 0, len(uc.avatarSizes))
+
+	for _, size := range uc.avatarSizes {
+		var buf bytes.Buffer
+
+		if err = imaging.Encode(&buf, imaging.Square(img, size), contentType); err != nil {
+			_ = uc.removeAvatar(ctx, keys)
+
+			return nil, fmt.Errorf("UseCase - UploadAvatar - imaging.Encode: %w", err)
+		}
+
+		key := fmt.Sprintf("avatars/%s/%s/%d.%s", user.ID, upload, size, ext)
+
+		if err = uc.storage.Put(ctx, key, buf.Bytes(), contentType); err != nil {
+			_ = uc.removeAvatar(ctx, keys)
+
+			return nil, fmt.Errorf("UseCase - UploadAvatar - uc.storage.Put: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	avatarURL := fmt.Sprintf("%s/%s/%s.%s", strings.TrimRight(uc.cfg.AvatarBaseURL, "/"), user.ID, upload, ext)
+	stale := user.AvatarKeys
+
+	user.AvatarURL = &avatarURL
+	user.AvatarKeys = keys
+
+	if err = uc.saveProfile(ctx, user, []string{"avatar_url"}, stale, in.Client); err != nil {
+		_ = uc.removeAvatar(ctx, keys)
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RemoveAvatar clears the user's avatar.
+func (uc *UseCase) RemoveAvatar(ctx context.Context, userID uuid.UUID, client auth.ClientInfo) (*auth.User, error) {
+	none := ""
+
+	return uc.UpdateProfile(ctx, auth.ProfileUpdateInput{UserID: userID, AvatarURL: &none, Client: client})
+}
+
+// Avatar opens an uploaded avatar in the smallest stored size of at least
+// in.Size pixels, or the largest one there is.
+func (uc *UseCase) Avatar(ctx context.Context, in auth.AvatarInput) (*auth.AvatarImage, error) {
+	var contentType string
+
+	for ct, ext := range avatarExtensions {
+		if ext == in.Extension {
+			contentType = ct
+		}
+	}
+
+	if contentType == "" || len(uc.avatarSizes) == 0 {
+		return nil, errAvatarNotFound()
+	}
+
+	size := uc.avatarSizes[len(uc.avatarSizes)-1]
+
+	if in.Size > 0 {
+		if i, _ := slices.BinarySearch(uc.avatarSizes, in.Size); i < len(uc.avatarSizes) {
+			size = uc.avatarSizes[i]
+		}
+	}
+
+	body, err := uc.storage.Get(ctx, fmt.Sprintf("avatars/%s/%s/%d.%s", in.UserID, in.UploadID, size, in.Extension))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, errAvatarNotFound()
+		}
+
+		return nil, fmt.Errorf("UseCase - Avatar - uc.storage.Get: %w", err)
+	}
+
+	return &auth.AvatarImage{ContentType: contentType, Body: body}, nil
+}
+
+// saveProfile stores the changed profile, deletes the stale avatar files it
+// no longer points to and audits which fields changed.
+func (uc *UseCase) saveProfile(ctx context.Context, user *auth.User, changed, stale []string, client auth.ClientInfo) error {
+	if err := uc.users.UpdateProfile(ctx, user); err != nil {
+		return fmt.Errorf("UseCase - saveProfile - uc.users.UpdateProfile: %w", err)
+	}
+
+	// Nothing points at the old files any more, so one that can't be deleted
+	// is only wasted space.
+	_ = uc.removeAvatar(ctx, stale)
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventProfileUpdated,
+		Success:   true,
+		RiskLevel: auth.RiskLow,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"fields": changed},
+	})
+
+	return nil
+}
+
+// removeAvatar deletes the stored files of an uploaded avatar.
+func (uc *UseCase) removeAvatar(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := uc.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("UseCase - removeAvatar - uc.storage.Delete: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateAvatarURL accepts absolute https URLs hosted elsewhere. Uploaded
+// avatars are set through UploadAvatar, so their URLs can't be pointed at.
+func (uc *UseCase) validateAvatarURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" || len(raw) > maxAvatarURLLength {
+		return apperror.Validation("Invalid avatar URL",
+			apperror.WithField("avatar_url", fmt.Sprintf("must be an https URL of at most %d characters", maxAvatarURLLength)))
+	}
+
+	if base := strings.TrimRight(uc.cfg.AvatarBaseURL, "/"); base != "" && strings.HasPrefix(raw, base+"/") {
+		return apperror.Validation("Invalid avatar URL",
+			apperror.WithField("avatar_url", "upload the image instead"))
+	}
+
+	return nil
+}
+
+// avatarSizes sorts the configured sizes, dropping duplicates and sizes
+// that aren't positive.
+func avatarSizes(sizes []int) []int {
+	sorted := make([]int, 0, len(sizes))
+
+	for _, s := range sizes {
+		if s > 0 {
+			sorted = append(sorted, s)
+		}
+	}
+
+	slices.Sort(sorted)
+
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,301 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type profileFixture struct {
+	uc      *authuc.UseCase
+	user    *auth.User
+	storage *memoryStorage
+	events  *mockSecurityEventRepo
+}
+
+func newProfileFixture(t *testing.T) *profileFixture {
+	t.Helper()
+
+	f := &profileFixture{
+		user:    existingUser(auth.StatusActive),
+		storage: newMemoryStorage(),
+		events:  &mockSecurityEventRepo{},
+	}
+
+	userRepo := newMemoryUserRepo(f.user)
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users:          userRepo,
+		Deletions:      newMemoryAccountDeletionRepo(userRepo),
+		Storage:        f.storage,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+// testImage encodes a w×h image, as a JPEG when opaque and a PNG otherwise.
+func testImage(t *testing.T, w, h int, c color.RGBA) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+
+	var buf bytes.Buffer
+
+	if c.A == 255 {
+		require.NoError(t, jpeg.Encode(&buf, img, nil))
+	} else {
+		require.NoError(t, png.Encode(&buf, img))
+	}
+
+	return buf.Bytes()
+}
+
+func TestUseCase_UpdateProfile(t *testing.T) {
+	t.Parallel()
+
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name      string
+		in        auth.ProfileUpdateInput
+		wantName  string
+		wantURL   string
+		wantField string
+	}{
+		{
+			name:     "name is trimmed",
+			in:       auth.ProfileUpdateInput{Name: str("  Jane Doe ")},
+			wantName: "Jane Doe",
+		},
+		{
+			name:    "external avatar",
+			in:      auth.ProfileUpdateInput{AvatarURL: str("https://cdn.example.org/jane.png")},
+			wantURL: "https://cdn.example.org/jane.png",
+		},
+		{
+			name:      "blank name",
+			in:        auth.ProfileUpdateInput{Name: str("   ")},
+			wantField: "name",
+		},
+		{
+			name:      "long name",
+			in:        auth.ProfileUpdateInput{Name: str(strings.Repeat("é", 101))},
+			wantField: "name",
+		},
+		{
+			name:      "plain http avatar",
+			in:        auth.ProfileUpdateInput{AvatarURL: str("http://cdn.example.org/jane.png")},
+			wantField: "avatar_url",
+		},
+		{
+			name:      "relative avatar",
+			in:        auth.ProfileUpdateInput{AvatarURL: str("/jane.png")},
+			wantField: "avatar_url",
+		},
+		{
+			name:      "another user's upload",
+			in:        auth.ProfileUpdateInput{AvatarURL: str("https://api.example.com/v1/avatars/" + uuid.NewString() + "/x.jpg")},
+			wantField: "avatar_url",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newProfileFixture(t)
+			tt.in.UserID = f.user.ID
+
+			got, err := f.uc.UpdateProfile(context.Background(), tt.in)
+			if tt.wantField != "" {
+				requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+
+				appErr, _ := apperror.AsAppError(err)
+				assert.Contains(t, appErr.Fields(), tt.wantField)
+				assert.Empty(t, eventsOfType(f.events, auth.EventProfileUpdated))
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tt.wantName != "" {
+				require.NotNil(t, got.Name)
+				assert.Equal(t, tt.wantName, *got.Name)
+			}
+
+			if tt.wantURL != "" {
+				require.NotNil(t, got.AvatarURL)
+				assert.Equal(t, tt.wantURL, *got.AvatarURL)
+			}
+
+			events := eventsOfType(f.events, auth.EventProfileUpdated)
+			require.Len(t, events, 1)
+			assert.Equal(t, auth.RiskLow, events[0].RiskLevel)
+		})
+	}
+}
+
+func TestUseCase_UpdateProfile_Unchanged(t *testing.T) {
+	t.Parallel()
+
+	f := newProfileFixture(t)
+	current := "Jane Doe"
+	f.user.Name = &current
+
+	got, err := f.uc.UpdateProfile(context.Background(), auth.ProfileUpdateInput{UserID: f.user.ID, Name: &current})
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", *got.Name)
+	assert.Empty(t, eventsOfType(f.events, auth.EventProfileUpdated), "nothing changed")
+}
+
+func TestUseCase_UploadAvatar(t *testing.T) {
+	t.Parallel()
+
+	f := newProfileFixture(t)
+	ctx := context.Background()
+
+	user, err := f.uc.UploadAvatar(ctx, auth.AvatarUploadInput{
+		UserID: f.user.ID,
+		Data:   testImage(t, 60, 40, color.RGBA{R: 200, A: 128}),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, user.AvatarURL)
+	require.Len(t, user.AvatarKeys, 2, "one file per configured size")
+	assert.Len(t, f.storage.objects, 2)
+
+	prefix := "https://api.example.com/v1/avatars/" + f.user.ID.String() + "/"
+	require.True(t, strings.HasPrefix(*user.AvatarURL, prefix), *user.AvatarURL)
+	require.True(t, strings.HasSuffix(*user.AvatarURL, ".png"), "transparency is kept")
+
+	upload := uuid.MustParse(strings.TrimSuffix(strings.TrimPrefix(*user.AvatarURL, prefix), ".png"))
+
+	for _, tt := range []struct{ ask, want int }{{0, 32}, {10, 16}, {16, 16}, {20, 32}, {500, 32}} {
+		img, err := f.uc.Avatar(ctx, auth.AvatarInput{UserID: f.user.ID, UploadID: upload, Extension: "png", Size: tt.ask})
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", img.ContentType)
+
+		data, err := io.ReadAll(img.Body)
+		require.NoError(t, err)
+		require.NoError(t, img.Body.Close())
+
+		cfg, err := png.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, cfg.Width, "asked for %d", tt.ask)
+		assert.Equal(t, tt.want, cfg.Height, "asked for %d", tt.ask)
+	}
+
+	_, err = f.uc.Avatar(ctx, auth.AvatarInput{UserID: f.user.ID, UploadID: upload, Extension: "jpg"})
+	requireAppError(t, err, apperror.KindNotFound, "NOT_FOUND")
+
+	_, err = f.uc.Avatar(ctx, auth.AvatarInput{UserID: f.user.ID, UploadID: uuid.New(), Extension: "png"})
+	requireAppError(t, err, apperror.KindNotFound, "NOT_FOUND")
+
+	// A new upload replaces the files of the old one.
+	user, err = f.uc.UploadAvatar(ctx, auth.AvatarUploadInput{UserID: f.user.ID, Data: testImage(t, 8, 8, color.RGBA{B: 255, A: 255})})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(*user.AvatarURL, ".jpg"), "opaque images are stored as JPEG")
+	assert.Len(t, f.storage.objects, 2)
+
+	for _, key := range user.AvatarKeys {
+		assert.Contains(t, f.storage.objects, key)
+	}
+
+	// Pointing at the current URL again changes nothing.
+	same := *user.AvatarURL
+	_, err = f.uc.UpdateProfile(ctx, auth.ProfileUpdateInput{UserID: f.user.ID, AvatarURL: &same})
+	require.NoError(t, err)
+	assert.Len(t, f.storage.objects, 2)
+
+	user, err = f.uc.RemoveAvatar(ctx, f.user.ID, auth.ClientInfo{})
+	require.NoError(t, err)
+	assert.Nil(t, user.AvatarURL)
+	assert.Nil(t, user.AvatarKeys)
+	assert.Empty(t, f.storage.objects)
+
+	events := eventsOfType(f.events, auth.EventProfileUpdated)
+	require.Len(t, events, 3)
+	assert.Equal(t, []string{"avatar_url"}, events[2].Details["fields"])
+}
+
+func TestUseCase_UploadAvatar_Rejected(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+		code string
+	}{
+		{
+			name: "too many bytes",
+			data: func(*testing.T) []byte { return make([]byte, 64<<10+1) },
+			code: "AVATAR_TOO_LARGE",
+		},
+		{
+			name: "too many pixels",
+			data: func(t *testing.T) []byte { return testImage(t, 513, 10, color.RGBA{A: 255}) },
+			code: "AVATAR_TOO_LARGE",
+		},
+		{
+			name: "not an image",
+			data: func(*testing.T) []byte { return []byte("<svg xmlns='http://www.w3.org/2000/svg'/>") },
+			code: "AVATAR_UNSUPPORTED",
+		},
+		{
+			name: "truncated",
+			data: func(t *testing.T) []byte { return testImage(t, 20, 20, color.RGBA{A: 255})[:100] },
+			code: "AVATAR_UNSUPPORTED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newProfileFixture(t)
+
+			_, err := f.uc.UploadAvatar(context.Background(), auth.AvatarUploadInput{UserID: f.user.ID, Data: tt.data(t)})
+			requireAppError(t, err, apperror.KindValidation, tt.code)
+			assert.Empty(t, f.storage.objects)
+			assert.Nil(t, f.user.AvatarURL)
+		})
+	}
+}
+
+func TestUseCase_PurgeDeletedAccounts_RemovesAvatar(t *testing.T) {
+	t.Parallel()
+
+	f := newProfileFixture(t)
+	ctx := context.Background()
+
+	_, err := f.uc.UploadAvatar(ctx, auth.AvatarUploadInput{UserID: f.user.ID, Data: testImage(t, 8, 8, color.RGBA{A: 255})})
+	require.NoError(t, err)
+	require.NotEmpty(t, f.storage.objects)
+
+	longAgo := time.Now().Add(-31 * 24 * time.Hour)
+	f.user.Status = auth.StatusDeleted
+	f.user.DeletedAt = &longAgo
+
+	purged, err := f.uc.PurgeDeletedAccounts(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Empty(t, f.storage.objects)
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/evrone/go-clean-template/pkg/logger"
+)
+
+// PurgeWorker periodically erases deleted accounts whose grace period is
+// over, in batches of batchSize until none are left.
+type PurgeWorker struct {
+	uc        *UseCase
+	logger    logger.Interface
+	interval  time.Duration
+	batchSize uint64
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewPurgeWorker(uc *UseCase, l logger.Interface, interval time.Duration, batchSize uint64) *PurgeWorker {
+	return &PurgeWorker{
+		uc:        uc,
+		logger:    l,
+		interval:  interval,
+		batchSize: batchSize,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (w *PurgeWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+
+	w.logger.Info("purge worker - started")
+}
+
+func (w *PurgeWorker) Stop() {
+	close(w.stop)
+	<-w.done
+	w.logger.Info("purge worker - stopped")
+}
+
+func (w *PurgeWorker) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.purge(ctx)
+		}
+	}
+}
+
+func (w *PurgeWorker) purge(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		default:
+		}
+
+		purged, err := w.uc.PurgeDeletedAccounts(ctx, w.batchSize)
+		if purged > 0 {
+			w.logger.Info("purge worker - purged %d accounts", purged)
+		}
+
+		if err != nil {
+			w.logger.Error(err, "purge worker - purge deleted accounts")
+
+			return
+		}
+
+		if uint64(purged) < w.batchSize { //nolint:gosec // purged is never negative
+			return
+		}
+	}
+}
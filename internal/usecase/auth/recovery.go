@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+)
+
+const securitySettingsPath = "/settings/security"
+
+// RecoveryCodeStatus reports how many of the user's recovery codes are left.
+func (uc *UseCase) RecoveryCodeStatus(ctx context.Context, userID uuid.UUID) (*auth.RecoveryCodeStatus, error) {
+	status, err := uc.recoveryCodes.Status(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - RecoveryCodeStatus - uc.recoveryCodes.Status: %w", err)
+	}
+
+	return status, nil
+}
+
+// GenerateRecoveryCodes replaces the user's recovery codes after re-checking
+// the password. The plain codes are returned here and never again.
+func (uc *UseCase) GenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, pw string, client auth.ClientInfo) ([]string, error) {
+	user, err := uc.reauthenticate(ctx, userID, pw, client)
+	if err != nil {
+		return nil, err
+	}
+
+	mfa, err := uc.MFAStatus(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mfa.Enabled() {
+		return nil, apperror.Validation("Enable a second factor before generating recovery codes",
+			apperror.WithCode(codeMFANotEnabled))
+	}
+
+	codes, err := uc.issueRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventRecoveryCodesRegenerated,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+	})
+
+	return codes, nil
+}
+
+// UseRecoveryCode signs a user in with their email and a recovery code, for
+// when the second factor is no longer at hand. Attempts count against the
+// same per-user limit as MFA challenges.
+func (uc *UseCase) UseRecoveryCode(ctx context.Context, in auth.RecoveryCodeLoginInput) (*auth.AuthResult, error) {
+	email, err := normalizeEmail(in.Email)
+	if err != nil {
+		return nil, errRecoveryCodeInvalid()
+	}
+
+	user, err := uc.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, errRecoveryCodeInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - UseRecoveryCode - uc.users.GetByEmail: %w", err)
+	}
+
+	if uc.signInBlocked(user) {
+		return nil, errRecoveryCodeInvalid()
+	}
+
+	if locked := uc.lockedOut(ctx, user, in.Client); locked != nil {
+		return locked, nil
+	}
+
+	mfa, err := uc.MFAStatus(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mfa.Enabled() || mfa.RecoveryCodesRemaining == 0 {
+		return nil, errRecoveryCodeInvalid()
+	}
+
+	methods := []auth.MFAMethod{auth.MFAMethodRecoveryCode}
+
+	c, _, err := uc.newChallenge(ctx, user.ID, methods, false, in.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.completeChallenge(ctx, c, auth.MFAMethodRecoveryCode, in.Code, in.Client)
+}
+
+// issueRecoveryCodes generates a new set of codes in the configured format
+// and stores their hashes in place of the old set.
+func (uc *UseCase) issueRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, uc.cfg.RecoveryCodeCount)
+	hashes := make([]string, uc.cfg.RecoveryCodeCount)
+
+	for i := range codes {
+		code, err := token.Pattern(uc.cfg.RecoveryCodeFormat)
+		if err != nil {
+			return nil, fmt.Errorf("UseCase - issueRecoveryCodes - token.Pattern: %w", err)
+		}
+
+		codes[i] = code
+		hashes[i] = recoveryCodeHash(userID, code)
+	}
+
+	if err := uc.recoveryCodes.Replace(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("UseCase - issueRecoveryCodes - uc.recoveryCodes.Replace: %w", err)
+	}
+
+	return codes, nil
+}
+
+// initialRecoveryCodes issues codes when a user turns on their first second
+// factor. Users who already hold a set keep it and get an empty list back.
+func (uc *UseCase) initialRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	status, err := uc.recoveryCodes.Status(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - initialRecoveryCodes - uc.recoveryCodes.Status: %w", err)
+	}
+
+	if status.Total > 0 {
+		return []string{}, nil
+	}
+
+	return uc.issueRecoveryCodes(ctx, userID)
+}
+
+// dropRecoveryCodesIfUnprotected deletes the user's recovery codes once no
+// second factor is left for them to stand in for.
+func (uc *UseCase) dropRecoveryCodesIfUnprotected(ctx context.Context, userID uuid.UUID) error {
+	mfa, err := uc.MFAStatus(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if mfa.Enabled() {
+		return nil
+	}
+
+	if err = uc.recoveryCodes.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("UseCase - dropRecoveryCodesIfUnprotected - uc.recoveryCodes.DeleteByUserID: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *UseCase) checkRecoveryCode(ctx context.Context, userID uuid.UUID, code string) (bool, error) {
+	err := uc.recoveryCodes.Consume(ctx, userID, recoveryCodeHash(userID, code), uc.now().UTC())
+	if err != nil {
+		if errors.Is(err, auth.ErrRecoveryCodeInvalid) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("UseCase - checkRecoveryCode - uc.recoveryCodes.Consume: %w", err)
+	}
+
+	return true, nil
+}
+
+// recoveryCodeUsed audits a sign-in completed with a recovery code and tells
+// the user by email, since it may mean someone else has their codes.
+func (uc *UseCase) recoveryCodeUsed(ctx context.Context, user *auth.User, client auth.ClientInfo) {
+	remaining := 0
+	if status, err := uc.recoveryCodes.Status(ctx, user.ID); err == nil {
+		remaining = status.Remaining
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventRecoveryCodeUsed,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"remaining": remaining},
+	})
+
+	// The sign-in already succeeded; delivery errors are recorded in the
+	// delivery log.
+	_ = uc.sendEmail(ctx, user, user.Email, recoveryCodeUsedTemplate, emailData{
+		Link:      uc.cfg.AppURL + securitySettingsPath,
+		Remaining: remaining,
+	})
+}
+
+// recoveryCodeHash ignores case and separators so "abcd efgh" matches
+// "ABCD-EFGH", and binds the hash to the user like smsCodeHash.
+func recoveryCodeHash(userID uuid.UUID, code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(token.CodeAlphabet, r) {
+			return r
+		}
+
+		return -1
+	}, strings.ToUpper(code))
+
+	return token.Hash(userID.String() + ":" + normalized)
+}
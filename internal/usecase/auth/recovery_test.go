@@ -0,0 +1,226 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recoveryFixture struct {
+	uc       *authuc.UseCase
+	user     *auth.User
+	events   *mockSecurityEventRepo
+	notifier *mockEmailNotifier
+}
+
+// newRecoveryFixture returns a use case whose only user has TOTP enabled and
+// holds a fresh set of recovery codes.
+func newRecoveryFixture(t *testing.T) (*recoveryFixture, []string) {
+	t.Helper()
+
+	user := existingUser(auth.StatusActive)
+
+	entry, _ := pendingTOTP(t, user.ID)
+	verifiedAt := time.Now()
+	entry.VerifiedAt = &verifiedAt
+
+	f := &recoveryFixture{
+		user:     user,
+		events:   &mockSecurityEventRepo{},
+		notifier: &mockEmailNotifier{},
+	}
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: &mockUserRepo{
+			getByEmailFunc: func(_ context.Context, email string) (*auth.User, error) {
+				if email != user.Email {
+					return nil, auth.ErrUserNotFound
+				}
+
+				return user, nil
+			},
+			getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+				return user, nil
+			},
+		},
+		TOTP:           newMemoryTOTPRepo(entry),
+		SecurityEvents: f.events,
+		Notifier:       f.notifier,
+	})
+
+	codes, err := f.uc.GenerateRecoveryCodes(context.Background(), user.ID, "SecureP@ss123", auth.ClientInfo{})
+	require.NoError(t, err)
+
+	return f, codes
+}
+
+func (f *recoveryFixture) eventTypes() []auth.SecurityEventType {
+	var types []auth.SecurityEventType
+	for _, e := range f.events.stored() {
+		types = append(types, e.Type)
+	}
+
+	return types
+}
+
+func TestUseCase_GenerateRecoveryCodes(t *testing.T) {
+	t.Parallel()
+
+	f, first := newRecoveryFixture(t)
+	ctx := context.Background()
+
+	require.Len(t, first, 4)
+
+	for _, c := range first {
+		assert.Regexp(t, `^[A-HJ-NP-Z2-9]{4}-[A-HJ-NP-Z2-9]{4}$`, c)
+	}
+
+	status, err := f.uc.RecoveryCodeStatus(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, status.Total)
+	assert.Equal(t, 4, status.Remaining)
+
+	_, err = f.uc.GenerateRecoveryCodes(ctx, f.user.ID, "WrongP@ss123", auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "PASSWORD_INCORRECT")
+
+	second, err := f.uc.GenerateRecoveryCodes(ctx, f.user.ID, "SecureP@ss123", auth.ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	// Regenerating invalidates the previous set.
+	_, err = f.uc.UseRecoveryCode(ctx, auth.RecoveryCodeLoginInput{Email: f.user.Email, Code: first[0]})
+	requireAppError(t, err, apperror.KindValidation, "RECOVERY_CODE_INVALID")
+
+	assert.Equal(t, []auth.SecurityEventType{
+		auth.EventRecoveryCodesRegenerated,
+		auth.EventLoginFailed,
+		auth.EventRecoveryCodesRegenerated,
+		auth.EventMFAChallengeFailed,
+	}, f.eventTypes())
+}
+
+func TestUseCase_GenerateRecoveryCodes_RequiresMFA(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: &mockUserRepo{
+			getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+				return user, nil
+			},
+		},
+	})
+
+	_, err := uc.GenerateRecoveryCodes(context.Background(), user.ID, "SecureP@ss123", auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "MFA_NOT_ENABLED")
+}
+
+func TestUseCase_CompleteMFAChallenge_RecoveryCode(t *testing.T) {
+	t.Parallel()
+
+	f, codes := newRecoveryFixture(t)
+	ctx := context.Background()
+
+	result, err := f.uc.Login(ctx, auth.LoginInput{Email: f.user.Email, Password: "SecureP@ss123"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, []string{"totp", "recovery_code"}, result.Challenge.AvailableMethods)
+
+	// Codes are accepted regardless of case and separators.
+	typed := strings.ToLower(strings.ReplaceAll(codes[0], "-", " "))
+
+	authResult, err := f.uc.CompleteMFAChallenge(ctx, auth.MFAChallengeInput{
+		ChallengeToken: result.Challenge.Token,
+		Code:           typed,
+		Method:         auth.MFAMethodRecoveryCode,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, authResult.Tokens)
+
+	status, err := f.uc.RecoveryCodeStatus(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Remaining)
+
+	assert.Equal(t, []auth.SecurityEventType{
+		auth.EventRecoveryCodesRegenerated,
+		auth.EventLoginSuccess,
+		auth.EventMFAChallengeSuccess,
+		auth.EventRecoveryCodeUsed,
+	}, f.eventTypes())
+	assert.Equal(t, 3, f.events.stored()[3].Details["remaining"])
+
+	sent := f.notifier.sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, []string{f.user.Email}, sent[0].To)
+	assert.Contains(t, sent[0].Body, "3 unused recovery codes")
+
+	// Each code works once.
+	result, err = f.uc.Login(ctx, auth.LoginInput{Email: f.user.Email, Password: "SecureP@ss123"})
+	require.NoError(t, err)
+
+	_, err = f.uc.CompleteMFAChallenge(ctx, auth.MFAChallengeInput{
+		ChallengeToken: result.Challenge.Token,
+		Code:           codes[0],
+		Method:         auth.MFAMethodRecoveryCode,
+	})
+	requireAppError(t, err, apperror.KindValidation, "RECOVERY_CODE_INVALID")
+}
+
+func TestUseCase_UseRecoveryCode(t *testing.T) {
+	t.Parallel()
+
+	f, codes := newRecoveryFixture(t)
+	ctx := context.Background()
+
+	_, err := f.uc.UseRecoveryCode(ctx, auth.RecoveryCodeLoginInput{Email: "nobody@example.com", Code: codes[0]})
+	requireAppError(t, err, apperror.KindValidation, "RECOVERY_CODE_INVALID")
+
+	_, err = f.uc.UseRecoveryCode(ctx, auth.RecoveryCodeLoginInput{Email: f.user.Email, Code: "AAAA-AAAA"})
+	requireAppError(t, err, apperror.KindValidation, "RECOVERY_CODE_INVALID")
+
+	result, err := f.uc.UseRecoveryCode(ctx, auth.RecoveryCodeLoginInput{Email: f.user.Email, Code: codes[1]})
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	assert.Contains(t, f.eventTypes(), auth.EventRecoveryCodeUsed)
+}
+
+func TestUseCase_UseRecoveryCode_Locked(t *testing.T) {
+	t.Parallel()
+
+	f, codes := newRecoveryFixture(t)
+	ctx := context.Background()
+
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	f.user.LockedUntil = &lockedUntil
+
+	result, err := f.uc.UseRecoveryCode(ctx, auth.RecoveryCodeLoginInput{Email: f.user.Email, Code: codes[0]})
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, auth.ChallengeAccountLocked, result.Challenge.Type)
+	assert.Nil(t, result.Tokens)
+
+	status, err := f.uc.RecoveryCodeStatus(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, status.Remaining, "the code is not spent")
+}
+
+func TestUseCase_DisableLastFactorDropsRecoveryCodes(t *testing.T) {
+	t.Parallel()
+
+	f, _ := newRecoveryFixture(t)
+	ctx := context.Background()
+
+	require.NoError(t, f.uc.DisableTOTP(ctx, f.user.ID, "SecureP@ss123", auth.ClientInfo{}))
+
+	status, err := f.uc.RecoveryCodeStatus(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, status.Total)
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+)
+
+// Refresh exchanges a refresh token for a new token pair. Every use rotates the
+// token within its family; presenting a token that was already rotated while
+// the family is still signed in means it leaked, so the whole family is
+// revoked.
+func (uc *UseCase) Refresh(ctx context.Context, in auth.RefreshInput) (*auth.TokenPair, error) {
+	current, err := uc.refreshTokens.GetByHash(ctx, token.Hash(in.RefreshToken))
+	if err != nil {
+		if errors.Is(err, auth.ErrRefreshTokenNotFound) {
+			return nil, errRefreshTokenInvalid()
+		}
+
+		return nil, fmt.Errorf("UseCase - Refresh - uc.refreshTokens.GetByHash: %w", err)
+	}
+
+	if current.RevokedAt != nil {
+		// last_used_at is only set by rotation, so a revoked token without it
+		// was merely logged out and is not a reuse signal. Neither is a rotated
+		// token of a session that has since been signed out.
+		if current.LastUsedAt != nil {
+			active, err := uc.refreshTokens.FamilyActive(ctx, current.UserID, current.FamilyID)
+			if err != nil {
+				return nil, fmt.Errorf("UseCase - Refresh - uc.refreshTokens.FamilyActive: %w", err)
+			}
+
+			if active {
+				return nil, uc.handleReuse(ctx, current, in.Client)
+			}
+		}
+
+		return nil, errRefreshTokenInvalid()
+	}
+
+	now := uc.now().UTC()
+
+	if !current.ExpiresAt.After(now) {
+		return nil, apperror.Unauthorized("Refresh token has expired", apperror.WithCode(codeRefreshExpired))
+	}
+
+	user, err := uc.users.GetByID(ctx, current.UserID)
+	if err != nil && !errors.Is(err, auth.ErrUserNotFound) {
+		return nil, fmt.Errorf("UseCase - Refresh - uc.users.GetByID: %w", err)
+	}
+
+	if user == nil || user.Status == auth.StatusDisabled || user.Status == auth.StatusDeleted {
+		if err = uc.refreshTokens.RevokeFamily(ctx, current.UserID, current.FamilyID); err != nil {
+			return nil, fmt.Errorf("UseCase - Refresh - uc.refreshTokens.RevokeFamily: %w", err)
+		}
+
+		return nil, errRefreshTokenInvalid()
+	}
+
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - Refresh - token.Generate: %w", err)
+	}
+
+	next := &auth.RefreshToken{
+		UserID:     current.UserID,
+		TokenHash:  token.Hash(raw),
+		FamilyID:   current.FamilyID,
+		Generation: current.Generation + 1,
+		DeviceInfo: current.DeviceInfo,
+		IPAddress:  current.IPAddress,
+		UserAgent:  current.UserAgent,
+		// Each rotation extends the session by the lifetime it was created with,
+		// so remember-me sessions stay long and regular ones stay short.
+		ExpiresAt: now.Add(current.ExpiresAt.Sub(current.CreatedAt)),
+	}
+
+	if in.Client.IPAddress != "" {
+		next.IPAddress = &in.Client.IPAddress
+	}
+
+	if in.Client.UserAgent != "" {
+		next.UserAgent = &in.Client.UserAgent
+	}
+
+	if err = uc.refreshTokens.Rotate(ctx, current.ID, next); err != nil {
+		if errors.Is(err, auth.ErrRefreshTokenReused) {
+			return nil, uc.handleReuse(ctx, current, in.Client)
+		}
+
+		return nil, fmt.Errorf("UseCase - Refresh - uc.refreshTokens.Rotate: %w", err)
+	}
+
+	return uc.tokenPair(ctx, current.UserID, current.FamilyID, raw)
+}
+
+func (uc *UseCase) handleReuse(ctx context.Context, rt *auth.RefreshToken, client auth.ClientInfo) error {
+	if err := uc.refreshTokens.RevokeFamily(ctx, rt.UserID, rt.FamilyID); err != nil {
+		return fmt.Errorf("UseCase - handleReuse - uc.refreshTokens.RevokeFamily: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &rt.UserID,
+		Type:      auth.EventSuspiciousActivity,
+		Success:   false,
+		RiskLevel: auth.RiskHigh,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details: map[string]any{
+			"reason":     "refresh_token_reuse",
+			"family_id":  rt.FamilyID.String(),
+			"generation": rt.Generation,
+		},
+	})
+
+	return errRefreshTokenInvalid()
+}
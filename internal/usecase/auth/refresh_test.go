@@ -0,0 +1,234 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func activeRefreshToken(userID uuid.UUID) *auth.RefreshToken {
+	createdAt := time.Now().Add(-time.Hour)
+
+	return &auth.RefreshToken{
+		ID:         uuid.New(),
+		UserID:     userID,
+		TokenHash:  token.Hash("current"),
+		FamilyID:   uuid.New(),
+		Generation: 3,
+		ExpiresAt:  createdAt.Add(24 * time.Hour),
+		CreatedAt:  createdAt,
+	}
+}
+
+func TestUseCase_Refresh_Rotates(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	current := activeRefreshToken(user.ID)
+
+	var (
+		rotatedID uuid.UUID
+		next      *auth.RefreshToken
+	)
+
+	users := &mockUserRepo{
+		getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+			return user, nil
+		},
+	}
+	refreshTokens := &mockRefreshTokenRepo{
+		getByHashFunc: func(_ context.Context, hash string) (*auth.RefreshToken, error) {
+			assert.Equal(t, token.Hash("current"), hash)
+
+			return current, nil
+		},
+		rotateFunc: func(_ context.Context, currentID uuid.UUID, n *auth.RefreshToken) error {
+			rotatedID = currentID
+			next = n
+
+			return nil
+		},
+	}
+
+	pair, err := newAuthUseCase(t, users, refreshTokens).Refresh(context.Background(), auth.RefreshInput{
+		RefreshToken: "current",
+		Client:       auth.ClientInfo{IPAddress: "198.51.100.1", UserAgent: "app/2.0"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, current.ID, rotatedID)
+	require.NotNil(t, next)
+	assert.Equal(t, current.FamilyID, next.FamilyID)
+	assert.Equal(t, 4, next.Generation)
+	assert.Equal(t, token.Hash(pair.RefreshToken), next.TokenHash)
+	assert.NotEqual(t, "current", pair.RefreshToken)
+	assert.Equal(t, "198.51.100.1", *next.IPAddress)
+	assert.Equal(t, "app/2.0", *next.UserAgent)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), next.ExpiresAt, time.Minute)
+	assert.NotEmpty(t, pair.AccessToken)
+	assert.Equal(t, auth.TokenTypeBearer, pair.TokenType)
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_Refresh_Rejects(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		stored      func() *auth.RefreshToken
+		user        *auth.User
+		rotateErr   error
+		signedOut   bool
+		wantCode    string
+		wantRevoked bool
+		wantEvent   bool
+	}{
+		{
+			name:     "unknown token",
+			stored:   func() *auth.RefreshToken { return nil },
+			wantCode: "REFRESH_TOKEN_INVALID",
+		},
+		{
+			name: "rotated token is reused",
+			stored: func() *auth.RefreshToken {
+				rt := activeRefreshToken(user.ID)
+				rt.RevokedAt = &now
+				rt.LastUsedAt = &now
+
+				return rt
+			},
+			wantCode:    "REFRESH_TOKEN_INVALID",
+			wantRevoked: true,
+			wantEvent:   true,
+		},
+		{
+			name: "rotated token after sign-out",
+			stored: func() *auth.RefreshToken {
+				rt := activeRefreshToken(user.ID)
+				rt.RevokedAt = &now
+				rt.LastUsedAt = &now
+
+				return rt
+			},
+			signedOut: true,
+			wantCode:  "REFRESH_TOKEN_INVALID",
+		},
+		{
+			name: "concurrent rotation loses the race",
+			stored: func() *auth.RefreshToken {
+				return activeRefreshToken(user.ID)
+			},
+			user:        user,
+			rotateErr:   auth.ErrRefreshTokenReused,
+			wantCode:    "REFRESH_TOKEN_INVALID",
+			wantRevoked: true,
+			wantEvent:   true,
+		},
+		{
+			name: "logged out token",
+			stored: func() *auth.RefreshToken {
+				rt := activeRefreshToken(user.ID)
+				rt.RevokedAt = &now
+
+				return rt
+			},
+			wantCode: "REFRESH_TOKEN_INVALID",
+		},
+		{
+			name: "expired token",
+			stored: func() *auth.RefreshToken {
+				rt := activeRefreshToken(user.ID)
+				rt.ExpiresAt = now.Add(-time.Second)
+
+				return rt
+			},
+			wantCode: "REFRESH_TOKEN_EXPIRED",
+		},
+		{
+			name: "disabled user",
+			stored: func() *auth.RefreshToken {
+				return activeRefreshToken(user.ID)
+			},
+			user:        existingUser(auth.StatusDisabled),
+			wantCode:    "REFRESH_TOKEN_INVALID",
+			wantRevoked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stored := tt.stored()
+
+			var revokedFamily uuid.UUID
+
+			users := &mockUserRepo{
+				getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+					return tt.user, nil
+				},
+			}
+			refreshTokens := &mockRefreshTokenRepo{
+				getByHashFunc: func(_ context.Context, _ string) (*auth.RefreshToken, error) {
+					if stored == nil {
+						return nil, auth.ErrRefreshTokenNotFound
+					}
+
+					return stored, nil
+				},
+				rotateFunc: func(_ context.Context, _ uuid.UUID, _ *auth.RefreshToken) error {
+					return tt.rotateErr
+				},
+				familyActiveFunc: func(_ context.Context, _, _ uuid.UUID) (bool, error) {
+					return !tt.signedOut, nil
+				},
+				revokeFamilyFunc: func(_ context.Context, _, familyID uuid.UUID) error {
+					revokedFamily = familyID
+
+					return nil
+				},
+			}
+			events := &mockSecurityEventRepo{}
+
+			pair, err := newAuthUseCaseWithEvents(t, users, refreshTokens, events).Refresh(context.Background(), auth.RefreshInput{
+				RefreshToken: "presented",
+				Client:       auth.ClientInfo{IPAddress: "203.0.113.9"},
+			})
+
+			require.Error(t, err)
+			assert.Nil(t, pair)
+			requireAppError(t, err, apperror.KindUnauthorized, tt.wantCode)
+
+			if tt.wantRevoked {
+				assert.Equal(t, stored.FamilyID, revokedFamily)
+			} else {
+				assert.Equal(t, uuid.Nil, revokedFamily)
+			}
+
+			if !tt.wantEvent {
+				assert.Empty(t, events.stored())
+
+				return
+			}
+
+			recorded := events.stored()
+			require.Len(t, recorded, 1)
+			assert.Equal(t, auth.EventSuspiciousActivity, recorded[0].Type)
+			assert.Equal(t, auth.RiskHigh, recorded[0].RiskLevel)
+			assert.False(t, recorded[0].Success)
+			assert.Equal(t, stored.UserID, *recorded[0].UserID)
+			assert.Equal(t, "refresh_token_reuse", recorded[0].Details["reason"])
+			assert.Equal(t, "203.0.113.9", *recorded[0].IPAddress)
+		})
+	}
+}
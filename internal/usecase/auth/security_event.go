@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/repo"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/geoip"
+	"github.com/evrone/go-clean-template/pkg/useragent"
+	"github.com/google/uuid"
+)
+
+const (
+	securityEventsDefaultLimit = 20
+	securityEventsMaxLimit     = 100
+)
+
+// GeoLocator resolves IP addresses to locations. *geoip.Reader implements it.
+type GeoLocator interface {
+	Lookup(ip string) (*geoip.Location, error)
+}
+
+// SecurityEventRecorder writes the audit log. It fills in the device from
+// the user agent and, when it has a GeoLocator, the location from the IP
+// address, unless the event already carries them.
+type SecurityEventRecorder struct {
+	repo repo.SecurityEventRepo
+	geo  GeoLocator
+}
+
+// NewSecurityEventRecorder returns a recorder; geo may be nil to skip
+// location lookups.
+func NewSecurityEventRecorder(r repo.SecurityEventRepo, geo GeoLocator) *SecurityEventRecorder {
+	return &SecurityEventRecorder{repo: r, geo: geo}
+}
+
+// Record enriches and stores e. Audit logging must never fail the operation
+// being audited, so errors are dropped.
+func (r *SecurityEventRecorder) Record(ctx context.Context, e *auth.SecurityEvent) {
+	if e.UserID != nil && *e.UserID == uuid.Nil {
+		e.UserID = nil
+	}
+
+	if e.UserAgent != nil && e.DeviceType == nil {
+		d := useragent.Parse(*e.UserAgent)
+		e.DeviceType = &d.Type
+		e.DeviceOS = optional(d.OS)
+		e.DeviceBrowser = optional(d.Browser)
+	}
+
+	if e.IPAddress != nil && e.LocationCountryCode == nil {
+		if loc := r.locate(*e.IPAddress); loc != nil {
+			e.LocationCity = optional(loc.City)
+			e.LocationRegion = optional(loc.Region)
+			e.LocationCountry = optional(loc.Country)
+			e.LocationCountryCode = optional(loc.CountryCode)
+		}
+	}
+
+	//nolint:errcheck // fire and forget - audit logging should not fail the main operation
+	r.repo.Store(ctx, e)
+}
+
+// locate returns where ip is, or nil when that is not known.
+func (r *SecurityEventRecorder) locate(ip string) *geoip.Location {
+	if r.geo == nil || ip == "" {
+		return nil
+	}
+
+	loc, err := r.geo.Lookup(ip)
+	if err != nil {
+		return nil
+	}
+
+	return loc
+}
+
+// SecurityEvents pages through the user's audit log, newest first.
+func (uc *UseCase) SecurityEvents(ctx context.Context, in auth.SecurityEventListInput) (*auth.SecurityEventList, error) {
+	if in.Filter.From != nil && in.Filter.To != nil && in.Filter.From.After(*in.Filter.To) {
+		return nil, apperror.Validation("Invalid date range", apperror.WithField("from", "must not be after to"))
+	}
+
+	page := max(in.Page, 1)
+
+	limit := in.Limit
+	if limit <= 0 {
+		limit = securityEventsDefaultLimit
+	}
+
+	limit = min(limit, securityEventsMaxLimit)
+
+	total, err := uc.eventLog.CountByUserID(ctx, in.UserID, in.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - SecurityEvents - uc.eventLog.CountByUserID: %w", err)
+	}
+
+	events, err := uc.eventLog.ListByUserID(ctx, in.UserID, in.Filter, uint64(limit), uint64((page-1)*limit)) //nolint:gosec // both are positive
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - SecurityEvents - uc.eventLog.ListByUserID: %w", err)
+	}
+
+	return &auth.SecurityEventList{Events: events, Page: page, Limit: limit, Total: total}, nil
+}
+
+func (uc *UseCase) SecurityEvent(ctx context.Context, userID, id uuid.UUID) (*auth.SecurityEvent, error) {
+	e, err := uc.eventLog.GetByID(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, auth.ErrSecurityEventNotFound) {
+			return nil, apperror.NotFound("Security event not found", apperror.WithCause(err))
+		}
+
+		return nil, fmt.Errorf("UseCase - SecurityEvent - uc.eventLog.GetByID: %w", err)
+	}
+
+	return e, nil
+}
+
+func (uc *UseCase) recordEvent(ctx context.Context, e *auth.SecurityEvent) {
+	uc.events.Record(ctx, e)
+}
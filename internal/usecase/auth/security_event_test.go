@@ -0,0 +1,160 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/geoip"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chromeOnMac = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func TestSecurityEventRecorder_Record(t *testing.T) {
+	t.Parallel()
+
+	events := &mockSecurityEventRepo{}
+	recorder := authuc.NewSecurityEventRecorder(events, fakeGeoLocator{known: map[string]geoip.Location{
+		"203.0.113.7": {City: "Lisbon", Region: "Lisbon", Country: "Portugal", CountryCode: "PT"},
+	}})
+
+	ip, ua := "203.0.113.7", chromeOnMac
+	recorder.Record(context.Background(), &auth.SecurityEvent{
+		UserID:    &uuid.Nil,
+		Type:      auth.EventLoginSuccess,
+		Success:   true,
+		IPAddress: &ip,
+		UserAgent: &ua,
+	})
+
+	unknownIP := "198.51.100.1"
+	recorder.Record(context.Background(), &auth.SecurityEvent{Type: auth.EventLoginFailed, IPAddress: &unknownIP})
+
+	stored := events.stored()
+	require.Len(t, stored, 2)
+
+	e := stored[0]
+	assert.Nil(t, e.UserID, "a nil user ID is not stored")
+	assert.Equal(t, "desktop", *e.DeviceType)
+	assert.Equal(t, "macOS 10.15.7", *e.DeviceOS)
+	assert.Equal(t, "Chrome 120", *e.DeviceBrowser)
+	assert.Equal(t, "Lisbon", *e.LocationCity)
+	assert.Equal(t, "PT", *e.LocationCountryCode)
+
+	assert.Nil(t, stored[1].DeviceType)
+	assert.Nil(t, stored[1].LocationCountryCode)
+}
+
+func TestUseCase_Login_RecordsEvents(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	events := &mockSecurityEventRepo{}
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{Users: newMemoryUserRepo(user), SecurityEvents: events})
+	ctx := context.Background()
+	client := auth.ClientInfo{IPAddress: "203.0.113.7", UserAgent: chromeOnMac}
+
+	_, err := uc.Login(ctx, auth.LoginInput{Email: user.Email, Password: "wrong", Client: client})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_CREDENTIALS")
+
+	_, err = uc.Login(ctx, auth.LoginInput{Email: "nobody@example.com", Password: "wrong", Client: client})
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_CREDENTIALS")
+
+	_, err = uc.Login(ctx, auth.LoginInput{Email: user.Email, Password: "SecureP@ss123", Client: client})
+	require.NoError(t, err)
+
+	stored := events.stored()
+	require.Len(t, stored, 3)
+
+	assert.Equal(t, auth.EventLoginFailed, stored[0].Type)
+	assert.Equal(t, user.ID, *stored[0].UserID)
+	assert.Equal(t, "invalid_password", stored[0].Details["reason"])
+
+	assert.Equal(t, auth.EventLoginFailed, stored[1].Type)
+	assert.Nil(t, stored[1].UserID)
+	assert.Equal(t, "unknown_email", stored[1].Details["reason"])
+
+	assert.Equal(t, auth.EventLoginSuccess, stored[2].Type)
+	assert.True(t, stored[2].Success)
+	assert.Equal(t, "Chrome 120", *stored[2].DeviceBrowser)
+}
+
+func TestUseCase_SecurityEvents(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	events := &mockSecurityEventRepo{}
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{SecurityEvents: events})
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	for i, typ := range []auth.SecurityEventType{
+		auth.EventLoginSuccess, auth.EventLoginFailed, auth.EventLoginSuccess, auth.EventPasswordChanged, auth.EventLoginSuccess,
+	} {
+		require.NoError(t, events.Store(ctx, &auth.SecurityEvent{
+			UserID:    &userID,
+			Type:      typ,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	other := uuid.New()
+	require.NoError(t, events.Store(ctx, &auth.SecurityEvent{UserID: &other, Type: auth.EventLoginSuccess}))
+
+	list, err := uc.SecurityEvents(ctx, auth.SecurityEventListInput{UserID: userID, Page: 2, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 5, list.Total)
+	assert.Equal(t, 2, list.Page)
+	require.Len(t, list.Events, 2)
+	assert.Equal(t, auth.EventLoginSuccess, list.Events[0].Type)
+	assert.Equal(t, auth.EventLoginFailed, list.Events[1].Type)
+
+	list, err = uc.SecurityEvents(ctx, auth.SecurityEventListInput{
+		UserID: userID,
+		Filter: auth.SecurityEventFilter{Type: auth.EventLoginSuccess},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.Total)
+	assert.Equal(t, 20, list.Limit, "the default page size applies")
+
+	from := base.Add(90 * time.Second)
+	to := base.Add(3 * time.Minute)
+
+	list, err = uc.SecurityEvents(ctx, auth.SecurityEventListInput{
+		UserID: userID,
+		Filter: auth.SecurityEventFilter{From: &from, To: &to},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, list.Total)
+
+	_, err = uc.SecurityEvents(ctx, auth.SecurityEventListInput{
+		UserID: userID,
+		Filter: auth.SecurityEventFilter{From: &to, To: &from},
+	})
+	requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+}
+
+func TestUseCase_SecurityEvent(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	events := &mockSecurityEventRepo{}
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{SecurityEvents: events})
+	ctx := context.Background()
+
+	e := &auth.SecurityEvent{UserID: &userID, Type: auth.EventMFAEnabled}
+	require.NoError(t, events.Store(ctx, e))
+
+	got, err := uc.SecurityEvent(ctx, userID, e.ID)
+	require.NoError(t, err)
+	assert.Equal(t, auth.EventMFAEnabled, got.Type)
+
+	_, err = uc.SecurityEvent(ctx, uuid.New(), e.ID)
+	requireAppError(t, err, apperror.KindNotFound, "NOT_FOUND")
+}
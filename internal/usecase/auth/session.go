@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+)
+
+// ListSessions returns the user's active sessions, marking the one the caller
+// is authenticated with.
+func (uc *UseCase) ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]auth.Session, error) {
+	sessions, err := uc.refreshTokens.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - ListSessions - uc.refreshTokens.ListSessions: %w", err)
+	}
+
+	for i := range sessions {
+		sessions[i].IsCurrent = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions. Access tokens already issued
+// for it stay valid until they expire.
+func (uc *UseCase) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, client auth.ClientInfo) error {
+	sessions, err := uc.refreshTokens.ListSessions(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("UseCase - RevokeSession - uc.refreshTokens.ListSessions: %w", err)
+	}
+
+	if !containsSession(sessions, sessionID) {
+		return apperror.NotFound("Session not found")
+	}
+
+	if err = uc.refreshTokens.RevokeFamily(ctx, userID, sessionID); err != nil {
+		return fmt.Errorf("UseCase - RevokeSession - uc.refreshTokens.RevokeFamily: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &userID,
+		Type:      auth.EventSessionRevoked,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"session_id": sessionID.String()},
+	})
+
+	return nil
+}
+
+// RevokeOtherSessions ends every session of the user except the current one
+// and returns how many were ended.
+func (uc *UseCase) RevokeOtherSessions(ctx context.Context, userID, currentSessionID uuid.UUID, client auth.ClientInfo) (int64, error) {
+	revoked, err := uc.refreshTokens.RevokeOtherFamilies(ctx, userID, currentSessionID)
+	if err != nil {
+		return 0, fmt.Errorf("UseCase - RevokeOtherSessions - uc.refreshTokens.RevokeOtherFamilies: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &userID,
+		Type:      auth.EventAllSessionsRevoked,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details: map[string]any{
+			"kept_session_id": currentSessionID.String(),
+			"revoked_count":   revoked,
+		},
+	})
+
+	return revoked, nil
+}
+
+func containsSession(sessions []auth.Session, id uuid.UUID) bool {
+	for i := range sessions {
+		if sessions[i].ID == id {
+			return true
+		}
+	}
+
+	return false
+}
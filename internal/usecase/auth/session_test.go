@@ -0,0 +1,142 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseCase_ListSessions(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	current := uuid.New()
+	other := uuid.New()
+
+	rts := &mockRefreshTokenRepo{
+		listSessionsFunc: func(_ context.Context, uid uuid.UUID) ([]auth.Session, error) {
+			assert.Equal(t, userID, uid)
+
+			return []auth.Session{{ID: other, UserID: uid}, {ID: current, UserID: uid}}, nil
+		},
+	}
+
+	sessions, err := newAuthUseCase(t, &mockUserRepo{}, rts).ListSessions(context.Background(), userID, current)
+
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.False(t, sessions[0].IsCurrent)
+	assert.True(t, sessions[1].IsCurrent)
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_RevokeSession(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name        string
+		sessions    []auth.Session
+		listErr     error
+		wantKind    apperror.Kind
+		wantErr     bool
+		wantRevoked bool
+	}{
+		{
+			name:        "revokes own session",
+			sessions:    []auth.Session{{ID: sessionID, UserID: userID}},
+			wantRevoked: true,
+		},
+		{
+			name:     "unknown session",
+			sessions: []auth.Session{{ID: uuid.New(), UserID: userID}},
+			wantErr:  true,
+			wantKind: apperror.KindNotFound,
+		},
+		{
+			name:     "repository failure",
+			listErr:  errors.New("db down"),
+			wantErr:  true,
+			wantKind: apperror.KindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var revoked bool
+
+			rts := &mockRefreshTokenRepo{
+				listSessionsFunc: func(_ context.Context, _ uuid.UUID) ([]auth.Session, error) {
+					return tt.sessions, tt.listErr
+				},
+				revokeFamilyFunc: func(_ context.Context, uid, familyID uuid.UUID) error {
+					assert.Equal(t, userID, uid)
+					assert.Equal(t, sessionID, familyID)
+
+					revoked = true
+
+					return nil
+				},
+			}
+			events := &mockSecurityEventRepo{}
+
+			err := newAuthUseCaseWithEvents(t, &mockUserRepo{}, rts, events).
+				RevokeSession(context.Background(), userID, sessionID, auth.ClientInfo{IPAddress: "10.0.0.1"})
+
+			assert.Equal(t, tt.wantRevoked, revoked)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantKind, apperror.GetKind(err))
+				assert.Empty(t, events.stored())
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			stored := events.stored()
+			require.Len(t, stored, 1)
+			assert.Equal(t, auth.EventSessionRevoked, stored[0].Type)
+			assert.Equal(t, sessionID.String(), stored[0].Details["session_id"])
+		})
+	}
+}
+
+func TestUseCase_RevokeOtherSessions(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	current := uuid.New()
+
+	rts := &mockRefreshTokenRepo{
+		revokeOtherFunc: func(_ context.Context, uid, keep uuid.UUID) (int64, error) {
+			assert.Equal(t, userID, uid)
+			assert.Equal(t, current, keep)
+
+			return 3, nil
+		},
+	}
+	events := &mockSecurityEventRepo{}
+
+	revoked, err := newAuthUseCaseWithEvents(t, &mockUserRepo{}, rts, events).
+		RevokeOtherSessions(context.Background(), userID, current, auth.ClientInfo{})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), revoked)
+
+	stored := events.stored()
+	require.Len(t, stored, 1)
+	assert.Equal(t, auth.EventAllSessionsRevoked, stored[0].Type)
+	assert.Equal(t, int64(3), stored[0].Details["revoked_count"])
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+)
+
+// Settings returns the rules in force, read from the same policy, token
+// service and config the other methods enforce, so clients never show a
+// value that differs from what the server checks.
+func (uc *UseCase) Settings(context.Context) *auth.Settings {
+	req := uc.policy.Requirements()
+
+	return &auth.Settings{
+		PasswordMinLength:        req.MinLength,
+		PasswordMaxLength:        req.MaxLength,
+		PasswordRequireUppercase: req.RequireUppercase,
+		PasswordRequireLowercase: req.RequireLowercase,
+		PasswordRequireNumber:    req.RequireNumber,
+		PasswordRequireSpecial:   req.RequireSpecial,
+
+		AccessTokenTTL:       uc.tokens.AccessTTL(),
+		RefreshTokenTTL:      uc.cfg.RefreshTokenTTL,
+		RememberMeTTL:        uc.cfg.RememberMeTTL,
+		PasswordResetTTL:     uc.cfg.PasswordResetTTL,
+		EmailVerificationTTL: uc.cfg.EmailVerificationTTL,
+		MFACodeTTL:           uc.cfg.MFACodeTTL,
+		RecoverySessionTTL:   uc.cfg.AccountRecoveryTTL,
+
+		LoginMaxAttempts:         uc.cfg.LoginMaxAttempts,
+		LoginAttemptWindow:       uc.cfg.LoginAttemptWindow,
+		LoginLockout:             uc.cfg.LoginLockout,
+		LoginMaxLockout:          uc.cfg.LoginMaxLockout,
+		PasswordResetMaxRequests: uc.cfg.PasswordResetMaxPerHour,
+		PasswordResetWindow:      passwordResetWindow,
+		MFAMaxAttempts:           uc.cfg.MFAMaxAttempts,
+		MFAAttemptWindow:         uc.cfg.MFAAttemptWindow,
+
+		RecoveryCodeCount:  uc.cfg.RecoveryCodeCount,
+		RecoveryCodeFormat: uc.cfg.RecoveryCodeFormat,
+	}
+}
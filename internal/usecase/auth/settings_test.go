@@ -0,0 +1,46 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseCase_Settings(t *testing.T) {
+	t.Parallel()
+
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{})
+
+	assert.Equal(t, &auth.Settings{
+		PasswordMinLength:        8,
+		PasswordMaxLength:        128,
+		PasswordRequireUppercase: true,
+		PasswordRequireLowercase: true,
+		PasswordRequireNumber:    true,
+		PasswordRequireSpecial:   false,
+
+		AccessTokenTTL:       15 * time.Minute,
+		RefreshTokenTTL:      24 * time.Hour,
+		RememberMeTTL:        30 * 24 * time.Hour,
+		PasswordResetTTL:     time.Hour,
+		EmailVerificationTTL: 24 * time.Hour,
+		MFACodeTTL:           5 * time.Minute,
+		RecoverySessionTTL:   15 * time.Minute,
+
+		LoginMaxAttempts:         3,
+		LoginAttemptWindow:       15 * time.Minute,
+		LoginLockout:             15 * time.Minute,
+		LoginMaxLockout:          time.Hour,
+		PasswordResetMaxRequests: 3,
+		PasswordResetWindow:      time.Hour,
+		MFAMaxAttempts:           3,
+		MFAAttemptWindow:         5 * time.Minute,
+
+		RecoveryCodeCount:  4,
+		RecoveryCodeFormat: "XXXX-XXXX",
+	}, uc.Settings(context.Background()))
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/notify"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+)
+
+const smsCodeDigits = 6
+
+// SMSNotifier delivers text messages. notification.Service implements it.
+type SMSNotifier interface {
+	SendSMS(ctx context.Context, msg *notification.SMSMessage) error
+}
+
+// SetupSMS starts SMS enrollment by texting a code to phone. Calling it again
+// before the number is verified replaces the pending number.
+func (uc *UseCase) SetupSMS(ctx context.Context, userID uuid.UUID, phone string) error {
+	if err := notify.ValidateE164(phone); err != nil {
+		return apperror.Validation("Invalid phone number",
+			apperror.WithField("phone_number", "must be in E.164 format, e.g. +14155551234"))
+	}
+
+	existing, err := uc.smsFactors.GetByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, auth.ErrSMSFactorNotFound) {
+		return fmt.Errorf("UseCase - SetupSMS - uc.smsFactors.GetByUserID: %w", err)
+	}
+
+	now := uc.now().UTC()
+
+	if existing != nil {
+		if existing.IsVerified() {
+			return apperror.Conflict("SMS verification is already enabled", apperror.WithCode(codeMFAAlreadyEnabled))
+		}
+
+		if err = uc.checkSMSCooldown(existing); err != nil {
+			return err
+		}
+	}
+
+	code, err := token.NumericCode(smsCodeDigits)
+	if err != nil {
+		return fmt.Errorf("UseCase - SetupSMS - token.NumericCode: %w", err)
+	}
+
+	hash := smsCodeHash(userID, code)
+	expiresAt := now.Add(uc.cfg.MFACodeTTL)
+
+	err = uc.smsFactors.Upsert(ctx, &auth.SMSFactor{
+		UserID:        userID,
+		PhoneNumber:   phone,
+		CodeHash:      &hash,
+		CodeExpiresAt: &expiresAt,
+		CodeSentAt:    &now,
+	})
+	if err != nil {
+		if errors.Is(err, auth.ErrSMSFactorAlreadyEnabled) {
+			return apperror.Conflict("SMS verification is already enabled", apperror.WithCode(codeMFAAlreadyEnabled))
+		}
+
+		return fmt.Errorf("UseCase - SetupSMS - uc.smsFactors.Upsert: %w", err)
+	}
+
+	return uc.sendSMSCode(ctx, userID, phone, code)
+}
+
+// VerifySMSSetup confirms a pending phone number with the texted code, after
+// which SMS can be used at login. Recovery codes are returned when this is the
+// user's first second factor.
+func (uc *UseCase) VerifySMSSetup(ctx context.Context, userID uuid.UUID, code string, client auth.ClientInfo) ([]string, error) {
+	f, err := uc.smsFactors.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrSMSFactorNotFound) {
+			return nil, apperror.Validation("Start SMS setup first", apperror.WithCode(codeMFANotEnabled))
+		}
+
+		return nil, fmt.Errorf("UseCase - VerifySMSSetup - uc.smsFactors.GetByUserID: %w", err)
+	}
+
+	if f.IsVerified() {
+		return nil, apperror.Conflict("SMS verification is already enabled", apperror.WithCode(codeMFAAlreadyEnabled))
+	}
+
+	ok, err := uc.checkSMSCode(ctx, f, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errMFAInvalidCode()
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &userID,
+		Type:      auth.EventMFAEnabled,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(auth.MFAMethodSMS)},
+	})
+
+	return uc.initialRecoveryCodes(ctx, userID)
+}
+
+// DisableSMS removes the enrolled phone after re-checking the password.
+func (uc *UseCase) DisableSMS(ctx context.Context, userID uuid.UUID, pw string, client auth.ClientInfo) error {
+	user, err := uc.reauthenticate(ctx, userID, pw, client)
+	if err != nil {
+		return err
+	}
+
+	if err = uc.smsFactors.Delete(ctx, user.ID); err != nil {
+		if errors.Is(err, auth.ErrSMSFactorNotFound) {
+			return apperror.Validation("SMS verification is not enabled", apperror.WithCode(codeMFANotEnabled))
+		}
+
+		return fmt.Errorf("UseCase - DisableSMS - uc.smsFactors.Delete: %w", err)
+	}
+
+	if err = uc.dropRecoveryCodesIfUnprotected(ctx, user.ID); err != nil {
+		return err
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventMFADisabled,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(auth.MFAMethodSMS)},
+	})
+
+	return nil
+}
+
+// SendMFACode texts a login code to the phone enrolled by the user behind a
+// pending MFA challenge, or emails one when the challenge asks for an email
+// code.
+func (uc *UseCase) SendMFACode(ctx context.Context, challengeToken string, method auth.MFAMethod) error {
+	c, err := uc.pendingChallenge(ctx, challengeToken)
+	if err != nil {
+		return err
+	}
+
+	if method == "" {
+		method = auth.MFAMethodSMS
+		if c.Allows(auth.MFAMethodEmail) {
+			method = auth.MFAMethodEmail
+		}
+	}
+
+	if method == auth.MFAMethodEmail && c.Allows(method) {
+		return uc.resendChallengeCode(ctx, c)
+	}
+
+	if method != auth.MFAMethodSMS || !c.Allows(method) {
+		return apperror.Validation("SMS verification is not enabled",
+			apperror.WithCode(codeMFANotEnabled),
+			apperror.WithField("method", "is not available for this account"),
+		)
+	}
+
+	f, err := uc.smsFactors.GetByUserID(ctx, c.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrSMSFactorNotFound) {
+			return apperror.Validation("SMS verification is not enabled", apperror.WithCode(codeMFANotEnabled))
+		}
+
+		return fmt.Errorf("UseCase - SendMFACode - uc.smsFactors.GetByUserID: %w", err)
+	}
+
+	if !f.IsVerified() {
+		return apperror.Validation("SMS verification is not enabled", apperror.WithCode(codeMFANotEnabled))
+	}
+
+	if err = uc.checkSMSCooldown(f); err != nil {
+		return err
+	}
+
+	code, err := token.NumericCode(smsCodeDigits)
+	if err != nil {
+		return fmt.Errorf("UseCase - SendMFACode - token.NumericCode: %w", err)
+	}
+
+	now := uc.now().UTC()
+
+	if err = uc.smsFactors.SetCode(ctx, f.UserID, smsCodeHash(f.UserID, code), now.Add(uc.cfg.MFACodeTTL), now); err != nil {
+		return fmt.Errorf("UseCase - SendMFACode - uc.smsFactors.SetCode: %w", err)
+	}
+
+	return uc.sendSMSCode(ctx, f.UserID, f.PhoneNumber, code)
+}
+
+// checkSMSCode consumes the outstanding code if it matches. A code stops
+// working once it expires or has been guessed wrong too many times.
+func (uc *UseCase) checkSMSCode(ctx context.Context, f *auth.SMSFactor, code string) (bool, error) {
+	now := uc.now().UTC()
+
+	if f.CodeExpired(now) || f.CodeAttempts >= uc.cfg.MFAMaxAttempts {
+		return false, apperror.Validation("Verification code has expired; request a new one",
+			apperror.WithCode(codeMFACodeExpired))
+	}
+
+	err := uc.smsFactors.ConsumeCode(ctx, f.UserID, smsCodeHash(f.UserID, code), now)
+	if err == nil {
+		return true, nil
+	}
+
+	if !errors.Is(err, auth.ErrSMSCodeInvalid) {
+		return false, fmt.Errorf("UseCase - checkSMSCode - uc.smsFactors.ConsumeCode: %w", err)
+	}
+
+	if _, err = uc.smsFactors.RecordCodeFailure(ctx, f.UserID); err != nil {
+		return false, fmt.Errorf("UseCase - checkSMSCode - uc.smsFactors.RecordCodeFailure: %w", err)
+	}
+
+	return false, nil
+}
+
+func (uc *UseCase) checkSMSCooldown(f *auth.SMSFactor) error {
+	if f.CodeSentAt == nil {
+		return nil
+	}
+
+	if wait := f.CodeSentAt.Add(uc.cfg.MFACodeResendCooldown).Sub(uc.now()); wait > 0 {
+		return apperror.RateLimited("Please wait before requesting another code", apperror.WithRetryAfter(wait))
+	}
+
+	return nil
+}
+
+func (uc *UseCase) sendSMSCode(ctx context.Context, userID uuid.UUID, phone, code string) error {
+	err := uc.sms.SendSMS(ctx, &notification.SMSMessage{
+		UserID:        userID,
+		To:            phone,
+		Body:          fmt.Sprintf("Your verification code is %s. It expires in %s.", code, humanDuration(uc.cfg.MFACodeTTL)),
+		Transactional: true,
+	})
+	if err != nil {
+		return fmt.Errorf("UseCase - sendSMSCode - uc.sms.SendSMS: %w", err)
+	}
+
+	return nil
+}
+
+// smsCodeHash binds the hash to the user so equal codes sent to different
+// users never share a stored value.
+func smsCodeHash(userID uuid.UUID, code string) string {
+	return token.Hash(userID.String() + ":" + code)
+}
@@ -0,0 +1,245 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPhone = "+14155551234"
+
+type smsFixture struct {
+	uc      *authuc.UseCase
+	user    *auth.User
+	factors *memorySMSFactorRepo
+	sms     *mockSMSNotifier
+	events  *mockSecurityEventRepo
+}
+
+func newSMSFixture(t *testing.T, factors ...*auth.SMSFactor) *smsFixture {
+	t.Helper()
+
+	user := existingUser(auth.StatusActive)
+
+	for _, f := range factors {
+		f.UserID = user.ID
+	}
+
+	f := &smsFixture{
+		user:    user,
+		factors: newMemorySMSFactorRepo(factors...),
+		sms:     &mockSMSNotifier{},
+		events:  &mockSecurityEventRepo{},
+	}
+
+	f.uc = newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: &mockUserRepo{
+			getByEmailFunc: func(_ context.Context, _ string) (*auth.User, error) {
+				return user, nil
+			},
+			getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+				return user, nil
+			},
+		},
+		SMSFactors:     f.factors,
+		SMS:            f.sms,
+		SecurityEvents: f.events,
+	})
+
+	return f
+}
+
+func verifiedSMSFactor() *auth.SMSFactor {
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	return &auth.SMSFactor{ID: uuid.New(), PhoneNumber: testPhone, VerifiedAt: &verifiedAt}
+}
+
+func TestUseCase_SetupSMS(t *testing.T) {
+	t.Parallel()
+
+	f := newSMSFixture(t)
+	ctx := context.Background()
+
+	err := f.uc.SetupSMS(ctx, f.user.ID, "4155551234")
+	requireAppError(t, err, apperror.KindValidation, "VALIDATION_ERROR")
+	assert.Empty(t, f.sms.sent())
+
+	require.NoError(t, f.uc.SetupSMS(ctx, f.user.ID, testPhone))
+
+	sent := f.sms.sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, testPhone, sent[0].To)
+	assert.Equal(t, f.user.ID, sent[0].UserID)
+	assert.True(t, sent[0].Transactional)
+	assert.Len(t, f.sms.lastCode(t), 6)
+
+	// A second request inside the cooldown is throttled.
+	err = f.uc.SetupSMS(ctx, f.user.ID, testPhone)
+	require.Error(t, err)
+	assert.True(t, apperror.IsRateLimited(err))
+	assert.Len(t, f.sms.sent(), 1)
+
+	status, err := f.uc.MFAStatus(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.False(t, status.SMSEnabled, "pending numbers do not enable MFA")
+}
+
+func TestUseCase_SetupSMS_AlreadyEnabled(t *testing.T) {
+	t.Parallel()
+
+	f := newSMSFixture(t, verifiedSMSFactor())
+
+	err := f.uc.SetupSMS(context.Background(), f.user.ID, "+442071234567")
+	requireAppError(t, err, apperror.KindConflict, "MFA_ALREADY_ENABLED")
+	assert.Empty(t, f.sms.sent())
+}
+
+func TestUseCase_VerifySMSSetup(t *testing.T) {
+	t.Parallel()
+
+	f := newSMSFixture(t)
+	ctx := context.Background()
+
+	require.NoError(t, f.uc.SetupSMS(ctx, f.user.ID, testPhone))
+
+	_, err := f.uc.VerifySMSSetup(ctx, f.user.ID, "000000", auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "MFA_INVALID_CODE")
+
+	codes, err := f.uc.VerifySMSSetup(ctx, f.user.ID, f.sms.lastCode(t), auth.ClientInfo{})
+	require.NoError(t, err)
+	assert.Len(t, codes, 4)
+
+	status, err := f.uc.MFAStatus(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.True(t, status.SMSEnabled)
+	assert.Equal(t, testPhone, status.PhoneNumber)
+	assert.Equal(t, []auth.MFAMethod{auth.MFAMethodSMS, auth.MFAMethodRecoveryCode}, status.Methods())
+
+	stored := f.events.stored()
+	require.Len(t, stored, 1)
+	assert.Equal(t, auth.EventMFAEnabled, stored[0].Type)
+	assert.Equal(t, "sms", stored[0].Details["method"])
+}
+
+func TestUseCase_VerifySMSSetup_Rejects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		prepare  func(t *testing.T, f *smsFixture) string
+		wantKind apperror.Kind
+		wantCode string
+	}{
+		{
+			name: "setup not started",
+			prepare: func(_ *testing.T, _ *smsFixture) string {
+				return "123456"
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "MFA_NOT_ENABLED",
+		},
+		{
+			name: "expired code",
+			prepare: func(t *testing.T, f *smsFixture) string {
+				t.Helper()
+
+				require.NoError(t, f.uc.SetupSMS(context.Background(), f.user.ID, testPhone))
+				f.factors.expireCode(f.user.ID)
+
+				return f.sms.lastCode(t)
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "MFA_CODE_EXPIRED",
+		},
+		{
+			name: "too many wrong guesses",
+			prepare: func(t *testing.T, f *smsFixture) string {
+				t.Helper()
+
+				require.NoError(t, f.uc.SetupSMS(context.Background(), f.user.ID, testPhone))
+
+				for range 3 {
+					_, err := f.uc.VerifySMSSetup(context.Background(), f.user.ID, "000000", auth.ClientInfo{})
+					requireAppError(t, err, apperror.KindValidation, "MFA_INVALID_CODE")
+				}
+
+				return f.sms.lastCode(t)
+			},
+			wantKind: apperror.KindValidation,
+			wantCode: "MFA_CODE_EXPIRED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newSMSFixture(t)
+			code := tt.prepare(t, f)
+
+			_, err := f.uc.VerifySMSSetup(context.Background(), f.user.ID, code, auth.ClientInfo{})
+			requireAppError(t, err, tt.wantKind, tt.wantCode)
+		})
+	}
+}
+
+func TestUseCase_DisableSMS(t *testing.T) {
+	t.Parallel()
+
+	f := newSMSFixture(t, verifiedSMSFactor())
+	ctx := context.Background()
+
+	err := f.uc.DisableSMS(ctx, f.user.ID, "WrongP@ss123", auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "PASSWORD_INCORRECT")
+
+	require.NoError(t, f.uc.DisableSMS(ctx, f.user.ID, "SecureP@ss123", auth.ClientInfo{}))
+
+	status, err := f.uc.MFAStatus(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.False(t, status.Enabled())
+
+	err = f.uc.DisableSMS(ctx, f.user.ID, "SecureP@ss123", auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "MFA_NOT_ENABLED")
+}
+
+func TestUseCase_CompleteMFAChallenge_SMS(t *testing.T) {
+	t.Parallel()
+
+	f := newSMSFixture(t, verifiedSMSFactor())
+	ctx := context.Background()
+
+	result, err := f.uc.Login(ctx, auth.LoginInput{Email: f.user.Email, Password: "SecureP@ss123"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Equal(t, []string{"sms"}, result.Challenge.AvailableMethods)
+
+	challengeToken := result.Challenge.Token
+
+	err = f.uc.SendMFACode(ctx, challengeToken, auth.MFAMethodTOTP)
+	requireAppError(t, err, apperror.KindValidation, "MFA_NOT_ENABLED")
+
+	require.NoError(t, f.uc.SendMFACode(ctx, challengeToken, ""))
+	require.Len(t, f.sms.sent(), 1)
+	assert.Equal(t, testPhone, f.sms.sent()[0].To)
+
+	err = f.uc.SendMFACode(ctx, challengeToken, auth.MFAMethodSMS)
+	require.Error(t, err)
+	assert.True(t, apperror.IsRateLimited(err), "resends honour the cooldown")
+
+	authResult, err := f.uc.CompleteMFAChallenge(ctx, auth.MFAChallengeInput{
+		ChallengeToken: challengeToken,
+		Code:           f.sms.lastCode(t),
+		Method:         auth.MFAMethodSMS,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, authResult.Tokens)
+	assert.NotEmpty(t, authResult.Tokens.AccessToken)
+}
@@ -0,0 +1,289 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenService_IssueAndVerify(t *testing.T) {
+	t.Parallel()
+
+	for _, alg := range []string{"HS256", "HS512", "RS256", "ES256", "ES384", "ES512"} {
+		t.Run(alg, func(t *testing.T) {
+			t.Parallel()
+
+			ring, _ := newTestKeyRing(t, alg)
+			svc := authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+			userID := uuid.New()
+			sessionID := uuid.New()
+
+			raw, err := svc.Issue(context.Background(), userID, sessionID)
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(raw, &jwt.RegisteredClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, alg, parsed.Header["alg"])
+			assert.NotEmpty(t, parsed.Header["kid"])
+
+			claims, err := svc.Verify(context.Background(), raw)
+			require.NoError(t, err)
+			assert.Equal(t, userID, claims.UserID)
+			assert.Equal(t, sessionID, claims.SessionID)
+			assert.NotEmpty(t, claims.TokenID)
+			assert.WithinDuration(t, time.Now().Add(15*time.Minute), claims.ExpiresAt, 2*time.Second)
+		})
+	}
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestTokenService_Verify_Rejects(t *testing.T) {
+	t.Parallel()
+
+	ring, _ := newTestKeyRing(t, "HS256")
+	svc := authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+
+	otherRing, _ := newTestKeyRing(t, "HS256")
+
+	foreignKey, err := authuc.NewTokenService(otherRing, "thiam-api", 15*time.Minute).Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	otherIssuer, err := authuc.NewTokenService(ring, "someone-else", 15*time.Minute).Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	expired, err := authuc.NewTokenService(ring, "thiam-api", -time.Minute).Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	valid, err := svc.Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	parts := strings.Split(valid, ".")
+	algNone := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": uuid.NewString(), "iss": "thiam-api"})
+	algNone.Header["kid"] = mustHeader(t, valid, "kid")
+	noneToken, err := algNone.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode string
+	}{
+		{name: "garbage", token: "not-a-jwt", wantCode: "INVALID_TOKEN"},
+		{name: "unknown key", token: foreignKey, wantCode: "INVALID_TOKEN"},
+		{name: "wrong issuer", token: otherIssuer, wantCode: "INVALID_TOKEN"},
+		{name: "tampered payload", token: parts[0] + "." + parts[1] + "x." + parts[2], wantCode: "INVALID_TOKEN"},
+		{name: "alg none", token: noneToken, wantCode: "INVALID_TOKEN"},
+		{name: "expired", token: expired, wantCode: "TOKEN_EXPIRED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims, err := svc.Verify(context.Background(), tt.token)
+
+			require.Error(t, err)
+			assert.Nil(t, claims)
+			requireAppError(t, err, apperror.KindUnauthorized, tt.wantCode)
+		})
+	}
+}
+
+func TestTokenService_Verify_KeyLoadError(t *testing.T) {
+	t.Parallel()
+
+	ring, keyRepo := newTestKeyRing(t, "HS256")
+	svc := authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+
+	raw, err := svc.Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	keyRepo.mu.Lock()
+	keyRepo.listErr = errRepo
+	keyRepo.mu.Unlock()
+
+	_, err = svc.Verify(context.Background(), raw)
+	require.ErrorIs(t, err, errRepo)
+	assert.False(t, apperror.Is(err, apperror.KindUnauthorized))
+}
+
+func TestKeyRing_RotationKeepsOldTokensValid(t *testing.T) {
+	t.Parallel()
+
+	ring, keyRepo := newTestKeyRing(t, "HS256")
+	svc := authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+
+	before, err := svc.Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	require.NoError(t, ring.Rotate(context.Background()))
+	assert.Equal(t, 2, keyRepo.count())
+
+	after, err := svc.Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	assert.NotEqual(t, mustHeader(t, before, "kid"), mustHeader(t, after, "kid"))
+
+	_, err = svc.Verify(context.Background(), before)
+	require.NoError(t, err, "tokens signed before rotation must keep validating")
+
+	_, err = svc.Verify(context.Background(), after)
+	require.NoError(t, err)
+}
+
+func TestKeyRetirement(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 15*time.Minute+time.Minute+30*time.Second, authuc.KeyRetirement(15*time.Minute, time.Minute))
+	assert.Equal(t, 15*time.Minute+5*time.Minute+30*time.Second, authuc.KeyRetirement(15*time.Minute, 5*time.Minute))
+	assert.Equal(t, 15*time.Minute+time.Minute+30*time.Second, authuc.KeyRetirement(15*time.Minute, 0), "the default reload interval")
+}
+
+func TestKeyRing_RotationRetiresAfterMargin(t *testing.T) {
+	t.Parallel()
+
+	keyRepo := &memorySigningKeyRepo{}
+	reload := 5 * time.Minute
+
+	ring, err := authuc.NewKeyRing(keyRepo, newTestCipher(t), authuc.KeyRingConfig{
+		Algorithm:        "HS256",
+		RotationInterval: 24 * time.Hour,
+		RetireAfter:      authuc.KeyRetirement(15*time.Minute, reload),
+		ReloadInterval:   reload,
+	})
+	require.NoError(t, err)
+
+	svc := authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+
+	_, err = svc.Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, ring.Rotate(context.Background()))
+
+	// Another instance may sign with the old key until it reloads, and the
+	// last token it issues has to validate until it expires.
+	lastIssued := time.Now().Add(reload)
+	retired := keyRepo.keys[0].ExpiresAt
+	require.NotNil(t, retired)
+	assert.False(t, retired.Before(lastIssued.Add(15*time.Minute)), "retired at %s", retired)
+	assert.WithinDuration(t, lastIssued.Add(15*time.Minute+30*time.Second), *retired, 5*time.Second)
+}
+
+func TestKeyRing_RevokedKeyStopsVerifying(t *testing.T) {
+	t.Parallel()
+
+	ring, _ := newTestKeyRing(t, "HS256")
+	svc := authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+
+	raw, err := svc.Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	require.NoError(t, ring.Revoke(context.Background(), mustHeader(t, raw, "kid")))
+
+	_, err = svc.Verify(context.Background(), raw)
+	requireAppError(t, err, apperror.KindUnauthorized, "INVALID_TOKEN")
+
+	fresh, err := svc.Issue(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err, "a new active key is created after revocation")
+
+	_, err = svc.Verify(context.Background(), fresh)
+	require.NoError(t, err)
+}
+
+func TestKeyRing_ReusesActiveKey(t *testing.T) {
+	t.Parallel()
+
+	ring, keyRepo := newTestKeyRing(t, "HS256")
+	svc := authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+
+	for range 3 {
+		_, err := svc.Issue(context.Background(), uuid.New(), uuid.New())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, keyRepo.count())
+}
+
+func TestKeyRing_JWKS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		alg     string
+		wantKty string
+		wantCrv string
+	}{
+		{alg: "HS256"},
+		{alg: "RS256", wantKty: "RSA"},
+		{alg: "ES256", wantKty: "EC", wantCrv: "P-256"},
+		{alg: "ES512", wantKty: "EC", wantCrv: "P-521"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			t.Parallel()
+
+			ring, _ := newTestKeyRing(t, tt.alg)
+			svc := authuc.NewTokenService(ring, "thiam-api", 15*time.Minute)
+
+			raw, err := svc.Issue(context.Background(), uuid.New(), uuid.New())
+			require.NoError(t, err)
+
+			keys, err := ring.JWKS(context.Background())
+			require.NoError(t, err)
+
+			if tt.wantKty == "" {
+				assert.Empty(t, keys, "symmetric keys must never be published")
+
+				return
+			}
+
+			require.Len(t, keys, 1)
+			assertJWK(t, keys[0], tt.alg, tt.wantKty, tt.wantCrv, mustHeader(t, raw, "kid"))
+		})
+	}
+}
+
+func TestNewKeyRing_UnsupportedAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	_, err := authuc.NewKeyRing(&memorySigningKeyRepo{}, nil, authuc.KeyRingConfig{Algorithm: "PS256"})
+	require.ErrorIs(t, err, authuc.ErrUnsupportedAlgorithm)
+}
+
+func assertJWK(t *testing.T, key auth.JWK, alg, kty, crv, kid string) {
+	t.Helper()
+
+	assert.Equal(t, kid, key.KeyID)
+	assert.Equal(t, alg, key.Algorithm)
+	assert.Equal(t, kty, key.KeyType)
+	assert.Equal(t, "sig", key.Use)
+	assert.Equal(t, crv, key.Curve)
+
+	if kty == "RSA" {
+		assert.NotEmpty(t, key.N)
+		assert.Equal(t, "AQAB", key.E)
+	} else {
+		assert.NotEmpty(t, key.X)
+		assert.NotEmpty(t, key.Y)
+	}
+}
+
+func mustHeader(t *testing.T, raw, name string) string {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(raw, &jwt.RegisteredClaims{})
+	require.NoError(t, err)
+
+	value, ok := parsed.Header[name].(string)
+	require.True(t, ok)
+
+	return value
+}
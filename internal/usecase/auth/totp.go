@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/totp"
+	"github.com/google/uuid"
+)
+
+// SetupTOTP starts authenticator app enrollment with a fresh secret. Calling it
+// again before the enrollment is verified replaces the pending secret.
+func (uc *UseCase) SetupTOTP(ctx context.Context, userID uuid.UUID) (*auth.TOTPSetup, error) {
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, apperror.NotFound("User not found", apperror.WithCause(err))
+		}
+
+		return nil, fmt.Errorf("UseCase - SetupTOTP - uc.users.GetByID: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - SetupTOTP - totp.GenerateSecret: %w", err)
+	}
+
+	encrypted, err := uc.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("UseCase - SetupTOTP - uc.cipher.Encrypt: %w", err)
+	}
+
+	err = uc.totp.Upsert(ctx, &auth.TOTP{UserID: user.ID, SecretEncrypted: encrypted})
+	if err != nil {
+		if errors.Is(err, auth.ErrTOTPAlreadyEnabled) {
+			return nil, errMFAAlreadyEnabled()
+		}
+
+		return nil, fmt.Errorf("UseCase - SetupTOTP - uc.totp.Upsert: %w", err)
+	}
+
+	return &auth.TOTPSetup{
+		Secret: secret,
+		URI:    totp.URI(uc.cfg.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// VerifyTOTPSetup confirms a pending enrollment with a code from the
+// authenticator app, after which TOTP is required at login. Recovery codes are
+// returned when this is the user's first second factor.
+func (uc *UseCase) VerifyTOTPSetup(ctx context.Context, userID uuid.UUID, code string, client auth.ClientInfo) ([]string, error) {
+	t, err := uc.totp.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrTOTPNotFound) {
+			return nil, apperror.Validation("Start authenticator app setup first", apperror.WithCode(codeTOTPSetupRequired))
+		}
+
+		return nil, fmt.Errorf("UseCase - VerifyTOTPSetup - uc.totp.GetByUserID: %w", err)
+	}
+
+	if t.IsVerified() {
+		return nil, errMFAAlreadyEnabled()
+	}
+
+	ok, err := uc.checkTOTP(ctx, t, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errMFAInvalidCode()
+	}
+
+	if err = uc.totp.MarkVerified(ctx, userID, uc.now().UTC()); err != nil {
+		if errors.Is(err, auth.ErrTOTPAlreadyEnabled) {
+			return nil, errMFAAlreadyEnabled()
+		}
+
+		return nil, fmt.Errorf("UseCase - VerifyTOTPSetup - uc.totp.MarkVerified: %w", err)
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &userID,
+		Type:      auth.EventMFAEnabled,
+		Success:   true,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(auth.MFAMethodTOTP)},
+	})
+
+	return uc.initialRecoveryCodes(ctx, userID)
+}
+
+// DisableTOTP removes the authenticator app after re-checking the password.
+func (uc *UseCase) DisableTOTP(ctx context.Context, userID uuid.UUID, pw string, client auth.ClientInfo) error {
+	user, err := uc.reauthenticate(ctx, userID, pw, client)
+	if err != nil {
+		return err
+	}
+
+	if err = uc.totp.Delete(ctx, user.ID); err != nil {
+		if errors.Is(err, auth.ErrTOTPNotFound) {
+			return apperror.Validation("Authenticator app is not enabled", apperror.WithCode(codeMFANotEnabled))
+		}
+
+		return fmt.Errorf("UseCase - DisableTOTP - uc.totp.Delete: %w", err)
+	}
+
+	if err = uc.dropRecoveryCodesIfUnprotected(ctx, user.ID); err != nil {
+		return err
+	}
+
+	uc.recordEvent(ctx, &auth.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      auth.EventMFADisabled,
+		Success:   true,
+		RiskLevel: auth.RiskMedium,
+		IPAddress: optional(client.IPAddress),
+		UserAgent: optional(client.UserAgent),
+		Details:   map[string]any{"method": string(auth.MFAMethodTOTP)},
+	})
+
+	return nil
+}
+
+// checkTOTP validates code against the enrollment's secret and claims its time
+// step, so the same code is rejected if presented again within its window.
+func (uc *UseCase) checkTOTP(ctx context.Context, t *auth.TOTP, code string) (bool, error) {
+	secret, err := uc.cipher.Decrypt(t.SecretEncrypted)
+	if err != nil {
+		return false, fmt.Errorf("UseCase - checkTOTP - uc.cipher.Decrypt: %w", err)
+	}
+
+	step, ok, err := totp.Validate(string(secret), code, uc.now(), uc.cfg.TOTPSkew)
+	if err != nil {
+		return false, fmt.Errorf("UseCase - checkTOTP - totp.Validate: %w", err)
+	}
+
+	if !ok {
+		return false, nil
+	}
+
+	if err = uc.totp.UseStep(ctx, t.UserID, step); err != nil {
+		if errors.Is(err, auth.ErrTOTPCodeReused) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("UseCase - checkTOTP - uc.totp.UseStep: %w", err)
+	}
+
+	return true, nil
+}
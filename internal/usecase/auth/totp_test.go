@@ -0,0 +1,231 @@
+package auth_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingTOTP returns an unverified enrollment for userID along with its plain secret.
+func pendingTOTP(t *testing.T, userID uuid.UUID) (*auth.TOTP, string) {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	encrypted, err := newTestCipher(t).Encrypt([]byte(secret))
+	require.NoError(t, err)
+
+	return &auth.TOTP{ID: uuid.New(), UserID: userID, SecretEncrypted: encrypted}, secret
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	return code
+}
+
+func TestUseCase_SetupTOTP(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	totpRepo := newMemoryTOTPRepo()
+
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: &mockUserRepo{
+			getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+				return user, nil
+			},
+		},
+		TOTP: totpRepo,
+	})
+
+	setup, err := uc.SetupTOTP(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	u, err := url.Parse(setup.URI)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, setup.Secret, u.Query().Get("secret"))
+	assert.Equal(t, "Thiam", u.Query().Get("issuer"))
+
+	stored, err := totpRepo.GetByUserID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored.SecretEncrypted, setup.Secret)
+	assert.False(t, stored.IsVerified())
+
+	plain, err := newTestCipher(t).Decrypt(stored.SecretEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, setup.Secret, string(plain))
+
+	// A second setup before verification replaces the pending secret.
+	again, err := uc.SetupTOTP(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, setup.Secret, again.Secret)
+
+	_, err = uc.VerifyTOTPSetup(context.Background(), user.ID, currentCode(t, again.Secret), auth.ClientInfo{})
+	require.NoError(t, err)
+
+	_, err = uc.SetupTOTP(context.Background(), user.ID)
+	requireAppError(t, err, apperror.KindConflict, "MFA_ALREADY_ENABLED")
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_VerifyTOTPSetup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		enrolled  bool
+		verified  bool
+		code      func(secret string) string
+		wantKind  apperror.Kind
+		wantCode  string
+		wantEvent bool
+	}{
+		{
+			name:      "enables totp",
+			enrolled:  true,
+			code:      func(secret string) string { return currentCode(t, secret) },
+			wantEvent: true,
+		},
+		{
+			name:     "wrong code",
+			enrolled: true,
+			code:     func(_ string) string { return "000000" },
+			wantKind: apperror.KindValidation,
+			wantCode: "MFA_INVALID_CODE",
+		},
+		{
+			name:     "no setup",
+			code:     func(_ string) string { return "123456" },
+			wantKind: apperror.KindValidation,
+			wantCode: "TOTP_SETUP_REQUIRED",
+		},
+		{
+			name:     "already verified",
+			enrolled: true,
+			verified: true,
+			code:     func(secret string) string { return currentCode(t, secret) },
+			wantKind: apperror.KindConflict,
+			wantCode: "MFA_ALREADY_ENABLED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := uuid.New()
+			totpRepo := newMemoryTOTPRepo()
+			events := &mockSecurityEventRepo{}
+
+			var secret string
+
+			if tt.enrolled {
+				var entry *auth.TOTP
+
+				entry, secret = pendingTOTP(t, userID)
+
+				if tt.verified {
+					now := time.Now()
+					entry.VerifiedAt = &now
+				}
+
+				totpRepo = newMemoryTOTPRepo(entry)
+			}
+
+			uc := newTestUseCase(t, &authuc.UseCaseDeps{TOTP: totpRepo, SecurityEvents: events})
+
+			codes, err := uc.VerifyTOTPSetup(context.Background(), userID, tt.code(secret), auth.ClientInfo{})
+
+			if tt.wantCode != "" {
+				requireAppError(t, err, tt.wantKind, tt.wantCode)
+				assert.Empty(t, events.stored())
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, codes, 4, "the first factor comes with recovery codes")
+
+			stored, err := totpRepo.GetByUserID(context.Background(), userID)
+			require.NoError(t, err)
+			assert.True(t, stored.IsVerified())
+
+			recorded := events.stored()
+			require.Len(t, recorded, 1)
+			assert.Equal(t, auth.EventMFAEnabled, recorded[0].Type)
+			assert.Equal(t, "totp", recorded[0].Details["method"])
+		})
+	}
+}
+
+func TestUseCase_VerifyTOTPSetup_RejectsReplayedCode(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	entry, secret := pendingTOTP(t, userID)
+	step := totp.Step(time.Now()) + 1
+	entry.LastUsedStep = &step
+
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{TOTP: newMemoryTOTPRepo(entry)})
+
+	_, err := uc.VerifyTOTPSetup(context.Background(), userID, currentCode(t, secret), auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "MFA_INVALID_CODE")
+}
+
+func TestUseCase_DisableTOTP(t *testing.T) {
+	t.Parallel()
+
+	user := existingUser(auth.StatusActive)
+	entry, _ := pendingTOTP(t, user.ID)
+	now := time.Now()
+	entry.VerifiedAt = &now
+
+	totpRepo := newMemoryTOTPRepo(entry)
+	events := &mockSecurityEventRepo{}
+
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{
+		Users: &mockUserRepo{
+			getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+				return user, nil
+			},
+		},
+		TOTP:           totpRepo,
+		SecurityEvents: events,
+	})
+
+	err := uc.DisableTOTP(context.Background(), user.ID, "WrongP@ss123", auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "PASSWORD_INCORRECT")
+
+	status, err := uc.MFAStatus(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.True(t, status.Enabled())
+
+	require.NoError(t, uc.DisableTOTP(context.Background(), user.ID, "SecureP@ss123", auth.ClientInfo{}))
+
+	status, err = uc.MFAStatus(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.False(t, status.Enabled())
+
+	recorded := events.stored()
+	require.Len(t, recorded, 2)
+	assert.Equal(t, auth.EventLoginFailed, recorded[0].Type, "the wrong password is audited")
+	assert.Equal(t, auth.EventMFADisabled, recorded[1].Type)
+
+	err = uc.DisableTOTP(context.Background(), user.ID, "SecureP@ss123", auth.ClientInfo{})
+	requireAppError(t, err, apperror.KindValidation, "MFA_NOT_ENABLED")
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+)
+
+const (
+	verifyEmailPath = "/verify-email"
+
+	verificationWindow = time.Hour
+)
+
+// VerifyEmail consumes an emailed verification token, marking the address as
+// verified and activating a pending account.
+func (uc *UseCase) VerifyEmail(ctx context.Context, rawToken string) error {
+	v, err := uc.verifications.GetByHash(ctx, token.Hash(rawToken))
+	if err != nil {
+		if errors.Is(err, auth.ErrVerificationNotFound) {
+			return errVerificationInvalid()
+		}
+
+		return fmt.Errorf("UseCase - VerifyEmail - uc.verifications.GetByHash: %w", err)
+	}
+
+	if v.VerifiedAt != nil {
+		return errVerificationInvalid()
+	}
+
+	now := uc.now().UTC()
+
+	if v.IsExpired(now) {
+		return apperror.Unauthorized("Verification link has expired", apperror.WithCode(codeTokenExpired))
+	}
+
+	if err = uc.verifications.Confirm(ctx, v, now); err != nil {
+		if errors.Is(err, auth.ErrVerificationUsed) || errors.Is(err, auth.ErrVerificationNotFound) {
+			return errVerificationInvalid()
+		}
+
+		return fmt.Errorf("UseCase - VerifyEmail - uc.verifications.Confirm: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerification emails a fresh verification link. Requests are limited to
+// one per cooldown and a fixed number per hour.
+func (uc *UseCase) ResendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return apperror.NotFound("User not found", apperror.WithCause(err))
+		}
+
+		return fmt.Errorf("UseCase - ResendVerification - uc.users.GetByID: %w", err)
+	}
+
+	if user.EmailVerified {
+		return apperror.Conflict("Email is already verified")
+	}
+
+	now := uc.now().UTC()
+
+	recent, err := uc.verifications.CountSince(ctx, userID, now.Add(-uc.cfg.VerificationResendCooldown))
+	if err != nil {
+		return fmt.Errorf("UseCase - ResendVerification - uc.verifications.CountSince: %w", err)
+	}
+
+	if recent > 0 {
+		return apperror.RateLimited("Please wait before requesting another verification email",
+			apperror.WithRetryAfter(uc.cfg.VerificationResendCooldown))
+	}
+
+	hourly, err := uc.verifications.CountSince(ctx, userID, now.Add(-verificationWindow))
+	if err != nil {
+		return fmt.Errorf("UseCase - ResendVerification - uc.verifications.CountSince: %w", err)
+	}
+
+	if hourly >= uc.cfg.VerificationMaxPerHour {
+		return apperror.RateLimited("Too many verification emails requested",
+			apperror.WithRetryAfter(verificationWindow))
+	}
+
+	return uc.sendVerification(ctx, user)
+}
+
+// sendVerification stores a new verification token for the user's current
+// email and mails the link.
+func (uc *UseCase) sendVerification(ctx context.Context, user *auth.User) error {
+	raw, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return fmt.Errorf("UseCase - sendVerification - token.Generate: %w", err)
+	}
+
+	v := &auth.EmailVerification{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: token.Hash(raw),
+		ExpiresAt: uc.now().UTC().Add(uc.cfg.EmailVerificationTTL),
+	}
+
+	if err = uc.verifications.Store(ctx, v); err != nil {
+		return fmt.Errorf("UseCase - sendVerification - uc.verifications.Store: %w", err)
+	}
+
+	return uc.sendEmail(ctx, user, user.Email, verifyEmailTemplate, emailData{
+		Link:      uc.link(verifyEmailPath, raw),
+		ExpiresIn: humanDuration(uc.cfg.EmailVerificationTTL),
+	})
+}
@@ -0,0 +1,284 @@
+package auth_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/auth"
+	authuc "github.com/evrone/go-clean-template/internal/usecase/auth"
+	"github.com/evrone/go-clean-template/pkg/apperror"
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_VerifyEmail(t *testing.T) {
+	t.Parallel()
+
+	pending := func() *auth.EmailVerification {
+		return &auth.EmailVerification{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			Email:     "user@example.com",
+			TokenHash: token.Hash("raw-token"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name         string
+		verification *auth.EmailVerification
+		confirmErr   error
+		wantCode     string
+		wantConfirm  bool
+	}{
+		{
+			name:         "success",
+			verification: pending(),
+			wantConfirm:  true,
+		},
+		{
+			name:     "unknown token",
+			wantCode: "INVALID_TOKEN",
+		},
+		{
+			name: "already used",
+			verification: func() *auth.EmailVerification {
+				v := pending()
+				v.VerifiedAt = &usedAt
+
+				return v
+			}(),
+			wantCode: "INVALID_TOKEN",
+		},
+		{
+			name: "expired",
+			verification: func() *auth.EmailVerification {
+				v := pending()
+				v.ExpiresAt = time.Now().Add(-time.Second)
+
+				return v
+			}(),
+			wantCode: "TOKEN_EXPIRED",
+		},
+		{
+			name:         "consumed concurrently",
+			verification: pending(),
+			confirmErr:   auth.ErrVerificationUsed,
+			wantCode:     "INVALID_TOKEN",
+			wantConfirm:  true,
+		},
+		{
+			name:         "email changed since",
+			verification: pending(),
+			confirmErr:   auth.ErrVerificationNotFound,
+			wantCode:     "INVALID_TOKEN",
+			wantConfirm:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var confirmed bool
+
+			verifications := &mockEmailVerificationRepo{
+				getByHashFunc: func(_ context.Context, hash string) (*auth.EmailVerification, error) {
+					if tt.verification == nil || hash != tt.verification.TokenHash {
+						return nil, auth.ErrVerificationNotFound
+					}
+
+					return tt.verification, nil
+				},
+				confirmFunc: func(_ context.Context, v *auth.EmailVerification, _ time.Time) error {
+					assert.Equal(t, tt.verification.ID, v.ID)
+
+					confirmed = true
+
+					return tt.confirmErr
+				},
+			}
+
+			uc := newTestUseCase(t, &authuc.UseCaseDeps{Verifications: verifications})
+			err := uc.VerifyEmail(context.Background(), "raw-token")
+
+			assert.Equal(t, tt.wantConfirm, confirmed)
+
+			if tt.wantCode != "" {
+				requireAppError(t, err, apperror.KindUnauthorized, tt.wantCode)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestUseCase_ResendVerification(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		verified   bool
+		recent     int
+		hourly     int
+		wantKind   apperror.Kind
+		wantRetry  string
+		wantErr    bool
+		wantSentTo string
+	}{
+		{
+			name:       "sends a new link",
+			wantSentTo: "user@example.com",
+		},
+		{
+			name:     "already verified",
+			verified: true,
+			wantErr:  true,
+			wantKind: apperror.KindConflict,
+		},
+		{
+			name:      "within cooldown",
+			recent:    1,
+			hourly:    1,
+			wantErr:   true,
+			wantKind:  apperror.KindRateLimited,
+			wantRetry: "60",
+		},
+		{
+			name:      "hourly limit reached",
+			hourly:    5,
+			wantErr:   true,
+			wantKind:  apperror.KindRateLimited,
+			wantRetry: "3600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			user := existingUser(auth.StatusPendingVerification)
+			user.EmailVerified = tt.verified
+
+			var stored *auth.EmailVerification
+
+			verifications := &mockEmailVerificationRepo{
+				countSinceFunc: func(_ context.Context, _ uuid.UUID, since time.Time) (int, error) {
+					if time.Since(since) < 2*time.Minute {
+						return tt.recent, nil
+					}
+
+					return tt.hourly, nil
+				},
+				storeFunc: func(_ context.Context, v *auth.EmailVerification) error {
+					stored = v
+
+					return nil
+				},
+			}
+			notifier := &mockEmailNotifier{}
+
+			uc := newTestUseCase(t, &authuc.UseCaseDeps{
+				Users: &mockUserRepo{
+					getByIDFunc: func(_ context.Context, _ uuid.UUID) (*auth.User, error) {
+						return user, nil
+					},
+				},
+				Verifications: verifications,
+				Notifier:      notifier,
+			})
+
+			err := uc.ResendVerification(context.Background(), user.ID)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantKind, apperror.GetKind(err))
+				assert.Empty(t, notifier.sent())
+
+				if tt.wantRetry != "" {
+					appErr, _ := apperror.AsAppError(err)
+					assert.Equal(t, tt.wantRetry, appErr.Fields()[apperror.RetryAfterField])
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, stored)
+			assert.Equal(t, user.ID, stored.UserID)
+			assert.Equal(t, user.Email, stored.Email)
+
+			sent := notifier.sent()
+			require.Len(t, sent, 1)
+			assert.Equal(t, []string{tt.wantSentTo}, sent[0].To)
+			assert.True(t, sent[0].Transactional)
+
+			raw := linkToken(t, sent[0].Body)
+			assert.Equal(t, stored.TokenHash, token.Hash(raw))
+			assert.Contains(t, sent[0].HTMLBody, "https://app.example.com/verify-email?token=")
+		})
+	}
+}
+
+func TestUseCase_Register_SendsVerification(t *testing.T) {
+	t.Parallel()
+
+	notifier := &mockEmailNotifier{}
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{Notifier: notifier})
+
+	_, err := uc.Register(context.Background(), auth.RegisterInput{
+		Email:    "new@example.com",
+		Password: "SecureP@ss123",
+		Name:     "Jane",
+	})
+	require.NoError(t, err)
+
+	sent := notifier.sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, []string{"new@example.com"}, sent[0].To)
+	assert.Contains(t, sent[0].Body, "Hi Jane")
+	assert.Contains(t, sent[0].Body, "1 day")
+}
+
+func TestUseCase_Register_IgnoresDeliveryFailure(t *testing.T) {
+	t.Parallel()
+
+	uc := newTestUseCase(t, &authuc.UseCaseDeps{Notifier: &mockEmailNotifier{err: errRepo}})
+
+	result, err := uc.Register(context.Background(), auth.RegisterInput{
+		Email:    "new@example.com",
+		Password: "SecureP@ss123",
+	})
+
+	require.NoError(t, err)
+	assert.NotNil(t, result.Tokens)
+}
+
+// linkToken extracts the token query parameter from the first link in body.
+func linkToken(t *testing.T, body string) string {
+	t.Helper()
+
+	start := strings.Index(body, "https://")
+	require.GreaterOrEqual(t, start, 0, "no link in %q", body)
+
+	link := strings.Fields(body[start:])[0]
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	raw := u.Query().Get("token")
+	require.NotEmpty(t, raw)
+
+	return raw
+}
@@ -3,8 +3,11 @@
 
 import (
 	"context"
+	"time"
 
+	"github.com/evrone/go-clean-template/internal/entity/auth"
 	"github.com/evrone/go-clean-template/internal/entity/notification"
+	"github.com/evrone/go-clean-template/pkg/password"
 	"github.com/google/uuid"
 )
 
@@ -34,4 +37,146 @@
 		Unregister(ctx context.Context, token string) error
 		UnregisterAll(ctx context.Context, userID uuid.UUID) error
 	}
+
+	// Auth handles registration, password login, token refresh and logout.
+	Auth interface {
+		Register(ctx context.Context, in auth.RegisterInput) (*auth.AuthResult, error)
+		Login(ctx context.Context, in auth.LoginInput) (*auth.AuthResult, error)
+		Logout(ctx context.Context, in auth.LogoutInput) error
+		Refresh(ctx context.Context, in auth.RefreshInput) (*auth.TokenPair, error)
+		Me(ctx context.Context, userID uuid.UUID) (*auth.User, error)
+	}
+
+	// Sessions lists and revokes a user's active sessions.
+	Sessions interface {
+		ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]auth.Session, error)
+		RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, client auth.ClientInfo) error
+		RevokeOtherSessions(ctx context.Context, userID, currentSessionID uuid.UUID, client auth.ClientInfo) (int64, error)
+	}
+
+	// EmailVerification confirms ownership of a user's email address.
+	EmailVerification interface {
+		VerifyEmail(ctx context.Context, token string) error
+		ResendVerification(ctx context.Context, userID uuid.UUID) error
+	}
+
+	// Password handles password resets, changes and strength checks.
+	Password interface {
+		ForgotPassword(ctx context.Context, email string, client auth.ClientInfo) error
+		ResetPassword(ctx context.Context, in auth.ResetPasswordInput) error
+		ChangePassword(ctx context.Context, in auth.ChangePasswordInput) error
+		ValidatePassword(ctx context.Context, pw string) password.Result
+	}
+
+	// MFA manages a user's second factors.
+	MFA interface {
+		MFAStatus(ctx context.Context, userID uuid.UUID) (*auth.MFAStatus, error)
+		SetupTOTP(ctx context.Context, userID uuid.UUID) (*auth.TOTPSetup, error)
+		VerifyTOTPSetup(ctx context.Context, userID uuid.UUID, code string, client auth.ClientInfo) ([]string, error)
+		DisableTOTP(ctx context.Context, userID uuid.UUID, pw string, client auth.ClientInfo) error
+		SetupSMS(ctx context.Context, userID uuid.UUID, phone string) error
+		VerifySMSSetup(ctx context.Context, userID uuid.UUID, code string, client auth.ClientInfo) ([]string, error)
+		DisableSMS(ctx context.Context, userID uuid.UUID, pw string, client auth.ClientInfo) error
+		CompleteMFAChallenge(ctx context.Context, in auth.MFAChallengeInput) (*auth.AuthResult, error)
+		SendMFACode(ctx context.Context, challengeToken string, method auth.MFAMethod) error
+	}
+
+	// RecoveryCodes manages MFA recovery codes and signing in with one.
+	RecoveryCodes interface {
+		RecoveryCodeStatus(ctx context.Context, userID uuid.UUID) (*auth.RecoveryCodeStatus, error)
+		GenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, pw string, client auth.ClientInfo) ([]string, error)
+		UseRecoveryCode(ctx context.Context, in auth.RecoveryCodeLoginInput) (*auth.AuthResult, error)
+	}
+
+	// OAuth signs users in with social login providers and manages their links.
+	OAuth interface {
+		AuthorizeOAuth(ctx context.Context, in auth.OAuthAuthorizeInput) (string, error)
+		OAuthCallback(ctx context.Context, in auth.OAuthCallbackInput) (*auth.AuthResult, error)
+		OAuthConnections(ctx context.Context, userID uuid.UUID) ([]auth.OAuthConnection, error)
+		UnlinkOAuth(ctx context.Context, userID uuid.UUID, provider auth.OAuthProvider, client auth.ClientInfo) error
+	}
+
+	// Passkeys registers WebAuthn credentials and signs users in with them.
+	Passkeys interface {
+		Passkeys(ctx context.Context, userID uuid.UUID) ([]auth.Passkey, error)
+		StartPasskeyRegistration(ctx context.Context, userID uuid.UUID) (*auth.PasskeyCreationOptions, error)
+		FinishPasskeyRegistration(ctx context.Context, in auth.PasskeyRegistrationInput) (*auth.Passkey, error)
+		StartPasskeyLogin(ctx context.Context, in auth.PasskeyLoginStartInput) (*auth.PasskeyRequestOptions, error)
+		FinishPasskeyLogin(ctx context.Context, in auth.PasskeyLoginInput) (*auth.AuthResult, error)
+		RenamePasskey(ctx context.Context, userID, id uuid.UUID, name string) (*auth.Passkey, error)
+		DeletePasskey(ctx context.Context, userID, id uuid.UUID, client auth.ClientInfo) error
+	}
+
+	MagicLink interface {
+		SendMagicLink(ctx context.Context, in auth.MagicLinkSendInput) (*auth.MagicLinkSent, error)
+		VerifyMagicLink(ctx context.Context, in auth.MagicLinkVerifyInput) (*auth.AuthResult, error)
+		ResendMagicLink(ctx context.Context, in auth.MagicLinkResendInput) (*auth.MagicLinkSent, error)
+	}
+
+	// AccountRecovery lets a user who lost their second factor prove control
+	// of their email or phone and reset MFA.
+	AccountRecovery interface {
+		StartAccountRecovery(ctx context.Context, in auth.RecoveryStartInput) (*auth.RecoveryStarted, error)
+		VerifyAccountRecovery(ctx context.Context, in auth.RecoveryVerifyInput) (*auth.RecoveryVerified, error)
+		ResetMFAWithRecovery(ctx context.Context, in auth.RecoveryResetMFAInput) (*auth.AuthResult, error)
+		ResendAccountRecovery(ctx context.Context, in auth.RecoveryResendInput) error
+	}
+
+	// ContactChange changes a user's email address and phone number.
+	ContactChange interface {
+		RequestEmailChange(ctx context.Context, in auth.EmailChangeInput) error
+		ConfirmEmailChange(ctx context.Context, rawToken string, client auth.ClientInfo) (*auth.EmailChange, error)
+		RequestPhoneChange(ctx context.Context, in auth.PhoneChangeInput) error
+		ConfirmPhoneChange(ctx context.Context, in auth.PhoneChangeConfirmInput) (*auth.User, error)
+		RemovePhone(ctx context.Context, userID uuid.UUID, password string, client auth.ClientInfo) (*auth.User, error)
+	}
+
+	// AccountDeletion deletes a user's account after a grace period.
+	AccountDeletion interface {
+		RequestAccountDeletion(ctx context.Context, in auth.AccountDeletionInput) error
+		ConfirmAccountDeletion(ctx context.Context, in auth.AccountDeletionConfirmInput) (time.Time, error)
+	}
+
+	// DataExport gives users a copy of their personal data.
+	DataExport interface {
+		RequestDataExport(ctx context.Context, in auth.DataExportInput) (*auth.DataExport, error)
+		DataExports(ctx context.Context, userID uuid.UUID) ([]auth.DataExport, error)
+		DownloadDataExport(ctx context.Context, in auth.DataExportDownloadInput) (*auth.DataExportArchive, error)
+	}
+
+	// Profile changes a user's name and avatar and serves uploaded avatars.
+	Profile interface {
+		UpdateProfile(ctx context.Context, in auth.ProfileUpdateInput) (*auth.User, error)
+		UploadAvatar(ctx context.Context, in auth.AvatarUploadInput) (*auth.User, error)
+		RemoveAvatar(ctx context.Context, userID uuid.UUID, client auth.ClientInfo) (*auth.User, error)
+		Avatar(ctx context.Context, in auth.AvatarInput) (*auth.AvatarImage, error)
+	}
+
+	// Availability tells whether an email address or phone number is free to
+	// register with.
+	Availability interface {
+		CheckEmailAvailability(ctx context.Context, in auth.AvailabilityInput) (*auth.Availability, error)
+		CheckPhoneAvailability(ctx context.Context, in auth.AvailabilityInput) (*auth.Availability, error)
+	}
+
+	// SecurityEvents reads a user's security audit log.
+	SecurityEvents interface {
+		SecurityEvents(ctx context.Context, in auth.SecurityEventListInput) (*auth.SecurityEventList, error)
+		SecurityEvent(ctx context.Context, userID, id uuid.UUID) (*auth.SecurityEvent, error)
+	}
+
+	// Settings publishes the auth rules clients need to know up front.
+	Settings interface {
+		Settings(ctx context.Context) *auth.Settings
+	}
+
+	// TokenVerifier validates access tokens.
+	TokenVerifier interface {
+		Verify(ctx context.Context, accessToken string) (*auth.Claims, error)
+	}
+
+	// JWKS publishes the public signing keys.
+	JWKS interface {
+		JWKS(ctx context.Context) ([]auth.JWK, error)
+	}
 )
@@ -16,15 +16,19 @@ type Service struct {
 	pushTokenRepo    repo.PushTokenRepo
 	deliveryLogRepo  repo.DeliveryLogRepo
 	emailSender      notify.EmailSender
+	smsSender        notify.SMSSender
 	pushSender       notify.PushSender
 }
 
+var _ notify.Notifier = (*Service)(nil)
+
 type ServiceDeps struct {
 	NotificationRepo repo.NotificationRepo
 	PrefsRepo        repo.NotificationPreferencesRepo
 	PushTokenRepo    repo.PushTokenRepo
 	DeliveryLogRepo  repo.DeliveryLogRepo
 	EmailSender      notify.EmailSender
+	SMSSender        notify.SMSSender
 	PushSender       notify.PushSender
 }
 
@@ -35,6 +39,7 @@ func NewService(deps *ServiceDeps) *Service {
 		pushTokenRepo:    deps.PushTokenRepo,
 		deliveryLogRepo:  deps.DeliveryLogRepo,
 		emailSender:      deps.EmailSender,
+		smsSender:        deps.SMSSender,
 		pushSender:       deps.PushSender,
 	}
 }
@@ -115,9 +120,11 @@ func (s *Service) SendEmail(ctx context.Context, msg *notification.EmailMessage)
 		return nil
 	}
 
-	prefs, err := s.prefsRepo.Get(ctx, msg.UserID)
-	if err == nil && prefs != nil && !prefs.EmailEnabled {
-		return nil
+	if !msg.Transactional {
+		prefs, err := s.prefsRepo.Get(ctx, msg.UserID)
+		if err == nil && prefs != nil && !prefs.EmailEnabled {
+			return nil
+		}
 	}
 
 	if err := s.emailSender.Send(ctx, msg); err != nil {
@@ -131,6 +138,29 @@ func (s *Service) SendEmail(ctx context.Context, msg *notification.EmailMessage)
 	return nil
 }
 
+func (s *Service) SendSMS(ctx context.Context, msg *notification.SMSMessage) error {
+	if s.smsSender == nil {
+		return nil
+	}
+
+	if !msg.Transactional {
+		prefs, err := s.prefsRepo.Get(ctx, msg.UserID)
+		if err == nil && prefs != nil && !prefs.SMSEnabled {
+			return nil
+		}
+	}
+
+	if err := s.smsSender.Send(ctx, msg); err != nil {
+		s.logDelivery(ctx, uuid.Nil, msg.UserID, notification.ChannelSMS, notification.StatusFailed, err.Error())
+
+		return fmt.Errorf("Service - SendSMS - s.smsSender.Send: %w", err)
+	}
+
+	s.logDelivery(ctx, uuid.Nil, msg.UserID, notification.ChannelSMS, notification.StatusSent, "")
+
+	return nil
+}
+
 func (s *Service) logDelivery(ctx context.Context, notificationID, userID uuid.UUID, channel notification.Channel, status notification.Status, errMsg string) {
 	log := &notification.DeliveryLog{
 		NotificationID: notificationID,
@@ -43,6 +43,18 @@ func (m *mockEmailSender) Send(ctx context.Context, msg *notification.EmailMessa
 	return nil
 }
 
+type mockSMSSender struct {
+	sendFunc func(ctx context.Context, msg *notification.SMSMessage) error
+}
+
+func (m *mockSMSSender) Send(ctx context.Context, msg *notification.SMSMessage) error {
+	if m.sendFunc != nil {
+		return m.sendFunc(ctx, msg)
+	}
+
+	return nil
+}
+
 type mockPushSender struct {
 	sendFunc func(ctx context.Context, msg *notification.PushMessage, tokens []string) error
 }
@@ -350,6 +362,28 @@ func TestService_SendEmail(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "transactional ignores preferences",
+			prefsRepo: &mockPreferencesRepo{
+				getFunc: func(_ context.Context, _ uuid.UUID) (*notification.UserPreferences, error) {
+					return &notification.UserPreferences{EmailEnabled: false}, nil
+				},
+			},
+			deliveryLog: &mockDeliveryLogRepo{},
+			emailSender: &mockEmailSender{
+				sendFunc: func(_ context.Context, _ *notification.EmailMessage) error {
+					return errRepo
+				},
+			},
+			input: &notification.EmailMessage{
+				UserID:        userID,
+				To:            []string{"test@example.com"},
+				Subject:       "Verify your email",
+				Body:          "Test body",
+				Transactional: true,
+			},
+			wantErr: true,
+		},
 		{
 			name: "send error",
 			prefsRepo: &mockPreferencesRepo{
@@ -397,3 +431,118 @@ func TestService_SendEmail(t *testing.T) {
 		})
 	}
 }
+
+//nolint:funlen // table-driven tests are verbose
+func TestService_SendSMS(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name       string
+		prefs      *notification.UserPreferences
+		sendErr    error
+		input      *notification.SMSMessage
+		wantSent   bool
+		wantStatus notification.Status
+		wantErr    bool
+	}{
+		{
+			name:       "success",
+			prefs:      &notification.UserPreferences{SMSEnabled: true},
+			input:      &notification.SMSMessage{UserID: userID, To: "+14155551234", Body: "Hello"},
+			wantSent:   true,
+			wantStatus: notification.StatusSent,
+		},
+		{
+			name:  "disabled by preferences",
+			prefs: &notification.UserPreferences{SMSEnabled: false},
+			input: &notification.SMSMessage{UserID: userID, To: "+14155551234", Body: "Hello"},
+		},
+		{
+			name:       "transactional ignores preferences",
+			prefs:      &notification.UserPreferences{SMSEnabled: false},
+			input:      &notification.SMSMessage{UserID: userID, To: "+14155551234", Body: "Your code is 123456", Transactional: true},
+			wantSent:   true,
+			wantStatus: notification.StatusSent,
+		},
+		{
+			name:       "send error is logged",
+			prefs:      &notification.UserPreferences{SMSEnabled: true},
+			sendErr:    errRepo,
+			input:      &notification.SMSMessage{UserID: userID, To: "+14155551234", Body: "Hello"},
+			wantSent:   true,
+			wantStatus: notification.StatusFailed,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				sent bool
+				logs []notification.DeliveryLog
+			)
+
+			svc := notificationuc.NewService(&notificationuc.ServiceDeps{
+				NotificationRepo: &mockNotificationRepo{},
+				PrefsRepo: &mockPreferencesRepo{
+					getFunc: func(_ context.Context, _ uuid.UUID) (*notification.UserPreferences, error) {
+						return tt.prefs, nil
+					},
+				},
+				PushTokenRepo: &mockPushTokenRepo{},
+				DeliveryLogRepo: &mockDeliveryLogRepo{
+					storeFunc: func(_ context.Context, log *notification.DeliveryLog) error {
+						logs = append(logs, *log)
+
+						return nil
+					},
+				},
+				SMSSender: &mockSMSSender{
+					sendFunc: func(_ context.Context, _ *notification.SMSMessage) error {
+						sent = true
+
+						return tt.sendErr
+					},
+				},
+			})
+
+			err := svc.SendSMS(context.Background(), tt.input)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.wantSent, sent)
+
+			if !tt.wantSent {
+				require.Empty(t, logs)
+
+				return
+			}
+
+			require.Len(t, logs, 1)
+			require.Equal(t, notification.ChannelSMS, logs[0].Channel)
+			require.Equal(t, tt.wantStatus, logs[0].Status)
+			require.Equal(t, userID, logs[0].UserID)
+		})
+	}
+}
+
+func TestService_SendSMS_NoSender(t *testing.T) {
+	t.Parallel()
+
+	svc := notificationuc.NewService(&notificationuc.ServiceDeps{
+		NotificationRepo: &mockNotificationRepo{},
+		PrefsRepo:        &mockPreferencesRepo{},
+		PushTokenRepo:    &mockPushTokenRepo{},
+		DeliveryLogRepo:  &mockDeliveryLogRepo{},
+	})
+
+	require.NoError(t, svc.SendSMS(context.Background(), &notification.SMSMessage{To: "+14155551234", Body: "Hello"}))
+}
@@ -0,0 +1,3 @@
+-- Remember the last accepted TOTP time step so a code cannot be replayed
+-- within its validity window.
+ALTER TABLE mfa_totp ADD COLUMN IF NOT EXISTS last_used_step BIGINT;
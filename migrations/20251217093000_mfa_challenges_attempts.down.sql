@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_mfa_challenges_user_created;
+
+ALTER TABLE mfa_challenges DROP COLUMN IF EXISTS remember_me;
+ALTER TABLE mfa_challenges DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,6 @@
+-- Track failed codes per challenge for the MFA attempt limit, and carry the
+-- login's remember-me choice through to the session created on completion.
+ALTER TABLE mfa_challenges ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE mfa_challenges ADD COLUMN IF NOT EXISTS remember_me BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_mfa_challenges_user_created ON mfa_challenges(user_id, created_at DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE mfa_sms DROP COLUMN IF EXISTS code_sent_at;
+ALTER TABLE mfa_sms DROP COLUMN IF EXISTS code_attempts;
+ALTER TABLE mfa_sms DROP COLUMN IF EXISTS code_expires_at;
+ALTER TABLE mfa_sms DROP COLUMN IF EXISTS code_hash;
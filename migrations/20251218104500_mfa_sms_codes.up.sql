@@ -0,0 +1,6 @@
+-- The latest one-time code sent to an enrolled phone, stored hashed, with a
+-- count of failed attempts against it.
+ALTER TABLE mfa_sms ADD COLUMN IF NOT EXISTS code_hash VARCHAR(64);
+ALTER TABLE mfa_sms ADD COLUMN IF NOT EXISTS code_expires_at TIMESTAMPTZ;
+ALTER TABLE mfa_sms ADD COLUMN IF NOT EXISTS code_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE mfa_sms ADD COLUMN IF NOT EXISTS code_sent_at TIMESTAMPTZ;
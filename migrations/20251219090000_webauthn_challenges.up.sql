@@ -0,0 +1,20 @@
+-- Pending passkey registration and authentication ceremonies. The random
+-- challenge is stored hashed and deleted when the ceremony finishes, so each
+-- one can be answered once. An authentication bound to an MFA challenge
+-- completes that login's second step.
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+
+    challenge_hash VARCHAR(64) NOT NULL,
+    ceremony VARCHAR(20) NOT NULL,
+    mfa_challenge_id UUID REFERENCES mfa_challenges(id) ON DELETE CASCADE,
+
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT webauthn_challenges_hash_unique UNIQUE (challenge_hash),
+    CONSTRAINT webauthn_challenges_ceremony_check CHECK (ceremony IN ('registration', 'authentication'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_expires_at ON webauthn_challenges(expires_at);
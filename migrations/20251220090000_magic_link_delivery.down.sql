@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_magic_link_sessions_created;
+ALTER TABLE magic_link_sessions DROP CONSTRAINT IF EXISTS magic_link_sessions_method_check;
+ALTER TABLE magic_link_sessions DROP COLUMN IF EXISTS sent_at;
+ALTER TABLE magic_link_sessions DROP COLUMN IF EXISTS attempts;
+ALTER TABLE magic_link_sessions DROP COLUMN IF EXISTS delivery_method;
@@ -0,0 +1,9 @@
+-- How a magic link session was last delivered, when, and how many wrong
+-- codes were tried against it. Sends are counted per identifier, so the
+-- lookup is indexed by creation time.
+ALTER TABLE magic_link_sessions ADD COLUMN IF NOT EXISTS delivery_method VARCHAR(10) NOT NULL DEFAULT 'email';
+ALTER TABLE magic_link_sessions ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE magic_link_sessions ADD COLUMN IF NOT EXISTS sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE magic_link_sessions ADD CONSTRAINT magic_link_sessions_method_check CHECK (delivery_method IN ('email', 'sms'));
+
+CREATE INDEX IF NOT EXISTS idx_magic_link_sessions_created ON magic_link_sessions(identifier, identifier_type, created_at);
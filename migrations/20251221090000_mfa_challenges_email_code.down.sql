@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_security_events_user_type_created;
+
+ALTER TABLE mfa_challenges DROP COLUMN IF EXISTS code_sent_at;
+ALTER TABLE mfa_challenges DROP COLUMN IF EXISTS code_expires_at;
+ALTER TABLE mfa_challenges DROP COLUMN IF EXISTS code_hash;
@@ -0,0 +1,9 @@
+-- A one-time code emailed to confirm a risky login, stored hashed on the
+-- challenge it completes. Risk checks read a user's recent sign-ins, so
+-- security events are also indexed by user and type.
+ALTER TABLE mfa_challenges ADD COLUMN IF NOT EXISTS code_hash VARCHAR(64);
+ALTER TABLE mfa_challenges ADD COLUMN IF NOT EXISTS code_expires_at TIMESTAMPTZ;
+ALTER TABLE mfa_challenges ADD COLUMN IF NOT EXISTS code_sent_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_security_events_user_type_created
+    ON security_events(user_id, event_type, created_at DESC) WHERE user_id IS NOT NULL;
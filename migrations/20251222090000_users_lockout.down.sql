@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS lockout_count;
+ALTER TABLE users DROP COLUMN IF EXISTS last_failed_login_at;
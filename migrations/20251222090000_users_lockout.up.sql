@@ -0,0 +1,4 @@
+-- Failed sign-ins only count towards a lockout within a window of the last
+-- one, and lockouts in a row grow longer, so both are tracked per user.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_failed_login_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS lockout_count INT NOT NULL DEFAULT 0;
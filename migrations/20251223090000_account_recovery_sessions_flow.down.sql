@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_recovery_sessions_user_open;
+
+ALTER TABLE account_recovery_sessions DROP COLUMN IF EXISTS canceled_at;
+ALTER TABLE account_recovery_sessions DROP COLUMN IF EXISTS available_at;
+ALTER TABLE account_recovery_sessions DROP COLUMN IF EXISTS sent_at;
+ALTER TABLE account_recovery_sessions DROP COLUMN IF EXISTS attempts;
+ALTER TABLE account_recovery_sessions DROP COLUMN IF EXISTS identifier_type;
@@ -0,0 +1,11 @@
+-- Recovery codes can be resent and guessed a limited number of times, and a
+-- verified session only unlocks its token after a cooling-off period. Signing
+-- in cancels a pending recovery, which is kept apart from completing one.
+ALTER TABLE account_recovery_sessions ADD COLUMN IF NOT EXISTS identifier_type VARCHAR(10) NOT NULL DEFAULT 'email';
+ALTER TABLE account_recovery_sessions ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE account_recovery_sessions ADD COLUMN IF NOT EXISTS sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE account_recovery_sessions ADD COLUMN IF NOT EXISTS available_at TIMESTAMPTZ;
+ALTER TABLE account_recovery_sessions ADD COLUMN IF NOT EXISTS canceled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_recovery_sessions_user_open
+    ON account_recovery_sessions(user_id) WHERE completed_at IS NULL AND canceled_at IS NULL;
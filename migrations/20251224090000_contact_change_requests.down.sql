@@ -0,0 +1,2 @@
+ALTER TABLE phone_change_requests DROP COLUMN IF EXISTS attempts;
+ALTER TABLE email_change_requests DROP COLUMN IF EXISTS reverts_id;
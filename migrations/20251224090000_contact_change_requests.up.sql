@@ -0,0 +1,6 @@
+-- Confirming an email change mails the old address a link that changes it
+-- back; that link is itself a change request pointing at the one it undoes.
+-- Codes texted for a phone change can only be guessed a limited number of times.
+ALTER TABLE email_change_requests ADD COLUMN IF NOT EXISTS reverts_id UUID
+    REFERENCES email_change_requests(id) ON DELETE CASCADE;
+ALTER TABLE phone_change_requests ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- A confirmed deletion only marks the account deleted; it can be restored by
+-- signing in until the grace period after deleted_at ends, when it is purged.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at) WHERE status = 'deleted';
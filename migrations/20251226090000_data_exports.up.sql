@@ -0,0 +1,30 @@
+-- Personal data exports. A background job claims pending exports, stores the
+-- archive in object storage under object_key and mails a download link
+-- whose token is hashed into token_hash; the archive is deleted once
+-- expires_at passes. A user has at most one export in progress at a time.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    object_key VARCHAR(255),
+    size BIGINT NOT NULL DEFAULT 0,
+    token_hash VARCHAR(64),
+    error TEXT,
+
+    expires_at TIMESTAMPTZ,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT data_exports_token_unique UNIQUE (token_hash),
+    CONSTRAINT data_exports_status_check CHECK (status IN ('pending', 'processing', 'ready', 'failed', 'expired'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS data_exports_in_progress_unique ON data_exports(user_id)
+    WHERE status IN ('pending', 'processing');
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_data_exports_queue ON data_exports(created_at)
+    WHERE status IN ('pending', 'processing');
+CREATE INDEX IF NOT EXISTS idx_data_exports_expiry ON data_exports(expires_at) WHERE status = 'ready';
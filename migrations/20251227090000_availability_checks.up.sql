@@ -0,0 +1,11 @@
+-- Email and phone availability checks, one row per request, so each IP
+-- address can only make a limited number within a window. Rows older than
+-- the window are deleted as new checks come in.
+CREATE TABLE IF NOT EXISTS availability_checks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ip_address VARCHAR(45) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_availability_checks_ip ON availability_checks(ip_address, created_at);
+CREATE INDEX IF NOT EXISTS idx_availability_checks_created_at ON availability_checks(created_at);
@@ -0,0 +1,4 @@
+-- Storage keys of the resized copies of an uploaded avatar, so they can be
+-- deleted when it is replaced or the account is purged. NULL when the user
+-- has no avatar or it is hosted elsewhere.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_keys TEXT[];
@@ -3,6 +3,8 @@
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 )
 
 type Kind uint8
@@ -17,10 +19,14 @@
 	KindInternal
 	KindExternal
 	KindTimeout
+	KindRateLimited
 )
 
 const unknownErrorStr = "UNKNOWN_ERROR"
 
+// RetryAfterField is the field that tells a rate-limited caller how many seconds to wait.
+const RetryAfterField = "retry_after"
+
 func (k Kind) String() string {
 	switch k {
 	case KindUnknown:
@@ -41,6 +47,8 @@ func (k Kind) String() string {
 		return "EXTERNAL_SERVICE_ERROR"
 	case KindTimeout:
 		return "TIMEOUT"
+	case KindRateLimited:
+		return "RATE_LIMITED"
 	}
 
 	return unknownErrorStr
@@ -123,6 +131,16 @@ func WithFields(fields map[string]string) Option {
 	}
 }
 
+// WithRetryAfter records how long a rate-limited caller should wait, rounded up to whole seconds.
+func WithRetryAfter(d time.Duration) Option {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return WithField(RetryAfterField, strconv.FormatInt(seconds, 10))
+}
+
 func newError(kind Kind, message string, opts ...Option) *Error {
 	e := &Error{
 		kind:    kind,
@@ -169,6 +187,10 @@ func Timeout(message string, opts ...Option) *Error {
 	return newError(KindTimeout, message, opts...)
 }
 
+func RateLimited(message string, opts ...Option) *Error {
+	return newError(KindRateLimited, message, opts...)
+}
+
 func GetKind(err error) Kind {
 	if err == nil {
 		return KindUnknown
@@ -218,6 +240,10 @@ func IsTimeout(err error) bool {
 	return Is(err, KindTimeout)
 }
 
+func IsRateLimited(err error) bool {
+	return Is(err, KindRateLimited)
+}
+
 func AsAppError(err error) (*Error, bool) {
 	var appErr *Error
 	if errors.As(err, &appErr) {
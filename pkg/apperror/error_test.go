@@ -3,6 +3,7 @@
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/evrone/go-clean-template/pkg/apperror"
 	"github.com/stretchr/testify/assert"
@@ -67,6 +68,7 @@ func TestError_Kind(t *testing.T) {
 		{"internal error", apperror.Internal("unexpected error"), apperror.KindInternal},
 		{"external error", apperror.External("payment gateway unavailable"), apperror.KindExternal},
 		{"timeout error", apperror.Timeout("request timed out"), apperror.KindTimeout},
+		{"rate limited error", apperror.RateLimited("too many requests"), apperror.KindRateLimited},
 	}
 
 	for _, tt := range tests {
@@ -122,6 +124,14 @@ func TestError_Fields(t *testing.T) {
 		assert.Equal(t, "must be valid email", fields["email"])
 		assert.Equal(t, "is required", fields["name"])
 	})
+
+	t.Run("retry after", func(t *testing.T) {
+		t.Parallel()
+
+		err := apperror.RateLimited("slow down", apperror.WithRetryAfter(1500*time.Millisecond))
+
+		assert.Equal(t, "2", err.Fields()[apperror.RetryAfterField])
+	})
 }
 
 func TestGetKind(t *testing.T) {
@@ -197,6 +207,12 @@ func TestIsTimeout(t *testing.T) {
 	assert.True(t, apperror.IsTimeout(apperror.Timeout("timeout")))
 }
 
+func TestIsRateLimited(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, apperror.IsRateLimited(apperror.RateLimited("rate limited")))
+}
+
 func TestAsAppError(t *testing.T) {
 	t.Parallel()
 
@@ -234,6 +250,7 @@ func TestKind_String(t *testing.T) {
 		{apperror.KindInternal, "INTERNAL_ERROR"},
 		{apperror.KindExternal, "EXTERNAL_SERVICE_ERROR"},
 		{apperror.KindTimeout, "TIMEOUT"},
+		{apperror.KindRateLimited, "RATE_LIMITED"},
 		{apperror.KindUnknown, "UNKNOWN_ERROR"},
 	}
 
@@ -0,0 +1,93 @@
+// Package encryption implements symmetric encryption for secrets stored at rest.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var (
+	errInvalidKeyLength = errors.New("encryption: key must be 16, 24 or 32 bytes")
+	errCiphertextShort  = errors.New("encryption: ciphertext too short")
+)
+
+// Cipher encrypts and decrypts small secrets into printable strings.
+type Cipher interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+// AESGCM encrypts with AES-GCM. The output is base64(nonce || ciphertext).
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+var _ Cipher = (*AESGCM)(nil)
+
+// NewAESGCM -.
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	switch len(key) {
+	case 16, 24, 32: //nolint:mnd // AES-128, AES-192 and AES-256 key sizes
+	default:
+		return nil, errInvalidKeyLength
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption - NewAESGCM - aes.NewCipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("encryption - NewAESGCM - cipher.NewGCM: %w", err)
+	}
+
+	return &AESGCM{aead: aead}, nil
+}
+
+// NewAESGCMFromBase64 decodes a standard base64 key and calls NewAESGCM.
+func NewAESGCMFromBase64(key string) (*AESGCM, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption - NewAESGCMFromBase64 - DecodeString: %w", err)
+	}
+
+	return NewAESGCM(raw)
+}
+
+// Encrypt -.
+func (c *AESGCM) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("encryption - Encrypt - rand.Read: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt -.
+func (c *AESGCM) Decrypt(ciphertext string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("encryption - Decrypt - DecodeString: %w", err)
+	}
+
+	if len(raw) < c.aead.NonceSize() {
+		return nil, errCiphertextShort
+	}
+
+	nonce, sealed := raw[:c.aead.NonceSize()], raw[c.aead.NonceSize():]
+
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("encryption - Decrypt - aead.Open: %w", err)
+	}
+
+	return plaintext, nil
+}
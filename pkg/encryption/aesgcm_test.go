@@ -0,0 +1,109 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey() []byte {
+	return bytes.Repeat([]byte{0x42}, 32)
+}
+
+func TestNewAESGCM_KeyLength(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{name: "aes-128", size: 16},
+		{name: "aes-192", size: 24},
+		{name: "aes-256", size: 32},
+		{name: "too short", size: 8, wantErr: true},
+		{name: "too long", size: 64, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewAESGCM(make([]byte, tt.size))
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, errInvalidKeyLength)
+				assert.Nil(t, c)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, c)
+		})
+	}
+}
+
+func TestAESGCM_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAESGCM(testKey())
+	require.NoError(t, err)
+
+	first, err := c.Encrypt([]byte("top secret"))
+	require.NoError(t, err)
+
+	second, err := c.Encrypt([]byte("top secret"))
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second, "nonce must be random")
+
+	plaintext, err := c.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("top secret"), plaintext)
+}
+
+func TestAESGCM_Decrypt_Errors(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAESGCM(testKey())
+	require.NoError(t, err)
+
+	other, err := NewAESGCM(bytes.Repeat([]byte{0x24}, 32))
+	require.NoError(t, err)
+
+	foreign, err := other.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		ciphertext string
+	}{
+		{name: "not base64", ciphertext: "!!!"},
+		{name: "too short", ciphertext: base64.StdEncoding.EncodeToString([]byte("abc"))},
+		{name: "wrong key", ciphertext: foreign},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := c.Decrypt(tt.ciphertext)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewAESGCMFromBase64(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAESGCMFromBase64(base64.StdEncoding.EncodeToString(testKey()))
+	require.NoError(t, err)
+	assert.NotNil(t, c)
+
+	_, err = NewAESGCMFromBase64("not base64!")
+	require.Error(t, err)
+}
@@ -0,0 +1,236 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Data section types, as numbered by the MaxMind DB format.
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// maxDepth bounds nesting so a corrupt file cannot exhaust the stack.
+const maxDepth = 32
+
+var errTruncated = errors.New("geoip: truncated data")
+
+// decoder reads values from the data section into plain Go values: maps,
+// slices, strings, bools, float64, int64 and uint64. 128-bit integers are
+// returned as raw bytes.
+type decoder struct {
+	buf   []byte
+	depth int
+}
+
+// decode returns the value at offset and the offset just past it.
+func (d *decoder) decode(offset uint) (any, uint, error) {
+	if d.depth++; d.depth > maxDepth {
+		return nil, 0, errors.New("geoip: data nested too deeply")
+	}
+	defer func() { d.depth-- }()
+
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == typePointer {
+		// A pointer's value lives elsewhere; decoding carries on after the pointer.
+		v, _, err := d.decode(size)
+
+		return v, offset, err
+	}
+
+	return d.value(typ, size, offset)
+}
+
+// control reads a control byte and returns the type and size it announces,
+// or for pointers the target offset, and where the payload starts.
+func (d *decoder) control(offset uint) (typ int, size, next uint, err error) {
+	b, err := d.bytes(offset, 1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	offset++
+	typ = int(b[0] >> 5)
+
+	if typ == typePointer {
+		return d.pointer(b[0], offset)
+	}
+
+	if typ == typeExtended {
+		ext, err := d.bytes(offset, 1)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		offset++
+		typ = 7 + int(ext[0])
+	}
+
+	size = uint(b[0] & 0x1F)
+
+	if size >= 29 {
+		n := size - 28
+
+		extra, err := d.bytes(offset, n)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		offset += n
+
+		switch n {
+		case 1:
+			size = 29 + uint(extra[0])
+		case 2:
+			size = 285 + (uint(extra[0])<<8 | uint(extra[1]))
+		default:
+			size = 65821 + (uint(extra[0])<<16 | uint(extra[1])<<8 | uint(extra[2]))
+		}
+	}
+
+	return typ, size, offset, nil
+}
+
+func (d *decoder) pointer(ctrl byte, offset uint) (typ int, target, next uint, err error) {
+	n := uint(ctrl>>3&0x3) + 1
+
+	b, err := d.bytes(offset, n)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	vvv := uint(ctrl & 0x7)
+
+	switch n {
+	case 1:
+		target = vvv<<8 | uint(b[0])
+	case 2:
+		target = (vvv<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 3:
+		target = (vvv<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		target = uint(binary.BigEndian.Uint32(b))
+	}
+
+	return typePointer, target, offset + n, nil
+}
+
+func (d *decoder) value(typ int, size, offset uint) (any, uint, error) {
+	switch typ {
+	case typeMap:
+		return d.decodeMap(size, offset)
+	case typeArray:
+		return d.decodeArray(size, offset)
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	b, err := d.bytes(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	next := offset + size
+
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes, typeUint128:
+		return b, next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("geoip: double of size %d", size)
+		}
+
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("geoip: float of size %d", size)
+		}
+
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		return uintValue(b), next, nil
+	case typeInt32:
+		return int64(int32(uint32(uintValue(b)))), next, nil //nolint:gosec // sign bits are meant to wrap
+	default:
+		return nil, 0, fmt.Errorf("geoip: unexpected data type %d", typ)
+	}
+}
+
+func (d *decoder) decodeMap(size, offset uint) (any, uint, error) {
+	m := make(map[string]any, size)
+
+	for range size {
+		k, next, err := d.decode(offset)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		key, ok := k.(string)
+		if !ok {
+			return nil, 0, errors.New("geoip: map key is not a string")
+		}
+
+		m[key], offset, err = d.decode(next)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return m, offset, nil
+}
+
+func (d *decoder) decodeArray(size, offset uint) (any, uint, error) {
+	a := make([]any, 0, size)
+
+	for range size {
+		v, next, err := d.decode(offset)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		a = append(a, v)
+		offset = next
+	}
+
+	return a, offset, nil
+}
+
+func (d *decoder) bytes(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.buf)) || offset+n < offset {
+		return nil, errTruncated
+	}
+
+	return d.buf[offset : offset+n], nil
+}
+
+func uintValue(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+
+	return n
+}
@@ -0,0 +1,214 @@
+// Package geoip resolves IP addresses to a city and country from a local
+// MaxMind DB file, such as GeoLite2-City or DB-IP City Lite. The file is
+// read into memory once and lookups never leave the process.
+package geoip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+)
+
+// ErrNotFound is returned when the database holds nothing for an address.
+var ErrNotFound = errors.New("geoip: address not found")
+
+var (
+	errInvalidDatabase = errors.New("geoip: invalid database")
+
+	metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+)
+
+// metadataMaxSize bounds how far from the end of the file the metadata may start.
+const metadataMaxSize = 128 * 1024
+
+// Location is where an address is registered. Names are in English; any
+// field the database does not have is empty.
+type Location struct {
+	City        string
+	Region      string
+	Country     string
+	CountryCode string
+}
+
+// Reader looks addresses up in an in-memory database. It is safe for
+// concurrent use.
+type Reader struct {
+	buf        []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+}
+
+// Open loads the database at path.
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip - Open - os.ReadFile: %w", err)
+	}
+
+	return New(buf)
+}
+
+// New reads a database already in memory.
+func New(buf []byte) (*Reader, error) {
+	start := max(len(buf)-metadataMaxSize, 0)
+
+	i := bytes.LastIndex(buf[start:], metadataMarker)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: metadata not found", errInvalidDatabase)
+	}
+
+	metaStart := start + i + len(metadataMarker)
+
+	meta, _, err := (&decoder{buf: buf[metaStart:]}).decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: metadata: %w", errInvalidDatabase, err)
+	}
+
+	m, ok := meta.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", errInvalidDatabase)
+	}
+
+	r := &Reader{
+		buf:        buf,
+		nodeCount:  uint(asUint(m["node_count"])),
+		recordSize: uint(asUint(m["record_size"])),
+		ipVersion:  uint(asUint(m["ip_version"])),
+	}
+
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("%w: unsupported record size %d", errInvalidDatabase, r.recordSize)
+	}
+
+	treeSize := r.nodeCount * r.recordSize / 4
+	dataStart := treeSize + 16
+
+	if dataStart > uint(start+i) {
+		return nil, fmt.Errorf("%w: search tree exceeds file", errInvalidDatabase)
+	}
+
+	r.data = buf[dataStart : start+i]
+
+	if r.ipVersion == 6 {
+		node := uint(0)
+		for range 96 {
+			if node >= r.nodeCount {
+				break
+			}
+
+			node = r.record(node, 0)
+		}
+
+		r.ipv4Start = node
+	}
+
+	return r, nil
+}
+
+// Lookup returns the location of ip, ErrNotFound when the database has
+// none, or an error when ip is not an address.
+func (r *Reader) Lookup(ip string) (*Location, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("geoip - Lookup - netip.ParseAddr: %w", err)
+	}
+
+	addr = addr.Unmap()
+
+	if addr.Is6() && r.ipVersion == 4 {
+		return nil, ErrNotFound
+	}
+
+	node := uint(0)
+	if addr.Is4() && r.ipVersion == 6 {
+		node = r.ipv4Start
+	}
+
+	raw := addr.AsSlice()
+
+	for i := 0; i < len(raw)*8 && node < r.nodeCount; i++ {
+		bit := uint(raw[i/8]>>(7-i%8)) & 1
+		node = r.record(node, bit)
+	}
+
+	if node <= r.nodeCount {
+		return nil, ErrNotFound
+	}
+
+	offset := node - r.nodeCount - 16
+	if offset >= uint(len(r.data)) {
+		return nil, fmt.Errorf("%w: data pointer out of range", errInvalidDatabase)
+	}
+
+	v, _, err := (&decoder{buf: r.data}).decode(offset)
+	if err != nil {
+		return nil, fmt.Errorf("geoip - Lookup - decode: %w", err)
+	}
+
+	return location(v), nil
+}
+
+// record reads the left (bit 0) or right (bit 1) record of node.
+func (r *Reader) record(node, bit uint) uint {
+	b := r.buf[node*r.recordSize/4:]
+
+	switch r.recordSize {
+	case 24:
+		b = b[bit*3:]
+
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		b = b[bit*4:]
+
+		return uint(b[0])<<24 | uint(b[1])<<16 | uint(b[2])<<8 | uint(b[3])
+	}
+}
+
+// location picks the English names out of a GeoIP2 City record.
+func location(v any) *Location {
+	m, _ := v.(map[string]any) //nolint:errcheck // anything else has no location
+
+	loc := &Location{
+		City:        englishName(m["city"]),
+		Country:     englishName(m["country"]),
+		CountryCode: isoCode(m["country"]),
+	}
+
+	if subdivisions, ok := m["subdivisions"].([]any); ok && len(subdivisions) > 0 {
+		loc.Region = englishName(subdivisions[0])
+	}
+
+	return loc
+}
+
+func englishName(v any) string {
+	m, _ := v.(map[string]any)              //nolint:errcheck // missing names are left empty
+	names, _ := m["names"].(map[string]any) //nolint:errcheck // as above
+	name, _ := names["en"].(string)         //nolint:errcheck // as above
+
+	return name
+}
+
+func isoCode(v any) string {
+	m, _ := v.(map[string]any)        //nolint:errcheck // missing codes are left empty
+	code, _ := m["iso_code"].(string) //nolint:errcheck // as above
+
+	return code
+}
+
+func asUint(v any) uint64 {
+	n, _ := v.(uint64) //nolint:errcheck // a missing field reads as zero and fails validation
+
+	return n
+}
@@ -0,0 +1,250 @@
+package geoip_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/evrone/go-clean-template/pkg/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB builds a small IPv6 MaxMind DB with 24-bit records. Values are
+// encoded in the order given; a value of type pointer refers to an earlier
+// one, which exercises the decoder's pointer handling.
+type testDB struct {
+	data     bytes.Buffer
+	networks []network
+}
+
+type network struct {
+	prefix netip.Prefix
+	offset int
+}
+
+type pointer int
+
+func (db *testDB) add(t *testing.T, prefix string, value any) int {
+	t.Helper()
+
+	p := netip.MustParsePrefix(prefix)
+	if p.Addr().Is4() {
+		// IPv4 lives in the first /96 of the IPv6 tree.
+		var raw [16]byte
+		v4 := p.Addr().As4()
+		copy(raw[12:], v4[:])
+		p = netip.PrefixFrom(netip.AddrFrom16(raw), p.Bits()+96)
+	}
+
+	offset := db.data.Len()
+	encode(&db.data, value)
+	db.networks = append(db.networks, network{prefix: p, offset: offset})
+
+	return offset
+}
+
+func (db *testDB) bytes() []byte {
+	type node struct{ children [2]int }
+
+	// Records hold -1 for "no data", a node index, or -(offset+2) for data.
+	nodes := []node{{children: [2]int{-1, -1}}}
+
+	for _, n := range db.networks {
+		cur := 0
+		raw := n.prefix.Addr().As16()
+
+		for i := range n.prefix.Bits() {
+			bit := raw[i/8] >> (7 - i%8) & 1
+
+			if i == n.prefix.Bits()-1 {
+				nodes[cur].children[bit] = -(n.offset + 2)
+
+				break
+			}
+
+			if nodes[cur].children[bit] < 0 {
+				nodes = append(nodes, node{children: [2]int{-1, -1}})
+				nodes[cur].children[bit] = len(nodes) - 1
+			}
+
+			cur = nodes[cur].children[bit]
+		}
+	}
+
+	var out bytes.Buffer
+
+	count := len(nodes)
+	for _, n := range nodes {
+		for _, c := range n.children {
+			var v int
+
+			switch {
+			case c == -1:
+				v = count
+			case c < -1:
+				v = count + 16 + (-c - 2)
+			default:
+				v = c
+			}
+
+			out.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+
+	out.Write(make([]byte, 16))
+	out.Write(db.data.Bytes())
+	out.WriteString("\xAB\xCD\xEFMaxMind.com")
+	encode(&out, map[string]any{
+		"node_count":    uint32(count),
+		"record_size":   uint16(24),
+		"ip_version":    uint16(6),
+		"database_type": "Test-City",
+	})
+
+	return out.Bytes()
+}
+
+func encode(b *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case string:
+		header(b, 2, len(v))
+		b.WriteString(v)
+	case uint16:
+		header(b, 5, 2)
+		_ = binary.Write(b, binary.BigEndian, v)
+	case uint32:
+		header(b, 6, 4)
+		_ = binary.Write(b, binary.BigEndian, v)
+	case map[string]any:
+		header(b, 7, len(v))
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			encode(b, k)
+			encode(b, v[k])
+		}
+	case []any:
+		header(b, 11, len(v))
+
+		for _, e := range v {
+			encode(b, e)
+		}
+	case pointer:
+		// Only the short form is needed: offsets below 2048.
+		b.Write([]byte{1<<5 | byte(v>>8&0x7), byte(v)})
+	}
+}
+
+func header(b *bytes.Buffer, typ, size int) {
+	if typ > 7 {
+		b.Write([]byte{byte(size), byte(typ - 7)})
+
+		return
+	}
+
+	b.WriteByte(byte(typ<<5 | size))
+}
+
+func names(en string) map[string]any {
+	return map[string]any{"names": map[string]any{"en": en, "de": en + "-de"}}
+}
+
+func newTestReader(t *testing.T) *geoip.Reader {
+	t.Helper()
+
+	db := &testDB{}
+
+	db.add(t, "8.8.0.0/16", map[string]any{
+		"city":         names("Mountain View"),
+		"country":      map[string]any{"iso_code": "US", "names": map[string]any{"en": "United States"}},
+		"subdivisions": []any{map[string]any{"iso_code": "CA", "names": map[string]any{"en": "California"}}},
+	})
+
+	db.add(t, "2001:db8::/32", map[string]any{
+		"country": map[string]any{"iso_code": "DE", "names": map[string]any{"en": "Germany"}},
+	})
+	// Shares the first record through a pointer.
+	db.add(t, "1.1.1.0/24", pointer(0))
+
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	require.NoError(t, os.WriteFile(path, db.bytes(), 0o600))
+
+	r, err := geoip.Open(path)
+	require.NoError(t, err)
+
+	return r
+}
+
+func TestReader_Lookup(t *testing.T) {
+	t.Parallel()
+
+	r := newTestReader(t)
+
+	tests := []struct {
+		ip   string
+		want *geoip.Location
+	}{
+		{
+			ip:   "8.8.8.8",
+			want: &geoip.Location{City: "Mountain View", Region: "California", Country: "United States", CountryCode: "US"},
+		},
+		{
+			ip:   "::ffff:8.8.4.4",
+			want: &geoip.Location{City: "Mountain View", Region: "California", Country: "United States", CountryCode: "US"},
+		},
+		{
+			ip:   "1.1.1.1",
+			want: &geoip.Location{City: "Mountain View", Region: "California", Country: "United States", CountryCode: "US"},
+		},
+		{
+			ip:   "2001:db8::1",
+			want: &geoip.Location{Country: "Germany", CountryCode: "DE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := r.Lookup(tt.ip)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReader_Lookup_NotFound(t *testing.T) {
+	t.Parallel()
+
+	r := newTestReader(t)
+
+	for _, ip := range []string{"9.9.9.9", "8.9.0.1", "2001:db9::1"} {
+		_, err := r.Lookup(ip)
+		require.ErrorIs(t, err, geoip.ErrNotFound, ip)
+	}
+
+	_, err := r.Lookup("not-an-ip")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, geoip.ErrNotFound)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := geoip.New([]byte("not a database"))
+	require.Error(t, err)
+
+	_, err = geoip.Open(filepath.Join(t.TempDir(), "missing.mmdb"))
+	require.Error(t, err)
+}
@@ -2,6 +2,8 @@
 
 import (
 	"net"
+
+	pbgrpc "google.golang.org/grpc"
 )
 
 // Option -.
@@ -13,3 +15,17 @@ func Port(port string) Option {
 		s.address = net.JoinHostPort("", port)
 	}
 }
+
+// UnaryInterceptors -.
+func UnaryInterceptors(interceptors ...pbgrpc.UnaryServerInterceptor) Option {
+	return func(s *Server) {
+		s.serverOptions = append(s.serverOptions, pbgrpc.ChainUnaryInterceptor(interceptors...))
+	}
+}
+
+// StreamInterceptors -.
+func StreamInterceptors(interceptors ...pbgrpc.StreamServerInterceptor) Option {
+	return func(s *Server) {
+		s.serverOptions = append(s.serverOptions, pbgrpc.ChainStreamInterceptor(interceptors...))
+	}
+}
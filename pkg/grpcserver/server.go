@@ -20,9 +20,10 @@ type Server struct {
 	ctx context.Context
 	eg  *errgroup.Group
 
-	App     *pbgrpc.Server
-	notify  chan error
-	address string
+	App           *pbgrpc.Server
+	notify        chan error
+	address       string
+	serverOptions []pbgrpc.ServerOption
 
 	logger logger.Interface
 }
@@ -35,7 +36,6 @@ func New(l logger.Interface, opts ...Option) *Server {
 	s := &Server{
 		ctx:     ctx,
 		eg:      group,
-		App:     pbgrpc.NewServer(),
 		notify:  make(chan error, 1),
 		address: _defaultAddr,
 		logger:  l,
@@ -46,6 +46,8 @@ func New(l logger.Interface, opts ...Option) *Server {
 		opt(s)
 	}
 
+	s.App = pbgrpc.NewServer(s.serverOptions...)
+
 	return s
 }
 
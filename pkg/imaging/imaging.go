@@ -0,0 +1,204 @@
+// Package imaging checks uploaded images and scales them down to square
+// thumbnails using only the standard library.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+)
+
+// Content types of the supported formats.
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	GIF  = "image/gif"
+)
+
+const jpegQuality = 85
+
+var (
+	// ErrUnsupported is returned for data that isn't a JPEG, PNG or GIF image.
+	ErrUnsupported = errors.New("imaging: unsupported image format")
+	// ErrTooLarge is returned for images wider or taller than allowed.
+	ErrTooLarge = errors.New("imaging: image dimensions too large")
+)
+
+// Sniff returns the content type of data judged by its first bytes alone,
+// whatever the uploader claimed it to be.
+func Sniff(data []byte) (string, error) {
+	switch ct := http.DetectContentType(data); ct {
+	case JPEG, PNG, GIF:
+		return ct, nil
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+// Decode sniffs and decodes an image; a GIF gives its first frame. The size
+// is read from the header first, so images wider or taller than maxSide
+// pixels are refused before their pixels are allocated.
+func Decode(data []byte, maxSide int) (image.Image, error) {
+	ct, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		decodeConfig func(io.Reader) (image.Config, error)
+		decode       func(io.Reader) (image.Image, error)
+	)
+
+	switch ct {
+	case JPEG:
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case PNG:
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	default:
+		decodeConfig, decode = gif.DecodeConfig, gif.Decode
+	}
+
+	cfg, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+
+	if cfg.Width > maxSide || cfg.Height > maxSide {
+		return nil, ErrTooLarge
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	return img, nil
+}
+
+// Square crops img to its largest centered square and scales that to size
+// pixels a side. Each output pixel is the average of the source area it
+// covers, which keeps downscaled photos smooth.
+func Square(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	origin := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+
+	// RGBA is premultiplied, so transparent pixels don't bleed their color
+	// into the average.
+	src := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(src, src.Bounds(), img, origin, draw.Src)
+
+	if side == size {
+		return src
+	}
+
+	weights := contributions(side, size)
+
+	// Scale rows first, then columns, keeping the sums in full precision.
+	rows := make([]float64, size*side*4)
+
+	for y := range side {
+		line := src.Pix[y*src.Stride:]
+
+		for x, c := range weights {
+			out := rows[(y*size+x)*4:]
+
+			for k, w := range c.weights {
+				p := line[(c.first+k)*4:]
+				out[0] += w * float64(p[0])
+				out[1] += w * float64(p[1])
+				out[2] += w * float64(p[2])
+				out[3] += w * float64(p[3])
+			}
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for y, c := range weights {
+		for x := range size {
+			var sum [4]float64
+
+			for k, w := range c.weights {
+				p := rows[((c.first+k)*size+x)*4:]
+				sum[0] += w * p[0]
+				sum[1] += w * p[1]
+				sum[2] += w * p[2]
+				sum[3] += w * p[3]
+			}
+
+			out := dst.Pix[y*dst.Stride+x*4:]
+			for i, v := range sum {
+				out[i] = uint8(min(math.Round(v), 255))
+			}
+		}
+	}
+
+	return dst
+}
+
+// Format picks the content type to store img as: JPEG when it is fully
+// opaque, PNG when transparency has to be kept.
+func Format(img image.Image) string {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return JPEG
+	}
+
+	return PNG
+}
+
+// Encode writes img as JPEG or PNG. Only the pixels are written, so any
+// metadata the upload carried, such as where a photo was taken, is dropped.
+func Encode(w io.Writer, img image.Image, contentType string) error {
+	switch contentType {
+	case JPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case PNG:
+		return png.Encode(w, img)
+	default:
+		return ErrUnsupported
+	}
+}
+
+// contribution is the run of source pixels, starting at first, that make up
+// one output pixel, and how much each of them counts.
+type contribution struct {
+	first   int
+	weights []float64
+}
+
+// contributions maps n source pixels onto m output pixels. Output pixel j
+// covers the source span [j*n/m, (j+1)*n/m), and each source pixel counts
+// by how much of that span it overlaps.
+func contributions(n, m int) []contribution {
+	scale := float64(n) / float64(m)
+	cs := make([]contribution, m)
+
+	for j := range cs {
+		lo := float64(j) * scale
+		hi := lo + scale
+		first := int(lo)
+		last := min(int(math.Ceil(hi)), n)
+
+		weights := make([]float64, 0, last-first)
+		for i := first; i < last; i++ {
+			weights = append(weights, (min(hi, float64(i+1))-max(lo, float64(i)))/scale)
+		}
+
+		cs[j] = contribution{first: first, weights: weights}
+	}
+
+	return cs
+}
@@ -0,0 +1,148 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func filled(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func encoded(t *testing.T, img image.Image, contentType string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	switch contentType {
+	case GIF:
+		require.NoError(t, gif.Encode(&buf, img, nil))
+	case JPEG:
+		require.NoError(t, jpeg.Encode(&buf, img, nil))
+	default:
+		require.NoError(t, png.Encode(&buf, img))
+	}
+
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	t.Parallel()
+
+	img := filled(4, 4, color.White)
+
+	for _, ct := range []string{JPEG, PNG, GIF} {
+		got, err := Sniff(encoded(t, img, ct))
+		require.NoError(t, err)
+		assert.Equal(t, ct, got)
+	}
+
+	for _, data := range [][]byte{[]byte("<svg xmlns='http://www.w3.org/2000/svg'/>"), []byte("GIF"), nil} {
+		_, err := Sniff(data)
+		require.ErrorIs(t, err, ErrUnsupported)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	data := encoded(t, filled(40, 20, color.White), PNG)
+
+	img, err := Decode(data, 40)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+
+	_, err = Decode(data, 39)
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Decode(data[:len(data)/2], 40)
+	require.ErrorIs(t, err, ErrUnsupported, "truncated")
+}
+
+func TestSquare(t *testing.T) {
+	t.Parallel()
+
+	red := color.RGBA{R: 255, A: 255}
+
+	// A red square between two blue bars: cropping keeps only the red.
+	img := filled(30, 10, color.RGBA{B: 255, A: 255})
+	for y := range 10 {
+		for x := 10; x < 20; x++ {
+			img.Set(x, y, red)
+		}
+	}
+
+	for _, size := range []int{4, 10, 16} {
+		got := Square(img, size)
+		assert.Equal(t, image.Rect(0, 0, size, size), got.Bounds())
+		assert.Equal(t, red, got.RGBAAt(0, 0), size)
+		assert.Equal(t, red, got.RGBAAt(size-1, size-1), size)
+	}
+
+	// Halving a checkerboard averages each black and white quad to grey.
+	board := filled(4, 4, color.White)
+	for y := range 4 {
+		for x := range 4 {
+			if (x+y)%2 == 0 {
+				board.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	got := Square(board, 2)
+	assert.Equal(t, color.RGBA{R: 128, G: 128, B: 128, A: 255}, got.RGBAAt(1, 1))
+
+	// Averaging with transparent pixels fades alpha without darkening.
+	half := filled(2, 2, color.Transparent)
+	half.Set(0, 0, red)
+	half.Set(0, 1, red)
+
+	got = Square(half, 1)
+	assert.Equal(t, color.RGBA{R: 128, A: 128}, got.RGBAAt(0, 0))
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	opaque := filled(2, 2, color.White)
+	assert.Equal(t, JPEG, Format(opaque))
+
+	opaque.Set(1, 1, color.RGBA{A: 10})
+	assert.Equal(t, PNG, Format(opaque))
+
+	assert.Equal(t, PNG, Format(image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Transparent})))
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	img := Square(filled(8, 8, color.White), 4)
+
+	for _, ct := range []string{JPEG, PNG} {
+		var buf bytes.Buffer
+
+		require.NoError(t, Encode(&buf, img, ct))
+
+		got, err := Sniff(buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, ct, got)
+	}
+
+	require.ErrorIs(t, Encode(&bytes.Buffer{}, img, GIF), ErrUnsupported)
+}
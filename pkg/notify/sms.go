@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	gsmSingleSegment  = 160
+	gsmMultiSegment   = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+// SMSEncoding is the character set a message is sent in, which decides how
+// many characters fit in one segment.
+type SMSEncoding string
+
+const (
+	SMSEncodingGSM7 SMSEncoding = "GSM-7"
+	SMSEncodingUCS2 SMSEncoding = "UCS-2"
+)
+
+var (
+	ErrInvalidPhoneNumber = errors.New("phone number must be in E.164 format")
+	ErrEmptySMS           = errors.New("sms body is empty")
+
+	e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+)
+
+// gsmBasic and gsmExtended are the GSM 03.38 default alphabet and its
+// extension table. Extension characters take two septets each.
+const (
+	gsmBasic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsmExtended = "^{}\\[~]|€\f"
+)
+
+// ValidateE164 reports whether number is a valid E.164 phone number.
+func ValidateE164(number string) error {
+	if !e164Pattern.MatchString(number) {
+		return ErrInvalidPhoneNumber
+	}
+
+	return nil
+}
+
+// SMSSegments returns the encoding a body will be sent in and the number of
+// segments it will be split into. Carriers bill per segment.
+func SMSSegments(body string) (SMSEncoding, int) {
+	if body == "" {
+		return SMSEncodingGSM7, 0
+	}
+
+	septets, ok := gsmSeptets(body)
+	if ok {
+		return SMSEncodingGSM7, segments(septets, gsmSingleSegment, gsmMultiSegment)
+	}
+
+	units := len(utf16.Encode([]rune(body)))
+
+	return SMSEncodingUCS2, segments(units, ucs2SingleSegment, ucs2MultiSegment)
+}
+
+func gsmSeptets(body string) (int, bool) {
+	var n int
+
+	for _, r := range body {
+		switch {
+		case strings.ContainsRune(gsmBasic, r):
+			n++
+		case strings.ContainsRune(gsmExtended, r):
+			n += 2
+		default:
+			return 0, false
+		}
+	}
+
+	return n, true
+}
+
+// segments splits length units into parts. A multipart message loses room in
+// every part to the concatenation header.
+func segments(length, single, multi int) int {
+	if length <= single {
+		return 1
+	}
+
+	return (length + multi - 1) / multi
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateE164(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		number  string
+		wantErr bool
+	}{
+		{number: "+14155551234"},
+		{number: "+442071838750"},
+		{number: "+123456789012345"},
+		{number: "14155551234", wantErr: true},
+		{number: "+04155551234", wantErr: true},
+		{number: "+1 415 555 1234", wantErr: true},
+		{number: "+1234567890123456", wantErr: true},
+		{number: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateE164(tt.number)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPhoneNumber)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSMSSegments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		body         string
+		wantEncoding SMSEncoding
+		wantSegments int
+	}{
+		{name: "empty", body: "", wantEncoding: SMSEncodingGSM7, wantSegments: 0},
+		{name: "short", body: "Your code is 123456", wantEncoding: SMSEncodingGSM7, wantSegments: 1},
+		{name: "gsm single limit", body: strings.Repeat("a", 160), wantEncoding: SMSEncodingGSM7, wantSegments: 1},
+		{name: "gsm over single", body: strings.Repeat("a", 161), wantEncoding: SMSEncodingGSM7, wantSegments: 2},
+		{name: "gsm two parts", body: strings.Repeat("a", 306), wantEncoding: SMSEncodingGSM7, wantSegments: 2},
+		{name: "gsm three parts", body: strings.Repeat("a", 307), wantEncoding: SMSEncodingGSM7, wantSegments: 3},
+		{name: "gsm accents", body: "Café à 5€", wantEncoding: SMSEncodingGSM7, wantSegments: 1},
+		{name: "extension chars count double", body: strings.Repeat("€", 81), wantEncoding: SMSEncodingGSM7, wantSegments: 2},
+		{name: "ucs2 single limit", body: strings.Repeat("ê", 70), wantEncoding: SMSEncodingUCS2, wantSegments: 1},
+		{name: "ucs2 over single", body: strings.Repeat("ê", 71), wantEncoding: SMSEncodingUCS2, wantSegments: 2},
+		{name: "emoji uses surrogate pairs", body: strings.Repeat("😀", 36), wantEncoding: SMSEncodingUCS2, wantSegments: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			encoding, n := SMSSegments(tt.body)
+
+			assert.Equal(t, tt.wantEncoding, encoding)
+			assert.Equal(t, tt.wantSegments, n)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+)
+
+const (
+	defaultTwilioBaseURL = "https://api.twilio.com"
+	defaultTwilioTimeout = 10 * time.Second
+
+	// maxSMSSegments caps how far a single message may be split. Anything
+	// longer is almost certainly a bug rather than a message worth paying for.
+	maxSMSSegments = 10
+)
+
+var (
+	errTwilioBadStatus = errors.New("twilio returned non-2xx status")
+	errSMSTooLong      = errors.New("sms body exceeds the segment limit")
+)
+
+// TwilioConfig configures the Twilio Messages API adapter. BaseURL can point
+// at any service that speaks the same API, such as a local stub.
+type TwilioConfig struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	From       string
+	Timeout    time.Duration
+}
+
+type TwilioSender struct {
+	config TwilioConfig
+	client *http.Client
+}
+
+var _ SMSSender = (*TwilioSender)(nil)
+
+func NewTwilioSender(config TwilioConfig) *TwilioSender {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultTwilioBaseURL
+	}
+
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultTwilioTimeout
+	}
+
+	return &TwilioSender{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (s *TwilioSender) Send(ctx context.Context, msg *notification.SMSMessage) error {
+	if err := ValidateE164(msg.To); err != nil {
+		return err
+	}
+
+	if msg.Body == "" {
+		return ErrEmptySMS
+	}
+
+	if _, n := SMSSegments(msg.Body); n > maxSMSSegments {
+		return fmt.Errorf("%w: %d segments", errSMSTooLong, n)
+	}
+
+	form := url.Values{}
+	form.Set("To", msg.To)
+	form.Set("From", s.config.From)
+	form.Set("Body", msg.Body)
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", s.config.BaseURL, url.PathEscape(s.config.AccountSID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.SetBasicAuth(s.config.AccountSID, s.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		var apiErr twilioError
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%w: %d: %d %s", errTwilioBadStatus, resp.StatusCode, apiErr.Code, apiErr.Message)
+		}
+
+		return fmt.Errorf("%w: %d", errTwilioBadStatus, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/internal/entity/notification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTwilioSender_Defaults(t *testing.T) {
+	t.Parallel()
+
+	sender := NewTwilioSender(TwilioConfig{AccountSID: "AC123"})
+
+	assert.Equal(t, defaultTwilioBaseURL, sender.config.BaseURL)
+	assert.Equal(t, defaultTwilioTimeout, sender.client.Timeout)
+
+	sender = NewTwilioSender(TwilioConfig{BaseURL: "http://localhost:4010/", Timeout: time.Second})
+
+	assert.Equal(t, "http://localhost:4010", sender.config.BaseURL)
+	assert.Equal(t, time.Second, sender.client.Timeout)
+}
+
+func TestTwilioSender_Send_Success(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "secret", pass)
+
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "+14155551234", r.PostForm.Get("To"))
+		assert.Equal(t, "+15005550006", r.PostForm.Get("From"))
+		assert.Equal(t, "Your code is 123456", r.PostForm.Get("Body"))
+
+		w.WriteHeader(http.StatusCreated)
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"sid":"SM123","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	sender := NewTwilioSender(TwilioConfig{
+		BaseURL:    server.URL,
+		AccountSID: "AC123",
+		AuthToken:  "secret",
+		From:       "+15005550006",
+	})
+
+	err := sender.Send(context.Background(), &notification.SMSMessage{
+		To:   "+14155551234",
+		Body: "Your code is 123456",
+	})
+
+	require.NoError(t, err)
+}
+
+func TestTwilioSender_Send_APIError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number."}`))
+	}))
+	defer server.Close()
+
+	sender := NewTwilioSender(TwilioConfig{BaseURL: server.URL, AccountSID: "AC123"})
+
+	err := sender.Send(context.Background(), &notification.SMSMessage{To: "+14155551234", Body: "hi"})
+
+	require.ErrorIs(t, err, errTwilioBadStatus)
+	assert.Contains(t, err.Error(), "21211")
+}
+
+func TestTwilioSender_Send_RejectsBeforeCallingProvider(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Error("provider must not be called")
+	}))
+	t.Cleanup(server.Close)
+
+	sender := NewTwilioSender(TwilioConfig{BaseURL: server.URL, AccountSID: "AC123"})
+
+	tests := []struct {
+		name    string
+		msg     *notification.SMSMessage
+		wantErr error
+	}{
+		{name: "invalid number", msg: &notification.SMSMessage{To: "4155551234", Body: "hi"}, wantErr: ErrInvalidPhoneNumber},
+		{name: "empty body", msg: &notification.SMSMessage{To: "+14155551234"}, wantErr: ErrEmptySMS},
+		{
+			name:    "too many segments",
+			msg:     &notification.SMSMessage{To: "+14155551234", Body: strings.Repeat("a", 153*maxSMSSegments+1)},
+			wantErr: errSMSTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := sender.Send(context.Background(), tt.msg)
+
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
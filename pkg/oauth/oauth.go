@@ -0,0 +1,361 @@
+// Package oauth is a small OAuth 2.0 / OpenID Connect client for the
+// authorization code flow with PKCE. It knows the endpoints and user info
+// shape of each social login provider we support and maps them onto a common
+// Identity.
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported providers.
+const (
+	Google   = "google"
+	Apple    = "apple"
+	Facebook = "facebook"
+	GitHub   = "github"
+)
+
+const defaultTimeout = 10 * time.Second
+
+var (
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	// ErrInvalidGrant means the provider rejected the authorization code,
+	// typically because it expired, was already used or fails the PKCE check.
+	ErrInvalidGrant   = errors.New("authorization code rejected")
+	ErrInvalidIDToken = errors.New("invalid id token")
+
+	errBadStatus = errors.New("oauth provider returned non-2xx status")
+	errNoSubject = errors.New("oauth provider returned no user id")
+)
+
+// Config configures one provider. Empty endpoints and scopes fall back to the
+// provider's public defaults, so pointing a provider at a local fake IdP only
+// takes overriding the URLs.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	// Issuer is the expected iss claim of ID tokens. Only Apple, which has no
+	// user info endpoint, relies on it.
+	Issuer  string
+	Scopes  []string
+	Timeout time.Duration
+}
+
+//nolint:gochecknoglobals // public provider endpoints
+var defaults = map[string]Config{
+	Google: {
+		AuthURL:     "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:    "https://oauth2.googleapis.com/token",
+		UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:      []string{"openid", "email", "profile"},
+	},
+	Apple: {
+		AuthURL:  "https://appleid.apple.com/auth/authorize",
+		TokenURL: "https://appleid.apple.com/auth/token",
+		Issuer:   "https://appleid.apple.com",
+		Scopes:   []string{"name", "email"},
+	},
+	Facebook: {
+		AuthURL:     "https://www.facebook.com/v19.0/dialog/oauth",
+		TokenURL:    "https://graph.facebook.com/v19.0/oauth/access_token",
+		UserInfoURL: "https://graph.facebook.com/v19.0/me",
+		Scopes:      []string{"email", "public_profile"},
+	},
+	GitHub: {
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		Scopes:      []string{"read:user", "user:email"},
+	},
+}
+
+// Token is the result of a code exchange.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	IDToken      string
+	// ExpiresAt is nil when the provider does not say; GitHub tokens do not expire.
+	ExpiresAt *time.Time
+}
+
+// Identity is the provider account a token belongs to.
+type Identity struct {
+	Subject string
+	Email   string
+	// EmailVerified is only set when the provider vouches for the address.
+	EmailVerified bool
+	Name          string
+	AvatarURL     string
+}
+
+type Client struct {
+	provider string
+	config   Config
+	client   *http.Client
+	now      func() time.Time
+}
+
+// New returns a client for one of the supported providers.
+func New(provider string, config Config) (*Client, error) {
+	def, ok := defaults[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+
+	config.AuthURL = orDefault(config.AuthURL, def.AuthURL)
+	config.TokenURL = orDefault(config.TokenURL, def.TokenURL)
+	config.UserInfoURL = orDefault(config.UserInfoURL, def.UserInfoURL)
+	config.Issuer = orDefault(config.Issuer, def.Issuer)
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = def.Scopes
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Client{
+		provider: provider,
+		config:   config,
+		client:   &http.Client{Timeout: timeout},
+		now:      time.Now,
+	}, nil
+}
+
+// Provider returns the provider name the client was built for.
+func (c *Client) Provider() string {
+	return c.provider
+}
+
+// AuthCodeURL returns the provider URL to send the user to.
+func (c *Client) AuthCodeURL(state, codeChallenge, redirectURI string) string {
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.config.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", strings.Join(c.config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	// Apple only returns the user's email when it posts the result back.
+	if c.provider == Apple {
+		q.Set("response_mode", "form_post")
+	}
+
+	sep := "?"
+	if strings.Contains(c.config.AuthURL, "?") {
+		sep = "&"
+	}
+
+	return c.config.AuthURL + sep + q.Encode()
+}
+
+// S256Challenge derives the PKCE code challenge for a verifier.
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+type tokenResponse struct {
+	AccessToken      string        `json:"access_token"`
+	RefreshToken     string        `json:"refresh_token"`
+	IDToken          string        `json:"id_token"`
+	ExpiresIn        int64         `json:"expires_in"`
+	Error            providerError `json:"error"`
+	ErrorDescription string        `json:"error_description"`
+}
+
+// Exchange trades an authorization code for tokens.
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier, redirectURI string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", c.config.ClientID)
+	form.Set("client_secret", c.config.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHub answers form-encoded unless asked for JSON.
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body tokenResponse
+
+	decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+
+	// GitHub reports a bad code with a 200 and an error field.
+	if body.Error.Code != "" {
+		if body.Error.Code == "invalid_grant" || body.Error.Code == "bad_verification_code" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidGrant, body.Error.describe(body.ErrorDescription))
+		}
+
+		return nil, fmt.Errorf("%w: %d: %s", errBadStatus, resp.StatusCode, body.Error.describe(body.ErrorDescription))
+	}
+
+	if !isSuccess(resp.StatusCode) {
+		return nil, fmt.Errorf("%w: %d", errBadStatus, resp.StatusCode)
+	}
+
+	if decodeErr != nil {
+		return nil, fmt.Errorf("decode token response: %w", decodeErr)
+	}
+
+	t := &Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		IDToken:      body.IDToken,
+	}
+
+	if body.ExpiresIn > 0 {
+		expiresAt := c.now().Add(time.Duration(body.ExpiresIn) * time.Second)
+		t.ExpiresAt = &expiresAt
+	}
+
+	return t, nil
+}
+
+// Identity fetches the provider account behind a token.
+func (c *Client) Identity(ctx context.Context, t *Token) (*Identity, error) {
+	var (
+		id  *Identity
+		err error
+	)
+
+	switch c.provider {
+	case Google:
+		id, err = c.googleIdentity(ctx, t)
+	case Apple:
+		id, err = c.appleIdentity(t)
+	case Facebook:
+		id, err = c.facebookIdentity(ctx, t)
+	case GitHub:
+		id, err = c.githubIdentity(ctx, t)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, c.provider)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if id.Subject == "" {
+		return nil, errNoSubject
+	}
+
+	return id, nil
+}
+
+// getJSON calls a user info endpoint with the access token and decodes the result.
+func (c *Client) getJSON(ctx context.Context, endpoint, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if !isSuccess(resp.StatusCode) {
+		return fmt.Errorf("%w: %d", errBadStatus, resp.StatusCode)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode user info: %w", err)
+	}
+
+	return nil
+}
+
+// providerError reads the error field of a token response. It is a string in
+// RFC 6749 but an object in Facebook's Graph API.
+type providerError struct {
+	Code    string
+	Message string
+}
+
+// facebookInvalidParameter is the Graph API code for a bad or reused authorization code.
+const facebookInvalidParameter = 100
+
+func (e *providerError) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		return json.Unmarshal(b, &e.Code)
+	}
+
+	var graph struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    int    `json:"code"`
+	}
+
+	if err := json.Unmarshal(b, &graph); err != nil {
+		return err
+	}
+
+	e.Code = graph.Type
+	if graph.Code == facebookInvalidParameter {
+		e.Code = "invalid_grant"
+	}
+
+	e.Message = graph.Message
+
+	return nil
+}
+
+func (e *providerError) describe(description string) string {
+	msg := e.Message
+	if msg == "" {
+		msg = description
+	}
+
+	if msg == "" {
+		return e.Code
+	}
+
+	return e.Code + " " + msg
+}
+
+func isSuccess(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+
+	return strings.TrimRight(v, "/")
+}
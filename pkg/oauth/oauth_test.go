@@ -0,0 +1,262 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Defaults(t *testing.T) {
+	t.Parallel()
+
+	_, err := New("myspace", Config{})
+	require.ErrorIs(t, err, ErrUnknownProvider)
+
+	c, err := New(Google, Config{ClientID: "client"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://oauth2.googleapis.com/token", c.config.TokenURL)
+	assert.Equal(t, []string{"openid", "email", "profile"}, c.config.Scopes)
+	assert.Equal(t, defaultTimeout, c.client.Timeout)
+
+	c, err = New(GitHub, Config{UserInfoURL: "http://localhost:9000/user/", Timeout: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9000/user", c.config.UserInfoURL)
+	assert.Equal(t, "https://github.com/login/oauth/authorize", c.config.AuthURL)
+	assert.Equal(t, time.Second, c.client.Timeout)
+}
+
+func TestClient_AuthCodeURL(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(Apple, Config{ClientID: "com.example.web", AuthURL: "http://idp.test/authorize"})
+	require.NoError(t, err)
+
+	u, err := url.Parse(c.AuthCodeURL("st", "challenge", "https://app.test/cb"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "idp.test", u.Host)
+	assert.Equal(t, "/authorize", u.Path)
+
+	q := u.Query()
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "com.example.web", q.Get("client_id"))
+	assert.Equal(t, "https://app.test/cb", q.Get("redirect_uri"))
+	assert.Equal(t, "name email", q.Get("scope"))
+	assert.Equal(t, "st", q.Get("state"))
+	assert.Equal(t, "challenge", q.Get("code_challenge"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, "form_post", q.Get("response_mode"))
+}
+
+func TestS256Challenge(t *testing.T) {
+	t.Parallel()
+
+	// RFC 7636, appendix B.
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestClient_Exchange(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "authorization_code", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "the-code", r.PostForm.Get("code"))
+		assert.Equal(t, "the-verifier", r.PostForm.Get("code_verifier"))
+		assert.Equal(t, "https://app.test/cb", r.PostForm.Get("redirect_uri"))
+		assert.Equal(t, "client", r.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"access_token":"at","refresh_token":"rt","id_token":"idt","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Google, Config{ClientID: "client", ClientSecret: "secret", TokenURL: server.URL})
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	tok, err := c.Exchange(context.Background(), "the-code", "the-verifier", "https://app.test/cb")
+	require.NoError(t, err)
+	assert.Equal(t, "at", tok.AccessToken)
+	assert.Equal(t, "rt", tok.RefreshToken)
+	assert.Equal(t, "idt", tok.IDToken)
+	require.NotNil(t, tok.ExpiresAt)
+	assert.Equal(t, now.Add(time.Hour), *tok.ExpiresAt)
+}
+
+func TestClient_Exchange_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		provider    string
+		status      int
+		body        string
+		wantInvalid bool
+	}{
+		{
+			name:        "rfc 6749 invalid_grant",
+			provider:    Google,
+			status:      http.StatusBadRequest,
+			body:        `{"error":"invalid_grant","error_description":"Bad Request"}`,
+			wantInvalid: true,
+		},
+		{
+			name:        "github error with 200",
+			provider:    GitHub,
+			status:      http.StatusOK,
+			body:        `{"error":"bad_verification_code","error_description":"The code passed is incorrect or expired."}`,
+			wantInvalid: true,
+		},
+		{
+			name:        "facebook graph error",
+			provider:    Facebook,
+			status:      http.StatusBadRequest,
+			body:        `{"error":{"message":"This authorization code has been used.","type":"OAuthException","code":100}}`,
+			wantInvalid: true,
+		},
+		{
+			name:     "misconfigured client",
+			provider: Google,
+			status:   http.StatusUnauthorized,
+			body:     `{"error":"invalid_client"}`,
+		},
+		{
+			name:     "server error",
+			provider: Google,
+			status:   http.StatusInternalServerError,
+			body:     `oops`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				//nolint:errcheck // test helper
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			c, err := New(tt.provider, Config{TokenURL: server.URL})
+			require.NoError(t, err)
+
+			_, err = c.Exchange(context.Background(), "code", "verifier", "https://app.test/cb")
+			require.Error(t, err)
+			assert.Equal(t, tt.wantInvalid, errors.Is(err, ErrInvalidGrant))
+		})
+	}
+}
+
+func TestClient_Identity(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/google", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer at", r.Header.Get("Authorization"))
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"sub":"g-1","email":"ada@example.com","email_verified":true,"name":"Ada","picture":"https://img.test/a"}`))
+	})
+	mux.HandleFunc("/facebook", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "id,name,email,picture", r.URL.Query().Get("fields"))
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"id":"fb-1","email":"ada@example.com","name":"Ada","picture":{"data":{"url":"https://img.test/f"}}}`))
+	})
+	mux.HandleFunc("/github", func(w http.ResponseWriter, _ *http.Request) {
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"id":42,"login":"ada","name":"","avatar_url":"https://img.test/g"}`))
+	})
+	mux.HandleFunc("/github/emails", func(w http.ResponseWriter, _ *http.Request) {
+		//nolint:errcheck // test helper
+		w.Write([]byte(`[{"email":"old@example.com","primary":false,"verified":true},{"email":"ada@example.com","primary":true,"verified":true}]`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		provider string
+		want     Identity
+	}{
+		{
+			provider: Google,
+			want:     Identity{Subject: "g-1", Email: "ada@example.com", EmailVerified: true, Name: "Ada", AvatarURL: "https://img.test/a"},
+		},
+		{
+			provider: Facebook,
+			want:     Identity{Subject: "fb-1", Email: "ada@example.com", Name: "Ada", AvatarURL: "https://img.test/f"},
+		},
+		{
+			provider: GitHub,
+			want:     Identity{Subject: "42", Email: "ada@example.com", EmailVerified: true, Name: "ada", AvatarURL: "https://img.test/g"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := New(tt.provider, Config{UserInfoURL: server.URL + "/" + tt.provider})
+			require.NoError(t, err)
+
+			id, err := c.Identity(context.Background(), &Token{AccessToken: "at"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *id)
+		})
+	}
+}
+
+func TestClient_Identity_Apple(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	idToken := func(iss, aud string, exp time.Time, verified any) string {
+		raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss":            iss,
+			"aud":            aud,
+			"exp":            exp.Unix(),
+			"sub":            "apple-1",
+			"email":          "ada@privaterelay.appleid.com",
+			"email_verified": verified,
+		}).SignedString([]byte("unused"))
+		require.NoError(t, err)
+
+		return raw
+	}
+
+	c, err := New(Apple, Config{ClientID: "com.example.web"})
+	require.NoError(t, err)
+
+	c.now = func() time.Time { return now }
+
+	id, err := c.Identity(context.Background(), &Token{IDToken: idToken("https://appleid.apple.com", "com.example.web", now.Add(time.Minute), "true")})
+	require.NoError(t, err)
+	assert.Equal(t, Identity{Subject: "apple-1", Email: "ada@privaterelay.appleid.com", EmailVerified: true}, *id)
+
+	for name, raw := range map[string]string{
+		"missing":      "",
+		"wrong issuer": idToken("https://evil.test", "com.example.web", now.Add(time.Minute), true),
+		"wrong client": idToken("https://appleid.apple.com", "com.other.app", now.Add(time.Minute), true),
+		"expired":      idToken("https://appleid.apple.com", "com.example.web", now.Add(-time.Minute), true),
+	} {
+		_, err = c.Identity(context.Background(), &Token{IDToken: raw})
+		assert.ErrorIs(t, err, ErrInvalidIDToken, name)
+	}
+}
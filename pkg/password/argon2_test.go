@@ -0,0 +1,93 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHasher() *Argon2id {
+	return NewArgon2id(Memory(1024), Iterations(1), Parallelism(1))
+}
+
+func TestNewArgon2id_Defaults(t *testing.T) {
+	t.Parallel()
+
+	h := NewArgon2id()
+
+	assert.Equal(t, uint32(_defaultMemory), h.memory)
+	assert.Equal(t, uint32(_defaultIterations), h.iterations)
+	assert.Equal(t, uint8(_defaultParallelism), h.parallelism)
+}
+
+func TestArgon2id_HashAndVerify(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHasher()
+
+	encoded, err := h.Hash("SecureP@ss123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	ok, err := h.Verify("SecureP@ss123", encoded)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify("wrong-password", encoded)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestArgon2id_Hash_UniqueSalt(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHasher()
+
+	first, err := h.Hash("same-password")
+	require.NoError(t, err)
+
+	second, err := h.Hash("same-password")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+}
+
+func TestArgon2id_Verify_UsesStoredParameters(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := newTestHasher().Hash("SecureP@ss123")
+	require.NoError(t, err)
+
+	ok, err := NewArgon2id(Memory(2048), Iterations(2)).Verify("SecureP@ss123", encoded)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestArgon2id_Verify_InvalidHash(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr error
+	}{
+		{name: "empty", encoded: "", wantErr: errInvalidHash},
+		{name: "wrong algorithm", encoded: "$bcrypt$v=19$m=1024,t=1,p=1$c2FsdA$a2V5", wantErr: errInvalidHash},
+		{name: "wrong version", encoded: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", wantErr: errIncompatibleVersion},
+		{name: "bad params", encoded: "$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5", wantErr: errInvalidHash},
+		{name: "bad salt", encoded: "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5", wantErr: errInvalidHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ok, err := newTestHasher().Verify("password", tt.encoded)
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, ok)
+		})
+	}
+}
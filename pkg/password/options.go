@@ -0,0 +1,25 @@
+package password
+
+// Option -.
+type Option func(*Argon2id)
+
+// Memory sets the memory cost in KiB.
+func Memory(kib uint32) Option {
+	return func(h *Argon2id) {
+		h.memory = kib
+	}
+}
+
+// Iterations sets the time cost.
+func Iterations(n uint32) Option {
+	return func(h *Argon2id) {
+		h.iterations = n
+	}
+}
+
+// Parallelism sets the number of threads.
+func Parallelism(n uint8) Option {
+	return func(h *Argon2id) {
+		h.parallelism = n
+	}
+}
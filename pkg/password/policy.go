@@ -0,0 +1,224 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxScore = 4
+
+	fairLength       = 8
+	strongLength     = 12
+	veryStrongLength = 16
+	allClasses       = 4
+	minContextLength = 3
+)
+
+// Requirements describes what a password must contain.
+type Requirements struct {
+	MinLength        int  `json:"min_length"`
+	MaxLength        int  `json:"max_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireNumber    bool `json:"require_number"`
+	RequireSpecial   bool `json:"require_special"`
+}
+
+// Result is the outcome of checking a password against a Policy. Score ranges
+// from 0 (very weak) to 4 (strong).
+type Result struct {
+	Valid    bool     `json:"valid"`
+	Score    int      `json:"score"`
+	Feedback []string `json:"feedback,omitempty"`
+}
+
+// Policy checks passwords against Requirements and a blocklist of known
+// breached passwords.
+type Policy struct {
+	req     Requirements
+	blocked map[string]struct{}
+}
+
+// NewPolicy -. Blocked passwords are matched case-insensitively.
+func NewPolicy(req Requirements, blocked ...string) *Policy {
+	p := &Policy{
+		req:     req,
+		blocked: make(map[string]struct{}, len(blocked)),
+	}
+
+	for _, b := range blocked {
+		if b = strings.ToLower(strings.TrimSpace(b)); b != "" {
+			p.blocked[b] = struct{}{}
+		}
+	}
+
+	return p
+}
+
+// LoadBlocklist reads one password per line, skipping blank lines and lines
+// starting with '#'.
+func LoadBlocklist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("password - LoadBlocklist - os.Open: %w", err)
+	}
+	defer f.Close()
+
+	var list []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		list = append(list, line)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("password - LoadBlocklist - scanner.Err: %w", err)
+	}
+
+	return list, nil
+}
+
+// Requirements returns the policy's requirements.
+func (p *Policy) Requirements() Requirements {
+	return p.req
+}
+
+// Check validates the password. Personal values such as the user's email or
+// name may be passed as context; passwords containing them are rejected.
+func (p *Policy) Check(password string, context ...string) Result {
+	var feedback []string
+
+	length := utf8.RuneCountInString(password)
+	classes := characterClasses(password)
+
+	if length < p.req.MinLength {
+		feedback = append(feedback, fmt.Sprintf("Use at least %d characters", p.req.MinLength))
+	}
+
+	if p.req.MaxLength > 0 && length > p.req.MaxLength {
+		feedback = append(feedback, fmt.Sprintf("Use at most %d characters", p.req.MaxLength))
+	}
+
+	if p.req.RequireUppercase && !classes.upper {
+		feedback = append(feedback, "Add an uppercase letter")
+	}
+
+	if p.req.RequireLowercase && !classes.lower {
+		feedback = append(feedback, "Add a lowercase letter")
+	}
+
+	if p.req.RequireNumber && !classes.number {
+		feedback = append(feedback, "Add a number")
+	}
+
+	if p.req.RequireSpecial && !classes.special {
+		feedback = append(feedback, "Add a special character")
+	}
+
+	if _, ok := p.blocked[strings.ToLower(password)]; ok {
+		return Result{
+			Valid:    false,
+			Score:    0,
+			Feedback: append(feedback, "This password is too common or has appeared in a data breach"),
+		}
+	}
+
+	if containsContext(password, context) {
+		feedback = append(feedback, "Avoid using your name or email in the password")
+	}
+
+	score := strengthScore(length, classes.count())
+	if len(feedback) > 0 && score > 1 {
+		score = 1
+	}
+
+	return Result{
+		Valid:    len(feedback) == 0,
+		Score:    score,
+		Feedback: feedback,
+	}
+}
+
+type classSet struct {
+	upper, lower, number, special bool
+}
+
+func (c classSet) count() int {
+	n := 0
+
+	for _, ok := range []bool{c.upper, c.lower, c.number, c.special} {
+		if ok {
+			n++
+		}
+	}
+
+	return n
+}
+
+func characterClasses(password string) classSet {
+	var c classSet
+
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			c.upper = true
+		case unicode.IsLower(r):
+			c.lower = true
+		case unicode.IsDigit(r):
+			c.number = true
+		default:
+			c.special = true
+		}
+	}
+
+	return c
+}
+
+func strengthScore(length, classes int) int {
+	score := 0
+
+	if length >= fairLength {
+		score++
+	}
+
+	if length >= strongLength {
+		score++
+	}
+
+	if classes >= allClasses-1 {
+		score++
+	}
+
+	if length >= veryStrongLength || classes == allClasses {
+		score++
+	}
+
+	return min(score, maxScore)
+}
+
+func containsContext(password string, context []string) bool {
+	lower := strings.ToLower(password)
+
+	for _, value := range context {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			value = local
+		}
+
+		if utf8.RuneCountInString(value) >= minContextLength && strings.Contains(lower, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPolicy(blocked ...string) *Policy {
+	return NewPolicy(Requirements{
+		MinLength:        8,
+		MaxLength:        64,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireNumber:    true,
+	}, blocked...)
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		password     string
+		context      []string
+		wantValid    bool
+		wantScore    int
+		wantFeedback []string
+	}{
+		{
+			name:      "meets requirements",
+			password:  "Secure123",
+			wantValid: true,
+			wantScore: 2,
+		},
+		{
+			name:      "long with all classes",
+			password:  "Correct-Horse-42-Battery",
+			wantValid: true,
+			wantScore: 4,
+		},
+		{
+			name:         "too short",
+			password:     "Ab1",
+			wantScore:    1,
+			wantFeedback: []string{"Use at least 8 characters"},
+		},
+		{
+			name:         "missing classes",
+			password:     "alllowercaseletters",
+			wantScore:    1,
+			wantFeedback: []string{"Add an uppercase letter", "Add a number"},
+		},
+		{
+			name:         "too long",
+			password:     "Aa1" + strings.Repeat("a", 70),
+			wantScore:    1,
+			wantFeedback: []string{"Use at most 64 characters"},
+		},
+		{
+			name:         "blocklisted",
+			password:     "Password123",
+			wantScore:    0,
+			wantFeedback: []string{"This password is too common or has appeared in a data breach"},
+		},
+		{
+			name:         "contains email",
+			password:     "Johnny2024!",
+			context:      []string{"johnny@example.com"},
+			wantScore:    1,
+			wantFeedback: []string{"Avoid using your name or email in the password"},
+		},
+	}
+
+	p := newTestPolicy("password123", "  qwerty  ")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := p.Check(tt.password, tt.context...)
+
+			assert.Equal(t, tt.wantValid, got.Valid)
+			assert.Equal(t, tt.wantScore, got.Score)
+			assert.Equal(t, tt.wantFeedback, got.Feedback)
+		})
+	}
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# common passwords\n\npassword\n 123456 \n"), 0o600))
+
+	list, err := LoadBlocklist(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password", "123456"}, list)
+
+	_, err = LoadBlocklist(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const localDirPerm = 0o750
+
+// Local keeps objects as files under a directory, one file per key.
+type Local struct {
+	dir string
+}
+
+var _ Storage = (*Local)(nil)
+
+// NewLocal stores objects under dir, creating it if needed.
+func NewLocal(dir string) (*Local, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("storage - NewLocal - filepath.Abs: %w", err)
+	}
+
+	if err = os.MkdirAll(abs, localDirPerm); err != nil {
+		return nil, fmt.Errorf("storage - NewLocal - os.MkdirAll: %w", err)
+	}
+
+	return &Local{dir: abs}, nil
+}
+
+// Put writes the object to a temporary file first, so readers never see a
+// partly written one.
+func (s *Local) Put(_ context.Context, key string, data []byte, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), localDirPerm); err != nil {
+		return fmt.Errorf("storage - Local.Put - os.MkdirAll: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("storage - Local.Put - os.CreateTemp: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck // no-op after rename
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+
+		return fmt.Errorf("storage - Local.Put - f.Write: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("storage - Local.Put - f.Close: %w", err)
+	}
+
+	if err = os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("storage - Local.Put - os.Rename: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("storage - Local.Get - os.Open: %w", err)
+	}
+
+	return f, nil
+}
+
+func (s *Local) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("storage - Local.Delete - os.Remove: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Local) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
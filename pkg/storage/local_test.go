@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	s, err := NewLocal(filepath.Join(dir, "objects"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, s.Put(ctx, "exports/user/archive.zip", []byte("archive"), "application/zip"))
+
+	stored, err := os.ReadFile(filepath.Join(dir, "objects", "exports", "user", "archive.zip"))
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(stored))
+
+	require.NoError(t, s.Put(ctx, "exports/user/archive.zip", []byte("replaced"), "application/zip"))
+
+	body, err := s.Get(ctx, "exports/user/archive.zip")
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, "replaced", string(data))
+
+	entries, err := os.ReadDir(filepath.Join(dir, "objects", "exports", "user"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+
+	require.NoError(t, s.Delete(ctx, "exports/user/archive.zip"))
+	require.NoError(t, s.Delete(ctx, "exports/user/archive.zip"), "deleting a missing object succeeds")
+
+	_, err = s.Get(ctx, "exports/user/archive.zip")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocal_InvalidKey(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../b", "a//b", `a\b`} {
+		require.ErrorIs(t, s.Put(context.Background(), key, []byte("x"), ""), errInvalidKey, key)
+	}
+}
@@ -0,0 +1,40 @@
+// Package storage keeps files in a local directory or an S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned by Get when no object has the key.
+	ErrNotFound = errors.New("storage: object not found")
+
+	errInvalidKey = errors.New("storage: invalid key")
+)
+
+// Storage stores objects under slash-separated keys such as
+// "exports/2024/archive.zip". Deleting a missing object is not an error.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// validateKey rejects keys that are empty, absolute or could climb out of
+// the directory or bucket prefix they are stored under.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsRune(key, '\\') {
+		return errInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return errInvalidKey
+		}
+	}
+
+	return nil
+}
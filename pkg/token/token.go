@@ -0,0 +1,89 @@
+// Package token generates opaque random tokens and the hashes stored in their place.
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// DefaultLength is the number of random bytes in a generated token.
+const DefaultLength = 32
+
+// CodeAlphabet is the character set of human-typed codes. It leaves out 0/O
+// and 1/I so codes survive being read aloud or copied from paper.
+const CodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// ErrEmptyPattern is returned by Pattern when the pattern has no X placeholders.
+var ErrEmptyPattern = errors.New("token pattern has no placeholders")
+
+// Generate returns a URL-safe random token built from n random bytes.
+func Generate(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("token - Generate - rand.Read: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns the hex-encoded SHA-256 of raw. It is what gets persisted
+// instead of the token itself.
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// NumericCode returns a uniformly distributed numeric code with the given number of digits.
+func NumericCode(digits int) (string, error) {
+	var sb strings.Builder
+
+	ten := big.NewInt(10)
+
+	for range digits {
+		n, err := rand.Int(rand.Reader, ten)
+		if err != nil {
+			return "", fmt.Errorf("token - NumericCode - rand.Int: %w", err)
+		}
+
+		sb.WriteByte(byte('0' + n.Int64()))
+	}
+
+	return sb.String(), nil
+}
+
+// Pattern returns a code shaped like pattern, with each X replaced by a
+// random character from CodeAlphabet and every other character kept as is.
+// "XXXX-XXXX" yields codes like "K7QM-2HZP".
+func Pattern(pattern string) (string, error) {
+	if !strings.ContainsRune(pattern, 'X') {
+		return "", ErrEmptyPattern
+	}
+
+	var sb strings.Builder
+
+	size := big.NewInt(int64(len(CodeAlphabet)))
+
+	for _, r := range pattern {
+		if r != 'X' {
+			sb.WriteRune(r)
+
+			continue
+		}
+
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("token - Pattern - rand.Int: %w", err)
+		}
+
+		sb.WriteByte(CodeAlphabet[n.Int64()])
+	}
+
+	return sb.String(), nil
+}
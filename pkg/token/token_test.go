@@ -0,0 +1,59 @@
+package token_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/evrone/go-clean-template/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	first, err := token.Generate(token.DefaultLength)
+	require.NoError(t, err)
+
+	second, err := token.Generate(token.DefaultLength)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+
+	raw, err := base64.RawURLEncoding.DecodeString(first)
+	require.NoError(t, err)
+	assert.Len(t, raw, token.DefaultLength)
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	hash := token.Hash("abc")
+
+	assert.Len(t, hash, 64)
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", hash)
+	assert.Equal(t, hash, token.Hash("abc"))
+	assert.NotEqual(t, hash, token.Hash("abd"))
+}
+
+func TestNumericCode(t *testing.T) {
+	t.Parallel()
+
+	code, err := token.NumericCode(6)
+	require.NoError(t, err)
+
+	assert.Len(t, code, 6)
+	assert.Regexp(t, `^[0-9]{6}$`, code)
+}
+
+func TestPattern(t *testing.T) {
+	t.Parallel()
+
+	code, err := token.Pattern("XXXX-XXXX")
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^[A-HJ-NP-Z2-9]{4}-[A-HJ-NP-Z2-9]{4}$`, code)
+
+	_, err = token.Pattern("----")
+	require.ErrorIs(t, err, token.ErrEmptyPattern)
+}
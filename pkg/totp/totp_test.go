@@ -0,0 +1,100 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evrone/go-clean-template/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 seed from RFC 6238 appendix B.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	t.Parallel()
+
+	// RFC 6238 publishes 8-digit values; the last six digits are the 6-digit code.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1234567890, 0)
+	current := totp.Step(now)
+
+	previous, err := totp.Code(rfcSecret, current-1)
+	require.NoError(t, err)
+
+	step, ok, err := totp.Validate(rfcSecret, previous, now, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, current-1, step)
+
+	_, ok, err = totp.Validate(rfcSecret, previous, now, 0)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = totp.Validate(rfcSecret, "12345", now, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = totp.Validate("not base32!", "123456", now, 1)
+	require.Error(t, err)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	t.Parallel()
+
+	a, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	b, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a, b)
+	assert.Len(t, a, 32)
+	assert.NotContains(t, a, "=")
+
+	_, err = totp.Code(a, 1)
+	require.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	t.Parallel()
+
+	uri := totp.URI("Thiam", "user@example.com", "JBSWY3DPEHPK3PXP")
+
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/Thiam:user@example.com?"))
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	assert.Equal(t, "Thiam", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+	assert.Equal(t, "30", u.Query().Get("period"))
+}
@@ -0,0 +1,163 @@
+// Package useragent derives a coarse device description from a User-Agent
+// header. It recognises the browsers and operating systems that make up
+// nearly all real traffic and reports anything else as unknown.
+package useragent
+
+import "strings"
+
+// Device types.
+const (
+	Desktop = "desktop"
+	Mobile  = "mobile"
+	Tablet  = "tablet"
+	Unknown = "unknown"
+)
+
+// Device describes where a request came from. OS and Browser are empty when
+// they cannot be told, and carry a version when one is given, e.g. "iOS 17.2"
+// or "Chrome 120".
+type Device struct {
+	Type    string
+	OS      string
+	Browser string
+}
+
+// Parse describes the device behind ua.
+func Parse(ua string) Device {
+	if ua == "" {
+		return Device{Type: Unknown}
+	}
+
+	return Device{
+		Type:    deviceType(ua),
+		OS:      operatingSystem(ua),
+		Browser: browser(ua),
+	}
+}
+
+// Fingerprint identifies the kind of device without version numbers, so a
+// browser or OS update does not make a known device look new.
+func (d Device) Fingerprint() string {
+	return d.Type + "|" + unversioned(d.OS) + "|" + unversioned(d.Browser)
+}
+
+func deviceType(ua string) string {
+	lower := strings.ToLower(ua)
+
+	switch {
+	case strings.Contains(lower, "bot"), strings.Contains(lower, "crawler"), strings.Contains(lower, "spider"):
+		return Unknown
+	case strings.Contains(ua, "iPad"), strings.Contains(lower, "tablet"),
+		strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile"):
+		return Tablet
+	case strings.Contains(ua, "Mobile"), strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"):
+		return Mobile
+	case strings.Contains(ua, "Windows"), strings.Contains(ua, "Macintosh"),
+		strings.Contains(ua, "CrOS"), strings.Contains(ua, "X11"), strings.Contains(ua, "Linux"):
+		return Desktop
+	default:
+		return Unknown
+	}
+}
+
+//nolint:gochecknoglobals // lookup table
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+}
+
+func operatingSystem(ua string) string {
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "iPod"):
+		return named("iOS", underscored(after(ua, " OS ")))
+	case strings.Contains(ua, "Android"):
+		return named("Android", version(after(ua, "Android ")))
+	case strings.Contains(ua, "Windows NT"):
+		return named("Windows", windowsVersions[version(after(ua, "Windows NT "))])
+	case strings.Contains(ua, "Mac OS X"):
+		return named("macOS", underscored(after(ua, "Mac OS X ")))
+	case strings.Contains(ua, "CrOS"):
+		return "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	default:
+		return ""
+	}
+}
+
+// browser checks the most specific markers first: Edge, Opera and Samsung
+// Internet all claim to be Chrome, and Chrome claims to be Safari.
+func browser(ua string) string {
+	for _, b := range []struct{ name, marker string }{
+		{"Edge", "Edg/"},
+		{"Edge", "EdgiOS/"},
+		{"Opera", "OPR/"},
+		{"Samsung Internet", "SamsungBrowser/"},
+		{"Firefox", "Firefox/"},
+		{"Firefox", "FxiOS/"},
+		{"Chrome", "CriOS/"},
+		{"Chrome", "Chrome/"},
+	} {
+		if strings.Contains(ua, b.marker) {
+			return named(b.name, major(after(ua, b.marker)))
+		}
+	}
+
+	if strings.Contains(ua, "Safari/") && strings.Contains(ua, "Version/") {
+		return named("Safari", major(after(ua, "Version/")))
+	}
+
+	return ""
+}
+
+// after returns what follows the first occurrence of marker in ua.
+func after(ua, marker string) string {
+	_, rest, _ := strings.Cut(ua, marker)
+
+	return rest
+}
+
+// version returns the dotted version number s starts with.
+func version(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end < 0 {
+		end = len(s)
+	}
+
+	return strings.Trim(s[:end], ".")
+}
+
+// underscored reads versions Apple writes as 10_15_7.
+func underscored(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '_' && r != '.' })
+	if end < 0 {
+		end = len(s)
+	}
+
+	return version(strings.ReplaceAll(s[:end], "_", "."))
+}
+
+func major(s string) string {
+	v, _, _ := strings.Cut(version(s), ".")
+
+	return v
+}
+
+func named(name, version string) string {
+	if version == "" {
+		return name
+	}
+
+	return name + " " + version
+}
+
+// unversioned drops the version named appends.
+func unversioned(s string) string {
+	if i := strings.LastIndexByte(s, ' '); i >= 0 && s[i+1] >= '0' && s[i+1] <= '9' {
+		return s[:i]
+	}
+
+	return s
+}
@@ -0,0 +1,90 @@
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/evrone/go-clean-template/pkg/useragent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ua   string
+		want useragent.Device
+	}{
+		{
+			name: "chrome on macOS",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: useragent.Device{Type: useragent.Desktop, OS: "macOS 10.15.7", Browser: "Chrome 120"},
+		},
+		{
+			name: "edge on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			want: useragent.Device{Type: useragent.Desktop, OS: "Windows 10", Browser: "Edge 120"},
+		},
+		{
+			name: "firefox on linux",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: useragent.Device{Type: useragent.Desktop, OS: "Linux", Browser: "Firefox 121"},
+		},
+		{
+			name: "safari on iPhone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: useragent.Device{Type: useragent.Mobile, OS: "iOS 17.2", Browser: "Safari 17"},
+		},
+		{
+			name: "chrome on android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			want: useragent.Device{Type: useragent.Mobile, OS: "Android 14", Browser: "Chrome 120"},
+		},
+		{
+			name: "android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+			want: useragent.Device{Type: useragent.Tablet, OS: "Android 13", Browser: "Chrome 119"},
+		},
+		{
+			name: "iPad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			want: useragent.Device{Type: useragent.Tablet, OS: "iOS 16.6", Browser: "Chrome 120"},
+		},
+		{
+			name: "bot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: useragent.Device{Type: useragent.Unknown},
+		},
+		{
+			name: "command line client",
+			ua:   "curl/8.4.0",
+			want: useragent.Device{Type: useragent.Unknown},
+		},
+		{
+			name: "empty",
+			want: useragent.Device{Type: useragent.Unknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, useragent.Parse(tt.ua))
+		})
+	}
+}
+
+func TestDevice_Fingerprint(t *testing.T) {
+	t.Parallel()
+
+	older := useragent.Parse("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36")
+	newer := useragent.Parse("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	other := useragent.Parse("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15")
+	samsung := useragent.Parse("Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36")
+
+	assert.Equal(t, "desktop|macOS|Chrome", newer.Fingerprint())
+	assert.Equal(t, older.Fingerprint(), newer.Fingerprint())
+	assert.NotEqual(t, newer.Fingerprint(), other.Fingerprint())
+	assert.Equal(t, "mobile|Android|Samsung Internet", samsung.Fingerprint())
+}
@@ -0,0 +1,138 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"slices"
+)
+
+// idFIDOGenCeAAGUID is the certificate extension carrying the authenticator's
+// AAGUID (section 8.2.1).
+//
+//nolint:gochecknoglobals // constant OID
+var idFIDOGenCeAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// attestationObject is the CBOR map returned by create() (section 6.5.4).
+type attestationObject struct {
+	format   string
+	stmt     map[any]any
+	authData []byte
+}
+
+func parseAttestationObject(raw []byte) (*attestationObject, error) {
+	v, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object is not a map", ErrMalformed)
+	}
+
+	att := &attestationObject{}
+	att.format, _ = m["fmt"].(string)
+	att.stmt, _ = m["attStmt"].(map[any]any)
+	att.authData, _ = m["authData"].([]byte)
+
+	if att.format == "" || att.stmt == nil || att.authData == nil {
+		return nil, fmt.Errorf("%w: attestation object is missing fmt, attStmt or authData", ErrMalformed)
+	}
+
+	return att, nil
+}
+
+func verifyAttestation(att *attestationObject, ad *authData, key *publicKey, clientDataHash []byte) error {
+	switch att.format {
+	case FormatNone:
+		if len(att.stmt) != 0 {
+			return fmt.Errorf("%w: none attestation with a statement", ErrMalformed)
+		}
+
+		return nil
+	case FormatPacked:
+		return verifyPacked(att, ad, key, clientDataHash)
+	default:
+		return fmt.Errorf("%w: attestation format %q", ErrUnsupported, att.format)
+	}
+}
+
+// verifyPacked checks a packed attestation statement (section 8.2), either
+// self attestation signed by the credential key or one signed by an
+// attestation certificate.
+func verifyPacked(att *attestationObject, ad *authData, key *publicKey, clientDataHash []byte) error {
+	alg, _ := att.stmt["alg"].(int64)
+	sig, _ := att.stmt["sig"].([]byte)
+
+	if sig == nil {
+		return fmt.Errorf("%w: packed attestation without a signature", ErrMalformed)
+	}
+
+	msg := signedData(att.authData, clientDataHash)
+
+	x5c, hasX5C := att.stmt["x5c"].([]any)
+	if !hasX5C {
+		if int(alg) != key.alg {
+			return fmt.Errorf("%w: self attestation algorithm %d does not match the credential key", ErrVerification, alg)
+		}
+
+		return key.verify(msg, sig)
+	}
+
+	if len(x5c) == 0 {
+		return fmt.Errorf("%w: empty x5c", ErrMalformed)
+	}
+
+	der, _ := x5c[0].([]byte)
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("%w: attestation certificate: %w", ErrMalformed, err)
+	}
+
+	if err = checkPackedCertificate(cert, ad.credential.aaguid); err != nil {
+		return err
+	}
+
+	return verifySignature(int(alg), cert.PublicKey, msg, sig)
+}
+
+// checkPackedCertificate applies the attestation certificate requirements
+// of section 8.2.1.
+func checkPackedCertificate(cert *x509.Certificate, aaguid []byte) error {
+	const (
+		version        = 3
+		organizational = "Authenticator Attestation"
+	)
+
+	if cert.Version != version {
+		return fmt.Errorf("%w: attestation certificate version %d", ErrVerification, cert.Version)
+	}
+
+	if !slices.Contains(cert.Subject.OrganizationalUnit, organizational) {
+		return fmt.Errorf("%w: attestation certificate subject OU", ErrVerification)
+	}
+
+	if cert.IsCA {
+		return fmt.Errorf("%w: attestation certificate is a CA", ErrVerification)
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(idFIDOGenCeAAGUID) {
+			continue
+		}
+
+		var certAAGUID []byte
+		if _, err := asn1.Unmarshal(ext.Value, &certAAGUID); err != nil {
+			return fmt.Errorf("%w: attestation certificate AAGUID: %w", ErrMalformed, err)
+		}
+
+		if ext.Critical || !bytes.Equal(certAAGUID, aaguid) {
+			return fmt.Errorf("%w: attestation certificate AAGUID does not match", ErrVerification)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package webauthn
+
+import (
+	"fmt"
+	"math"
+)
+
+// CBOR major types (RFC 8949, section 3.1).
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborSimple = 7
+)
+
+const (
+	cborFalse = 20
+	cborTrue  = 21
+	cborNull  = 22
+
+	// maxCBORDepth bounds nesting so a hostile payload cannot exhaust the stack.
+	maxCBORDepth = 16
+)
+
+// cborDecoder reads the subset of CBOR that authenticators emit: definite
+// lengths, integers, byte and text strings, arrays, maps, booleans and null.
+// Integers decode to int64, maps to map[any]any keyed by int64 or string.
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// decodeCBOR decodes a single item that must span all of data.
+func decodeCBOR(data []byte) (any, error) {
+	d := &cborDecoder{data: data}
+
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.pos != len(data) {
+		return nil, fmt.Errorf("%w: %d trailing bytes after CBOR item", ErrMalformed, len(data)-d.pos)
+	}
+
+	return v, nil
+}
+
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > maxCBORDepth {
+		return nil, fmt.Errorf("%w: CBOR nested too deeply", ErrMalformed)
+	}
+
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: CBOR integer overflows int64", ErrMalformed)
+		}
+
+		return int64(arg), nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: CBOR integer overflows int64", ErrMalformed)
+		}
+
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		b, err := d.take(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if major == cborText {
+			return string(b), nil
+		}
+
+		return b, nil
+	case cborArray:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, fmt.Errorf("%w: CBOR array longer than input", ErrMalformed)
+		}
+
+		items := make([]any, arg)
+		for i := range items {
+			if items[i], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+
+		return items, nil
+	case cborMap:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, fmt.Errorf("%w: CBOR map longer than input", ErrMalformed)
+		}
+
+		m := make(map[any]any, arg)
+
+		for range arg {
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("%w: unsupported CBOR map key %T", ErrMalformed, k)
+			}
+
+			if _, dup := m[k]; dup {
+				return nil, fmt.Errorf("%w: duplicate CBOR map key %v", ErrMalformed, k)
+			}
+
+			if m[k], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+	case cborSimple:
+		switch arg {
+		case cborFalse:
+			return false, nil
+		case cborTrue:
+			return true, nil
+		case cborNull:
+			return nil, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unsupported CBOR item (major type %d)", ErrMalformed, major)
+}
+
+// head reads an item's initial byte and argument. Indefinite lengths and
+// floats are rejected.
+func (d *cborDecoder) head() (byte, uint64, error) {
+	b, err := d.take(1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	major, info := b[0]>>5, b[0]&0x1f
+
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info <= 27:
+		n, err := d.take(1 << (info - 24))
+		if err != nil {
+			return 0, 0, err
+		}
+
+		var arg uint64
+		for _, c := range n {
+			arg = arg<<8 | uint64(c)
+		}
+
+		if major == cborSimple {
+			return 0, 0, fmt.Errorf("%w: CBOR floats are not supported", ErrMalformed)
+		}
+
+		return major, arg, nil
+	default:
+		return 0, 0, fmt.Errorf("%w: indefinite-length CBOR is not supported", ErrMalformed)
+	}
+}
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, fmt.Errorf("%w: CBOR input truncated", ErrMalformed)
+	}
+
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+
+	return b, nil
+}
@@ -0,0 +1,169 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers (RFC 9053) for the signature schemes accepted.
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// Algorithms lists the accepted algorithms in order of preference, for the
+// pubKeyCredParams of a creation request.
+//
+//nolint:gochecknoglobals // fixed list exposed for building options
+var Algorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters (RFC 9052, section 7; RFC 9053, section 7).
+const (
+	coseKty = 1
+	coseAlg = 3
+
+	coseCrv = -1
+	coseX   = -2
+	coseY   = -3
+	coseN   = -1
+	coseE   = -2
+
+	ktyOKP = 1
+	ktyEC2 = 2
+	ktyRSA = 3
+
+	crvP256    = 1
+	crvEd25519 = 6
+
+	minRSABits = 2048
+)
+
+// publicKey is a credential public key decoded from its COSE form.
+type publicKey struct {
+	alg int
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key holding an ES256, EdDSA or RS256 key.
+func parsePublicKey(raw []byte) (*publicKey, error) {
+	v, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: COSE key is not a map", ErrMalformed)
+	}
+
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		return parseEC2(m)
+	case kty == ktyOKP && alg == AlgEdDSA:
+		return parseOKP(m)
+	case kty == ktyRSA && alg == AlgRS256:
+		return parseRSA(m)
+	default:
+		return nil, fmt.Errorf("%w: COSE key type %d with algorithm %d", ErrUnsupported, kty, alg)
+	}
+}
+
+func parseEC2(m map[any]any) (*publicKey, error) {
+	crv, _ := m[int64(coseCrv)].(int64)
+	x, _ := m[int64(coseX)].([]byte)
+	y, _ := m[int64(coseY)].([]byte)
+
+	const coordLen = 32
+
+	if crv != crvP256 || len(x) != coordLen || len(y) != coordLen {
+		return nil, fmt.Errorf("%w: EC2 key is not a P-256 point", ErrUnsupported)
+	}
+
+	// Uncompressed SEC 1 point, which NewPublicKey checks lies on the curve.
+	point := make([]byte, 0, 1+2*coordLen)
+	point = append(point, 4)
+	point = append(point, x...)
+	point = append(point, y...)
+
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("%w: EC2 key is not on the curve", ErrMalformed)
+	}
+
+	return &publicKey{
+		alg: AlgES256,
+		key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)},
+	}, nil
+}
+
+func parseOKP(m map[any]any) (*publicKey, error) {
+	crv, _ := m[int64(coseCrv)].(int64)
+	x, _ := m[int64(coseX)].([]byte)
+
+	if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: OKP key is not Ed25519", ErrUnsupported)
+	}
+
+	return &publicKey{alg: AlgEdDSA, key: ed25519.PublicKey(x)}, nil
+}
+
+func parseRSA(m map[any]any) (*publicKey, error) {
+	n, _ := m[int64(coseN)].([]byte)
+	e, _ := m[int64(coseE)].([]byte)
+
+	const maxExponentLen = 4
+
+	if len(n) == 0 || len(e) == 0 || len(e) > maxExponentLen {
+		return nil, fmt.Errorf("%w: RSA key parameters", ErrMalformed)
+	}
+
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if key.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("%w: RSA key shorter than %d bits", ErrUnsupported, minRSABits)
+	}
+
+	return &publicKey{alg: AlgRS256, key: key}, nil
+}
+
+// verify checks sig over msg with the key's algorithm.
+func (k *publicKey) verify(msg, sig []byte) error {
+	return verifySignature(k.alg, k.key, msg, sig)
+}
+
+// verifySignature checks sig over msg. ES256 signatures are ASN.1 DER, as
+// WebAuthn requires, not the raw r||s form COSE uses elsewhere.
+func verifySignature(alg int, key crypto.PublicKey, msg, sig []byte) error {
+	ok := false
+
+	switch alg {
+	case AlgES256:
+		pub, isECDSA := key.(*ecdsa.PublicKey)
+		digest := sha256.Sum256(msg)
+		ok = isECDSA && ecdsa.VerifyASN1(pub, digest[:], sig)
+	case AlgEdDSA:
+		pub, isEd25519 := key.(ed25519.PublicKey)
+		ok = isEd25519 && ed25519.Verify(pub, msg, sig)
+	case AlgRS256:
+		pub, isRSA := key.(*rsa.PublicKey)
+		digest := sha256.Sum256(msg)
+		ok = isRSA && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	default:
+		return fmt.Errorf("%w: signature algorithm %d", ErrUnsupported, alg)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: bad signature", ErrVerification)
+	}
+
+	return nil
+}
@@ -0,0 +1,402 @@
+// Package webauthn verifies WebAuthn (passkey) registration and
+// authentication responses for a relying party, following the ceremonies in
+// sections 7.1 and 7.2 of the Web Authentication Level 2 recommendation.
+//
+// Building the options handed to the browser and storing challenges and
+// credentials are left to the caller.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ChallengeLength is the size in bytes of challenges from NewChallenge.
+const ChallengeLength = 32
+
+// Attestation formats that can be verified. Others are rejected.
+const (
+	FormatNone   = "none"
+	FormatPacked = "packed"
+)
+
+var (
+	// ErrInvalidConfig is returned by New when the relying party ID or origins are missing.
+	ErrInvalidConfig = errors.New("webauthn: invalid config")
+	// ErrMalformed means the response could not be decoded.
+	ErrMalformed = errors.New("webauthn: malformed response")
+	// ErrUnsupported means the response uses an algorithm, key type or
+	// attestation format this package does not handle.
+	ErrUnsupported = errors.New("webauthn: unsupported")
+	// ErrVerification means the response decoded but failed a check, such as
+	// a wrong challenge, origin or signature.
+	ErrVerification = errors.New("webauthn: verification failed")
+)
+
+// Authenticator data flags (section 6.1).
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackedUp       = 0x10
+	flagAttestedData   = 0x40
+	flagExtensionData  = 0x80
+)
+
+const (
+	clientDataCreate = "webauthn.create"
+	clientDataGet    = "webauthn.get"
+
+	rpIDHashLen = 32
+	aaguidLen   = 16
+	// authDataMinLen covers the RP ID hash, flags and signature counter.
+	authDataMinLen = rpIDHashLen + 1 + 4
+	// maxCredentialIDLen is the limit set in section 5.8.3.
+	maxCredentialIDLen = 1023
+)
+
+// Config identifies the relying party.
+type Config struct {
+	// RPID is the relying party ID, a registrable domain such as "example.com".
+	RPID string
+	// Origins are the exact origins, such as "https://app.example.com", that
+	// ceremonies may run on.
+	Origins []string
+}
+
+// RelyingParty verifies responses produced for one relying party ID.
+type RelyingParty struct {
+	id      string
+	idHash  [sha256.Size]byte
+	origins []string
+}
+
+// New returns a relying party for cfg.
+func New(cfg Config) (*RelyingParty, error) {
+	if cfg.RPID == "" {
+		return nil, fmt.Errorf("%w: relying party ID is required", ErrInvalidConfig)
+	}
+
+	if len(cfg.Origins) == 0 {
+		return nil, fmt.Errorf("%w: at least one origin is required", ErrInvalidConfig)
+	}
+
+	origins := make([]string, len(cfg.Origins))
+	for i, o := range cfg.Origins {
+		origins[i] = strings.TrimSuffix(o, "/")
+	}
+
+	return &RelyingParty{
+		id:      cfg.RPID,
+		idHash:  sha256.Sum256([]byte(cfg.RPID)),
+		origins: origins,
+	}, nil
+}
+
+// ID returns the relying party ID.
+func (rp *RelyingParty) ID() string {
+	return rp.id
+}
+
+// NewChallenge returns ChallengeLength random bytes.
+func NewChallenge() ([]byte, error) {
+	c := make([]byte, ChallengeLength)
+	if _, err := rand.Read(c); err != nil {
+		return nil, fmt.Errorf("webauthn - NewChallenge - rand.Read: %w", err)
+	}
+
+	return c, nil
+}
+
+// ClientChallenge returns the challenge a response's client data was signed
+// for, so the caller can find the ceremony it belongs to before verifying it.
+func ClientChallenge(clientDataJSON []byte) ([]byte, error) {
+	cd, err := parseClientData(clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return cd.challenge, nil
+}
+
+// RegistrationResponse is the AuthenticatorAttestationResponse returned by
+// navigator.credentials.create().
+type RegistrationResponse struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// Credential is a newly registered public key credential.
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE_Key to store and pass to VerifyAssertion.
+	PublicKey []byte
+	Algorithm int
+	AAGUID    []byte
+	SignCount uint32
+	// AttestationFormat is the statement format that was verified.
+	AttestationFormat string
+	UserVerified      bool
+	BackupEligible    bool
+	BackedUp          bool
+}
+
+// VerifyRegistration checks a registration response against the challenge
+// it was issued for. User presence is always required; user verification
+// only when requireUV is set.
+//
+// Attestation statements are checked for integrity only: the authenticator's
+// certificate chain is not evaluated against a trust store, so Credential
+// says nothing about the authenticator's make or certification.
+func (rp *RelyingParty) VerifyRegistration(resp RegistrationResponse, challenge []byte, requireUV bool) (*Credential, error) {
+	if err := rp.verifyClientData(resp.ClientDataJSON, clientDataCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	att, err := parseAttestationObject(resp.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := parseAuthData(att.authData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rp.verifyAuthData(ad, requireUV); err != nil {
+		return nil, err
+	}
+
+	if ad.credential == nil {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrMalformed)
+	}
+
+	key, err := parsePublicKey(ad.credential.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	if err = verifyAttestation(att, ad, key, clientDataHash[:]); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:                ad.credential.id,
+		PublicKey:         ad.credential.publicKey,
+		Algorithm:         key.alg,
+		AAGUID:            ad.credential.aaguid,
+		SignCount:         ad.signCount,
+		AttestationFormat: att.format,
+		UserVerified:      ad.flags&flagUserVerified != 0,
+		BackupEligible:    ad.flags&flagBackupEligible != 0,
+		BackedUp:          ad.flags&flagBackedUp != 0,
+	}, nil
+}
+
+// AssertionResponse is the AuthenticatorAssertionResponse returned by
+// navigator.credentials.get().
+type AssertionResponse struct {
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+// Assertion is the verified outcome of an authentication ceremony. Comparing
+// SignCount with the stored value is left to the caller.
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+	BackedUp     bool
+}
+
+// VerifyAssertion checks an authentication response against the challenge
+// it was issued for and the credential's stored COSE public key.
+func (rp *RelyingParty) VerifyAssertion(resp AssertionResponse, challenge, publicKey []byte, requireUV bool) (*Assertion, error) {
+	if err := rp.verifyClientData(resp.ClientDataJSON, clientDataGet, challenge); err != nil {
+		return nil, err
+	}
+
+	ad, err := parseAuthData(resp.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rp.verifyAuthData(ad, requireUV); err != nil {
+		return nil, err
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	if err = key.verify(signedData(resp.AuthenticatorData, clientDataHash[:]), resp.Signature); err != nil {
+		return nil, err
+	}
+
+	return &Assertion{
+		SignCount:    ad.signCount,
+		UserVerified: ad.flags&flagUserVerified != 0,
+		BackedUp:     ad.flags&flagBackedUp != 0,
+	}, nil
+}
+
+// clientData is the parsed CollectedClientData (section 5.8.1).
+type clientData struct {
+	typ         string
+	challenge   []byte
+	origin      string
+	crossOrigin bool
+}
+
+func parseClientData(raw []byte) (*clientData, error) {
+	var cd struct {
+		Type        string `json:"type"`
+		Challenge   string `json:"challenge"`
+		Origin      string `json:"origin"`
+		CrossOrigin bool   `json:"crossOrigin"`
+	}
+
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, fmt.Errorf("%w: client data: %w", ErrMalformed, err)
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil || len(challenge) == 0 {
+		return nil, fmt.Errorf("%w: client data challenge", ErrMalformed)
+	}
+
+	return &clientData{typ: cd.Type, challenge: challenge, origin: cd.Origin, crossOrigin: cd.CrossOrigin}, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, typ string, challenge []byte) error {
+	cd, err := parseClientData(raw)
+	if err != nil {
+		return err
+	}
+
+	if cd.typ != typ {
+		return fmt.Errorf("%w: client data type %q, want %q", ErrVerification, cd.typ, typ)
+	}
+
+	if len(challenge) == 0 || subtle.ConstantTimeCompare(cd.challenge, challenge) != 1 {
+		return fmt.Errorf("%w: challenge mismatch", ErrVerification)
+	}
+
+	if !slices.Contains(rp.origins, cd.origin) {
+		return fmt.Errorf("%w: origin %q is not allowed", ErrVerification, cd.origin)
+	}
+
+	if cd.crossOrigin {
+		return fmt.Errorf("%w: cross-origin ceremonies are not allowed", ErrVerification)
+	}
+
+	return nil
+}
+
+// authData is the parsed authenticator data (section 6.1).
+type authData struct {
+	rpIDHash   []byte
+	flags      byte
+	signCount  uint32
+	credential *attestedCredential
+}
+
+// attestedCredential is the attested credential data (section 6.5.1).
+type attestedCredential struct {
+	aaguid    []byte
+	id        []byte
+	publicKey []byte
+}
+
+func parseAuthData(raw []byte) (*authData, error) {
+	if len(raw) < authDataMinLen {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrMalformed)
+	}
+
+	ad := &authData{
+		rpIDHash:  raw[:rpIDHashLen],
+		flags:     raw[rpIDHashLen],
+		signCount: binary.BigEndian.Uint32(raw[rpIDHashLen+1 : authDataMinLen]),
+	}
+
+	rest := raw[authDataMinLen:]
+
+	if ad.flags&flagAttestedData != 0 {
+		const idLenSize = 2
+
+		if len(rest) < aaguidLen+idLenSize {
+			return nil, fmt.Errorf("%w: attested credential data too short", ErrMalformed)
+		}
+
+		cred := &attestedCredential{aaguid: rest[:aaguidLen]}
+		idLen := int(binary.BigEndian.Uint16(rest[aaguidLen:]))
+		rest = rest[aaguidLen+idLenSize:]
+
+		if idLen == 0 || idLen > maxCredentialIDLen || idLen > len(rest) {
+			return nil, fmt.Errorf("%w: credential ID length %d", ErrMalformed, idLen)
+		}
+
+		cred.id, rest = rest[:idLen], rest[idLen:]
+
+		d := &cborDecoder{data: rest}
+		if _, err := d.value(0); err != nil {
+			return nil, err
+		}
+
+		cred.publicKey, rest = rest[:d.pos], rest[d.pos:]
+		ad.credential = cred
+	}
+
+	if ad.flags&flagExtensionData != 0 {
+		d := &cborDecoder{data: rest}
+		if _, err := d.value(0); err != nil {
+			return nil, err
+		}
+
+		rest = rest[d.pos:]
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes in authenticator data", ErrMalformed, len(rest))
+	}
+
+	return ad, nil
+}
+
+func (rp *RelyingParty) verifyAuthData(ad *authData, requireUV bool) error {
+	if subtle.ConstantTimeCompare(ad.rpIDHash, rp.idHash[:]) != 1 {
+		return fmt.Errorf("%w: relying party ID mismatch", ErrVerification)
+	}
+
+	if ad.flags&flagUserPresent == 0 {
+		return fmt.Errorf("%w: user not present", ErrVerification)
+	}
+
+	if requireUV && ad.flags&flagUserVerified == 0 {
+		return fmt.Errorf("%w: user not verified", ErrVerification)
+	}
+
+	if ad.flags&flagBackedUp != 0 && ad.flags&flagBackupEligible == 0 {
+		return fmt.Errorf("%w: backed up but not backup eligible", ErrVerification)
+	}
+
+	return nil
+}
+
+// signedData is what assertion and packed attestation signatures cover.
+func signedData(authenticatorData, clientDataHash []byte) []byte {
+	return bytes.Join([][]byte{authenticatorData, clientDataHash}, nil)
+}
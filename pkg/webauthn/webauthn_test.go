@@ -0,0 +1,557 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://app.example.com"
+)
+
+// cborEncode writes the CBOR subset the decoder reads. Maps are encoded
+// with their keys sorted by encoded bytes, as CTAP2 requires.
+func cborEncode(v any) []byte {
+	switch x := v.(type) {
+	case int:
+		if x < 0 {
+			return cborHead(cborNegInt, uint64(-1-x))
+		}
+
+		return cborHead(cborUint, uint64(x))
+	case []byte:
+		return append(cborHead(cborBytes, uint64(len(x))), x...)
+	case string:
+		return append(cborHead(cborText, uint64(len(x))), x...)
+	case bool:
+		if x {
+			return []byte{0xf5}
+		}
+
+		return []byte{0xf4}
+	case []any:
+		out := cborHead(cborArray, uint64(len(x)))
+		for _, item := range x {
+			out = append(out, cborEncode(item)...)
+		}
+
+		return out
+	case map[any]any:
+		type pair struct{ k, v []byte }
+
+		pairs := make([]pair, 0, len(x))
+		for k, val := range x {
+			pairs = append(pairs, pair{cborEncode(k), cborEncode(val)})
+		}
+
+		sort.Slice(pairs, func(i, j int) bool { return string(pairs[i].k) < string(pairs[j].k) })
+
+		out := cborHead(cborMap, uint64(len(x)))
+		for _, p := range pairs {
+			out = append(out, p.k...)
+			out = append(out, p.v...)
+		}
+
+		return out
+	default:
+		panic("cborEncode: unsupported type")
+	}
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+}
+
+// authenticator is a software authenticator holding one credential.
+type authenticator struct {
+	t      *testing.T
+	alg    int
+	signer crypto.Signer
+	credID []byte
+	aaguid []byte
+	rpID   string
+	flags  byte
+	count  uint32
+}
+
+func newAuthenticator(t *testing.T, alg int) *authenticator {
+	t.Helper()
+
+	a := &authenticator{
+		t:      t,
+		alg:    alg,
+		credID: []byte("credential-0123456789"),
+		aaguid: []byte("0123456789abcdef"),
+		rpID:   testRPID,
+		flags:  flagUserPresent | flagUserVerified,
+	}
+
+	var err error
+
+	switch alg {
+	case AlgES256:
+		a.signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, a.signer, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		a.signer, err = rsa.GenerateKey(rand.Reader, minRSABits)
+	}
+
+	require.NoError(t, err)
+
+	return a
+}
+
+func (a *authenticator) coseKey() []byte {
+	switch pub := a.signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		return cborEncode(map[any]any{
+			coseKty: ktyEC2, coseAlg: AlgES256, coseCrv: crvP256,
+			coseX: pub.X.FillBytes(make([]byte, 32)), coseY: pub.Y.FillBytes(make([]byte, 32)),
+		})
+	case ed25519.PublicKey:
+		return cborEncode(map[any]any{coseKty: ktyOKP, coseAlg: AlgEdDSA, coseCrv: crvEd25519, coseX: []byte(pub)})
+	case *rsa.PublicKey:
+		return cborEncode(map[any]any{
+			coseKty: ktyRSA, coseAlg: AlgRS256, coseN: pub.N.Bytes(), coseE: big.NewInt(int64(pub.E)).Bytes(),
+		})
+	}
+
+	panic("coseKey: unsupported key")
+}
+
+func (a *authenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+
+	flags := a.flags
+	if attested {
+		flags |= flagAttestedData
+	}
+
+	out := append([]byte{}, rpIDHash[:]...)
+	out = append(out, flags)
+	out = binary.BigEndian.AppendUint32(out, a.count)
+
+	if attested {
+		out = append(out, a.aaguid...)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(a.credID)))
+		out = append(out, a.credID...)
+		out = append(out, a.coseKey()...)
+	}
+
+	return out
+}
+
+func (a *authenticator) sign(msg []byte) []byte {
+	var (
+		sig []byte
+		err error
+	)
+
+	switch a.alg {
+	case AlgEdDSA:
+		sig, err = a.signer.Sign(rand.Reader, msg, crypto.Hash(0))
+	default:
+		digest := sha256.Sum256(msg)
+		sig, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+
+	require.NoError(a.t, err)
+
+	return sig
+}
+
+func clientDataJSON(t *testing.T, typ string, challenge []byte, origin string) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string]any{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	require.NoError(t, err)
+
+	return raw
+}
+
+// register builds a registration response with the given attestation format.
+func (a *authenticator) register(challenge []byte, origin, format string) RegistrationResponse {
+	cd := clientDataJSON(a.t, clientDataCreate, challenge, origin)
+	ad := a.authData(true)
+	cdHash := sha256.Sum256(cd)
+
+	stmt := map[any]any{}
+	if format == FormatPacked {
+		stmt["alg"] = a.alg
+		stmt["sig"] = a.sign(signedData(ad, cdHash[:]))
+	}
+
+	return RegistrationResponse{
+		ClientDataJSON:    cd,
+		AttestationObject: cborEncode(map[any]any{"fmt": format, "attStmt": stmt, "authData": ad}),
+	}
+}
+
+// assert builds an authentication response.
+func (a *authenticator) assert(challenge []byte, origin string) AssertionResponse {
+	cd := clientDataJSON(a.t, clientDataGet, challenge, origin)
+	ad := a.authData(false)
+	cdHash := sha256.Sum256(cd)
+
+	return AssertionResponse{
+		ClientDataJSON:    cd,
+		AuthenticatorData: ad,
+		Signature:         a.sign(signedData(ad, cdHash[:])),
+	}
+}
+
+func newTestRP(t *testing.T) *RelyingParty {
+	t.Helper()
+
+	rp, err := New(Config{RPID: testRPID, Origins: []string{testOrigin + "/"}})
+	require.NoError(t, err)
+
+	return rp
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(Config{Origins: []string{testOrigin}})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = New(Config{RPID: testRPID})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	rp := newTestRP(t)
+	assert.Equal(t, testRPID, rp.ID())
+	assert.Equal(t, []string{testOrigin}, rp.origins)
+}
+
+func TestNewChallenge(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewChallenge()
+	require.NoError(t, err)
+	assert.Len(t, a, ChallengeLength)
+
+	b, err := NewChallenge()
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+
+	got, err := ClientChallenge(clientDataJSON(t, clientDataGet, a, testOrigin))
+	require.NoError(t, err)
+	assert.Equal(t, a, got)
+
+	_, err = ClientChallenge([]byte(`{"type":"webauthn.get","challenge":""}`))
+	require.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestRelyingParty_Ceremonies(t *testing.T) {
+	t.Parallel()
+
+	rp := newTestRP(t)
+
+	for _, alg := range Algorithms {
+		for _, format := range []string{FormatNone, FormatPacked} {
+			t.Run(format, func(t *testing.T) {
+				t.Parallel()
+
+				a := newAuthenticator(t, alg)
+				a.flags |= flagBackupEligible | flagBackedUp
+				a.count = 7
+
+				challenge, err := NewChallenge()
+				require.NoError(t, err)
+
+				cred, err := rp.VerifyRegistration(a.register(challenge, testOrigin, format), challenge, true)
+				require.NoError(t, err)
+				assert.Equal(t, a.credID, cred.ID)
+				assert.Equal(t, a.aaguid, cred.AAGUID)
+				assert.Equal(t, alg, cred.Algorithm)
+				assert.Equal(t, format, cred.AttestationFormat)
+				assert.Equal(t, uint32(7), cred.SignCount)
+				assert.True(t, cred.UserVerified)
+				assert.True(t, cred.BackupEligible)
+				assert.True(t, cred.BackedUp)
+
+				a.count = 8
+
+				got, err := rp.VerifyAssertion(a.assert(challenge, testOrigin), challenge, cred.PublicKey, true)
+				require.NoError(t, err)
+				assert.Equal(t, Assertion{SignCount: 8, UserVerified: true, BackedUp: true}, *got)
+			})
+		}
+	}
+}
+
+func TestRelyingParty_VerifyRegistration_Packed_X5C(t *testing.T) {
+	t.Parallel()
+
+	rp := newTestRP(t)
+	a := newAuthenticator(t, AlgES256)
+
+	attKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	certificate := func(ou string, aaguid []byte) []byte {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject: pkix.Name{
+				Country: []string{"US"}, Organization: []string{"Vendor"},
+				OrganizationalUnit: []string{ou}, CommonName: "Vendor Attestation",
+			},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			BasicConstraintsValid: true,
+		}
+
+		if aaguid != nil {
+			value, err := asn1.Marshal(aaguid)
+			require.NoError(t, err)
+
+			tmpl.ExtraExtensions = []pkix.Extension{{Id: idFIDOGenCeAAGUID, Value: value}}
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, attKey.Public(), attKey)
+		require.NoError(t, err)
+
+		return der
+	}
+
+	response := func(challenge, cert []byte) RegistrationResponse {
+		cd := clientDataJSON(t, clientDataCreate, challenge, testOrigin)
+		ad := a.authData(true)
+		cdHash := sha256.Sum256(cd)
+		digest := sha256.Sum256(signedData(ad, cdHash[:]))
+
+		sig, err := ecdsa.SignASN1(rand.Reader, attKey, digest[:])
+		require.NoError(t, err)
+
+		stmt := map[any]any{"alg": AlgES256, "sig": sig, "x5c": []any{cert}}
+
+		return RegistrationResponse{
+			ClientDataJSON:    cd,
+			AttestationObject: cborEncode(map[any]any{"fmt": FormatPacked, "attStmt": stmt, "authData": ad}),
+		}
+	}
+
+	challenge := []byte("challenge")
+
+	_, err = rp.VerifyRegistration(response(challenge, certificate("Authenticator Attestation", a.aaguid)), challenge, false)
+	require.NoError(t, err)
+
+	_, err = rp.VerifyRegistration(response(challenge, certificate("Authenticator Attestation", []byte("fedcba9876543210"))), challenge, false)
+	require.ErrorIs(t, err, ErrVerification)
+
+	_, err = rp.VerifyRegistration(response(challenge, certificate("Marketing", nil)), challenge, false)
+	require.ErrorIs(t, err, ErrVerification)
+}
+
+//nolint:funlen // table-driven tests are verbose
+func TestRelyingParty_VerifyRegistration_Rejects(t *testing.T) {
+	t.Parallel()
+
+	rp := newTestRP(t)
+	challenge := []byte("expected-challenge")
+
+	tests := []struct {
+		name      string
+		requireUV bool
+		tamper    func(a *authenticator) RegistrationResponse
+		want      error
+	}{
+		{
+			name: "wrong challenge",
+			tamper: func(a *authenticator) RegistrationResponse {
+				return a.register([]byte("other-challenge"), testOrigin, FormatNone)
+			},
+			want: ErrVerification,
+		},
+		{
+			name: "wrong origin",
+			tamper: func(a *authenticator) RegistrationResponse {
+				return a.register(challenge, "https://evil.example", FormatNone)
+			},
+			want: ErrVerification,
+		},
+		{
+			name: "assertion client data",
+			tamper: func(a *authenticator) RegistrationResponse {
+				resp := a.register(challenge, testOrigin, FormatNone)
+				resp.ClientDataJSON = clientDataJSON(t, clientDataGet, challenge, testOrigin)
+
+				return resp
+			},
+			want: ErrVerification,
+		},
+		{
+			name: "wrong rp id",
+			tamper: func(a *authenticator) RegistrationResponse {
+				a.rpID = "evil.example"
+
+				return a.register(challenge, testOrigin, FormatNone)
+			},
+			want: ErrVerification,
+		},
+		{
+			name: "user not present",
+			tamper: func(a *authenticator) RegistrationResponse {
+				a.flags = flagUserVerified
+
+				return a.register(challenge, testOrigin, FormatNone)
+			},
+			want: ErrVerification,
+		},
+		{
+			name:      "user not verified",
+			requireUV: true,
+			tamper: func(a *authenticator) RegistrationResponse {
+				a.flags = flagUserPresent
+
+				return a.register(challenge, testOrigin, FormatNone)
+			},
+			want: ErrVerification,
+		},
+		{
+			name: "backed up without eligibility",
+			tamper: func(a *authenticator) RegistrationResponse {
+				a.flags |= flagBackedUp
+
+				return a.register(challenge, testOrigin, FormatNone)
+			},
+			want: ErrVerification,
+		},
+		{
+			name: "bad self attestation signature",
+			tamper: func(a *authenticator) RegistrationResponse {
+				resp := a.register(challenge, testOrigin, FormatPacked)
+				resp.ClientDataJSON = append(resp.ClientDataJSON, ' ')
+
+				return resp
+			},
+			want: ErrVerification,
+		},
+		{
+			name: "unsupported format",
+			tamper: func(a *authenticator) RegistrationResponse {
+				return a.register(challenge, testOrigin, "fido-u2f")
+			},
+			want: ErrUnsupported,
+		},
+		{
+			name: "none with a statement",
+			tamper: func(a *authenticator) RegistrationResponse {
+				resp := a.register(challenge, testOrigin, FormatNone)
+				resp.AttestationObject = cborEncode(map[any]any{
+					"fmt": FormatNone, "attStmt": map[any]any{"sig": []byte("x")}, "authData": a.authData(true),
+				})
+
+				return resp
+			},
+			want: ErrMalformed,
+		},
+		{
+			name: "truncated attestation object",
+			tamper: func(a *authenticator) RegistrationResponse {
+				resp := a.register(challenge, testOrigin, FormatNone)
+				resp.AttestationObject = resp.AttestationObject[:len(resp.AttestationObject)-1]
+
+				return resp
+			},
+			want: ErrMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := rp.VerifyRegistration(tt.tamper(newAuthenticator(t, AlgES256)), challenge, tt.requireUV)
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestRelyingParty_VerifyAssertion_Rejects(t *testing.T) {
+	t.Parallel()
+
+	rp := newTestRP(t)
+	challenge := []byte("expected-challenge")
+
+	a := newAuthenticator(t, AlgES256)
+	other := newAuthenticator(t, AlgES256)
+
+	resp := a.assert(challenge, testOrigin)
+	_, err := rp.VerifyAssertion(resp, challenge, other.coseKey(), false)
+	require.ErrorIs(t, err, ErrVerification, "signed by another key")
+
+	resp.Signature[len(resp.Signature)-1] ^= 0xff
+	_, err = rp.VerifyAssertion(resp, challenge, a.coseKey(), false)
+	require.ErrorIs(t, err, ErrVerification, "tampered signature")
+
+	_, err = rp.VerifyAssertion(a.assert([]byte("stale"), testOrigin), challenge, a.coseKey(), false)
+	require.ErrorIs(t, err, ErrVerification, "wrong challenge")
+
+	a.flags = flagUserPresent
+	_, err = rp.VerifyAssertion(a.assert(challenge, testOrigin), challenge, a.coseKey(), true)
+	require.ErrorIs(t, err, ErrVerification, "user not verified")
+
+	_, err = rp.VerifyAssertion(a.assert(challenge, testOrigin), challenge, a.coseKey(), false)
+	require.NoError(t, err, "user verification not required")
+
+	resp = a.assert(challenge, testOrigin)
+	resp.AuthenticatorData = append(resp.AuthenticatorData, 0)
+	_, err = rp.VerifyAssertion(resp, challenge, a.coseKey(), false)
+	require.ErrorIs(t, err, ErrMalformed, "trailing authenticator data")
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	t.Parallel()
+
+	v, err := decodeCBOR(cborEncode(map[any]any{1: -7, "k": []any{[]byte{1}, "s", true, false}}))
+	require.NoError(t, err)
+	assert.Equal(t, map[any]any{int64(1): int64(-7), "k": []any{[]byte{1}, "s", true, false}}, v)
+
+	for name, raw := range map[string][]byte{
+		"trailing bytes":   {0x01, 0x02},
+		"indefinite array": {0x9f, 0x01, 0xff},
+		"float":            {0xfb, 0, 0, 0, 0, 0, 0, 0, 0},
+		"truncated bytes":  {0x45, 0x01},
+		"huge array":       {0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"duplicate key":    {0xa2, 0x01, 0x01, 0x01, 0x02},
+		"array key":        {0xa1, 0x80, 0x01},
+		"tag":              {0xc0, 0x01},
+	} {
+		_, err = decodeCBOR(raw)
+		assert.ErrorIs(t, err, ErrMalformed, name)
+	}
+}